@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// letsEncryptStagingURL is the directory of the Let's Encrypt staging CA
+const letsEncryptStagingURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+// newACMEManager returns an autocert manager for the configured domains.
+// Account keys and certificates are cached under the data directory so they
+// survive restarts and do not run into Let's Encrypt rate limits.
+func newACMEManager(cfg *config.Config, dataDir string) *autocert.Manager {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+		Cache:      autocert.DirCache(filepath.Join(dataDir, "acme")),
+		Email:      cfg.ACMEEmail,
+	}
+	if cfg.ACMEStaging {
+		manager.Client = &acme.Client{DirectoryURL: letsEncryptStagingURL}
+	}
+	return manager
+}
+
+// acmeTLSConfig serves certificates from the manager, including TLS-ALPN-01 challenges
+func acmeTLSConfig(manager *autocert.Manager) *tls.Config {
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig
+}
+
+// startACMEChallengeServer answers HTTP-01 challenges on the given port and
+// redirects every other request to HTTPS
+func startACMEChallengeServer(manager *autocert.Manager, port int) *http.Server {
+	log := logger.New(logger.ModuleMain)
+
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", port),
+		Handler:      manager.HTTPHandler(nil),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			// TLS-ALPN-01 on the HTTPS port can still validate the domain
+			log.Error("ACME HTTP-01 challenge server on port %d failed: %v", port, err)
+		}
+	}()
+
+	log.Info("ACME HTTP-01 challenges served on port %d", port)
+	return server
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// auditRetentionInterval is how often expired authentication events are deleted
+const auditRetentionInterval = time.Hour
+
+// startAuditRetention deletes authentication events older than
+// event_retention.audit_days from auth.db every hour. It runs whether or not
+// data collection is enabled, as the audit trail does not live in metrics.db.
+func startAuditRetention(cfgManager *config.Manager) {
+	log := logger.New(logger.ModuleMain)
+
+	prune := func() {
+		db := database.AuthInstance()
+		days := cfgManager.GetConfig().EventRetention.Days(database.EventCategoryAudit)
+		if db == nil || days == 0 {
+			return // Kept forever
+		}
+		deleted, err := db.PruneAuthEvents(time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			log.Error("Failed to apply audit retention to auth events: %v", err)
+		} else if deleted > 0 {
+			log.Info("Deleted %d auth events older than %d days", deleted, days)
+		}
+	}
+
+	go func() {
+		prune()
+		ticker := time.NewTicker(auditRetentionInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			prune()
+		}
+	}()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+	"github.com/scottwalter/axeos-dashboard/internal/power"
+	"github.com/scottwalter/axeos-dashboard/internal/scheduler"
+)
+
+// collectionController starts and stops the database and scheduler as
+// data_collection_enabled changes, and reloads the scheduler's tasks after
+// other configuration changes, without requiring a restart. It also suspends
+// collection while the host is about to lose power.
+type collectionController struct {
+	dataDir    string // --data-dir, or database.MemoryDataPath with --ephemeral
+	baseDir    string // Install directory, for a relative data_path
+	cfgManager *config.Manager
+	dbManager  *database.Manager
+	scheduler  *scheduler.Manager
+	running    bool
+	suspended  bool // Stopped by a power event until power is restored
+	mu         sync.Mutex
+	log        *logger.Logger
+}
+
+// newCollectionController creates a controller and subscribes it to configuration changes
+func newCollectionController(dataDir, baseDir string, cfgManager *config.Manager) *collectionController {
+	c := &collectionController{
+		dataDir:    dataDir,
+		baseDir:    baseDir,
+		cfgManager: cfgManager,
+		log:        logger.New(logger.ModuleMain),
+	}
+
+	cfgManager.OnChange(func(cfg *config.Config) {
+		if err := c.Apply(cfg); err != nil {
+			c.log.Error("Failed to apply data collection setting: %v", err)
+		}
+	})
+	power.GetManager(cfgManager).OnEvent(c.PowerEvent)
+
+	return c
+}
+
+// Apply starts or stops data collection to match the configuration
+func (c *collectionController) Apply(cfg *config.Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.suspended {
+		return nil // Resumed by the power event that restores power
+	}
+
+	switch {
+	case cfg.DataCollectionEnabled && !c.running:
+		return c.start(cfg)
+	case !cfg.DataCollectionEnabled && c.running:
+		c.stop()
+		c.log.Info("Data collection disabled")
+	case c.running:
+		// Pick up added or removed sources and interval changes
+		c.scheduler.Reload(cfg)
+	}
+	return nil
+}
+
+// Stop shuts down the scheduler and closes the database
+func (c *collectionController) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		c.stop()
+	}
+}
+
+// PowerEvent stops collection and closes the database cleanly when the host
+// is about to lose power, and resumes collection once power is restored
+func (c *collectionController) PowerEvent(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case power.IsShutdownEvent(event):
+		c.suspended = true
+		if c.running {
+			c.stop()
+			c.log.Info("Data collection suspended until power is restored")
+		}
+	case event == power.EventOnline && c.suspended:
+		c.suspended = false
+		if cfg := c.cfgManager.GetConfig(); cfg.DataCollectionEnabled {
+			if err := c.start(cfg); err != nil {
+				c.log.Error("Failed to resume data collection: %v", err)
+				return
+			}
+			c.log.Info("Power restored, data collection resumed")
+		}
+	}
+}
+
+// databasePath returns where the metrics database is kept: --ephemeral wins,
+// then data_path (relative paths are taken from the install directory), then
+// --data-dir
+func (c *collectionController) databasePath(cfg *config.Config) string {
+	if c.dataDir == database.MemoryDataPath || cfg.DataPath == "" {
+		return c.dataDir
+	}
+	if cfg.DataPath == database.MemoryDataPath || filepath.IsAbs(cfg.DataPath) {
+		return cfg.DataPath
+	}
+	return filepath.Join(c.baseDir, cfg.DataPath)
+}
+
+func (c *collectionController) start(cfg *config.Config) error {
+	// The database manager keeps the path of its first start until the server restarts
+	c.dbManager = database.GetManager(c.databasePath(cfg))
+	if err := c.dbManager.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	c.scheduler = scheduler.GetManager(c.dbManager, c.cfgManager)
+	if err := c.scheduler.Start(); err != nil {
+		c.dbManager.Close()
+		return fmt.Errorf("failed to start scheduler: %w", err)
+	}
+
+	c.running = true
+	c.log.Info("Data collection enabled and scheduler started")
+	return nil
+}
+
+func (c *collectionController) stop() {
+	// Scheduler first so no task writes to a closed database
+	c.scheduler.Stop()
+	if err := c.dbManager.Checkpoint(); err != nil {
+		c.log.Warn("%v", err)
+	}
+	if err := c.dbManager.Close(); err != nil {
+		c.log.Error("Error closing database: %v", err)
+	}
+	c.running = false
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// setupLogging applies the logging section of the configuration and
+// re-applies it whenever the configuration changes
+func setupLogging(cfgManager *config.Manager, cfg *config.Config, baseDir string) {
+	apply := func(cfg *config.Config) {
+		if err := logger.Configure(cfg.Logging.Options(baseDir)); err != nil {
+			logger.New(logger.ModuleMain).Error("Failed to apply logging settings: %v", err)
+		}
+	}
+	apply(cfg)
+	cfgManager.OnChange(apply)
+}
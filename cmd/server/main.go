@@ -2,21 +2,29 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/scottwalter/axeos-dashboard/internal/auth"
 	"github.com/scottwalter/axeos-dashboard/internal/config"
 	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/handlers"
 	"github.com/scottwalter/axeos-dashboard/internal/logger"
+	"github.com/scottwalter/axeos-dashboard/internal/migration"
+	"github.com/scottwalter/axeos-dashboard/internal/power"
 	"github.com/scottwalter/axeos-dashboard/internal/router"
-	"github.com/scottwalter/axeos-dashboard/internal/scheduler"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
 )
 
 const (
@@ -25,54 +33,184 @@ const (
 
 // dynamicHandler wraps the bootstrap and normal handlers,
 // allowing hot-reload from bootstrap mode to normal mode
+// and swapping the normal handler when the server is reloaded
 type dynamicHandler struct {
+	baseDir          string
 	configDir        string
 	publicDir        string
+	dataDir          string
+	metricsDir       string
 	isBootstrapMode  bool
 	cfgManager       *config.Manager
 	bootstrapHandler http.Handler
 	normalHandler    http.Handler
+	collection       *collectionController
+	routerState      *router.State
+	mu               sync.RWMutex // Guards the mode and handlers; held only while switching
+	reloadMu         sync.Mutex   // Serializes reloads so the last one read wins
 }
 
 // ServeHTTP implements http.Handler interface
 func (h *dynamicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	log := logger.New(logger.ModuleMain)
+	h.mu.RLock()
+	bootstrap := h.isBootstrapMode
+	h.mu.RUnlock()
 
 	// Check if we're in bootstrap mode and config files now exist
+	if bootstrap && config.CheckConfigFilesExist(h.configDir) {
+		h.mu.Lock()
+		ok := h.leaveBootstrapMode(w)
+		h.mu.Unlock()
+		if !ok {
+			return
+		}
+	}
+
+	// Route to appropriate handler; requests in flight keep the handler they started on
+	h.mu.RLock()
+	handler := h.normalHandler
 	if h.isBootstrapMode {
-		if config.CheckConfigFilesExist(h.configDir) {
-			log.Info("Configuration files detected. Switching to normal mode...")
-
-			// Initialize JWT service
-			if err := auth.InitJWTService(h.configDir); err != nil {
-				log.Error("Error initializing JWT service: %v", err)
-				http.Error(w, "Failed to initialize authentication", http.StatusInternalServerError)
-				return
-			}
+		handler = h.bootstrapHandler
+	}
+	h.mu.RUnlock()
+	handler.ServeHTTP(w, r)
+}
 
-			// Load configuration
-			h.cfgManager = config.GetManager(h.configDir)
-			cfg, err := h.cfgManager.LoadConfig()
-			if err != nil {
-				log.Error("Error loading configuration: %v", err)
-				http.Error(w, "Failed to load configuration", http.StatusInternalServerError)
-				return
-			}
+// leaveBootstrapMode switches to normal mode once the configuration files
+// exist, answering the request with an error if that fails. The caller
+// holds h.mu.
+func (h *dynamicHandler) leaveBootstrapMode(w http.ResponseWriter) bool {
+	log := logger.New(logger.ModuleMain)
+
+	if !h.isBootstrapMode {
+		return true // Switched by a concurrent request
+	}
+	log.Info("Configuration files detected. Switching to normal mode...")
+
+	if err := initAuth(h.configDir, h.dataDir); err != nil {
+		log.Error("Error initializing authentication: %v", err)
+		http.Error(w, "Failed to initialize authentication", http.StatusInternalServerError)
+		return false
+	}
+
+	// Load configuration
+	h.cfgManager = config.GetManager(h.configDir)
+	cfg, err := h.cfgManager.LoadConfig()
+	if err != nil {
+		log.Error("Error loading configuration: %v", err)
+		http.Error(w, "Failed to load configuration", http.StatusInternalServerError)
+		return false
+	}
+	setupLogging(h.cfgManager, cfg, h.baseDir)
+
+	// Start data collection if the new configuration enables it
+	h.collection = newCollectionController(h.metricsDir, h.baseDir, h.cfgManager)
+	if err := h.collection.Apply(cfg); err != nil {
+		log.Error("Error starting data collection: %v", err)
+	}
+	services.GetPriceFeed(h.cfgManager).Start()
+	services.GetStatusSSH(h.cfgManager).Start()
+	startAuditRetention(h.cfgManager)
+
+	// Setup normal router
+	h.normalHandler = router.SetupRouter(h.cfgManager, cfg, h.configDir, h.publicDir, h.routerState, h.Reload)
+	h.isBootstrapMode = false
+
+	log.Info("Successfully switched to normal mode!")
+	return true
+}
+
+// Reload re-reads the configuration files, reinitializes authentication and
+// swaps in a router built from the new configuration; the change listeners
+// reload the scheduler's tasks. The new router is built before the swap, so
+// requests are only held for the swap itself. Open connections are kept; the
+// listen port and TLS settings still require a restart.
+func (h *dynamicHandler) Reload() error {
+	log := logger.New(logger.ModuleMain)
 
-			// Setup normal router
-			h.normalHandler = router.SetupRouter(h.cfgManager, cfg, h.configDir, h.publicDir)
-			h.isBootstrapMode = false
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	h.mu.RLock()
+	bootstrap, cfgManager := h.isBootstrapMode, h.cfgManager
+	h.mu.RUnlock()
+	if bootstrap {
+		return fmt.Errorf("the server is not configured yet")
+	}
+	log.Info("Reloading server...")
+
+	// Change listeners pick up the new configuration as after an update
+	cfg, err := cfgManager.ReloadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := initAuth(h.configDir, h.dataDir); err != nil {
+		return err
+	}
+	normalHandler := router.SetupRouter(cfgManager, cfg, h.configDir, h.publicDir, h.routerState, h.Reload)
+
+	h.mu.Lock()
+	h.normalHandler = normalHandler
+	h.mu.Unlock()
+
+	log.Info("Server reloaded")
+	return nil
+}
+
+// initAuth loads the JWT secret, API keys, sessions and token revocations
+// from the config directory, after tightening the credential file
+// permissions, and opens auth.db in the data directory for the audit trail
+// and the login lockout
+func initAuth(configDir, dataDir string) error {
+	repairSecretFiles(configDir)
+
+	if err := database.OpenAuthDB(dataDir); err != nil {
+		return err
+	}
+	if err := auth.InitLoginGuard(); err != nil {
+		return fmt.Errorf("failed to load failed login counts: %w", err)
+	}
+
+	if err := auth.InitJWTService(configDir); err != nil {
+		return fmt.Errorf("failed to initialize JWT service: %w", err)
+	}
+	if err := auth.InitAPIKeyStore(configDir); err != nil {
+		return fmt.Errorf("failed to load API keys: %w", err)
+	}
+	if err := auth.InitSessionStore(configDir); err != nil {
+		return fmt.Errorf("failed to load sessions: %w", err)
+	}
+	if err := auth.InitRevocationList(configDir); err != nil {
+		return fmt.Errorf("failed to load token revocations: %w", err)
+	}
+	return nil
+}
+
+// repairSecretFiles tightens permissions on credential files and logs
+// anything that could not be fixed
+func repairSecretFiles(configDir string) {
+	log := logger.New(logger.ModuleMain)
 
-			log.Info("Successfully switched to normal mode!")
+	for _, issue := range config.RepairSecretFilePermissions(configDir) {
+		if issue.Repaired {
+			log.Warn("SECURITY: %s had mode %s, permissions reset to %04o", issue.File, issue.Mode, config.SecretFileMode)
+		} else {
+			log.Error("SECURITY: %s", issue.Message)
 		}
 	}
+}
 
-	// Route to appropriate handler
-	if h.isBootstrapMode {
-		h.bootstrapHandler.ServeHTTP(w, r)
-	} else {
-		h.normalHandler.ServeHTTP(w, r)
+// resolveDir returns the directory set by a flag or environment variable as
+// an absolute path, or the named directory under the base directory
+func resolveDir(dir, baseDir, name string) (string, error) {
+	if dir == "" {
+		return filepath.Join(baseDir, name), nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s directory %s: %w", name, dir, err)
 	}
+	return abs, nil
 }
 
 func main() {
@@ -85,6 +223,13 @@ func main() {
 func run() error {
 	log := logger.New(logger.ModuleMain)
 
+	ephemeral := flag.Bool("ephemeral", false, "keep collected metrics in a temporary database that is deleted on shutdown")
+	importLegacy := flag.String("import-legacy", "", "import the config directory of the Node.js bitaxe-dashboard before starting")
+	configDirFlag := flag.String("config-dir", os.Getenv("AXEOS_CONFIG_DIR"), "configuration directory (env AXEOS_CONFIG_DIR; default <base>/config)")
+	dataDirFlag := flag.String("data-dir", os.Getenv("AXEOS_DATA_DIR"), "data directory for metrics.db and auth.db (env AXEOS_DATA_DIR; default <base>/data)")
+	publicDirFlag := flag.String("public-dir", os.Getenv("AXEOS_PUBLIC_DIR"), "web assets directory (env AXEOS_PUBLIC_DIR; default <base>/public)")
+	flag.Parse()
+
 	// Determine paths
 	execPath, err := os.Executable()
 	if err != nil {
@@ -97,15 +242,63 @@ func run() error {
 		baseDir, _ = os.Getwd()
 	}
 
-	configDir := filepath.Join(baseDir, "config")
-	publicDir := filepath.Join(baseDir, "public")
-	dataDir := filepath.Join(baseDir, "data")
+	// Each directory can be set on its own (flag first, then environment),
+	// e.g. for separate container volumes; the rest stay under the base directory
+	configDir, err := resolveDir(*configDirFlag, baseDir, "config")
+	if err != nil {
+		return err
+	}
+	publicDir, err := resolveDir(*publicDirFlag, baseDir, "public")
+	if err != nil {
+		return err
+	}
+	dataDir, err := resolveDir(*dataDirFlag, baseDir, "data")
+	if err != nil {
+		return err
+	}
 
 	log.Info("Base directory: %s", baseDir)
 	log.Info("Config directory: %s", configDir)
 	log.Info("Public directory: %s", publicDir)
 	log.Info("Data directory: %s", dataDir)
 
+	// The metrics database normally lives in the data directory (or data_path);
+	// --ephemeral overrides both for demos and local testing
+	metricsDir := dataDir
+	if *ephemeral {
+		metricsDir = database.MemoryDataPath
+	}
+
+	// The page templates are read from disk on every request; refuse to start
+	// without them instead of answering every page with an error
+	if assets := handlers.CheckPublicAssets(publicDir); !assets.OK {
+		return fmt.Errorf("public assets missing from %s: %s (run the server from the project directory, install the public folder next to the binary or set --public-dir)",
+			publicDir, strings.Join(assets.Missing, ", "))
+	}
+
+	// Convert the configuration of the Node.js dashboard into the config directory
+	if *importLegacy != "" {
+		status, err := migration.ImportDir(*importLegacy, configDir)
+		if err != nil {
+			return fmt.Errorf("failed to import legacy configuration from %s: %w", *importLegacy, err)
+		}
+		log.Info("Imported legacy configuration from %s: wrote %s", *importLegacy, strings.Join(status.Files, ", "))
+		for legacy, current := range status.Renamed {
+			log.Info("Renamed %s to %s", legacy, current)
+		}
+		if len(status.Dropped) > 0 {
+			log.Warn("Dropped settings this version does not use: %s", strings.Join(status.Dropped, ", "))
+		}
+	}
+
+	// Requests to a remote secret backend carry the configured outbound
+	// headers once config.json is loaded
+	secrets.SetRequestHeaders(func(req *http.Request) {
+		if cfg := config.GetManager(configDir).GetConfig(); cfg != nil {
+			services.SetOutboundHeaders(req, cfg)
+		}
+	})
+
 	// Check if configuration files exist
 	configFilesExist := config.CheckConfigFilesExist(configDir)
 	log.Info("Config files exist: %v", configFilesExist)
@@ -113,8 +306,7 @@ func run() error {
 	var cfg *config.Config
 	var isBootstrapMode bool
 	var cfgManager *config.Manager
-	var dbManager *database.Manager
-	var schedManager *scheduler.Manager
+	var collection *collectionController
 
 	if !configFilesExist {
 		log.Info("Configuration files missing. Starting in bootstrap mode...")
@@ -123,9 +315,10 @@ func run() error {
 			WebServerPort: DefaultWebServerPort,
 		}
 	} else {
-		// Initialize JWT service
-		if err := auth.InitJWTService(configDir); err != nil {
-			return fmt.Errorf("failed to initialize JWT service: %w", err)
+		// Make sure credential files are not readable by other users and
+		// initialize the JWT service and credential stores
+		if err := initAuth(configDir, dataDir); err != nil {
+			return err
 		}
 
 		// Load configuration
@@ -134,26 +327,20 @@ func run() error {
 		if err != nil {
 			return fmt.Errorf("failed to load configuration: %w", err)
 		}
+		setupLogging(cfgManager, cfg, baseDir)
 
-		// Initialize database if data collection is enabled
-		if cfg.DataCollectionEnabled {
-			dbManager = database.GetManager(dataDir)
-			if err := dbManager.Initialize(); err != nil {
-				return fmt.Errorf("failed to initialize database: %w", err)
-			}
-			defer dbManager.Close()
-
-			// Initialize scheduler
-			schedManager = scheduler.GetManager(dbManager, cfgManager)
-			if err := schedManager.Start(); err != nil {
-				return fmt.Errorf("failed to start scheduler: %w", err)
-			}
-			defer schedManager.Stop()
-
-			log.Info("Data collection enabled and scheduler started")
-		} else {
+		// Initialize database and scheduler if data collection is enabled.
+		// The controller also follows later changes made through the configuration API.
+		collection = newCollectionController(metricsDir, baseDir, cfgManager)
+		if err := collection.Apply(cfg); err != nil {
+			return err
+		}
+		if !cfg.DataCollectionEnabled {
 			log.Info("Data collection disabled")
 		}
+		services.GetPriceFeed(cfgManager).Start()
+		services.GetStatusSSH(cfgManager).Start()
+		startAuditRetention(cfgManager)
 	}
 
 	// Determine port
@@ -168,16 +355,35 @@ func run() error {
 
 	// Create dynamic handler that can switch from bootstrap to normal mode
 	handler := &dynamicHandler{
+		baseDir:          baseDir,
 		configDir:        configDir,
 		publicDir:        publicDir,
+		dataDir:          dataDir,
+		metricsDir:       metricsDir,
 		isBootstrapMode:  isBootstrapMode,
 		cfgManager:       cfgManager,
+		collection:       collection,
 		bootstrapHandler: router.SetupBootstrapRouter(configDir, publicDir),
+		routerState:      router.NewState(),
 	}
 
+	// Stop data collection on exit (it may also have been started after leaving bootstrap mode)
+	defer func() {
+		if handler.collection != nil {
+			handler.collection.Stop()
+		}
+		if handler.cfgManager != nil {
+			services.GetPriceFeed(handler.cfgManager).Stop()
+			services.GetStatusSSH(handler.cfgManager).Stop()
+		}
+		if err := database.CloseAuthDB(); err != nil {
+			log.Error("Error closing auth.db: %v", err)
+		}
+	}()
+
 	// Initialize normal handler if not in bootstrap mode
 	if !isBootstrapMode {
-		handler.normalHandler = router.SetupRouter(cfgManager, cfg, configDir, publicDir)
+		handler.normalHandler = router.SetupRouter(cfgManager, cfg, configDir, publicDir, handler.routerState, handler.Reload)
 	}
 
 	server := &http.Server{
@@ -188,7 +394,28 @@ func run() error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	log.Info("Server running on http://localhost:%d", port)
+	// HTTPS when enabled (bootstrap mode always starts on plain HTTP).
+	// Let's Encrypt takes precedence over certificate files.
+	var certPath, keyPath string
+	var challengeServer *http.Server
+	scheme := "http"
+	switch {
+	case cfg.ACMEEnabled:
+		manager := newACMEManager(cfg, dataDir)
+		server.TLSConfig = acmeTLSConfig(manager)
+		challengeServer = startACMEChallengeServer(manager, cfg.ACMEHTTPPort)
+		scheme = "https"
+		log.Info("Let's Encrypt certificates for: %s", strings.Join(cfg.ACMEDomains, ", "))
+	case cfg.TLSEnabled:
+		certPath, keyPath, err = prepareTLS(cfg, configDir)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		scheme = "https"
+	}
+
+	log.Info("Server running on %s://localhost:%d", scheme, port)
 	log.Info("Server started at: %s", time.Now().Format(time.RFC3339))
 	log.Info("Config directory: %s", configDir)
 	log.Info("Public directory: %s", publicDir)
@@ -196,7 +423,13 @@ func run() error {
 	// Setup graceful shutdown
 	serverErr := make(chan error, 1)
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if scheme == "https" {
+			err = server.ListenAndServeTLS(certPath, keyPath)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
@@ -205,6 +438,21 @@ func run() error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// Power failure reported by init on UPS-backed hosts (Linux only)
+	if len(powerSignals) > 0 {
+		powerFail := make(chan os.Signal, 1)
+		signal.Notify(powerFail, powerSignals...)
+		go func() {
+			for range powerFail {
+				if manager := power.Instance(); manager != nil {
+					if err := manager.Handle(power.EventPowerFail); err != nil {
+						log.Warn("Ignoring power failure signal: %v", err)
+					}
+				}
+			}
+		}()
+	}
+
 	select {
 	case <-quit:
 		log.Info("Shutdown signal received, gracefully shutting down...")
@@ -216,6 +464,9 @@ func run() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if challengeServer != nil {
+		challengeServer.Shutdown(ctx)
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
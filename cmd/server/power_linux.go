@@ -0,0 +1,11 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// powerSignals are sent by init when the UPS reports a power failure
+var powerSignals = []os.Signal{syscall.SIGPWR}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// powerSignals is empty where SIGPWR does not exist; use POST /api/power instead
+var powerSignals []os.Signal
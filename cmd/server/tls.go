@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+const (
+	// Default certificate and key files, relative to the config directory
+	defaultTLSCertFile = "tls-cert.pem"
+	defaultTLSKeyFile  = "tls-key.pem"
+
+	// selfSignedValidity is the lifetime of a generated certificate
+	selfSignedValidity = 2 * 365 * 24 * time.Hour
+)
+
+// resolveTLSFiles returns the certificate and key paths; relative paths are
+// resolved against the config directory
+func resolveTLSFiles(cfg *config.Config, configDir string) (string, string) {
+	certPath, keyPath := cfg.TLSCertPath, cfg.TLSKeyPath
+	if certPath == "" {
+		certPath = defaultTLSCertFile
+	}
+	if keyPath == "" {
+		keyPath = defaultTLSKeyFile
+	}
+	if !filepath.IsAbs(certPath) {
+		certPath = filepath.Join(configDir, certPath)
+	}
+	if !filepath.IsAbs(keyPath) {
+		keyPath = filepath.Join(configDir, keyPath)
+	}
+	return certPath, keyPath
+}
+
+// prepareTLS makes sure a usable certificate exists, generating a
+// self-signed one when enabled and none is present yet
+func prepareTLS(cfg *config.Config, configDir string) (string, string, error) {
+	log := logger.New(logger.ModuleMain)
+	certPath, keyPath := resolveTLSFiles(cfg, configDir)
+
+	_, certErr := os.Stat(certPath)
+	_, keyErr := os.Stat(keyPath)
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) && cfg.TLSSelfSigned {
+		log.Info("Generating self-signed TLS certificate: %s", certPath)
+		if err := generateSelfSignedCert(certPath, keyPath); err != nil {
+			return "", "", fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+	}
+
+	// Fail at startup rather than on the first handshake
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+		return "", "", fmt.Errorf("failed to load TLS certificate %s: %w", certPath, err)
+	}
+	return certPath, keyPath, nil
+}
+
+// generateSelfSignedCert writes an ECDSA P-256 certificate valid for
+// localhost, the host name and every local IP address
+func generateSelfSignedCert(certPath, keyPath string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "AxeOS Dashboard", Organization: []string{"AxeOS Dashboard"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if hostname != "" && hostname != "localhost" {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+				template.IPAddresses = append(template.IPAddresses, ipNet.IP)
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range []string{certPath, keyPath} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+	return config.WriteSecretFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
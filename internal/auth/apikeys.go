@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// APIKeyPrefix starts every API key, so keys are easy to recognize in scripts and logs
+const APIKeyPrefix = "axd_"
+
+// apiKeyFile holds the hashed API keys in the config directory
+const apiKeyFile = "apikeys.json"
+
+// API key scopes. A key with scopes can only do what they allow; keys
+// without scopes have every permission of their role.
+const (
+	ScopeReadMetrics   = "read:metrics"   // Read the API
+	ScopeWriteSettings = "write:settings" // Change device settings, restart devices and switch pools
+	ScopeAdminConfig   = "admin:config"   // Change the dashboard configuration, users and API keys
+)
+
+// APIKeyScopes lists every API key scope
+var APIKeyScopes = []string{ScopeReadMetrics, ScopeWriteSettings, ScopeAdminConfig}
+
+// NormalizeScopes checks a list of scopes and returns it sorted without
+// duplicates. Every scope can read, so read:metrics is added to the others.
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	normalized := []string{ScopeReadMetrics}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(APIKeyScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q (use %s)", scope, strings.Join(APIKeyScopes, ", "))
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// RoleForScopes returns the role a key with the given scopes needs: admin
+// for write:settings or admin:config, viewer for read:metrics alone
+func RoleForScopes(scopes []string) string {
+	if slices.Contains(scopes, ScopeWriteSettings) || slices.Contains(scopes, ScopeAdminConfig) {
+		return RoleAdmin
+	}
+	return RoleViewer
+}
+
+// APIKey is one API key for machine clients. Only a SHA256 hash of the key is
+// stored; the key itself is shown once when it is created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes,omitempty"` // Empty for every permission of the role
+	Hint       string     `json:"hint"`             // First characters of the key, to tell keys apart
+	Hash       string     `json:"hash"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// APIKeyStore keeps the API keys of apikeys.json in memory
+type APIKeyStore struct {
+	mu   sync.Mutex
+	path string
+	keys []*APIKey
+}
+
+var apiKeyStore *APIKeyStore
+
+// InitAPIKeyStore loads apikeys.json from the config directory. A missing
+// file means no API keys have been created yet.
+func InitAPIKeyStore(configDir string) error {
+	store := &APIKeyStore{path: filepath.Join(configDir, apiKeyFile)}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read %s: %w", apiKeyFile, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &store.keys); err != nil {
+			return fmt.Errorf("could not parse %s: %w", apiKeyFile, err)
+		}
+	}
+
+	apiKeyStore = store
+	return nil
+}
+
+// GetAPIKeyStore returns the initialized API key store
+func GetAPIKeyStore() *APIKeyStore {
+	return apiKeyStore
+}
+
+// hashToken returns the stored form of an API key or refresh token. Both are
+// long random strings, so a fast hash is enough and keeps verification cheap.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// save writes the keys to apikeys.json; the caller holds the lock
+func (s *APIKeyStore) save() error {
+	data, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteSecretFile(s.path, data); err != nil {
+		return fmt.Errorf("error writing %s: %w", apiKeyFile, err)
+	}
+	return nil
+}
+
+// List returns a copy of all API keys
+func (s *APIKeyStore) List() []APIKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	return keys
+}
+
+// Get returns a copy of the API key with the given id
+func (s *APIKeyStore) Get(id string) (APIKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.ID == id {
+			return *key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// Create generates a new API key. It returns the key itself, which is not
+// stored and cannot be retrieved again, along with its record. A key with
+// scopes gets the role they need.
+func (s *APIKeyStore) Create(name, role string, scopes []string, createdBy string) (string, APIKey, error) {
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	if len(scopes) > 0 {
+		role = RoleForScopes(scopes)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", APIKey{}, fmt.Errorf("failed to generate API key id: %w", err)
+	}
+
+	token := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key := &APIKey{
+		ID:        hex.EncodeToString(idBytes),
+		Name:      name,
+		Role:      NormalizeRole(role),
+		Scopes:    scopes,
+		Hint:      token[:len(APIKeyPrefix)+6],
+		Hash:      hashToken(token),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = append(s.keys, key)
+	if err := s.save(); err != nil {
+		s.keys = s.keys[:len(s.keys)-1]
+		return "", APIKey{}, err
+	}
+	return token, *key, nil
+}
+
+// Update changes the name, role and scopes of an API key
+func (s *APIKeyStore) Update(id, name, role string, scopes []string) (APIKey, error) {
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return APIKey{}, err
+	}
+	if len(scopes) > 0 {
+		role = RoleForScopes(scopes)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		previous := *key
+		key.Name = name
+		key.Role = NormalizeRole(role)
+		key.Scopes = scopes
+		if err := s.save(); err != nil {
+			*key = previous
+			return APIKey{}, err
+		}
+		return *key, nil
+	}
+	return APIKey{}, fmt.Errorf("API key %q not found", id)
+}
+
+// Delete revokes an API key. It reports whether the key existed.
+func (s *APIKeyStore) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		keys := append(append([]*APIKey{}, s.keys[:i]...), s.keys[i+1:]...)
+		previous := s.keys
+		s.keys = keys
+		if err := s.save(); err != nil {
+			s.keys = previous
+			return true, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Verify returns the API key matching token and records when it was used
+func (s *APIKeyStore) Verify(token string) (APIKey, bool) {
+	if !strings.HasPrefix(token, APIKeyPrefix) {
+		return APIKey{}, false
+	}
+	hash := hashToken(token)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) == 1 {
+			// Kept in memory only; writing the file on every request is not worth it
+			now := time.Now()
+			key.LastUsedAt = &now
+			return *key, true
+		}
+	}
+	return APIKey{}, false
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// FileProvider authenticates users against access.json
+type FileProvider struct {
+	configDir string
+	log       *logger.Logger
+}
+
+// NewFileProvider creates a provider reading access.json from configDir
+func NewFileProvider(configDir string) *FileProvider {
+	return &FileProvider{configDir: configDir, log: logger.New(logger.ModuleAuth)}
+}
+
+// Name returns the provider name used in auth_provider
+func (p *FileProvider) Name() string {
+	return config.AuthProviderFile
+}
+
+// Authenticate verifies the password hash stored in access.json. A legacy
+// SHA256 hash is replaced with Argon2id once the password is known to be correct.
+func (p *FileProvider) Authenticate(username, password string) (*Identity, error) {
+	accessData, err := LoadAccessCredentials(p.configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	user, exists := accessData[username]
+	if !exists {
+		return nil, ErrUnknownUser
+	}
+	valid, needsRehash := VerifyPassword(user.Password, password)
+	if !valid {
+		return nil, ErrWrongPassword
+	}
+
+	if needsRehash {
+		if hash, err := HashPassword(password); err != nil {
+			p.log.Error("Error hashing password for %s: %v", username, err)
+		} else if err := UpdateAccessPassword(p.configDir, username, hash); err != nil {
+			p.log.Warn("Could not upgrade password hash for %s: %v", username, err)
+		} else {
+			p.log.Info("Upgraded password hash for %s to Argon2id", username)
+		}
+	}
+
+	return &Identity{Username: username, Role: user.Role}, nil
+}
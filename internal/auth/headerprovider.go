@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// HeaderProvider trusts the username a reverse proxy puts in a request
+// header. Requests from other addresses, and the login page, fall back to
+// access.json.
+type HeaderProvider struct {
+	*FileProvider
+	settings config.AuthHeaderSettings
+}
+
+// newHeaderProvider creates the header provider from auth_header
+func newHeaderProvider(configDir string, cfg *config.Config) (Provider, error) {
+	if cfg.AuthHeader == nil || len(cfg.AuthHeader.TrustedProxies) == 0 {
+		return nil, fmt.Errorf("auth_provider header needs auth_header.trusted_proxies")
+	}
+
+	settings := *cfg.AuthHeader
+	if settings.UserHeader == "" {
+		settings.UserHeader = config.DefaultAuthUserHeader
+	}
+	if settings.DefaultRole == "" {
+		settings.DefaultRole = RoleViewer
+	}
+	return &HeaderProvider{FileProvider: NewFileProvider(configDir), settings: settings}, nil
+}
+
+// Name returns the provider name used in auth_provider
+func (p *HeaderProvider) Name() string {
+	return config.AuthProviderHeader
+}
+
+// AuthenticateRequest reads the username (and optionally the role) from the
+// headers of requests sent by a trusted proxy
+func (p *HeaderProvider) AuthenticateRequest(r *http.Request, clientIP string) (*Identity, bool) {
+	if !config.IPAllowed(net.ParseIP(clientIP), p.settings.TrustedProxies) {
+		return nil, false
+	}
+	username := strings.TrimSpace(r.Header.Get(p.settings.UserHeader))
+	if username == "" {
+		return nil, false
+	}
+
+	role := p.settings.DefaultRole
+	if p.settings.RoleHeader != "" {
+		if value := strings.TrimSpace(r.Header.Get(p.settings.RoleHeader)); value != "" {
+			role = value
+		}
+	}
+	if slices.Contains(p.settings.Admins, username) {
+		role = RoleAdmin
+	}
+	return &Identity{Username: username, Role: NormalizeRole(role)}, true
+}
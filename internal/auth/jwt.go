@@ -3,17 +3,17 @@ package auth
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
 )
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	JsonWebTokenKey string `json:"jsonWebTokenKey"`
-	ExpiresIn       string `json:"expiresIn"`
+	JsonWebTokenKey string     `json:"jsonWebTokenKey"`
+	ExpiresIn       string     `json:"expiresIn"`
+	RotatedAt       *time.Time `json:"rotatedAt,omitempty"` // When the key was last replaced, for rotation reminders
 }
 
 // JWTService handles JWT creation and verification
@@ -25,6 +25,9 @@ type JWTService struct {
 // Claims represents JWT claims
 type Claims struct {
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`  // Session role (admin or viewer)
+	Scope    string `json:"scope,omitempty"` // Empty for session tokens
+	Session  string `json:"sid,omitempty"`   // Renewable session the token belongs to, when refresh tokens are enabled
 	jwt.RegisteredClaims
 }
 
@@ -32,9 +35,7 @@ var jwtService *JWTService
 
 // InitJWTService initializes the JWT service with configuration
 func InitJWTService(configDir string) error {
-	keyFilePath := filepath.Join(configDir, "jsonWebTokenKey.json")
-
-	data, err := os.ReadFile(keyFilePath)
+	data, err := secrets.Read(configDir, "jsonWebTokenKey.json")
 	if err != nil {
 		return fmt.Errorf("fatal: could not load JWT secret key from jsonWebTokenKey.json: %w", err)
 	}
@@ -67,12 +68,57 @@ func GetJWTService() *JWTService {
 	return jwtService
 }
 
-// CreateToken creates a new JWT token for the given username
-func (j *JWTService) CreateToken(username string) (string, error) {
+// ExpiresIn returns the default token lifetime from jsonWebTokenKey.json
+func (j *JWTService) ExpiresIn() time.Duration {
+	return j.expiresIn
+}
+
+// CreateToken creates a new JWT token for the given username and role
+func (j *JWTService) CreateToken(username, role string) (string, error) {
+	return j.CreateTokenWithExpiry(username, role, j.expiresIn)
+}
+
+// CreateTokenWithExpiry creates a new JWT token that expires after the given lifetime
+func (j *JWTService) CreateTokenWithExpiry(username, role string, expiresIn time.Duration) (string, error) {
+	return j.CreateSessionToken(username, role, "", expiresIn)
+}
+
+// CreateSessionToken creates an access token for a renewable session. The
+// token stops working as soon as the session is revoked.
+func (j *JWTService) CreateSessionToken(username, role, sessionID string, expiresIn time.Duration) (string, error) {
+	// The token id lets a single token be revoked, e.g. on logout
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		Username: username,
+		Role:     NormalizeRole(role),
+		Session:  sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(j.secretKey))
+	if err != nil {
+		return "", fmt.Errorf("JWT creation error: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// CreateScopedToken creates a token for username that is only valid for the given scope
+func (j *JWTService) CreateScopedToken(username, scope string, expiresIn time.Duration) (string, error) {
 	claims := Claims{
 		Username: username,
+		Scope:    scope,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiresIn)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -86,6 +132,27 @@ func (j *JWTService) CreateToken(username string) (string, error) {
 	return tokenString, nil
 }
 
+// VerifyScopedToken verifies a token created by CreateScopedToken for the given scope
+func (j *JWTService) VerifyScopedToken(tokenString, scope string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(j.secretKey), nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("JWT verification error: %w", err)
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.Scope != scope {
+		return nil, fmt.Errorf("invalid token for scope %s", scope)
+	}
+
+	return claims, nil
+}
+
 // VerifyToken verifies a JWT token and returns the claims
 func (j *JWTService) VerifyToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -101,20 +168,34 @@ func (j *JWTService) VerifyToken(tokenString string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// Scoped tokens (e.g. share links) must never be accepted as a login session
+		if claims.Scope != "" || claims.Username == "" {
+			return nil, fmt.Errorf("token is not a session token")
+		}
+		// Tokens on the revocation list (logout, revoked users) stop working before they expire
+		if list := GetRevocationList(); list != nil && list.Revoked(claims) {
+			return nil, fmt.Errorf("token has been revoked")
+		}
+		// Tokens of a revoked or expired session stop working before they expire
+		if claims.Session != "" {
+			if store := GetSessionStore(); store == nil || !store.Active(claims.Session) {
+				return nil, fmt.Errorf("session has been revoked")
+			}
+		}
+		// Tokens issued before roles existed belong to the single admin user
+		claims.Role = NormalizeRole(claims.Role)
 		return claims, nil
 	}
 
 	return nil, fmt.Errorf("invalid token")
 }
 
-// AccessCredentials represents user credentials
-type AccessCredentials map[string]string
+// AccessCredentials represents user credentials keyed by username
+type AccessCredentials map[string]AccessUser
 
 // LoadAccessCredentials loads user credentials from access.json
 func LoadAccessCredentials(configDir string) (AccessCredentials, error) {
-	accessFilePath := filepath.Join(configDir, "access.json")
-
-	data, err := os.ReadFile(accessFilePath)
+	data, err := secrets.Read(configDir, "access.json")
 	if err != nil {
 		return nil, fmt.Errorf("security warning: access.json file not found: %w", err)
 	}
@@ -126,3 +207,25 @@ func LoadAccessCredentials(configDir string) (AccessCredentials, error) {
 
 	return accessData, nil
 }
+
+// UpdateAccessPassword replaces the stored password hash of one user in
+// access.json, keeping the other entries as they are. access.json provided by
+// a secret backend is read-only and is left unchanged.
+func UpdateAccessPassword(configDir, username, passwordHash string) error {
+	return modifyAccessFile(configDir, func(entries map[string]json.RawMessage) error {
+		entry, ok := entries[username]
+		if !ok {
+			return fmt.Errorf("user %q not found in access.json", username)
+		}
+
+		var updated interface{} = passwordHash // Plain "username": "hash" entry
+		var object map[string]interface{}
+		if json.Unmarshal(entry, &object) == nil {
+			object["password"] = passwordHash
+			updated = object
+		}
+		var err error
+		entries[username], err = json.Marshal(updated)
+		return err
+	})
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// loginFailures counts the failed logins of one username or client IP
+type loginFailures struct {
+	count       int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// LoginGuard locks usernames and client IPs after repeated failed logins.
+// The counters are kept in memory and written through to auth.db, so
+// lockouts survive a restart.
+type LoginGuard struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+	store    *database.AuthDB // nil keeps the counters in memory only
+	log      *logger.Logger
+}
+
+var (
+	loginGuard     *LoginGuard
+	loginGuardOnce sync.Once
+)
+
+// GetLoginGuard returns the singleton login guard
+func GetLoginGuard() *LoginGuard {
+	loginGuardOnce.Do(func() {
+		loginGuard = &LoginGuard{failures: make(map[string]*loginFailures), log: logger.New(logger.ModuleAuth)}
+	})
+	return loginGuard
+}
+
+// InitLoginGuard loads the stored failed login counters from auth.db
+func InitLoginGuard() error {
+	g := GetLoginGuard()
+	store := database.AuthInstance()
+	if store == nil {
+		return nil
+	}
+	stored, err := store.GetLoginFailures()
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.store = store
+	g.failures = make(map[string]*loginFailures, len(stored))
+	for _, f := range stored {
+		g.failures[f.Key] = &loginFailures{count: f.Count, windowStart: f.WindowStart, lockedUntil: f.LockedUntil}
+	}
+	return nil
+}
+
+// save writes the counters of the given keys to auth.db. The caller holds g.mu.
+func (g *LoginGuard) save(keys []string) {
+	if g.store == nil {
+		return
+	}
+	for _, key := range keys {
+		f, ok := g.failures[key]
+		if !ok {
+			continue
+		}
+		err := g.store.SaveLoginFailure(&database.LoginFailure{Key: key, Count: f.count, WindowStart: f.windowStart, LockedUntil: f.lockedUntil})
+		if err != nil {
+			g.log.Error("Failed to store failed login count: %v", err)
+		}
+	}
+}
+
+// forget removes the counters of the given keys, also from auth.db. The caller holds g.mu.
+func (g *LoginGuard) forget(keys []string) {
+	for _, key := range keys {
+		delete(g.failures, key)
+	}
+	if g.store == nil {
+		return
+	}
+	if err := g.store.DeleteLoginFailures(keys); err != nil {
+		g.log.Error("Failed to clear failed login counts: %v", err)
+	}
+}
+
+// lockoutKeys returns the counters a login attempt is charged to
+func lockoutKeys(username, ip string) []string {
+	return []string{"user:" + strings.ToLower(username), "ip:" + ip}
+}
+
+// Locked reports whether the username or the client IP is locked, and for how long
+func (g *LoginGuard) Locked(username, ip string) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var remaining time.Duration
+	for _, key := range lockoutKeys(username, ip) {
+		if f, ok := g.failures[key]; ok && f.lockedUntil.After(now) {
+			remaining = max(remaining, f.lockedUntil.Sub(now))
+		}
+	}
+	return remaining, remaining > 0
+}
+
+// Failure records a failed login. It returns the lockout duration when this
+// failure locked the username or the client IP, or 0. Attempts are checked
+// with Locked first, so no failures are counted while locked.
+func (g *LoginGuard) Failure(username, ip string, policy *config.LoginLockout) time.Duration {
+	attempts := policy.Attempts()
+	if attempts == 0 {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop stale counters so the map does not grow without bound
+	if len(g.failures) > 10000 {
+		var stale []string
+		for key, f := range g.failures {
+			if now.Sub(f.windowStart) >= policy.Window() && now.After(f.lockedUntil) {
+				stale = append(stale, key)
+			}
+		}
+		g.forget(stale)
+	}
+
+	keys := lockoutKeys(username, ip)
+	defer g.save(keys)
+
+	var locked time.Duration
+	for _, key := range keys {
+		f, ok := g.failures[key]
+		if !ok || now.Sub(f.windowStart) >= policy.Window() {
+			f = &loginFailures{windowStart: now}
+			g.failures[key] = f
+		}
+		f.count++
+		if f.count >= attempts {
+			f.lockedUntil = now.Add(policy.Duration())
+			f.count = 0
+			f.windowStart = now
+			locked = policy.Duration()
+		}
+	}
+	return locked
+}
+
+// Success clears the failures of the username and client IP after a successful login
+func (g *LoginGuard) Success(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.forget(lockoutKeys(username, ip))
+}
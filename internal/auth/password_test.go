@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// digest returns what the login page sends for a password: its SHA256 hex digest
+func digest(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerifyPassword(t *testing.T) {
+	argon2Hash, err := HashPassword(digest("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyHash := digest("secret") // Earlier versions stored the digest itself
+
+	tests := []struct {
+		name        string
+		stored      string
+		password    string
+		ok          bool
+		needsRehash bool
+	}{
+		{"argon2id", argon2Hash, digest("secret"), true, false},
+		{"argon2id wrong password", argon2Hash, digest("wrong"), false, false},
+		{"legacy", legacyHash, digest("secret"), true, true},
+		{"legacy upper case", strings.ToUpper(legacyHash), digest("secret"), true, true},
+		{"legacy wrong password", legacyHash, digest("wrong"), false, false},
+		{"unknown algorithm", strings.Replace(argon2Hash, "argon2id", "argon2i", 1), digest("secret"), false, false},
+		{"other argon2 version", strings.Replace(argon2Hash, "v=19", "v=16", 1), digest("secret"), false, false},
+		{"truncated hash", argon2Hash[:strings.LastIndex(argon2Hash, "$")], digest("secret"), false, false},
+		{"empty hash", "", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash := VerifyPassword(tt.stored, tt.password)
+			if ok != tt.ok || needsRehash != tt.needsRehash {
+				t.Errorf("VerifyPassword() = %v, %v, want %v, %v", ok, needsRehash, tt.ok, tt.needsRehash)
+			}
+		})
+	}
+}
+
+// writeAccessFile writes access.json to a new config directory
+func writeAccessFile(t *testing.T, contents string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "access.json"), []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFileProviderRehashesLegacyHash(t *testing.T) {
+	dir := writeAccessFile(t, `{"admin": {"password": "`+digest("secret")+`", "role": "admin"}}`)
+	provider := NewFileProvider(dir)
+
+	identity, err := provider.Authenticate("admin", digest("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username != "admin" || identity.Role != RoleAdmin {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	users, err := LoadAccessCredentials(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := users["admin"].Password
+	if !strings.HasPrefix(stored, "$argon2id$") {
+		t.Fatalf("password hash was not upgraded: %s", stored)
+	}
+	if ok, needsRehash := VerifyPassword(stored, digest("secret")); !ok || needsRehash {
+		t.Errorf("upgraded hash: VerifyPassword() = %v, %v", ok, needsRehash)
+	}
+	if users["admin"].Role != RoleAdmin {
+		t.Errorf("role = %q after the upgrade", users["admin"].Role)
+	}
+
+	// The upgraded hash keeps working
+	if _, err := provider.Authenticate("admin", digest("secret")); err != nil {
+		t.Errorf("login after the upgrade: %v", err)
+	}
+}
+
+func TestFileProviderWrongPasswordKeepsFile(t *testing.T) {
+	argon2Hash, err := HashPassword(digest("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		access string
+	}{
+		{"legacy", `{"admin": "` + digest("secret") + `"}`},
+		{"argon2id", `{"admin": {"password": "` + argon2Hash + `", "role": "admin"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeAccessFile(t, tt.access)
+
+			_, err := NewFileProvider(dir).Authenticate("admin", digest("wrong"))
+			if !errors.Is(err, ErrWrongPassword) {
+				t.Errorf("err = %v, want ErrWrongPassword", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "access.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.access {
+				t.Errorf("access.json changed to %s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// Errors returned by Provider.Authenticate for rejected credentials
+var (
+	ErrUnknownUser   = errors.New("unknown username")
+	ErrWrongPassword = errors.New("wrong password")
+)
+
+// Identity is a user authenticated by a provider
+type Identity struct {
+	Username string
+	Role     string // admin or viewer
+}
+
+// Provider authenticates users for the login API. Providers are selected
+// with auth_provider in config.json and created for each request, so
+// configuration changes apply immediately.
+type Provider interface {
+	Name() string
+	// Authenticate checks a username and the SHA256 password digest sent by
+	// the login page. Rejected credentials return ErrUnknownUser or ErrWrongPassword.
+	Authenticate(username, password string) (*Identity, error)
+}
+
+// RequestAuthenticator is implemented by providers that identify users from
+// the request itself (e.g. a header set by a reverse proxy), without the login page
+type RequestAuthenticator interface {
+	// AuthenticateRequest returns the identity carried by r, or false when
+	// the request must be authenticated another way
+	AuthenticateRequest(r *http.Request, clientIP string) (*Identity, bool)
+}
+
+// ProviderFactory creates a provider from the configuration
+type ProviderFactory func(configDir string, cfg *config.Config) (Provider, error)
+
+var (
+	providerFactories = map[string]ProviderFactory{}
+	providersMu       sync.RWMutex
+)
+
+// RegisterProvider makes an authentication provider available under name
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providerFactories[strings.ToLower(name)] = factory
+}
+
+// ProviderNames lists the registered providers
+func ProviderNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providerFactories))
+	for name := range providerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider creates the provider selected by auth_provider (file by default)
+func NewProvider(configDir string, cfg *config.Config) (Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.AuthProvider))
+	if name == "" {
+		name = config.AuthProviderFile
+	}
+
+	providersMu.RLock()
+	factory, ok := providerFactories[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown auth_provider %q (available: %s)", cfg.AuthProvider, strings.Join(ProviderNames(), ", "))
+	}
+	return factory(configDir, cfg)
+}
+
+func init() {
+	RegisterProvider(config.AuthProviderFile, func(configDir string, cfg *config.Config) (Provider, error) {
+		return NewFileProvider(configDir), nil
+	})
+	RegisterProvider(config.AuthProviderHeader, newHeaderProvider)
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// ErrNoPushToken is returned when push.json has no token for a device
+var ErrNoPushToken = errors.New("no push token configured for this device")
+
+// VerifyPushToken checks the token a push device sent against its entry in
+// push.json, which maps device ids to tokens: {"RemoteAxe": "long-random-token"}
+func VerifyPushToken(configDir, instanceID, token string) (bool, error) {
+	data, err := secrets.Read(configDir, "push.json")
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrNoPushToken, err)
+	}
+
+	var tokens map[string]string
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return false, fmt.Errorf("error parsing push.json: %w", err)
+	}
+	expected := tokens[instanceID]
+	if expected == "" {
+		return false, ErrNoPushToken
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1, nil
+}
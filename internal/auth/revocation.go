@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// revocationFile holds the revoked session tokens in the config directory
+const revocationFile = "revocations.json"
+
+// revocationData is the content of revocations.json
+type revocationData struct {
+	// Tokens issued before AllBefore are rejected for every user
+	AllBefore time.Time `json:"allBefore,omitempty"`
+	// Tokens issued before the time are rejected for that user
+	Users map[string]time.Time `json:"users,omitempty"`
+	// Individual tokens (by id) with their expiry; dropped once expired
+	Tokens map[string]time.Time `json:"tokens,omitempty"`
+}
+
+// RevocationList rejects session tokens before they expire, e.g. after logout
+// or when a cookie may have been stolen
+type RevocationList struct {
+	mu   sync.Mutex
+	path string
+	data revocationData
+}
+
+// RevocationStatus summarizes the revocation list
+type RevocationStatus struct {
+	AllBefore     *time.Time           `json:"allBefore,omitempty"`
+	Users         map[string]time.Time `json:"users"`
+	RevokedTokens int                  `json:"revokedTokens"`
+}
+
+var revocationList *RevocationList
+
+// InitRevocationList loads revocations.json from the config directory
+func InitRevocationList(configDir string) error {
+	list := &RevocationList{path: filepath.Join(configDir, revocationFile)}
+
+	data, err := os.ReadFile(list.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read %s: %w", revocationFile, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &list.data); err != nil {
+			return fmt.Errorf("could not parse %s: %w", revocationFile, err)
+		}
+	}
+	if list.data.Users == nil {
+		list.data.Users = make(map[string]time.Time)
+	}
+	if list.data.Tokens == nil {
+		list.data.Tokens = make(map[string]time.Time)
+	}
+
+	revocationList = list
+	return nil
+}
+
+// GetRevocationList returns the initialized revocation list
+func GetRevocationList() *RevocationList {
+	return revocationList
+}
+
+// newTokenID returns a random id for a session token
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// revocationCutoff returns the cutoff for tokens issued up to now. Token
+// issue times have a precision of one second, so the cutoff is rounded up
+// to also catch tokens issued earlier within the current second.
+func revocationCutoff() time.Time {
+	return time.Now().Truncate(time.Second).Add(time.Second)
+}
+
+// save drops expired token entries and writes the list to revocations.json; the caller holds the lock
+func (l *RevocationList) save() error {
+	now := time.Now()
+	for id, expiresAt := range l.data.Tokens {
+		if now.After(expiresAt) {
+			delete(l.data.Tokens, id)
+		}
+	}
+
+	data, err := json.MarshalIndent(l.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteSecretFile(l.path, data); err != nil {
+		return fmt.Errorf("error writing %s: %w", revocationFile, err)
+	}
+	return nil
+}
+
+// Revoked reports whether a verified session token has been revoked
+func (l *RevocationList) Revoked(claims *Claims) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if claims.ID != "" {
+		if _, ok := l.data.Tokens[claims.ID]; ok {
+			return true
+		}
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if !l.data.AllBefore.IsZero() && issuedAt.Before(l.data.AllBefore) {
+		return true
+	}
+	if before, ok := l.data.Users[claims.Username]; ok && issuedAt.Before(before) {
+		return true
+	}
+	return false
+}
+
+// RevokeToken rejects one token until it expires
+func (l *RevocationList) RevokeToken(id string, expiresAt time.Time) error {
+	if id == "" {
+		return nil // Tokens issued before token ids existed cannot be revoked one by one
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data.Tokens[id] = expiresAt
+	return l.save()
+}
+
+// RevokeUser rejects every token issued to username so far
+func (l *RevocationList) RevokeUser(username string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data.Users[username] = revocationCutoff()
+	return l.save()
+}
+
+// RevokeAll rejects every token issued so far, logging out all users
+func (l *RevocationList) RevokeAll() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data.AllBefore = revocationCutoff()
+	// Per-user cutoffs up to the new cutoff are no longer needed
+	for username, before := range l.data.Users {
+		if !before.After(l.data.AllBefore) {
+			delete(l.data.Users, username)
+		}
+	}
+	return l.save()
+}
+
+// Status returns the current cutoffs and the number of revoked tokens
+func (l *RevocationList) Status() RevocationStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := RevocationStatus{Users: make(map[string]time.Time), RevokedTokens: len(l.data.Tokens)}
+	if !l.data.AllBefore.IsZero() {
+		allBefore := l.data.AllBefore
+		status.AllBefore = &allBefore
+	}
+	for username, before := range l.data.Users {
+		status.Users[username] = before
+	}
+	return status
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User roles stored in access.json and carried in session tokens
+const (
+	RoleAdmin  = "admin"
+	RoleViewer = "viewer"
+)
+
+// NormalizeRole returns a known role. Missing roles mean admin (single-user
+// access.json files predate roles); unknown roles get the least privilege.
+func NormalizeRole(role string) string {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "", RoleAdmin:
+		return RoleAdmin
+	default:
+		return RoleViewer
+	}
+}
+
+// AccessUser is one user in access.json
+type AccessUser struct {
+	Password          string     `json:"password"` // Argon2id (or legacy SHA256) hash
+	Role              string     `json:"role,omitempty"`
+	PasswordChangedAt *time.Time `json:"passwordChangedAt,omitempty"` // For rotation reminders
+}
+
+// UnmarshalJSON accepts the original "username": "hash" format as well as
+// "username": {"password": "hash", "role": "viewer"}
+func (u *AccessUser) UnmarshalJSON(data []byte) error {
+	var hash string
+	if err := json.Unmarshal(data, &hash); err == nil {
+		*u = AccessUser{Password: hash, Role: RoleAdmin}
+		return nil
+	}
+
+	type plain AccessUser
+	var user plain
+	if err := json.Unmarshal(data, &user); err != nil {
+		return fmt.Errorf("user must be a password hash or an object with password and role")
+	}
+	*u = AccessUser(user)
+	u.Role = NormalizeRole(u.Role)
+	return nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// Credentials whose age is tracked for rotation reminders
+const (
+	CredentialJWTKey   = "jwt_key"
+	CredentialPassword = "password"
+)
+
+// CredentialAge reports how long a credential has been in use
+type CredentialAge struct {
+	Credential  string     `json:"credential"`          // jwt_key or password
+	User        string     `json:"user,omitempty"`      // Owner of a password
+	ChangedAt   *time.Time `json:"changedAt,omitempty"` // Nil when the age is unknown
+	AgeDays     int        `json:"ageDays"`
+	MaxAgeDays  int        `json:"maxAgeDays"`
+	RotationDue bool       `json:"rotationDue"`
+}
+
+// fileModTime returns when a file-backed secret was last written
+func fileModTime(configDir, name string) *time.Time {
+	if !secrets.IsFileBacked(name) {
+		return nil
+	}
+	info, err := os.Stat(filepath.Join(configDir, name))
+	if err != nil {
+		return nil
+	}
+	modTime := info.ModTime()
+	return &modTime
+}
+
+// newCredentialAge computes the age of a credential changed at changedAt
+func newCredentialAge(credential, user string, changedAt *time.Time, maxAgeDays int) CredentialAge {
+	age := CredentialAge{Credential: credential, User: user, ChangedAt: changedAt, MaxAgeDays: maxAgeDays}
+	if changedAt != nil {
+		age.AgeDays = int(time.Since(*changedAt).Hours() / 24)
+		age.RotationDue = age.AgeDays >= maxAgeDays
+	}
+	return age
+}
+
+// CheckCredentialAges returns the age of the JWT signing key and of every
+// user password checked by the rotation policy. An explicit rotatedAt in
+// jsonWebTokenKey.json or passwordChangedAt in access.json takes precedence;
+// otherwise the modification time of the file is used. Credentials from a
+// secret backend without such a timestamp have an unknown age.
+func CheckCredentialAges(configDir string, policy *config.CredentialRotation) []CredentialAge {
+	ages := []CredentialAge{}
+	if !policy.Enabled() {
+		return ages
+	}
+
+	if policy.JWTKeyDays > 0 {
+		if data, err := secrets.Read(configDir, "jsonWebTokenKey.json"); err == nil {
+			var keyData JWTConfig
+			if json.Unmarshal(data, &keyData) == nil && keyData.JsonWebTokenKey != "" {
+				changedAt := keyData.RotatedAt
+				if changedAt == nil {
+					changedAt = fileModTime(configDir, "jsonWebTokenKey.json")
+				}
+				ages = append(ages, newCredentialAge(CredentialJWTKey, "", changedAt, policy.JWTKeyDays))
+			}
+		}
+	}
+
+	if policy.PasswordDays > 0 {
+		if users, err := LoadAccessCredentials(configDir); err == nil {
+			names := make([]string, 0, len(users))
+			for name := range users {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			modTime := fileModTime(configDir, "access.json")
+			for _, name := range names {
+				changedAt := users[name].PasswordChangedAt
+				if changedAt == nil {
+					changedAt = modTime
+				}
+				ages = append(ages, newCredentialAge(CredentialPassword, name, changedAt, policy.PasswordDays))
+			}
+		}
+	}
+
+	return ages
+}
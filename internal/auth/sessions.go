@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// sessionFile holds the sessions that can be renewed with a refresh token
+const sessionFile = "sessions.json"
+
+// refreshReuseGrace is how long the previous refresh token of a session is
+// still accepted, so parallel requests renewing the same session do not log
+// the user out
+const refreshReuseGrace = 30 * time.Second
+
+// Errors returned when a refresh token cannot renew a session
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; session revoked")
+)
+
+// Session is a login that can be renewed with a refresh token. Only a hash of
+// the current refresh token is stored; each renewal replaces the token and
+// extends the session.
+type Session struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	Hash         string    `json:"hash"`
+	PreviousHash string    `json:"previousHash,omitempty"`
+	RotatedAt    time.Time `json:"rotatedAt"`
+	ClientIP     string    `json:"clientIp,omitempty"`
+	UserAgent    string    `json:"userAgent,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// SessionStore keeps the sessions of sessions.json in memory
+type SessionStore struct {
+	mu       sync.Mutex
+	path     string
+	sessions []*Session
+}
+
+var sessionStore *SessionStore
+
+// InitSessionStore loads sessions.json from the config directory, so
+// sessions survive a restart
+func InitSessionStore(configDir string) error {
+	store := &SessionStore{path: filepath.Join(configDir, sessionFile)}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read %s: %w", sessionFile, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &store.sessions); err != nil {
+			return fmt.Errorf("could not parse %s: %w", sessionFile, err)
+		}
+	}
+
+	sessionStore = store
+	return nil
+}
+
+// GetSessionStore returns the initialized session store
+func GetSessionStore() *SessionStore {
+	return sessionStore
+}
+
+// newRefreshToken returns a random refresh token
+func newRefreshToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// save drops expired sessions and writes the rest to sessions.json; the caller holds the lock
+func (s *SessionStore) save() error {
+	now := time.Now()
+	active := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if now.Before(session.ExpiresAt) {
+			active = append(active, session)
+		}
+	}
+	s.sessions = active
+
+	data, err := json.MarshalIndent(s.sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteSecretFile(s.path, data); err != nil {
+		return fmt.Errorf("error writing %s: %w", sessionFile, err)
+	}
+	return nil
+}
+
+// Create starts a session for a user that logged in. It returns the refresh
+// token, which is not stored, along with the session.
+func (s *SessionStore) Create(username, role string, lifetime time.Duration, clientIP, userAgent string) (string, Session, error) {
+	token, err := newRefreshToken()
+	if err != nil {
+		return "", Session{}, err
+	}
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", Session{}, fmt.Errorf("failed to generate session id: %w", err)
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:        hex.EncodeToString(idBytes),
+		Username:  username,
+		Role:      NormalizeRole(role),
+		Hash:      hashToken(token),
+		RotatedAt: now,
+		ClientIP:  clientIP,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifetime),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = append(s.sessions, session)
+	if err := s.save(); err != nil {
+		s.sessions = s.sessions[:len(s.sessions)-1]
+		return "", Session{}, err
+	}
+	return token, *session, nil
+}
+
+// Renew exchanges a refresh token for a new one and extends the session by
+// lifetime. The previous token is still accepted for a short grace period,
+// without rotating again (the returned token is then empty). Any later use of
+// an old token revokes the session, since the token has probably been stolen;
+// the revoked session is returned with ErrRefreshTokenReused.
+func (s *SessionStore) Renew(token string, lifetime time.Duration) (string, Session, error) {
+	hash := hashToken(token)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			continue
+		}
+		if session.PreviousHash != "" && subtle.ConstantTimeCompare([]byte(session.PreviousHash), []byte(hash)) == 1 {
+			if now.Sub(session.RotatedAt) <= refreshReuseGrace {
+				return "", *session, nil
+			}
+			revoked := *session
+			s.sessions = append(s.sessions[:i:i], s.sessions[i+1:]...)
+			if err := s.save(); err != nil {
+				return "", Session{}, err
+			}
+			return "", revoked, ErrRefreshTokenReused
+		}
+		if subtle.ConstantTimeCompare([]byte(session.Hash), []byte(hash)) != 1 {
+			continue
+		}
+
+		next, err := newRefreshToken()
+		if err != nil {
+			return "", Session{}, err
+		}
+		previous := *session
+		session.PreviousHash = session.Hash
+		session.Hash = hashToken(next)
+		session.RotatedAt = now
+		session.ExpiresAt = now.Add(lifetime)
+		if err := s.save(); err != nil {
+			*session = previous
+			return "", Session{}, err
+		}
+		return next, *session, nil
+	}
+	return "", Session{}, ErrRefreshTokenInvalid
+}
+
+// Active reports whether the session with the given id exists and has not expired
+func (s *SessionStore) Active(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.ID == id {
+			return time.Now().Before(session.ExpiresAt)
+		}
+	}
+	return false
+}
+
+// List returns the active sessions, newest first
+func (s *SessionStore) List() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if now.Before(session.ExpiresAt) {
+			sessions = append(sessions, *session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions
+}
+
+// revoke removes the sessions matching fn and returns how many were removed
+func (s *SessionStore) revoke(fn func(*Session) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if !fn(session) {
+			kept = append(kept, session)
+		}
+	}
+	removed := len(s.sessions) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	previous := s.sessions
+	s.sessions = kept
+	if err := s.save(); err != nil {
+		s.sessions = previous
+		return 0, err
+	}
+	return removed, nil
+}
+
+// Revoke ends the session with the given id. It reports whether the session existed.
+func (s *SessionStore) Revoke(id string) (bool, error) {
+	n, err := s.revoke(func(session *Session) bool { return session.ID == id })
+	return n > 0, err
+}
+
+// RevokeToken ends the session a refresh token belongs to, e.g. on logout
+func (s *SessionStore) RevokeToken(token string) (bool, error) {
+	hash := hashToken(token)
+	n, err := s.revoke(func(session *Session) bool {
+		return subtle.ConstantTimeCompare([]byte(session.Hash), []byte(hash)) == 1 ||
+			subtle.ConstantTimeCompare([]byte(session.PreviousHash), []byte(hash)) == 1
+	})
+	return n > 0, err
+}
+
+// RevokeUser ends all sessions of a user and returns how many there were
+func (s *SessionStore) RevokeUser(username string) (int, error) {
+	return s.revoke(func(session *Session) bool { return session.Username == username })
+}
+
+// RevokeAll ends every session and returns how many there were
+func (s *SessionStore) RevokeAll() (int, error) {
+	return s.revoke(func(*Session) bool { return true })
+}
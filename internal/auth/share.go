@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ShareScope is the scope carried by read-only share link tokens
+const ShareScope = "share:read"
+
+// FeedScope is the scope carried by calendar and feed reader tokens
+const FeedScope = "feed:read"
+
+// StatsCardScope is the scope carried by tokens embedding the statistics image
+const StatsCardScope = "stats:card"
+
+// MaxShareLifetime is the longest lifetime allowed for a share link
+const MaxShareLifetime = 7 * 24 * time.Hour
+
+// ShareClaims represents the claims of a read-only share link token
+type ShareClaims struct {
+	InstanceID string `json:"instanceId"`
+	Scope      string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// CreateShareToken creates a token granting read-only access to a single instance
+func (j *JWTService) CreateShareToken(instanceID, createdBy string, expiresIn time.Duration) (string, time.Time, error) {
+	if expiresIn <= 0 || expiresIn > MaxShareLifetime {
+		return "", time.Time{}, fmt.Errorf("share lifetime must be between 1s and %v", MaxShareLifetime)
+	}
+
+	expiresAt := time.Now().Add(expiresIn)
+	claims := ShareClaims{
+		InstanceID: instanceID,
+		Scope:      ShareScope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   createdBy,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(j.secretKey))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("share token creation error: %w", err)
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// VerifyShareToken verifies a share link token and returns its claims
+func (j *JWTService) VerifyShareToken(tokenString string) (*ShareClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ShareClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(j.secretKey), nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("share token verification error: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ShareClaims)
+	if !ok || !token.Valid || claims.Scope != ShareScope || claims.InstanceID == "" {
+		return nil, fmt.Errorf("invalid share token")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// Errors returned when access.json cannot be changed as requested
+var (
+	ErrUserExists       = errors.New("user already exists")
+	ErrLastAdmin        = errors.New("the last admin user cannot be removed")
+	ErrAccessFileLocked = errors.New("access.json is provided by a secret backend")
+)
+
+// maxUsernameLength limits the usernames accepted by CreateUser
+const maxUsernameLength = 64
+
+// accessMu serializes changes to access.json
+var accessMu sync.Mutex
+
+// UserInfo describes a user in access.json without the password hash
+type UserInfo struct {
+	Username          string     `json:"username"`
+	Role              string     `json:"role"`
+	PasswordChangedAt *time.Time `json:"passwordChangedAt,omitempty"`
+}
+
+// ValidateUsername checks a username for a new user
+func ValidateUsername(username string) error {
+	switch {
+	case username == "":
+		return fmt.Errorf("username is required")
+	case len(username) > maxUsernameLength:
+		return fmt.Errorf("username must be at most %d characters", maxUsernameLength)
+	case strings.ContainsAny(username, " \t\r\n/:"):
+		return fmt.Errorf("username must not contain spaces, slashes or colons")
+	}
+	return nil
+}
+
+// modifyAccessFile applies fn to the entries of access.json and writes the
+// result. Entries fn does not touch keep their original form.
+func modifyAccessFile(configDir string, fn func(entries map[string]json.RawMessage) error) error {
+	if !secrets.IsFileBacked("access.json") {
+		return ErrAccessFileLocked
+	}
+
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	path := filepath.Join(configDir, "access.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading access.json: %w", err)
+	}
+
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("error parsing access.json: %w", err)
+	}
+	if entries == nil {
+		entries = make(map[string]json.RawMessage)
+	}
+	if err := fn(entries); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteSecretFile(path, data); err != nil {
+		return fmt.Errorf("error writing access.json: %w", err)
+	}
+	return nil
+}
+
+// ListUsers returns the users in access.json, sorted by name
+func ListUsers(configDir string) ([]UserInfo, error) {
+	users, err := LoadAccessCredentials(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]UserInfo, 0, len(users))
+	for name, user := range users {
+		list = append(list, UserInfo{Username: name, Role: user.Role, PasswordChangedAt: user.PasswordChangedAt})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Username < list[j].Username })
+	return list, nil
+}
+
+// CreateUser adds a user with a password hash (see HashPassword) to access.json
+func CreateUser(configDir, username, role, passwordHash string) error {
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	entry, err := json.Marshal(AccessUser{Password: passwordHash, Role: NormalizeRole(role), PasswordChangedAt: &now})
+	if err != nil {
+		return err
+	}
+
+	return modifyAccessFile(configDir, func(entries map[string]json.RawMessage) error {
+		if _, ok := entries[username]; ok {
+			return ErrUserExists
+		}
+		entries[username] = entry
+		return nil
+	})
+}
+
+// DeleteUser removes a user from access.json. The last admin cannot be
+// removed, so the dashboard cannot be locked out of its own settings.
+func DeleteUser(configDir, username string) error {
+	return modifyAccessFile(configDir, func(entries map[string]json.RawMessage) error {
+		entry, ok := entries[username]
+		if !ok {
+			return ErrUnknownUser
+		}
+
+		var user AccessUser
+		if err := json.Unmarshal(entry, &user); err == nil && user.Role == RoleAdmin {
+			admins := 0
+			for _, other := range entries {
+				var u AccessUser
+				if json.Unmarshal(other, &u) == nil && u.Role == RoleAdmin {
+					admins++
+				}
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		delete(entries, username)
+		return nil
+	})
+}
+
+// ValidateAccessFile checks the contents of an access.json backup: every
+// user needs a password and at least one must be an admin, so a restore
+// cannot lock the dashboard out of its own settings
+func ValidateAccessFile(data []byte) error {
+	var restored map[string]json.RawMessage
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return fmt.Errorf("error parsing access.json: %w", err)
+	}
+	admins := 0
+	for username, entry := range restored {
+		var user AccessUser
+		if err := json.Unmarshal(entry, &user); err != nil {
+			return fmt.Errorf("error parsing access.json entry of %s: %w", username, err)
+		}
+		if user.Password == "" {
+			return fmt.Errorf("user %s in access.json has no password", username)
+		}
+		if user.Role == RoleAdmin {
+			admins++
+		}
+	}
+	if admins == 0 {
+		return fmt.Errorf("access.json must contain an admin user")
+	}
+	return nil
+}
+
+// RestoreAccessFile replaces the users in access.json with those of a
+// backup that passes ValidateAccessFile
+func RestoreAccessFile(configDir string, data []byte) error {
+	if err := ValidateAccessFile(data); err != nil {
+		return err
+	}
+	var restored map[string]json.RawMessage
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return err
+	}
+
+	return modifyAccessFile(configDir, func(entries map[string]json.RawMessage) error {
+		clear(entries)
+		for username, entry := range restored {
+			entries[username] = entry
+		}
+		return nil
+	})
+}
+
+// ChangePassword replaces the password hash of a user and records when it
+// changed for rotation reminders. Plain "username": "hash" entries become
+// objects so the change time can be stored.
+func ChangePassword(configDir, username, passwordHash string) error {
+	return modifyAccessFile(configDir, func(entries map[string]json.RawMessage) error {
+		entry, ok := entries[username]
+		if !ok {
+			return ErrUnknownUser
+		}
+
+		now := time.Now().UTC()
+		object := map[string]interface{}{"role": RoleAdmin} // Plain "username": "hash" entry
+		if err := json.Unmarshal(entry, &object); err != nil {
+			var hash string
+			if json.Unmarshal(entry, &hash) != nil {
+				return fmt.Errorf("error parsing access.json entry of %s: %w", username, err)
+			}
+		}
+		object["password"] = passwordHash
+		object["passwordChangedAt"] = now
+
+		updated, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		entries[username] = updated
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ambient temperature source types
+const (
+	AmbientOpenWeatherMap = "openweathermap"
+	AmbientURL            = "url"
+	AmbientMQTT           = "mqtt"
+)
+
+// AmbientSource configures where the room (or outdoor) temperature comes from.
+// The OpenWeatherMap API key is read from openWeatherMap.json and optional MQTT
+// credentials from mqtt.json.
+type AmbientSource struct {
+	Type string `json:"type"` // "openweathermap", "url" or "mqtt"
+
+	// OpenWeatherMap location
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+
+	// Local sensor: an HTTP URL or an MQTT topic whose payload is a number or
+	// a JSON object holding the temperature at Field (dot separated path)
+	URL    string `json:"url,omitempty"`
+	Broker string `json:"broker,omitempty"` // host:port, port 1883 by default
+	Topic  string `json:"topic,omitempty"`
+	Field  string `json:"field,omitempty"` // Defaults to "temperature"
+	Unit   string `json:"unit,omitempty"`  // Unit of the sensor value, C by default
+}
+
+// normalize validates the source and fills in defaults
+func (a *AmbientSource) normalize() error {
+	a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+	switch a.Type {
+	case AmbientOpenWeatherMap:
+		if a.Latitude == 0 && a.Longitude == 0 {
+			return fmt.Errorf("openweathermap requires latitude and longitude")
+		}
+	case AmbientURL:
+		if a.URL == "" {
+			return fmt.Errorf("url source requires url")
+		}
+	case AmbientMQTT:
+		if a.Broker == "" || a.Topic == "" {
+			return fmt.Errorf("mqtt source requires broker and topic")
+		}
+	default:
+		return fmt.Errorf("unknown type %q (use openweathermap, url or mqtt)", a.Type)
+	}
+
+	if a.Field == "" {
+		a.Field = "temperature"
+	}
+	if unit, ok := NormalizeTemperatureUnit(a.Unit); ok {
+		a.Unit = unit
+	} else {
+		a.Unit = UnitCelsius
+	}
+	return nil
+}
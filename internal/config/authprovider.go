@@ -0,0 +1,43 @@
+package config
+
+import (
+	"net"
+	"strings"
+)
+
+// Built-in authentication providers
+const (
+	AuthProviderFile   = "file"   // access.json (default)
+	AuthProviderHeader = "header" // Username set by a trusted reverse proxy
+)
+
+// DefaultAuthUserHeader is the request header carrying the username from the proxy
+const DefaultAuthUserHeader = "X-Forwarded-User"
+
+// AuthHeaderSettings configures the header provider, which trusts the
+// username a reverse proxy (e.g. Authelia or oauth2-proxy) puts in a header
+type AuthHeaderSettings struct {
+	UserHeader     string   `json:"user_header,omitempty"`  // Defaults to X-Forwarded-User
+	RoleHeader     string   `json:"role_header,omitempty"`  // Optional header with admin or viewer
+	TrustedProxies []string `json:"trusted_proxies"`        // IPs or CIDRs allowed to set the headers
+	DefaultRole    string   `json:"default_role,omitempty"` // Defaults to viewer
+	Admins         []string `json:"admins,omitempty"`       // Usernames that always get the admin role
+}
+
+// IPAllowed reports whether ip matches one of the entries (single IPs or CIDRs)
+func IPAllowed(ip net.IP, allowlist []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
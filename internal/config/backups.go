@@ -0,0 +1,183 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultConfigBackups is how many copies of config.json are kept
+const DefaultConfigBackups = 20
+
+// configBackupLayout names backups by the UTC time they were taken, so they
+// sort by name
+const configBackupLayout = "20060102T150405.000Z"
+
+// configBackupName matches the file names of config.json backups
+var configBackupName = regexp.MustCompile(`^config-\d{8}T\d{6}\.\d{3}Z\.json$`)
+
+// ConfigVersion is a backup of config.json taken before it was changed
+type ConfigVersion struct {
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+	Size      int64     `json:"size"`
+}
+
+// backupDir returns the directory holding the backups of config.json
+func (m *Manager) backupDir() string {
+	return filepath.Join(m.GetConfigDir(), "backups")
+}
+
+// writeConfigFile replaces config.json, first keeping the previous contents
+// as a timestamped backup. The caller holds m.mu.
+func (m *Manager) writeConfigFile(previous, data []byte) error {
+	limit := DefaultConfigBackups
+	if m.config != nil {
+		limit = m.config.ConfigBackups
+	}
+	if limit > 0 && len(previous) > 0 {
+		if err := m.backupConfigFile(previous, limit); err != nil {
+			// A failed backup must not block the change
+			m.log.Warn("Failed to back up config.json: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	return nil
+}
+
+// backupConfigFile stores a copy of config.json and deletes the oldest
+// copies beyond limit
+func (m *Manager) backupConfigFile(data []byte, limit int) error {
+	dir := m.backupDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := "config-" + time.Now().UTC().Format(configBackupLayout) + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		return err
+	}
+
+	versions, err := m.listConfigVersions()
+	if err != nil {
+		return err
+	}
+	for _, version := range versions[min(limit, len(versions)):] {
+		if err := os.Remove(filepath.Join(dir, version.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigVersions returns the backups of config.json, newest first
+func (m *Manager) ConfigVersions() ([]ConfigVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listConfigVersions()
+}
+
+// listConfigVersions lists the backups of config.json, newest first
+func (m *Manager) listConfigVersions() ([]ConfigVersion, error) {
+	entries, err := os.ReadDir(m.backupDir())
+	if os.IsNotExist(err) {
+		return []ConfigVersion{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := []ConfigVersion{}
+	for _, entry := range entries {
+		if entry.IsDir() || !configBackupName.MatchString(entry.Name()) {
+			continue
+		}
+		timestamp, err := time.Parse(configBackupLayout, strings.TrimSuffix(strings.TrimPrefix(entry.Name(), "config-"), ".json"))
+		if err != nil {
+			continue
+		}
+		version := ConfigVersion{Name: entry.Name(), Timestamp: timestamp}
+		if info, err := entry.Info(); err == nil {
+			version.Size = info.Size()
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Name > versions[j].Name })
+	return versions, nil
+}
+
+// ReadConfigVersion returns the contents of a backup of config.json; ok is
+// false when there is no backup of that name
+func (m *Manager) ReadConfigVersion(name string) (data []byte, ok bool, err error) {
+	if !configBackupName.MatchString(name) {
+		return nil, false, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, err = os.ReadFile(filepath.Join(m.backupDir(), name))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// ValidateConfigData checks a complete config.json, such as a backup, with
+// the checks of UpdateConfig
+func ValidateConfigData(data []byte) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil || values == nil {
+		return &ValidationError{Field: "config.json", Message: "must be a JSON object"}
+	}
+	if err := json.Unmarshal(data, &Config{}); err != nil {
+		return &ValidationError{Field: "config.json", Message: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+	return validateValues(values)
+}
+
+// RestoreConfig replaces config.json with a complete configuration, such as
+// a backup, after the checks of UpdateConfig. The current file is backed up
+// first, so a restore can itself be undone.
+func (m *Manager) RestoreConfig(data []byte) error {
+	if err := ValidateConfigData(data); err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	normalizeLegacyKeys(values)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, err := os.ReadFile(m.configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	formatted, err := json.MarshalIndent(values, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling config: %w", err)
+	}
+	if err := m.writeConfigFile(previous, formatted); err != nil {
+		return err
+	}
+	m.log.Info("Configuration restored")
+
+	// Reload config into memory (unlock first to avoid deadlock)
+	m.mu.Unlock()
+	cfg, err := m.LoadConfig()
+	if err == nil {
+		m.notifyListeners(cfg)
+	}
+	m.mu.Lock() // Re-lock before defer unlocks
+	return err
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeBasePath returns base_path with a leading slash and without a
+// trailing slash, e.g. "axeos/" becomes "/axeos". The root path is "".
+func NormalizeBasePath(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("%q is not a valid path", path)
+		}
+	}
+	if strings.ContainsAny(path, "?#%\\\"'<> \t") {
+		return "", fmt.Errorf("must be a plain path such as /axeos")
+	}
+	return "/" + path, nil
+}
+
+// validateBasePath checks base_path in a configuration update
+func validateBasePath(values map[string]interface{}) error {
+	raw, ok := values["base_path"]
+	if !ok || raw == nil {
+		return nil
+	}
+	path, ok := raw.(string)
+	if !ok {
+		return &ValidationError{Field: "base_path", Message: "must be a string"}
+	}
+	if _, err := NormalizeBasePath(path); err != nil {
+		return &ValidationError{Field: "base_path", Message: err.Error()}
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/scottwalter/axeos-dashboard/internal/logger"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
 )
 
 // Config represents the application configuration
@@ -20,32 +23,175 @@ type Config struct {
 	MiningCoreEnabled        bool                     `json:"mining_core_enabled"`
 	MiningCoreURL            []map[string]string      `json:"mining_core_url"`
 	MiningCoreDisplayFields  interface{}              `json:"mining_core_display_fields"` // Can be []string or complex nested structure
+	MiningCoreCacheSeconds   int                      `json:"mining_core_cache_seconds"`  // Share pool responses for this long (15 by default, negative disables)
 	CryptNodesEnabled        bool                     `json:"cryptNodesEnabled"`
 	CryptoNodes              interface{}              `json:"cryptoNodes"` // Crypto node configuration
+	RPCCacheSeconds          int                      `json:"rpc_cache_seconds"` // Share node RPC results for this long (15 by default, negative disables)
+	NodeDisplayFields        map[string]NodeDisplayFields `json:"node_display_fields,omitempty"` // Crypto node card fields per node type, replacing the built-in defaults
 	DisableAuthentication    bool                     `json:"disable_authentication"`
 	DisableSettings          bool                     `json:"disable_settings"`
 	DisableConfigurations    bool                     `json:"disable_configurations"`
 	CookieMaxAge             int                      `json:"cookie_max_age"`
+	JWTExpiry                string                   `json:"jwt_expiry"` // Session token lifetime, e.g. "1h" or "7d"
+	RefreshTokenExpiry       string                   `json:"refresh_token_expiry,omitempty"` // Sliding session lifetime kept alive by refresh tokens; empty disables them
+	AuthProvider             string                   `json:"auth_provider,omitempty"` // "file" (access.json, default) or "header"
+	AuthHeader               *AuthHeaderSettings      `json:"auth_header,omitempty"`   // Settings of the header provider
 	ConfigurationOutdated    bool                     `json:"configuration_outdated"`
 	AxeosAPI                 map[string]string        `json:"axeos_api"`
+	BasePath                 string                   `json:"base_path,omitempty"` // Subpath the dashboard is served below behind a reverse proxy, e.g. "/axeos"
+
+	// Failed logins that lock a username or client IP
+	LoginLockout *LoginLockout `json:"login_lockout,omitempty"`
+
+	// Reminders to rotate the JWT signing key and user passwords
+	CredentialRotation *CredentialRotation `json:"credential_rotation,omitempty"`
+
+	// Weekly report of devices whose efficiency (J/TH) is degrading
+	EfficiencyReport *EfficiencyReport `json:"efficiency_report,omitempty"`
+
+	// Brown-out and power supply degradation alerts from device input voltage and current
+	PowerMonitoring *PowerMonitoring `json:"power_monitoring,omitempty"`
+
+	// Nominal wattage per device or model and the fleet's household power cap
+	PowerBudget *PowerBudget `json:"power_budget,omitempty"`
+
+	// Title and themes of the shareable statistics image
+	StatsCard *StatsCard `json:"stats_card,omitempty"`
+
+	// Read-only fleet status for SSH clients (changes apply after a restart)
+	SSHStatus *SSHStatus `json:"ssh_status,omitempty"`
+
+	// Developer option: simulated device failures, slow and malformed responses
+	FaultInjection *FaultInjection `json:"fault_injection,omitempty"`
+
+	// Timeout and retry policy for requests to devices and pools
+	HTTPClient *HTTPClientSettings `json:"http_client,omitempty"`
+
+	// Sent with every request to devices, pools and nodes, e.g. for an
+	// authenticating proxy. The user agent defaults to "axeos-dashboard".
+	UserAgent       string            `json:"user_agent,omitempty"`
+	OutboundHeaders map[string]string `json:"outbound_headers,omitempty"`
+
+	// HTTPS. Relative certificate paths are resolved against the config
+	// directory; with tls_self_signed a certificate is generated when none exists.
+	TLSEnabled    bool   `json:"tls_enabled"`
+	TLSCertPath   string `json:"tls_cert_path"`
+	TLSKeyPath    string `json:"tls_key_path"`
+	TLSSelfSigned bool   `json:"tls_self_signed"`
+
+	// Let's Encrypt certificates for a public domain (overrides the TLS
+	// certificate paths). HTTP-01 challenges are answered on acme_http_port.
+	ACMEEnabled  bool     `json:"acme_enabled"`
+	ACMEDomains  []string `json:"acme_domains"`
+	ACMEEmail    string   `json:"acme_email"`
+	ACMEHTTPPort int      `json:"acme_http_port"` // Defaults to 80
+	ACMEStaging  bool     `json:"acme_staging"`   // Use the Let's Encrypt staging CA for testing
+
+	// Public status badges (opt-in)
+	BadgesEnabled  bool     `json:"badges_enabled"`
+	BadgeInstances []string `json:"badge_instances"` // Empty means all instances
+
+	// Prometheus exporter at /metrics. Clients in the allowlist (IPs or CIDRs)
+	// skip JWT authentication; everyone else needs a session.
+	PrometheusEnabled   bool     `json:"prometheus_enabled"`
+	PrometheusAllowlist []string `json:"prometheus_allowlist"`
+
+	// Serve each device's own web UI at /device/{id}/ (opt-in, admins only)
+	// The device's pages run on the dashboard origin with the admin's session,
+	// so only enable it for trusted devices on a trusted network
+	DeviceProxyEnabled bool `json:"device_proxy_enabled"`
+
+	// Saved dashboard layouts served by /api/dashboards
+	Dashboards []Dashboard `json:"dashboards,omitempty"`
+
+	// Messages of the day shown as banners, managed through /api/motd
+	MOTD []Message `json:"motd,omitempty"`
+
+	// Devices the dashboard cannot reach that send their system info to
+	// /api/push/{id} instead of being polled (tokens in push.json)
+	PushDevices []string `json:"push_devices,omitempty"`
+
+	// Public pools (public-pool.io, CKPool solo, OCEAN) whose stats are read
+	// for a payout address, alongside the Mining Core pools
+	PublicPools []PublicPool `json:"public_pools,omitempty"`
+
+	// Payout addresses watched for incoming pool and coinbase payments
+	PayoutAddresses []PayoutAddress `json:"payout_addresses,omitempty"`
+
+	// Named stratum pools pushed to devices by /api/instances/pool-switch
+	PoolProfiles []PoolProfile `json:"pool_profiles,omitempty"`
+
+	// Planned maintenance, published in the iCal feed
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows"`
 
 	// Data collection settings
 	DataCollectionEnabled    bool `json:"data_collection_enabled"`
 	CollectionIntervalSeconds int  `json:"collection_interval_seconds"`
 	DataRetentionDays        int  `json:"data_retention_days"`
 
+	// Directory holding metrics.db, or ":memory:" for a temporary database that
+	// is deleted on shutdown (read when data collection starts)
+	DataPath string `json:"data_path,omitempty"`
+
+	// Timestamped copies of config.json kept in config/backups, taken before
+	// every change (20 by default, negative disables)
+	ConfigBackups int `json:"config_backups"`
+
+	// How long events, alerts and audit records are kept, independently of metrics
+	EventRetention *EventRetention `json:"event_retention,omitempty"`
+
+	// Stop collection and checkpoint the database when the UPS reports power loss
+	PowerEvents *PowerEvents `json:"power_events,omitempty"`
+
+	// Serve the scheduler's last sample from /api/systems/info when it is no
+	// older than this, instead of polling the device again (0 disables)
+	SystemsInfoMaxAgeSeconds int `json:"systems_info_max_age_seconds"`
+
+	// Unit for temperatures returned by aggregation and alert APIs ("C" or "F")
+	TemperatureUnit string `json:"temperature_unit"`
+
+	// Carbon footprint estimate (gCO2/kWh, or live from Electricity Maps with
+	// the API key in electricityMaps.json)
+	CarbonIntensity     float64 `json:"carbon_intensity"`
+	ElectricityMapsZone string  `json:"electricity_maps_zone"`
+
+	// Electricity price (flat or time-of-use) for energy cost and profitability
+	Electricity *Electricity `json:"electricity,omitempty"`
+
+	// Price and network difficulty sources for profitability
+	Market *Market `json:"market,omitempty"`
+
+	// Alert notifications (webhook, Discord, Telegram)
+	Notifications *Notifications `json:"notifications,omitempty"`
+
+	// Server log level, format and destination
+	Logging *Logging `json:"logging,omitempty"`
+
+	// Ambient temperature source, collected alongside miner samples
+	AmbientSource *AmbientSource `json:"ambient_source,omitempty"`
+
+	// Disk space guard for the metrics database
+	DiskMinFreeMB     int    `json:"disk_min_free_mb"`     // Minimum free space on the data volume
+	DatabaseMaxSizeMB int    `json:"database_max_size_mb"` // Maximum size of metrics.db (0 = unlimited)
+	DiskGuardAction   string `json:"disk_guard_action"`    // "prune" (default) or "pause"
+
 	// NOTE: RPC credentials are stored in a separate rpcConfig.json file
 	// and should NEVER be exposed through the API or stored in config.json
 
 	mu sync.RWMutex
 }
 
+// ChangeListener is called after the configuration has been updated and reloaded
+type ChangeListener func(cfg *Config)
+
 // Manager handles configuration loading and hot-reloading
 type Manager struct {
-	config     *Config
-	configPath string
-	mu         sync.RWMutex
-	log        *logger.Logger
+	config      *Config
+	configPath  string
+	mu          sync.RWMutex
+	log         *logger.Logger
+	listeners   []ChangeListener
+	listenersMu sync.Mutex
 }
 
 var (
@@ -84,10 +230,43 @@ func (m *Manager) LoadConfig() (*Config, error) {
 	// Set default values if not present
 	config.ConfigurationOutdated = false
 
+	// Keys of the Node.js dashboard are still read, and flag the file as outdated
+	legacy, err := readLegacyKeys(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
+	if len(legacy) > 0 {
+		m.log.Warn("config.json uses the legacy keys %s; they are renamed when the configuration is next saved", strings.Join(legacy, ", "))
+		config.ConfigurationOutdated = true
+	}
+
 	// Apply defaults for missing fields
+	if config.JWTExpiry != "" {
+		if lifetime, err := ParseLifetime(config.JWTExpiry); err != nil || lifetime < MinTokenLifetime {
+			m.log.Warn("Ignoring invalid jwt_expiry %q", config.JWTExpiry)
+			config.JWTExpiry = ""
+		} else if config.CookieMaxAge == 0 {
+			config.CookieMaxAge = int(lifetime.Seconds()) // Cookie matches token lifetime
+		} else if config.CookieMaxAge > int(lifetime.Seconds()) {
+			m.log.Warn("cookie_max_age (%ds) exceeds jwt_expiry (%s); cookies will be limited to the token lifetime", config.CookieMaxAge, config.JWTExpiry)
+		}
+	}
 	if config.CookieMaxAge == 0 {
 		config.CookieMaxAge = 3600 // 1 hour default
 	}
+	if config.RefreshTokenExpiry != "" {
+		if lifetime, err := ParseLifetime(config.RefreshTokenExpiry); err != nil || lifetime < MinTokenLifetime {
+			m.log.Warn("Ignoring invalid refresh_token_expiry %q", config.RefreshTokenExpiry)
+			config.RefreshTokenExpiry = ""
+		}
+	}
+
+	if basePath, err := NormalizeBasePath(config.BasePath); err != nil {
+		m.log.Warn("Ignoring invalid base_path %q: %v", config.BasePath, err)
+		config.BasePath = ""
+	} else {
+		config.BasePath = basePath
+	}
 
 	// Apply defaults for data collection
 	if config.CollectionIntervalSeconds == 0 {
@@ -96,6 +275,80 @@ func (m *Manager) LoadConfig() (*Config, error) {
 	if config.DataRetentionDays == 0 {
 		config.DataRetentionDays = 30 // 30 days default
 	}
+	if config.DiskMinFreeMB == 0 {
+		config.DiskMinFreeMB = 200 // 200 MB default
+	}
+	if config.MiningCoreCacheSeconds == 0 {
+		config.MiningCoreCacheSeconds = 15
+	}
+	if config.RPCCacheSeconds == 0 {
+		config.RPCCacheSeconds = 15
+	}
+	if config.ConfigBackups == 0 {
+		config.ConfigBackups = DefaultConfigBackups
+	}
+	if err := checkOutboundHeaders(config.UserAgent, config.OutboundHeaders); err != nil {
+		m.log.Warn("Ignoring user_agent and outbound_headers: %v", err)
+		config.UserAgent = ""
+		config.OutboundHeaders = nil
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+	if config.FaultInjection.IsEnabled() {
+		m.log.Warn("Fault injection is enabled: requests to devices fail, slow down or return malformed JSON on purpose")
+	}
+	if config.ACMEHTTPPort == 0 {
+		config.ACMEHTTPPort = 80
+	}
+	if config.ACMEEnabled && len(config.ACMEDomains) == 0 {
+		m.log.Warn("acme_enabled is set but acme_domains is empty; Let's Encrypt is disabled")
+		config.ACMEEnabled = false
+	}
+	if config.DiskGuardAction != "pause" {
+		config.DiskGuardAction = "prune"
+	}
+	if unit, ok := NormalizeTemperatureUnit(config.TemperatureUnit); ok {
+		config.TemperatureUnit = unit
+	} else {
+		if config.TemperatureUnit != "" {
+			m.log.Warn("Ignoring invalid temperature_unit %q, using Celsius", config.TemperatureUnit)
+		}
+		config.TemperatureUnit = UnitCelsius
+	}
+	if config.AmbientSource != nil {
+		if err := config.AmbientSource.normalize(); err != nil {
+			m.log.Warn("Ignoring ambient_source: %v", err)
+			config.AmbientSource = nil
+		}
+	}
+
+	if config.Market == nil {
+		config.Market = &Market{}
+	}
+	if err := config.Market.normalize(); err != nil {
+		m.log.Warn("Ignoring %v", err)
+	}
+	if config.Notifications != nil {
+		if err := config.Notifications.validate(); err != nil {
+			m.log.Warn("Ignoring %v", err)
+			config.Notifications = nil
+		} else if config.Notifications.OverheatTemperature == 0 {
+			config.Notifications.OverheatTemperature = DefaultOverheatTemperature
+		}
+	}
+	if config.Electricity != nil {
+		if err := config.Electricity.validate(); err != nil {
+			m.log.Warn("Ignoring %v", err)
+			config.Electricity = nil
+		}
+	}
+	if config.Logging != nil {
+		if err := config.Logging.validate(); err != nil {
+			m.log.Warn("Ignoring %v", err)
+			config.Logging = nil
+		}
+	}
 
 	m.config = &config
 	m.log.Info("Configuration loaded successfully")
@@ -103,10 +356,26 @@ func (m *Manager) LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
-// ReloadConfig reloads the configuration from file
+// MiningCoreCacheTTL returns how long Mining Core responses are shared
+func (c *Config) MiningCoreCacheTTL() time.Duration {
+	return time.Duration(c.MiningCoreCacheSeconds) * time.Second
+}
+
+// RPCCacheTTL returns how long crypto node RPC results are shared
+func (c *Config) RPCCacheTTL() time.Duration {
+	return time.Duration(c.RPCCacheSeconds) * time.Second
+}
+
+// ReloadConfig reloads the configuration from file and notifies the change
+// listeners
 func (m *Manager) ReloadConfig() (*Config, error) {
 	m.log.Info("Reloading configuration...")
-	return m.LoadConfig()
+	cfg, err := m.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	m.notifyListeners(cfg)
+	return cfg, nil
 }
 
 // GetConfig returns the current configuration
@@ -116,6 +385,24 @@ func (m *Manager) GetConfig() *Config {
 	return m.config
 }
 
+// OnChange registers a listener that is notified after every configuration update
+func (m *Manager) OnChange(listener ChangeListener) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// notifyListeners calls all registered change listeners with the new configuration
+func (m *Manager) notifyListeners(cfg *Config) {
+	m.listenersMu.Lock()
+	listeners := append([]ChangeListener(nil), m.listeners...)
+	m.listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		listener(cfg)
+	}
+}
+
 // GetConfigDir returns the configuration directory path
 func (m *Manager) GetConfigDir() string {
 	return filepath.Dir(m.configPath)
@@ -137,10 +424,16 @@ func (m *Manager) UpdateConfig(updates map[string]interface{}) error {
 		return fmt.Errorf("error parsing config file: %w", err)
 	}
 
-	// Apply updates
+	// Apply updates, saving legacy keys under their current names
+	normalizeLegacyKeys(updates)
 	for key, value := range updates {
 		currentConfig[key] = value
 	}
+	normalizeLegacyKeys(currentConfig)
+
+	if err := validateValues(currentConfig); err != nil {
+		return err
+	}
 
 	// Write back to file
 	updatedData, err := json.MarshalIndent(currentConfig, "", "    ")
@@ -148,24 +441,95 @@ func (m *Manager) UpdateConfig(updates map[string]interface{}) error {
 		return fmt.Errorf("error marshaling config: %w", err)
 	}
 
-	if err := os.WriteFile(m.configPath, updatedData, 0644); err != nil {
-		return fmt.Errorf("error writing config file: %w", err)
+	if err := m.writeConfigFile(data, updatedData); err != nil {
+		return err
 	}
 
 	// Reload config into memory (unlock first to avoid deadlock)
 	m.mu.Unlock()
-	_, err = m.LoadConfig()
+	cfg, err := m.LoadConfig()
+	if err == nil {
+		m.notifyListeners(cfg)
+	}
 	m.mu.Lock() // Re-lock before defer unlocks
 	return err
 }
 
-// CheckConfigFilesExist checks if all required configuration files exist
+// validateValues checks a complete configuration, as written to config.json
+func validateValues(currentConfig map[string]interface{}) error {
+	// Reject updates that leave session lifetimes inconsistent
+	if err := validateSessionSettings(currentConfig); err != nil {
+		return err
+	}
+	if err := validateDashboards(currentConfig); err != nil {
+		return err
+	}
+	if err := validateMOTD(currentConfig); err != nil {
+		return err
+	}
+	if err := validatePushDevices(currentConfig); err != nil {
+		return err
+	}
+	if err := validatePublicPools(currentConfig); err != nil {
+		return err
+	}
+	if err := validatePayoutAddresses(currentConfig); err != nil {
+		return err
+	}
+	if err := validatePowerMonitoring(currentConfig); err != nil {
+		return err
+	}
+	if err := validatePowerBudget(currentConfig); err != nil {
+		return err
+	}
+	if err := validateStatsCard(currentConfig); err != nil {
+		return err
+	}
+	if err := validateSSHStatus(currentConfig); err != nil {
+		return err
+	}
+	if err := validateFaultInjection(currentConfig); err != nil {
+		return err
+	}
+	if err := validatePoolProfiles(currentConfig); err != nil {
+		return err
+	}
+	if err := validateNodeDisplayFields(currentConfig); err != nil {
+		return err
+	}
+	if err := validateNotifications(currentConfig); err != nil {
+		return err
+	}
+	if err := validateOutboundHeaders(currentConfig); err != nil {
+		return err
+	}
+	if err := validateBasePath(currentConfig); err != nil {
+		return err
+	}
+	if err := validateLogging(currentConfig); err != nil {
+		return err
+	}
+	if err := validateElectricity(currentConfig); err != nil {
+		return err
+	}
+	if raw, ok := currentConfig["temperature_unit"].(string); ok && raw != "" {
+		if _, valid := NormalizeTemperatureUnit(raw); !valid {
+			return &ValidationError{Field: "temperature_unit", Message: "must be \"C\" or \"F\""}
+		}
+	}
+	return nil
+}
+
+// CheckConfigFilesExist checks if all required configuration files exist.
+// Secret files may also be provided by a secret backend instead of the config directory.
 func CheckConfigFilesExist(configDir string) bool {
-	requiredFiles := []string{"config.json", "access.json", "jsonWebTokenKey.json"}
+	if _, err := os.Stat(filepath.Join(configDir, "config.json")); os.IsNotExist(err) {
+		return false
+	}
 
-	for _, file := range requiredFiles {
-		path := filepath.Join(configDir, file)
-		if _, err := os.Stat(path); os.IsNotExist(err) {
+	requiredSecrets := []string{"access.json", "jsonWebTokenKey.json"}
+	for _, name := range requiredSecrets {
+		if !secrets.Exists(configDir, name) {
 			return false
 		}
 	}
@@ -0,0 +1,27 @@
+package config
+
+// DefaultCredentialReminderDays is how often a rotation reminder is repeated
+// while a credential stays overdue
+const DefaultCredentialReminderDays = 7
+
+// CredentialRotation sets how many days the JWT signing key and user
+// passwords may be used before a rotation reminder is raised. Zero (the
+// default) disables a check.
+type CredentialRotation struct {
+	JWTKeyDays   int `json:"jwt_key_days,omitempty"`
+	PasswordDays int `json:"password_days,omitempty"`
+	ReminderDays int `json:"reminder_days,omitempty"` // Defaults to 7
+}
+
+// Enabled reports whether any credential age is checked
+func (r *CredentialRotation) Enabled() bool {
+	return r != nil && (r.JWTKeyDays > 0 || r.PasswordDays > 0)
+}
+
+// ReminderInterval returns the days between reminders for an overdue credential
+func (r *CredentialRotation) ReminderInterval() int {
+	if r == nil || r.ReminderDays <= 0 {
+		return DefaultCredentialReminderDays
+	}
+	return r.ReminderDays
+}
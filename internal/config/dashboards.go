@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Dashboard card types
+const (
+	CardFleet  = "fleet"  // Fleet totals (hashrate, power, shares)
+	CardDevice = "device" // One AxeOS device
+	CardPool   = "pool"   // One Mining Core pool
+	CardNode   = "node"   // One crypto node
+	CardChart  = "chart"  // History chart of one device's fields
+)
+
+// MaxCardWidth is the number of columns in the dashboard grid
+const MaxCardWidth = 12
+
+// DashboardCard is one card on a dashboard, in display order
+type DashboardCard struct {
+	Type   string   `json:"type"`
+	Source string   `json:"source,omitempty"` // Device, pool or node id; unused for fleet cards
+	Title  string   `json:"title,omitempty"`
+	Fields []string `json:"fields,omitempty"` // Panels to show; empty means the card's defaults
+	Width  int      `json:"width,omitempty"`  // Grid columns (1-12); 0 lets the page decide
+}
+
+// Dashboard is a named, saved arrangement of cards
+type Dashboard struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Cards       []DashboardCard `json:"cards"`
+}
+
+// Validate checks the structure of a dashboard. Sources are checked against
+// the configured devices by the dashboards API.
+func (d Dashboard) Validate() error {
+	if strings.TrimSpace(d.Name) == "" {
+		return &ValidationError{Field: "dashboards", Message: "every dashboard needs a name"}
+	}
+	for i, card := range d.Cards {
+		switch card.Type {
+		case CardFleet:
+		case CardDevice, CardPool, CardNode, CardChart:
+			if card.Source == "" {
+				return &ValidationError{Field: "dashboards", Message: fmt.Sprintf("%s card %d needs a source", d.Name, i+1)}
+			}
+		default:
+			return &ValidationError{Field: "dashboards", Message: fmt.Sprintf("%s card %d has unknown type %q", d.Name, i+1, card.Type)}
+		}
+		if card.Width < 0 || card.Width > MaxCardWidth {
+			return &ValidationError{Field: "dashboards", Message: fmt.Sprintf("%s card %d width must be between 1 and %d", d.Name, i+1, MaxCardWidth)}
+		}
+	}
+	return nil
+}
+
+// validateDashboards checks the dashboards in a configuration update and that names are unique
+func validateDashboards(values map[string]interface{}) error {
+	raw, ok := values["dashboards"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "dashboards", Message: err.Error()}
+	}
+	var dashboards []Dashboard
+	if err := json.Unmarshal(data, &dashboards); err != nil {
+		return &ValidationError{Field: "dashboards", Message: "must be a list of dashboards"}
+	}
+
+	names := map[string]bool{}
+	for _, d := range dashboards {
+		if err := d.Validate(); err != nil {
+			return err
+		}
+		key := strings.ToLower(d.Name)
+		if names[key] {
+			return &ValidationError{Field: "dashboards", Message: fmt.Sprintf("duplicate dashboard name %q", d.Name)}
+		}
+		names[key] = true
+	}
+	return nil
+}
+
+// DefaultDashboard is served when no dashboards are configured: fleet totals
+// followed by every configured device and pool
+func DefaultDashboard(cfg *Config) Dashboard {
+	dashboard := Dashboard{
+		Name:  "Overview",
+		Cards: []DashboardCard{{Type: CardFleet}},
+	}
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			dashboard.Cards = append(dashboard.Cards, DashboardCard{Type: CardDevice, Source: name})
+		}
+	}
+	if cfg.MiningCoreEnabled {
+		for _, pool := range cfg.MiningCoreURL {
+			for name := range pool {
+				dashboard.Cards = append(dashboard.Cards, DashboardCard{Type: CardPool, Source: name})
+			}
+		}
+	}
+	for _, pool := range cfg.PublicPools {
+		dashboard.Cards = append(dashboard.Cards, DashboardCard{Type: CardPool, Source: pool.Name})
+	}
+	return dashboard
+}
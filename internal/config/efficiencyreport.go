@@ -0,0 +1,41 @@
+package config
+
+// Defaults of the weekly efficiency report
+const (
+	DefaultEfficiencyReportWeeks     = 4
+	DefaultEfficiencyReportThreshold = 3.0 // Percent J/TH increase per week
+	maxEfficiencyReportWeeks         = 26
+)
+
+// EfficiencyReport fits a trend to each device's daily efficiency (J/TH)
+// and reports devices whose efficiency worsens faster than the threshold,
+// a sign of dust buildup or a failing fan
+type EfficiencyReport struct {
+	Enabled          bool    `json:"enabled"`
+	Weeks            int     `json:"weeks,omitempty"`             // Weeks of daily rollups the trend is fitted to; defaults to 4, at most 26
+	ThresholdPercent float64 `json:"threshold_percent,omitempty"` // J/TH increase per week that flags a device; defaults to 3
+}
+
+// IsEnabled reports whether the weekly report runs
+func (r *EfficiencyReport) IsEnabled() bool {
+	return r != nil && r.Enabled
+}
+
+// TrendWeeks returns the weeks of data the trend is fitted to
+func (r *EfficiencyReport) TrendWeeks() int {
+	if r == nil || r.Weeks <= 0 {
+		return DefaultEfficiencyReportWeeks
+	}
+	if r.Weeks > maxEfficiencyReportWeeks {
+		return maxEfficiencyReportWeeks
+	}
+	return r.Weeks
+}
+
+// Threshold returns the weekly J/TH increase, in percent, that flags a device
+func (r *EfficiencyReport) Threshold() float64 {
+	if r == nil || r.ThresholdPercent <= 0 {
+		return DefaultEfficiencyReportThreshold
+	}
+	return r.ThresholdPercent
+}
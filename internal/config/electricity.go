@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ElectricityTier is a time-of-use price that applies between two times of day
+type ElectricityTier struct {
+	Name        string  `json:"name,omitempty"` // e.g. "peak" or "night"
+	Start       string  `json:"start"`          // "HH:MM", local time, inclusive
+	End         string  `json:"end"`            // "HH:MM", exclusive; before start for tiers that span midnight
+	PricePerKWh float64 `json:"price_per_kwh"`
+}
+
+// Electricity configures the electricity price used for energy cost, in the market currency
+type Electricity struct {
+	PricePerKWh float64           `json:"price_per_kwh"`   // Applies outside of every tier
+	Tiers       []ElectricityTier `json:"tiers,omitempty"` // The first tier covering a time wins
+}
+
+// parseTimeOfDay parses "HH:MM" into minutes after midnight
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// covers reports whether the tier applies at the given minute of the day
+func (t ElectricityTier) covers(minute int) bool {
+	start, err := parseTimeOfDay(t.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseTimeOfDay(t.End)
+	if err != nil {
+		return false
+	}
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end // Spans midnight
+}
+
+// PriceAt returns the price per kWh at a moment, or 0 when no price is configured
+func (e *Electricity) PriceAt(t time.Time) float64 {
+	if e == nil {
+		return 0
+	}
+	t = t.Local()
+	minute := t.Hour()*60 + t.Minute()
+	for _, tier := range e.Tiers {
+		if tier.covers(minute) {
+			return tier.PricePerKWh
+		}
+	}
+	return e.PricePerKWh
+}
+
+// validate checks prices and tier times
+func (e *Electricity) validate() error {
+	if e.PricePerKWh < 0 {
+		return &ValidationError{Field: "electricity", Message: "price_per_kwh must not be negative"}
+	}
+	for i, tier := range e.Tiers {
+		name := tier.Name
+		if name == "" {
+			name = fmt.Sprintf("tier %d", i+1)
+		}
+		if tier.PricePerKWh < 0 {
+			return &ValidationError{Field: "electricity", Message: fmt.Sprintf("%s: price_per_kwh must not be negative", name)}
+		}
+		if _, err := parseTimeOfDay(tier.Start); err != nil {
+			return &ValidationError{Field: "electricity", Message: fmt.Sprintf("%s: start: %v", name, err)}
+		}
+		if _, err := parseTimeOfDay(tier.End); err != nil {
+			return &ValidationError{Field: "electricity", Message: fmt.Sprintf("%s: end: %v", name, err)}
+		}
+		if tier.Start == tier.End {
+			return &ValidationError{Field: "electricity", Message: fmt.Sprintf("%s: start and end must differ", name)}
+		}
+	}
+	return nil
+}
+
+// validateElectricity checks the electricity section of a configuration update
+func validateElectricity(values map[string]interface{}) error {
+	raw, ok := values["electricity"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "electricity", Message: err.Error()}
+	}
+	var electricity Electricity
+	if err := json.Unmarshal(data, &electricity); err != nil {
+		return &ValidationError{Field: "electricity", Message: err.Error()}
+	}
+	return electricity.validate()
+}
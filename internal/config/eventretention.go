@@ -0,0 +1,30 @@
+package config
+
+// EventRetention is how many days each category of the events table is kept.
+// Zero (the default) keeps a category forever.
+type EventRetention struct {
+	EventsDays int `json:"events_days,omitempty"` // Found blocks, best difficulties and other milestones
+	AlertsDays int `json:"alerts_days,omitempty"`
+	AuditDays  int `json:"audit_days,omitempty"` // Restarts and other actions taken by users
+}
+
+// Days returns the retention of an event category ("events", "alerts" or
+// "audit"), or 0 to keep it forever
+func (r *EventRetention) Days(category string) int {
+	if r == nil {
+		return 0
+	}
+	var days int
+	switch category {
+	case "events":
+		days = r.EventsDays
+	case "alerts":
+		days = r.AlertsDays
+	case "audit":
+		days = r.AuditDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return days
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// DefaultFaultSlowMs is the delay of a simulated slow response
+const DefaultFaultSlowMs = 3000
+
+// FaultInjection is a developer option that makes requests to devices fail,
+// respond slowly or return malformed JSON at random, in data collection, the
+// dashboard API and the device proxy, to exercise alert rules, retries and
+// error states without unplugging hardware. Never enable it in production.
+type FaultInjection struct {
+	Enabled       bool     `json:"enabled"`
+	Instances     []string `json:"instances,omitempty"`      // Device names; all devices when empty
+	FailureRate   float64  `json:"failure_rate,omitempty"`   // Share of requests failing to connect, 0-1
+	SlowRate      float64  `json:"slow_rate,omitempty"`      // Share of requests delayed by slow_ms, 0-1
+	SlowMs        int      `json:"slow_ms,omitempty"`        // Default 3000
+	MalformedRate float64  `json:"malformed_rate,omitempty"` // Share of JSON responses cut short, 0-1
+}
+
+// IsEnabled reports whether faults are injected at all
+func (f *FaultInjection) IsEnabled() bool {
+	return f != nil && f.Enabled
+}
+
+// Applies reports whether faults are injected into requests to an instance
+func (f *FaultInjection) Applies(instance string) bool {
+	if !f.IsEnabled() {
+		return false
+	}
+	return len(f.Instances) == 0 || slices.Contains(f.Instances, instance)
+}
+
+// SlowDelayMs returns how long a slow response is delayed
+func (f *FaultInjection) SlowDelayMs() int {
+	if f == nil || f.SlowMs <= 0 {
+		return DefaultFaultSlowMs
+	}
+	return f.SlowMs
+}
+
+// validateFaultInjection checks that the rates are shares and the delay is sane
+func validateFaultInjection(values map[string]interface{}) error {
+	raw, ok := values["fault_injection"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "fault_injection", Message: err.Error()}
+	}
+	var faults FaultInjection
+	if err := json.Unmarshal(data, &faults); err != nil {
+		return &ValidationError{Field: "fault_injection", Message: "must be an object"}
+	}
+	for _, rate := range []float64{faults.FailureRate, faults.SlowRate, faults.MalformedRate} {
+		if rate < 0 || rate > 1 {
+			return &ValidationError{Field: "fault_injection", Message: "failure_rate, slow_rate and malformed_rate must be between 0 and 1"}
+		}
+	}
+	if faults.SlowMs < 0 || faults.SlowMs > 120000 {
+		return &ValidationError{Field: "fault_injection", Message: "slow_ms must be between 0 and 120000"}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import "time"
+
+// Defaults for requests to devices, pools and nodes
+const (
+	DefaultHTTPTimeout = 10 * time.Second
+	DefaultHTTPRetries = 1
+	DefaultHTTPBackoff = 250 * time.Millisecond
+	MaxHTTPRetries     = 5
+
+	// ESP32-based miners drop connections when several requests arrive at
+	// once, so the dashboard keeps few sockets open to each of them
+	DefaultHTTPMaxInFlight     = 2
+	DefaultHTTPMaxConnsPerHost = 4
+	DefaultHTTPMaxIdlePerHost  = 2
+)
+
+// HTTPClientPolicy is the timeout and retry policy for outgoing requests.
+// Zero values fall back to the global policy, then to the defaults.
+type HTTPClientPolicy struct {
+	TimeoutSeconds float64 `json:"timeout_seconds,omitempty"`
+	Retries        *int    `json:"retries,omitempty"`       // Extra attempts after a failed GET
+	BackoffMillis  int     `json:"backoff_ms,omitempty"`    // Delay before the first retry, doubled for each further one
+	MaxInFlight    int     `json:"max_in_flight,omitempty"` // Requests sent to the instance at the same time; others wait
+}
+
+// HTTPClientSettings is the global policy plus overrides per instance name
+type HTTPClientSettings struct {
+	HTTPClientPolicy
+	MaxConnsPerHost     int                         `json:"max_conns_per_host,omitempty"`      // Open connections per host, including the device web UI proxy
+	MaxIdleConnsPerHost int                         `json:"max_idle_conns_per_host,omitempty"` // Connections kept open for reuse per host
+	Instances           map[string]HTTPClientPolicy `json:"instances,omitempty"`
+}
+
+// Timeout returns the request timeout
+func (p HTTPClientPolicy) Timeout() time.Duration {
+	if p.TimeoutSeconds <= 0 {
+		return DefaultHTTPTimeout
+	}
+	return time.Duration(p.TimeoutSeconds * float64(time.Second))
+}
+
+// RetryCount returns how many times a failed GET is retried
+func (p HTTPClientPolicy) RetryCount() int {
+	switch {
+	case p.Retries == nil || *p.Retries < 0:
+		return DefaultHTTPRetries
+	case *p.Retries > MaxHTTPRetries:
+		return MaxHTTPRetries
+	}
+	return *p.Retries
+}
+
+// Backoff returns the delay before the first retry
+func (p HTTPClientPolicy) Backoff() time.Duration {
+	if p.BackoffMillis <= 0 {
+		return DefaultHTTPBackoff
+	}
+	return time.Duration(p.BackoffMillis) * time.Millisecond
+}
+
+// InFlightLimit returns how many requests may be sent to the instance at the same time
+func (p HTTPClientPolicy) InFlightLimit() int {
+	if p.MaxInFlight <= 0 {
+		return DefaultHTTPMaxInFlight
+	}
+	return p.MaxInFlight
+}
+
+// HTTPConnLimits returns the per-host connection limits of the shared transport
+func (c *Config) HTTPConnLimits() (maxConns, maxIdle int) {
+	maxConns, maxIdle = DefaultHTTPMaxConnsPerHost, DefaultHTTPMaxIdlePerHost
+	if c.HTTPClient == nil {
+		return maxConns, maxIdle
+	}
+	if c.HTTPClient.MaxConnsPerHost > 0 {
+		maxConns = c.HTTPClient.MaxConnsPerHost
+	}
+	if c.HTTPClient.MaxIdleConnsPerHost > 0 {
+		maxIdle = c.HTTPClient.MaxIdleConnsPerHost
+	}
+	return maxConns, maxIdle
+}
+
+// HTTPPolicy returns the policy for requests to a device, pool or node,
+// applying its override on top of the global settings
+func (c *Config) HTTPPolicy(instance string) HTTPClientPolicy {
+	if c.HTTPClient == nil {
+		return HTTPClientPolicy{}
+	}
+	policy := c.HTTPClient.HTTPClientPolicy
+	override, ok := c.HTTPClient.Instances[instance]
+	if !ok {
+		return policy
+	}
+	if override.TimeoutSeconds > 0 {
+		policy.TimeoutSeconds = override.TimeoutSeconds
+	}
+	if override.Retries != nil {
+		policy.Retries = override.Retries
+	}
+	if override.BackoffMillis > 0 {
+		policy.BackoffMillis = override.BackoffMillis
+	}
+	if override.MaxInFlight > 0 {
+		policy.MaxInFlight = override.MaxInFlight
+	}
+	return policy
+}
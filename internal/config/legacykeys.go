@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// LegacyKeys maps the config.json keys of the Node.js dashboard to their
+// current names. Both are read; saving the configuration keeps only the
+// current name.
+var LegacyKeys = map[string]string{
+	"bitaxe_instances":         "axeos_instances",
+	"bitaxe_api":               "axeos_api",
+	"bitaxe_dashboard_version": "axeos_dashboard_version",
+}
+
+// readLegacyKeys decodes the legacy keys of a config.json into the fields of
+// their current names that the file does not set, and returns the legacy
+// keys found, sorted
+func readLegacyKeys(data []byte, config *Config) ([]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var found []string
+	for legacy, current := range LegacyKeys {
+		value, ok := raw[legacy]
+		if !ok {
+			continue
+		}
+		found = append(found, legacy)
+		if _, ok := raw[current]; ok {
+			continue // The current key wins
+		}
+		field, err := json.Marshal(map[string]json.RawMessage{current: value})
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(field, config); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+// normalizeLegacyKeys renames the legacy keys of decoded configuration
+// values to their current names; a current key already present wins
+func normalizeLegacyKeys(values map[string]interface{}) {
+	for legacy, current := range LegacyKeys {
+		value, ok := values[legacy]
+		if !ok {
+			continue
+		}
+		delete(values, legacy)
+		if _, ok := values[current]; !ok {
+			values[current] = value
+		}
+	}
+}
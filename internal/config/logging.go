@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Log outputs
+const (
+	LogOutputStdout = "stdout"
+	LogOutputFile   = "file"
+	LogOutputBoth   = "both" // stdout and the log file
+)
+
+// DefaultLogFile is the log file, relative to the install directory, when output includes a file
+const DefaultLogFile = "logs/axeos-dashboard.log"
+
+// Logging configures the server log
+type Logging struct {
+	Level     string `json:"level,omitempty"`       // debug, info (default), warn or error
+	Format    string `json:"format,omitempty"`      // text (default) or json
+	Output    string `json:"output,omitempty"`      // stdout (default), file or both
+	File      string `json:"file,omitempty"`        // Defaults to logs/axeos-dashboard.log
+	MaxSizeMB int    `json:"max_size_mb,omitempty"` // Rotate the file at this size (default 10)
+	MaxFiles  int    `json:"max_files,omitempty"`   // Rotated files to keep (default 5)
+}
+
+// validate checks the logging section
+func (l *Logging) validate() error {
+	if _, err := logger.ParseLevel(l.Level); err != nil {
+		return &ValidationError{Field: "logging", Message: err.Error()}
+	}
+	switch l.Format {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return &ValidationError{Field: "logging", Message: fmt.Sprintf("unknown format %q (use text or json)", l.Format)}
+	}
+	switch l.Output {
+	case "", LogOutputStdout, LogOutputFile, LogOutputBoth:
+	default:
+		return &ValidationError{Field: "logging", Message: fmt.Sprintf("unknown output %q (use stdout, file or both)", l.Output)}
+	}
+	if l.MaxSizeMB < 0 || l.MaxFiles < 0 {
+		return &ValidationError{Field: "logging", Message: "max_size_mb and max_files must not be negative"}
+	}
+	return nil
+}
+
+// Options returns the logger options of the section. Relative file paths
+// are taken from baseDir. A nil section logs text at info level to stdout.
+func (l *Logging) Options(baseDir string) logger.Options {
+	opts := logger.Options{Level: logger.LevelInfo, Stdout: true}
+	if l == nil {
+		return opts
+	}
+	opts.Level, _ = logger.ParseLevel(l.Level)
+	opts.JSON = l.Format == LogFormatJSON
+	if l.Output == LogOutputFile || l.Output == LogOutputBoth {
+		opts.Stdout = l.Output == LogOutputBoth
+		opts.File = l.File
+		if opts.File == "" {
+			opts.File = DefaultLogFile
+		}
+		if !filepath.IsAbs(opts.File) {
+			opts.File = filepath.Join(baseDir, opts.File)
+		}
+		opts.MaxSizeMB = l.MaxSizeMB
+		opts.MaxFiles = l.MaxFiles
+	}
+	return opts
+}
+
+// validateLogging checks the logging section of a configuration update
+func validateLogging(values map[string]interface{}) error {
+	raw, ok := values["logging"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "logging", Message: err.Error()}
+	}
+	var logging Logging
+	if err := json.Unmarshal(data, &logging); err != nil {
+		return &ValidationError{Field: "logging", Message: err.Error()}
+	}
+	return logging.validate()
+}
@@ -0,0 +1,46 @@
+package config
+
+import "time"
+
+// Login lockout defaults, used when login_lockout is missing
+const (
+	DefaultLoginMaxAttempts    = 5
+	DefaultLoginWindowMinutes  = 15
+	DefaultLoginLockoutMinutes = 15
+)
+
+// LoginLockout limits password guessing: after max_attempts failed logins
+// within window_minutes, the username and the client IP are locked for
+// lockout_minutes
+type LoginLockout struct {
+	MaxAttempts    int `json:"max_attempts,omitempty"`    // Defaults to 5; negative disables the lockout
+	WindowMinutes  int `json:"window_minutes,omitempty"`  // Defaults to 15
+	LockoutMinutes int `json:"lockout_minutes,omitempty"` // Defaults to 15
+}
+
+// Attempts returns how many failures lock a username or IP, or 0 when the lockout is disabled
+func (l *LoginLockout) Attempts() int {
+	switch {
+	case l == nil || l.MaxAttempts == 0:
+		return DefaultLoginMaxAttempts
+	case l.MaxAttempts < 0:
+		return 0
+	}
+	return l.MaxAttempts
+}
+
+// Window returns the period in which failures are counted
+func (l *LoginLockout) Window() time.Duration {
+	if l == nil || l.WindowMinutes <= 0 {
+		return DefaultLoginWindowMinutes * time.Minute
+	}
+	return time.Duration(l.WindowMinutes) * time.Minute
+}
+
+// Duration returns how long a username or IP stays locked
+func (l *LoginLockout) Duration() time.Duration {
+	if l == nil || l.LockoutMinutes <= 0 {
+		return DefaultLoginLockoutMinutes * time.Minute
+	}
+	return time.Duration(l.LockoutMinutes) * time.Minute
+}
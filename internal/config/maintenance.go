@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaintenanceWindow describes a planned maintenance period for one or more devices
+type MaintenanceWindow struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Start       string   `json:"start"`                // RFC3339 timestamp of the first occurrence
+	Duration    string   `json:"duration"`             // e.g. "30m", "2h"
+	Recurrence  string   `json:"recurrence,omitempty"` // "", "daily", "weekly" or "monthly"
+	Instances   []string `json:"instances,omitempty"`  // Empty means all devices
+}
+
+// StartTime parses the start of the first occurrence
+func (w MaintenanceWindow) StartTime() (time.Time, error) {
+	start, err := time.Parse(time.RFC3339, w.Start)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid maintenance window start %q: %w", w.Start, err)
+	}
+	return start, nil
+}
+
+// Length returns the duration of each occurrence (one hour if unset or invalid)
+func (w MaintenanceWindow) Length() time.Duration {
+	length, err := ParseLifetime(w.Duration)
+	if err != nil || length <= 0 {
+		return time.Hour
+	}
+	return length
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Market data providers, in the default order of preference
+const (
+	MarketCoinGecko      = "coingecko"       // Price
+	MarketCoinbase       = "coinbase"        // Price
+	MarketMempool        = "mempool"         // Difficulty, network hashrate and block reward (BTC)
+	MarketBlockchainInfo = "blockchain_info" // Difficulty, network hashrate and block reward (BTC)
+	MarketNode           = "node"            // Difficulty and network hashrate from the configured crypto node
+)
+
+// DefaultMarketProviders is used when market.providers is empty
+var DefaultMarketProviders = []string{MarketCoinGecko, MarketCoinbase, MarketMempool, MarketBlockchainInfo, MarketNode}
+
+// marketProviderNames are the providers market.providers may list
+var marketProviderNames = map[string]bool{
+	MarketCoinGecko:      true,
+	MarketCoinbase:       true,
+	MarketMempool:        true,
+	MarketBlockchainInfo: true,
+	MarketNode:           true,
+}
+
+// RegisterMarketProvider allows market.providers to list an additional
+// provider. It must be called before the configuration is loaded.
+func RegisterMarketProvider(name string) {
+	marketProviderNames[strings.ToLower(name)] = true
+}
+
+// Market configures where price and network data for profitability come from
+type Market struct {
+	Coin            string   `json:"coin"`              // Ticker, "BTC" by default
+	Currency        string   `json:"currency"`          // Fiat currency, "USD" by default
+	Providers       []string `json:"providers"`         // Order of preference; later providers are used when earlier ones fail
+	CacheTTLSeconds int      `json:"cache_ttl_seconds"` // How long a provider response is reused (300 by default)
+	BlockReward     float64  `json:"block_reward"`      // Coins per block, when no provider reports it
+	// PriceCoins are further tickers the price feed tracks besides BTC and coin
+	PriceCoins []string `json:"price_coins,omitempty"`
+}
+
+// normalize fills in defaults and drops unknown providers, reporting them
+func (m *Market) normalize() error {
+	m.Coin = strings.ToUpper(strings.TrimSpace(m.Coin))
+	if m.Coin == "" {
+		m.Coin = "BTC"
+	}
+	m.Currency = strings.ToUpper(strings.TrimSpace(m.Currency))
+	if m.Currency == "" {
+		m.Currency = "USD"
+	}
+	if m.CacheTTLSeconds <= 0 {
+		m.CacheTTLSeconds = 300
+	}
+	coins := make([]string, 0, len(m.PriceCoins))
+	for _, coin := range m.PriceCoins {
+		if coin = strings.ToUpper(strings.TrimSpace(coin)); coin != "" {
+			coins = append(coins, coin)
+		}
+	}
+	m.PriceCoins = coins
+	if len(m.Providers) == 0 {
+		m.Providers = append([]string(nil), DefaultMarketProviders...)
+		return nil
+	}
+
+	var unknown []string
+	providers := make([]string, 0, len(m.Providers))
+	for _, name := range m.Providers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if marketProviderNames[name] {
+			providers = append(providers, name)
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	m.Providers = providers
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown market providers %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
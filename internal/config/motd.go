@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message levels, which set the style of the banner
+const (
+	MessageInfo     = "info"
+	MessageWarning  = "warning"
+	MessageCritical = "critical"
+)
+
+// Message is a message of the day shown as a banner to every dashboard user
+type Message struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title,omitempty"`
+	Text     string     `json:"text"`
+	Level    string     `json:"level,omitempty"`     // info (default), warning or critical
+	StartsAt *time.Time `json:"starts_at,omitempty"` // Not shown before; nil shows it right away
+	EndsAt   *time.Time `json:"ends_at,omitempty"`   // Not shown after; nil shows it until deleted
+	// Persistent messages cannot be dismissed, e.g. for an ongoing outage
+	Persistent bool      `json:"persistent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Active reports whether a message is scheduled to be shown at a time
+func (m Message) Active(now time.Time) bool {
+	if m.StartsAt != nil && now.Before(*m.StartsAt) {
+		return false
+	}
+	return m.EndsAt == nil || now.Before(*m.EndsAt)
+}
+
+// Validate checks a message. IDs are checked for uniqueness by validateMOTD.
+func (m Message) Validate() error {
+	switch {
+	case m.ID == "":
+		return &ValidationError{Field: "motd", Message: "every message needs an id"}
+	case strings.TrimSpace(m.Text) == "":
+		return &ValidationError{Field: "motd", Message: fmt.Sprintf("message %s needs a text", m.ID)}
+	case m.StartsAt != nil && m.EndsAt != nil && !m.EndsAt.After(*m.StartsAt):
+		return &ValidationError{Field: "motd", Message: fmt.Sprintf("message %s must end after it starts", m.ID)}
+	}
+	switch m.Level {
+	case "", MessageInfo, MessageWarning, MessageCritical:
+	default:
+		return &ValidationError{Field: "motd", Message: fmt.Sprintf("message %s has unknown level %q (use info, warning or critical)", m.ID, m.Level)}
+	}
+	return nil
+}
+
+// validateMOTD checks the messages in a configuration update and that ids are unique
+func validateMOTD(values map[string]interface{}) error {
+	raw, ok := values["motd"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "motd", Message: err.Error()}
+	}
+	var messages []Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return &ValidationError{Field: "motd", Message: "must be a list of messages"}
+	}
+
+	ids := map[string]bool{}
+	for _, m := range messages {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+		if ids[m.ID] {
+			return &ValidationError{Field: "motd", Message: fmt.Sprintf("duplicate message id %q", m.ID)}
+		}
+		ids[m.ID] = true
+	}
+	return nil
+}
+
+// dismissalsFile records which messages each user dismissed, in the config directory
+const dismissalsFile = "motd_dismissals.json"
+
+// dismissalsMu serializes changes to motd_dismissals.json
+var dismissalsMu sync.Mutex
+
+// readDismissals reads motd_dismissals.json: the time each user dismissed
+// each message, by username and message id
+func readDismissals(configDir string) (map[string]map[string]time.Time, error) {
+	dismissals := map[string]map[string]time.Time{}
+	data, err := os.ReadFile(filepath.Join(configDir, dismissalsFile))
+	if os.IsNotExist(err) {
+		return dismissals, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", dismissalsFile, err)
+	}
+	if err := json.Unmarshal(data, &dismissals); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", dismissalsFile, err)
+	}
+	return dismissals, nil
+}
+
+// DismissedMessages returns the ids of the messages a user dismissed
+func DismissedMessages(configDir, username string) (map[string]time.Time, error) {
+	dismissalsMu.Lock()
+	defer dismissalsMu.Unlock()
+
+	dismissals, err := readDismissals(configDir)
+	if err != nil {
+		return nil, err
+	}
+	if dismissals[username] == nil {
+		return map[string]time.Time{}, nil
+	}
+	return dismissals[username], nil
+}
+
+// DismissMessage records that a user dismissed a message. Dismissals of
+// messages that are no longer configured are dropped.
+func DismissMessage(configDir, username, id string, messages []Message) error {
+	dismissalsMu.Lock()
+	defer dismissalsMu.Unlock()
+
+	dismissals, err := readDismissals(configDir)
+	if err != nil {
+		return err
+	}
+	if dismissals[username] == nil {
+		dismissals[username] = map[string]time.Time{}
+	}
+	dismissals[username][id] = time.Now()
+
+	configured := map[string]bool{}
+	for _, m := range messages {
+		configured[m.ID] = true
+	}
+	for user, ids := range dismissals {
+		for messageID := range ids {
+			if !configured[messageID] {
+				delete(ids, messageID)
+			}
+		}
+		if len(ids) == 0 {
+			delete(dismissals, user)
+		}
+	}
+
+	data, err := json.MarshalIndent(dismissals, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := WriteSecretFile(filepath.Join(configDir, dismissalsFile), data); err != nil {
+		return fmt.Errorf("error writing %s: %w", dismissalsFile, err)
+	}
+	return nil
+}
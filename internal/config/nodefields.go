@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// NodeDisplayFields are the fields shown on a crypto node card: a list of
+// categories, each mapping its title to {"rpcField": "Label"} entries. Nested
+// RPC fields are written as "parent/child".
+type NodeDisplayFields []map[string][]map[string]string
+
+// Validate checks that every category has one title and every field one key and label
+func (f NodeDisplayFields) Validate() error {
+	for i, category := range f {
+		if len(category) != 1 {
+			return fmt.Errorf("category %d must have exactly one title", i+1)
+		}
+		for title, fields := range category {
+			for j, field := range fields {
+				if len(field) != 1 {
+					return fmt.Errorf("%s field %d must map one RPC field to its label", title, j+1)
+				}
+				for key := range field {
+					if strings.TrimSpace(key) == "" {
+						return fmt.Errorf("%s field %d has an empty RPC field", title, j+1)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeNodeType returns the catalog key of a node type ("BTC" -> "btc")
+func NormalizeNodeType(nodeType string) string {
+	return strings.ToLower(strings.TrimSpace(nodeType))
+}
+
+// validateNodeDisplayFields checks node_display_fields in a configuration update
+func validateNodeDisplayFields(values map[string]interface{}) error {
+	raw, ok := values["node_display_fields"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "node_display_fields", Message: err.Error()}
+	}
+	var byType map[string]NodeDisplayFields
+	if err := json.Unmarshal(data, &byType); err != nil {
+		return &ValidationError{Field: "node_display_fields", Message: "must map node types to lists of field categories"}
+	}
+	for nodeType, fields := range byType {
+		if nodeType != NormalizeNodeType(nodeType) || nodeType == "" {
+			return &ValidationError{Field: "node_display_fields", Message: fmt.Sprintf("node type %q must be lower case", nodeType)}
+		}
+		if err := fields.Validate(); err != nil {
+			return &ValidationError{Field: "node_display_fields", Message: fmt.Sprintf("%s: %v", nodeType, err)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Notification channel types
+const (
+	ChannelWebhook  = "webhook"
+	ChannelDiscord  = "discord"
+	ChannelTelegram = "telegram"
+)
+
+// Alert events that can be sent to notification channels
+const (
+	AlertMinerOffline         = "miner_offline"
+	AlertOverheat             = "overheat"
+	AlertBlockFound           = "block_found"
+	AlertCredentialRotation   = "credential_rotation"
+	AlertPoolFailover         = "pool_failover"
+	AlertDifficultyAdjustment = "difficulty_adjustment" // Sent only with difficulty_adjustments enabled
+	AlertEfficiencyRegression = "efficiency_regression" // Weekly, with efficiency_report enabled
+	AlertBrownout             = "brownout"              // With power_monitoring enabled
+	AlertSupplyDegradation    = "supply_degradation"    // Daily, with power_monitoring enabled
+	AlertShareRate            = "share_rate"
+	AlertPowerBudget          = "power_budget" // With power_budget enabled
+	AlertPowerCap             = "power_cap"    // With power_budget fleet_cap_watts set
+)
+
+// DefaultOverheatTemperature is the ASIC temperature (Celsius) that triggers an overheat alert
+const DefaultOverheatTemperature Temperature = 70
+
+// DefaultFallbackAlertMinutes is how long a device may mine on its fallback pool before an alert
+const DefaultFallbackAlertMinutes = 30
+
+// DefaultShareRateTolerance is how far (percent) the accepted shares may be
+// from the shares expected at the pool difficulty before an alert
+const DefaultShareRateTolerance = 50
+
+// NotificationChannel is one destination for alert events. Webhook and Discord
+// URLs and the Telegram bot token may instead be kept in notifications.json,
+// keyed by channel name, so they are not exposed through the configuration API.
+type NotificationChannel struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`              // "webhook", "discord" or "telegram"
+	URL    string   `json:"url,omitempty"`     // Webhook or Discord webhook URL
+	ChatID string   `json:"chat_id,omitempty"` // Telegram chat to post to
+	Events []string `json:"events,omitempty"`  // Alerts to send; empty means all
+	// Language of the templates used for the channel; the notifications language by default
+	Language string `json:"language,omitempty"`
+
+	// TLS of deliveries to the channel: a PEM CA bundle or self-signed
+	// certificate to trust (relative to the config directory), or the pinned
+	// SHA-256 fingerprint of the server certificate
+	CACert     string `json:"ca_cert,omitempty"`
+	CertSHA256 string `json:"cert_sha256,omitempty"`
+}
+
+// Wants reports whether the channel subscribes to an alert event
+func (c NotificationChannel) Wants(event string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifications configures alert delivery
+type Notifications struct {
+	Enabled               bool                  `json:"enabled"`
+	OverheatTemperature   Temperature           `json:"overheat_temperature,omitempty"`   // Defaults to 70C
+	FallbackAlertMinutes  int                   `json:"fallback_alert_minutes,omitempty"` // Defaults to 30; negative disables the alert
+	DifficultyAdjustments bool                  `json:"difficulty_adjustments,omitempty"` // Notify on each difficulty retarget
+	ShareRateTolerance    int                   `json:"share_rate_tolerance,omitempty"`   // Percent; defaults to 50, negative disables the alert
+	RequireHTTPS          bool                  `json:"require_https,omitempty"`          // Refuse plaintext http:// destinations and redirects
+	Channels              []NotificationChannel `json:"channels"`
+
+	// Message templates replacing the built-in English title and message
+	Language  string                 `json:"language,omitempty"` // Default language of the channels
+	Templates []NotificationTemplate `json:"templates,omitempty"`
+}
+
+// FallbackAlertWindow returns how long a device may mine on its fallback
+// pool before an alert, or 0 when the alert is disabled. It is safe to call
+// on a nil section, as failovers are tracked without notifications too.
+func (n *Notifications) FallbackAlertWindow() time.Duration {
+	minutes := DefaultFallbackAlertMinutes
+	if n != nil && n.FallbackAlertMinutes != 0 {
+		minutes = n.FallbackAlertMinutes
+	}
+	if minutes < 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ShareRateTolerancePercent returns how far (percent) a device's accepted
+// shares may be from the expected shares, or 0 when the check is disabled.
+// It is safe to call on a nil section.
+func (n *Notifications) ShareRateTolerancePercent() float64 {
+	tolerance := DefaultShareRateTolerance
+	if n != nil && n.ShareRateTolerance != 0 {
+		tolerance = n.ShareRateTolerance
+	}
+	if tolerance < 0 {
+		return 0
+	}
+	return float64(tolerance)
+}
+
+// validate checks the channels of a notifications section
+func (n *Notifications) validate() error {
+	names := map[string]bool{}
+	for i, channel := range n.Channels {
+		if strings.TrimSpace(channel.Name) == "" {
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("channel %d needs a name", i+1)}
+		}
+		key := strings.ToLower(channel.Name)
+		if names[key] {
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("duplicate channel name %q", channel.Name)}
+		}
+		names[key] = true
+
+		switch channel.Type {
+		case ChannelWebhook, ChannelDiscord:
+			if n.RequireHTTPS && channel.URL != "" && !IsHTTPSURL(channel.URL) {
+				return &ValidationError{Field: "notifications", Message: fmt.Sprintf("channel %s must use an https:// url as require_https is set", channel.Name)}
+			}
+			if (channel.CACert != "" || channel.CertSHA256 != "") && channel.URL != "" && !IsHTTPSURL(channel.URL) {
+				return &ValidationError{Field: "notifications", Message: fmt.Sprintf("channel %s has TLS settings but its url is not https://", channel.Name)}
+			}
+		case ChannelTelegram:
+			if channel.ChatID == "" {
+				return &ValidationError{Field: "notifications", Message: fmt.Sprintf("telegram channel %s needs a chat_id", channel.Name)}
+			}
+		default:
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("channel %s has unknown type %q (use webhook, discord or telegram)", channel.Name, channel.Type)}
+		}
+
+		if channel.CertSHA256 != "" {
+			if _, err := ParseCertFingerprint(channel.CertSHA256); err != nil {
+				return &ValidationError{Field: "notifications", Message: fmt.Sprintf("channel %s: cert_sha256 %v", channel.Name, err)}
+			}
+		}
+
+		for _, event := range channel.Events {
+			if !isAlertEvent(event) {
+				return &ValidationError{Field: "notifications", Message: fmt.Sprintf("channel %s has unknown event %q", channel.Name, event)}
+			}
+		}
+	}
+	return n.validateTemplates(names)
+}
+
+// IsHTTPSURL reports whether a destination URL uses https
+func IsHTTPSURL(target string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(target)), "https://")
+}
+
+// isAlertEvent reports whether an event is one of the alerts sent to channels
+func isAlertEvent(event string) bool {
+	switch event {
+	case AlertMinerOffline, AlertOverheat, AlertBlockFound, AlertCredentialRotation, AlertPoolFailover, AlertDifficultyAdjustment, AlertEfficiencyRegression, AlertBrownout, AlertSupplyDegradation, AlertShareRate, AlertPowerBudget, AlertPowerCap:
+		return true
+	}
+	return false
+}
+
+// validateNotifications checks the notifications section of a configuration update
+func validateNotifications(values map[string]interface{}) error {
+	raw, ok := values["notifications"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "notifications", Message: err.Error()}
+	}
+	var notifications Notifications
+	if err := json.Unmarshal(data, &notifications); err != nil {
+		return &ValidationError{Field: "notifications", Message: err.Error()}
+	}
+	return notifications.validate()
+}
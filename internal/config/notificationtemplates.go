@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NotificationTemplateAny matches every event in a template's event
+const NotificationTemplateAny = "*"
+
+// NotificationTemplate replaces the title and message of an alert with Go
+// templates. The templates see the event as .Type, .Source, .Title,
+// .Message (the built-in English texts), .Timestamp and .Fields, the values
+// of the event by name (e.g. {{.Fields.temperature}}).
+type NotificationTemplate struct {
+	Event    string `json:"event"`              // Alert type, "test" or "*" for every event
+	Language string `json:"language,omitempty"` // Channel language it is for; empty matches any
+	Channel  string `json:"channel,omitempty"`  // Channel it is for; empty matches any
+	Title    string `json:"title,omitempty"`    // The built-in title when empty
+	Message  string `json:"message,omitempty"`  // The built-in message when empty
+}
+
+// NotificationTemplateFuncs are the functions notification templates may use
+var NotificationTemplateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// round rounds a number to the given decimals
+	"round": func(value float64, decimals int) float64 {
+		scale := math.Pow(10, float64(decimals))
+		return math.Round(value*scale) / scale
+	},
+	// date formats a time with a Go layout, e.g. "02.01.2006 15:04"
+	"date": func(layout string, t time.Time) string {
+		return t.Local().Format(layout)
+	},
+}
+
+// ParseNotificationTemplate parses one title or message template
+func ParseNotificationTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(NotificationTemplateFuncs).Parse(text)
+}
+
+// FindTemplate returns the template for an event sent to a channel, or nil
+// to use the built-in texts. A template naming the event beats "*", one
+// naming the channel beats one for any channel, and one in the channel's
+// language beats one without a language.
+func (n *Notifications) FindTemplate(event string, channel NotificationChannel) *NotificationTemplate {
+	if n == nil {
+		return nil
+	}
+	language := channel.Language
+	if language == "" {
+		language = n.Language
+	}
+
+	var best *NotificationTemplate
+	bestScore := -1
+	for i := range n.Templates {
+		t := &n.Templates[i]
+		score := 0
+		switch {
+		case t.Event == event:
+			score += 4
+		case t.Event != NotificationTemplateAny:
+			continue
+		}
+		switch {
+		case strings.EqualFold(t.Channel, channel.Name):
+			score += 2
+		case t.Channel != "":
+			continue
+		}
+		switch {
+		case strings.EqualFold(t.Language, language) && language != "":
+			score++
+		case t.Language != "":
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
+
+// validateTemplates checks that every template names a known event and
+// channel and parses; channels holds the lower-case channel names
+func (n *Notifications) validateTemplates(channels map[string]bool) error {
+	for i, t := range n.Templates {
+		label := fmt.Sprintf("template %d", i+1)
+		switch {
+		case t.Event != NotificationTemplateAny && t.Event != "test" && !isAlertEvent(t.Event):
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("%s has unknown event %q", label, t.Event)}
+		case t.Channel != "" && !channels[strings.ToLower(t.Channel)]:
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("%s names unknown channel %q", label, t.Channel)}
+		case t.Title == "" && t.Message == "":
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("%s needs a title or message", label)}
+		}
+		if _, err := ParseNotificationTemplate("title", t.Title); err != nil {
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("%s title: %v", label, err)}
+		}
+		if _, err := ParseNotificationTemplate("message", t.Message); err != nil {
+			return &ValidationError{Field: "notifications", Message: fmt.Sprintf("%s message: %v", label, err)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultUserAgent identifies requests to devices, pools and nodes
+const DefaultUserAgent = "axeos-dashboard"
+
+// validHeaderName reports whether name is a valid HTTP header field name (RFC 9110 token)
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c > 0x7e || !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// validHeaderValue reports whether value can be sent as a header value
+func validHeaderValue(value string) bool {
+	return !strings.ContainsAny(value, "\r\n\x00")
+}
+
+// checkOutboundHeaders returns an error describing the first invalid user agent or header
+func checkOutboundHeaders(userAgent string, headers map[string]string) error {
+	if !validHeaderValue(userAgent) {
+		return fmt.Errorf("user_agent must not contain line breaks")
+	}
+	for name, value := range headers {
+		if !validHeaderName(name) {
+			return fmt.Errorf("%q is not a valid header name", name)
+		}
+		if !validHeaderValue(value) {
+			return fmt.Errorf("value of header %q must not contain line breaks", name)
+		}
+	}
+	return nil
+}
+
+// validateOutboundHeaders checks user_agent and outbound_headers in a configuration update
+func validateOutboundHeaders(values map[string]interface{}) error {
+	var settings struct {
+		UserAgent       string            `json:"user_agent"`
+		OutboundHeaders map[string]string `json:"outbound_headers"`
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"user_agent":       values["user_agent"],
+		"outbound_headers": values["outbound_headers"],
+	})
+	if err == nil {
+		err = json.Unmarshal(data, &settings)
+	}
+	if err != nil {
+		return &ValidationError{Field: "outbound_headers", Message: "user_agent must be a string and outbound_headers an object of strings"}
+	}
+	if err := checkOutboundHeaders(settings.UserAgent, settings.OutboundHeaders); err != nil {
+		return &ValidationError{Field: "outbound_headers", Message: err.Error()}
+	}
+	return nil
+}
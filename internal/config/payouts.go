@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Sources a payout address is watched with
+const (
+	PayoutSourceMempool = "mempool" // mempool.space or a self-hosted mempool/esplora API
+	PayoutSourceNode    = "node"    // listtransactions of a crypto node wallet holding the address
+)
+
+// DefaultMempoolURL is the mempool API used by payout addresses without a url
+const DefaultMempoolURL = "https://mempool.space"
+
+// PayoutAddress is an address the pools pay to, watched for incoming payments
+type PayoutAddress struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Source  string `json:"source,omitempty"` // mempool (default) or node
+	URL     string `json:"url,omitempty"`    // mempool API base URL; mempool.space by default
+	Node    string `json:"node,omitempty"`   // NodeId of the crypto node, for the node source
+}
+
+// SourceOrDefault returns the source of the address, mempool when not set
+func (p PayoutAddress) SourceOrDefault() string {
+	if p.Source == "" {
+		return PayoutSourceMempool
+	}
+	return strings.ToLower(p.Source)
+}
+
+// MempoolURL returns the mempool API base URL without a trailing slash
+func (p PayoutAddress) MempoolURL() string {
+	if p.URL == "" {
+		return DefaultMempoolURL
+	}
+	return strings.TrimRight(p.URL, "/")
+}
+
+// FindPayoutAddress returns the payout address with a name, or nil
+func (c *Config) FindPayoutAddress(name string) *PayoutAddress {
+	for i := range c.PayoutAddresses {
+		if c.PayoutAddresses[i].Name == name {
+			return &c.PayoutAddresses[i]
+		}
+	}
+	return nil
+}
+
+// validatePayoutAddresses checks the payout addresses in a configuration
+// update: names and addresses must be unique, the source known, and the node
+// source needs the node
+func validatePayoutAddresses(values map[string]interface{}) error {
+	raw, ok := values["payout_addresses"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "payout_addresses", Message: err.Error()}
+	}
+	var addresses []PayoutAddress
+	if err := json.Unmarshal(data, &addresses); err != nil {
+		return &ValidationError{Field: "payout_addresses", Message: "must be a list of addresses with name and address"}
+	}
+
+	names := map[string]bool{}
+	seen := map[string]bool{}
+	for _, payout := range addresses {
+		switch {
+		case strings.TrimSpace(payout.Name) == "":
+			return &ValidationError{Field: "payout_addresses", Message: "address names must not be empty"}
+		case names[payout.Name]:
+			return &ValidationError{Field: "payout_addresses", Message: fmt.Sprintf("duplicate address name %q", payout.Name)}
+		case strings.TrimSpace(payout.Address) == "" || strings.ContainsAny(payout.Address, "/?# "):
+			return &ValidationError{Field: "payout_addresses", Message: fmt.Sprintf("address %q has an invalid address", payout.Name)}
+		case seen[payout.Address]:
+			return &ValidationError{Field: "payout_addresses", Message: fmt.Sprintf("address of %q is already watched", payout.Name)}
+		}
+
+		switch payout.SourceOrDefault() {
+		case PayoutSourceMempool:
+			if payout.URL != "" {
+				u, err := url.Parse(payout.URL)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return &ValidationError{Field: "payout_addresses", Message: fmt.Sprintf("address %q url must be an http or https URL", payout.Name)}
+				}
+			}
+		case PayoutSourceNode:
+			if strings.TrimSpace(payout.Node) == "" {
+				return &ValidationError{Field: "payout_addresses", Message: fmt.Sprintf("address %q needs the node it is watched with", payout.Name)}
+			}
+		default:
+			return &ValidationError{Field: "payout_addresses", Message: fmt.Sprintf("address %q has unknown source %q", payout.Name, payout.Source)}
+		}
+		names[payout.Name] = true
+		seen[payout.Address] = true
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholders in a pool user, replaced for each device so every device can
+// mine as its own worker, e.g. "bc1qexample.{hostname}"
+const (
+	PoolUserInstancePlaceholder = "{instanceId}" // Instance name in axeos_instances
+	PoolUserHostnamePlaceholder = "{hostname}"   // Hostname the device reports
+	PoolUserMACPlaceholder      = "{mac}"        // MAC address, lowercase without colons
+)
+
+// poolUserPlaceholder matches anything that looks like a placeholder in a pool user
+var poolUserPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// PoolUserDevice holds the values the placeholders of a pool user are replaced with
+type PoolUserDevice struct {
+	InstanceID string
+	Hostname   string
+	MAC        string
+}
+
+// ValidatePoolUser checks that a pool user only contains known placeholders
+func ValidatePoolUser(user string) error {
+	for _, placeholder := range poolUserPlaceholder.FindAllString(user, -1) {
+		switch placeholder {
+		case PoolUserInstancePlaceholder, PoolUserHostnamePlaceholder, PoolUserMACPlaceholder:
+		default:
+			return fmt.Errorf("unknown placeholder %s in pool user (use %s, %s or %s)",
+				placeholder, PoolUserInstancePlaceholder, PoolUserHostnamePlaceholder, PoolUserMACPlaceholder)
+		}
+	}
+	return nil
+}
+
+// PoolUserNeedsDeviceInfo reports whether a pool user has placeholders that
+// are filled in from the device's system info
+func PoolUserNeedsDeviceInfo(user string) bool {
+	return strings.Contains(user, PoolUserHostnamePlaceholder) || strings.Contains(user, PoolUserMACPlaceholder)
+}
+
+// ExpandPoolUser replaces the placeholders of a pool user with the values of a device
+func ExpandPoolUser(user string, device PoolUserDevice) string {
+	mac := strings.ToLower(strings.NewReplacer(":", "", "-", "").Replace(device.MAC))
+	return strings.NewReplacer(
+		PoolUserInstancePlaceholder, device.InstanceID,
+		PoolUserHostnamePlaceholder, device.Hostname,
+		PoolUserMACPlaceholder, mac,
+	).Replace(user)
+}
+
+// PoolEndpoint is a stratum pool a device can mine on
+type PoolEndpoint struct {
+	URL      string `json:"url"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`               // May contain {instanceId}, {hostname} or {mac}
+	Password string `json:"password,omitempty"` // Defaults to "x"
+}
+
+// Validate checks that the endpoint can be sent to a device
+func (p *PoolEndpoint) Validate() error {
+	if strings.TrimSpace(p.URL) == "" || strings.TrimSpace(p.User) == "" {
+		return fmt.Errorf("url and user are required")
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	return ValidatePoolUser(p.User)
+}
+
+// PasswordOrDefault returns the pool password, "x" when none is set
+func (p *PoolEndpoint) PasswordOrDefault() string {
+	if p.Password == "" {
+		return "x"
+	}
+	return p.Password
+}
+
+// PoolProfile is a named primary pool with an optional fallback, for
+// switching devices between setups such as solo and pooled mining
+type PoolProfile struct {
+	Name string `json:"name"`
+	PoolEndpoint
+	Fallback *PoolEndpoint `json:"fallback,omitempty"`
+}
+
+// Validate checks the primary and fallback pools of a profile
+func (p *PoolProfile) Validate() error {
+	if err := p.PoolEndpoint.Validate(); err != nil {
+		return err
+	}
+	if p.Fallback != nil {
+		if err := p.Fallback.Validate(); err != nil {
+			return fmt.Errorf("fallback: %w", err)
+		}
+	}
+	return nil
+}
+
+// FindPoolProfile returns the pool profile with the given name (case-insensitive)
+func (c *Config) FindPoolProfile(name string) (*PoolProfile, bool) {
+	for i := range c.PoolProfiles {
+		if strings.EqualFold(c.PoolProfiles[i].Name, name) {
+			return &c.PoolProfiles[i], true
+		}
+	}
+	return nil, false
+}
+
+// validatePoolProfiles checks the pool profiles in a configuration update and that names are unique
+func validatePoolProfiles(values map[string]interface{}) error {
+	raw, ok := values["pool_profiles"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "pool_profiles", Message: err.Error()}
+	}
+	var profiles []PoolProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return &ValidationError{Field: "pool_profiles", Message: "must be a list of pool profiles"}
+	}
+
+	names := map[string]bool{}
+	for _, p := range profiles {
+		if strings.TrimSpace(p.Name) == "" {
+			return &ValidationError{Field: "pool_profiles", Message: "every pool profile needs a name"}
+		}
+		if err := p.Validate(); err != nil {
+			return &ValidationError{Field: "pool_profiles", Message: fmt.Sprintf("%s: %v", p.Name, err)}
+		}
+		key := strings.ToLower(p.Name)
+		if names[key] {
+			return &ValidationError{Field: "pool_profiles", Message: fmt.Sprintf("duplicate pool profile name %q", p.Name)}
+		}
+		names[key] = true
+	}
+	return nil
+}
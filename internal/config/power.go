@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// DefaultPowerScriptTimeout is how long the power event script may run
+const DefaultPowerScriptTimeout = 30 * time.Second
+
+// PowerEvents configures the reaction to UPS power events, received as SIGPWR
+// or through POST /api/power (e.g. from a NUT upsmon NOTIFYCMD)
+type PowerEvents struct {
+	Enabled              bool   `json:"enabled"`
+	Script               string `json:"script,omitempty"`                 // Run for every event with the event name as argument
+	ScriptTimeoutSeconds int    `json:"script_timeout_seconds,omitempty"` // Defaults to 30
+}
+
+// IsEnabled reports whether power events are handled
+func (p *PowerEvents) IsEnabled() bool {
+	return p != nil && p.Enabled
+}
+
+// ScriptTimeout returns how long the script may run before it is killed
+func (p *PowerEvents) ScriptTimeout() time.Duration {
+	if p == nil || p.ScriptTimeoutSeconds <= 0 {
+		return DefaultPowerScriptTimeout
+	}
+	return time.Duration(p.ScriptTimeoutSeconds) * time.Second
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultPowerBudgetMargin is how far (percent) a device may draw above its
+// nominal wattage before a power_budget alert
+const DefaultPowerBudgetMargin = 15.0
+
+// PowerBudget is the expected power draw of the devices and the household
+// cap of the whole fleet. A device drawing well above its nominal wattage
+// points to a shorted regulator or a wrong core voltage setting.
+type PowerBudget struct {
+	Enabled       bool               `json:"enabled"`
+	MarginPercent float64            `json:"margin_percent,omitempty"`  // Above the nominal wattage before an alert; defaults to 15
+	Devices       map[string]float64 `json:"devices,omitempty"`         // Nominal watts by instance name
+	Models        map[string]float64 `json:"models,omitempty"`          // Nominal watts by deviceModel, boardVersion or ASICModel
+	FleetCapWatts float64            `json:"fleet_cap_watts,omitempty"` // Total power of all devices before an alert; 0 disables
+	CapControl    bool               `json:"cap_control,omitempty"`     // Step devices down through Profiles to stay below the cap
+	Profiles      []PowerProfile     `json:"profiles,omitempty"`        // Lower-power settings, from the highest to the lowest power
+}
+
+// PowerProfile is a frequency and core voltage the power cap controller can
+// step a device down to
+type PowerProfile struct {
+	Name        string `json:"name"`
+	Frequency   int    `json:"frequency"`    // MHz
+	CoreVoltage int    `json:"core_voltage"` // mV
+}
+
+// IsEnabled reports whether power budgets are checked
+func (b *PowerBudget) IsEnabled() bool {
+	return b != nil && b.Enabled
+}
+
+// Margin returns the percent above the nominal wattage a device may draw
+func (b *PowerBudget) Margin() float64 {
+	if b == nil || b.MarginPercent <= 0 {
+		return DefaultPowerBudgetMargin
+	}
+	return b.MarginPercent
+}
+
+// ControlsCap reports whether the power cap controller adjusts devices to
+// keep the fleet below the cap
+func (b *PowerBudget) ControlsCap() bool {
+	return b.IsEnabled() && b.CapControl && b.FleetCapWatts > 0 && len(b.Profiles) > 0
+}
+
+// BudgetOf returns the nominal wattage of a device and the key it was found
+// by: the instance name, else the first of its models with a budget. It
+// returns 0 when the device has none.
+func (b *PowerBudget) BudgetOf(instanceID string, models []string) (float64, string) {
+	if b == nil {
+		return 0, ""
+	}
+	if watts, ok := b.Devices[instanceID]; ok {
+		return watts, instanceID
+	}
+	for _, model := range models {
+		if watts, ok := b.Models[model]; ok {
+			return watts, model
+		}
+	}
+	return 0, ""
+}
+
+// validatePowerBudget checks the power_budget section of a configuration update
+func validatePowerBudget(values map[string]interface{}) error {
+	raw, ok := values["power_budget"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "power_budget", Message: err.Error()}
+	}
+	var budget PowerBudget
+	if err := json.Unmarshal(data, &budget); err != nil {
+		return &ValidationError{Field: "power_budget", Message: "must be an object with nominal watts by device and model"}
+	}
+	if budget.MarginPercent < 0 || budget.MarginPercent > 200 {
+		return &ValidationError{Field: "power_budget", Message: "margin_percent must be between 0 and 200"}
+	}
+	if budget.FleetCapWatts < 0 {
+		return &ValidationError{Field: "power_budget", Message: "fleet_cap_watts must not be negative"}
+	}
+	for name, watts := range budget.Devices {
+		if watts <= 0 {
+			return &ValidationError{Field: "power_budget", Message: fmt.Sprintf("device %q needs a positive wattage", name)}
+		}
+	}
+	for model, watts := range budget.Models {
+		if watts <= 0 {
+			return &ValidationError{Field: "power_budget", Message: fmt.Sprintf("model %q needs a positive wattage", model)}
+		}
+	}
+	if budget.CapControl && (budget.FleetCapWatts <= 0 || len(budget.Profiles) == 0) {
+		return &ValidationError{Field: "power_budget", Message: "cap_control needs fleet_cap_watts and at least one profile"}
+	}
+	for i, profile := range budget.Profiles {
+		if strings.TrimSpace(profile.Name) == "" {
+			return &ValidationError{Field: "power_budget", Message: "every profile needs a name"}
+		}
+		if profile.Frequency <= 0 || profile.CoreVoltage <= 0 {
+			return &ValidationError{Field: "power_budget", Message: fmt.Sprintf("profile %q needs a positive frequency and core_voltage", profile.Name)}
+		}
+		if i > 0 {
+			previous := budget.Profiles[i-1]
+			if profile.Frequency > previous.Frequency || profile.CoreVoltage > previous.CoreVoltage ||
+				(profile.Frequency == previous.Frequency && profile.CoreVoltage == previous.CoreVoltage) {
+				return &ValidationError{Field: "power_budget", Message: fmt.Sprintf("profile %q must use less power than %q; list profiles from the highest to the lowest power", profile.Name, previous.Name)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Defaults of power supply monitoring
+const (
+	DefaultBrownoutSagPercent       = 5.0 // Input voltage below nominal under load
+	DefaultSupplyTrendDays          = 14
+	DefaultSupplyDegradationPercent = 2.0 // Loaded voltage lost over the trend days
+	maxSupplyTrendDays              = 90
+)
+
+// standardSupplyVolts are the rails a nominal voltage is inferred from
+var standardSupplyVolts = []float64{5, 12}
+
+// PowerSupply is a power supply shared by several devices. Their input
+// voltage and current are judged together, as one supply.
+type PowerSupply struct {
+	Name         string   `json:"name"`
+	Instances    []string `json:"instances"`               // Devices powered by the supply
+	NominalVolts float64  `json:"nominal_volts,omitempty"` // Inferred from the readings (5 or 12 V) when not set
+	RatedWatts   float64  `json:"rated_watts,omitempty"`   // Shown as the load percentage when set
+}
+
+// PowerMonitoring watches device input voltage and current for brown-outs
+// (voltage sagging under load) and for supplies whose loaded voltage drifts
+// down over the days, a sign of an aging power supply. Devices not in a
+// supply are treated as having their own.
+type PowerMonitoring struct {
+	Enabled            bool          `json:"enabled"`
+	SagPercent         float64       `json:"sag_percent,omitempty"`         // Brown-out below nominal by this much; defaults to 5
+	TrendDays          int           `json:"trend_days,omitempty"`          // Days the voltage trend is fitted to; defaults to 14, at most 90
+	DegradationPercent float64       `json:"degradation_percent,omitempty"` // Voltage loss over the trend days that flags a supply; defaults to 2
+	Supplies           []PowerSupply `json:"supplies,omitempty"`
+}
+
+// IsEnabled reports whether brown-outs and supply trends are checked
+func (p *PowerMonitoring) IsEnabled() bool {
+	return p != nil && p.Enabled
+}
+
+// Sag returns the percent below nominal voltage that is a brown-out
+func (p *PowerMonitoring) Sag() float64 {
+	if p == nil || p.SagPercent <= 0 {
+		return DefaultBrownoutSagPercent
+	}
+	return p.SagPercent
+}
+
+// Days returns the days of data the supply voltage trend is fitted to
+func (p *PowerMonitoring) Days() int {
+	if p == nil || p.TrendDays <= 0 {
+		return DefaultSupplyTrendDays
+	}
+	if p.TrendDays > maxSupplyTrendDays {
+		return maxSupplyTrendDays
+	}
+	return p.TrendDays
+}
+
+// Degradation returns the voltage loss, in percent of nominal, that flags a supply
+func (p *PowerMonitoring) Degradation() float64 {
+	if p == nil || p.DegradationPercent <= 0 {
+		return DefaultSupplyDegradationPercent
+	}
+	return p.DegradationPercent
+}
+
+// SupplyOf returns the supply powering a device; a device in no supply gets
+// one of its own, named after it
+func (p *PowerMonitoring) SupplyOf(instanceID string) PowerSupply {
+	if p != nil {
+		for _, supply := range p.Supplies {
+			for _, id := range supply.Instances {
+				if id == instanceID {
+					return supply
+				}
+			}
+		}
+	}
+	return PowerSupply{Name: instanceID, Instances: []string{instanceID}}
+}
+
+// AllSupplies returns the configured supplies followed by one for each
+// device in instances that no supply lists
+func (p *PowerMonitoring) AllSupplies(instances []string) []PowerSupply {
+	var supplies []PowerSupply
+	grouped := map[string]bool{}
+	if p != nil {
+		for _, supply := range p.Supplies {
+			supplies = append(supplies, supply)
+			for _, id := range supply.Instances {
+				grouped[id] = true
+			}
+		}
+	}
+	for _, id := range instances {
+		if !grouped[id] {
+			supplies = append(supplies, PowerSupply{Name: id, Instances: []string{id}})
+		}
+	}
+	return supplies
+}
+
+// Nominal returns the nominal voltage of the supply, inferred from a reading
+// in mV as the nearest standard rail when not configured
+func (s PowerSupply) Nominal(readingMV float64) float64 {
+	if s.NominalVolts > 0 {
+		return s.NominalVolts
+	}
+	nearest := standardSupplyVolts[0]
+	for _, volts := range standardSupplyVolts[1:] {
+		if math.Abs(readingMV/1000-volts) < math.Abs(readingMV/1000-nearest) {
+			nearest = volts
+		}
+	}
+	return nearest
+}
+
+// validatePowerMonitoring checks the supplies in a configuration update:
+// names must be unique and each device may be on one supply only
+func validatePowerMonitoring(values map[string]interface{}) error {
+	raw, ok := values["power_monitoring"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "power_monitoring", Message: err.Error()}
+	}
+	var monitoring PowerMonitoring
+	if err := json.Unmarshal(data, &monitoring); err != nil {
+		return &ValidationError{Field: "power_monitoring", Message: "must be an object with a list of supplies"}
+	}
+	if monitoring.SagPercent < 0 || monitoring.SagPercent >= 50 {
+		return &ValidationError{Field: "power_monitoring", Message: "sag_percent must be between 0 and 50"}
+	}
+	if monitoring.DegradationPercent < 0 || monitoring.DegradationPercent >= 50 {
+		return &ValidationError{Field: "power_monitoring", Message: "degradation_percent must be between 0 and 50"}
+	}
+
+	names := map[string]bool{}
+	supplyOf := map[string]string{}
+	for _, supply := range monitoring.Supplies {
+		switch {
+		case strings.TrimSpace(supply.Name) == "":
+			return &ValidationError{Field: "power_monitoring", Message: "supply names must not be empty"}
+		case names[supply.Name]:
+			return &ValidationError{Field: "power_monitoring", Message: fmt.Sprintf("duplicate supply name %q", supply.Name)}
+		case len(supply.Instances) == 0:
+			return &ValidationError{Field: "power_monitoring", Message: fmt.Sprintf("supply %q needs at least one instance", supply.Name)}
+		case supply.NominalVolts < 0 || supply.RatedWatts < 0:
+			return &ValidationError{Field: "power_monitoring", Message: fmt.Sprintf("supply %q has a negative rating", supply.Name)}
+		}
+		for _, id := range supply.Instances {
+			if other, ok := supplyOf[id]; ok {
+				return &ValidationError{Field: "power_monitoring", Message: fmt.Sprintf("%q is on both supply %q and %q", id, other, supply.Name)}
+			}
+			supplyOf[id] = supply.Name
+		}
+		names[supply.Name] = true
+	}
+	return nil
+}
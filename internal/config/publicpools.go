@@ -0,0 +1,104 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Public pool types with a built-in adapter
+const (
+	PoolTypePublicPool = "public-pool" // public-pool.io and self-hosted public-pool instances
+	PoolTypeCKPool     = "ckpool"      // CKPool solo (solo.ckpool.org)
+	PoolTypeOcean      = "ocean"       // OCEAN (ocean.xyz)
+)
+
+// publicPoolTypes are the types public_pools entries may use
+var publicPoolTypes = map[string]bool{
+	PoolTypePublicPool: true,
+	PoolTypeCKPool:     true,
+	PoolTypeOcean:      true,
+}
+
+// RegisterPoolType allows public_pools entries to use an additional pool
+// type. It must be called before the configuration is loaded.
+func RegisterPoolType(poolType string) {
+	publicPoolTypes[strings.ToLower(poolType)] = true
+}
+
+// PublicPool is a public pool the devices mine on. Its stats are read for
+// the payout address rather than for the whole pool.
+type PublicPool struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`          // public-pool, ckpool, ocean or a registered type
+	Address string `json:"address"`       // Payout address the devices mine to
+	URL     string `json:"url,omitempty"` // API base URL; the public instance of the type by default
+}
+
+// FindPublicPool returns the public pool with a name, or nil
+func (c *Config) FindPublicPool(name string) *PublicPool {
+	for i := range c.PublicPools {
+		if c.PublicPools[i].Name == name {
+			return &c.PublicPools[i]
+		}
+	}
+	return nil
+}
+
+// validatePublicPools checks the public pools in a configuration update:
+// names must be unique and not name a Mining Core pool, types must be known
+// and every pool needs an address
+func validatePublicPools(values map[string]interface{}) error {
+	raw, ok := values["public_pools"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "public_pools", Message: err.Error()}
+	}
+	var pools []PublicPool
+	if err := json.Unmarshal(data, &pools); err != nil {
+		return &ValidationError{Field: "public_pools", Message: "must be a list of pools with name, type and address"}
+	}
+
+	miningCore := map[string]bool{}
+	if data, err := json.Marshal(values["mining_core_url"]); err == nil {
+		var instances []map[string]string
+		if json.Unmarshal(data, &instances) == nil {
+			for _, instance := range instances {
+				for name := range instance {
+					miningCore[name] = true
+				}
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, pool := range pools {
+		switch {
+		case strings.TrimSpace(pool.Name) == "":
+			return &ValidationError{Field: "public_pools", Message: "pool names must not be empty"}
+		case seen[pool.Name]:
+			return &ValidationError{Field: "public_pools", Message: fmt.Sprintf("duplicate pool name %q", pool.Name)}
+		case miningCore[pool.Name]:
+			return &ValidationError{Field: "public_pools", Message: fmt.Sprintf("%q is already a pool in mining_core_url", pool.Name)}
+		case !publicPoolTypes[strings.ToLower(pool.Type)]:
+			return &ValidationError{Field: "public_pools", Message: fmt.Sprintf("pool %q has unknown type %q", pool.Name, pool.Type)}
+		case strings.TrimSpace(pool.Address) == "":
+			return &ValidationError{Field: "public_pools", Message: fmt.Sprintf("pool %q needs the payout address", pool.Name)}
+		case strings.ContainsAny(pool.Address, "/?#"):
+			return &ValidationError{Field: "public_pools", Message: fmt.Sprintf("pool %q has an invalid address", pool.Name)}
+		}
+		if pool.URL != "" {
+			u, err := url.Parse(pool.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return &ValidationError{Field: "public_pools", Message: fmt.Sprintf("pool %q url must be an http or https URL", pool.Name)}
+			}
+		}
+		seen[pool.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IsPushDevice reports whether a device reports its system info by push
+func (c *Config) IsPushDevice(instanceID string) bool {
+	for _, id := range c.PushDevices {
+		if id == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePushDevices checks the push devices in a configuration update:
+// ids must be unique, usable in a URL path and not name a polled device
+func validatePushDevices(values map[string]interface{}) error {
+	raw, ok := values["push_devices"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "push_devices", Message: err.Error()}
+	}
+	var devices []string
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return &ValidationError{Field: "push_devices", Message: "must be a list of device ids"}
+	}
+
+	polled := map[string]bool{}
+	if data, err := json.Marshal(values["axeos_instances"]); err == nil {
+		var instances []map[string]string
+		if json.Unmarshal(data, &instances) == nil {
+			for _, instance := range instances {
+				for name := range instance {
+					polled[name] = true
+				}
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, id := range devices {
+		switch {
+		case strings.TrimSpace(id) == "":
+			return &ValidationError{Field: "push_devices", Message: "device ids must not be empty"}
+		case strings.ContainsAny(id, "/?#"):
+			return &ValidationError{Field: "push_devices", Message: fmt.Sprintf("device id %q must not contain / ? or #", id)}
+		case seen[id]:
+			return &ValidationError{Field: "push_devices", Message: fmt.Sprintf("duplicate device id %q", id)}
+		case polled[id]:
+			return &ValidationError{Field: "push_devices", Message: fmt.Sprintf("%q is already a polled device in axeos_instances", id)}
+		}
+		seen[id] = true
+	}
+	return nil
+}
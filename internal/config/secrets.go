@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// SecretFileMode is the permission mode used for files holding credentials
+const SecretFileMode os.FileMode = 0600
+
+// SecretFiles lists the configuration files that contain credentials or keys
+var SecretFiles = []string{"access.json", "jsonWebTokenKey.json", "rpcConfig.json", "electricityMaps.json", "openWeatherMap.json", "mqtt.json", "notifications.json", "apikeys.json", "sessions.json", "push.json"}
+
+// SecretFileIssue describes a secret file whose permissions are too open
+type SecretFileIssue struct {
+	File     string `json:"file"`
+	Mode     string `json:"mode"`
+	Repaired bool   `json:"repaired"`
+	Message  string `json:"message"`
+}
+
+// WriteSecretFile writes data to path, making sure the file is only
+// readable and writable by the owner (even if it already existed)
+func WriteSecretFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, SecretFileMode); err != nil {
+		return err
+	}
+	// os.WriteFile does not change the mode of an existing file
+	return os.Chmod(path, SecretFileMode)
+}
+
+// CheckSecretFilePermissions reports secret files that are readable or
+// writable by group or others. Missing files are ignored.
+func CheckSecretFilePermissions(configDir string) []SecretFileIssue {
+	issues := []SecretFileIssue{}
+
+	// Unix permission bits are meaningless on Windows
+	if runtime.GOOS == "windows" {
+		return issues
+	}
+
+	for _, file := range SecretFiles {
+		info, err := os.Stat(filepath.Join(configDir, file))
+		if err != nil {
+			continue
+		}
+
+		mode := info.Mode().Perm()
+		if mode&0077 != 0 {
+			issues = append(issues, SecretFileIssue{
+				File:    file,
+				Mode:    fmt.Sprintf("%04o", mode),
+				Message: fmt.Sprintf("%s is accessible by other users (mode %04o, expected %04o)", file, mode, SecretFileMode),
+			})
+		}
+	}
+
+	return issues
+}
+
+// RepairSecretFilePermissions tightens the permissions of any secret file
+// that is too open. Returned issues are marked as repaired when chmod succeeded.
+func RepairSecretFilePermissions(configDir string) []SecretFileIssue {
+	issues := CheckSecretFilePermissions(configDir)
+
+	for i := range issues {
+		path := filepath.Join(configDir, issues[i].File)
+		if err := os.Chmod(path, SecretFileMode); err != nil {
+			issues[i].Message = fmt.Sprintf("%s; failed to repair: %v", issues[i].Message, err)
+			continue
+		}
+		issues[i].Repaired = true
+	}
+
+	return issues
+}
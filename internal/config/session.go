@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinTokenLifetime is the shortest JWT lifetime accepted in config.json
+const MinTokenLifetime = time.Minute
+
+// ValidationError is returned when a configuration update is rejected
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// ParseLifetime parses a lifetime such as "30m", "8h" or "7d"
+func ParseLifetime(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid lifetime %q", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// TokenLifetime returns the JWT lifetime configured via jwt_expiry,
+// or fallback when it is not set or invalid
+func (c *Config) TokenLifetime(fallback time.Duration) time.Duration {
+	if c.JWTExpiry == "" {
+		return fallback
+	}
+	lifetime, err := ParseLifetime(c.JWTExpiry)
+	if err != nil || lifetime < MinTokenLifetime {
+		return fallback
+	}
+	return lifetime
+}
+
+// RefreshTokenLifetime returns how long a session stays renewable without
+// logging in again (refresh_token_expiry), or 0 when refresh tokens are disabled
+func (c *Config) RefreshTokenLifetime() time.Duration {
+	if c.RefreshTokenExpiry == "" {
+		return 0
+	}
+	lifetime, err := ParseLifetime(c.RefreshTokenExpiry)
+	if err != nil || lifetime < MinTokenLifetime {
+		return 0
+	}
+	return lifetime
+}
+
+// SessionCookieMaxAge returns the cookie max age in seconds for a token of
+// the given lifetime. The cookie never outlives the token.
+func (c *Config) SessionCookieMaxAge(tokenLifetime time.Duration) int {
+	lifetimeSeconds := int(tokenLifetime.Seconds())
+	if c.CookieMaxAge <= 0 || c.CookieMaxAge > lifetimeSeconds {
+		return lifetimeSeconds
+	}
+	return c.CookieMaxAge
+}
+
+// validateSessionSettings checks that jwt_expiry, refresh_token_expiry and cookie_max_age agree
+func validateSessionSettings(values map[string]interface{}) error {
+	var lifetime time.Duration
+
+	if raw, ok := values["jwt_expiry"]; ok && raw != nil {
+		expiry, ok := raw.(string)
+		if !ok {
+			return &ValidationError{Field: "jwt_expiry", Message: "must be a duration string such as \"1h\" or \"7d\""}
+		}
+		if expiry != "" {
+			parsed, err := ParseLifetime(expiry)
+			if err != nil {
+				return &ValidationError{Field: "jwt_expiry", Message: err.Error()}
+			}
+			if parsed < MinTokenLifetime {
+				return &ValidationError{Field: "jwt_expiry", Message: fmt.Sprintf("must be at least %v", MinTokenLifetime)}
+			}
+			lifetime = parsed
+		}
+	}
+
+	if raw, ok := values["refresh_token_expiry"]; ok && raw != nil {
+		expiry, ok := raw.(string)
+		if !ok {
+			return &ValidationError{Field: "refresh_token_expiry", Message: "must be a duration string such as \"12h\" or \"7d\""}
+		}
+		if expiry != "" {
+			parsed, err := ParseLifetime(expiry)
+			if err != nil {
+				return &ValidationError{Field: "refresh_token_expiry", Message: err.Error()}
+			}
+			if lifetime > 0 && parsed <= lifetime {
+				return &ValidationError{Field: "refresh_token_expiry", Message: "must be longer than jwt_expiry"}
+			}
+			if parsed < MinTokenLifetime {
+				return &ValidationError{Field: "refresh_token_expiry", Message: fmt.Sprintf("must be at least %v", MinTokenLifetime)}
+			}
+		}
+	}
+
+	if raw, ok := values["cookie_max_age"]; ok && raw != nil {
+		maxAge, ok := raw.(float64)
+		if !ok || maxAge < 0 || maxAge != float64(int(maxAge)) {
+			return &ValidationError{Field: "cookie_max_age", Message: "must be a non-negative number of seconds"}
+		}
+		if lifetime > 0 && maxAge > lifetime.Seconds() {
+			return &ValidationError{
+				Field:   "cookie_max_age",
+				Message: fmt.Sprintf("%d seconds exceeds the token lifetime of %d seconds (jwt_expiry)", int(maxAge), int(lifetime.Seconds())),
+			}
+		}
+	}
+
+	return nil
+}
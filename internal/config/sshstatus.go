@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+)
+
+// SSHStatus serves the fleet status to SSH clients for headless monitoring.
+// Clients log in with a public key listed in the authorized keys file; the
+// session is read-only.
+type SSHStatus struct {
+	Enabled            bool   `json:"enabled"`
+	Listen             string `json:"listen,omitempty"`               // Default ":2222"
+	HostKeyFile        string `json:"host_key_file,omitempty"`        // Created when missing
+	AuthorizedKeysFile string `json:"authorized_keys_file,omitempty"` // OpenSSH authorized_keys format
+	RefreshSeconds     int    `json:"refresh_seconds,omitempty"`      // Default 5
+}
+
+// Default SSH status settings; the key files live in the config directory
+const (
+	DefaultSSHStatusListen         = ":2222"
+	DefaultSSHStatusHostKeyFile    = "ssh_host_ed25519_key"
+	DefaultSSHStatusAuthorizedKeys = "ssh_authorized_keys"
+	DefaultSSHStatusRefreshSeconds = 5
+)
+
+// IsEnabled reports whether the SSH status server should run
+func (s *SSHStatus) IsEnabled() bool {
+	return s != nil && s.Enabled
+}
+
+// Address returns the address the SSH status server listens on
+func (s *SSHStatus) Address() string {
+	if s == nil || s.Listen == "" {
+		return DefaultSSHStatusListen
+	}
+	return s.Listen
+}
+
+// HostKeyPath returns the path of the server's host key
+func (s *SSHStatus) HostKeyPath(configDir string) string {
+	if s == nil || s.HostKeyFile == "" {
+		return filepath.Join(configDir, DefaultSSHStatusHostKeyFile)
+	}
+	return resolveConfigPath(configDir, s.HostKeyFile)
+}
+
+// AuthorizedKeysPath returns the path of the keys allowed to log in
+func (s *SSHStatus) AuthorizedKeysPath(configDir string) string {
+	if s == nil || s.AuthorizedKeysFile == "" {
+		return filepath.Join(configDir, DefaultSSHStatusAuthorizedKeys)
+	}
+	return resolveConfigPath(configDir, s.AuthorizedKeysFile)
+}
+
+// Refresh returns how often the status is redrawn
+func (s *SSHStatus) Refresh() int {
+	if s == nil || s.RefreshSeconds <= 0 {
+		return DefaultSSHStatusRefreshSeconds
+	}
+	return s.RefreshSeconds
+}
+
+// resolveConfigPath resolves a relative path against the config directory
+func resolveConfigPath(configDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(configDir, path)
+}
+
+// validateSSHStatus checks the listen address and refresh interval
+func validateSSHStatus(values map[string]interface{}) error {
+	raw, ok := values["ssh_status"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "ssh_status", Message: err.Error()}
+	}
+	var status SSHStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return &ValidationError{Field: "ssh_status", Message: "must be an object"}
+	}
+	if _, _, err := net.SplitHostPort(status.Address()); err != nil {
+		return &ValidationError{Field: "ssh_status", Message: fmt.Sprintf("listen must be host:port or :port: %v", err)}
+	}
+	if status.RefreshSeconds < 0 || status.RefreshSeconds > 3600 {
+		return &ValidationError{Field: "ssh_status", Message: "refresh_seconds must be between 1 and 3600"}
+	}
+	return nil
+}
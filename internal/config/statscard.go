@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// DefaultStatsCardTheme is the theme of the statistics card when none is chosen
+const DefaultStatsCardTheme = "dark"
+
+// StatsCardTheme holds the colors of the statistics card as #rgb or #rrggbb
+type StatsCardTheme struct {
+	Background string `json:"background"`
+	Text       string `json:"text"`   // Values
+	Muted      string `json:"muted"`  // Labels and the footer
+	Accent     string `json:"accent"` // Title and the bar at the top
+}
+
+// StatsCardThemes are the built-in themes; themes in stats_card override them by name
+var StatsCardThemes = map[string]StatsCardTheme{
+	"dark":   {Background: "#1e1e24", Text: "#f5f5f5", Muted: "#9a9aa5", Accent: "#f7931a"},
+	"light":  {Background: "#ffffff", Text: "#1e1e24", Muted: "#6b6b76", Accent: "#f7931a"},
+	"forest": {Background: "#12261c", Text: "#e8f3ec", Muted: "#8fb39c", Accent: "#5fd38d"},
+}
+
+// StatsCard configures the shareable statistics image of the fleet
+type StatsCard struct {
+	Title  string                    `json:"title,omitempty"` // Defaults to the dashboard title
+	Theme  string                    `json:"theme,omitempty"` // Used when the request names none
+	Themes map[string]StatsCardTheme `json:"themes,omitempty"`
+}
+
+// CardTitle returns the title shown on the card
+func (s *StatsCard) CardTitle(dashboardTitle string) string {
+	if s == nil || strings.TrimSpace(s.Title) == "" {
+		return dashboardTitle
+	}
+	return s.Title
+}
+
+// FindTheme returns a theme by name, the configured default for an empty
+// name; ok is false when there is no theme of that name
+func (s *StatsCard) FindTheme(name string) (StatsCardTheme, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultStatsCardTheme
+		if s != nil && s.Theme != "" {
+			name = strings.ToLower(s.Theme)
+		}
+	}
+	if s != nil {
+		for themeName, theme := range s.Themes {
+			if strings.ToLower(themeName) == name {
+				return theme, true
+			}
+		}
+	}
+	theme, ok := StatsCardThemes[name]
+	return theme, ok
+}
+
+// ParseHexColor parses a #rgb or #rrggbb color
+func ParseHexColor(value string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("%q is not a #rgb or #rrggbb color", value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%q is not a #rgb or #rrggbb color", value)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
+
+// validateStatsCard checks the colors of the themes and that the default theme exists
+func validateStatsCard(values map[string]interface{}) error {
+	raw, ok := values["stats_card"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return &ValidationError{Field: "stats_card", Message: err.Error()}
+	}
+	var card StatsCard
+	if err := json.Unmarshal(data, &card); err != nil {
+		return &ValidationError{Field: "stats_card", Message: "must be an object with a title, theme and themes"}
+	}
+	for name, theme := range card.Themes {
+		for _, value := range []string{theme.Background, theme.Text, theme.Muted, theme.Accent} {
+			if _, err := ParseHexColor(value); err != nil {
+				return &ValidationError{Field: "stats_card", Message: fmt.Sprintf("theme %q: %v", name, err)}
+			}
+		}
+	}
+	if card.Theme != "" {
+		if _, ok := card.FindTheme(card.Theme); !ok {
+			return &ValidationError{Field: "stats_card", Message: fmt.Sprintf("unknown theme %q", card.Theme)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseCertFingerprint decodes a SHA-256 certificate fingerprint written as
+// hex, with or without colons
+func ParseCertFingerprint(fingerprint string) ([]byte, error) {
+	pin, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, errors.New("must be a hex SHA-256 fingerprint")
+	}
+	return pin, nil
+}
+
+// ClientTLSConfig returns the TLS settings of a client trusting the PEM
+// certificates in caCert, or only the certificate whose SHA-256 fingerprint
+// is certSHA256, for servers with self-signed certificates. Either may be
+// empty; a relative caCert is resolved against the config directory.
+func ClientTLSConfig(caCert, certSHA256, configDir string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caCert != "" {
+		path := caCert
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(configDir, path)
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA certificate %s has no PEM certificates", caCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certSHA256 != "" {
+		pin, err := ParseCertFingerprint(certSHA256)
+		if err != nil {
+			return nil, fmt.Errorf("pinned certificate fingerprint %w", err)
+		}
+		// The pinned certificate replaces chain and host name verification
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return fmt.Errorf("server presented no certificate")
+			}
+			sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+			if !bytes.Equal(sum[:], pin) {
+				return fmt.Errorf("server certificate fingerprint %s does not match the pinned fingerprint", hex.EncodeToString(sum[:]))
+			}
+			return nil
+		}
+	}
+	return tlsConfig, nil
+}
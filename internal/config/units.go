@@ -0,0 +1,134 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Temperature units accepted by temperature_unit and the units query parameter
+const (
+	UnitCelsius    = "C"
+	UnitFahrenheit = "F"
+)
+
+// NormalizeTemperatureUnit maps "c", "celsius", "f", "fahrenheit" (any case) to C or F
+func NormalizeTemperatureUnit(unit string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "c", "celsius":
+		return UnitCelsius, true
+	case "f", "fahrenheit":
+		return UnitFahrenheit, true
+	}
+	return "", false
+}
+
+// CelsiusTo converts a Celsius value to the given unit
+func CelsiusTo(unit string, celsius float64) float64 {
+	if unit == UnitFahrenheit {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
+// CelsiusFrom converts a value in the given unit to Celsius
+func CelsiusFrom(unit string, value float64) float64 {
+	if unit == UnitFahrenheit {
+		return (value - 32) * 5 / 9
+	}
+	return value
+}
+
+// Temperature is a temperature threshold stored in Celsius. In JSON it can be
+// a plain number (Celsius) or a string with a unit suffix such as "85C" or "185F".
+type Temperature float64
+
+// ParseTemperature parses "85", "85C" or "185F" into Celsius
+func ParseTemperature(s string) (Temperature, error) {
+	s = strings.TrimSpace(s)
+	unit := UnitCelsius
+	if n := len(s); n > 0 {
+		if u, ok := NormalizeTemperatureUnit(s[n-1:]); ok {
+			unit = u
+			s = strings.TrimSpace(strings.TrimSuffix(s[:n-1], "°"))
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid temperature %q", s)
+	}
+	if unit == UnitFahrenheit {
+		value = (value - 32) * 5 / 9
+	}
+	return Temperature(value), nil
+}
+
+// Celsius returns the temperature in degrees Celsius
+func (t Temperature) Celsius() float64 {
+	return float64(t)
+}
+
+// UnmarshalJSON accepts a number (Celsius) or a string with a unit suffix
+func (t *Temperature) UnmarshalJSON(data []byte) error {
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		*t = Temperature(number)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("temperature must be a number or a string like \"85C\"")
+	}
+	parsed, err := ParseTemperature(text)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Hashrate units accepted by the hashrateUnit query parameter
+const (
+	UnitHs  = "H/s"
+	UnitKHs = "KH/s"
+	UnitMHs = "MH/s"
+	UnitGHs = "GH/s"
+	UnitTHs = "TH/s"
+	UnitPHs = "PH/s"
+	UnitEHs = "EH/s"
+)
+
+// hashesPerUnit is the number of H/s in one of each hashrate unit
+var hashesPerUnit = map[string]float64{
+	UnitHs:  1,
+	UnitKHs: 1e3,
+	UnitMHs: 1e6,
+	UnitGHs: 1e9,
+	UnitTHs: 1e12,
+	UnitPHs: 1e15,
+	UnitEHs: 1e18,
+}
+
+// NormalizeHashrateUnit maps "th", "ths" or "TH/s" (any case) to TH/s, and
+// likewise for the other units
+func NormalizeHashrateUnit(unit string) (string, bool) {
+	key := strings.ToUpper(strings.TrimSpace(unit))
+	key = strings.TrimSuffix(strings.TrimSuffix(key, "/S"), "S")
+	for name := range hashesPerUnit {
+		if strings.TrimSuffix(strings.ToUpper(name), "/S") == key {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ConvertHashrate converts a hashrate between two units
+func ConvertHashrate(value float64, from, to string) float64 {
+	if from == to || hashesPerUnit[from] == 0 || hashesPerUnit[to] == 0 {
+		return value
+	}
+	return value * hashesPerUnit[from] / hashesPerUnit[to]
+}
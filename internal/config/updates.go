@@ -0,0 +1,161 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileOnlySettings make the server read, write or run files at the given
+// path, so the configuration API may not change them; edit config.json on the
+// host instead. Dotted names refer to a key inside a section.
+var fileOnlySettings = []string{
+	"tls_cert_path",
+	"tls_key_path",
+	"data_path",
+	"logging.file",
+	"ssh_status.host_key_file",
+	"ssh_status.authorized_keys_file",
+	"power_events.script",
+}
+
+// RejectedField is a key of a configuration update that was refused
+type RejectedField struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ConfigUpdate is a partial configuration sent to the configuration API,
+// with each value still encoded
+type ConfigUpdate map[string]json.RawMessage
+
+// configFieldTypes maps the JSON keys of Config to their Go types
+var configFieldTypes = sync.OnceValue(func() map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field.Type
+	}
+	return fields
+})
+
+// IsConfigField reports whether a key is a field of config.json
+func IsConfigField(key string) bool {
+	_, ok := configFieldTypes()[key]
+	return ok
+}
+
+// CheckUpdate checks a configuration update before it is applied: every key
+// must be a configuration field spelled exactly as in config.json, every
+// value must decode into the field's type without unknown keys, and the
+// settings in fileOnlySettings must keep their current value. It returns the
+// refused fields, sorted by name; value checks such as ranges are left to
+// UpdateConfig.
+func (m *Manager) CheckUpdate(update ConfigUpdate) []RejectedField {
+	m.mu.RLock()
+	data, err := os.ReadFile(m.configPath)
+	m.mu.RUnlock()
+	var current map[string]interface{}
+	if err == nil {
+		json.Unmarshal(data, &current)
+	}
+
+	rejected := []RejectedField{}
+	fieldTypes := configFieldTypes()
+	for key, raw := range update {
+		fieldType, ok := fieldTypes[key]
+		if current, legacy := LegacyKeys[key]; legacy {
+			fieldType, ok = fieldTypes[current], true
+		}
+		if !ok {
+			rejected = append(rejected, RejectedField{Field: key, Reason: "unknown configuration field"})
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(reflect.New(fieldType).Interface()); err != nil {
+			rejected = append(rejected, RejectedField{Field: key, Reason: strings.TrimPrefix(err.Error(), "json: ")})
+			continue
+		}
+
+		var value interface{}
+		json.Unmarshal(raw, &value)
+		rejected = append(rejected, fileOnlyChanges(key, value, current[key])...)
+	}
+
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Field < rejected[j].Field })
+	return rejected
+}
+
+// CheckRestore checks that a complete config.json from outside the server,
+// such as an uploaded backup, keeps the settings in fileOnlySettings
+func (m *Manager) CheckRestore(data []byte) []RejectedField {
+	m.mu.RLock()
+	currentData, err := os.ReadFile(m.configPath)
+	m.mu.RUnlock()
+	var current, restored map[string]interface{}
+	if err == nil {
+		json.Unmarshal(currentData, &current)
+	}
+	json.Unmarshal(data, &restored)
+
+	rejected := []RejectedField{}
+	for key, value := range restored {
+		rejected = append(rejected, fileOnlyChanges(key, value, current[key])...)
+	}
+	sort.Slice(rejected, func(i, j int) bool { return rejected[i].Field < rejected[j].Field })
+	return rejected
+}
+
+// fileOnlyChanges returns the settings in fileOnlySettings that a new value
+// of a top-level key changes
+func fileOnlyChanges(key string, value, current interface{}) []RejectedField {
+	var rejected []RejectedField
+	for _, setting := range fileOnlySettings {
+		section, name, nested := strings.Cut(setting, ".")
+		if section != key {
+			continue
+		}
+		newValue, oldValue := value, current
+		if nested {
+			newValue, oldValue = sectionValue(value, name), sectionValue(current, name)
+		}
+		// Clearing a path restores the default, which is always allowed
+		if newValue != nil && newValue != "" && !reflect.DeepEqual(newValue, oldValue) {
+			rejected = append(rejected, RejectedField{Field: setting, Reason: "can only be changed in config.json on the server"})
+		}
+	}
+	return rejected
+}
+
+// Values decodes the update for UpdateConfig
+func (u ConfigUpdate) Values() (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(u))
+	for key, raw := range u {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", key, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// sectionValue returns a key of a decoded section, or nil
+func sectionValue(section interface{}, key string) interface{} {
+	if values, ok := section.(map[string]interface{}); ok {
+		return values[key]
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// newTestManager returns a manager reading the given config.json
+func newTestManager(t *testing.T, configJSON string) *Manager {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(configJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Manager{configPath: path, log: logger.New(logger.ModuleConfig)}
+}
+
+func TestCheckUpdateFileOnlySettings(t *testing.T) {
+	m := newTestManager(t, `{
+		"data_path": "data",
+		"logging": {"output": "file", "file": "logs/dashboard.log"},
+		"power_events": {"enabled": true, "script": "/usr/local/bin/on-power.sh"}
+	}`)
+
+	tests := []struct {
+		name     string
+		update   string
+		rejected []string
+	}{
+		{"top-level path", `{"data_path": "/tmp/elsewhere"}`, []string{"data_path"}},
+		{"section path", `{"logging": {"output": "file", "file": "/etc/passwd"}}`, []string{"logging.file"}},
+		{"power script", `{"power_events": {"enabled": true, "script": "/bin/sh"}}`, []string{"power_events.script"}},
+		{"path in a section not set yet", `{"ssh_status": {"host_key_file": "/root/.ssh/id_ed25519"}}`, []string{"ssh_status.host_key_file"}},
+		{"current value", `{"power_events": {"enabled": false, "script": "/usr/local/bin/on-power.sh"}}`, nil},
+		{"cleared value", `{"power_events": {"enabled": true, "script": ""}}`, nil},
+		{"other settings", `{"title": "Mining", "data_path": "data"}`, nil},
+		{"several paths", `{"tls_cert_path": "a.pem", "tls_key_path": "b.pem"}`, []string{"tls_cert_path", "tls_key_path"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var update ConfigUpdate
+			if err := json.Unmarshal([]byte(tt.update), &update); err != nil {
+				t.Fatal(err)
+			}
+			rejected := m.CheckUpdate(update)
+			if len(rejected) != len(tt.rejected) {
+				t.Fatalf("rejected %v, want %v", rejected, tt.rejected)
+			}
+			for i, field := range tt.rejected {
+				if rejected[i].Field != field {
+					t.Errorf("rejected[%d] = %s, want %s", i, rejected[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckRestoreFileOnlySettings(t *testing.T) {
+	m := newTestManager(t, `{"power_events": {"enabled": true, "script": "/usr/local/bin/on-power.sh"}}`)
+
+	tests := []struct {
+		name     string
+		restored string
+		rejected int
+	}{
+		{"same script", `{"power_events": {"enabled": true, "script": "/usr/local/bin/on-power.sh"}}`, 0},
+		{"other script", `{"power_events": {"enabled": true, "script": "/tmp/payload"}}`, 1},
+		{"no power events", `{"title": "Mining"}`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rejected := m.CheckRestore([]byte(tt.restored)); len(rejected) != tt.rejected {
+				t.Errorf("rejected %v, want %d fields", rejected, tt.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AmbientMetric is one ambient (room or outdoor) temperature sample
+type AmbientMetric struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Source      string    `json:"source"`
+	Temperature float64   `json:"temperature"` // Celsius
+	Humidity    *float64  `json:"humidity,omitempty"`
+}
+
+const (
+	// Schema for ambient temperature samples
+	createAmbientMetricsTable = `
+		CREATE TABLE IF NOT EXISTS ambient_metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			source TEXT NOT NULL,
+			temperature REAL NOT NULL,
+			humidity REAL
+		);
+	`
+
+	createAmbientMetricsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_ambient_timestamp ON ambient_metrics(timestamp);
+	`
+)
+
+// InsertAmbientMetric inserts an ambient temperature sample
+func (m *Manager) InsertAmbientMetric(metric *AmbientMetric) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO ambient_metrics (timestamp, source, temperature, humidity)
+		VALUES (?, ?, ?, ?)
+	`, metric.Timestamp, metric.Source, metric.Temperature, metric.Humidity)
+	if err != nil {
+		return fmt.Errorf("failed to insert ambient metric: %w", err)
+	}
+	return nil
+}
+
+// GetAmbientMetrics retrieves ambient samples within a time range, oldest first
+func (m *Manager) GetAmbientMetrics(startTime, endTime time.Time, limit int) ([]*AmbientMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT timestamp, source, temperature, humidity
+		FROM ambient_metrics
+		WHERE timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
+		LIMIT ?
+	`, startTime, endTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ambient metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []*AmbientMetric
+	for rows.Next() {
+		metric := &AmbientMetric{}
+		var humidity sql.NullFloat64
+		if err := rows.Scan(&metric.Timestamp, &metric.Source, &metric.Temperature, &humidity); err != nil {
+			return nil, err
+		}
+		if humidity.Valid {
+			metric.Humidity = &humidity.Float64
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, rows.Err()
+}
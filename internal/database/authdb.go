@@ -0,0 +1,148 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// AuthDB keeps the authentication audit trail and the failed login counters
+// in auth.db, next to metrics.db. Unlike the metrics database it is open
+// whether or not data collection is enabled, so lockouts survive restarts.
+type AuthDB struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+var (
+	authDB   *AuthDB
+	authDBMu sync.RWMutex
+)
+
+// LoginFailure is the failed login count of one username or client IP
+type LoginFailure struct {
+	Key         string // "user:<name>" or "ip:<address>"
+	Count       int
+	WindowStart time.Time
+	LockedUntil time.Time
+}
+
+const (
+	// Schema for the failed login counters of the login lockout
+	createLoginFailuresTable = `
+		CREATE TABLE IF NOT EXISTS login_failures (
+			key TEXT PRIMARY KEY,
+			count INTEGER NOT NULL,
+			window_start DATETIME NOT NULL,
+			locked_until DATETIME NOT NULL
+		);
+	`
+)
+
+// OpenAuthDB opens auth.db in the data directory, creating it if needed.
+// Opening it again is a no-op.
+func OpenAuthDB(dataDir string) error {
+	authDBMu.Lock()
+	defer authDBMu.Unlock()
+
+	if authDB != nil {
+		return nil
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	dbFile := filepath.Join(dataDir, "auth.db")
+	db, err := sql.Open("sqlite", dbFile+"?_time_format="+TimeFormat)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", dbFile, err)
+	}
+	for _, stmt := range []string{
+		`PRAGMA journal_mode=WAL; PRAGMA synchronous=NORMAL; PRAGMA busy_timeout=5000;`,
+		createAuthEventsTable,
+		createAuthEventsIndexes,
+		createLoginFailuresTable,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return fmt.Errorf("failed to initialize %s: %w", dbFile, err)
+		}
+	}
+
+	authDB = &AuthDB{db: db, log: logger.New(logger.ModuleDatabase)}
+	authDB.log.Info("Authentication database opened at: %s", dbFile)
+	return nil
+}
+
+// CloseAuthDB closes auth.db
+func CloseAuthDB() error {
+	authDBMu.Lock()
+	defer authDBMu.Unlock()
+
+	if authDB == nil {
+		return nil
+	}
+	err := authDB.db.Close()
+	authDB = nil
+	return err
+}
+
+// AuthInstance returns the authentication database, or nil before it is opened
+func AuthInstance() *AuthDB {
+	authDBMu.RLock()
+	defer authDBMu.RUnlock()
+	return authDB
+}
+
+// GetLoginFailures returns every stored failed login counter
+func (a *AuthDB) GetLoginFailures() ([]*LoginFailure, error) {
+	rows, err := a.db.Query(`SELECT key, count, window_start, locked_until FROM login_failures`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query login failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []*LoginFailure
+	for rows.Next() {
+		f := &LoginFailure{}
+		if err := rows.Scan(&f.Key, &f.Count, &f.WindowStart, &f.LockedUntil); err != nil {
+			return nil, fmt.Errorf("failed to scan login failure: %w", err)
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// SaveLoginFailure stores a failed login counter
+func (a *AuthDB) SaveLoginFailure(f *LoginFailure) error {
+	_, err := a.db.Exec(`
+		INSERT OR REPLACE INTO login_failures (key, count, window_start, locked_until)
+		VALUES (?, ?, ?, ?)
+	`, f.Key, f.Count, f.WindowStart, f.LockedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to save login failure: %w", err)
+	}
+	return nil
+}
+
+// DeleteLoginFailures removes the counters of the given keys
+func (a *AuthDB) DeleteLoginFailures(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+	_, err := a.db.Exec(`DELETE FROM login_failures WHERE key IN (?`+strings.Repeat(", ?", len(keys)-1)+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete login failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Authentication event types
+const (
+	AuthLoginSuccess = "login_success"
+	AuthLoginFailure = "login_failure"
+	AuthLoginBlocked = "login_blocked" // Attempt rejected while locked out
+	AuthLockout      = "lockout"       // A username or client IP was locked
+)
+
+// AuthEvent records a login attempt or lockout
+type AuthEvent struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Username  string    `json:"username"`
+	ClientIP  string    `json:"clientIp"`
+	Message   string    `json:"message,omitempty"`
+}
+
+const (
+	// Schema for the authentication audit trail
+	createAuthEventsTable = `
+		CREATE TABLE IF NOT EXISTS auth_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			type TEXT NOT NULL,
+			username TEXT NOT NULL,
+			client_ip TEXT NOT NULL,
+			message TEXT
+		);
+	`
+
+	createAuthEventsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_auth_events_timestamp ON auth_events(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_auth_events_username_timestamp ON auth_events(username, timestamp);
+	`
+)
+
+// InsertAuthEvent records an authentication event
+func (a *AuthDB) InsertAuthEvent(event *AuthEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	result, err := a.db.Exec(`
+		INSERT INTO auth_events (timestamp, type, username, client_ip, message)
+		VALUES (?, ?, ?, ?, ?)
+	`, event.Timestamp, event.Type, event.Username, event.ClientIP, event.Message)
+	if err != nil {
+		return fmt.Errorf("failed to insert auth event: %w", err)
+	}
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetAuthEvents retrieves the most recent authentication events since the
+// given time, optionally for one username and of the given types
+func (a *AuthDB) GetAuthEvents(since time.Time, username string, types []string, limit int) ([]*AuthEvent, error) {
+	query := `SELECT id, timestamp, type, username, client_ip, COALESCE(message, '') FROM auth_events WHERE timestamp >= ?`
+	args := []interface{}{since}
+
+	if username != "" {
+		query += " AND username = ?"
+		args = append(args, username)
+	}
+	if len(types) > 0 {
+		query += " AND type IN (?" + strings.Repeat(", ?", len(types)-1) + ")"
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+
+	query += " ORDER BY timestamp DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query auth events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*AuthEvent
+	for rows.Next() {
+		event := &AuthEvent{}
+		if err := rows.Scan(&event.ID, &event.Timestamp, &event.Type, &event.Username, &event.ClientIP, &event.Message); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// PruneAuthEvents deletes the authentication events recorded before the given time
+func (a *AuthDB) PruneAuthEvents(before time.Time) (int64, error) {
+	result, err := a.db.Exec("DELETE FROM auth_events WHERE timestamp < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune auth events: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Chart aggregation functions
+const (
+	ChartAvg = "avg"
+	ChartMin = "min"
+	ChartMax = "max"
+)
+
+// ChartMetric is a device metric that can be charted
+type ChartMetric struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+	expr string // SQL expression over axeos_metrics
+}
+
+// chartMetrics are the device metrics served by GetChartSeries, by API name
+var chartMetrics = map[string]ChartMetric{
+	"hashrate":       {Name: "hashrate", Unit: "GH/s", expr: "hashrate / 1e9"},
+	"temperature":    {Name: "temperature", Unit: "°C", expr: "temperature"},
+	"power":          {Name: "power", Unit: "W", expr: "power"},
+	"efficiency":     {Name: "efficiency", Unit: "J/TH", expr: "power * 1e12 / NULLIF(hashrate, 0)"},
+	"fanSpeed":       {Name: "fanSpeed", Unit: "%", expr: "fan_speed"},
+	"frequency":      {Name: "frequency", Unit: "MHz", expr: "frequency"},
+	"voltage":        {Name: "voltage", Unit: "mV", expr: "voltage"},
+	"coreVoltage":    {Name: "coreVoltage", Unit: "mV", expr: "core_voltage"},
+	"current":        {Name: "current", Unit: "mA", expr: "current"},
+	"sharesAccepted": {Name: "sharesAccepted", Unit: "shares", expr: "shares_accepted"},
+	"sharesRejected": {Name: "sharesRejected", Unit: "shares", expr: "shares_rejected"},
+}
+
+// GetChartMetric returns a chartable metric by name
+func GetChartMetric(name string) (ChartMetric, bool) {
+	metric, ok := chartMetrics[name]
+	return metric, ok
+}
+
+// ChartMetricNames returns the names of the chartable metrics, sorted
+func ChartMetricNames() []string {
+	names := make([]string, 0, len(chartMetrics))
+	for name := range chartMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChartPoint is one bucket of a chart series
+type ChartPoint struct {
+	Timestamp time.Time `json:"timestamp"` // Start of the bucket
+	Value     float64   `json:"value"`
+	Samples   int       `json:"samples"`
+}
+
+// GetChartSeries aggregates a device metric into buckets of a fixed length
+// between start and end, oldest first. Buckets are aligned to multiples of
+// their length since the Unix epoch; buckets without samples are left out.
+func (m *Manager) GetChartSeries(metric ChartMetric, instanceID string, start, end time.Time, bucket time.Duration, agg string) ([]*ChartPoint, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	var fn string
+	switch agg {
+	case ChartAvg:
+		fn = "AVG"
+	case ChartMin:
+		fn = "MIN"
+	case ChartMax:
+		fn = "MAX"
+	default:
+		return nil, fmt.Errorf("unknown aggregation %q", agg)
+	}
+	seconds := int64(bucket / time.Second)
+	if seconds <= 0 {
+		return nil, fmt.Errorf("bucket must be at least one second")
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT (CAST(strftime('%%s', timestamp) AS INTEGER) / ?) * ? AS bucket, %s(value), COUNT(*)
+		FROM (
+			SELECT timestamp, %s AS value
+			FROM axeos_metrics
+			WHERE instance_id = ? AND timestamp >= ? AND timestamp < ?
+		)
+		WHERE value IS NOT NULL
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`, fn, metric.expr), seconds, seconds, instanceID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chart series: %w", err)
+	}
+	defer rows.Close()
+
+	points := []*ChartPoint{}
+	for rows.Next() {
+		var unix int64
+		point := &ChartPoint{}
+		if err := rows.Scan(&unix, &point.Value, &point.Samples); err != nil {
+			return nil, fmt.Errorf("failed to scan chart point: %w", err)
+		}
+		point.Timestamp = time.Unix(unix, 0)
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Classes of collection failures
+const (
+	ErrorClassUnreachable = "unreachable" // Network error or timeout
+	ErrorClassBadStatus   = "bad_status"  // The source answered with an HTTP error
+	ErrorClassParse       = "parse_error" // The response could not be parsed
+	ErrorClassOther       = "other"
+)
+
+// CollectionError records a failed collection from a device, pool or node, so
+// gaps in the history can be explained
+type CollectionError struct {
+	Timestamp  time.Time `json:"timestamp"`
+	SourceType string    `json:"sourceType"` // axeos, pool or node
+	SourceID   string    `json:"sourceId"`
+	Class      string    `json:"class"`
+	Message    string    `json:"message"`
+}
+
+const (
+	// Schema for collection failures
+	createCollectionErrorsTable = `
+		CREATE TABLE IF NOT EXISTS collection_errors (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			source_type TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			class TEXT NOT NULL,
+			message TEXT
+		);
+	`
+
+	createCollectionErrorsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_collection_errors_timestamp ON collection_errors(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_collection_errors_source_timestamp ON collection_errors(source_type, source_id, timestamp);
+	`
+)
+
+// InsertCollectionError records a collection failure
+func (m *Manager) InsertCollectionError(collectionError *CollectionError) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if collectionError.Timestamp.IsZero() {
+		collectionError.Timestamp = time.Now()
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO collection_errors (timestamp, source_type, source_id, class, message)
+		VALUES (?, ?, ?, ?, ?)
+	`, collectionError.Timestamp, collectionError.SourceType, collectionError.SourceID,
+		collectionError.Class, collectionError.Message)
+	if err != nil {
+		return fmt.Errorf("failed to insert collection error: %w", err)
+	}
+	return nil
+}
+
+// GetCollectionErrors retrieves the failures of one source within a time range, newest first
+func (m *Manager) GetCollectionErrors(sourceType, sourceID string, startTime, endTime time.Time, limit int) ([]*CollectionError, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT timestamp, source_type, source_id, class, COALESCE(message, '')
+		FROM collection_errors
+		WHERE source_type = ? AND source_id = ? AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp DESC
+		LIMIT ?
+	`, sourceType, sourceID, startTime, endTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection errors: %w", err)
+	}
+	defer rows.Close()
+
+	var collectionErrors []*CollectionError
+	for rows.Next() {
+		e := &CollectionError{}
+		if err := rows.Scan(&e.Timestamp, &e.SourceType, &e.SourceID, &e.Class, &e.Message); err != nil {
+			return nil, err
+		}
+		collectionErrors = append(collectionErrors, e)
+	}
+
+	return collectionErrors, rows.Err()
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DiskStatus describes the space used by the metrics database and left on its volume
+type DiskStatus struct {
+	DataPath      string `json:"dataPath"`
+	FreeBytes     uint64 `json:"freeBytes"`
+	DatabaseBytes int64  `json:"databaseBytes"`
+}
+
+// DataPath returns the directory holding metrics.db
+func (m *Manager) DataPath() string {
+	if m.tempDir != "" {
+		return m.tempDir
+	}
+	return m.dataPath
+}
+
+// DiskStatus reports free space on the data volume and the size of metrics.db (including WAL files)
+func (m *Manager) DiskStatus() (*DiskStatus, error) {
+	dataPath := m.DataPath()
+	free, err := freeDiskSpace(dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read free disk space: %w", err)
+	}
+
+	status := &DiskStatus{DataPath: dataPath, FreeBytes: free}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if info, err := os.Stat(filepath.Join(dataPath, "metrics.db"+suffix)); err == nil {
+			status.DatabaseBytes += info.Size()
+		}
+	}
+
+	return status, nil
+}
+
+// PruneOldest deletes the oldest fraction (0-1) of rows from each metrics table
+// and checkpoints the WAL so the space can be reused. Daily snapshots are
+// kept, and so is the authentication audit log, which has its own retention.
+func (m *Manager) PruneOldest(fraction float64) (int64, error) {
+	db, err := m.conn()
+	if err != nil {
+		return 0, err
+	}
+	tables := []string{"axeos_metrics", "pool_metrics", "node_metrics", "ambient_metrics", "collection_errors"}
+	var deleted int64
+
+	for _, table := range tables {
+		var count int64
+		if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count); err != nil {
+			return deleted, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+
+		toDelete := int64(float64(count) * fraction)
+		if toDelete == 0 {
+			continue
+		}
+
+		result, err := db.Exec(fmt.Sprintf(
+			"DELETE FROM %s WHERE id IN (SELECT id FROM %s ORDER BY timestamp ASC LIMIT ?)", table, table), toDelete)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to prune %s: %w", table, err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+
+	if _, err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		m.log.Warn("WAL checkpoint after prune failed: %v", err)
+	}
+
+	return deleted, nil
+}
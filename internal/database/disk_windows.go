@@ -0,0 +1,26 @@
+//go:build windows
+
+package database
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// freeDiskSpace returns the number of bytes available to the current user on the volume holding path
+func freeDiskSpace(path string) (uint64, error) {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	getDiskFreeSpaceEx := kernel32.NewProc("GetDiskFreeSpaceExW")
+
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeBytes uint64
+	ret, _, callErr := getDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&freeBytes)), 0, 0)
+	if ret == 0 {
+		return 0, callErr
+	}
+	return freeBytes, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// EnergyFleetID is the source id of the fleet-wide energy bucket
+const EnergyFleetID = "_fleet"
+
+// EnergyBucket is the energy used by a device (or the whole fleet) on one day
+type EnergyBucket struct {
+	Day       string    `json:"day"`
+	SourceID  string    `json:"sourceId"`
+	EnergyKWh float64   `json:"energyKWh"`
+	CO2Kg     float64   `json:"co2Kg"`
+	Cost      float64   `json:"cost"` // At the electricity price when the energy was used
+	Seconds   int       `json:"seconds"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+const (
+	// Schema for daily energy buckets (excluded from pruning)
+	createEnergyDailyTable = `
+		CREATE TABLE IF NOT EXISTS energy_daily (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			day TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			energy_wh REAL NOT NULL DEFAULT 0,
+			co2_grams REAL NOT NULL DEFAULT 0,
+			cost REAL NOT NULL DEFAULT 0,
+			seconds INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL,
+			UNIQUE(source_id, day)
+		);
+	`
+)
+
+// AddEnergy adds watt-hours measured over the given seconds, the estimated
+// emissions in grams of CO2 and the cost of the energy to a device's bucket for
+// the day and to the fleet bucket, in one transaction
+func (m *Manager) AddEnergy(instanceID, day string, wattHours, co2Grams, cost float64, seconds int) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin energy update: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	upsert := `
+		INSERT INTO energy_daily (day, source_id, energy_wh, co2_grams, cost, seconds, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source_id, day) DO UPDATE SET
+			energy_wh = energy_wh + excluded.energy_wh,
+			co2_grams = co2_grams + excluded.co2_grams,
+			cost = cost + excluded.cost,
+			seconds = seconds + excluded.seconds,
+			updated_at = excluded.updated_at`
+
+	if _, err := tx.Exec(upsert, day, instanceID, wattHours, co2Grams, cost, seconds, now); err != nil {
+		return fmt.Errorf("failed to add device energy: %w", err)
+	}
+	// Fleet seconds are not meaningful across devices, only energy is summed
+	if _, err := tx.Exec(upsert, day, EnergyFleetID, wattHours, co2Grams, cost, 0, now); err != nil {
+		return fmt.Errorf("failed to add fleet energy: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetEnergyBuckets returns the daily buckets of a source from the given day (inclusive), oldest first
+func (m *Manager) GetEnergyBuckets(sourceID, sinceDay string) ([]*EnergyBucket, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT day, source_id, energy_wh, co2_grams, cost, seconds, updated_at
+		FROM energy_daily
+		WHERE source_id = ? AND day >= ?
+		ORDER BY day ASC
+	`, sourceID, sinceDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query energy buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*EnergyBucket
+	for rows.Next() {
+		b := &EnergyBucket{}
+		var wattHours, co2Grams float64
+		if err := rows.Scan(&b.Day, &b.SourceID, &wattHours, &co2Grams, &b.Cost, &b.Seconds, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		b.EnergyKWh = wattHours / 1000
+		b.CO2Kg = co2Grams / 1000
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+// GetDayEnergy returns the kWh recorded for a source on one day (0 when there is no bucket)
+func (m *Manager) GetDayEnergy(sourceID, day string) (float64, error) {
+	db, err := m.conn()
+	if err != nil {
+		return 0, err
+	}
+	var wattHours sql.NullFloat64
+	err = db.QueryRow(`SELECT energy_wh FROM energy_daily WHERE source_id = ? AND day = ?`,
+		sourceID, day).Scan(&wattHours)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query day energy: %w", err)
+	}
+	return wattHours.Float64 / 1000, nil
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event types recorded in the events table
+const (
+	EventBlockFound  = "block_found"
+	EventBestDiff    = "best_diff"
+	EventRestart     = "restart"
+	EventMaintenance = "maintenance"
+	EventAlert       = "alert"
+)
+
+// Event categories, each with its own retention
+const (
+	EventCategoryEvents = "events" // Milestones and every type not listed below
+	EventCategoryAlerts = "alerts"
+	EventCategoryAudit  = "audit" // Actions taken by users
+)
+
+// EventCategories lists every event category
+var EventCategories = []string{EventCategoryEvents, EventCategoryAlerts, EventCategoryAudit}
+
+// eventCategoryTypes are the event types of the alerts and audit categories
+var eventCategoryTypes = map[string][]string{
+	EventCategoryAlerts: {EventAlert},
+	EventCategoryAudit:  {EventRestart, EventMaintenance},
+}
+
+// Event represents a notable dashboard event (milestones, alerts, actions)
+type Event struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+}
+
+const (
+	// Schema for dashboard events
+	createEventsTable = `
+		CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			type TEXT NOT NULL,
+			source TEXT NOT NULL,
+			title TEXT NOT NULL,
+			message TEXT
+		);
+	`
+
+	createEventsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_events_type ON events(type);
+	`
+)
+
+// Instance returns the database manager if it has been initialized, or nil
+// when data collection is disabled
+func Instance() *Manager {
+	if instance == nil || instance.DB() == nil {
+		return nil
+	}
+	return instance
+}
+
+// InsertEvent records a single event
+func (m *Manager) InsertEvent(event *Event) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO events (timestamp, type, source, title, message) VALUES (?, ?, ?, ?, ?)`,
+		event.Timestamp, event.Type, event.Source, event.Title, event.Message,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert event: %w", err)
+	}
+
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// PruneEvents deletes the events of a category recorded before the given time
+func (m *Manager) PruneEvents(category string, before time.Time) (int64, error) {
+	db, err := m.conn()
+	if err != nil {
+		return 0, err
+	}
+	var types []string
+	negate := ""
+	switch category {
+	case EventCategoryAlerts, EventCategoryAudit:
+		types = eventCategoryTypes[category]
+	case EventCategoryEvents:
+		types = append(types, eventCategoryTypes[EventCategoryAlerts]...)
+		types = append(types, eventCategoryTypes[EventCategoryAudit]...)
+		negate = "NOT "
+	default:
+		return 0, fmt.Errorf("unknown event category %q", category)
+	}
+
+	args := []interface{}{before}
+	placeholders := make([]string, len(types))
+	for i, t := range types {
+		placeholders[i] = "?"
+		args = append(args, t)
+	}
+
+	result, err := db.Exec(
+		"DELETE FROM events WHERE timestamp < ? AND type "+negate+"IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", category, err)
+	}
+	return result.RowsAffected()
+}
+
+// GetEvents retrieves the most recent events since the given time, optionally filtered by type
+func (m *Manager) GetEvents(since time.Time, types []string, limit int) ([]*Event, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, timestamp, type, source, title, message FROM events WHERE timestamp >= ?`
+	args := []interface{}{since}
+
+	if len(types) > 0 {
+		placeholders := make([]string, len(types))
+		for i, t := range types {
+			placeholders[i] = "?"
+			args = append(args, t)
+		}
+		query += " AND type IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	query += " ORDER BY timestamp DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		event := &Event{}
+		var message *string
+		if err := rows.Scan(&event.ID, &event.Timestamp, &event.Type, &event.Source, &event.Title, &message); err != nil {
+			return nil, err
+		}
+		if message != nil {
+			event.Message = *message
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetLatestAxeOSMetric returns the most recent stored metric for an instance, or nil
+func (m *Manager) GetLatestAxeOSMetric(instanceID string) (*AxeOSMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT timestamp, instance_id, instance_name, hashrate, temperature, power,
+		       fan_speed, best_diff, shares_accepted, shares_rejected,
+		       frequency, voltage, core_voltage, current
+		FROM axeos_metrics
+		WHERE instance_id = ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest AxeOS metric: %w", err)
+	}
+	defer rows.Close()
+
+	metrics, err := scanAxeOSMetrics(rows)
+	if err != nil || len(metrics) == 0 {
+		return nil, err
+	}
+	return metrics[0], nil
+}
+
+// GetLatestPoolMetric returns the most recent stored metric for a pool, or nil
+func (m *Manager) GetLatestPoolMetric(poolID string) (*PoolMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT timestamp, pool_id, pool_name, pool_hashrate, pool_workers,
+		       network_hashrate, network_difficulty, last_block_time, blocks_found
+		FROM pool_metrics
+		WHERE pool_id = ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest pool metric: %w", err)
+	}
+	defer rows.Close()
+
+	metrics, err := scanPoolMetrics(rows)
+	if err != nil || len(metrics) == 0 {
+		return nil, err
+	}
+	return metrics[0], nil
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// EventFailover is the event type of a device switching between its primary and fallback pool
+const EventFailover = "pool_failover"
+
+// PoolFailover is a period a device mined on its fallback stratum pool
+type PoolFailover struct {
+	ID              int64      `json:"id"`
+	InstanceID      string     `json:"instanceId"`
+	StartedAt       time.Time  `json:"startedAt"`
+	EndedAt         *time.Time `json:"endedAt,omitempty"` // Nil while the device is still on its fallback pool
+	PrimaryPool     string     `json:"primaryPool,omitempty"`
+	FallbackPool    string     `json:"fallbackPool,omitempty"`
+	Alerted         bool       `json:"alerted"` // An alert was raised for the length of the failover
+	Active          bool       `json:"active"`
+	DurationSeconds int64      `json:"durationSeconds"`
+}
+
+const (
+	// Schema for pool failover periods
+	createPoolFailoversTable = `
+		CREATE TABLE IF NOT EXISTS pool_failovers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			instance_id TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME,
+			primary_pool TEXT,
+			fallback_pool TEXT,
+			alerted INTEGER NOT NULL DEFAULT 0
+		);
+	`
+
+	createPoolFailoversIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_pool_failovers_instance_started ON pool_failovers(instance_id, started_at);
+		CREATE INDEX IF NOT EXISTS idx_pool_failovers_started ON pool_failovers(started_at);
+	`
+)
+
+// scanPoolFailover reads a pool failover row and fills in its duration
+func scanPoolFailover(row interface{ Scan(...interface{}) error }) (*PoolFailover, error) {
+	var f PoolFailover
+	var endedAt sql.NullTime
+	var primary, fallback sql.NullString
+	if err := row.Scan(&f.ID, &f.InstanceID, &f.StartedAt, &endedAt, &primary, &fallback, &f.Alerted); err != nil {
+		return nil, err
+	}
+	f.PrimaryPool, f.FallbackPool = primary.String, fallback.String
+
+	end := time.Now()
+	if endedAt.Valid {
+		end = endedAt.Time
+		f.EndedAt = &endedAt.Time
+	} else {
+		f.Active = true
+	}
+	f.DurationSeconds = int64(end.Sub(f.StartedAt).Seconds())
+	return &f, nil
+}
+
+// StartPoolFailover records that a device switched to its fallback pool
+func (m *Manager) StartPoolFailover(failover *PoolFailover) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	result, err := db.Exec(`
+		INSERT INTO pool_failovers (instance_id, started_at, primary_pool, fallback_pool)
+		VALUES (?, ?, ?, ?)
+	`, failover.InstanceID, failover.StartedAt, failover.PrimaryPool, failover.FallbackPool)
+	if err != nil {
+		return fmt.Errorf("failed to insert pool failover: %w", err)
+	}
+	failover.ID, _ = result.LastInsertId()
+	failover.Active = true
+	return nil
+}
+
+// EndPoolFailover records that a device returned to its primary pool
+func (m *Manager) EndPoolFailover(id int64, endedAt time.Time) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`UPDATE pool_failovers SET ended_at = ? WHERE id = ?`, endedAt, id); err != nil {
+		return fmt.Errorf("failed to end pool failover: %w", err)
+	}
+	return nil
+}
+
+// MarkPoolFailoverAlerted records that an alert was raised for a failover
+func (m *Manager) MarkPoolFailoverAlerted(id int64) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`UPDATE pool_failovers SET alerted = 1 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to update pool failover: %w", err)
+	}
+	return nil
+}
+
+// GetActivePoolFailover returns the failover a device is in, or nil when it
+// is on its primary pool
+func (m *Manager) GetActivePoolFailover(instanceID string) (*PoolFailover, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	row := db.QueryRow(`
+		SELECT id, instance_id, started_at, ended_at, primary_pool, fallback_pool, alerted
+		FROM pool_failovers
+		WHERE instance_id = ? AND ended_at IS NULL
+		ORDER BY started_at DESC
+		LIMIT 1
+	`, instanceID)
+	failover, err := scanPoolFailover(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pool failover: %w", err)
+	}
+	return failover, nil
+}
+
+// GetPoolFailovers retrieves failovers that were active at or after since,
+// those still going on first and then newest first, optionally for one
+// device. A limit of 0 or less returns all.
+func (m *Manager) GetPoolFailovers(instanceID string, since time.Time, limit int) ([]*PoolFailover, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, instance_id, started_at, ended_at, primary_pool, fallback_pool, alerted
+		FROM pool_failovers
+		WHERE (ended_at IS NULL OR ended_at >= ?)`
+	args := []interface{}{since}
+	if instanceID != "" {
+		query += " AND instance_id = ?"
+		args = append(args, instanceID)
+	}
+	query += " ORDER BY ended_at IS NULL DESC, started_at DESC" // Failovers still going on first
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pool failovers: %w", err)
+	}
+	defer rows.Close()
+
+	var failovers []*PoolFailover
+	for rows.Next() {
+		failover, err := scanPoolFailover(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pool failover: %w", err)
+		}
+		failovers = append(failovers, failover)
+	}
+	return failovers, rows.Err()
+}
+
+// PrunePoolFailovers deletes failovers that ended before a cutoff
+func (m *Manager) PrunePoolFailovers(before time.Time) (int64, error) {
+	db, err := m.conn()
+	if err != nil {
+		return 0, err
+	}
+	result, err := db.Exec(`DELETE FROM pool_failovers WHERE ended_at IS NOT NULL AND ended_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune pool failovers: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// HashrateUnit is the unit of every hashrate column. Pools and nodes report
+// H/s; AxeOS devices report GH/s, so device hashrates are converted on the
+// way in and out and AxeOSMetric, AxeOSRollup, FleetHashrate and AxeOS
+// DailySnapshot values stay in GH/s.
+const HashrateUnit = "H/s"
+
+// hashesPerGH converts the GH/s of AxeOS devices to H/s
+const hashesPerGH = 1e9
+
+// hashrateUnitKey records the unit of the stored hashrates in schema_meta
+const hashrateUnitKey = "hashrate_unit"
+
+const (
+	// Schema for key/value metadata about the stored data
+	createSchemaMetaTable = `
+		CREATE TABLE IF NOT EXISTS schema_meta (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);
+	`
+)
+
+// deviceHashrateColumns are the columns that held GH/s before hashrates were
+// stored in H/s, with the condition selecting device rows
+var deviceHashrateColumns = []struct {
+	table   string
+	columns []string
+	where   string
+}{
+	{"axeos_metrics", []string{"hashrate"}, ""},
+	{"axeos_rollups_hourly", []string{"avg_hashrate", "min_hashrate", "max_hashrate"}, ""},
+	{"axeos_rollups_daily", []string{"avg_hashrate", "min_hashrate", "max_hashrate"}, ""},
+	{"daily_snapshots", []string{"avg_hashrate", "max_hashrate"}, "source_type = 'axeos'"},
+}
+
+// normalizeHashrateUnits converts device hashrates of databases created
+// before hashrates were stored in H/s, once, and records the unit
+func (m *Manager) normalizeHashrateUnits() error {
+	var unit string
+	err := m.db.QueryRow(`SELECT value FROM schema_meta WHERE key = ?`, hashrateUnitKey).Scan(&unit)
+	if err == nil && unit == HashrateUnit {
+		return nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read hashrate unit: %w", err)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var converted int64
+	for _, table := range deviceHashrateColumns {
+		for _, column := range table.columns {
+			query := fmt.Sprintf("UPDATE %s SET %s = %s * %.0f WHERE %s IS NOT NULL", table.table, column, column, hashesPerGH, column)
+			if table.where != "" {
+				query += " AND " + table.where
+			}
+			result, err := tx.Exec(query)
+			if err != nil {
+				return fmt.Errorf("failed to convert %s.%s to %s: %w", table.table, column, HashrateUnit, err)
+			}
+			if n, _ := result.RowsAffected(); column == table.columns[0] {
+				converted += n
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO schema_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, hashrateUnitKey, HashrateUnit); err != nil {
+		return fmt.Errorf("failed to record hashrate unit: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if converted > 0 {
+		m.log.Info("Converted %d stored device hashrates from GH/s to %s", converted, HashrateUnit)
+	}
+	return nil
+}
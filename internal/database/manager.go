@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,10 +18,18 @@ var (
 	once     sync.Once
 )
 
+// ErrDatabaseClosed is returned by queries made after the database was closed
+var ErrDatabaseClosed = errors.New("database is closed")
+
+// MemoryDataPath selects an ephemeral database: it lives in a temporary
+// directory that is deleted when the database is closed
+const MemoryDataPath = ":memory:"
+
 // Manager handles SQLite database connections and operations
 type Manager struct {
 	db       *sql.DB
 	dataPath string
+	tempDir  string // Directory of an ephemeral database while it is open
 	mu       sync.RWMutex
 	log      *logger.Logger
 }
@@ -40,22 +50,40 @@ func (m *Manager) Initialize() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Ensure data directory exists
-	if err := os.MkdirAll(m.dataPath, 0755); err != nil {
+	if m.db != nil {
+		return nil // Already initialized
+	}
+
+	dataDir := m.dataPath
+	if m.Ephemeral() {
+		// A temporary file rather than SQLite's :memory:, which would give
+		// every pooled connection its own empty database
+		tempDir, err := os.MkdirTemp("", "axeos-dashboard-")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary data directory: %w", err)
+		}
+		m.tempDir = tempDir
+		dataDir = tempDir
+	} else if err := os.MkdirAll(m.dataPath, 0755); err != nil {
+		// Ensure data directory exists
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
 	// Database file path
-	dbFile := filepath.Join(m.dataPath, "metrics.db")
+	dbFile := filepath.Join(dataDir, "metrics.db")
 
-	// Open SQLite connection
-	db, err := sql.Open("sqlite", dbFile)
+	// Open SQLite connection. Times are stored in SQLite's own format so the
+	// same instant always produces the same text (no monotonic clock suffix)
+	db, err := sql.Open("sqlite", dbFile+"?_time_format="+TimeFormat)
 	if err != nil {
+		m.removeTempDir()
 		return fmt.Errorf("failed to open SQLite: %w", err)
 	}
 
 	// Test connection
 	if err := db.Ping(); err != nil {
+		db.Close()
+		m.removeTempDir()
 		return fmt.Errorf("failed to ping SQLite: %w", err)
 	}
 
@@ -77,21 +105,94 @@ func (m *Manager) Initialize() error {
 		return fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	if m.Ephemeral() {
+		m.log.Warn("Using an ephemeral database at %s; collected metrics are deleted on shutdown", dbFile)
+	}
 	m.log.Info("SQLite initialized successfully at: %s", dbFile)
 	return nil
 }
 
+// Ephemeral reports whether the database is deleted when it is closed
+func (m *Manager) Ephemeral() bool {
+	return m.dataPath == MemoryDataPath
+}
+
+// removeTempDir deletes the directory of an ephemeral database. The caller holds m.mu.
+func (m *Manager) removeTempDir() {
+	if m.tempDir == "" {
+		return
+	}
+	if err := os.RemoveAll(m.tempDir); err != nil {
+		m.log.Warn("Failed to remove temporary data directory %s: %v", m.tempDir, err)
+	}
+	m.tempDir = ""
+}
+
 // Close closes the database connection
 func (m *Manager) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if m.db != nil {
-		return m.db.Close()
+		err := m.db.Close()
+		m.db = nil
+		m.removeTempDir()
+		if err == nil {
+			m.log.Info("SQLite connection closed")
+		}
+		return err
+	}
+	return nil
+}
+
+// conn returns the open database handle. Once the database is closed, e.g.
+// when data collection is disabled while a request is in flight, it returns
+// ErrDatabaseClosed instead.
+func (m *Manager) conn() (*sql.DB, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.db == nil {
+		return nil, ErrDatabaseClosed
+	}
+	return m.db, nil
+}
+
+// Checkpoint writes the WAL into the database file, so nothing is lost if
+// the host loses power before the database is closed
+func (m *Manager) Checkpoint() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.db == nil {
+		return nil
+	}
+	if _, err := m.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("WAL checkpoint failed: %w", err)
 	}
 	return nil
 }
 
+// Ping checks that the database answers queries
+func (m *Manager) Ping(ctx context.Context) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	var one int
+	return db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// Vacuum rebuilds the database file to give the space of deleted rows back
+func (m *Manager) Vacuum(ctx context.Context) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "VACUUM")
+	return err
+}
+
 // DB returns the database connection (for queries)
 func (m *Manager) DB() *sql.DB {
 	m.mu.RLock()
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event types of network milestones
+const (
+	EventDifficultyAdjustment = "difficulty_adjustment"
+	EventHalving              = "halving"
+)
+
+// DifficultyAdjustment is the result of a difficulty retarget seen by a crypto node
+type DifficultyAdjustment struct {
+	ID                 int64     `json:"id"`
+	NodeID             string    `json:"nodeId"`
+	Height             int       `json:"height"` // First block of the new difficulty period
+	Timestamp          time.Time `json:"timestamp"`
+	PreviousDifficulty float64   `json:"previousDifficulty"`
+	Difficulty         float64   `json:"difficulty"`
+	ChangePercent      float64   `json:"changePercent"`
+}
+
+const (
+	// Schema for difficulty retarget results
+	createDifficultyAdjustmentsTable = `
+		CREATE TABLE IF NOT EXISTS difficulty_adjustments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			node_id TEXT NOT NULL,
+			height INTEGER NOT NULL,
+			timestamp DATETIME NOT NULL,
+			previous_difficulty REAL NOT NULL,
+			difficulty REAL NOT NULL,
+			change_percent REAL NOT NULL,
+			UNIQUE(node_id, height)
+		);
+	`
+)
+
+// InsertDifficultyAdjustment stores a retarget result. It reports false when
+// the node's adjustment at that height was already stored.
+func (m *Manager) InsertDifficultyAdjustment(adjustment *DifficultyAdjustment) (bool, error) {
+	db, err := m.conn()
+	if err != nil {
+		return false, err
+	}
+	result, err := db.Exec(`
+		INSERT OR IGNORE INTO difficulty_adjustments (node_id, height, timestamp, previous_difficulty, difficulty, change_percent)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, adjustment.NodeID, adjustment.Height, adjustment.Timestamp, adjustment.PreviousDifficulty, adjustment.Difficulty, adjustment.ChangePercent)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert difficulty adjustment: %w", err)
+	}
+	inserted, _ := result.RowsAffected()
+	if inserted == 0 {
+		return false, nil
+	}
+	adjustment.ID, _ = result.LastInsertId()
+	return true, nil
+}
+
+// GetDifficultyAdjustments returns a node's stored retarget results, newest first
+func (m *Manager) GetDifficultyAdjustments(nodeID string, limit int) ([]*DifficultyAdjustment, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT id, node_id, height, timestamp, previous_difficulty, difficulty, change_percent
+		FROM difficulty_adjustments
+		WHERE node_id = ?
+		ORDER BY height DESC
+		LIMIT ?
+	`, nodeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query difficulty adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	var adjustments []*DifficultyAdjustment
+	for rows.Next() {
+		a := &DifficultyAdjustment{}
+		if err := rows.Scan(&a.ID, &a.NodeID, &a.Height, &a.Timestamp, &a.PreviousDifficulty, &a.Difficulty, &a.ChangePercent); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, a)
+	}
+	return adjustments, rows.Err()
+}
@@ -4,41 +4,49 @@ import "time"
 
 // AxeOSMetric represents a single metric collection from an AxeOS miner
 type AxeOSMetric struct {
-	Timestamp      time.Time
-	InstanceID     string
-	InstanceName   string
-	Hashrate       float64
-	Temperature    float64
-	Power          float64
-	FanSpeed       int
-	BestDiff       string
-	SharesAccepted int
-	SharesRejected int
-	Frequency      int
-	Voltage        float64
-	CoreVoltage    float64
+	Timestamp      time.Time `json:"timestamp"`
+	InstanceID     string    `json:"instanceId"`
+	InstanceName   string    `json:"instanceName"`
+	Hashrate       float64   `json:"hashrate"` // GH/s
+	Temperature    float64   `json:"temperature"`
+	Power          float64   `json:"power"`
+	FanSpeed       int       `json:"fanSpeed"`
+	BestDiff       string    `json:"bestDiff"`
+	SharesAccepted int       `json:"sharesAccepted"`
+	SharesRejected int       `json:"sharesRejected"`
+	Frequency      int       `json:"frequency"`
+	Voltage        float64   `json:"voltage"`
+	CoreVoltage    float64   `json:"coreVoltage"`
+	Current        float64   `json:"current"` // Input current in mA
 }
 
 // PoolMetric represents a single metric collection from a Mining Core pool
 type PoolMetric struct {
-	Timestamp        time.Time
-	PoolID           string
-	PoolName         string
-	PoolHashrate     float64
-	PoolWorkers      int
-	NetworkHashrate  float64
-	NetworkDifficulty float64
-	LastBlockTime    *time.Time
-	BlocksFound      int
+	Timestamp         time.Time  `json:"timestamp"`
+	PoolID            string     `json:"poolId"`
+	PoolName          string     `json:"poolName"`
+	PoolHashrate      float64    `json:"poolHashrate"` // H/s
+	PoolWorkers       int        `json:"poolWorkers"`
+	NetworkHashrate   float64    `json:"networkHashrate"` // H/s
+	NetworkDifficulty float64    `json:"networkDifficulty"`
+	LastBlockTime     *time.Time `json:"lastBlockTime,omitempty"`
+	BlocksFound       int        `json:"blocksFound"`
 }
 
 // NodeMetric represents a single metric collection from a crypto node
 type NodeMetric struct {
-	Timestamp       time.Time
-	NodeID          string
-	NodeName        string
-	BlockHeight     int
-	Connections     int
-	Difficulty      float64
-	NetworkHashrate float64
+	Timestamp       time.Time `json:"timestamp"`
+	NodeID          string    `json:"nodeId"`
+	NodeName        string    `json:"nodeName"`
+	BlockHeight     int       `json:"blockHeight"`
+	Connections     int       `json:"connections"`
+	Difficulty      float64   `json:"difficulty"`
+	NetworkHashrate float64   `json:"networkHashrate"` // H/s
+
+	// Mempool and fee estimates in sat/vB, 0 unless the node has NodeMempool set
+	MempoolTransactions int     `json:"mempoolTransactions"`
+	MempoolBytes        int64   `json:"mempoolBytes"`
+	FeeFast             float64 `json:"feeFast"`   // 2 blocks
+	FeeMedium           float64 `json:"feeMedium"` // 6 blocks
+	FeeSlow             float64 `json:"feeSlow"`   // 144 blocks
 }
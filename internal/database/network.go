@@ -0,0 +1,178 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// NetworkMetric is one sample of the network difficulty and hashrate reported by a crypto node
+type NetworkMetric struct {
+	Timestamp       time.Time `json:"timestamp"`
+	NodeID          string    `json:"nodeId"`
+	BlockHeight     int       `json:"blockHeight"`
+	Difficulty      float64   `json:"difficulty"`
+	NetworkHashrate float64   `json:"networkHashrate"` // H/s, 0 when the node does not estimate it
+}
+
+// FleetHashrate is the combined hashrate of all devices over one time bucket
+type FleetHashrate struct {
+	Timestamp time.Time `json:"timestamp"` // Start of the bucket
+	Hashrate  float64   `json:"hashrate"`  // GH/s, the sum of each device's average in the bucket
+	Devices   int       `json:"devices"`
+}
+
+const (
+	// Schema for network difficulty and hashrate samples
+	createNetworkMetricsTable = `
+		CREATE TABLE IF NOT EXISTS network_metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			node_id TEXT NOT NULL,
+			block_height INTEGER,
+			difficulty REAL,
+			network_hashrate REAL
+		);
+	`
+
+	createNetworkMetricsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_network_node_timestamp ON network_metrics(node_id, timestamp);
+	`
+)
+
+// InsertNetworkMetric inserts a network sample
+func (m *Manager) InsertNetworkMetric(metric *NetworkMetric) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO network_metrics (timestamp, node_id, block_height, difficulty, network_hashrate)
+		VALUES (?, ?, ?, ?, ?)
+	`, metric.Timestamp, metric.NodeID, metric.BlockHeight, metric.Difficulty, metric.NetworkHashrate)
+	if err != nil {
+		return fmt.Errorf("failed to insert network metric: %w", err)
+	}
+	return nil
+}
+
+// GetLatestNetworkMetric returns a node's newest network sample, or nil when there is none
+func (m *Manager) GetLatestNetworkMetric(nodeID string) (*NetworkMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	metric := &NetworkMetric{}
+	err = db.QueryRow(`
+		SELECT timestamp, node_id, block_height, difficulty, network_hashrate
+		FROM network_metrics
+		WHERE node_id = ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`, nodeID).Scan(&metric.Timestamp, &metric.NodeID, &metric.BlockHeight, &metric.Difficulty, &metric.NetworkHashrate)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest network metric: %w", err)
+	}
+	return metric, nil
+}
+
+// GetNetworkMetrics retrieves a node's network samples within a time range, oldest first
+func (m *Manager) GetNetworkMetrics(nodeID string, startTime, endTime time.Time, limit int) ([]*NetworkMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT timestamp, node_id, block_height, difficulty, network_hashrate
+		FROM network_metrics
+		WHERE node_id = ? AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
+		LIMIT ?
+	`, nodeID, startTime, endTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query network metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []*NetworkMetric
+	for rows.Next() {
+		metric := &NetworkMetric{}
+		if err := rows.Scan(&metric.Timestamp, &metric.NodeID, &metric.BlockHeight, &metric.Difficulty, &metric.NetworkHashrate); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, rows.Err()
+}
+
+// GetFleetHashrate sums the hashrate of all devices in buckets of the given
+// width between start and end, oldest first. Each device counts with its
+// average over the bucket, so devices sampled at different moments add up.
+func (m *Manager) GetFleetHashrate(startTime, endTime time.Time, bucket time.Duration) ([]*FleetHashrate, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	if bucket <= 0 {
+		return nil, fmt.Errorf("bucket must be positive")
+	}
+
+	rows, err := db.Query(`
+		SELECT timestamp, instance_id, hashrate
+		FROM axeos_metrics
+		WHERE timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
+	`, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fleet hashrate: %w", err)
+	}
+	defer rows.Close()
+
+	type deviceSum struct {
+		total   float64
+		samples int
+	}
+	var buckets []*FleetHashrate
+	var devices map[string]*deviceSum
+	flush := func() {
+		if len(buckets) == 0 {
+			return
+		}
+		current := buckets[len(buckets)-1]
+		for _, sum := range devices {
+			current.Hashrate += sum.total / float64(sum.samples)
+		}
+		current.Devices = len(devices)
+	}
+
+	for rows.Next() {
+		var timestamp time.Time
+		var instanceID string
+		var hashrate float64
+		if err := rows.Scan(&timestamp, &instanceID, &hashrate); err != nil {
+			return nil, err
+		}
+
+		start := startTime.Add(timestamp.Sub(startTime) / bucket * bucket)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Timestamp.Equal(start) {
+			flush()
+			buckets = append(buckets, &FleetHashrate{Timestamp: start})
+			devices = map[string]*deviceSum{}
+		}
+		sum := devices[instanceID]
+		if sum == nil {
+			sum = &deviceSum{}
+			devices[instanceID] = sum
+		}
+		sum.total += hashrate / hashesPerGH
+		sum.samples++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return buckets, nil
+}
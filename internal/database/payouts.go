@@ -0,0 +1,182 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventPayout is the event type of a payment received by a payout address
+const EventPayout = "payout"
+
+// Payout is a confirmed payment received by a watched payout address
+type Payout struct {
+	ID          int64     `json:"id"`
+	Address     string    `json:"address"`
+	TxID        string    `json:"txid"`
+	AmountSats  int64     `json:"amountSats"` // Paid to the address by the transaction
+	Coinbase    bool      `json:"coinbase"`   // Paid by a block's coinbase transaction
+	BlockHeight int       `json:"blockHeight"`
+	Timestamp   time.Time `json:"timestamp"` // Block time
+}
+
+// PayoutTotal sums the payments received by a payout address
+type PayoutTotal struct {
+	Address         string     `json:"address"`
+	Payouts         int        `json:"payouts"`
+	CoinbasePayouts int        `json:"coinbasePayouts"`
+	TotalSats       int64      `json:"totalSats"`
+	LastPayout      *time.Time `json:"lastPayout,omitempty"`
+	WatchingSince   *time.Time `json:"watchingSince,omitempty"`
+}
+
+const (
+	// Schema for payments to payout addresses
+	createPayoutsTable = `
+		CREATE TABLE IF NOT EXISTS payouts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			address TEXT NOT NULL,
+			txid TEXT NOT NULL,
+			amount_sats INTEGER NOT NULL,
+			coinbase INTEGER NOT NULL DEFAULT 0,
+			block_height INTEGER NOT NULL,
+			timestamp DATETIME NOT NULL,
+			UNIQUE(address, txid)
+		);
+
+		CREATE TABLE IF NOT EXISTS payout_watches (
+			address TEXT PRIMARY KEY,
+			since DATETIME NOT NULL
+		);
+	`
+
+	createPayoutsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_payouts_timestamp ON payouts(timestamp);
+	`
+)
+
+// StartPayoutWatch returns when an address was first watched, recording now
+// for a new address. Payments from before that time are stored without events.
+func (m *Manager) StartPayoutWatch(address string) (time.Time, error) {
+	db, err := m.conn()
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT OR IGNORE INTO payout_watches (address, since) VALUES (?, ?)`, address, now); err != nil {
+		return time.Time{}, fmt.Errorf("failed to start payout watch: %w", err)
+	}
+	var since time.Time
+	if err := db.QueryRow(`SELECT since FROM payout_watches WHERE address = ?`, address).Scan(&since); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read payout watch: %w", err)
+	}
+	return since, nil
+}
+
+// InsertPayout stores a payment. It reports false when the address's payment
+// by that transaction was already stored.
+func (m *Manager) InsertPayout(payout *Payout) (bool, error) {
+	db, err := m.conn()
+	if err != nil {
+		return false, err
+	}
+	result, err := db.Exec(`
+		INSERT OR IGNORE INTO payouts (address, txid, amount_sats, coinbase, block_height, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, payout.Address, payout.TxID, payout.AmountSats, payout.Coinbase, payout.BlockHeight, payout.Timestamp)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert payout: %w", err)
+	}
+	inserted, _ := result.RowsAffected()
+	if inserted == 0 {
+		return false, nil
+	}
+	payout.ID, _ = result.LastInsertId()
+	return true, nil
+}
+
+// GetPayouts returns the stored payments, newest first, to one address or
+// to all when address is empty
+func (m *Manager) GetPayouts(address string, limit int) ([]*Payout, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT id, address, txid, amount_sats, coinbase, block_height, timestamp
+		FROM payouts
+		WHERE ? = '' OR address = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`, address, address, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payouts: %w", err)
+	}
+	defer rows.Close()
+
+	var payouts []*Payout
+	for rows.Next() {
+		p := &Payout{}
+		if err := rows.Scan(&p.ID, &p.Address, &p.TxID, &p.AmountSats, &p.Coinbase, &p.BlockHeight, &p.Timestamp); err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, rows.Err()
+}
+
+// GetPayoutTotals returns the cumulative payments of every watched address
+// by address
+func (m *Manager) GetPayoutTotals() (map[string]*PayoutTotal, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	totals := map[string]*PayoutTotal{}
+
+	watches, err := db.Query(`SELECT address, since FROM payout_watches`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payout watches: %w", err)
+	}
+	defer watches.Close()
+	for watches.Next() {
+		var since time.Time
+		total := &PayoutTotal{}
+		if err := watches.Scan(&total.Address, &since); err != nil {
+			return nil, err
+		}
+		total.WatchingSince = &since
+		totals[total.Address] = total
+	}
+	if err := watches.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT address, amount_sats, coinbase, timestamp FROM payouts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payout totals: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var address string
+		var amount int64
+		var coinbase bool
+		var timestamp time.Time
+		if err := rows.Scan(&address, &amount, &coinbase, &timestamp); err != nil {
+			return nil, err
+		}
+		total := totals[address]
+		if total == nil {
+			total = &PayoutTotal{Address: address}
+			totals[address] = total
+		}
+		total.Payouts++
+		total.TotalSats += amount
+		if coinbase {
+			total.CoinbasePayouts++
+		}
+		if total.LastPayout == nil || timestamp.After(*total.LastPayout) {
+			total.LastPayout = &timestamp
+		}
+	}
+	return totals, rows.Err()
+}
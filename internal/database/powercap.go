@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventPowerCap is the event type of the power cap controller changing a device's settings
+const EventPowerCap = "power_cap_adjustment"
+
+// PowerCapStep is how far the power cap controller stepped a device down,
+// with the settings the device had before so they can be restored
+type PowerCapStep struct {
+	InstanceID  string    `json:"instanceId"`
+	Level       int       `json:"level"`       // 1 is the first power_budget profile
+	Frequency   int       `json:"frequency"`   // MHz before the first step
+	CoreVoltage int       `json:"coreVoltage"` // mV before the first step
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+const (
+	// Schema for devices stepped down by the power cap controller
+	createPowerCapStepsTable = `
+		CREATE TABLE IF NOT EXISTS power_cap_steps (
+			instance_id TEXT PRIMARY KEY,
+			level INTEGER NOT NULL,
+			frequency INTEGER NOT NULL,
+			core_voltage INTEGER NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+	`
+)
+
+// SetPowerCapStep records the level a device was stepped to
+func (m *Manager) SetPowerCapStep(step *PowerCapStep) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if step.UpdatedAt.IsZero() {
+		step.UpdatedAt = time.Now()
+	}
+	_, err = db.Exec(`
+		INSERT OR REPLACE INTO power_cap_steps (instance_id, level, frequency, core_voltage, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, step.InstanceID, step.Level, step.Frequency, step.CoreVoltage, step.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save power cap step: %w", err)
+	}
+	return nil
+}
+
+// DeletePowerCapStep forgets a device once its own settings are restored
+func (m *Manager) DeletePowerCapStep(instanceID string) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DELETE FROM power_cap_steps WHERE instance_id = ?`, instanceID); err != nil {
+		return fmt.Errorf("failed to delete power cap step: %w", err)
+	}
+	return nil
+}
+
+// GetPowerCapSteps returns the devices stepped down by the power cap
+// controller, keyed by instance
+func (m *Manager) GetPowerCapSteps() (map[string]*PowerCapStep, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT instance_id, level, frequency, core_voltage, updated_at FROM power_cap_steps`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query power cap steps: %w", err)
+	}
+	defer rows.Close()
+
+	steps := map[string]*PowerCapStep{}
+	for rows.Next() {
+		var step PowerCapStep
+		if err := rows.Scan(&step.InstanceID, &step.Level, &step.Frequency, &step.CoreVoltage, &step.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan power cap step: %w", err)
+		}
+		steps[step.InstanceID] = &step
+	}
+	return steps, rows.Err()
+}
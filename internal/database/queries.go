@@ -3,23 +3,28 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"time"
 )
 
 // InsertAxeOSMetric inserts a single AxeOS metric into the database
 func (m *Manager) InsertAxeOSMetric(metric *AxeOSMetric) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO axeos_metrics (
 			timestamp, instance_id, instance_name, hashrate, temperature, power,
 			fan_speed, best_diff, shares_accepted, shares_rejected,
-			frequency, voltage, core_voltage
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			frequency, voltage, core_voltage, current
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := m.db.Exec(query,
+	_, err = db.Exec(query,
 		metric.Timestamp,
 		metric.InstanceID,
 		metric.InstanceName,
-		metric.Hashrate,
+		metric.Hashrate*hashesPerGH,
 		metric.Temperature,
 		metric.Power,
 		metric.FanSpeed,
@@ -29,6 +34,7 @@ func (m *Manager) InsertAxeOSMetric(metric *AxeOSMetric) error {
 		metric.Frequency,
 		metric.Voltage,
 		metric.CoreVoltage,
+		metric.Current,
 	)
 
 	if err != nil {
@@ -40,6 +46,10 @@ func (m *Manager) InsertAxeOSMetric(metric *AxeOSMetric) error {
 
 // InsertPoolMetric inserts a single pool metric into the database
 func (m *Manager) InsertPoolMetric(metric *PoolMetric) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO pool_metrics (
 			timestamp, pool_id, pool_name, pool_hashrate, pool_workers,
@@ -52,7 +62,7 @@ func (m *Manager) InsertPoolMetric(metric *PoolMetric) error {
 		lastBlockTime = *metric.LastBlockTime
 	}
 
-	_, err := m.db.Exec(query,
+	_, err = db.Exec(query,
 		metric.Timestamp,
 		metric.PoolID,
 		metric.PoolName,
@@ -73,14 +83,19 @@ func (m *Manager) InsertPoolMetric(metric *PoolMetric) error {
 
 // InsertNodeMetric inserts a single node metric into the database
 func (m *Manager) InsertNodeMetric(metric *NodeMetric) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO node_metrics (
 			timestamp, node_id, node_name, block_height, connections,
-			difficulty, network_hashrate
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
+			difficulty, network_hashrate, mempool_transactions, mempool_bytes,
+			fee_fast, fee_medium, fee_slow
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := m.db.Exec(query,
+	_, err = db.Exec(query,
 		metric.Timestamp,
 		metric.NodeID,
 		metric.NodeName,
@@ -88,6 +103,11 @@ func (m *Manager) InsertNodeMetric(metric *NodeMetric) error {
 		metric.Connections,
 		metric.Difficulty,
 		metric.NetworkHashrate,
+		metric.MempoolTransactions,
+		metric.MempoolBytes,
+		metric.FeeFast,
+		metric.FeeMedium,
+		metric.FeeSlow,
 	)
 
 	if err != nil {
@@ -98,18 +118,22 @@ func (m *Manager) InsertNodeMetric(metric *NodeMetric) error {
 }
 
 // GetAxeOSMetrics retrieves AxeOS metrics for a specific instance within a time range
-func (m *Manager) GetAxeOSMetrics(instanceID string, startTime, endTime string, limit int) ([]*AxeOSMetric, error) {
+func (m *Manager) GetAxeOSMetrics(instanceID string, startTime, endTime time.Time, limit int) ([]*AxeOSMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
 	query := `
 		SELECT timestamp, instance_id, instance_name, hashrate, temperature, power,
 		       fan_speed, best_diff, shares_accepted, shares_rejected,
-		       frequency, voltage, core_voltage
+		       frequency, voltage, core_voltage, current
 		FROM axeos_metrics
 		WHERE instance_id = ? AND timestamp BETWEEN ? AND ?
 		ORDER BY timestamp DESC
 		LIMIT ?
 	`
 
-	rows, err := m.db.Query(query, instanceID, startTime, endTime, limit)
+	rows, err := db.Query(query, instanceID, startTime, endTime, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query AxeOS metrics: %w", err)
 	}
@@ -119,7 +143,11 @@ func (m *Manager) GetAxeOSMetrics(instanceID string, startTime, endTime string,
 }
 
 // GetPoolMetrics retrieves pool metrics for a specific pool within a time range
-func (m *Manager) GetPoolMetrics(poolID string, startTime, endTime string, limit int) ([]*PoolMetric, error) {
+func (m *Manager) GetPoolMetrics(poolID string, startTime, endTime time.Time, limit int) ([]*PoolMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
 	query := `
 		SELECT timestamp, pool_id, pool_name, pool_hashrate, pool_workers,
 		       network_hashrate, network_difficulty, last_block_time, blocks_found
@@ -129,7 +157,7 @@ func (m *Manager) GetPoolMetrics(poolID string, startTime, endTime string, limit
 		LIMIT ?
 	`
 
-	rows, err := m.db.Query(query, poolID, startTime, endTime, limit)
+	rows, err := db.Query(query, poolID, startTime, endTime, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query pool metrics: %w", err)
 	}
@@ -139,17 +167,22 @@ func (m *Manager) GetPoolMetrics(poolID string, startTime, endTime string, limit
 }
 
 // GetNodeMetrics retrieves node metrics for a specific node within a time range
-func (m *Manager) GetNodeMetrics(nodeID string, startTime, endTime string, limit int) ([]*NodeMetric, error) {
+func (m *Manager) GetNodeMetrics(nodeID string, startTime, endTime time.Time, limit int) ([]*NodeMetric, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
 	query := `
 		SELECT timestamp, node_id, node_name, block_height, connections,
-		       difficulty, network_hashrate
+		       difficulty, network_hashrate, mempool_transactions, mempool_bytes,
+		       fee_fast, fee_medium, fee_slow
 		FROM node_metrics
 		WHERE node_id = ? AND timestamp BETWEEN ? AND ?
 		ORDER BY timestamp DESC
 		LIMIT ?
 	`
 
-	rows, err := m.db.Query(query, nodeID, startTime, endTime, limit)
+	rows, err := db.Query(query, nodeID, startTime, endTime, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query node metrics: %w", err)
 	}
@@ -160,26 +193,36 @@ func (m *Manager) GetNodeMetrics(nodeID string, startTime, endTime string, limit
 
 // Helper functions to scan rows into structs
 
+func scanAxeOSMetric(rows *sql.Rows) (*AxeOSMetric, error) {
+	metric := &AxeOSMetric{}
+	err := rows.Scan(
+		&metric.Timestamp,
+		&metric.InstanceID,
+		&metric.InstanceName,
+		&metric.Hashrate,
+		&metric.Temperature,
+		&metric.Power,
+		&metric.FanSpeed,
+		&metric.BestDiff,
+		&metric.SharesAccepted,
+		&metric.SharesRejected,
+		&metric.Frequency,
+		&metric.Voltage,
+		&metric.CoreVoltage,
+		&metric.Current,
+	)
+	if err != nil {
+		return nil, err
+	}
+	metric.Hashrate /= hashesPerGH
+	return metric, nil
+}
+
 func scanAxeOSMetrics(rows *sql.Rows) ([]*AxeOSMetric, error) {
 	var metrics []*AxeOSMetric
 
 	for rows.Next() {
-		metric := &AxeOSMetric{}
-		err := rows.Scan(
-			&metric.Timestamp,
-			&metric.InstanceID,
-			&metric.InstanceName,
-			&metric.Hashrate,
-			&metric.Temperature,
-			&metric.Power,
-			&metric.FanSpeed,
-			&metric.BestDiff,
-			&metric.SharesAccepted,
-			&metric.SharesRejected,
-			&metric.Frequency,
-			&metric.Voltage,
-			&metric.CoreVoltage,
-		)
+		metric, err := scanAxeOSMetric(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -189,52 +232,72 @@ func scanAxeOSMetrics(rows *sql.Rows) ([]*AxeOSMetric, error) {
 	return metrics, rows.Err()
 }
 
+func scanPoolMetric(rows *sql.Rows) (*PoolMetric, error) {
+	metric := &PoolMetric{}
+	var lastBlockTime sql.NullTime
+
+	err := rows.Scan(
+		&metric.Timestamp,
+		&metric.PoolID,
+		&metric.PoolName,
+		&metric.PoolHashrate,
+		&metric.PoolWorkers,
+		&metric.NetworkHashrate,
+		&metric.NetworkDifficulty,
+		&lastBlockTime,
+		&metric.BlocksFound,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastBlockTime.Valid {
+		metric.LastBlockTime = &lastBlockTime.Time
+	}
+	return metric, nil
+}
+
 func scanPoolMetrics(rows *sql.Rows) ([]*PoolMetric, error) {
 	var metrics []*PoolMetric
 
 	for rows.Next() {
-		metric := &PoolMetric{}
-		var lastBlockTime sql.NullTime
-
-		err := rows.Scan(
-			&metric.Timestamp,
-			&metric.PoolID,
-			&metric.PoolName,
-			&metric.PoolHashrate,
-			&metric.PoolWorkers,
-			&metric.NetworkHashrate,
-			&metric.NetworkDifficulty,
-			&lastBlockTime,
-			&metric.BlocksFound,
-		)
+		metric, err := scanPoolMetric(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		if lastBlockTime.Valid {
-			metric.LastBlockTime = &lastBlockTime.Time
-		}
-
 		metrics = append(metrics, metric)
 	}
 
 	return metrics, rows.Err()
 }
 
+func scanNodeMetric(rows *sql.Rows) (*NodeMetric, error) {
+	metric := &NodeMetric{}
+	err := rows.Scan(
+		&metric.Timestamp,
+		&metric.NodeID,
+		&metric.NodeName,
+		&metric.BlockHeight,
+		&metric.Connections,
+		&metric.Difficulty,
+		&metric.NetworkHashrate,
+		&metric.MempoolTransactions,
+		&metric.MempoolBytes,
+		&metric.FeeFast,
+		&metric.FeeMedium,
+		&metric.FeeSlow,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return metric, nil
+}
+
 func scanNodeMetrics(rows *sql.Rows) ([]*NodeMetric, error) {
 	var metrics []*NodeMetric
 
 	for rows.Next() {
-		metric := &NodeMetric{}
-		err := rows.Scan(
-			&metric.Timestamp,
-			&metric.NodeID,
-			&metric.NodeName,
-			&metric.BlockHeight,
-			&metric.Connections,
-			&metric.Difficulty,
-			&metric.NetworkHashrate,
-		)
+		metric, err := scanNodeMetric(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -246,6 +309,10 @@ func scanNodeMetrics(rows *sql.Rows) ([]*NodeMetric, error) {
 
 // CleanupOldMetrics deletes metrics older than the specified retention period (in days)
 func (m *Manager) CleanupOldMetrics(retentionDays int) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
 	queries := []string{
 		fmt.Sprintf("DELETE FROM axeos_metrics WHERE timestamp < NOW() - INTERVAL '%d days'", retentionDays),
 		fmt.Sprintf("DELETE FROM pool_metrics WHERE timestamp < NOW() - INTERVAL '%d days'", retentionDays),
@@ -253,7 +320,7 @@ func (m *Manager) CleanupOldMetrics(retentionDays int) error {
 	}
 
 	for _, query := range queries {
-		if _, err := m.db.Exec(query); err != nil {
+		if _, err := db.Exec(query); err != nil {
 			return fmt.Errorf("failed to cleanup old metrics: %w", err)
 		}
 	}
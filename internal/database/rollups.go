@@ -0,0 +1,284 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// History resolutions: raw samples or hourly and daily rollups
+const (
+	ResolutionRaw    = "raw"
+	ResolutionHourly = "hourly"
+	ResolutionDaily  = "daily"
+)
+
+// AxeOSRollup aggregates a device's samples over one hour or day
+type AxeOSRollup struct {
+	Timestamp      time.Time `json:"timestamp"` // Start of the bucket (local time)
+	InstanceID     string    `json:"instanceId"`
+	Samples        int       `json:"samples"`
+	AvgHashrate    float64   `json:"avgHashrate"`
+	MinHashrate    float64   `json:"minHashrate"`
+	MaxHashrate    float64   `json:"maxHashrate"`
+	AvgTemperature float64   `json:"avgTemperature"`
+	MinTemperature float64   `json:"minTemperature"`
+	MaxTemperature float64   `json:"maxTemperature"`
+	AvgPower       float64   `json:"avgPower"`
+	MinPower       float64   `json:"minPower"`
+	MaxPower       float64   `json:"maxPower"`
+}
+
+const (
+	// Schema for hourly and daily AxeOS rollups (excluded from pruning)
+	createAxeOSRollupTables = `
+		CREATE TABLE IF NOT EXISTS axeos_rollups_hourly (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bucket DATETIME NOT NULL,
+			instance_id TEXT NOT NULL,
+			samples INTEGER NOT NULL,
+			avg_hashrate REAL,
+			min_hashrate REAL,
+			max_hashrate REAL,
+			avg_temperature REAL,
+			min_temperature REAL,
+			max_temperature REAL,
+			avg_power REAL,
+			min_power REAL,
+			max_power REAL,
+			updated_at DATETIME NOT NULL,
+			UNIQUE(instance_id, bucket)
+		);
+		CREATE TABLE IF NOT EXISTS axeos_rollups_daily (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bucket DATETIME NOT NULL,
+			instance_id TEXT NOT NULL,
+			samples INTEGER NOT NULL,
+			avg_hashrate REAL,
+			min_hashrate REAL,
+			max_hashrate REAL,
+			avg_temperature REAL,
+			min_temperature REAL,
+			max_temperature REAL,
+			avg_power REAL,
+			min_power REAL,
+			max_power REAL,
+			updated_at DATETIME NOT NULL,
+			UNIQUE(instance_id, bucket)
+		);
+	`
+)
+
+// rollupTable returns the table holding rollups of a resolution
+func rollupTable(resolution string) (string, error) {
+	switch resolution {
+	case ResolutionHourly:
+		return "axeos_rollups_hourly", nil
+	case ResolutionDaily:
+		return "axeos_rollups_daily", nil
+	}
+	return "", fmt.Errorf("unknown rollup resolution %q", resolution)
+}
+
+// RollupBucket returns the start of the local hour or day containing t
+func RollupBucket(resolution string, t time.Time) time.Time {
+	t = t.Local()
+	if resolution == ResolutionDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// NextRollupBucket returns the start of the bucket after the one starting at bucket
+func NextRollupBucket(resolution string, bucket time.Time) time.Time {
+	if resolution == ResolutionDaily {
+		return bucket.AddDate(0, 0, 1)
+	}
+	return bucket.Add(time.Hour)
+}
+
+// RollupAxeOS aggregates a device's samples in the bucket starting at bucket
+// and stores (or refreshes) the rollup. Empty buckets are skipped.
+func (m *Manager) RollupAxeOS(resolution, instanceID string, bucket time.Time) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return err
+	}
+
+	r := &AxeOSRollup{Timestamp: bucket, InstanceID: instanceID}
+	var avgHash, minHash, maxHash, avgTemp, minTemp, maxTemp, avgPower, minPower, maxPower sql.NullFloat64
+	err = db.QueryRow(`
+		SELECT COUNT(*), AVG(hashrate), MIN(hashrate), MAX(hashrate),
+		       AVG(temperature), MIN(temperature), MAX(temperature),
+		       AVG(power), MIN(power), MAX(power)
+		FROM axeos_metrics
+		WHERE instance_id = ? AND timestamp >= ? AND timestamp < ?
+	`, instanceID, bucket, NextRollupBucket(resolution, bucket)).Scan(&r.Samples,
+		&avgHash, &minHash, &maxHash, &avgTemp, &minTemp, &maxTemp, &avgPower, &minPower, &maxPower)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate AxeOS metrics: %w", err)
+	}
+	if r.Samples == 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`
+		INSERT INTO %s (
+			bucket, instance_id, samples, avg_hashrate, min_hashrate, max_hashrate,
+			avg_temperature, min_temperature, max_temperature,
+			avg_power, min_power, max_power, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(instance_id, bucket) DO UPDATE SET
+			samples = excluded.samples,
+			avg_hashrate = excluded.avg_hashrate,
+			min_hashrate = excluded.min_hashrate,
+			max_hashrate = excluded.max_hashrate,
+			avg_temperature = excluded.avg_temperature,
+			min_temperature = excluded.min_temperature,
+			max_temperature = excluded.max_temperature,
+			avg_power = excluded.avg_power,
+			min_power = excluded.min_power,
+			max_power = excluded.max_power,
+			updated_at = excluded.updated_at`, table),
+		bucket, instanceID, r.Samples, avgHash.Float64, minHash.Float64, maxHash.Float64,
+		avgTemp.Float64, minTemp.Float64, maxTemp.Float64,
+		avgPower.Float64, minPower.Float64, maxPower.Float64, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save %s rollup: %w", resolution, err)
+	}
+	return nil
+}
+
+// GetLatestAxeOSRollup returns the start of a device's newest rollup bucket,
+// or false if there is none yet
+func (m *Manager) GetLatestAxeOSRollup(resolution, instanceID string) (time.Time, bool, error) {
+	db, err := m.conn()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var bucket time.Time
+	err = db.QueryRow(fmt.Sprintf(`SELECT bucket FROM %s WHERE instance_id = ? ORDER BY bucket DESC LIMIT 1`, table),
+		instanceID).Scan(&bucket)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to query latest %s rollup: %w", resolution, err)
+	}
+	return bucket, true, nil
+}
+
+// GetOldestAxeOSTimestamp returns the time of a device's oldest stored sample,
+// or false if there is none
+func (m *Manager) GetOldestAxeOSTimestamp(instanceID string) (time.Time, bool, error) {
+	db, err := m.conn()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	var timestamp time.Time
+	err = db.QueryRow(`SELECT timestamp FROM axeos_metrics WHERE instance_id = ? ORDER BY timestamp ASC LIMIT 1`,
+		instanceID).Scan(&timestamp)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to query oldest AxeOS metric: %w", err)
+	}
+	return timestamp, true, nil
+}
+
+// GetAxeOSRollups returns a device's rollups with buckets starting between start and end, newest first
+func (m *Manager) GetAxeOSRollups(resolution, instanceID string, start, end time.Time, limit int) ([]*AxeOSRollup, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT bucket, instance_id, samples, avg_hashrate, min_hashrate, max_hashrate,
+		       avg_temperature, min_temperature, max_temperature,
+		       avg_power, min_power, max_power
+		FROM %s
+		WHERE instance_id = ? AND bucket BETWEEN ? AND ?
+		ORDER BY bucket DESC
+		LIMIT ?
+	`, table), instanceID, RollupBucket(resolution, start), end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s rollups: %w", resolution, err)
+	}
+	defer rows.Close()
+
+	var rollups []*AxeOSRollup
+	for rows.Next() {
+		r := &AxeOSRollup{}
+		if err := rows.Scan(&r.Timestamp, &r.InstanceID, &r.Samples,
+			&r.AvgHashrate, &r.MinHashrate, &r.MaxHashrate,
+			&r.AvgTemperature, &r.MinTemperature, &r.MaxTemperature,
+			&r.AvgPower, &r.MinPower, &r.MaxPower); err != nil {
+			return nil, err
+		}
+		r.AvgHashrate /= hashesPerGH
+		r.MinHashrate /= hashesPerGH
+		r.MaxHashrate /= hashesPerGH
+		rollups = append(rollups, r)
+	}
+
+	return rollups, rows.Err()
+}
+
+// StreamAxeOSRollups calls emit with each hourly or daily rollup of a device
+// between start and end, oldest first, without loading them into memory
+func (m *Manager) StreamAxeOSRollups(resolution, instanceID string, start, end time.Time, emit func(rollup *AxeOSRollup) error) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT bucket, instance_id, samples, avg_hashrate, min_hashrate, max_hashrate,
+		       avg_temperature, min_temperature, max_temperature,
+		       avg_power, min_power, max_power
+		FROM %s
+		WHERE instance_id = ? AND bucket BETWEEN ? AND ?
+		ORDER BY bucket ASC
+	`, table), instanceID, RollupBucket(resolution, start), end)
+	if err != nil {
+		return fmt.Errorf("failed to query %s rollups: %w", resolution, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := &AxeOSRollup{}
+		if err := rows.Scan(&r.Timestamp, &r.InstanceID, &r.Samples,
+			&r.AvgHashrate, &r.MinHashrate, &r.MaxHashrate,
+			&r.AvgTemperature, &r.MinTemperature, &r.MaxTemperature,
+			&r.AvgPower, &r.MinPower, &r.MaxPower); err != nil {
+			return err
+		}
+		r.AvgHashrate /= hashesPerGH
+		r.MinHashrate /= hashesPerGH
+		r.MaxHashrate /= hashesPerGH
+		if err := emit(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
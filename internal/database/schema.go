@@ -1,5 +1,11 @@
 package database
 
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
 const (
 	// Schema for AxeOS miner metrics
 	createAxeOSMetricsTable = `
@@ -17,7 +23,8 @@ const (
 			shares_rejected INTEGER,
 			frequency INTEGER,
 			voltage REAL,
-			core_voltage REAL
+			core_voltage REAL,
+			current REAL DEFAULT 0
 		);
 	`
 
@@ -57,7 +64,12 @@ const (
 			block_height INTEGER,
 			connections INTEGER,
 			difficulty REAL,
-			network_hashrate REAL
+			network_hashrate REAL,
+			mempool_transactions INTEGER DEFAULT 0,
+			mempool_bytes INTEGER DEFAULT 0,
+			fee_fast REAL DEFAULT 0,
+			fee_medium REAL DEFAULT 0,
+			fee_slow REAL DEFAULT 0
 		);
 	`
 
@@ -67,6 +79,89 @@ const (
 	`
 )
 
+// axeosAddedColumns were added to axeos_metrics after its first version
+var axeosAddedColumns = []string{
+	"current REAL DEFAULT 0",
+}
+
+// nodeAddedColumns were added to node_metrics after its first version
+var nodeAddedColumns = []string{
+	"mempool_transactions INTEGER DEFAULT 0",
+	"mempool_bytes INTEGER DEFAULT 0",
+	"fee_fast REAL DEFAULT 0",
+	"fee_medium REAL DEFAULT 0",
+	"fee_slow REAL DEFAULT 0",
+}
+
+// upsertKeys defines the natural key of each table, used to skip duplicates on import
+var upsertKeys = []struct {
+	table   string
+	index   string
+	columns string
+}{
+	{"axeos_metrics", "idx_axeos_upsert", "instance_id, timestamp"},
+	{"pool_metrics", "idx_pool_upsert", "pool_id, timestamp"},
+	{"node_metrics", "idx_node_upsert", "node_id, timestamp"},
+	{"events", "idx_events_upsert", "type, source, timestamp"},
+}
+
+// createUpsertKeys adds unique indexes on each table's natural key,
+// removing any duplicate rows left by older versions first
+func (m *Manager) createUpsertKeys() error {
+	for _, key := range upsertKeys {
+		var exists int
+		err := m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?`, key.index).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+
+		dedupe := fmt.Sprintf(`DELETE FROM %s WHERE id NOT IN (SELECT MIN(id) FROM %s GROUP BY %s)`, key.table, key.table, key.columns)
+		if _, err := m.db.Exec(dedupe); err != nil {
+			return fmt.Errorf("failed to remove duplicates from %s: %w", key.table, err)
+		}
+
+		create := fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s(%s)`, key.index, key.table, key.columns)
+		if _, err := m.db.Exec(create); err != nil {
+			return fmt.Errorf("failed to create %s: %w", key.index, err)
+		}
+	}
+	return nil
+}
+
+// addMissingColumns adds columns (given as "name TYPE") that an older table lacks
+func (m *Manager) addMissingColumns(table string, columns []string) error {
+	rows, err := m.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, column := range columns {
+		name := strings.Fields(column)[0]
+		if existing[name] {
+			continue
+		}
+		if _, err := m.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, column)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", table, name, err)
+		}
+	}
+	return nil
+}
+
 // initializeSchema creates all necessary tables and indexes
 func (m *Manager) initializeSchema() error {
 	statements := []string{
@@ -76,6 +171,26 @@ func (m *Manager) initializeSchema() error {
 		createPoolMetricsIndexes,
 		createNodeMetricsTable,
 		createNodeMetricsIndexes,
+		createEventsTable,
+		createEventsIndexes,
+		createDailySnapshotsTable,
+		createEnergyDailyTable,
+		createAmbientMetricsTable,
+		createAmbientMetricsIndexes,
+		createAxeOSRollupTables,
+		createCollectionErrorsTable,
+		createCollectionErrorsIndexes,
+		createSettingsBackupsTable,
+		createSettingsBackupsIndexes,
+		createPoolFailoversTable,
+		createPoolFailoversIndexes,
+		createNetworkMetricsTable,
+		createNetworkMetricsIndexes,
+		createDifficultyAdjustmentsTable,
+		createPayoutsTable,
+		createPayoutsIndexes,
+		createPowerCapStepsTable,
+		createSchemaMetaTable,
 	}
 
 	for _, stmt := range statements {
@@ -84,5 +199,18 @@ func (m *Manager) initializeSchema() error {
 		}
 	}
 
-	return nil
+	if err := m.addMissingColumns("axeos_metrics", axeosAddedColumns); err != nil {
+		return err
+	}
+	if err := m.addMissingColumns("node_metrics", nodeAddedColumns); err != nil {
+		return err
+	}
+	if err := m.normalizeHashrateUnits(); err != nil {
+		return err
+	}
+	if err := m.normalizeTimestamps(); err != nil {
+		return err
+	}
+
+	return m.createUpsertKeys()
 }
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Reasons a settings backup was taken
+const (
+	BackupReasonSettings = "settings" // Before a settings change
+	BackupReasonPool     = "pool"     // Before a pool switch
+	BackupReasonRestore  = "restore"  // Before restoring an older backup
+	BackupReasonManual   = "manual"   // Requested by a user, e.g. before a firmware update
+)
+
+// MaxSettingsBackups is how many settings backups are kept per device
+const MaxSettingsBackups = 20
+
+// SettingsBackup is a copy of a device's settings taken before they were changed
+type SettingsBackup struct {
+	ID         int64     `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	InstanceID string    `json:"instanceId"`
+	Reason     string    `json:"reason"`
+	CreatedBy  string    `json:"createdBy"`
+	Settings   string    `json:"-"` // JSON object of AxeOS settings
+}
+
+const (
+	// Schema for device settings backups
+	createSettingsBackupsTable = `
+		CREATE TABLE IF NOT EXISTS settings_backups (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME NOT NULL,
+			instance_id TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			settings TEXT NOT NULL
+		);
+	`
+
+	createSettingsBackupsIndexes = `
+		CREATE INDEX IF NOT EXISTS idx_settings_backups_instance_timestamp ON settings_backups(instance_id, timestamp);
+	`
+)
+
+// InsertSettingsBackup stores a settings backup and drops the oldest backups
+// of the device beyond MaxSettingsBackups
+func (m *Manager) InsertSettingsBackup(backup *SettingsBackup) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if backup.Timestamp.IsZero() {
+		backup.Timestamp = time.Now()
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO settings_backups (timestamp, instance_id, reason, created_by, settings)
+		VALUES (?, ?, ?, ?, ?)
+	`, backup.Timestamp, backup.InstanceID, backup.Reason, backup.CreatedBy, backup.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to insert settings backup: %w", err)
+	}
+	backup.ID, _ = result.LastInsertId()
+
+	if _, err := db.Exec(`
+		DELETE FROM settings_backups WHERE instance_id = ? AND id NOT IN (
+			SELECT id FROM settings_backups WHERE instance_id = ? ORDER BY timestamp DESC, id DESC LIMIT ?
+		)
+	`, backup.InstanceID, backup.InstanceID, MaxSettingsBackups); err != nil {
+		return fmt.Errorf("failed to prune settings backups: %w", err)
+	}
+	return nil
+}
+
+// GetSettingsBackups retrieves the settings backups, newest first, optionally
+// for one device. The settings themselves are not loaded.
+func (m *Manager) GetSettingsBackups(instanceID string) ([]*SettingsBackup, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, timestamp, instance_id, reason, created_by FROM settings_backups`
+	var args []interface{}
+	if instanceID != "" {
+		query += " WHERE instance_id = ?"
+		args = append(args, instanceID)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settings backups: %w", err)
+	}
+	defer rows.Close()
+
+	var backups []*SettingsBackup
+	for rows.Next() {
+		backup := &SettingsBackup{}
+		if err := rows.Scan(&backup.ID, &backup.Timestamp, &backup.InstanceID, &backup.Reason, &backup.CreatedBy); err != nil {
+			return nil, err
+		}
+		backups = append(backups, backup)
+	}
+
+	return backups, rows.Err()
+}
+
+// GetSettingsBackup retrieves one settings backup with its settings, or nil if it does not exist
+func (m *Manager) GetSettingsBackup(id int64) (*SettingsBackup, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	backup := &SettingsBackup{}
+	err = db.QueryRow(`
+		SELECT id, timestamp, instance_id, reason, created_by, settings FROM settings_backups WHERE id = ?
+	`, id).Scan(&backup.ID, &backup.Timestamp, &backup.InstanceID, &backup.Reason, &backup.CreatedBy, &backup.Settings)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query settings backup: %w", err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Snapshot source types
+const (
+	SnapshotAxeOS = "axeos"
+	SnapshotPool  = "pool"
+)
+
+// SnapshotDayFormat is the layout of DailySnapshot.Day (local date)
+const SnapshotDayFormat = "2006-01-02"
+
+// DailySnapshot holds the cumulative counters and daily rollup of one device or pool.
+// Snapshots are never pruned, so lifetime stats survive raw-data cleanup.
+type DailySnapshot struct {
+	Day            string    `json:"day"`
+	SourceType     string    `json:"sourceType"`
+	SourceID       string    `json:"sourceId"`
+	SourceName     string    `json:"sourceName"`
+	SharesAccepted int       `json:"sharesAccepted"`
+	SharesRejected int       `json:"sharesRejected"`
+	BestDiff       float64   `json:"bestDiff"`
+	BlocksFound    int       `json:"blocksFound"`
+	Samples        int       `json:"samples"`
+	AvgHashrate    float64   `json:"avgHashrate"`
+	MaxHashrate    float64   `json:"maxHashrate"`
+	AvgTemperature float64   `json:"avgTemperature"`
+	UptimeSeconds  int       `json:"uptimeSeconds"`
+	EnergyKWh      float64   `json:"energyKWh"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// DayTotals are the largest counter values and averages recorded for a source during a day
+type DayTotals struct {
+	Samples        int
+	SourceName     string
+	SharesAccepted int
+	SharesRejected int
+	BlocksFound    int
+	BestDiffs      []string
+	AvgHashrate    float64
+	MaxHashrate    float64
+	AvgTemperature float64
+}
+
+const (
+	// Schema for daily snapshots (excluded from pruning)
+	createDailySnapshotsTable = `
+		CREATE TABLE IF NOT EXISTS daily_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			day TEXT NOT NULL,
+			source_type TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			source_name TEXT,
+			shares_accepted INTEGER,
+			shares_rejected INTEGER,
+			best_diff REAL,
+			blocks_found INTEGER,
+			samples INTEGER,
+			avg_hashrate REAL,
+			max_hashrate REAL,
+			avg_temperature REAL,
+			uptime_seconds INTEGER,
+			energy_kwh REAL,
+			updated_at DATETIME NOT NULL,
+			UNIQUE(source_type, source_id, day)
+		);
+	`
+)
+
+// UpsertDailySnapshot inserts or replaces the snapshot for a source and day
+func (m *Manager) UpsertDailySnapshot(s *DailySnapshot) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = time.Now()
+	}
+	avgHashrate, maxHashrate := s.AvgHashrate, s.MaxHashrate
+	if s.SourceType == SnapshotAxeOS {
+		avgHashrate, maxHashrate = avgHashrate*hashesPerGH, maxHashrate*hashesPerGH
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO daily_snapshots (
+			day, source_type, source_id, source_name, shares_accepted,
+			shares_rejected, best_diff, blocks_found, samples, avg_hashrate,
+			max_hashrate, avg_temperature, uptime_seconds, energy_kwh, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source_type, source_id, day) DO UPDATE SET
+			source_name = excluded.source_name,
+			shares_accepted = excluded.shares_accepted,
+			shares_rejected = excluded.shares_rejected,
+			best_diff = excluded.best_diff,
+			blocks_found = excluded.blocks_found,
+			samples = excluded.samples,
+			avg_hashrate = excluded.avg_hashrate,
+			max_hashrate = excluded.max_hashrate,
+			avg_temperature = excluded.avg_temperature,
+			uptime_seconds = excluded.uptime_seconds,
+			energy_kwh = excluded.energy_kwh,
+			updated_at = excluded.updated_at`,
+		s.Day, s.SourceType, s.SourceID, s.SourceName, s.SharesAccepted,
+		s.SharesRejected, s.BestDiff, s.BlocksFound, s.Samples, avgHashrate,
+		maxHashrate, s.AvgTemperature, s.UptimeSeconds, s.EnergyKWh, s.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save daily snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetDailySnapshots returns snapshots for a source from the given day (inclusive), oldest first
+func (m *Manager) GetDailySnapshots(sourceType, sourceID, sinceDay string) ([]*DailySnapshot, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT day, source_type, source_id, source_name, shares_accepted,
+		       shares_rejected, best_diff, blocks_found, samples, avg_hashrate,
+		       max_hashrate, avg_temperature, uptime_seconds, energy_kwh, updated_at
+		FROM daily_snapshots
+		WHERE source_type = ? AND source_id = ? AND day >= ?
+		ORDER BY day ASC
+	`, sourceType, sourceID, sinceDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*DailySnapshot
+	for rows.Next() {
+		s := &DailySnapshot{}
+		var name sql.NullString
+		var accepted, rejected, blocks, samples, uptime sql.NullInt64
+		var bestDiff, avgHashrate, maxHashrate, avgTemp, energy sql.NullFloat64
+		if err := rows.Scan(&s.Day, &s.SourceType, &s.SourceID, &name, &accepted,
+			&rejected, &bestDiff, &blocks, &samples, &avgHashrate,
+			&maxHashrate, &avgTemp, &uptime, &energy, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		s.SourceName = name.String
+		s.SharesAccepted = int(accepted.Int64)
+		s.SharesRejected = int(rejected.Int64)
+		s.BestDiff = bestDiff.Float64
+		s.BlocksFound = int(blocks.Int64)
+		s.Samples = int(samples.Int64)
+		s.AvgHashrate = avgHashrate.Float64
+		s.MaxHashrate = maxHashrate.Float64
+		if s.SourceType == SnapshotAxeOS {
+			s.AvgHashrate /= hashesPerGH
+			s.MaxHashrate /= hashesPerGH
+		}
+		s.AvgTemperature = avgTemp.Float64
+		s.UptimeSeconds = int(uptime.Int64)
+		s.EnergyKWh = energy.Float64
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// GetAxeOSDayTotals returns the largest share counters and every distinct best
+// difficulty recorded for an instance between start and end
+func (m *Manager) GetAxeOSDayTotals(instanceID string, start, end time.Time) (*DayTotals, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	totals := &DayTotals{}
+	var name sql.NullString
+	var accepted, rejected sql.NullInt64
+	var avgHashrate, maxHashrate, avgTemp sql.NullFloat64
+
+	err = db.QueryRow(`
+		SELECT COUNT(*), MAX(instance_name), MAX(shares_accepted), MAX(shares_rejected),
+		       AVG(hashrate), MAX(hashrate), AVG(temperature)
+		FROM axeos_metrics
+		WHERE instance_id = ? AND timestamp >= ? AND timestamp < ?
+	`, instanceID, start, end).Scan(&totals.Samples, &name, &accepted, &rejected,
+		&avgHashrate, &maxHashrate, &avgTemp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query AxeOS day totals: %w", err)
+	}
+	totals.SourceName = name.String
+	totals.SharesAccepted = int(accepted.Int64)
+	totals.SharesRejected = int(rejected.Int64)
+	totals.AvgHashrate = avgHashrate.Float64 / hashesPerGH
+	totals.MaxHashrate = maxHashrate.Float64 / hashesPerGH
+	totals.AvgTemperature = avgTemp.Float64
+
+	rows, err := db.Query(`
+		SELECT DISTINCT best_diff FROM axeos_metrics
+		WHERE instance_id = ? AND timestamp >= ? AND timestamp < ? AND best_diff IS NOT NULL
+	`, instanceID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query AxeOS best difficulty: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bestDiff string
+		if err := rows.Scan(&bestDiff); err != nil {
+			return nil, err
+		}
+		totals.BestDiffs = append(totals.BestDiffs, bestDiff)
+	}
+
+	return totals, rows.Err()
+}
+
+// GetPoolDayTotals returns the largest block count recorded for a pool between start and end
+func (m *Manager) GetPoolDayTotals(poolID string, start, end time.Time) (*DayTotals, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	totals := &DayTotals{}
+	var name sql.NullString
+	var blocks sql.NullInt64
+
+	err = db.QueryRow(`
+		SELECT COUNT(*), MAX(pool_name), MAX(blocks_found)
+		FROM pool_metrics
+		WHERE pool_id = ? AND timestamp >= ? AND timestamp < ?
+	`, poolID, start, end).Scan(&totals.Samples, &name, &blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pool day totals: %w", err)
+	}
+	totals.SourceName = name.String
+	totals.BlocksFound = int(blocks.Int64)
+
+	return totals, nil
+}
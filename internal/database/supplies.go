@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SupplyDay is the input voltage and draw of the devices on a power supply
+// over one day, counting only samples taken while they were hashing
+type SupplyDay struct {
+	Day        time.Time `json:"day"`
+	Samples    int       `json:"samples"`
+	AvgVoltage float64   `json:"avgVoltage"` // mV
+	MinVoltage float64   `json:"minVoltage"` // mV
+	AvgPower   float64   `json:"avgPower"`   // W per device
+	AvgCurrent float64   `json:"avgCurrent"` // mA per device
+}
+
+// GetSupplyDays returns the loaded input voltage of a group of devices for
+// each local day from start until end, oldest first. Days without samples
+// are left out.
+func (m *Manager) GetSupplyDays(instanceIDs []string, start, end time.Time) ([]*SupplyDay, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	if len(instanceIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(instanceIDs)), ", ")
+	query := fmt.Sprintf(`
+		SELECT COUNT(*), AVG(voltage), MIN(voltage), AVG(power), AVG(current)
+		FROM axeos_metrics
+		WHERE instance_id IN (%s) AND timestamp >= ? AND timestamp < ?
+		  AND hashrate > 0 AND power > 0 AND voltage > 0
+	`, placeholders)
+
+	days := []*SupplyDay{}
+	for day := RollupBucket(ResolutionDaily, start); day.Before(end); day = NextRollupBucket(ResolutionDaily, day) {
+		args := make([]interface{}, 0, len(instanceIDs)+2)
+		for _, id := range instanceIDs {
+			args = append(args, id)
+		}
+		args = append(args, day, NextRollupBucket(ResolutionDaily, day))
+
+		d := &SupplyDay{Day: day}
+		var avgVoltage, minVoltage, avgPower, avgCurrent sql.NullFloat64
+		if err := db.QueryRow(query, args...).Scan(&d.Samples, &avgVoltage, &minVoltage, &avgPower, &avgCurrent); err != nil {
+			return nil, fmt.Errorf("failed to query supply voltage: %w", err)
+		}
+		if d.Samples == 0 {
+			continue
+		}
+		d.AvgVoltage = avgVoltage.Float64
+		d.MinVoltage = minVoltage.Float64
+		d.AvgPower = avgPower.Float64
+		d.AvgCurrent = avgCurrent.Float64
+		days = append(days, d)
+	}
+	return days, nil
+}
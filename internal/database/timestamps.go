@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TimeFormat is the name of the format times are stored in (the driver's
+// _time_format): "2006-01-02 15:04:05.999999999-07:00"
+const TimeFormat = "sqlite"
+
+// timeFormatKey records the format of the stored times in schema_meta
+const timeFormatKey = "time_format"
+
+// legacyTimeCondition matches times written by older versions with Go's
+// time.String, e.g. "2006-01-02 15:04:05.5 -0700 MST m=+0.01"; times in
+// TimeFormat contain a single space
+const legacyTimeCondition = "%[1]s LIKE '____-__-__ __:__:__%% %% %%'"
+
+// legacyTimeConversion rewrites a time.String value in TimeFormat: the date
+// and time up to the second space, then the offset with a colon. Go trims
+// trailing zeros of the fraction the same way in both formats.
+const legacyTimeConversion = `substr(%[1]s, 1, instr(substr(%[1]s, 12), ' ') + 10) ||
+	substr(%[1]s, instr(substr(%[1]s, 12), ' ') + 12, 3) || ':' ||
+	substr(%[1]s, instr(substr(%[1]s, 12), ' ') + 15, 2)`
+
+// normalizeTimestamps rewrites the times stored by versions before
+// TimeFormat, once, and records the format, so an instant is always stored as
+// the same text and imports match the upsert keys of existing rows. A
+// converted row that now duplicates a newer copy of itself replaces it.
+func (m *Manager) normalizeTimestamps() error {
+	var format string
+	err := m.db.QueryRow(`SELECT value FROM schema_meta WHERE key = ?`, timeFormatKey).Scan(&format)
+	if err == nil && format == TimeFormat {
+		return nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read time format: %w", err)
+	}
+
+	columns, err := m.datetimeColumns()
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var converted int64
+	for _, column := range columns {
+		result, err := tx.Exec(fmt.Sprintf(`UPDATE OR REPLACE %s SET %s = %s WHERE %s`, column.table, column.name,
+			fmt.Sprintf(legacyTimeConversion, column.name), fmt.Sprintf(legacyTimeCondition, column.name)))
+		if err != nil {
+			return fmt.Errorf("failed to convert %s.%s: %w", column.table, column.name, err)
+		}
+		n, _ := result.RowsAffected()
+		converted += n
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO schema_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, timeFormatKey, TimeFormat); err != nil {
+		return fmt.Errorf("failed to record time format: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if converted > 0 {
+		m.log.Info("Converted %d stored times to the %s format", converted, TimeFormat)
+	}
+	return nil
+}
+
+// datetimeColumn is a DATETIME column of a table
+type datetimeColumn struct {
+	table string
+	name  string
+}
+
+// datetimeColumns returns the DATETIME columns of every table
+func (m *Manager) datetimeColumns() ([]datetimeColumn, error) {
+	rows, err := m.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var columns []datetimeColumn
+	for _, table := range tables {
+		rows, err := m.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var cid, notNull, pk int
+			var name, colType string
+			var dflt sql.NullString
+			if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if colType == "DATETIME" {
+				columns = append(columns, datetimeColumn{table, name})
+			}
+		}
+		rows.Close()
+	}
+	return columns, nil
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MetricsExportVersion is the format version of exported metric documents
+const MetricsExportVersion = 1
+
+// MetricsExport is a portable snapshot of collected metrics
+type MetricsExport struct {
+	Version      int            `json:"version"`
+	ExportedAt   time.Time      `json:"exportedAt"`
+	InstanceID   string         `json:"instanceId,omitempty"`
+	AxeOSMetrics []*AxeOSMetric `json:"axeosMetrics"`
+	PoolMetrics  []*PoolMetric  `json:"poolMetrics"`
+	NodeMetrics  []*NodeMetric  `json:"nodeMetrics"`
+	Events       []*Event       `json:"events"`
+}
+
+// ImportResult reports how many rows were inserted or skipped as duplicates
+type ImportResult struct {
+	Inserted map[string]int `json:"inserted"`
+	Skipped  map[string]int `json:"skipped"`
+}
+
+// StreamEvents calls emit with each event, oldest first, without loading the
+// events table into memory. source limits the events to one device when set.
+func (m *Manager) StreamEvents(source string, emit func(event *Event) error) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	query := `SELECT id, timestamp, type, source, title, message FROM events`
+	var args []interface{}
+	if source != "" {
+		query += ` WHERE source = ?`
+		args = append(args, source)
+	}
+
+	rows, err := db.Query(query+` ORDER BY timestamp ASC`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event := &Event{}
+		var message sql.NullString
+		if err := rows.Scan(&event.ID, &event.Timestamp, &event.Type, &event.Source, &event.Title, &message); err != nil {
+			return err
+		}
+		event.Message = message.String
+		if err := emit(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportMetrics inserts exported metrics in a single transaction, skipping
+// rows whose upsert key (source id + timestamp) already exists. Timestamps are
+// converted to local time to match how collected rows are stored
+func (m *Manager) ImportMetrics(export *MetricsExport) (*ImportResult, error) {
+	db, err := m.conn()
+	if err != nil {
+		return nil, err
+	}
+	if export.Version != MetricsExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", export.Version)
+	}
+
+	result := &ImportResult{Inserted: map[string]int{}, Skipped: map[string]int{}}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	count := func(table string, res sql.Result) {
+		if n, _ := res.RowsAffected(); n > 0 {
+			result.Inserted[table]++
+		} else {
+			result.Skipped[table]++
+		}
+	}
+
+	for _, metric := range export.AxeOSMetrics {
+		res, err := tx.Exec(`
+			INSERT OR IGNORE INTO axeos_metrics (
+				timestamp, instance_id, instance_name, hashrate, temperature, power,
+				fan_speed, best_diff, shares_accepted, shares_rejected,
+				frequency, voltage, core_voltage, current
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			metric.Timestamp.Local(), metric.InstanceID, metric.InstanceName, metric.Hashrate*hashesPerGH,
+			metric.Temperature, metric.Power, metric.FanSpeed, metric.BestDiff,
+			metric.SharesAccepted, metric.SharesRejected, metric.Frequency,
+			metric.Voltage, metric.CoreVoltage, metric.Current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import AxeOS metric: %w", err)
+		}
+		count("axeos_metrics", res)
+	}
+
+	for _, metric := range export.PoolMetrics {
+		var lastBlockTime interface{}
+		if metric.LastBlockTime != nil {
+			lastBlockTime = *metric.LastBlockTime
+		}
+		res, err := tx.Exec(`
+			INSERT OR IGNORE INTO pool_metrics (
+				timestamp, pool_id, pool_name, pool_hashrate, pool_workers,
+				network_hashrate, network_difficulty, last_block_time, blocks_found
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			metric.Timestamp.Local(), metric.PoolID, metric.PoolName, metric.PoolHashrate,
+			metric.PoolWorkers, metric.NetworkHashrate, metric.NetworkDifficulty,
+			lastBlockTime, metric.BlocksFound)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import pool metric: %w", err)
+		}
+		count("pool_metrics", res)
+	}
+
+	for _, metric := range export.NodeMetrics {
+		res, err := tx.Exec(`
+			INSERT OR IGNORE INTO node_metrics (
+				timestamp, node_id, node_name, block_height, connections,
+				difficulty, network_hashrate, mempool_transactions, mempool_bytes,
+				fee_fast, fee_medium, fee_slow
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			metric.Timestamp.Local(), metric.NodeID, metric.NodeName, metric.BlockHeight,
+			metric.Connections, metric.Difficulty, metric.NetworkHashrate, metric.MempoolTransactions,
+			metric.MempoolBytes, metric.FeeFast, metric.FeeMedium, metric.FeeSlow)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import node metric: %w", err)
+		}
+		count("node_metrics", res)
+	}
+
+	for _, event := range export.Events {
+		res, err := tx.Exec(
+			`INSERT OR IGNORE INTO events (timestamp, type, source, title, message) VALUES (?, ?, ?, ?, ?)`,
+			event.Timestamp.Local(), event.Type, event.Source, event.Title, event.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import event: %w", err)
+		}
+		count("events", res)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return result, nil
+}
+
+// Tables that can be streamed by StreamMetrics
+const (
+	TableAxeOS = "axeos"
+	TablePool  = "pool"
+	TableNode  = "node"
+)
+
+// StreamMetrics calls emit with each row of one metrics table between start
+// and end, oldest first, without loading the table into memory. Zero start
+// and end times stream the whole table; sourceID limits the rows to one
+// device, pool or node when set. Rows are *AxeOSMetric, *PoolMetric or
+// *NodeMetric depending on the table.
+func (m *Manager) StreamMetrics(table, sourceID string, start, end time.Time, emit func(metric interface{}) error) error {
+	db, err := m.conn()
+	if err != nil {
+		return err
+	}
+	var query, sourceColumn string
+	var scan func(*sql.Rows) (interface{}, error)
+	switch table {
+	case TableAxeOS:
+		query = `SELECT timestamp, instance_id, instance_name, hashrate, temperature, power,
+		       fan_speed, best_diff, shares_accepted, shares_rejected,
+		       frequency, voltage, core_voltage, current
+		FROM axeos_metrics`
+		sourceColumn = "instance_id"
+		scan = func(rows *sql.Rows) (interface{}, error) { return scanAxeOSMetric(rows) }
+	case TablePool:
+		query = `SELECT timestamp, pool_id, pool_name, pool_hashrate, pool_workers,
+		       network_hashrate, network_difficulty, last_block_time, blocks_found
+		FROM pool_metrics`
+		sourceColumn = "pool_id"
+		scan = func(rows *sql.Rows) (interface{}, error) { return scanPoolMetric(rows) }
+	case TableNode:
+		query = `SELECT timestamp, node_id, node_name, block_height, connections,
+		       difficulty, network_hashrate, mempool_transactions, mempool_bytes,
+		       fee_fast, fee_medium, fee_slow
+		FROM node_metrics`
+		sourceColumn = "node_id"
+		scan = func(rows *sql.Rows) (interface{}, error) { return scanNodeMetric(rows) }
+	default:
+		return fmt.Errorf("unknown metrics table %q", table)
+	}
+
+	var conditions []string
+	var args []interface{}
+	if !start.IsZero() || !end.IsZero() {
+		conditions = append(conditions, `timestamp BETWEEN ? AND ?`)
+		args = append(args, start, end)
+	}
+	if sourceID != "" {
+		conditions = append(conditions, sourceColumn+` = ?`)
+		args = append(args, sourceID)
+	}
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+
+	rows, err := db.Query(query+` ORDER BY timestamp ASC`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export %s metrics: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		metric, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := emit(metric); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
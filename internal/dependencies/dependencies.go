@@ -0,0 +1,194 @@
+// Package dependencies tracks the reachability and latency of the external
+// services the dashboard talks to
+package dependencies
+
+import (
+	"sync"
+	"time"
+)
+
+// Kinds of external dependencies
+const (
+	KindAxeOS        = "axeos"        // Miners
+	KindPool         = "pool"         // Mining Core pools
+	KindNode         = "node"         // Crypto node RPC
+	KindMarket       = "market"       // Price and network data providers
+	KindNotification = "notification" // Notification channels
+)
+
+// Dependency statuses
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded" // Reachable now, but recent checks failed
+	StatusDown     = "down"     // The last check failed
+	StatusUnknown  = "unknown"  // Not checked since the server started
+)
+
+const (
+	// maxChecks caps the checks kept per dependency
+	maxChecks = 2000
+	// checkRetention is how long checks are kept for the success rates
+	checkRetention = 24 * time.Hour
+	// degradedBelow is the success rate over the last hour below which a dependency is degraded
+	degradedBelow = 0.9
+)
+
+// check is the outcome of one request to a dependency
+type check struct {
+	at      time.Time
+	latency time.Duration
+	ok      bool
+}
+
+// dependency holds the recent checks of one dependency
+type dependency struct {
+	checks      []check
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Status summarizes the recent checks of one dependency
+type Status struct {
+	Kind           string     `json:"kind"`
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`
+	LastCheck      *time.Time `json:"lastCheck,omitempty"`
+	LastLatencyMs  float64    `json:"lastLatencyMs,omitempty"`
+	LastSuccess    *time.Time `json:"lastSuccess,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	LastErrorAt    *time.Time `json:"lastErrorAt,omitempty"`
+	Checks1h       int        `json:"checks1h"`
+	SuccessRate1h  *float64   `json:"successRate1h,omitempty"`
+	AvgLatencyMs1h *float64   `json:"avgLatencyMs1h,omitempty"`
+	Checks24h      int        `json:"checks24h"`
+	SuccessRate24h *float64   `json:"successRate24h,omitempty"`
+}
+
+// Tracker keeps the recent checks of every dependency in memory
+type Tracker struct {
+	mu           sync.Mutex
+	dependencies map[string]map[string]*dependency // kind -> name -> checks
+}
+
+var (
+	tracker     *Tracker
+	trackerOnce sync.Once
+)
+
+// GetTracker returns the singleton tracker
+func GetTracker() *Tracker {
+	trackerOnce.Do(func() {
+		tracker = &Tracker{dependencies: make(map[string]map[string]*dependency)}
+	})
+	return tracker
+}
+
+// Record adds the outcome of a request to a dependency; err is nil on success
+func Record(kind, name string, latency time.Duration, err error) {
+	GetTracker().Record(kind, name, latency, err)
+}
+
+// Record adds the outcome of a request to a dependency; err is nil on success
+func (t *Tracker) Record(kind, name string, latency time.Duration, err error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byName, ok := t.dependencies[kind]
+	if !ok {
+		byName = make(map[string]*dependency)
+		t.dependencies[kind] = byName
+	}
+	d, ok := byName[name]
+	if !ok {
+		d = &dependency{}
+		byName[name] = d
+	}
+
+	// Drop checks that are too old or too many
+	drop := 0
+	for drop < len(d.checks) && (now.Sub(d.checks[drop].at) > checkRetention || len(d.checks)-drop >= maxChecks) {
+		drop++
+	}
+	d.checks = append(d.checks[drop:], check{at: now, latency: latency, ok: err == nil})
+
+	if err != nil {
+		d.lastError = err.Error()
+		d.lastErrorAt = now
+	} else {
+		d.lastSuccess = now
+	}
+}
+
+// Status returns the summary of one dependency; dependencies that were never
+// checked are reported as unknown
+func (t *Tracker) Status(kind, name string) Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := Status{Kind: kind, Name: name, Status: StatusUnknown}
+	d, ok := t.dependencies[kind][name]
+	if !ok || len(d.checks) == 0 {
+		return status
+	}
+
+	now := time.Now()
+	var ok1h, ok24h int
+	var latency1h time.Duration
+	for _, c := range d.checks {
+		age := now.Sub(c.at)
+		if age > checkRetention {
+			continue
+		}
+		status.Checks24h++
+		if c.ok {
+			ok24h++
+		}
+		if age <= time.Hour {
+			status.Checks1h++
+			latency1h += c.latency
+			if c.ok {
+				ok1h++
+			}
+		}
+	}
+	rate := func(ok, total int) *float64 {
+		if total == 0 {
+			return nil
+		}
+		r := float64(ok) / float64(total)
+		return &r
+	}
+	status.SuccessRate1h = rate(ok1h, status.Checks1h)
+	status.SuccessRate24h = rate(ok24h, status.Checks24h)
+	if status.Checks1h > 0 {
+		avg := float64(latency1h.Microseconds()) / 1000 / float64(status.Checks1h)
+		status.AvgLatencyMs1h = &avg
+	}
+
+	last := d.checks[len(d.checks)-1]
+	lastCheck := last.at
+	status.LastCheck = &lastCheck
+	status.LastLatencyMs = float64(last.latency.Microseconds()) / 1000
+	if !d.lastSuccess.IsZero() {
+		lastSuccess := d.lastSuccess
+		status.LastSuccess = &lastSuccess
+	}
+	if !d.lastErrorAt.IsZero() {
+		lastErrorAt := d.lastErrorAt
+		status.LastError = d.lastError
+		status.LastErrorAt = &lastErrorAt
+	}
+
+	switch {
+	case !last.ok:
+		status.Status = StatusDown
+	case status.SuccessRate1h != nil && *status.SuccessRate1h < degradedBelow:
+		status.Status = StatusDegraded
+	default:
+		status.Status = StatusUp
+	}
+	return status
+}
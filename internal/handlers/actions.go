@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// Bulk action types
+const (
+	ActionRestart  = "restart"
+	ActionSettings = "settings"
+	ActionPool     = "pool"
+)
+
+// Bulk action states
+const (
+	ActionPending   = "pending"
+	ActionRunning   = "running"
+	ActionCompleted = "completed"
+)
+
+const (
+	// actionRetention is how long finished actions can be polled
+	actionRetention = 24 * time.Hour
+	// actionConcurrency limits how many devices a bulk action contacts at once
+	actionConcurrency = 4
+)
+
+// ActionResult is the outcome of a bulk action for one device
+type ActionResult struct {
+	InstanceID string `json:"instanceId"`
+	Status     string `json:"status"` // "pending", "success" or "error"
+	Message    string `json:"message,omitempty"`
+}
+
+// Action is a bulk action running in the background
+type Action struct {
+	ID          string         `json:"id"`
+	Type        string         `json:"type"`
+	Status      string         `json:"status"`
+	RequestedBy string         `json:"requestedBy"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	FinishedAt  *time.Time     `json:"finishedAt,omitempty"`
+	Succeeded   int            `json:"succeeded"`
+	Failed      int            `json:"failed"`
+	Results     []ActionResult `json:"results"`
+}
+
+// BulkActionRequest is the body of POST /api/instance/service/bulk and /api/instances/batch
+type BulkActionRequest struct {
+	Action      string                 `json:"action"`
+	InstanceIDs []string               `json:"instanceIds"` // Empty means all instances
+	Settings    map[string]interface{} `json:"settings,omitempty"`
+	Pool        *config.PoolProfile    `json:"pool,omitempty"`    // The name is optional here
+	Restart     bool                   `json:"restart,omitempty"` // Restart each device after applying settings or a pool
+}
+
+// stratumUserFields are the settings whose placeholders, such as {hostname},
+// are filled in for each device by the settings and pool actions
+var stratumUserFields = []string{"stratumUser", "fallbackStratumUser"}
+
+// poolSettings returns the AxeOS settings that switch a device to a pool
+// profile. The pool users are templates, expanded by deviceSettings.
+func poolSettings(profile *config.PoolProfile) map[string]interface{} {
+	settings := map[string]interface{}{
+		"stratumURL":      profile.URL,
+		"stratumPort":     profile.Port,
+		"stratumUser":     profile.User,
+		"stratumPassword": profile.PasswordOrDefault(),
+	}
+	if fallback := profile.Fallback; fallback != nil {
+		settings["fallbackStratumURL"] = fallback.URL
+		settings["fallbackStratumPort"] = fallback.Port
+		settings["fallbackStratumUser"] = fallback.User
+		settings["fallbackStratumPassword"] = fallback.PasswordOrDefault()
+	}
+	return settings
+}
+
+// validateStratumUsers checks the pool users of bulk settings
+func validateStratumUsers(settings map[string]interface{}) error {
+	for _, field := range stratumUserFields {
+		value, ok := settings[field]
+		if !ok {
+			continue
+		}
+		user, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", field)
+		}
+		if err := config.ValidatePoolUser(user); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// deviceSettings returns the body to PATCH to one device, with the
+// placeholders of its pool users filled in. The device is only asked for its
+// hostname and MAC address when a pool user needs them.
+func deviceSettings(ctx context.Context, cfg *config.Config, instanceID, instanceURL string, settings map[string]interface{}) ([]byte, error) {
+	device := config.PoolUserDevice{InstanceID: instanceID}
+	fetched := false
+	expanded := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		expanded[key] = value
+	}
+
+	for _, field := range stratumUserFields {
+		user, ok := settings[field].(string)
+		if !ok {
+			continue
+		}
+		if config.PoolUserNeedsDeviceInfo(user) && !fetched {
+			info, err := fetchDeviceInfo(ctx, cfg, instanceID, instanceURL)
+			if err != nil {
+				return nil, fmt.Errorf("could not read device info for %s: %w", field, err)
+			}
+			fetched = true
+			device.Hostname, _ = info["hostname"].(string)
+			device.MAC, _ = info["macAddr"].(string)
+		}
+		if strings.Contains(user, config.PoolUserHostnamePlaceholder) && device.Hostname == "" {
+			return nil, fmt.Errorf("device reports no hostname for %s", field)
+		}
+		if strings.Contains(user, config.PoolUserMACPlaceholder) && device.MAC == "" {
+			return nil, fmt.Errorf("device reports no MAC address for %s", field)
+		}
+		expanded[field] = config.ExpandPoolUser(user, device)
+	}
+	return json.Marshal(expanded)
+}
+
+var (
+	actions   = map[string]*Action{}
+	actionsMu sync.Mutex
+)
+
+// newActionID returns a random identifier for an action
+func newActionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// storeAction registers an action, dropping finished ones past their retention
+func storeAction(action *Action) {
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+
+	for id, a := range actions {
+		if a.FinishedAt != nil && time.Since(*a.FinishedAt) > actionRetention {
+			delete(actions, id)
+		}
+	}
+	actions[action.ID] = action
+}
+
+// snapshotAction returns a copy of an action that is safe to encode
+func snapshotAction(action *Action) Action {
+	copied := *action
+	copied.Results = append([]ActionResult(nil), action.Results...)
+	return copied
+}
+
+// runAction performs a bulk action on every device, a few at a time. settings
+// holds the settings to PATCH to each device for the settings and pool
+// actions, with pool users expanded per device.
+func runAction(action *Action, cfg *config.Config, urls map[string]string, settings map[string]interface{}, restart bool) {
+	actionsMu.Lock()
+	action.Status = ActionRunning
+	actionsMu.Unlock()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, actionConcurrency)
+	for i := range action.Results {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			instanceID := action.Results[i].InstanceID
+			restarted := false
+			var err error
+			switch action.Type {
+			case ActionRestart:
+				err = services.SendDeviceRequest(cfg, instanceID, http.MethodPost, urls[instanceID]+services.GetAPIPath(cfg, "instanceRestart"), nil)
+				restarted = err == nil
+			case ActionSettings, ActionPool:
+				// Keep a copy of the current settings so the change can be rolled back
+				reason := database.BackupReasonSettings
+				if action.Type == ActionPool {
+					reason = database.BackupReasonPool
+				}
+				if _, backupErr := backupDeviceSettings(context.Background(), cfg, instanceID, urls[instanceID], reason, action.RequestedBy); backupErr != nil {
+					log.Error("Failed to back up settings of %s: %v", instanceID, backupErr)
+				}
+				var body []byte
+				if body, err = deviceSettings(context.Background(), cfg, instanceID, urls[instanceID], settings); err != nil {
+					break
+				}
+				err = services.SendDeviceRequest(cfg, instanceID, http.MethodPatch, urls[instanceID]+services.GetAPIPath(cfg, "instanceSettings"), body)
+				if err == nil && restart {
+					// AxeOS applies stratum and frequency changes after a restart
+					if err = services.SendDeviceRequest(cfg, instanceID, http.MethodPost, urls[instanceID]+services.GetAPIPath(cfg, "instanceRestart"), nil); err != nil {
+						err = fmt.Errorf("settings applied but restart failed: %w", err)
+					}
+					restarted = err == nil
+				}
+			}
+
+			if restarted {
+				// Record the restart so it shows up in event feeds
+				if db := database.Instance(); db != nil {
+					if err := db.InsertEvent(&database.Event{
+						Type:    database.EventRestart,
+						Source:  instanceID,
+						Title:   fmt.Sprintf("%s restarted", instanceID),
+						Message: fmt.Sprintf("Restart requested by %s (bulk action %s)", action.RequestedBy, action.ID),
+					}); err != nil {
+						log.Error("Failed to record restart event: %v", err)
+					}
+				}
+			}
+
+			actionsMu.Lock()
+			defer actionsMu.Unlock()
+			if err != nil {
+				log.Error("Bulk %s failed for %s: %v", action.Type, instanceID, err)
+				action.Results[i].Status = "error"
+				action.Results[i].Message = err.Error()
+				action.Failed++
+			} else {
+				action.Results[i].Status = "success"
+				action.Succeeded++
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+	now := time.Now()
+	action.Status = ActionCompleted
+	action.FinishedAt = &now
+}
+
+// HandleBulkAction handles POST /api/instance/service/bulk and POST /api/instances/batch
+// Starts a restart, settings update or pool change on several devices and returns 202 with the action to poll
+func HandleBulkAction(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if cfg.DisableSettings {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Settings are disabled by configuration."})
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": message})
+		}
+
+		var req BulkActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			badRequest("Invalid JSON in request body")
+			return
+		}
+		defer r.Body.Close()
+
+		startBulkAction(w, r, cfg, req)
+	}
+}
+
+// PoolSwitchRequest is the body of POST /api/instances/pool-switch
+type PoolSwitchRequest struct {
+	Profile     string   `json:"profile"`
+	InstanceIDs []string `json:"instanceIds"` // Empty means all instances
+	Restart     bool     `json:"restart,omitempty"`
+}
+
+// HandlePoolSwitch handles POST /api/instances/pool-switch
+// Pushes a pool profile from config.json to several devices as a bulk pool action
+func HandlePoolSwitch(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if cfg.DisableSettings {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Settings are disabled by configuration."})
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		var req PoolSwitchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Profile == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Request body must name a pool profile"})
+			return
+		}
+		defer r.Body.Close()
+
+		profile, ok := cfg.FindPoolProfile(req.Profile)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": fmt.Sprintf("Pool profile \"%s\" not found in configuration.", req.Profile),
+			})
+			return
+		}
+
+		startBulkAction(w, r, cfg, BulkActionRequest{
+			Action:      ActionPool,
+			InstanceIDs: req.InstanceIDs,
+			Pool:        profile,
+			Restart:     req.Restart,
+		})
+	}
+}
+
+// startBulkAction validates a bulk action, starts it in the background and
+// responds with 202 and the action to poll
+func startBulkAction(w http.ResponseWriter, r *http.Request, cfg *config.Config, req BulkActionRequest) {
+	badRequest := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}
+
+	switch req.Action {
+	case ActionRestart:
+	case ActionSettings:
+		if len(req.Settings) == 0 {
+			badRequest("settings are required for the settings action")
+			return
+		}
+		if err := validateStratumUsers(req.Settings); err != nil {
+			badRequest("Invalid settings: " + err.Error())
+			return
+		}
+	case ActionPool:
+		if req.Pool == nil {
+			badRequest("pool is required for the pool action")
+			return
+		}
+		if err := req.Pool.Validate(); err != nil {
+			badRequest("Invalid pool: " + err.Error())
+			return
+		}
+	default:
+		badRequest("action must be restart, settings or pool")
+		return
+	}
+
+	urls := map[string]string{}
+	if len(req.InstanceIDs) == 0 {
+		for _, instance := range cfg.AxeosInstances {
+			for name, url := range instance {
+				urls[name] = url
+				req.InstanceIDs = append(req.InstanceIDs, name)
+			}
+		}
+		sort.Strings(req.InstanceIDs)
+	}
+	for _, id := range req.InstanceIDs {
+		url := findInstanceURL(cfg, id)
+		if url == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", id),
+			})
+			return
+		}
+		urls[id] = url
+	}
+	if len(urls) == 0 {
+		badRequest("No instances to act on")
+		return
+	}
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	action := &Action{
+		ID:          newActionID(),
+		Type:        req.Action,
+		Status:      ActionPending,
+		RequestedBy: username,
+		CreatedAt:   time.Now(),
+	}
+	for id := range urls {
+		action.Results = append(action.Results, ActionResult{InstanceID: id, Status: "pending"})
+	}
+	sort.Slice(action.Results, func(i, j int) bool { return action.Results[i].InstanceID < action.Results[j].InstanceID })
+
+	storeAction(action)
+	actionsMu.Lock()
+	response := snapshotAction(action)
+	actionsMu.Unlock()
+
+	settings := req.Settings
+	if req.Action == ActionPool {
+		settings = poolSettings(req.Pool)
+	}
+
+	go runAction(action, cfg, urls, settings, req.Restart)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", middleware.BasePath(r)+"/api/actions/"+action.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleActionStatus handles GET /api/actions and GET /api/actions/{id}
+// Lists recent bulk actions, or returns the progress of one
+func HandleActionStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/actions"), "/")
+
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+
+	if id == "" {
+		list := []Action{}
+		for _, action := range actions {
+			list = append(list, snapshotAction(action))
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"actions": list})
+		return
+	}
+
+	action, ok := actions[id]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Action not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(snapshotAction(action))
+}
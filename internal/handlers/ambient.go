@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// minAmbientCorrelationSamples is the fewest paired samples needed for statistics
+const minAmbientCorrelationSamples = 3
+
+// AmbientSample pairs a miner sample with the nearest ambient reading
+type AmbientSample struct {
+	Timestamp          time.Time `json:"timestamp"`
+	AmbientTemperature float64   `json:"ambientTemperature"`
+	Humidity           *float64  `json:"humidity,omitempty"`
+	ASICTemperature    float64   `json:"asicTemperature"`
+}
+
+// AmbientCorrelation is the response of GET /api/ambient/correlation
+type AmbientCorrelation struct {
+	InstanceID      string          `json:"instanceId"`
+	Start           time.Time       `json:"start"`
+	End             time.Time       `json:"end"`
+	TemperatureUnit string          `json:"temperatureUnit"`
+	Count           int             `json:"count"`
+	Correlation     *float64        `json:"correlation"` // Pearson r between ambient and ASIC temperature
+	Slope           *float64        `json:"slope"`       // ASIC degrees per ambient degree
+	AvgDelta        *float64        `json:"avgDelta"`    // Mean ASIC minus ambient temperature
+	Samples         []AmbientSample `json:"samples"`
+}
+
+// pairAmbientSamples matches each miner sample with the ambient reading closest
+// in time, skipping miner samples with no reading within tolerance. ambient must
+// be sorted oldest first.
+func pairAmbientSamples(metrics []*database.AxeOSMetric, ambient []*database.AmbientMetric, tolerance time.Duration) []AmbientSample {
+	samples := []AmbientSample{}
+	for _, metric := range metrics {
+		i := sort.Search(len(ambient), func(i int) bool {
+			return !ambient[i].Timestamp.Before(metric.Timestamp)
+		})
+
+		var nearest *database.AmbientMetric
+		for _, j := range []int{i - 1, i} {
+			if j < 0 || j >= len(ambient) {
+				continue
+			}
+			if nearest == nil || absDuration(ambient[j].Timestamp.Sub(metric.Timestamp)) < absDuration(nearest.Timestamp.Sub(metric.Timestamp)) {
+				nearest = ambient[j]
+			}
+		}
+		if nearest == nil || absDuration(nearest.Timestamp.Sub(metric.Timestamp)) > tolerance {
+			continue
+		}
+
+		samples = append(samples, AmbientSample{
+			Timestamp:          metric.Timestamp,
+			AmbientTemperature: nearest.Temperature,
+			Humidity:           nearest.Humidity,
+			ASICTemperature:    metric.Temperature,
+		})
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) })
+	return samples
+}
+
+// absDuration returns the absolute value of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// correlateAmbient computes the Pearson correlation, regression slope and mean
+// difference of ASIC over ambient temperature. Values are nil when undefined.
+func correlateAmbient(samples []AmbientSample) (correlation, slope, avgDelta *float64) {
+	n := float64(len(samples))
+	if len(samples) < minAmbientCorrelationSamples {
+		return nil, nil, nil
+	}
+
+	var sumX, sumY float64
+	for _, s := range samples {
+		sumX += s.AmbientTemperature
+		sumY += s.ASICTemperature
+	}
+	meanX, meanY := sumX/n, sumY/n
+	delta := meanY - meanX
+	avgDelta = &delta
+
+	var covariance, varianceX, varianceY float64
+	for _, s := range samples {
+		dx, dy := s.AmbientTemperature-meanX, s.ASICTemperature-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 {
+		return nil, nil, avgDelta // Ambient never changed
+	}
+	b := covariance / varianceX
+	slope = &b
+	if varianceY > 0 {
+		r := covariance / math.Sqrt(varianceX*varianceY)
+		correlation = &r
+	}
+	return correlation, slope, avgDelta
+}
+
+// HandleAmbientCorrelation handles GET /api/ambient/correlation?instanceId=X[&start=&end=&units=C|F]
+// Returns a miner's ASIC temperature paired with the ambient temperature, with correlation statistics.
+// start and end accept RFC 3339 or Unix seconds and default to the last 24 hours.
+func HandleAmbientCorrelation(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+		}
+
+		instanceID := query.Get("instanceId")
+		if instanceID == "" {
+			badRequest("instanceId is required")
+			return
+		}
+		if !knownDevice(cfg, instanceID) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Instance not found"})
+			return
+		}
+
+		end := time.Now()
+		if value := query.Get("end"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid end time: use RFC 3339 or Unix seconds")
+				return
+			}
+			end = t
+		}
+		start := end.Add(-defaultHistoryWindow)
+		if value := query.Get("start"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid start time: use RFC 3339 or Unix seconds")
+				return
+			}
+			start = t
+		}
+		if !start.Before(end) {
+			badRequest("start must be before end")
+			return
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		// Stored timestamps are local time; compare in the same zone
+		start, end = start.Local(), end.Local()
+
+		metrics, err := db.GetAxeOSMetrics(instanceID, start, end, maxHistoryLimit)
+		var ambient []*database.AmbientMetric
+		if err == nil {
+			ambient, err = db.GetAmbientMetrics(start, end, maxHistoryLimit)
+		}
+		if err != nil {
+			log.ErrorWithRequest(r, "Error correlating ambient temperature for %s: %v", instanceID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		// Readings are taken once per collection interval, so one interval apart is still a match
+		tolerance := time.Duration(cfg.CollectionIntervalSeconds) * time.Second
+		unit := temperatureUnit(r, cfg)
+		samples := pairAmbientSamples(metrics, ambient, tolerance)
+		for i := range samples {
+			samples[i].AmbientTemperature = config.CelsiusTo(unit, samples[i].AmbientTemperature)
+			samples[i].ASICTemperature = config.CelsiusTo(unit, samples[i].ASICTemperature)
+		}
+
+		response := AmbientCorrelation{
+			InstanceID:      instanceID,
+			Start:           start,
+			End:             end,
+			TemperatureUnit: unit,
+			Count:           len(samples),
+			Samples:         samples,
+		}
+		response.Correlation, response.Slope, response.AvgDelta = correlateAmbient(samples)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// APIKeyRequest is the body of POST /api/apikeys and PUT /api/apikeys/{id}
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Role   string   `json:"role"`             // admin or viewer; defaults to viewer, or the role the scopes need
+	Scopes []string `json:"scopes,omitempty"` // read:metrics, write:settings, admin:config; empty for every permission of the role
+}
+
+// APIKeyInfo describes an API key without its hash
+type APIKeyInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	Hint       string     `json:"hint"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// newAPIKeyInfo returns the public fields of an API key
+func newAPIKeyInfo(key auth.APIKey) APIKeyInfo {
+	return APIKeyInfo{
+		ID:         key.ID,
+		Name:       key.Name,
+		Role:       key.Role,
+		Scopes:     key.Scopes,
+		Hint:       key.Hint,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
+
+// HandleAPIKeys handles /api/apikeys and /api/apikeys/{id}
+//
+//	GET    /api/apikeys       - list API keys
+//	POST   /api/apikeys       - create an API key; the key is only returned here
+//	GET    /api/apikeys/{id}  - one API key
+//	PUT    /api/apikeys/{id}  - rename an API key or change its role and scopes
+//	DELETE /api/apikeys/{id}  - revoke an API key
+//
+// Keys are stored hashed in apikeys.json and are sent by clients as
+// "Authorization: Bearer <key>". Scopes limit a key given to a third-party
+// tool to what it needs, e.g. read:metrics for Grafana.
+func HandleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+	methodNotAllowed := func() {
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+	}
+	serverError := func(err error) {
+		log.ErrorWithRequest(r, "Error saving API keys: %v", err)
+		writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+	}
+
+	// decode reads and validates an API key request from the body
+	decode := func() (APIKeyRequest, bool) {
+		var req APIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+			return req, false
+		}
+		defer r.Body.Close()
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "name is required"})
+			return req, false
+		}
+		scopes, err := auth.NormalizeScopes(req.Scopes)
+		if err != nil {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return req, false
+		}
+		req.Scopes = scopes
+		req.Role = strings.ToLower(strings.TrimSpace(req.Role))
+		switch req.Role {
+		case "":
+			req.Role = auth.RoleViewer // Least privilege unless admin is asked for
+			if len(scopes) > 0 {
+				req.Role = auth.RoleForScopes(scopes)
+			}
+		case auth.RoleAdmin, auth.RoleViewer:
+			if len(scopes) > 0 && req.Role != auth.RoleForScopes(scopes) {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "role must be " + auth.RoleForScopes(scopes) + " for these scopes, or left out"})
+				return req, false
+			}
+		default:
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "role must be admin or viewer"})
+			return req, false
+		}
+		return req, true
+	}
+
+	store := auth.GetAPIKeyStore()
+	if store == nil {
+		writeJSON(http.StatusServiceUnavailable, map[string]string{"message": "API keys are not available"})
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/apikeys"), "/")
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			keys := []APIKeyInfo{}
+			for _, key := range store.List() {
+				keys = append(keys, newAPIKeyInfo(key))
+			}
+			writeJSON(http.StatusOK, map[string]interface{}{"apiKeys": keys})
+		case http.MethodPost:
+			req, ok := decode()
+			if !ok {
+				return
+			}
+			username := "anonymous"
+			if user := middleware.GetUserFromContext(r); user != nil {
+				username = user.Username
+			}
+			token, key, err := store.Create(req.Name, req.Role, req.Scopes, username)
+			if err != nil {
+				serverError(err)
+				return
+			}
+			scopes := "all scopes"
+			if len(key.Scopes) > 0 {
+				scopes = strings.Join(key.Scopes, ", ")
+			}
+			log.InfoWithRequest(r, "%s created API key %q (%s, %s)", username, key.Name, key.Role, scopes)
+			writeJSON(http.StatusCreated, map[string]interface{}{
+				"apiKey": newAPIKeyInfo(key),
+				"key":    token,
+			})
+		default:
+			methodNotAllowed()
+		}
+		return
+	}
+
+	key, found := store.Get(id)
+	switch r.Method {
+	case http.MethodGet:
+		if !found {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "API key not found"})
+			return
+		}
+		writeJSON(http.StatusOK, newAPIKeyInfo(key))
+	case http.MethodPut:
+		if !found {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "API key not found"})
+			return
+		}
+		req, ok := decode()
+		if !ok {
+			return
+		}
+		updated, err := store.Update(id, req.Name, req.Role, req.Scopes)
+		if err != nil {
+			serverError(err)
+			return
+		}
+		writeJSON(http.StatusOK, newAPIKeyInfo(updated))
+	case http.MethodDelete:
+		deleted, err := store.Delete(id)
+		if err != nil {
+			serverError(err)
+			return
+		}
+		if !deleted {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "API key not found"})
+			return
+		}
+		log.InfoWithRequest(r, "API key %q revoked", key.Name)
+		writeJSON(http.StatusOK, map[string]string{"status": "success", "message": "API key revoked"})
+	default:
+		methodNotAllowed()
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// criticalAssets are the page templates under the public directory that the
+// server reads itself; without them the pages cannot be rendered
+var criticalAssets = []string{
+	"html/bootstrap.html",
+	"html/login.html",
+	"html/dashboard.html",
+	"html/share.html",
+}
+
+// AssetStatus reports whether the public directory holds every critical asset
+type AssetStatus struct {
+	PublicDir string   `json:"publicDir"`
+	OK        bool     `json:"ok"`
+	Missing   []string `json:"missing"`
+}
+
+// CheckPublicAssets looks for the critical page templates in publicDir
+func CheckPublicAssets(publicDir string) AssetStatus {
+	status := AssetStatus{PublicDir: publicDir, OK: true, Missing: []string{}}
+	for _, asset := range criticalAssets {
+		info, err := os.Stat(filepath.Join(publicDir, filepath.FromSlash(asset)))
+		if err != nil || info.IsDir() {
+			status.Missing = append(status.Missing, asset)
+			status.OK = false
+		}
+	}
+	return status
+}
+
+// writeAssetError answers a page request whose template could not be read.
+// The error is logged; the browser gets a short explanation without file system details.
+func writeAssetError(w http.ResponseWriter, asset string, err error) {
+	log.Error("Error reading %s: %v", asset, err)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, "<h1>Dashboard files missing</h1><p>The page template %s could not be loaded. "+
+		"Make sure the public directory was installed next to the server, then restart it. "+
+		"An administrator can list the missing files at /api/health/details.</p>", asset)
+}
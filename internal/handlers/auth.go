@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"path/filepath"
+	"strconv"
 
 	"github.com/scottwalter/axeos-dashboard/internal/auth"
 	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
 )
 
 // LoginRequest represents the login request body
@@ -22,7 +27,31 @@ type LoginResponse struct {
 	Message string `json:"message"`
 }
 
+// maxAuditUsernameLength caps the usernames stored in the audit trail, since
+// failed attempts may send anything
+const maxAuditUsernameLength = 128
+
+// recordAuthEvent adds a login attempt to the audit trail in auth.db
+func recordAuthEvent(eventType, username, clientIP, message string) {
+	db := database.AuthInstance()
+	if db == nil {
+		return
+	}
+	if len(username) > maxAuditUsernameLength {
+		username = username[:maxAuditUsernameLength]
+	}
+	if err := db.InsertAuthEvent(&database.AuthEvent{
+		Type:     eventType,
+		Username: username,
+		ClientIP: clientIP,
+		Message:  message,
+	}); err != nil {
+		log.Error("Error recording %s for %s: %v", eventType, username, err)
+	}
+}
+
 // HandleLogin handles POST /api/login
+// Usernames and client IPs are locked out after repeated failures (login_lockout)
 func HandleLogin(configDir string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -51,70 +80,141 @@ func HandleLogin(configDir string) http.HandlerFunc {
 			return
 		}
 
-		// Load access credentials
-		accessData, err := auth.LoadAccessCredentials(configDir)
-		if err != nil {
-			fmt.Printf("Error reading access.json: %v\n", err)
+		cfgManager := config.GetManager(filepath.Dir(configDir))
+		cfg := cfgManager.GetConfig()
+
+		// Refuse attempts while the username or client IP is locked out
+		clientIP := middleware.ClientIP(r)
+		guard := auth.GetLoginGuard()
+		if remaining, locked := guard.Locked(loginReq.Username, clientIP); locked {
+			recordAuthEvent(database.AuthLoginBlocked, loginReq.Username, clientIP, "")
+			minutes := int(math.Ceil(remaining.Minutes()))
 			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"message": "Server configuration error."})
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": fmt.Sprintf("Too many failed login attempts. Try again in %d minute(s).", minutes),
+			})
 			return
 		}
 
-		// Verify credentials
-		hashedPassword, exists := accessData[loginReq.Username]
-		if !exists || hashedPassword != loginReq.HashedPassword {
+		// Verify credentials with the configured provider (auth_provider)
+		provider, err := auth.NewProvider(configDir, cfg)
+		var identity *auth.Identity
+		if err == nil {
+			identity, err = provider.Authenticate(loginReq.Username, loginReq.HashedPassword)
+		}
+		if errors.Is(err, auth.ErrUnknownUser) || errors.Is(err, auth.ErrWrongPassword) {
+			recordAuthEvent(database.AuthLoginFailure, loginReq.Username, clientIP, err.Error())
+			if lockedFor := guard.Failure(loginReq.Username, clientIP, cfg.LoginLockout); lockedFor > 0 {
+				log.WarnWithRequest(r, "Login locked for %s from %s for %v after repeated failures", loginReq.Username, clientIP, lockedFor)
+				recordAuthEvent(database.AuthLockout, loginReq.Username, clientIP,
+					fmt.Sprintf("Locked for %v after %d failed logins", lockedFor, cfg.LoginLockout.Attempts()))
+			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Invalid username or password"})
 			return
 		}
-
-		// Create JWT token
-		jwtService := auth.GetJWTService()
-		token, err := jwtService.CreateToken(loginReq.Username)
 		if err != nil {
-			fmt.Printf("Error creating JWT: %v\n", err)
+			log.ErrorWithRequest(r, "Error authenticating %s: %v", loginReq.Username, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Server configuration error."})
+			return
+		}
+
+		guard.Success(loginReq.Username, clientIP)
+		recordAuthEvent(database.AuthLoginSuccess, loginReq.Username, clientIP, "")
+
+		// Set the access token (and the refresh token, when enabled) in HTTP-only cookies
+		if err := middleware.StartSession(w, r, cfg, identity.Username, identity.Role); err != nil {
+			log.ErrorWithRequest(r, "Error creating session: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
 			return
 		}
 
-		// Get cookie max age from config
-		cfgManager := config.GetManager(filepath.Dir(configDir))
-		cfg := cfgManager.GetConfig()
-		maxAge := cfg.CookieMaxAge
-		if maxAge == 0 {
-			maxAge = 3600 // Default 1 hour
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LoginResponse{Message: "Login successful"})
+	}
+}
+
+// HandleTokenRefresh handles POST /api/token/refresh
+// Exchanges the refresh token cookie for a new access token and refresh token,
+// extending the session by refresh_token_expiry
+func HandleTokenRefresh(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+		if cfg.RefreshTokenLifetime() <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Refresh tokens are not enabled"})
+			return
 		}
 
-		// Set JWT in HTTP-only cookie
-		http.SetCookie(w, &http.Cookie{
-			Name:     "sessionToken",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			MaxAge:   maxAge,
-			SameSite: http.SameSiteStrictMode,
-		})
+		user, expiresAt, err := middleware.RenewSession(w, r, cfg)
+		if errors.Is(err, auth.ErrRefreshTokenInvalid) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			if errors.Is(err, auth.ErrRefreshTokenReused) {
+				log.WarnWithRequest(r, "Revoked session of %s after reuse of an old refresh token from %s", user.Username, middleware.ClientIP(r))
+			}
+			middleware.ClearSessionCookies(w, r)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Session expired, please log in again"})
+			return
+		}
+		if err != nil {
+			log.ErrorWithRequest(r, "Error renewing session: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
+			return
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(LoginResponse{Message: "Login successful"})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Token refreshed",
+			"username":  user.Username,
+			"role":      user.Role,
+			"expiresAt": expiresAt,
+		})
 	}
 }
 
 // HandleLogout handles ANY /api/logout
 func HandleLogout(w http.ResponseWriter, r *http.Request) {
-	// Clear session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "sessionToken",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   -1,
-	})
+	// Revoke the access token, so a copy of the cookie stops working too
+	if cookie, err := r.Cookie(middleware.SessionCookie); err == nil {
+		if list := auth.GetRevocationList(); list != nil {
+			if claims, err := auth.GetJWTService().VerifyToken(cookie.Value); err == nil && claims.ExpiresAt != nil {
+				if err := list.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+					log.ErrorWithRequest(r, "Error revoking token: %v", err)
+				}
+			}
+		}
+	}
+
+	// End the renewable session so its refresh token cannot be used again
+	if cookie, err := r.Cookie(middleware.RefreshCookie); err == nil {
+		if store := auth.GetSessionStore(); store != nil {
+			if _, err := store.RevokeToken(cookie.Value); err != nil {
+				log.ErrorWithRequest(r, "Error revoking session: %v", err)
+			}
+		}
+	}
+
+	// Clear session cookies
+	middleware.ClearSessionCookies(w, r)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
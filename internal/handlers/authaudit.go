@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+const (
+	defaultAuditWindow = 7 * 24 * time.Hour
+	defaultAuditLimit  = 100
+	maxAuditLimit      = 1000
+)
+
+// HandleAuthAudit handles GET /api/auth/audit
+// Lists recent logins, failed attempts and lockouts, newest first. Optional
+// query parameters: since (RFC 3339 or Unix seconds, default 7 days ago),
+// username, type and limit (default 100).
+func HandleAuthAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	badRequest := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+	}
+
+	since := time.Now().Add(-defaultAuditWindow)
+	if value := query.Get("since"); value != "" {
+		t, err := parseHistoryTime(value)
+		if err != nil {
+			badRequest("Invalid since time: use RFC 3339 or Unix seconds")
+			return
+		}
+		since = t
+	}
+
+	var types []string
+	if eventType := query.Get("type"); eventType != "" {
+		switch eventType {
+		case database.AuthLoginSuccess, database.AuthLoginFailure, database.AuthLoginBlocked, database.AuthLockout:
+			types = []string{eventType}
+		default:
+			badRequest("type must be login_success, login_failure, login_blocked or lockout")
+			return
+		}
+	}
+
+	limit := defaultAuditLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			badRequest(fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit))
+			return
+		}
+		limit = n
+	}
+
+	db := database.AuthInstance()
+	if db == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": "The authentication database is not open"})
+		return
+	}
+
+	events, err := db.GetAuthEvents(since, query.Get("username"), types, limit)
+	if err != nil {
+		log.ErrorWithRequest(r, "Error reading auth events: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+		return
+	}
+	if events == nil {
+		events = []*database.AuthEvent{}
+	}
+	for _, event := range events {
+		event.Timestamp = event.Timestamp.Local()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": events,
+		"since":  since,
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// badgeCacheTTL limits how often a public badge can reach the device
+const badgeCacheTTL = 60 * time.Second
+
+// BadgeResponse is the shields.io endpoint badge schema
+type BadgeResponse struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	CacheSeconds  int    `json:"cacheSeconds,omitempty"`
+	IsError       bool   `json:"isError,omitempty"`
+}
+
+type badgeCacheEntry struct {
+	fetchedAt time.Time
+	info      map[string]interface{}
+	err       error
+}
+
+var (
+	badgeCache   = map[string]badgeCacheEntry{}
+	badgeCacheMu sync.Mutex
+)
+
+// badgeAllowed reports whether the instance has opted in to a public badge
+func badgeAllowed(cfg *config.Config, instanceID string) bool {
+	if !cfg.BadgesEnabled {
+		return false
+	}
+	if len(cfg.BadgeInstances) == 0 {
+		return true
+	}
+	for _, id := range cfg.BadgeInstances {
+		if id == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedShareableInfo returns device info, reusing a recent result when available
+func cachedShareableInfo(cfg *config.Config, instanceID, instanceURL string) (map[string]interface{}, error) {
+	badgeCacheMu.Lock()
+	entry, ok := badgeCache[instanceID]
+	badgeCacheMu.Unlock()
+
+	if ok && time.Since(entry.fetchedAt) < badgeCacheTTL {
+		return entry.info, entry.err
+	}
+
+	info, err := fetchShareableInfo(context.Background(), cfg, instanceID, instanceURL)
+
+	badgeCacheMu.Lock()
+	badgeCache[instanceID] = badgeCacheEntry{fetchedAt: time.Now(), info: info, err: err}
+	badgeCacheMu.Unlock()
+
+	return info, err
+}
+
+// HandleBadge handles GET /api/badge/{instanceId}?metric=hashrate|status
+// Returns a shields.io compatible endpoint badge for instances that opted in
+func HandleBadge(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		instanceID := strings.TrimPrefix(r.URL.Path, "/api/badge/")
+		instanceURL := findInstanceURL(cfg, instanceID)
+
+		// Unknown and non-public instances look the same to avoid leaking device names
+		if instanceID == "" || instanceURL == "" || !badgeAllowed(cfg, instanceID) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+			return
+		}
+
+		metric := r.URL.Query().Get("metric")
+		badge := BadgeResponse{
+			SchemaVersion: 1,
+			Label:         instanceID,
+			CacheSeconds:  int(badgeCacheTTL.Seconds()),
+		}
+
+		info, err := cachedShareableInfo(cfg, instanceID, instanceURL)
+		switch {
+		case err != nil:
+			badge.Message = "offline"
+			badge.Color = "red"
+			badge.IsError = true
+		case metric == "status":
+			badge.Message = "online"
+			badge.Color = "brightgreen"
+		default:
+			hashrate, _ := info["hashRate"].(float64)
+			badge.Message = services.FormatHashrate(hashrate)
+			badge.Color = "orange"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeCacheTTL.Seconds())))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(badge)
+	}
+}
@@ -12,6 +12,9 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
 )
 
 // AxeosInstance represents a single AxeOS device
@@ -59,43 +62,32 @@ type BootstrapRequest struct {
 }
 
 // HandleBootstrapPage serves the bootstrap HTML page
-func HandleBootstrapPage(w http.ResponseWriter, r *http.Request) {
-	// Determine publicDir from the current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("Error getting current working directory: %v\n", err)
-		w.Header().Set("Content-Type", "text/html")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "<h1>Error</h1><p>Failed to determine public directory</p>")
-		return
-	}
-
-	publicDir := filepath.Join(cwd, "public")
-	bootstrapHTMLPath := filepath.Join(publicDir, "html", "bootstrap.html")
+func HandleBootstrapPage(publicDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bootstrapHTMLPath := filepath.Join(publicDir, "html", "bootstrap.html")
 
-	htmlContent, err := os.ReadFile(bootstrapHTMLPath)
-	if err != nil {
-		fmt.Printf("Error reading bootstrap.html: %v\n", err)
-		w.Header().Set("Content-Type", "text/html")
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "<h1>Error</h1><p>Failed to load bootstrap page: %v</p>", err)
-		return
-	}
+		htmlContent, err := os.ReadFile(bootstrapHTMLPath)
+		if err != nil {
+			writeAssetError(w, "html/bootstrap.html", err)
+			return
+		}
 
-	html := string(htmlContent)
+		html := string(htmlContent)
 
-	// Replace placeholders
-	title := "AxeOS Dashboard"
-	version := "1.0"
-	currentYear := fmt.Sprintf("%d", time.Now().Year())
+		// Replace placeholders
+		title := "AxeOS Dashboard"
+		version := "1.0"
+		currentYear := fmt.Sprintf("%d", time.Now().Year())
 
-	html = strings.ReplaceAll(html, "<!-- TITLE -->", title)
-	html = strings.ReplaceAll(html, "<!-- VERSION -->", version)
-	html = strings.ReplaceAll(html, "<!-- CURRENT_YEAR -->", currentYear)
+		html = strings.ReplaceAll(html, "<!-- TITLE -->", title)
+		html = strings.ReplaceAll(html, "<!-- VERSION -->", version)
+		html = strings.ReplaceAll(html, "<!-- CURRENT_YEAR -->", currentYear)
+		html = strings.ReplaceAll(html, "<!-- BASE_PATH -->", "") // Setup always runs at the root path
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(html))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(html))
+	}
 }
 
 // HandleBootstrapSubmit processes the bootstrap form submission
@@ -162,7 +154,7 @@ func HandleBootstrapSubmit(configDir string) http.HandlerFunc {
 
 		// Create config directory if it doesn't exist
 		if err := os.MkdirAll(configDir, 0755); err != nil {
-			fmt.Printf("Error creating config directory: %v\n", err)
+			log.ErrorWithRequest(r, "Error creating config directory: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Failed to create config directory"})
@@ -172,7 +164,7 @@ func HandleBootstrapSubmit(configDir string) http.HandlerFunc {
 		// Create config.json
 		cfg := createConfig(req)
 		if err := saveConfigJSON(configDir, cfg); err != nil {
-			fmt.Printf("Error saving config.json: %v\n", err)
+			log.ErrorWithRequest(r, "Error saving config.json: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save configuration"})
@@ -182,7 +174,7 @@ func HandleBootstrapSubmit(configDir string) http.HandlerFunc {
 		// Create access.json if authentication is enabled
 		if enableAuth {
 			if err := saveAccessJSON(configDir, req.Username, req.Password); err != nil {
-				fmt.Printf("Error saving access.json: %v\n", err)
+				log.ErrorWithRequest(r, "Error saving access.json: %v", err)
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusInternalServerError)
 				json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save access credentials"})
@@ -190,8 +182,8 @@ func HandleBootstrapSubmit(configDir string) http.HandlerFunc {
 			}
 
 			// Create jsonWebTokenKey.json
-			if err := saveJWTKeyJSON(configDir, req.JWTKey); err != nil {
-				fmt.Printf("Error saving jsonWebTokenKey.json: %v\n", err)
+			if err := saveJWTKeyJSON(configDir, req.JWTKey, jwtExpiryDuration(req.JWTExpiry)); err != nil {
+				log.ErrorWithRequest(r, "Error saving jsonWebTokenKey.json: %v", err)
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusInternalServerError)
 				json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save JWT key"})
@@ -200,12 +192,12 @@ func HandleBootstrapSubmit(configDir string) http.HandlerFunc {
 		} else {
 			// Create empty access.json and jsonWebTokenKey.json files for non-auth mode
 			if err := saveAccessJSON(configDir, "", ""); err != nil {
-				fmt.Printf("Error saving empty access.json: %v\n", err)
+				log.ErrorWithRequest(r, "Error saving empty access.json: %v", err)
 			}
 			// Generate a random JWT key even if auth is disabled (for potential future use)
 			randomKey := generateRandomKey(32)
-			if err := saveJWTKeyJSON(configDir, randomKey); err != nil {
-				fmt.Printf("Error saving jsonWebTokenKey.json: %v\n", err)
+			if err := saveJWTKeyJSON(configDir, randomKey, jwtExpiryDuration("1h")); err != nil {
+				log.ErrorWithRequest(r, "Error saving jsonWebTokenKey.json: %v", err)
 			}
 		}
 
@@ -213,7 +205,7 @@ func HandleBootstrapSubmit(configDir string) http.HandlerFunc {
 		enableCryptoNode := req.EnableCryptoNode == "true"
 		if enableCryptoNode {
 			if err := saveRPCConfigJSON(configDir, req); err != nil {
-				fmt.Printf("Error saving rpcConfig.json: %v\n", err)
+				log.ErrorWithRequest(r, "Error saving rpcConfig.json: %v", err)
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusInternalServerError)
 				json.NewEncoder(w).Encode(map[string]string{"message": "Failed to save RPC configuration"})
@@ -290,9 +282,10 @@ func createConfig(req BootstrapRequest) map[string]interface{} {
 		cfg["mining_core_url"] = miningCoreURLs
 	}
 
-	// Set JWT expiry (convert to seconds)
+	// Set JWT expiry; the session cookie lives exactly as long as the token
 	if enableAuth && req.JWTExpiry != "" {
 		cfg["cookie_max_age"] = parseJWTExpiry(req.JWTExpiry)
+		cfg["jwt_expiry"] = jwtExpiryDuration(req.JWTExpiry)
 	}
 
 	// Add crypto nodes if enabled
@@ -308,9 +301,8 @@ func createConfig(req BootstrapRequest) map[string]interface{} {
 					},
 				},
 			},
-			{
-				"NodeDisplayFields": getCryptoNodeDisplayFields(req.CryptoNodeType),
-			},
+			// Card fields come from the built-in catalog for the node type
+			// (customizable through /api/nodes/display-fields)
 		}
 	}
 
@@ -349,6 +341,11 @@ func parseJWTExpiry(expiry string) int {
 	}
 }
 
+// jwtExpiryDuration converts the bootstrap expiry choice to a duration string
+func jwtExpiryDuration(expiry string) string {
+	return (time.Duration(parseJWTExpiry(expiry)) * time.Second).String()
+}
+
 // saveConfigJSON saves the config to config.json
 func saveConfigJSON(configDir string, cfg map[string]interface{}) error {
 	configPath := filepath.Join(configDir, "config.json")
@@ -366,13 +363,15 @@ func saveAccessJSON(configDir, username, password string) error {
 	// If no username/password, create empty object
 	if username == "" || password == "" {
 		emptyData := []byte("{}")
-		return os.WriteFile(accessPath, emptyData, 0644)
+		return config.WriteSecretFile(accessPath, emptyData)
 	}
 
-	// Hash the password with SHA256
-	hasher := sha256.New()
-	hasher.Write([]byte(password))
-	hashedPassword := hex.EncodeToString(hasher.Sum(nil))
+	// The login page sends the SHA256 digest of the password; store an Argon2id hash of that digest
+	digest := sha256.Sum256([]byte(password))
+	hashedPassword, err := auth.HashPassword(hex.EncodeToString(digest[:]))
+	if err != nil {
+		return err
+	}
 
 	// Create access data
 	accessData := map[string]string{
@@ -383,21 +382,21 @@ func saveAccessJSON(configDir, username, password string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(accessPath, data, 0644)
+	return config.WriteSecretFile(accessPath, data)
 }
 
-// saveJWTKeyJSON saves the JWT key to jsonWebTokenKey.json
-func saveJWTKeyJSON(configDir, jwtKey string) error {
+// saveJWTKeyJSON saves the JWT key and token lifetime to jsonWebTokenKey.json
+func saveJWTKeyJSON(configDir, jwtKey, expiresIn string) error {
 	jwtKeyPath := filepath.Join(configDir, "jsonWebTokenKey.json")
 	jwtData := map[string]string{
 		"jsonWebTokenKey": jwtKey,
-		"expiresIn":       "1h",
+		"expiresIn":       expiresIn,
 	}
 	data, err := json.MarshalIndent(jwtData, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(jwtKeyPath, data, 0644)
+	return config.WriteSecretFile(jwtKeyPath, data)
 }
 
 // saveRPCConfigJSON saves the RPC configuration to rpcConfig.json
@@ -427,7 +426,7 @@ func saveRPCConfigJSON(configDir string, req BootstrapRequest) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(rpcConfigPath, data, 0644)
+	return config.WriteSecretFile(rpcConfigPath, data)
 }
 
 // generateRandomKey generates a random hex string of specified length
@@ -519,54 +518,3 @@ func getDefaultMiningCoreDisplayFields() []map[string]interface{} {
 		},
 	}
 }
-
-// getCryptoNodeDisplayFields returns the default display fields for crypto nodes
-func getCryptoNodeDisplayFields(nodeType string) []map[string]interface{} {
-	// Return generic crypto node display fields (works for dgb, btc, etc.)
-	return []map[string]interface{}{
-		{
-			"Block Chain Info": []map[string]string{
-				{"chain": "Chain"},
-				{"blocks": "Blocks"},
-				{"headers": "Headers"},
-				{"size_on_disk": "Size on Disk"},
-				{"mediantime": "Median Time"},
-				{"pruned": "Pruned"},
-				{"verificationprogress": "Verification"},
-				{"initialblockdownload": "Initializing"},
-				{"warnings": "Warnings"},
-				{"difficulties/sha256d": "Difficulty"},
-			},
-		},
-		{
-			"Network Info": []map[string]string{
-				{"version": "Version"},
-				{"subversion": "Subversion"},
-				{"protocolversion": "Protocol"},
-				{"networkactive": "Active"},
-				{"warnings": "Warnings"},
-				{"connections": "Connections"},
-				{"connections_in": "In"},
-				{"connections_out": "Out"},
-			},
-		},
-		{
-			"Network Totals": []map[string]string{
-				{"target": "Target"},
-				{"totalbytesrecv": "Received"},
-				{"totalbytessent": "Sent"},
-				{"bytes_left_in_cycle": "Bytes Left"},
-				{"timemillis": "Updated"},
-				{"target_reached": "Target Reached"},
-				{"serve_historical_blocks": "Historicals"},
-				{"timeframe": "Cycle Time"},
-				{"time_left_in_cycle": "Time Left"},
-			},
-		},
-		{
-			"Wallet Info": []map[string]string{
-				{"balance": "Balance"},
-			},
-		},
-	}
-}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+const (
+	defaultChartRange = 24 * time.Hour
+	maxChartRange     = 366 * 24 * time.Hour
+	minChartBucket    = 10 * time.Second
+	// defaultChartPoints is how many buckets the automatic bucket size aims for
+	defaultChartPoints = 300
+	// maxChartPoints limits the buckets of one response
+	maxChartPoints = 2000
+)
+
+// chartBuckets are the bucket sizes chosen when the bucket parameter is omitted
+var chartBuckets = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// chartBucketFor returns the smallest bucket size that keeps a range within
+// defaultChartPoints buckets
+func chartBucketFor(window time.Duration) time.Duration {
+	for _, bucket := range chartBuckets {
+		if window/bucket <= defaultChartPoints {
+			return bucket
+		}
+	}
+	return chartBuckets[len(chartBuckets)-1]
+}
+
+// HandleCharts handles GET /api/charts/{metric}
+// Returns a device metric aggregated into fixed buckets, ready for charting.
+// Query parameters: instanceId (required), range (e.g. 6h or 7d, default
+// 24h), agg (avg, min or max, default avg) and bucket (e.g. 5m; by default
+// chosen to give about 300 points). Buckets are aligned to multiples of their
+// size and buckets without samples are left out. The hashrate chart is in
+// GH/s unless another hashrateUnit is requested.
+func HandleCharts(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+		}
+
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/charts"), "/")
+		metric, ok := database.GetChartMetric(name)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": fmt.Sprintf("Unknown chart metric \"%s\"", name),
+				"metrics": database.ChartMetricNames(),
+			})
+			return
+		}
+
+		instanceID := query.Get("instanceId")
+		if instanceID == "" {
+			badRequest("instanceId is required")
+			return
+		}
+		if !knownDevice(cfg, instanceID) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Instance not found"})
+			return
+		}
+
+		window := defaultChartRange
+		if value := query.Get("range"); value != "" {
+			d, err := config.ParseLifetime(value)
+			if err != nil || d <= 0 || d > maxChartRange {
+				badRequest("range must be a duration such as 6h or 7d, at most 366d")
+				return
+			}
+			window = d
+		}
+
+		agg := query.Get("agg")
+		if agg == "" {
+			agg = database.ChartAvg
+		}
+		if agg != database.ChartAvg && agg != database.ChartMin && agg != database.ChartMax {
+			badRequest("agg must be avg, min or max")
+			return
+		}
+
+		unit := metric.Unit
+		if metric.Unit == config.UnitGHs {
+			var ok bool
+			if unit, ok = hashrateUnit(r, config.UnitGHs); !ok {
+				badRequest("hashrateUnit must be H/s, KH/s, MH/s, GH/s, TH/s, PH/s or EH/s")
+				return
+			}
+		}
+
+		bucket := chartBucketFor(window)
+		if value := query.Get("bucket"); value != "" {
+			d, err := config.ParseLifetime(value)
+			if err != nil || d < minChartBucket {
+				badRequest(fmt.Sprintf("bucket must be a duration of at least %v, such as 5m", minChartBucket))
+				return
+			}
+			if window/d > maxChartPoints {
+				badRequest(fmt.Sprintf("bucket is too small for the range: at most %d buckets are returned", maxChartPoints))
+				return
+			}
+			bucket = d.Truncate(time.Second)
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		end := time.Now()
+		start := end.Add(-window)
+		points, err := db.GetChartSeries(metric, instanceID, start, end, bucket, agg)
+		if err != nil {
+			log.ErrorWithRequest(r, "Error reading chart series: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+		for _, point := range points {
+			point.Value = config.ConvertHashrate(point.Value, metric.Unit, unit)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"metric":        metric.Name,
+			"unit":          unit,
+			"instanceId":    instanceID,
+			"agg":           agg,
+			"bucketSeconds": int(bucket.Seconds()),
+			"start":         start,
+			"end":           end,
+			"points":        points,
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// maxConfigBundleBytes bounds an uploaded configuration backup
+const maxConfigBundleBytes = 4 << 20
+
+// configBundleVersion is the format version of configuration backups
+const configBundleVersion = 1
+
+// bundleSecretFiles are the credential files included in a backup on
+// request. Signing keys, API keys and sessions are left out: they belong to
+// one installation.
+var bundleSecretFiles = []string{"access.json", "rpcConfig.json", "notifications.json", "electricityMaps.json", "openWeatherMap.json", "mqtt.json"}
+
+// ConfigBundle is a downloadable backup of the configuration files
+type ConfigBundle struct {
+	Version   int                        `json:"version"`
+	CreatedAt time.Time                  `json:"createdAt"`
+	Files     map[string]json.RawMessage `json:"files"` // By file name
+}
+
+// SkippedFile is a file of a restored backup that was not written
+type SkippedFile struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// HandleConfigurationBackups handles the configuration backups below /api/configuration/
+//
+//	GET  /api/configuration/backup[?secrets=true]       - download config.json, and the credential files with secrets
+//	POST /api/configuration/restore                     - restore a downloaded backup
+//	GET  /api/configuration/versions                    - automatic backups of config.json, newest first
+//	GET  /api/configuration/versions/{name}             - one automatic backup
+//	POST /api/configuration/versions/{name}/restore     - restore an automatic backup
+func HandleConfigurationBackups(cfgManager *config.Manager, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		writeRestoreError := func(err error) {
+			var validationErr *config.ValidationError
+			if errors.As(err, &validationErr) {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			log.ErrorWithRequest(r, "Error restoring configuration: %v", err)
+			writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+		}
+
+		// Check if configurations are disabled
+		if cfg.DisableConfigurations {
+			writeJSON(http.StatusForbidden, map[string]string{"message": "Configurations are disabled by configuration."})
+			return
+		}
+
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/configuration/"), "/")
+		parts := strings.Split(path, "/")
+		method := http.MethodGet
+		switch {
+		case path == "backup":
+		case path == "restore", len(parts) == 3 && parts[0] == "versions" && parts[2] == "restore":
+			method = http.MethodPost
+		case parts[0] == "versions" && len(parts) <= 2:
+		default:
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if r.Method != method {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		switch {
+		case path == "backup":
+			includeSecrets, _ := strconv.ParseBool(r.URL.Query().Get("secrets"))
+			bundle, err := buildConfigBundle(cfgManager.GetConfigDir(), includeSecrets)
+			if err != nil {
+				log.ErrorWithRequest(r, "Error creating configuration backup: %v", err)
+				writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			log.InfoWithRequest(r, "Configuration backup downloaded by %s (secrets: %v)", username, includeSecrets)
+
+			filename := fmt.Sprintf("axeos-dashboard-backup-%s.json", bundle.CreatedAt.Format("20060102-150405"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			w.Header().Set("Cache-Control", "no-store")
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(bundle)
+
+		case path == "restore":
+			var bundle ConfigBundle
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfigBundleBytes)).Decode(&bundle); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": "Invalid backup: " + err.Error()})
+				return
+			}
+			restored, skipped, err := restoreConfigBundle(cfgManager, bundle)
+			if err != nil {
+				writeRestoreError(err)
+				return
+			}
+			log.InfoWithRequest(r, "Configuration restored from a backup by %s: %s", username, strings.Join(restored, ", "))
+			writeJSON(http.StatusOK, map[string]interface{}{
+				"status":   "success",
+				"message":  "Configuration restored. Changes to config.json have been applied; restored credential files are read again after a restart.",
+				"restored": restored,
+				"skipped":  skipped,
+			})
+
+		case len(parts) == 1:
+			versions, err := cfgManager.ConfigVersions()
+			if err != nil {
+				log.ErrorWithRequest(r, "Error listing configuration versions: %v", err)
+				writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			writeJSON(http.StatusOK, map[string]interface{}{"versions": versions})
+
+		default:
+			data, ok, err := cfgManager.ReadConfigVersion(parts[1])
+			if err != nil {
+				log.ErrorWithRequest(r, "Error reading configuration version %s: %v", parts[1], err)
+				writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			if !ok {
+				writeJSON(http.StatusNotFound, map[string]string{"message": fmt.Sprintf("Configuration version %q not found", parts[1])})
+				return
+			}
+
+			if len(parts) == 2 {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", parts[1]))
+				w.Header().Set("Cache-Control", "no-store")
+				w.Write(data)
+				return
+			}
+			if err := cfgManager.RestoreConfig(data); err != nil {
+				writeRestoreError(err)
+				return
+			}
+			log.InfoWithRequest(r, "Configuration version %s restored by %s", parts[1], username)
+			writeJSON(http.StatusOK, map[string]interface{}{
+				"status":  "success",
+				"message": fmt.Sprintf("Configuration version %s restored and applied.", parts[1]),
+				"data":    cfgManager.GetConfig(),
+			})
+		}
+	}
+}
+
+// buildConfigBundle collects config.json, and the credential files when
+// includeSecrets is set. Credential files that do not exist are left out.
+func buildConfigBundle(configDir string, includeSecrets bool) (*ConfigBundle, error) {
+	bundle := &ConfigBundle{
+		Version:   configBundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Files:     map[string]json.RawMessage{},
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading config.json: %w", err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("config.json is not valid JSON")
+	}
+	bundle.Files["config.json"] = data
+
+	if includeSecrets {
+		for _, name := range bundleSecretFiles {
+			data, err := secrets.Read(configDir, name)
+			if err != nil {
+				continue // Not configured
+			}
+			if !json.Valid(data) {
+				return nil, fmt.Errorf("%s is not valid JSON", name)
+			}
+			bundle.Files[name] = data
+		}
+	}
+	return bundle, nil
+}
+
+// restoreConfigBundle writes the files of a backup after checking all of
+// them, so an invalid file leaves everything unchanged. Credential files
+// provided by a secret backend are skipped.
+func restoreConfigBundle(cfgManager *config.Manager, bundle ConfigBundle) ([]string, []SkippedFile, error) {
+	if bundle.Version != configBundleVersion {
+		return nil, nil, &config.ValidationError{Field: "version", Message: fmt.Sprintf("unsupported backup version %d", bundle.Version)}
+	}
+	configData, ok := bundle.Files["config.json"]
+	if !ok {
+		return nil, nil, &config.ValidationError{Field: "files", Message: "backup has no config.json"}
+	}
+	if err := config.ValidateConfigData(configData); err != nil {
+		return nil, nil, err
+	}
+	if rejected := cfgManager.CheckRestore(configData); len(rejected) > 0 {
+		return nil, nil, &config.ValidationError{Field: "config.json", Message: fmt.Sprintf("%s %s", rejected[0].Field, rejected[0].Reason)}
+	}
+	for name, data := range bundle.Files {
+		switch {
+		case name == "config.json":
+		case !slices.Contains(bundleSecretFiles, name):
+			return nil, nil, &config.ValidationError{Field: "files", Message: fmt.Sprintf("%q cannot be restored", name)}
+		case name == "access.json":
+			if err := auth.ValidateAccessFile(data); err != nil {
+				return nil, nil, &config.ValidationError{Field: name, Message: err.Error()}
+			}
+		default:
+			var object map[string]interface{}
+			if err := json.Unmarshal(data, &object); err != nil || object == nil {
+				return nil, nil, &config.ValidationError{Field: name, Message: "must be a JSON object"}
+			}
+		}
+	}
+
+	if err := cfgManager.RestoreConfig(configData); err != nil {
+		return nil, nil, err
+	}
+	restored := []string{"config.json"}
+	skipped := []SkippedFile{}
+
+	configDir := cfgManager.GetConfigDir()
+	for _, name := range bundleSecretFiles {
+		data, ok := bundle.Files[name]
+		if !ok {
+			continue
+		}
+		if !secrets.IsFileBacked(name) {
+			skipped = append(skipped, SkippedFile{File: name, Reason: "provided by a secret backend"})
+			continue
+		}
+
+		var err error
+		if name == "access.json" {
+			err = auth.RestoreAccessFile(configDir, data)
+		} else {
+			err = config.WriteSecretFile(filepath.Join(configDir, name), data)
+		}
+		if err != nil {
+			return restored, skipped, fmt.Errorf("error restoring %s: %w", name, err)
+		}
+		restored = append(restored, name)
+	}
+	return restored, skipped, nil
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 
@@ -71,8 +73,8 @@ func handleUpdateConfiguration(w http.ResponseWriter, r *http.Request, cfgManage
 	}
 
 	// Parse JSON
-	var updates map[string]interface{}
-	if err := json.Unmarshal(body, &updates); err != nil {
+	var update config.ConfigUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -82,10 +84,37 @@ func handleUpdateConfiguration(w http.ResponseWriter, r *http.Request, cfgManage
 		return
 	}
 
+	// Only known fields with values of the right type may be written
+	if rejected := cfgManager.CheckUpdate(update); len(rejected) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":   "error",
+			"message":  fmt.Sprintf("Configuration not updated: %d field(s) rejected", len(rejected)),
+			"rejected": rejected,
+		})
+		return
+	}
+	updates, err := update.Values()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// Update configuration
 	if err := cfgManager.UpdateConfig(updates); err != nil {
+		status := http.StatusInternalServerError
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			status = http.StatusBadRequest
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]string{
 			"status":  "error",
 			"message": err.Error(),
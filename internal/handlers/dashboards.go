@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// findDashboard returns the index of the named dashboard (case-insensitive), or -1
+func findDashboard(dashboards []config.Dashboard, name string) int {
+	for i, d := range dashboards {
+		if strings.EqualFold(d.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkDashboardSources verifies that every card refers to a configured device, pool or node
+func checkDashboardSources(cfg *config.Config, configDir string, d config.Dashboard) error {
+	var nodes []string
+	for i, card := range d.Cards {
+		found := true
+		switch card.Type {
+		case config.CardDevice, config.CardChart:
+			found = findInstanceURL(cfg, card.Source) != ""
+		case config.CardPool:
+			found = cfg.FindPublicPool(card.Source) != nil
+			for _, pool := range cfg.MiningCoreURL {
+				if _, ok := pool[card.Source]; ok {
+					found = true
+				}
+			}
+		case config.CardNode:
+			if nodes == nil {
+				rpcClient := services.NewRPCClient(configDir)
+				if err := rpcClient.LoadConfig(); err == nil {
+					nodes = rpcClient.GetConfiguredNodes()
+				}
+			}
+			found = slices.Contains(nodes, card.Source)
+		}
+		if !found {
+			return &config.ValidationError{
+				Field:   "dashboards",
+				Message: fmt.Sprintf("%s card %d refers to unknown %s %q", d.Name, i+1, card.Type, card.Source),
+			}
+		}
+	}
+	return nil
+}
+
+// HandleDashboards handles /api/dashboards and /api/dashboards/{name}
+//
+//	GET    /api/dashboards         - list saved dashboards (a generated "Overview" when none are saved)
+//	POST   /api/dashboards         - create a dashboard
+//	GET    /api/dashboards/{name}  - one dashboard
+//	PUT    /api/dashboards/{name}  - create or replace a dashboard
+//	DELETE /api/dashboards/{name}  - delete a dashboard
+//
+// Dashboards are stored in config.json under "dashboards".
+func HandleDashboards(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		methodNotAllowed := func() {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		}
+
+		// save writes the dashboards to config.json, reporting validation errors as 400
+		save := func(dashboards []config.Dashboard) bool {
+			if err := cfgManager.UpdateConfig(map[string]interface{}{"dashboards": dashboards}); err != nil {
+				status := http.StatusInternalServerError
+				var validationErr *config.ValidationError
+				if errors.As(err, &validationErr) {
+					status = http.StatusBadRequest
+				} else {
+					log.ErrorWithRequest(r, "Error saving dashboards: %v", err)
+				}
+				writeJSON(status, map[string]string{"status": "error", "message": err.Error()})
+				return false
+			}
+			return true
+		}
+
+		// decode reads and validates a dashboard from the request body. A name
+		// from the path replaces the one in the body.
+		decode := func(name string) (config.Dashboard, bool) {
+			var d config.Dashboard
+			if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+				return d, false
+			}
+			defer r.Body.Close()
+
+			if name != "" {
+				d.Name = name
+			}
+			if d.Cards == nil {
+				d.Cards = []config.DashboardCard{}
+			}
+			err := d.Validate()
+			if err == nil {
+				err = checkDashboardSources(cfg, cfgManager.GetConfigDir(), d)
+			}
+			if err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": err.Error()})
+				return d, false
+			}
+			return d, true
+		}
+
+		dashboards := slices.Clone(cfg.Dashboards)
+		name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/dashboards"), "/")
+
+		if name == "" {
+			switch r.Method {
+			case http.MethodGet:
+				if len(dashboards) == 0 {
+					dashboards = []config.Dashboard{config.DefaultDashboard(cfg)}
+				}
+				writeJSON(http.StatusOK, map[string]interface{}{"dashboards": dashboards})
+			case http.MethodPost:
+				d, ok := decode("")
+				if !ok {
+					return
+				}
+				if findDashboard(dashboards, d.Name) >= 0 {
+					writeJSON(http.StatusConflict, map[string]string{
+						"message": fmt.Sprintf("Dashboard %q already exists", d.Name),
+					})
+					return
+				}
+				if save(append(dashboards, d)) {
+					writeJSON(http.StatusCreated, d)
+				}
+			default:
+				methodNotAllowed()
+			}
+			return
+		}
+
+		index := findDashboard(dashboards, name)
+		switch r.Method {
+		case http.MethodGet:
+			if index < 0 {
+				writeJSON(http.StatusNotFound, map[string]string{"message": "Dashboard not found"})
+				return
+			}
+			writeJSON(http.StatusOK, dashboards[index])
+		case http.MethodPut:
+			d, ok := decode(name)
+			if !ok {
+				return
+			}
+			if index < 0 {
+				dashboards = append(dashboards, d)
+			} else {
+				dashboards[index] = d
+			}
+			if save(dashboards) {
+				writeJSON(http.StatusOK, d)
+			}
+		case http.MethodDelete:
+			if index < 0 {
+				writeJSON(http.StatusNotFound, map[string]string{"message": "Dashboard not found"})
+				return
+			}
+			if save(slices.Delete(dashboards, index, index+1)) {
+				writeJSON(http.StatusOK, map[string]string{"status": "success", "message": "Dashboard deleted"})
+			}
+		default:
+			methodNotAllowed()
+		}
+	}
+}
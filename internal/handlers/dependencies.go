@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// configuredDependencies lists the external dependencies of the configuration by kind
+func configuredDependencies(cfg *config.Config, configDir string) map[string][]string {
+	configured := map[string][]string{}
+
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			configured[dependencies.KindAxeOS] = append(configured[dependencies.KindAxeOS], name)
+		}
+	}
+	if cfg.MiningCoreEnabled {
+		for _, poolMap := range cfg.MiningCoreURL {
+			for name := range poolMap {
+				configured[dependencies.KindPool] = append(configured[dependencies.KindPool], name)
+			}
+		}
+	}
+	for _, pool := range cfg.PublicPools {
+		configured[dependencies.KindPool] = append(configured[dependencies.KindPool], pool.Name)
+	}
+	if cfg.CryptNodesEnabled {
+		rpcClient := services.NewRPCClient(configDir)
+		if err := rpcClient.LoadConfig(); err == nil {
+			configured[dependencies.KindNode] = rpcClient.GetConfiguredNodes()
+		}
+	}
+	if cfg.Market != nil {
+		configured[dependencies.KindMarket] = cfg.Market.Providers
+	}
+	if cfg.Notifications != nil && cfg.Notifications.Enabled {
+		for _, channel := range cfg.Notifications.Channels {
+			configured[dependencies.KindNotification] = append(configured[dependencies.KindNotification], channel.Name)
+		}
+	}
+	return configured
+}
+
+// HandleDependencies handles GET /api/dependencies[?kind=axeos|pool|node|market|notification]
+// Reports reachability, latency and rolling success rates of every configured
+// miner, pool, node, market data provider and notification channel
+func HandleDependencies(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		kinds := []string{
+			dependencies.KindAxeOS, dependencies.KindPool, dependencies.KindNode,
+			dependencies.KindMarket, dependencies.KindNotification,
+		}
+		if kind := r.URL.Query().Get("kind"); kind != "" {
+			valid := false
+			for _, k := range kinds {
+				valid = valid || k == kind
+			}
+			if !valid {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"message": "kind must be axeos, pool, node, market or notification"})
+				return
+			}
+			kinds = []string{kind}
+		}
+
+		tracker := dependencies.GetTracker()
+		configured := configuredDependencies(cfg, cfgManager.GetConfigDir())
+		statuses := []dependencies.Status{}
+		summary := map[string]int{
+			dependencies.StatusUp:       0,
+			dependencies.StatusDegraded: 0,
+			dependencies.StatusDown:     0,
+			dependencies.StatusUnknown:  0,
+		}
+		for _, kind := range kinds {
+			for _, name := range configured[kind] {
+				status := tracker.Status(kind, name)
+				summary[status.Status]++
+				statuses = append(statuses, status)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"dependencies": statuses,
+			"summary":      summary,
+		})
+	}
+}
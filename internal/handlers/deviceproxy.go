@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// maxProxyRewriteBytes limits the size of a device page or script rewritten by the proxy
+const maxProxyRewriteBytes = 16 << 20
+
+// deviceAPIPath matches absolute /api/ paths in quoted strings and templates of the device UI
+var deviceAPIPath = regexp.MustCompile("[\"'`}]/api/")
+
+// rewriteDevicePaths points the absolute paths of a device page or script at the proxy prefix
+func rewriteDevicePaths(body []byte, prefix string) []byte {
+	body = deviceAPIPath.ReplaceAllFunc(body, func(match []byte) []byte {
+		return append([]byte{match[0]}, prefix+"/api/"...)
+	})
+	return bytes.ReplaceAll(body, []byte(`<base href="/">`), []byte(`<base href="`+prefix+`/">`))
+}
+
+// rewriteDeviceResponse adjusts redirects, HTML and JavaScript from a device so
+// the UI keeps working below the proxy prefix
+func rewriteDeviceResponse(prefix string) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if location := resp.Header.Get("Location"); strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") {
+			resp.Header.Set("Location", prefix+location)
+		}
+
+		contentType := resp.Header.Get("Content-Type")
+		if !strings.HasPrefix(contentType, "text/html") && !strings.Contains(contentType, "javascript") {
+			return nil
+		}
+
+		// AxeOS serves its static files gzipped whatever the request accepts
+		var reader io.Reader = resp.Body
+		switch resp.Header.Get("Content-Encoding") {
+		case "":
+		case "gzip":
+			gz, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			reader = gz
+		default:
+			return nil
+		}
+
+		body, err := io.ReadAll(io.LimitReader(reader, maxProxyRewriteBytes+1))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if len(body) > maxProxyRewriteBytes {
+			return fmt.Errorf("response larger than %d bytes", maxProxyRewriteBytes)
+		}
+
+		body = rewriteDevicePaths(body, prefix)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("ETag")
+		return nil
+	}
+}
+
+// withoutSessionCookie removes the dashboard session from the cookies sent to a device
+func withoutSessionCookie(header http.Header) {
+	cookies := (&http.Request{Header: header}).Cookies()
+	header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != middleware.SessionCookie && cookie.Name != middleware.RefreshCookie {
+			header.Add("Cookie", cookie.String())
+		}
+	}
+}
+
+// HandleDeviceProxy handles /device/{id}/...
+// Serves a device's own AxeOS web UI through the dashboard, including its
+// WebSocket log stream, so devices need not be reachable from the browser
+// The device's pages and scripts run on the dashboard origin with the
+// admin's session; README.md documents the risk next to device_proxy_enabled
+func HandleDeviceProxy(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		writeError := func(status int, message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"message": message})
+		}
+
+		if !cfg.DeviceProxyEnabled {
+			writeError(http.StatusNotFound, "Not Found")
+			return
+		}
+
+		instanceID, rest, hasSlash := strings.Cut(strings.TrimPrefix(r.URL.Path, "/device/"), "/")
+		instanceURL := findInstanceURL(cfg, instanceID)
+		if instanceURL == "" {
+			writeError(http.StatusNotFound, fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", instanceID))
+			return
+		}
+
+		// Count each device separately in the request statistics to spot slow ones
+		prefix := "/device/" + url.PathEscape(instanceID)
+		middleware.SetRouteLabel(r, prefix)
+		if !hasSlash {
+			// Relative paths in the device UI need the trailing slash
+			middleware.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+		// Paths in the device UI are rewritten as the browser sees them, below base_path
+		prefix = middleware.BasePath(r) + prefix
+
+		target, err := url.Parse(instanceURL)
+		if err != nil {
+			writeError(http.StatusInternalServerError, fmt.Sprintf("Invalid URL for %s: %v", instanceID, err))
+			return
+		}
+
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.Out.URL.Path = "/" + rest
+				pr.Out.URL.RawPath = ""
+				pr.SetURL(target)
+				pr.SetXForwarded()
+				pr.Out.Header.Del("Authorization")
+				withoutSessionCookie(pr.Out.Header)
+				services.SetOutboundHeaders(pr.Out, cfg)
+			},
+			Transport:      services.GetHTTPClientPool().Transport(cfg, instanceID),
+			ModifyResponse: rewriteDeviceResponse(prefix),
+			ErrorHandler: func(_ http.ResponseWriter, _ *http.Request, err error) {
+				log.ErrorWithRequest(r, "Device proxy error for %s: %v", instanceID, err)
+				writeError(http.StatusBadGateway, fmt.Sprintf("Device %s is unreachable", instanceID))
+			},
+		}
+
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			// The log stream outlives the server's read and write timeouts
+			rc := http.NewResponseController(w)
+			rc.SetReadDeadline(time.Time{})
+			rc.SetWriteDeadline(time.Time{})
+		}
+		proxy.ServeHTTP(w, r)
+	}
+}
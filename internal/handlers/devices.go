@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// summaryWindows are the rolling windows (in days, including today) reported by the summary endpoint
+var summaryWindows = []struct {
+	name string
+	days int
+}{
+	{"1d", 1},
+	{"7d", 7},
+	{"30d", 30},
+}
+
+// DeviceStats summarizes a device over a range of daily rollups
+type DeviceStats struct {
+	Days              int     `json:"days"`
+	AvgHashrate       float64 `json:"avgHashrate"`
+	MaxHashrate       float64 `json:"maxHashrate"`
+	AvgTemperature    float64 `json:"avgTemperature"`
+	UptimePercent     float64 `json:"uptimePercent"`
+	EnergyKWh         float64 `json:"energyKWh"`
+	BestDiff          float64 `json:"bestDiff"`
+	BestDiffFormatted string  `json:"bestDiffFormatted"`
+}
+
+// DeviceSummary is the response of GET /api/devices/{id}/summary
+type DeviceSummary struct {
+	InstanceID      string                 `json:"instanceId"`
+	Since           string                 `json:"since,omitempty"`
+	TemperatureUnit string                 `json:"temperatureUnit"`
+	Lifetime        DeviceStats            `json:"lifetime"`
+	Windows         map[string]DeviceStats `json:"windows"`
+}
+
+// summarizeSnapshots combines daily rollups into one set of stats. Averages are
+// weighted by sample count; uptime is relative to the time between from and now.
+// Temperatures are converted to unit.
+func summarizeSnapshots(snapshots []*database.DailySnapshot, from, now time.Time, unit string) DeviceStats {
+	stats := DeviceStats{Days: len(snapshots)}
+	if len(snapshots) == 0 {
+		stats.BestDiffFormatted = services.FormatDifficulty(0)
+		return stats
+	}
+
+	var samples, uptime int
+	var hashrateSum, tempSum float64
+	for _, s := range snapshots {
+		samples += s.Samples
+		uptime += s.UptimeSeconds
+		hashrateSum += s.AvgHashrate * float64(s.Samples)
+		tempSum += s.AvgTemperature * float64(s.Samples)
+		stats.EnergyKWh += s.EnergyKWh
+		if s.MaxHashrate > stats.MaxHashrate {
+			stats.MaxHashrate = s.MaxHashrate
+		}
+		if s.BestDiff > stats.BestDiff {
+			stats.BestDiff = s.BestDiff
+		}
+	}
+
+	if samples > 0 {
+		stats.AvgHashrate = hashrateSum / float64(samples)
+		stats.AvgTemperature = config.CelsiusTo(unit, tempSum/float64(samples))
+	}
+
+	if elapsed := now.Sub(from).Seconds(); elapsed > 0 {
+		stats.UptimePercent = float64(uptime) / elapsed * 100
+		if stats.UptimePercent > 100 {
+			stats.UptimePercent = 100
+		}
+	}
+
+	stats.BestDiffFormatted = services.FormatDifficulty(stats.BestDiff)
+	return stats
+}
+
+// HandleDeviceSummary handles GET /api/devices/{instanceId}/summary[?units=C|F]
+// Returns lifetime and rolling-window stats assembled from daily rollups
+func HandleDeviceSummary(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/api/devices/")
+		instanceID, ok := strings.CutSuffix(path, "/summary")
+		if !ok || instanceID == "" || strings.Contains(instanceID, "/") || !knownDevice(cfg, instanceID) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+			return
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		snapshots, err := db.GetDailySnapshots(database.SnapshotAxeOS, instanceID, "")
+		if err != nil {
+			log.ErrorWithRequest(r, "Error loading summary for %s: %v", instanceID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+		// Uptime is measured from the first day the device was seen
+		firstSeen := today
+		if len(snapshots) > 0 {
+			if day, err := time.ParseInLocation(database.SnapshotDayFormat, snapshots[0].Day, now.Location()); err == nil {
+				firstSeen = day
+			}
+		}
+
+		unit := temperatureUnit(r, cfg)
+		summary := DeviceSummary{
+			InstanceID:      instanceID,
+			TemperatureUnit: unit,
+			Lifetime:        summarizeSnapshots(snapshots, firstSeen, now, unit),
+			Windows:         map[string]DeviceStats{},
+		}
+		if len(snapshots) > 0 {
+			summary.Since = snapshots[0].Day
+		}
+
+		for _, window := range summaryWindows {
+			from := today.AddDate(0, 0, 1-window.days)
+			if from.Before(firstSeen) {
+				from = firstSeen
+			}
+			firstDay := from.Format(database.SnapshotDayFormat)
+
+			var inWindow []*database.DailySnapshot
+			for _, s := range snapshots {
+				if s.Day >= firstDay {
+					inWindow = append(inWindow, s)
+				}
+			}
+			summary.Windows[window.name] = summarizeSnapshots(inWindow, from, now, unit)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(summary)
+	}
+}
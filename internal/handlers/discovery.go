@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/jobs"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// Job types of device discovery
+const (
+	DiscoveryJobType        = "discovery_scan"    // Network scan
+	MiningCoreImportJobType = "miningcore_import" // Workers of Mining Core pools
+)
+
+// maxScanRequestBytes limits the body of a scan request
+const maxScanRequestBytes = 4 << 10
+
+// DiscoveryScanRequest is the body of POST /api/discovery/scan
+type DiscoveryScanRequest struct {
+	Subnet string `json:"subnet,omitempty"` // CIDR, e.g. "192.168.1.0/24"; the local networks when empty
+}
+
+// MiningCoreImportRequest is the body of POST /api/discovery/miningcore
+type MiningCoreImportRequest struct {
+	Pool string `json:"pool,omitempty"` // Name in mining_core_url; every configured pool when empty
+}
+
+// configuredInstances maps the base URLs in axeos_instances to their names
+func configuredInstances(cfg *config.Config) map[string]string {
+	configured := map[string]string{}
+	for _, instance := range cfg.AxeosInstances {
+		for name, url := range instance {
+			configured[strings.TrimRight(url, "/")] = name
+		}
+	}
+	return configured
+}
+
+// scanSubnets returns the subnets a scan request covers
+func scanSubnets(req DiscoveryScanRequest) ([]*net.IPNet, error) {
+	if req.Subnet == "" {
+		return services.LocalSubnets()
+	}
+	subnet, err := services.ParseScanSubnet(req.Subnet)
+	if err != nil {
+		return nil, err
+	}
+	return []*net.IPNet{subnet}, nil
+}
+
+// decodeScanRequest reads an optional scan request body
+func decodeScanRequest(w http.ResponseWriter, r *http.Request) (DiscoveryScanRequest, error) {
+	var req DiscoveryScanRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScanRequestBytes)).Decode(&req)
+	if errors.Is(err, io.EOF) {
+		return req, nil // No body scans the local networks
+	}
+	return req, err
+}
+
+// NewDiscoveryScanJob returns the factory of network scan jobs. The result
+// lists the AxeOS devices found and whether they are already configured.
+func NewDiscoveryScanJob(cfgManager *config.Manager) jobs.Factory {
+	return func(params json.RawMessage) (jobs.Func, error) {
+		var req DiscoveryScanRequest
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &req); err != nil {
+				return nil, fmt.Errorf("invalid scan parameters: %w", err)
+			}
+		}
+		subnets, err := scanSubnets(req)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context, progress *jobs.Progress) (interface{}, error) {
+			cfg := cfgManager.GetConfig()
+			return services.ScanForMiners(ctx, cfg, subnets, services.GetAPIPath(cfg, "instanceInfo"), configuredInstances(cfg),
+				func(done, total int) {
+					if done == 1 {
+						progress.SetTotal(total)
+					}
+					progress.Step("")
+				})
+		}, nil
+	}
+}
+
+// miningCoreServers returns the Mining Core servers an import request covers
+func miningCoreServers(cfg *config.Config, req MiningCoreImportRequest) (map[string]string, error) {
+	if !cfg.MiningCoreEnabled || len(cfg.MiningCoreURL) == 0 {
+		return nil, errors.New("Mining Core is not enabled in the configuration")
+	}
+	servers := map[string]string{}
+	for _, poolMap := range cfg.MiningCoreURL {
+		for name, url := range poolMap {
+			if req.Pool == "" || name == req.Pool {
+				servers[name] = url
+			}
+		}
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("Mining Core pool %q not found in configuration", req.Pool)
+	}
+	return servers, nil
+}
+
+// NewMiningCoreImportJob returns the factory of Mining Core import jobs. The
+// result lists the AxeOS devices behind the pools' workers and the workers
+// for which no device was found.
+func NewMiningCoreImportJob(cfgManager *config.Manager) jobs.Factory {
+	return func(params json.RawMessage) (jobs.Func, error) {
+		var req MiningCoreImportRequest
+		if len(params) > 0 {
+			if err := json.Unmarshal(params, &req); err != nil {
+				return nil, fmt.Errorf("invalid import parameters: %w", err)
+			}
+		}
+		servers, err := miningCoreServers(cfgManager.GetConfig(), req)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context, progress *jobs.Progress) (interface{}, error) {
+			cfg := cfgManager.GetConfig()
+			progress.SetMessage("Reading workers from Mining Core")
+			return services.ImportMiningCoreWorkers(ctx, cfg, servers, services.GetAPIPath(cfg, "instanceInfo"), configuredInstances(cfg),
+				func(done, total int) {
+					if done == 1 {
+						progress.SetTotal(total)
+					}
+					progress.Step("")
+				})
+		}, nil
+	}
+}
+
+// HandleDiscoveryScan handles POST /api/discovery/scan
+// Starts a background scan of the local network (or the posted subnet) for
+// AxeOS devices; poll the returned job for the candidates
+func HandleDiscoveryScan(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	if r.Method != http.MethodPost {
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	req, err := decodeScanRequest(w, r)
+	if err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": "Request body must be {\"subnet\": \"192.168.1.0/24\"} or empty"})
+		return
+	}
+	params, _ := json.Marshal(req)
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	job, err := jobs.GetManager().Start(DiscoveryJobType, params, username)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		writeJSON(http.StatusServiceUnavailable, map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	w.Header().Set("Location", middleware.BasePath(r)+"/api/jobs/"+job.ID)
+	writeJSON(http.StatusAccepted, job)
+}
+
+// HandleBootstrapDiscoveryScan handles POST /api/discovery/scan in bootstrap mode
+// Scans synchronously and returns the candidates, so the setup form can fill in devices
+func HandleBootstrapDiscoveryScan(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	if r.Method != http.MethodPost {
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	req, err := decodeScanRequest(w, r)
+	if err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": "Request body must be {\"subnet\": \"192.168.1.0/24\"} or empty"})
+		return
+	}
+	subnets, err := scanSubnets(req)
+	if err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+
+	// There is no configuration yet; probes carry the default user agent
+	result, err := services.ScanForMiners(r.Context(), &config.Config{}, subnets, "/api/system/info", nil, nil)
+	if err != nil {
+		writeJSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+		return
+	}
+	writeJSON(http.StatusOK, result)
+}
+
+// HandleMiningCoreImport handles POST /api/discovery/miningcore
+// Starts a background job that reads the workers of the configured Mining
+// Core pools and looks for the AxeOS devices behind them; poll the returned
+// job for the candidates
+func HandleMiningCoreImport(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	if r.Method != http.MethodPost {
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	var req MiningCoreImportRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScanRequestBytes)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": "Request body must be {\"pool\": \"name\"} or empty"})
+		return
+	}
+	params, _ := json.Marshal(req)
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	job, err := jobs.GetManager().Start(MiningCoreImportJobType, params, username)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		writeJSON(http.StatusServiceUnavailable, map[string]string{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	w.Header().Set("Location", middleware.BasePath(r)+"/api/jobs/"+job.ID)
+	writeJSON(http.StatusAccepted, job)
+}
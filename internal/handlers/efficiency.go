@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// HandleEfficiencyReport handles GET /api/reports/efficiency[?weeks=N&threshold=P]
+// Fits a trend to each device's daily efficiency (J/TH) and flags devices
+// degrading faster than the threshold. weeks and threshold default to the
+// efficiency_report section, which does not need to be enabled.
+func HandleEfficiencyReport(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+		}
+
+		weeks := cfg.EfficiencyReport.TrendWeeks()
+		if value := query.Get("weeks"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 2 || n > 26 {
+				badRequest("weeks must be between 2 and 26")
+				return
+			}
+			weeks = n
+		}
+		threshold := cfg.EfficiencyReport.Threshold()
+		if value := query.Get("threshold"); value != "" {
+			p, err := strconv.ParseFloat(value, 64)
+			if err != nil || p <= 0 {
+				badRequest("threshold must be a positive percentage")
+				return
+			}
+			threshold = p
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		now := time.Now()
+		trends := []*services.EfficiencyTrend{}
+		for _, instance := range cfg.AxeosInstances {
+			for name := range instance {
+				trend, err := services.AnalyzeEfficiency(db, name, now, weeks, threshold)
+				if err != nil {
+					log.ErrorWithRequest(r, "Error analyzing the efficiency of %s: %v", name, err)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+					return
+				}
+				trends = append(trends, trend)
+			}
+		}
+		// Worst first
+		sort.Slice(trends, func(i, j int) bool {
+			return trends[i].ChangePercentPerWeek > trends[j].ChangePercentPerWeek
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"weeks":            weeks,
+			"thresholdPercent": threshold,
+			"devices":          trends,
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	defaultEnergyDays = 30
+	maxEnergyDays     = 366
+)
+
+// EnergyResponse is the response of GET /api/energy
+type EnergyResponse struct {
+	SourceID        string                    `json:"sourceId"`
+	TotalKWh        float64                   `json:"totalKWh"`
+	TotalCO2Kg      float64                   `json:"totalCO2Kg"`
+	TotalCost       float64                   `json:"totalCost"`
+	Currency        string                    `json:"currency"` // Of the costs, from the market settings
+	CarbonIntensity *services.CarbonIntensity `json:"carbonIntensity,omitempty"`
+	Days            []*database.EnergyBucket  `json:"days"`
+}
+
+// HandleEnergy handles GET /api/energy[?instanceId=X&days=N]
+// Returns daily kWh, estimated CO2 and cost buckets for one device, or the whole fleet when instanceId is omitted
+func HandleEnergy(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		sourceID := database.EnergyFleetID
+		if instanceID := query.Get("instanceId"); instanceID != "" {
+			if !knownDevice(cfg, instanceID) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Instance not found"})
+				return
+			}
+			sourceID = instanceID
+		}
+
+		days := defaultEnergyDays
+		if value := query.Get("days"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxEnergyDays {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"message": fmt.Sprintf("days must be between 1 and %d", maxEnergyDays),
+				})
+				return
+			}
+			days = n
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		sinceDay := time.Now().AddDate(0, 0, 1-days).Format(database.SnapshotDayFormat)
+		buckets, err := db.GetEnergyBuckets(sourceID, sinceDay)
+		if err != nil {
+			log.ErrorWithRequest(r, "Error loading energy for %s: %v", sourceID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		response := EnergyResponse{
+			SourceID:        sourceID,
+			Currency:        cfg.Market.Currency,
+			CarbonIntensity: services.GetCarbonIntensity(cfg, cfgManager.GetConfigDir()),
+			Days:            []*database.EnergyBucket{},
+		}
+		for _, b := range buckets {
+			response.TotalKWh += b.EnergyKWh
+			response.TotalCO2Kg += b.CO2Kg
+			response.TotalCost += b.Cost
+			response.Days = append(response.Days, b)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// EventPurgeRequest is the body of POST /api/events/purge
+type EventPurgeRequest struct {
+	Categories    []string `json:"categories"`    // Empty means all categories
+	OlderThanDays *int     `json:"olderThanDays"` // 0 deletes everything in the categories
+}
+
+// HandleEventsPurge handles POST /api/events/purge
+// Deletes events, alerts or audit records older than a number of days
+func HandleEventsPurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	badRequest := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}
+
+	var req EventPurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badRequest("Invalid JSON in request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.OlderThanDays == nil || *req.OlderThanDays < 0 {
+		badRequest("olderThanDays is required and must not be negative")
+		return
+	}
+	if len(req.Categories) == 0 {
+		req.Categories = database.EventCategories
+	}
+	for _, category := range req.Categories {
+		switch category {
+		case database.EventCategoryEvents, database.EventCategoryAlerts, database.EventCategoryAudit:
+		default:
+			badRequest("categories must be events, alerts or audit")
+			return
+		}
+	}
+
+	db := database.Instance()
+	if db == nil {
+		writeDataCollectionDisabled(w)
+		return
+	}
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	before := time.Now().AddDate(0, 0, -*req.OlderThanDays)
+	deleted := map[string]int64{}
+	var total int64
+	for _, category := range req.Categories {
+		n, err := db.PruneEvents(category, before)
+		if authDB := database.AuthInstance(); err == nil && authDB != nil && category == database.EventCategoryAudit {
+			// The authentication audit trail shares the audit retention
+			var authDeleted int64
+			authDeleted, err = authDB.PruneAuthEvents(before)
+			n += authDeleted
+		}
+		if err != nil {
+			log.ErrorWithRequest(r, "Error purging %s: %v", category, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+		deleted[category] = n
+		total += n
+	}
+	log.InfoWithRequest(r, "%s purged %d events older than %d days (%v)", username, total, *req.OlderThanDays, req.Categories)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deleted": deleted,
+		"total":   total,
+		"before":  before,
+	})
+}
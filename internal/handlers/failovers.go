@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+const (
+	defaultFailoverWindow = 7 * 24 * time.Hour
+	defaultFailoverLimit  = 100
+	maxFailoverLimit      = 1000
+)
+
+// HandlePoolFailovers handles GET /api/failovers
+// Lists the periods devices mined on their fallback pool, newest first, and
+// the devices on their fallback pool now. Optional query parameters:
+// instanceId, since (RFC 3339 or Unix seconds, default 7 days ago) and limit
+// (default 100).
+func HandlePoolFailovers(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+		}
+
+		instanceID := query.Get("instanceId")
+		if instanceID != "" && !knownDevice(cfg, instanceID) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", instanceID),
+			})
+			return
+		}
+
+		since := time.Now().Add(-defaultFailoverWindow)
+		if value := query.Get("since"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid since time: use RFC 3339 or Unix seconds")
+				return
+			}
+			since = t
+		}
+
+		limit := defaultFailoverLimit
+		if value := query.Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxFailoverLimit {
+				badRequest(fmt.Sprintf("limit must be between 1 and %d", maxFailoverLimit))
+				return
+			}
+			limit = n
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		failovers, err := db.GetPoolFailovers(instanceID, since, limit)
+		if err != nil {
+			log.ErrorWithRequest(r, "Error reading pool failovers: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+		if failovers == nil {
+			failovers = []*database.PoolFailover{}
+		}
+
+		onFallback := []string{}
+		for _, failover := range failovers {
+			failover.StartedAt = failover.StartedAt.Local()
+			if failover.EndedAt != nil {
+				ended := failover.EndedAt.Local()
+				failover.EndedAt = &ended
+			}
+			if failover.Active {
+				onFallback = append(onFallback, failover.InstanceID)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"failovers":         failovers,
+			"onFallback":        onFallback,
+			"since":             since,
+			"alertAfterMinutes": int(cfg.Notifications.FallbackAlertWindow().Minutes()),
+		})
+	}
+}
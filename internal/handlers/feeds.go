@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+const (
+	// feedTokenLifetime is how long a calendar/feed subscription token stays valid
+	feedTokenLifetime = 365 * 24 * time.Hour
+	// feedHistory is how far back events are included in feeds
+	feedHistory = 90 * 24 * time.Hour
+	// feedMaxEvents caps the number of events in a single feed
+	feedMaxEvents = 500
+)
+
+// authorizeFeed checks the token query parameter of a feed request.
+// Feeds are open when authentication is disabled.
+func authorizeFeed(r *http.Request, cfg *config.Config) bool {
+	if cfg.DisableAuthentication {
+		return true
+	}
+
+	jwtService := auth.GetJWTService()
+	if jwtService == nil {
+		return false
+	}
+
+	_, err := jwtService.VerifyScopedToken(r.URL.Query().Get("token"), auth.FeedScope)
+	return err == nil
+}
+
+// HandleFeedToken handles POST /api/feeds/token
+// Issues a long-lived token for subscribing to feeds from calendar apps and feed readers
+func HandleFeedToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	token, err := auth.GetJWTService().CreateScopedToken(username, auth.FeedScope, feedTokenLifetime)
+	if err != nil {
+		log.ErrorWithRequest(r, "Error creating feed token: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
+		return
+	}
+
+	query := url.Values{"token": {token}}.Encode()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"token":     token,
+		"icalUrl":   middleware.BasePath(r) + "/api/feeds/events.ics?" + query,
+		"rssUrl":    middleware.BasePath(r) + "/api/feeds/events.rss?" + query,
+		"atomUrl":   middleware.BasePath(r) + "/api/feeds/events.atom?" + query,
+		"expiresAt": time.Now().Add(feedTokenLifetime).UTC().Format(time.RFC3339),
+	})
+}
+
+// loadFeedEvents returns recent events, or none when data collection is disabled
+func loadFeedEvents() []*database.Event {
+	db := database.Instance()
+	if db == nil {
+		return nil
+	}
+
+	events, err := db.GetEvents(time.Now().Add(-feedHistory), nil, feedMaxEvents)
+	if err != nil {
+		log.Error("Error loading events for feed: %v", err)
+		return nil
+	}
+	return events
+}
+
+// icalEscape escapes text values per RFC 5545
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalLine writes a content line, folding it at 75 octets per RFC 5545
+func icalLine(b *strings.Builder, line string) {
+	for len(line) > 75 {
+		cut := 75
+		// Do not split a multi-byte UTF-8 character
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// icalTime formats a time in UTC iCal form
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// buildICal renders events and maintenance windows as an iCalendar document
+func buildICal(cfg *config.Config, events []*database.Event) string {
+	var b strings.Builder
+	now := icalTime(time.Now())
+
+	icalLine(&b, "BEGIN:VCALENDAR")
+	icalLine(&b, "VERSION:2.0")
+	icalLine(&b, "PRODID:-//AxeOS Dashboard//Events//EN")
+	icalLine(&b, "CALSCALE:GREGORIAN")
+	icalLine(&b, "X-WR-CALNAME:"+icalEscape(cfg.Title))
+
+	for _, event := range events {
+		icalLine(&b, "BEGIN:VEVENT")
+		icalLine(&b, fmt.Sprintf("UID:event-%d@axeos-dashboard", event.ID))
+		icalLine(&b, "DTSTAMP:"+now)
+		icalLine(&b, "DTSTART:"+icalTime(event.Timestamp))
+		icalLine(&b, "DTEND:"+icalTime(event.Timestamp.Add(time.Minute)))
+		icalLine(&b, "SUMMARY:"+icalEscape(event.Title))
+		icalLine(&b, "DESCRIPTION:"+icalEscape(event.Message))
+		icalLine(&b, "CATEGORIES:"+icalEscape(event.Type))
+		icalLine(&b, "TRANSP:TRANSPARENT")
+		icalLine(&b, "END:VEVENT")
+	}
+
+	for i, window := range cfg.MaintenanceWindows {
+		start, err := window.StartTime()
+		if err != nil {
+			continue
+		}
+
+		description := window.Description
+		if len(window.Instances) > 0 {
+			description = strings.TrimSpace(description + "\nDevices: " + strings.Join(window.Instances, ", "))
+		}
+
+		icalLine(&b, "BEGIN:VEVENT")
+		icalLine(&b, fmt.Sprintf("UID:maintenance-%d-%d@axeos-dashboard", i, start.Unix()))
+		icalLine(&b, "DTSTAMP:"+now)
+		icalLine(&b, "DTSTART:"+icalTime(start))
+		icalLine(&b, "DTEND:"+icalTime(start.Add(window.Length())))
+		icalLine(&b, "SUMMARY:"+icalEscape(window.Title))
+		if description != "" {
+			icalLine(&b, "DESCRIPTION:"+icalEscape(description))
+		}
+		icalLine(&b, "CATEGORIES:"+database.EventMaintenance)
+		switch window.Recurrence {
+		case "daily", "weekly", "monthly":
+			icalLine(&b, "RRULE:FREQ="+strings.ToUpper(window.Recurrence))
+		}
+		icalLine(&b, "END:VEVENT")
+	}
+
+	icalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// HandleICalFeed handles GET /api/feeds/events.ics?token=X
+// Publishes found blocks, best difficulty records, restarts and maintenance windows
+func HandleICalFeed(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		if !authorizeFeed(r, cfg) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Invalid or missing feed token"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", "inline; filename=\"axeos-events.ics\"")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(buildICal(cfg, loadFeedEvents())))
+	}
+}
+
+// rssFeed is the RSS 2.0 document structure
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	LastBuild   string    `xml:"lastBuildDate"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	Category    string  `xml:"category"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// atomFeed is the Atom 1.0 document structure
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Updated  string       `xml:"updated"`
+	Summary  string       `xml:"summary"`
+	Category atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// feedBaseURL returns the externally visible base URL of the dashboard
+func feedBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + middleware.BasePath(r)
+}
+
+// HandleEventFeed handles GET /api/feeds/events.rss and /api/feeds/events.atom (token-in-URL)
+func HandleEventFeed(cfgManager *config.Manager, format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		if !authorizeFeed(r, cfg) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Invalid or missing feed token"})
+			return
+		}
+
+		events := loadFeedEvents()
+		baseURL := feedBaseURL(r)
+		updated := time.Now()
+		if len(events) > 0 {
+			updated = events[0].Timestamp
+		}
+
+		var doc interface{}
+		contentType := "application/rss+xml; charset=utf-8"
+
+		if format == "atom" {
+			feed := atomFeed{
+				ID:      baseURL + "/api/feeds/events.atom",
+				Title:   cfg.Title,
+				Updated: updated.UTC().Format(time.RFC3339),
+				Link:    atomLink{Href: baseURL + "/"},
+			}
+			for _, event := range events {
+				feed.Entries = append(feed.Entries, atomEntry{
+					ID:       fmt.Sprintf("%s/events/%d", baseURL, event.ID),
+					Title:    event.Title,
+					Updated:  event.Timestamp.UTC().Format(time.RFC3339),
+					Summary:  event.Message,
+					Category: atomCategory{Term: event.Type},
+				})
+			}
+			doc = feed
+			contentType = "application/atom+xml; charset=utf-8"
+		} else {
+			channel := rssChannel{
+				Title:       cfg.Title,
+				Link:        baseURL + "/",
+				Description: "Alerts and milestones from " + cfg.Title,
+				LastBuild:   updated.UTC().Format(time.RFC1123Z),
+			}
+			for _, event := range events {
+				channel.Items = append(channel.Items, rssItem{
+					Title:       event.Title,
+					Description: event.Message,
+					Category:    event.Type,
+					PubDate:     event.Timestamp.UTC().Format(time.RFC1123Z),
+					GUID:        rssGUID{Value: fmt.Sprintf("axeos-dashboard-event-%d", event.ID)},
+				})
+			}
+			doc = rssFeed{Version: "2.0", Channel: channel}
+		}
+
+		output, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			log.ErrorWithRequest(r, "Error rendering %s feed: %v", format, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(xml.Header))
+		w.Write(output)
+	}
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
+	"github.com/scottwalter/axeos-dashboard/internal/scheduler"
+)
+
+// databasePingTimeout bounds the database check of the health endpoints
+const databasePingTimeout = 2 * time.Second
+
+// serverStartedAt is when the server process started, for the uptime
+var serverStartedAt = time.Now()
+
+// healthStatusTTL is how long the public health status is reused, so frequent
+// unauthenticated polls do not run every check each time
+const healthStatusTTL = 10 * time.Second
+
+var (
+	healthStatus   string
+	healthStatusAt time.Time
+	healthStatusMu sync.Mutex
+)
+
+// HealthStatus is the public response of the health endpoint
+type HealthStatus struct {
+	Status string `json:"status"` // "ok", "warning" or "setup"
+}
+
+// HealthResponse represents the response for the health details endpoint
+type HealthResponse struct {
+	Status        string                 `json:"status"`
+	StartedAt     time.Time              `json:"startedAt"`
+	UptimeSeconds int64                  `json:"uptimeSeconds"`
+	Warnings      []string               `json:"warnings"`
+	Checks        map[string]interface{} `json:"checks"`
+}
+
+// ReadyResponse represents the response for the readiness endpoint
+type ReadyResponse struct {
+	Status  string   `json:"status"` // "ready" or "not_ready"
+	Reasons []string `json:"reasons"`
+}
+
+// DatabaseCheck is the database check of the health endpoints
+type DatabaseCheck struct {
+	Enabled   bool    `json:"enabled"` // data_collection_enabled
+	OK        bool    `json:"ok"`
+	Ephemeral bool    `json:"ephemeral,omitempty"`
+	LatencyMs float64 `json:"latencyMs,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// checkDatabase checks that the metrics database answers queries when data collection is enabled
+func checkDatabase(ctx context.Context, cfg *config.Config) DatabaseCheck {
+	check := DatabaseCheck{Enabled: cfg.DataCollectionEnabled}
+	if !check.Enabled {
+		return check
+	}
+	db := database.Instance()
+	if db == nil {
+		check.Error = "database is not open"
+		return check
+	}
+	check.Ephemeral = db.Ephemeral()
+
+	ctx, cancel := context.WithTimeout(ctx, databasePingTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := db.Ping(ctx); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	check.OK = true
+	return check
+}
+
+// checkScheduler returns the state of the collection scheduler
+func checkScheduler() scheduler.Status {
+	if sched := scheduler.Instance(); sched != nil {
+		return sched.Status()
+	}
+	return scheduler.Status{Tasks: []scheduler.TaskStatus{}}
+}
+
+// writeHealthJSON writes a health response that must never be cached
+func writeHealthJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// checkHealth runs every health check: database and scheduler state,
+// reachability of miners, pools and nodes, insecure secret file permissions,
+// missing page templates, credentials due for rotation and disk space
+func checkHealth(r *http.Request, cfgManager *config.Manager, configDir, publicDir string) HealthResponse {
+	cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+	response := HealthResponse{
+		Status:        "ok",
+		StartedAt:     serverStartedAt,
+		UptimeSeconds: int64(time.Since(serverStartedAt).Seconds()),
+		Warnings:      []string{},
+		Checks:        map[string]interface{}{},
+	}
+
+	// Secret files must not be readable by other users. Startup repairs and
+	// logs them, so they are not logged again on every check.
+	secretIssues := config.CheckSecretFilePermissions(configDir)
+	response.Checks["secretFiles"] = secretIssues
+	for _, issue := range secretIssues {
+		response.Warnings = append(response.Warnings, issue.Message)
+	}
+
+	// Page templates can disappear after startup (e.g. a broken volume mount)
+	assets := CheckPublicAssets(publicDir)
+	response.Checks["assets"] = assets
+	if !assets.OK {
+		log.WarnWithRequest(r, "ASSETS: missing %v", assets.Missing)
+		response.Warnings = append(response.Warnings, "Missing public assets: "+strings.Join(assets.Missing, ", "))
+	}
+
+	// Credentials older than the rotation policy
+	if cfg != nil && !cfg.DisableAuthentication && cfg.CredentialRotation.Enabled() {
+		ages := auth.CheckCredentialAges(configDir, cfg.CredentialRotation)
+		passwordsDue := 0
+		for _, age := range ages {
+			if !age.RotationDue {
+				continue
+			}
+			if age.Credential == auth.CredentialJWTKey {
+				response.Warnings = append(response.Warnings, fmt.Sprintf("JWT signing key is %d days old (rotate every %d days)", age.AgeDays, age.MaxAgeDays))
+			} else {
+				passwordsDue++
+			}
+		}
+		if passwordsDue > 0 {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("%d user password(s) older than %d days", passwordsDue, cfg.CredentialRotation.PasswordDays))
+		}
+		response.Checks["credentials"] = ages
+	}
+
+	// The metrics database must not fill up the data volume
+	if sched := scheduler.Instance(); sched != nil {
+		guard := sched.DiskGuardStatus()
+		response.Checks["disk"] = guard
+		if guard.OverLimit {
+			log.WarnWithRequest(r, "DISK SPACE: %s", guard.Reason)
+			response.Warnings = append(response.Warnings, "Disk space: "+guard.Reason)
+		}
+	}
+
+	if cfg != nil {
+		db := checkDatabase(r.Context(), cfg)
+		response.Checks["database"] = db
+		if db.Enabled && !db.OK {
+			log.WarnWithRequest(r, "DATABASE: %s", db.Error)
+			response.Warnings = append(response.Warnings, "Database unavailable: "+db.Error)
+		}
+
+		sched := checkScheduler()
+		response.Checks["scheduler"] = sched
+		if cfg.DataCollectionEnabled && !sched.Running {
+			response.Warnings = append(response.Warnings, "Data collection is enabled but the scheduler is not running")
+		}
+
+		// Only counts; /api/dependencies lists each dependency
+		tracker := dependencies.GetTracker()
+		counts := map[string]map[string]int{}
+		configured := configuredDependencies(cfg, configDir)
+		kinds := make([]string, 0, len(configured))
+		for kind := range configured {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			counts[kind] = map[string]int{
+				dependencies.StatusUp:       0,
+				dependencies.StatusDegraded: 0,
+				dependencies.StatusDown:     0,
+				dependencies.StatusUnknown:  0,
+			}
+			for _, name := range configured[kind] {
+				counts[kind][tracker.Status(kind, name).Status]++
+			}
+			if down := counts[kind][dependencies.StatusDown]; down > 0 {
+				response.Warnings = append(response.Warnings, fmt.Sprintf("%d of %d %s dependencies unreachable", down, len(configured[kind]), kind))
+			}
+		}
+		response.Checks["dependencies"] = counts
+	}
+
+	if len(response.Warnings) > 0 {
+		response.Status = "warning"
+	}
+	return response
+}
+
+// HandleHealth handles GET /api/health
+// Answers 200 while the server is up, so it suits liveness checks, with only
+// the overall status: "ok", or "warning" when a check of /api/health/details
+// failed. The endpoint is public, so it reveals nothing else, and the checks
+// run at most once per healthStatusTTL.
+func HandleHealth(cfgManager *config.Manager, configDir, publicDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		healthStatusMu.Lock()
+		if time.Since(healthStatusAt) >= healthStatusTTL {
+			healthStatus = checkHealth(r, cfgManager, configDir, publicDir).Status
+			healthStatusAt = time.Now()
+		}
+		status := healthStatus
+		healthStatusMu.Unlock()
+
+		writeHealthJSON(w, http.StatusOK, HealthStatus{Status: status})
+	}
+}
+
+// HandleHealthDetails handles GET /api/health/details
+// Reports the overall health of the dashboard with uptime, the warnings and
+// the result of each check
+func HandleHealthDetails(cfgManager *config.Manager, configDir, publicDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		writeHealthJSON(w, http.StatusOK, checkHealth(r, cfgManager, configDir, publicDir))
+	}
+}
+
+// HandleReady handles GET /api/ready
+// Answers 200 when the dashboard can serve requests: the configuration is
+// loaded, the page templates are present and, with data collection enabled,
+// the database answers and the scheduler is running. Otherwise it answers 503
+// with the reasons. Unreachable miners do not make the dashboard unready.
+func HandleReady(cfgManager *config.Manager, publicDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		response := ReadyResponse{Status: "ready", Reasons: []string{}}
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if cfg == nil {
+			response.Reasons = append(response.Reasons, "Configuration is not loaded")
+		}
+		if assets := CheckPublicAssets(publicDir); !assets.OK {
+			response.Reasons = append(response.Reasons, "Missing public assets: "+strings.Join(assets.Missing, ", "))
+		}
+		if cfg != nil && cfg.DataCollectionEnabled {
+			if db := checkDatabase(r.Context(), cfg); !db.OK {
+				response.Reasons = append(response.Reasons, "Database unavailable: "+db.Error)
+			}
+			if !checkScheduler().Running {
+				response.Reasons = append(response.Reasons, "Scheduler is not running")
+			}
+		}
+
+		status := http.StatusOK
+		if len(response.Reasons) > 0 {
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+		writeHealthJSON(w, status, response)
+	}
+}
+
+// HandleSetupHealth handles GET /api/health and GET /api/ready before the
+// first-time setup is complete. The server is alive but not ready.
+func HandleSetupHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	if r.URL.Path == "/api/ready" {
+		writeHealthJSON(w, http.StatusServiceUnavailable, ReadyResponse{
+			Status:  "not_ready",
+			Reasons: []string{"First-time setup has not been completed"},
+		})
+		return
+	}
+	writeHealthJSON(w, http.StatusOK, HealthStatus{Status: "setup"})
+}
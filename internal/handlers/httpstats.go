@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// HandleHTTPStats handles /api/admin/http-stats
+//
+//	GET    - request counts, error rates and latency per route, slowest first
+//	DELETE - reset the statistics
+//
+// Requests to the device web UI proxy are counted per device, so slow devices stand out.
+func HandleHTTPStats(w http.ResponseWriter, r *http.Request) {
+	stats := middleware.GetHTTPStats()
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"since":          stats.Since(),
+			"bucketsSeconds": middleware.HTTPLatencyBuckets,
+			"routes":         stats.Snapshot(),
+		})
+	case http.MethodDelete:
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+		stats.Reset()
+		log.InfoWithRequest(r, "%s reset the HTTP request statistics", username)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "HTTP statistics reset"})
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+	}
+}
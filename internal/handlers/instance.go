@@ -8,6 +8,8 @@ import (
 	"net/http"
 
 	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
 	"github.com/scottwalter/axeos-dashboard/internal/services"
 )
 
@@ -60,9 +62,9 @@ func HandleInstanceInfo(cfgManager *config.Manager) http.HandlerFunc {
 		infoURL := instanceURL + apiPath
 
 		// Fetch data from the AxeOS device
-		resp, err := http.Get(infoURL)
+		resp, err := services.GetHTTPClientPool().Get(r.Context(), cfg, instanceID, infoURL)
 		if err != nil {
-			fmt.Printf("Error fetching from AxeOS instance: %v\n", err)
+			log.ErrorWithRequest(r, "Error fetching from AxeOS instance: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{
@@ -139,9 +141,18 @@ func HandleInstanceRestart(cfgManager *config.Manager) http.HandlerFunc {
 		apiPath := services.GetAPIPath(cfg, "instanceRestart")
 		restartURL := instanceURL + apiPath
 
-		resp, err := http.Post(restartURL, "application/json", nil)
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, restartURL, nil)
 		if err != nil {
-			fmt.Printf("Failed to restart AxeOS: %v\n", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error", "error": err.Error()})
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := services.GetHTTPClientPool().Do(cfg, instanceID, req)
+		if err != nil {
+			log.ErrorWithRequest(r, "Failed to restart AxeOS: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error", "error": err.Error()})
@@ -159,6 +170,22 @@ func HandleInstanceRestart(cfgManager *config.Manager) http.HandlerFunc {
 			return
 		}
 
+		// Record the restart so it shows up in event feeds
+		if db := database.Instance(); db != nil {
+			username := "anonymous"
+			if user := middleware.GetUserFromContext(r); user != nil {
+				username = user.Username
+			}
+			if err := db.InsertEvent(&database.Event{
+				Type:    database.EventRestart,
+				Source:  instanceID,
+				Title:   fmt.Sprintf("%s restarted", instanceID),
+				Message: fmt.Sprintf("Restart requested by %s", username),
+			}); err != nil {
+				log.ErrorWithRequest(r, "Failed to record restart event: %v", err)
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -231,6 +258,15 @@ func HandleInstanceSettings(cfgManager *config.Manager) http.HandlerFunc {
 			return
 		}
 
+		// Keep a copy of the current settings so the change can be rolled back
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+		if _, err := backupDeviceSettings(r.Context(), cfg, instanceID, instanceURL, database.BackupReasonSettings, username); err != nil {
+			log.ErrorWithRequest(r, "Failed to back up settings of %s: %v", instanceID, err)
+		}
+
 		// Get API path and make request
 		apiPath := services.GetAPIPath(cfg, "instanceSettings")
 		settingsURL := instanceURL + apiPath
@@ -244,10 +280,9 @@ func HandleInstanceSettings(cfgManager *config.Manager) http.HandlerFunc {
 		}
 		req.Header.Set("Content-Type", "application/json")
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := services.GetHTTPClientPool().Do(cfg, instanceID, req)
 		if err != nil {
-			fmt.Printf("Failed to update settings: %v\n", err)
+			log.ErrorWithRequest(r, "Failed to update settings: %v", err)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error", "error": err.Error()})
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/jobs"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// StartJobRequest is the body of POST /api/jobs
+type StartJobRequest struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// HandleJobs handles /api/jobs and /api/jobs/{id}
+//   - GET    /api/jobs[?status=S]  list jobs (newest first) and the types that can be started
+//   - POST   /api/jobs             start a job: {"type": "...", "params": {...}}; returns 202
+//   - GET    /api/jobs/{id}        status, progress and result of one job
+//   - DELETE /api/jobs/{id}        cancel a queued or running job
+func HandleJobs(w http.ResponseWriter, r *http.Request) {
+	manager := jobs.GetManager()
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/jobs"), "/")
+
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		status := r.URL.Query().Get("status")
+		list := []jobs.Job{}
+		for _, job := range manager.List() {
+			if status == "" || job.Status == status {
+				list = append(list, job)
+			}
+		}
+		writeJSON(http.StatusOK, map[string]interface{}{"jobs": list, "types": manager.Types()})
+
+	case id == "" && r.Method == http.MethodPost:
+		var req StartJobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Type == "" {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "Request body must be {\"type\": \"...\", \"params\": {...}}"})
+			return
+		}
+		defer r.Body.Close()
+
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+
+		job, err := manager.Start(req.Type, req.Params, username)
+		if errors.Is(err, jobs.ErrQueueFull) {
+			writeJSON(http.StatusServiceUnavailable, map[string]string{"message": err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+			return
+		}
+		w.Header().Set("Location", middleware.BasePath(r)+"/api/jobs/"+job.ID)
+		writeJSON(http.StatusAccepted, job)
+
+	case id != "" && r.Method == http.MethodGet:
+		job, err := manager.Get(id)
+		if err != nil {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Job not found"})
+			return
+		}
+		writeJSON(http.StatusOK, job)
+
+	case id != "" && r.Method == http.MethodDelete:
+		job, err := manager.Cancel(id)
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Job not found"})
+		case errors.Is(err, jobs.ErrFinished):
+			writeJSON(http.StatusConflict, map[string]string{"message": "Job already finished"})
+		default:
+			writeJSON(http.StatusOK, job)
+		}
+
+	default:
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+	}
+}
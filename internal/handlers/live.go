@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/live"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+	"github.com/scottwalter/axeos-dashboard/internal/websocket"
+)
+
+const (
+	liveWriteTimeout = 10 * time.Second
+	livePingInterval = 30 * time.Second
+
+	// streamSettleDelay is how long the stream waits after an update before
+	// sending, so one collection cycle results in a single payload
+	streamSettleDelay = 2 * time.Second
+)
+
+// HandleSystemsWebSocket handles GET /ws/systems
+// Streams a snapshot of the latest collected values, then deltas as the scheduler collects them
+func HandleSystemsWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		log.WarnWithRequest(r, "WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	snapshot, updates, unsubscribe := live.GetHub().Subscribe()
+	defer unsubscribe()
+
+	if err := conn.WriteText(snapshot, liveWriteTimeout); err != nil {
+		return
+	}
+
+	// The read loop ends when the browser closes the connection
+	done := make(chan struct{})
+	go func() {
+		conn.ReadLoop()
+		close(done)
+	}()
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case message, ok := <-updates:
+			if !ok {
+				return // Dropped as a slow client; the browser reconnects
+			}
+			if err := conn.WriteText(message, liveWriteTimeout); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.Ping(liveWriteTimeout); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// HandleSystemsStream handles GET /api/systems/stream
+// Server-Sent Events fallback for clients that cannot use WebSockets: sends the
+// systems info payload on connect and again after each scheduled collection
+func HandleSystemsStream(cfgManager *config.Manager, cryptoNodeSvc *services.CryptoNodeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		_, updates, unsubscribe := live.GetHub().Subscribe()
+		defer unsubscribe()
+
+		// The stream outlives the server's write timeout
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+		w.WriteHeader(http.StatusOK)
+
+		send := func() error {
+			cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+			// Reuse the samples the scheduler just collected instead of polling every miner
+			maxAge := time.Duration(cfg.CollectionIntervalSeconds) * time.Second
+			data, err := json.Marshal(buildSystemsInfo(r.Context(), cfg, cryptoNodeSvc, maxAge))
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: systems\ndata: %s\n\n", data); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+
+		if err := send(); err != nil {
+			return
+		}
+
+		ping := time.NewTicker(livePingInterval)
+		defer ping.Stop()
+
+		// Armed by the first update of a collection cycle
+		settle := time.NewTimer(streamSettleDelay)
+		settle.Stop()
+		pending := false
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case _, ok := <-updates:
+				if !ok {
+					return // Dropped as a slow client; EventSource reconnects
+				}
+				if !pending {
+					pending = true
+					settle.Reset(streamSettleDelay)
+				}
+			case <-settle.C:
+				pending = false
+				if err := send(); err != nil {
+					return
+				}
+			case <-ping.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
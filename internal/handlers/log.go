@@ -0,0 +1,6 @@
+package handlers
+
+import "github.com/scottwalter/axeos-dashboard/internal/logger"
+
+// log is shared by the handlers; the middleware logs the requests themselves
+var log = logger.New(logger.ModuleHandler)
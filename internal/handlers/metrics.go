@@ -0,0 +1,691 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// maxImportBytes limits the size of an uploaded metrics export
+const maxImportBytes = 512 << 20
+
+const (
+	defaultHistoryWindow = 24 * time.Hour
+	defaultHistoryLimit  = 1000
+	maxHistoryLimit      = 10000
+
+	// Longest ranges served from raw samples and hourly rollups when the
+	// resolution is picked automatically; longer ones use daily rollups
+	rawHistoryMaxWindow    = 48 * time.Hour
+	hourlyHistoryMaxWindow = 31 * 24 * time.Hour
+)
+
+// historyResolution picks the resolution of a history request covering start to end
+func historyResolution(requested, metricType string, start, end time.Time) (string, error) {
+	switch requested {
+	case "", "auto":
+		window := end.Sub(start)
+		switch {
+		case metricType != "axeos" || window <= rawHistoryMaxWindow:
+			return database.ResolutionRaw, nil
+		case window <= hourlyHistoryMaxWindow:
+			return database.ResolutionHourly, nil
+		}
+		return database.ResolutionDaily, nil
+	case database.ResolutionRaw:
+		return requested, nil
+	case database.ResolutionHourly, database.ResolutionDaily:
+		if metricType != "axeos" {
+			return "", fmt.Errorf("%s resolution is only available for axeos metrics", requested)
+		}
+		return requested, nil
+	}
+	return "", fmt.Errorf("resolution must be auto, raw, hourly or daily")
+}
+
+// parseHistoryTime parses an RFC 3339 timestamp or Unix seconds
+func parseHistoryTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// writeDataCollectionDisabled responds when an endpoint needs the metrics database
+func writeDataCollectionDisabled(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  "error",
+		"message": "Data collection is disabled. Set data_collection_enabled to use historical metrics.",
+	})
+}
+
+// HandleMetricsExport handles GET /api/metrics/export[?instanceId=X&table=axeos|pool|node&format=csv|json|ndjson&start=&end=]
+// Without table, downloads the full metric history (or one device's history) as
+// a JSON document that /api/metrics/import accepts. With table, streams the rows
+// of that table as CSV, a JSON array or NDJSON. Both are written as rows are
+// read, so memory use does not grow with the history.
+func HandleMetricsExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	db := database.Instance()
+	if db == nil {
+		writeDataCollectionDisabled(w)
+		return
+	}
+
+	query := r.URL.Query()
+	instanceID := query.Get("instanceId")
+	if format := query.Get("format"); query.Get("table") != "" || format == "csv" || format == "ndjson" {
+		streamMetricsExport(w, r, db, instanceID)
+		return
+	}
+
+	streamMetricsDocument(w, r, db, instanceID)
+}
+
+// exportFlushRows is how many rows are written between flushes of a streamed export
+const exportFlushRows = 500
+
+// ndjsonContentType is the media type of newline-delimited JSON responses
+const ndjsonContentType = "application/x-ndjson"
+
+// rowStream writes the rows of a streamed response and flushes them every
+// exportFlushRows rows. Writes block while the client is slow to read, so
+// rows are read from the database no faster than they are delivered.
+type rowStream struct {
+	w    http.ResponseWriter
+	r    *http.Request
+	rc   *http.ResponseController
+	rows int
+}
+
+// newRowStream starts a streamed response. Large responses take longer than
+// the server's write timeout, so the deadline is lifted.
+func newRowStream(w http.ResponseWriter, r *http.Request) *rowStream {
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	return &rowStream{w: w, r: r, rc: rc}
+}
+
+// write sends raw bytes of the response
+func (s *rowStream) write(data []byte) error {
+	_, err := s.w.Write(data)
+	return err
+}
+
+// row counts a written row and flushes every exportFlushRows rows. It fails
+// once the client went away, which stops the database scan.
+func (s *rowStream) row(flush func()) error {
+	s.rows++
+	if s.rows%exportFlushRows == 0 {
+		if flush != nil {
+			flush()
+		}
+		s.rc.Flush()
+	}
+	return s.r.Context().Err()
+}
+
+// ndjson writes a value as one line of newline-delimited JSON
+func (s *rowStream) ndjson(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := s.write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.row(nil)
+}
+
+// jsonArray writes the rows emitted by stream as a JSON array
+func (s *rowStream) jsonArray(stream func(emit func(row interface{}) error) error) error {
+	if err := s.write([]byte("[")); err != nil {
+		return err
+	}
+	first := true
+	err := stream(func(row interface{}) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if !first {
+			data = append([]byte(","), data...)
+		}
+		first = false
+		if err := s.write(data); err != nil {
+			return err
+		}
+		return s.row(nil)
+	})
+	if err != nil {
+		return err
+	}
+	return s.write([]byte("]"))
+}
+
+// streamMetricsDocument writes the full metric history, or one device's, as
+// the document /api/metrics/import accepts, table by table as rows are read
+func streamMetricsDocument(w http.ResponseWriter, r *http.Request, db *database.Manager, instanceID string) {
+	filename := "axeos-metrics"
+	if instanceID != "" {
+		filename += "-" + instanceID
+	}
+	filename += "-" + time.Now().Format("20060102") + ".json"
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	stream := newRowStream(w, r)
+	exportedAt, _ := json.Marshal(time.Now())
+	instance, _ := json.Marshal(instanceID)
+
+	tables := func(table string) func(emit func(row interface{}) error) error {
+		return func(emit func(row interface{}) error) error {
+			// Pool and node history is not tied to a device
+			if instanceID != "" && table != database.TableAxeOS {
+				return nil
+			}
+			return db.StreamMetrics(table, instanceID, time.Time{}, time.Time{}, emit)
+		}
+	}
+	sections := []struct {
+		key    string
+		stream func(emit func(row interface{}) error) error
+	}{
+		{"axeosMetrics", tables(database.TableAxeOS)},
+		{"poolMetrics", tables(database.TablePool)},
+		{"nodeMetrics", tables(database.TableNode)},
+		{"events", func(emit func(row interface{}) error) error {
+			return db.StreamEvents(instanceID, func(event *database.Event) error { return emit(event) })
+		}},
+	}
+
+	// The fields of database.MetricsExport, with the arrays written as rows are read
+	err := stream.write([]byte(fmt.Sprintf(`{"version":%d,"exportedAt":%s`, database.MetricsExportVersion, exportedAt)))
+	if err == nil && instanceID != "" {
+		err = stream.write([]byte(fmt.Sprintf(`,"instanceId":%s`, instance)))
+	}
+	for _, section := range sections {
+		if err != nil {
+			break
+		}
+		if err = stream.write([]byte(fmt.Sprintf(`,%q:`, section.key))); err == nil {
+			err = stream.jsonArray(section.stream)
+		}
+	}
+	if err == nil {
+		err = stream.write([]byte("}\n"))
+	}
+	if err != nil {
+		// The status is already sent; the truncated download shows the failure
+		log.ErrorWithRequest(r, "Error streaming metrics export: %v", err)
+	}
+}
+
+// metricCSVHeader returns the CSV column names of a metrics table
+func metricCSVHeader(table string) []string {
+	switch table {
+	case database.TablePool:
+		return []string{"timestamp", "pool_id", "pool_name", "pool_hashrate", "pool_workers",
+			"network_hashrate", "network_difficulty", "last_block_time", "blocks_found"}
+	case database.TableNode:
+		return []string{"timestamp", "node_id", "node_name", "block_height", "connections",
+			"difficulty", "network_hashrate", "mempool_transactions", "mempool_bytes", "fee_fast", "fee_medium", "fee_slow"}
+	}
+	return []string{"timestamp", "instance_id", "instance_name", "hashrate", "temperature", "power",
+		"fan_speed", "best_diff", "shares_accepted", "shares_rejected", "frequency", "voltage", "core_voltage", "current"}
+}
+
+// metricCSVRecord formats a row from database.StreamMetrics as CSV fields
+func metricCSVRecord(metric interface{}) []string {
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	switch m := metric.(type) {
+	case *database.AxeOSMetric:
+		return []string{m.Timestamp.Format(time.RFC3339), m.InstanceID, m.InstanceName,
+			float(m.Hashrate), float(m.Temperature), float(m.Power), strconv.Itoa(m.FanSpeed), m.BestDiff,
+			strconv.Itoa(m.SharesAccepted), strconv.Itoa(m.SharesRejected), strconv.Itoa(m.Frequency),
+			float(m.Voltage), float(m.CoreVoltage), float(m.Current)}
+	case *database.PoolMetric:
+		lastBlock := ""
+		if m.LastBlockTime != nil {
+			lastBlock = m.LastBlockTime.Format(time.RFC3339)
+		}
+		return []string{m.Timestamp.Format(time.RFC3339), m.PoolID, m.PoolName,
+			float(m.PoolHashrate), strconv.Itoa(m.PoolWorkers), float(m.NetworkHashrate),
+			float(m.NetworkDifficulty), lastBlock, strconv.Itoa(m.BlocksFound)}
+	case *database.NodeMetric:
+		return []string{m.Timestamp.Format(time.RFC3339), m.NodeID, m.NodeName,
+			strconv.Itoa(m.BlockHeight), strconv.Itoa(m.Connections), float(m.Difficulty), float(m.NetworkHashrate),
+			strconv.Itoa(m.MempoolTransactions), strconv.FormatInt(m.MempoolBytes, 10),
+			float(m.FeeFast), float(m.FeeMedium), float(m.FeeSlow)}
+	}
+	return nil
+}
+
+// streamMetricsExport writes one metrics table as CSV, a JSON array or NDJSON, row by row
+func streamMetricsExport(w http.ResponseWriter, r *http.Request, db *database.Manager, instanceID string) {
+	query := r.URL.Query()
+	badRequest := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+	}
+
+	table := query.Get("table")
+	switch table {
+	case database.TableAxeOS, database.TablePool, database.TableNode:
+	case "":
+		badRequest("table is required for CSV and NDJSON exports")
+		return
+	default:
+		badRequest("table must be axeos, pool or node")
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "csv" && format != "json" && format != "ndjson" {
+		badRequest("format must be csv, json or ndjson")
+		return
+	}
+
+	// The whole history by default
+	start, end := time.Unix(0, 0), time.Now()
+	if value := query.Get("start"); value != "" {
+		t, err := parseHistoryTime(value)
+		if err != nil {
+			badRequest("Invalid start time: use RFC 3339 or Unix seconds")
+			return
+		}
+		start = t
+	}
+	if value := query.Get("end"); value != "" {
+		t, err := parseHistoryTime(value)
+		if err != nil {
+			badRequest("Invalid end time: use RFC 3339 or Unix seconds")
+			return
+		}
+		end = t
+	}
+	if start.After(end) {
+		badRequest("start must be before end")
+		return
+	}
+	// Stored timestamps are in local time, so compare in local time as well
+	start, end = start.Local(), end.Local()
+
+	filename := "axeos-metrics-" + table
+	if instanceID != "" {
+		filename += "-" + instanceID
+	}
+	filename += "-" + time.Now().Format("20060102") + "." + format
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	case "ndjson":
+		w.Header().Set("Content-Type", ndjsonContentType)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	stream := newRowStream(w, r)
+	rows := func(emit func(row interface{}) error) error {
+		return db.StreamMetrics(table, instanceID, start, end, emit)
+	}
+	var err error
+	switch format {
+	case "csv":
+		csvWriter := csv.NewWriter(w)
+		csvWriter.Write(metricCSVHeader(table))
+		err = rows(func(metric interface{}) error {
+			if err := csvWriter.Write(metricCSVRecord(metric)); err != nil {
+				return err
+			}
+			return stream.row(csvWriter.Flush)
+		})
+		csvWriter.Flush()
+	case "ndjson":
+		err = rows(stream.ndjson)
+	default:
+		if err = stream.jsonArray(rows); err == nil {
+			err = stream.write([]byte("\n"))
+		}
+	}
+	if err != nil {
+		// The status is already sent; the truncated download shows the failure
+		log.ErrorWithRequest(r, "Error streaming %s metrics export: %v", table, err)
+	}
+}
+
+// HandleMetricsImport handles POST /api/metrics/import
+// Imports a document produced by /api/metrics/export, skipping rows that already exist
+func HandleMetricsImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	db := database.Instance()
+	if db == nil {
+		writeDataCollectionDisabled(w)
+		return
+	}
+
+	var export database.MetricsExport
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err := decoder.Decode(&export); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "error",
+			"message": "Invalid export document: " + err.Error(),
+		})
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := db.ImportMetrics(&export)
+	if err != nil {
+		log.ErrorWithRequest(r, "Error importing metrics: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "success",
+		"message":  "Metrics imported successfully",
+		"inserted": result.Inserted,
+		"skipped":  result.Skipped,
+	})
+}
+
+// HandleMetricsHistory handles GET /api/metrics/history?instanceId=X[&type=axeos|pool|node&start=&end=&limit=&resolution=&units=C|F&hashrateUnit=TH/s&format=json|ndjson]
+// Returns stored metrics for one device, pool or node, newest first. start and end accept
+// RFC 3339 or Unix seconds and default to the last 24 hours. Long AxeOS ranges are served
+// from hourly or daily rollups unless a resolution is requested. Failed collections in
+// the range are listed under errors. Hashrates are in GH/s for devices and H/s
+// for pools and nodes unless another hashrateUnit is requested. With
+// format=ndjson the rows are streamed oldest first, one per line, as they are
+// read; see streamMetricsHistory.
+func HandleMetricsHistory(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+		}
+
+		instanceID := query.Get("instanceId")
+		if instanceID == "" {
+			badRequest("instanceId is required")
+			return
+		}
+
+		metricType := query.Get("type")
+		if metricType == "" {
+			metricType = "axeos"
+		}
+
+		end := time.Now()
+		if value := query.Get("end"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid end time: use RFC 3339 or Unix seconds")
+				return
+			}
+			end = t
+		}
+		start := end.Add(-defaultHistoryWindow)
+		if value := query.Get("start"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid start time: use RFC 3339 or Unix seconds")
+				return
+			}
+			start = t
+		}
+		if start.After(end) {
+			badRequest("start must be before end")
+			return
+		}
+
+		format := query.Get("format")
+		if format != "" && format != "json" && format != "ndjson" {
+			badRequest("format must be json or ndjson")
+			return
+		}
+		streamed := format == "ndjson"
+
+		// NDJSON is streamed, so it returns every row in the range unless limited
+		limit := defaultHistoryLimit
+		if streamed {
+			limit = 0
+		}
+		if value := query.Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || (!streamed && n > maxHistoryLimit) {
+				badRequest(fmt.Sprintf("limit must be between 1 and %d", maxHistoryLimit))
+				return
+			}
+			limit = n
+		}
+
+		resolution, err := historyResolution(query.Get("resolution"), metricType, start, end)
+		if err != nil {
+			badRequest(err.Error())
+			return
+		}
+
+		nativeHashrateUnit := config.UnitHs
+		if metricType == "axeos" {
+			nativeHashrateUnit = config.UnitGHs
+		}
+		hashUnit, ok := hashrateUnit(r, nativeHashrateUnit)
+		if !ok {
+			badRequest("hashrateUnit must be H/s, KH/s, MH/s, GH/s, TH/s, PH/s or EH/s")
+			return
+		}
+		hashrate := func(value float64) float64 {
+			return config.ConvertHashrate(value, nativeHashrateUnit, hashUnit)
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		// Stored timestamps are in local time, so compare in local time as well
+		start, end = start.Local(), end.Local()
+		unit := temperatureUnit(r, cfg)
+
+		if streamed {
+			switch metricType {
+			case database.TableAxeOS, database.TablePool, database.TableNode:
+			default:
+				badRequest("type must be axeos, pool or node")
+				return
+			}
+			w.Header().Set("X-Temperature-Unit", unit)
+			w.Header().Set("X-Hashrate-Unit", hashUnit)
+			streamMetricsHistory(w, r, db, metricType, instanceID, resolution, start, end, limit, unit, hashrate)
+			return
+		}
+
+		var metrics interface{}
+		var count int
+		switch {
+		case resolution != database.ResolutionRaw:
+			var rows []*database.AxeOSRollup
+			rows, err = db.GetAxeOSRollups(resolution, instanceID, start, end, limit)
+			if rows == nil {
+				rows = []*database.AxeOSRollup{}
+			}
+			for _, row := range rows {
+				row.AvgTemperature = config.CelsiusTo(unit, row.AvgTemperature)
+				row.MinTemperature = config.CelsiusTo(unit, row.MinTemperature)
+				row.MaxTemperature = config.CelsiusTo(unit, row.MaxTemperature)
+				row.AvgHashrate = hashrate(row.AvgHashrate)
+				row.MinHashrate = hashrate(row.MinHashrate)
+				row.MaxHashrate = hashrate(row.MaxHashrate)
+			}
+			metrics, count = rows, len(rows)
+		case metricType == "axeos":
+			var rows []*database.AxeOSMetric
+			rows, err = db.GetAxeOSMetrics(instanceID, start, end, limit)
+			if rows == nil {
+				rows = []*database.AxeOSMetric{}
+			}
+			for _, row := range rows {
+				row.Temperature = config.CelsiusTo(unit, row.Temperature)
+				row.Hashrate = hashrate(row.Hashrate)
+			}
+			metrics, count = rows, len(rows)
+		case metricType == "pool":
+			var rows []*database.PoolMetric
+			rows, err = db.GetPoolMetrics(instanceID, start, end, limit)
+			if rows == nil {
+				rows = []*database.PoolMetric{}
+			}
+			for _, row := range rows {
+				row.PoolHashrate = hashrate(row.PoolHashrate)
+				row.NetworkHashrate = hashrate(row.NetworkHashrate)
+			}
+			metrics, count = rows, len(rows)
+		case metricType == "node":
+			var rows []*database.NodeMetric
+			rows, err = db.GetNodeMetrics(instanceID, start, end, limit)
+			if rows == nil {
+				rows = []*database.NodeMetric{}
+			}
+			for _, row := range rows {
+				row.NetworkHashrate = hashrate(row.NetworkHashrate)
+			}
+			metrics, count = rows, len(rows)
+		default:
+			badRequest("type must be axeos, pool or node")
+			return
+		}
+
+		// Failed collections in the range explain gaps in the metrics
+		var collectionErrors []*database.CollectionError
+		if err == nil {
+			collectionErrors, err = db.GetCollectionErrors(metricType, instanceID, start, end, limit)
+		}
+		if collectionErrors == nil {
+			collectionErrors = []*database.CollectionError{}
+		}
+
+		if err != nil {
+			log.ErrorWithRequest(r, "Error querying %s history for %s: %v", metricType, instanceID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"type":            metricType,
+			"instanceId":      instanceID,
+			"resolution":      resolution,
+			"start":           start,
+			"end":             end,
+			"temperatureUnit": unit,
+			"hashrateUnit":    hashUnit,
+			"count":           count,
+			"metrics":         metrics,
+			"errors":          collectionErrors,
+		})
+	}
+}
+
+// errStreamLimit stops a streamed history once it reached its limit
+var errStreamLimit = errors.New("stream limit reached")
+
+// streamMetricsHistory writes the stored metrics or rollups of one device,
+// pool or node as NDJSON, oldest first, converted to the requested units. A
+// limit of 0 streams every row in the range. The units are sent in the
+// X-Temperature-Unit and X-Hashrate-Unit headers; failed collections are
+// left out.
+func streamMetricsHistory(w http.ResponseWriter, r *http.Request, db *database.Manager, metricType, instanceID, resolution string,
+	start, end time.Time, limit int, unit string, hashrate func(float64) float64) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	stream := newRowStream(w, r)
+	emit := func(row interface{}) error {
+		if limit > 0 && stream.rows >= limit {
+			return errStreamLimit
+		}
+		return stream.ndjson(row)
+	}
+
+	var err error
+	if resolution != database.ResolutionRaw {
+		err = db.StreamAxeOSRollups(resolution, instanceID, start, end, func(row *database.AxeOSRollup) error {
+			row.AvgTemperature = config.CelsiusTo(unit, row.AvgTemperature)
+			row.MinTemperature = config.CelsiusTo(unit, row.MinTemperature)
+			row.MaxTemperature = config.CelsiusTo(unit, row.MaxTemperature)
+			row.AvgHashrate = hashrate(row.AvgHashrate)
+			row.MinHashrate = hashrate(row.MinHashrate)
+			row.MaxHashrate = hashrate(row.MaxHashrate)
+			return emit(row)
+		})
+	} else {
+		err = db.StreamMetrics(metricType, instanceID, start, end, func(metric interface{}) error {
+			switch row := metric.(type) {
+			case *database.AxeOSMetric:
+				row.Temperature = config.CelsiusTo(unit, row.Temperature)
+				row.Hashrate = hashrate(row.Hashrate)
+			case *database.PoolMetric:
+				row.PoolHashrate = hashrate(row.PoolHashrate)
+				row.NetworkHashrate = hashrate(row.NetworkHashrate)
+			case *database.NodeMetric:
+				row.NetworkHashrate = hashrate(row.NetworkHashrate)
+			}
+			return emit(metric)
+		})
+	}
+	if err != nil && !errors.Is(err, errStreamLimit) {
+		// The status is already sent; the truncated response shows the failure
+		log.ErrorWithRequest(r, "Error streaming %s history for %s: %v", metricType, instanceID, err)
+	}
+}
@@ -2,9 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/migration"
 )
 
+// maxLegacyImportBytes bounds the legacy configuration files uploaded during setup
+const maxLegacyImportBytes = 4 << 20
+
 // MigrationStatusResponse represents the response for migration status
 type MigrationStatusResponse struct {
 	Success bool                   `json:"success"`
@@ -12,54 +18,125 @@ type MigrationStatusResponse struct {
 	Message string                 `json:"message,omitempty"`
 }
 
+// LegacyImportRequest is the setup page's upload of the Node.js dashboard's
+// configuration files, keyed by file name
+type LegacyImportRequest struct {
+	Files map[string]json.RawMessage `json:"files"`
+}
+
 // HandleMigrationStatus handles GET /api/migration/status
-// Returns empty migration status (Go version doesn't need migration)
-func HandleMigrationStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// Reports whether the configuration was imported from the Node.js dashboard,
+// with the files written and the keys renamed or dropped
+func HandleMigrationStatus(configDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			response := MigrationStatusResponse{
+				Success: false,
+				Message: "Method not allowed",
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		status, err := migration.ReadStatus(configDir)
+		if err != nil {
+			log.ErrorWithRequest(r, "Error reading migration status: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(MigrationStatusResponse{Success: false, Message: err.Error()})
+			return
+		}
+
+		data := map[string]interface{}{}
+		encoded, _ := json.Marshal(status)
+		json.Unmarshal(encoded, &data)
 		response := MigrationStatusResponse{
-			Success: false,
-			Message: "Method not allowed",
+			Success: true,
+			Data:    data,
 		}
+
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	// Go version doesn't have migration status - return empty/no migration needed
-	response := MigrationStatusResponse{
-		Success: true,
-		Data: map[string]interface{}{
-			"migrated": false,
-		},
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
 }
 
 // HandleMigrationClear handles POST /api/migration/clear
-// No-op for Go version but returns success for compatibility
-func HandleMigrationClear(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// Forgets the last import, so the status reports no migration
+func HandleMigrationClear(configDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			response := MigrationStatusResponse{
+				Success: false,
+				Message: "Method not allowed",
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		if err := migration.ClearStatus(configDir); err != nil {
+			log.ErrorWithRequest(r, "Error clearing migration status: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(MigrationStatusResponse{Success: false, Message: err.Error()})
+			return
+		}
+
 		response := MigrationStatusResponse{
-			Success: false,
-			Message: "Method not allowed",
+			Success: true,
+			Message: "Migration status cleared",
 		}
+
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
-		return
 	}
+}
 
-	// No-op for Go version - just return success
-	response := MigrationStatusResponse{
-		Success: true,
-		Message: "Migration status cleared",
-	}
+// HandleBootstrapImport handles POST /bootstrap/import
+// Creates the configuration from the uploaded files of the Node.js dashboard
+// instead of the setup form
+func HandleBootstrapImport(configDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		if r.Method != http.MethodPost {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+		var req LegacyImportRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLegacyImportBytes)).Decode(&req); err != nil {
+			writeJSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": "Invalid request format"})
+			return
+		}
+		files := make(map[string][]byte, len(req.Files))
+		for name, data := range req.Files {
+			files[name] = data
+		}
+
+		status, err := migration.Import(configDir, files, "upload")
+		if errors.Is(err, migration.ErrAlreadyConfigured) {
+			writeJSON(http.StatusConflict, map[string]interface{}{"success": false, "message": err.Error()})
+			return
+		} else if err != nil {
+			log.ErrorWithRequest(r, "Error importing legacy configuration: %v", err)
+			writeJSON(http.StatusBadRequest, map[string]interface{}{"success": false, "message": err.Error()})
+			return
+		}
+
+		log.InfoWithRequest(r, "Legacy configuration imported: %v", status.Files)
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Configuration imported successfully! Redirecting to dashboard...",
+			"data":    status,
+		})
+	}
 }
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// MOTDMessage is a message of the day with its state for the requesting user
+type MOTDMessage struct {
+	config.Message
+	Active    bool `json:"active"`
+	Dismissed bool `json:"dismissed,omitempty"`
+}
+
+// newMessageID returns a random message id
+func newMessageID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// findMessage returns the index of the message with an id, or -1
+func findMessage(messages []config.Message, id string) int {
+	for i, m := range messages {
+		if m.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// HandleMOTD handles /api/motd and /api/motd/{id}
+//
+//	GET    /api/motd               - messages scheduled now that the user has not dismissed (?all=true lists every message, admins only)
+//	POST   /api/motd               - create a message (admin)
+//	GET    /api/motd/{id}          - one message
+//	PUT    /api/motd/{id}          - replace a message (admin)
+//	DELETE /api/motd/{id}          - delete a message (admin)
+//	POST   /api/motd/{id}/dismiss  - hide a message for the current user
+//
+// Messages are stored in config.json under "motd"; dismissals are kept per
+// user in motd_dismissals.json.
+func HandleMOTD(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		methodNotAllowed := func() {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		}
+		// requireAdmin answers 403 for viewers, like middleware.RequireAdmin
+		requireAdmin := func() bool {
+			if middleware.IsAdmin(r) {
+				return true
+			}
+			log.WarnWithRequest(r, "Forbidden: %s %s requires the admin role", r.Method, r.URL.Path)
+			writeJSON(http.StatusForbidden, map[string]string{"message": "Forbidden: admin role required"})
+			return false
+		}
+
+		// save writes the messages to config.json, reporting validation errors as 400
+		save := func(messages []config.Message) bool {
+			if err := cfgManager.UpdateConfig(map[string]interface{}{"motd": messages}); err != nil {
+				status := http.StatusInternalServerError
+				var validationErr *config.ValidationError
+				if errors.As(err, &validationErr) {
+					status = http.StatusBadRequest
+				} else {
+					log.ErrorWithRequest(r, "Error saving messages of the day: %v", err)
+				}
+				writeJSON(status, map[string]string{"status": "error", "message": err.Error()})
+				return false
+			}
+			return true
+		}
+
+		// decode reads and validates a message from the request body
+		decode := func(id string, createdAt time.Time) (config.Message, bool) {
+			var m config.Message
+			if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+				return m, false
+			}
+			defer r.Body.Close()
+
+			m.ID, m.CreatedAt = id, createdAt
+			if err := m.Validate(); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": err.Error()})
+				return m, false
+			}
+			return m, true
+		}
+
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+
+		messages := slices.Clone(cfg.MOTD)
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/motd"), "/")
+		id, action, _ := strings.Cut(path, "/")
+
+		if id == "" {
+			switch r.Method {
+			case http.MethodGet:
+				all := r.URL.Query().Get("all") == "true"
+				if all && !requireAdmin() {
+					return
+				}
+				dismissed, err := config.DismissedMessages(cfgManager.GetConfigDir(), username)
+				if err != nil {
+					log.WarnWithRequest(r, "Ignoring message dismissals: %v", err)
+				}
+
+				now := time.Now()
+				list := []MOTDMessage{}
+				for _, m := range messages {
+					_, isDismissed := dismissed[m.ID]
+					message := MOTDMessage{Message: m, Active: m.Active(now), Dismissed: isDismissed && !m.Persistent}
+					if all || (message.Active && !message.Dismissed) {
+						list = append(list, message)
+					}
+				}
+				writeJSON(http.StatusOK, map[string]interface{}{"messages": list})
+			case http.MethodPost:
+				if !requireAdmin() {
+					return
+				}
+				m, ok := decode(newMessageID(), time.Now())
+				if !ok {
+					return
+				}
+				if save(append(messages, m)) {
+					log.InfoWithRequest(r, "Message of the day %s created", m.ID)
+					writeJSON(http.StatusCreated, m)
+				}
+			default:
+				methodNotAllowed()
+			}
+			return
+		}
+
+		index := findMessage(messages, id)
+		if index < 0 {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Message not found"})
+			return
+		}
+
+		if action == "dismiss" {
+			if r.Method != http.MethodPost {
+				methodNotAllowed()
+				return
+			}
+			if messages[index].Persistent {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": "This message cannot be dismissed"})
+				return
+			}
+			if err := config.DismissMessage(cfgManager.GetConfigDir(), username, id, messages); err != nil {
+				log.ErrorWithRequest(r, "Error saving message dismissal: %v", err)
+				writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			writeJSON(http.StatusOK, map[string]string{"status": "success", "message": "Message dismissed"})
+			return
+		}
+		if action != "" {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Not found"})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(http.StatusOK, messages[index])
+		case http.MethodPut:
+			if !requireAdmin() {
+				return
+			}
+			m, ok := decode(id, messages[index].CreatedAt)
+			if !ok {
+				return
+			}
+			messages[index] = m
+			if save(messages) {
+				writeJSON(http.StatusOK, m)
+			}
+		case http.MethodDelete:
+			if !requireAdmin() {
+				return
+			}
+			if save(slices.Delete(messages, index, index+1)) {
+				log.InfoWithRequest(r, "Message of the day %s deleted", id)
+				writeJSON(http.StatusOK, map[string]string{"status": "success", "message": "Message deleted"})
+			}
+		default:
+			methodNotAllowed()
+		}
+	}
+}
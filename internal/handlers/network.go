@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// maxStoredAdjustments is how many past difficulty adjustments the milestones response lists
+const maxStoredAdjustments = 10
+
+// maxNetworkHistoryPoints caps the fleet buckets of a network history response;
+// longer ranges use wider buckets
+const maxNetworkHistoryPoints = 500
+
+// NetworkSharePoint is the fleet hashrate of one bucket next to the network it mines on
+type NetworkSharePoint struct {
+	Timestamp       time.Time `json:"timestamp"`       // Start of the bucket
+	FleetHashrate   float64   `json:"fleetHashrate"`   // GH/s
+	Devices         int       `json:"devices"`         // Devices with samples in the bucket
+	Difficulty      float64   `json:"difficulty"`      // Network difficulty, 0 when no sample precedes the bucket
+	NetworkHashrate float64   `json:"networkHashrate"` // H/s
+	SharePercent    *float64  `json:"sharePercent"`    // Fleet share of the network hashrate, nil when unknown
+}
+
+// NetworkHistory is the response of GET /api/network/history
+type NetworkHistory struct {
+	NodeID        string                    `json:"nodeId"`
+	Start         time.Time                 `json:"start"`
+	End           time.Time                 `json:"end"`
+	BucketSeconds int                       `json:"bucketSeconds"`
+	Network       []*database.NetworkMetric `json:"network"`
+	Fleet         []NetworkSharePoint       `json:"fleet"`
+}
+
+// networkHistoryBucket returns the fleet bucket width for a range: the
+// collection interval, widened so the range has at most maxNetworkHistoryPoints buckets
+func networkHistoryBucket(cfg *config.Config, start, end time.Time) time.Duration {
+	bucket := time.Duration(cfg.CollectionIntervalSeconds) * time.Second
+	if bucket <= 0 {
+		bucket = time.Minute
+	}
+	if minimum := end.Sub(start) / maxNetworkHistoryPoints; bucket < minimum {
+		bucket = minimum.Truncate(time.Second) + time.Second
+	}
+	return bucket
+}
+
+// pairNetworkShare places each fleet bucket next to the last network sample
+// taken before the bucket ended. Difficulty and network hashrate change
+// slowly, so the last known values stand for the whole bucket. network must
+// be sorted oldest first.
+func pairNetworkShare(fleet []*database.FleetHashrate, network []*database.NetworkMetric, bucket time.Duration) []NetworkSharePoint {
+	points := make([]NetworkSharePoint, 0, len(fleet))
+	for _, f := range fleet {
+		point := NetworkSharePoint{
+			Timestamp:     f.Timestamp,
+			FleetHashrate: f.Hashrate,
+			Devices:       f.Devices,
+		}
+
+		end := f.Timestamp.Add(bucket)
+		i := sort.Search(len(network), func(i int) bool { return !network[i].Timestamp.Before(end) })
+		if i > 0 {
+			sample := network[i-1]
+			point.Difficulty = sample.Difficulty
+			point.NetworkHashrate = sample.NetworkHashrate
+			if sample.NetworkHashrate > 0 {
+				share := f.Hashrate * 1e9 / sample.NetworkHashrate * 100 // Fleet GH/s to H/s
+				point.SharePercent = &share
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// firstConfiguredNode returns the first node in rpcConfig.json, or "" when there is none
+func firstConfiguredNode(cfgManager *config.Manager) string {
+	rpcClient := services.NewRPCClient(cfgManager.GetConfigDir())
+	if err := rpcClient.LoadConfig(); err != nil {
+		return ""
+	}
+	if nodes := rpcClient.GetConfiguredNodes(); len(nodes) > 0 {
+		return nodes[0]
+	}
+	return ""
+}
+
+// HandleNetworkHistory handles GET /api/network/history[?nodeId=X&start=&end=]
+// Returns the network difficulty and hashrate collected from a crypto node (the
+// first configured one by default) and the fleet hashrate over the same range,
+// with the fleet's share of the network for overlaying on hashrate charts.
+// start and end accept RFC 3339 or Unix seconds and default to the last 24 hours.
+func HandleNetworkHistory(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		badRequest := func(message string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": message})
+		}
+
+		nodeID := query.Get("nodeId")
+		if nodeID == "" {
+			nodeID = firstConfiguredNode(cfgManager)
+			if nodeID == "" {
+				badRequest("nodeId is required when no crypto node is configured")
+				return
+			}
+		}
+
+		end := time.Now()
+		if value := query.Get("end"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid end time: use RFC 3339 or Unix seconds")
+				return
+			}
+			end = t
+		}
+		start := end.Add(-defaultHistoryWindow)
+		if value := query.Get("start"); value != "" {
+			t, err := parseHistoryTime(value)
+			if err != nil {
+				badRequest("Invalid start time: use RFC 3339 or Unix seconds")
+				return
+			}
+			start = t
+		}
+		if !start.Before(end) {
+			badRequest("start must be before end")
+			return
+		}
+		if end.Sub(start) > hourlyHistoryMaxWindow {
+			badRequest(fmt.Sprintf("The range may span at most %d days", int(hourlyHistoryMaxWindow.Hours()/24)))
+			return
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		// Stored timestamps are local time; compare in the same zone
+		start, end = start.Local(), end.Local()
+		bucket := networkHistoryBucket(cfg, start, end)
+
+		// Include the sample before the range so the first buckets have network values
+		network, err := db.GetNetworkMetrics(nodeID, start.Add(-time.Hour), end, maxHistoryLimit)
+		var fleet []*database.FleetHashrate
+		if err == nil {
+			fleet, err = db.GetFleetHashrate(start, end, bucket)
+		}
+		if err != nil {
+			log.ErrorWithRequest(r, "Error loading network history for %s: %v", nodeID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		response := NetworkHistory{
+			NodeID:        nodeID,
+			Start:         start,
+			End:           end,
+			BucketSeconds: int(bucket.Seconds()),
+			Network:       []*database.NetworkMetric{},
+			Fleet:         pairNetworkShare(fleet, network, bucket),
+		}
+		for _, sample := range network {
+			if !sample.Timestamp.Before(start) {
+				response.Network = append(response.Network, sample)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// HandleNetworkMilestones handles GET /api/network/milestones[?nodeId=X]
+// Returns the halving countdown and the progress, ETA and estimated change of
+// the next difficulty adjustment from a crypto node (the first configured one
+// by default), with the last stored retarget results when data collection is enabled.
+func HandleNetworkMilestones(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		rpcClient := services.NewRPCClient(cfgManager.GetConfigDir())
+		rpcClient.SetOutboundConfig(cfg)
+		if err := rpcClient.LoadConfig(); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "No crypto node is configured"})
+			return
+		}
+
+		nodeID := r.URL.Query().Get("nodeId")
+		nodes := rpcClient.GetConfiguredNodes()
+		if nodeID == "" && len(nodes) > 0 {
+			nodeID = nodes[0]
+		}
+		found := false
+		for _, node := range nodes {
+			found = found || node == nodeID
+		}
+		if !found {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Crypto node \"%s\" not found in configuration.", nodeID)})
+			return
+		}
+
+		milestones, err := services.GetNetworkMilestones(rpcClient, nodeID)
+		if err != nil {
+			log.ErrorWithRequest(r, "Error reading milestones from %s: %v", nodeID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		adjustments := []*database.DifficultyAdjustment{}
+		if db := database.Instance(); db != nil {
+			stored, err := db.GetDifficultyAdjustments(nodeID, maxStoredAdjustments)
+			if err != nil {
+				log.ErrorWithRequest(r, "Error reading difficulty adjustments of %s: %v", nodeID, err)
+			}
+			adjustments = append(adjustments, stored...)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"milestones":  milestones,
+			"adjustments": adjustments,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// HandleNodeDisplayFields handles /api/nodes/display-fields and /api/nodes/display-fields/{type}
+//
+//	GET    /api/nodes/display-fields         - card fields of every built-in or customized node type
+//	GET    /api/nodes/display-fields/{type}  - card fields of one node type (unknown types get the generic set)
+//	PUT    /api/nodes/display-fields/{type}  - customize the card fields of a node type
+//	DELETE /api/nodes/display-fields/{type}  - return a node type to its built-in fields
+//
+// Customizations are stored in config.json under "node_display_fields".
+func HandleNodeDisplayFields(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		methodNotAllowed := func() {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		}
+
+		// save writes the customizations to config.json, reporting validation errors as 400
+		save := func(byType map[string]config.NodeDisplayFields) bool {
+			if err := cfgManager.UpdateConfig(map[string]interface{}{"node_display_fields": byType}); err != nil {
+				status := http.StatusInternalServerError
+				var validationErr *config.ValidationError
+				if errors.As(err, &validationErr) {
+					status = http.StatusBadRequest
+				} else {
+					log.ErrorWithRequest(r, "Error saving node display fields: %v", err)
+				}
+				writeJSON(status, map[string]string{"status": "error", "message": err.Error()})
+				return false
+			}
+			return true
+		}
+
+		// entry describes the effective fields of a node type
+		entry := func(nodeType string) map[string]interface{} {
+			fields, customized := cfg.NodeDisplayFields[nodeType]
+			if !customized {
+				fields = services.DefaultNodeDisplayFields(nodeType)
+			}
+			return map[string]interface{}{
+				"nodeType":   nodeType,
+				"customized": customized,
+				"fields":     fields,
+			}
+		}
+
+		customizations := maps.Clone(cfg.NodeDisplayFields)
+		if customizations == nil {
+			customizations = map[string]config.NodeDisplayFields{}
+		}
+		nodeType := config.NormalizeNodeType(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/nodes/display-fields"), "/"))
+
+		if nodeType == "" {
+			if r.Method != http.MethodGet {
+				methodNotAllowed()
+				return
+			}
+			types := services.NodeDisplayFieldTypes()
+			for t := range customizations {
+				if !slices.Contains(types, t) {
+					types = append(types, t)
+				}
+			}
+			slices.Sort(types)
+			list := []map[string]interface{}{}
+			for _, t := range types {
+				list = append(list, entry(t))
+			}
+			writeJSON(http.StatusOK, map[string]interface{}{"nodeTypes": list})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(http.StatusOK, entry(nodeType))
+		case http.MethodPut:
+			var fields config.NodeDisplayFields
+			if err := json.NewDecoder(r.Body).Decode(&fields); err != nil || fields == nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Request body must be a list of field categories"})
+				return
+			}
+			defer r.Body.Close()
+
+			customizations[nodeType] = fields
+			if save(customizations) {
+				writeJSON(http.StatusOK, map[string]interface{}{"nodeType": nodeType, "customized": true, "fields": fields})
+			}
+		case http.MethodDelete:
+			if _, ok := customizations[nodeType]; !ok {
+				writeJSON(http.StatusNotFound, map[string]string{"message": "Node type has no customized fields"})
+				return
+			}
+			delete(customizations, nodeType)
+			if save(customizations) {
+				writeJSON(http.StatusOK, map[string]interface{}{
+					"nodeType":   nodeType,
+					"customized": false,
+					"fields":     services.DefaultNodeDisplayFields(nodeType),
+				})
+			}
+		default:
+			methodNotAllowed()
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+)
+
+// NotificationTestRequest is the optional body of POST /api/notifications/test
+type NotificationTestRequest struct {
+	Channel string `json:"channel"` // Empty means every channel
+}
+
+// HandleNotificationTest handles POST /api/notifications/test
+// Sends a test message to one or all configured notification channels and reports the outcome of each
+func HandleNotificationTest(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		var req NotificationTestRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Invalid JSON in request body"})
+				return
+			}
+			defer r.Body.Close()
+		}
+
+		results, err := notifications.GetDispatcher(cfgManager).SendTest(req.Channel)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+			return
+		}
+
+		status := "success"
+		for _, result := range results {
+			if result.Status != "sent" {
+				status = "error"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "results": results})
+	}
+}
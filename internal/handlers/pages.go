@@ -25,10 +25,7 @@ func HandleDashboard(cfgManager *config.Manager, publicDir string) http.HandlerF
 
 		htmlContent, err := os.ReadFile(dashboardHTMLPath)
 		if err != nil {
-			fmt.Printf("Error reading dashboard.html: %v\n", err)
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(http.StatusInternalServerError)
-			fmt.Fprintf(w, "<h1>Error</h1><p>An internal server error occurred while preparing the dashboard.</p><p>Details: %s</p>", err.Error())
+			writeAssetError(w, "html/dashboard.html", err)
 			return
 		}
 
@@ -48,6 +45,7 @@ func HandleDashboard(cfgManager *config.Manager, publicDir string) http.HandlerF
 		html = strings.ReplaceAll(html, "<!-- TITLE -->", title)
 		html = strings.ReplaceAll(html, "<!-- TIMESTAMP -->", timestamp)
 		html = strings.ReplaceAll(html, "<!-- CURRENT_YEAR -->", currentYear)
+		html = strings.ReplaceAll(html, "<!-- BASE_PATH -->", middleware.BasePath(r))
 		html = strings.ReplaceAll(html, "<!-- VERSION -->", version)
 
 		// Handle config outdated warning
@@ -62,7 +60,7 @@ func HandleDashboard(cfgManager *config.Manager, publicDir string) http.HandlerF
 		if cfg != nil && !cfg.DisableAuthentication {
 			user := middleware.GetUserFromContext(r)
 			if user != nil {
-				loginInfo = fmt.Sprintf("<p>Username: %s</p>", user.Username)
+				loginInfo = fmt.Sprintf("<p>Username: %s (%s)</p>", user.Username, user.Role)
 			}
 		}
 		html = strings.ReplaceAll(html, "<!-- LOGIN INFO -->", loginInfo)
@@ -81,10 +79,7 @@ func HandleLoginPage(cfgManager *config.Manager, publicDir string) http.HandlerF
 
 		htmlContent, err := os.ReadFile(loginHTMLPath)
 		if err != nil {
-			fmt.Printf("Error serving login page: %v\n", err)
-			w.Header().Set("Content-Type", "text/plain")
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("Internal Server Error"))
+			writeAssetError(w, "html/login.html", err)
 			return
 		}
 
@@ -102,6 +97,7 @@ func HandleLoginPage(cfgManager *config.Manager, publicDir string) http.HandlerF
 		html = strings.ReplaceAll(html, "<!-- TITLE -->", title)
 		html = strings.ReplaceAll(html, "<!-- VERSION -->", version)
 		html = strings.ReplaceAll(html, "<!-- CURRENT_YEAR -->", currentYear)
+		html = strings.ReplaceAll(html, "<!-- BASE_PATH -->", middleware.BasePath(r))
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
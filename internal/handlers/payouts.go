@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+const (
+	defaultPayoutLimit = 50
+	maxPayoutLimit     = 1000
+)
+
+// PayoutAddressTotal is the cumulative amount received by a payout address
+type PayoutAddressTotal struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Source  string `json:"source"`
+	database.PayoutTotal
+	TotalBTC float64 `json:"totalBtc"`
+}
+
+// PayoutEntry is a payment with the name of the payout address it was paid to
+type PayoutEntry struct {
+	Name string `json:"name"`
+	*database.Payout
+}
+
+// HandlePayouts handles GET /api/payouts
+// Lists the payout addresses with the payments they received in total and
+// the latest payments, newest first. Optional query parameters: address (a
+// payout address name) and limit (default 50).
+func HandlePayouts(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		if r.Method != http.MethodGet {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		addresses := cfg.PayoutAddresses
+		if name := query.Get("address"); name != "" {
+			payout := cfg.FindPayoutAddress(name)
+			if payout == nil {
+				writeJSON(http.StatusNotFound, map[string]string{
+					"message": fmt.Sprintf("Payout address \"%s\" not found in configuration.", name),
+				})
+				return
+			}
+			addresses = []config.PayoutAddress{*payout}
+		}
+
+		limit := defaultPayoutLimit
+		if value := query.Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxPayoutLimit {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": fmt.Sprintf("limit must be between 1 and %d", maxPayoutLimit)})
+				return
+			}
+			limit = n
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		address := ""
+		if len(addresses) == 1 {
+			address = addresses[0].Address
+		}
+		totals, err := db.GetPayoutTotals()
+		var payouts []*database.Payout
+		if err == nil {
+			payouts, err = db.GetPayouts(address, limit)
+		}
+		if err != nil {
+			log.ErrorWithRequest(r, "Error reading payouts: %v", err)
+			writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		names := map[string]string{}
+		addressTotals := []PayoutAddressTotal{}
+		for _, payout := range addresses {
+			names[payout.Address] = payout.Name
+			total := PayoutAddressTotal{Name: payout.Name, Address: payout.Address, Source: payout.SourceOrDefault()}
+			if stored := totals[payout.Address]; stored != nil {
+				total.PayoutTotal = *stored
+				total.TotalBTC = float64(stored.TotalSats) / 1e8
+			}
+			addressTotals = append(addressTotals, total)
+		}
+
+		// Payments to addresses no longer configured are left out
+		recent := []PayoutEntry{}
+		for _, payout := range payouts {
+			if name, ok := names[payout.Address]; ok {
+				payout.Timestamp = payout.Timestamp.Local()
+				recent = append(recent, PayoutEntry{Name: name, Payout: payout})
+			}
+		}
+
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"addresses": addressTotals,
+			"payouts":   recent,
+		})
+	}
+}
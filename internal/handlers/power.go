@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/power"
+)
+
+// PowerEventRequest is the body of POST /api/power
+type PowerEventRequest struct {
+	Event string `json:"event"` // onbatt, online, lowbatt, fsd or powerfail
+}
+
+// HandlePowerEvent handles /api/power
+//
+//	GET  /api/power - last power event and whether collection is suspended
+//	POST /api/power - report a UPS event, e.g. from a NUT upsmon NOTIFYCMD script
+//
+// Shutdown events (lowbatt, fsd, powerfail) stop data collection and
+// checkpoint the database; online resumes collection.
+func HandlePowerEvent(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+
+		manager := power.GetManager(cfgManager)
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(http.StatusOK, manager.Status())
+		case http.MethodPost:
+			var req PowerEventRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+				return
+			}
+			defer r.Body.Close()
+
+			// NUT passes NOTIFYTYPE in upper case
+			req.Event = strings.ToLower(strings.TrimSpace(req.Event))
+			if !power.ValidEvent(req.Event) {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "event must be onbatt, online, lowbatt, fsd or powerfail"})
+				return
+			}
+			if !cfgManager.GetConfig().PowerEvents.IsEnabled() {
+				writeJSON(http.StatusConflict, map[string]string{"message": "Power events are disabled; set power_events.enabled"})
+				return
+			}
+			if err := manager.Handle(req.Event); err != nil {
+				writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			writeJSON(http.StatusOK, manager.Status())
+		default:
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// HandlePowerBudget handles GET /api/power/budget
+// Reports each device's latest power draw against its nominal wattage from
+// the power_budget section, with the margin added, and the fleet's total
+// power against the household cap, and the devices the power cap controller
+// stepped down. The section does not need to be enabled.
+func HandlePowerBudget(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		var instances []string
+		for _, instance := range cfg.AxeosInstances {
+			for name := range instance {
+				instances = append(instances, name)
+			}
+		}
+		budget := cfg.PowerBudget
+		reading := services.ReadPowerBudget(budget, instances, services.LatestDeviceMetrics())
+
+		// Devices the power cap controller stepped down, with the profile they run on
+		stepped := []map[string]interface{}{}
+		if db := database.Instance(); db != nil {
+			steps, err := db.GetPowerCapSteps()
+			if err != nil {
+				log.ErrorWithRequest(r, "Failed to load power cap steps: %v", err)
+			}
+			ids := make([]string, 0, len(steps))
+			for id := range steps {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				step := steps[id]
+				entry := map[string]interface{}{
+					"instanceId":  step.InstanceID,
+					"level":       step.Level,
+					"frequency":   step.Frequency,
+					"coreVoltage": step.CoreVoltage,
+					"updatedAt":   step.UpdatedAt,
+				}
+				if budget != nil && step.Level <= len(budget.Profiles) {
+					entry["profile"] = budget.Profiles[step.Level-1].Name
+				}
+				stepped = append(stepped, entry)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled":       budget.IsEnabled(),
+			"marginPercent": budget.Margin(),
+			"devices":       reading.Devices,
+			"fleetWatts":    reading.FleetWatts,
+			"fleetCapWatts": reading.FleetCapWatts,
+			"overCap":       reading.OverCap,
+			"capControl":    budget.ControlsCap(),
+			"steppedDown":   stepped,
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// powerSupplyStatus is the live reading and voltage trend of one supply
+type powerSupplyStatus struct {
+	*services.SupplyReading
+	Instances  []string              `json:"instances"`
+	RatedWatts float64               `json:"ratedWatts,omitempty"`
+	Trend      *services.SupplyTrend `json:"trend"`
+}
+
+// HandlePowerSupplies handles GET /api/power/supplies[?days=N]
+// Groups the devices by the power supply they share and reports each
+// supply's input voltage and current from the latest samples, whether it is
+// browning out, and the trend of its loaded voltage over the last days.
+// Devices in no configured supply are reported as their own supply. The
+// thresholds come from the power_monitoring section, which does not need
+// to be enabled.
+func HandlePowerSupplies(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		monitoring := cfg.PowerMonitoring
+		days := monitoring.Days()
+		if value := r.URL.Query().Get("days"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 7 || n > 90 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": "days must be between 7 and 90"})
+				return
+			}
+			days = n
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		var instances []string
+		for _, instance := range cfg.AxeosInstances {
+			for name := range instance {
+				instances = append(instances, name)
+			}
+		}
+
+		now := time.Now()
+		metrics := services.LatestDeviceMetrics()
+		supplies := []powerSupplyStatus{}
+		for _, supply := range monitoring.AllSupplies(instances) {
+			trend, err := services.AnalyzeSupply(db, supply, now, days, monitoring.Degradation())
+			if err != nil {
+				log.ErrorWithRequest(r, "Error analyzing power supply %s: %v", supply.Name, err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			supplies = append(supplies, powerSupplyStatus{
+				SupplyReading: services.ReadSupply(supply, metrics, monitoring.Sag()),
+				Instances:     supply.Instances,
+				RatedWatts:    supply.RatedWatts,
+				Trend:         trend,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled":            monitoring.IsEnabled(),
+			"sagPercent":         monitoring.Sag(),
+			"days":               days,
+			"degradationPercent": monitoring.Degradation(),
+			"supplies":           supplies,
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	defaultProfitabilityDays = 7
+	maxProfitabilityDays     = 90
+)
+
+// ProfitabilityDay is the energy cost and estimated earnings of one day
+type ProfitabilityDay struct {
+	Day         string   `json:"day"`
+	EnergyKWh   float64  `json:"energyKWh"`
+	Cost        float64  `json:"cost"`
+	AvgHashrate float64  `json:"avgHashrate"` // GH/s
+	Sats        *float64 `json:"sats"`        // Estimated at the current difficulty; nil without market data
+	Revenue     *float64 `json:"revenue"`     // Sats at the current price
+	Profit      *float64 `json:"profit"`      // Revenue minus cost
+}
+
+// MinerProfitability totals the days of a miner, or of the whole fleet
+type MinerProfitability struct {
+	InstanceID  string             `json:"instanceId"`
+	EnergyKWh   float64            `json:"energyKWh"`
+	Cost        float64            `json:"cost"`
+	CostPerDay  float64            `json:"costPerDay"`
+	CostPerWeek float64            `json:"costPerWeek"`
+	Sats        *float64           `json:"sats"`
+	SatsPerDay  *float64           `json:"satsPerDay"`
+	Revenue     *float64           `json:"revenue"`
+	Profit      *float64           `json:"profit"`
+	Days        []ProfitabilityDay `json:"days,omitempty"`
+}
+
+// ProfitabilityResponse is the response of GET /api/metrics/profitability
+type ProfitabilityResponse struct {
+	Coin               string                `json:"coin"`
+	Currency           string                `json:"currency"`
+	Days               int                   `json:"days"`
+	CurrentPricePerKWh float64               `json:"currentPricePerKWh"`
+	Price              *services.MarketValue `json:"price"`
+	Difficulty         *services.MarketValue `json:"difficulty"`
+	BlockReward        *services.MarketValue `json:"blockReward"`
+	Miners             []*MinerProfitability `json:"miners"`
+	Fleet              *MinerProfitability   `json:"fleet"`
+}
+
+// addEstimate adds value to a total that stays nil while values are unknown
+func addEstimate(total **float64, value *float64) {
+	if value == nil {
+		return
+	}
+	if *total == nil {
+		*total = new(float64)
+	}
+	**total += *value
+}
+
+// minerProfitability combines a miner's energy buckets and daily hashrate
+// rollups into days, oldest first. satsFactor is the sats per day of 1 GH/s
+// (0 when unknown) and price the coin price (0 when unknown).
+func minerProfitability(instanceID string, energy []*database.EnergyBucket, rollups []*database.AxeOSRollup,
+	satsFactor, price float64, now time.Time) *MinerProfitability {
+	days := map[string]*ProfitabilityDay{}
+	seconds := map[string]int{}
+	day := func(name string) *ProfitabilityDay {
+		d, ok := days[name]
+		if !ok {
+			d = &ProfitabilityDay{Day: name}
+			days[name] = d
+		}
+		return d
+	}
+	for _, b := range energy {
+		d := day(b.Day)
+		d.EnergyKWh, d.Cost = b.EnergyKWh, b.Cost
+		seconds[b.Day] = b.Seconds
+	}
+	for _, r := range rollups {
+		day(r.Timestamp.Local().Format(database.SnapshotDayFormat)).AvgHashrate = r.AvgHashrate
+	}
+
+	names := make([]string, 0, len(days))
+	for name := range days {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	miner := &MinerProfitability{InstanceID: instanceID, Days: []ProfitabilityDay{}}
+	today := now.Format(database.SnapshotDayFormat)
+	for _, name := range names {
+		d := days[name]
+		if satsFactor > 0 {
+			// Count the time the device ran; without power readings assume the whole day (so far)
+			fraction := float64(seconds[name]) / 86400
+			if fraction <= 0 || fraction > 1 {
+				fraction = 1
+				if name == today {
+					midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+					fraction = now.Sub(midnight).Hours() / 24
+				}
+			}
+			sats := d.AvgHashrate * satsFactor * fraction
+			d.Sats = &sats
+			if price > 0 {
+				revenue := sats / 1e8 * price
+				profit := revenue - d.Cost
+				d.Revenue, d.Profit = &revenue, &profit
+			}
+		}
+		miner.Days = append(miner.Days, *d)
+		miner.EnergyKWh += d.EnergyKWh
+		miner.Cost += d.Cost
+		addEstimate(&miner.Sats, d.Sats)
+		addEstimate(&miner.Revenue, d.Revenue)
+		addEstimate(&miner.Profit, d.Profit)
+	}
+	return miner
+}
+
+// finishProfitability fills in the per day and per week averages over the
+// given number of days with data
+func finishProfitability(miner *MinerProfitability, days int) {
+	if days == 0 {
+		return
+	}
+	miner.CostPerDay = miner.Cost / float64(days)
+	miner.CostPerWeek = miner.CostPerDay * 7
+	if miner.Sats != nil {
+		perDay := *miner.Sats / float64(days)
+		miner.SatsPerDay = &perDay
+	}
+}
+
+// HandleProfitability handles GET /api/metrics/profitability[?instanceId=X&days=N]
+// Returns each miner's daily energy cost (at the configured electricity price,
+// including time-of-use tiers) and estimated earnings in sats from its average
+// hashrate at the current difficulty, block reward and price, with totals over
+// the last N days (7 by default) and per day and week averages over the days
+// with data. Costs are recorded as energy is collected, so days before a price
+// was set cost 0.
+func HandleProfitability(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		query := r.URL.Query()
+		var instanceIDs []string
+		if instanceID := query.Get("instanceId"); instanceID != "" {
+			if !knownDevice(cfg, instanceID) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Instance not found"})
+				return
+			}
+			instanceIDs = []string{instanceID}
+		} else {
+			for _, instance := range cfg.AxeosInstances {
+				for name := range instance {
+					instanceIDs = append(instanceIDs, name)
+				}
+			}
+			instanceIDs = append(instanceIDs, cfg.PushDevices...)
+		}
+
+		days := defaultProfitabilityDays
+		if value := query.Get("days"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxProfitabilityDays {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"message": fmt.Sprintf("days must be between 1 and %d", maxProfitabilityDays),
+				})
+				return
+			}
+			days = n
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		now := time.Now()
+		since := now.AddDate(0, 0, 1-days)
+		sinceDay := since.Format(database.SnapshotDayFormat)
+		since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, now.Location())
+
+		market := services.GetMarketData(r.Context(), cfg)
+		var satsFactor, price float64
+		if market.Difficulty != nil && market.BlockReward != nil {
+			satsFactor = services.SatsPerDay(1, market.Difficulty.Value, market.BlockReward.Value)
+		}
+		if market.Price != nil {
+			price = market.Price.Value
+		}
+
+		response := ProfitabilityResponse{
+			Coin:               market.Coin,
+			Currency:           market.Currency,
+			Days:               days,
+			CurrentPricePerKWh: cfg.Electricity.PriceAt(now),
+			Price:              market.Price,
+			Difficulty:         market.Difficulty,
+			BlockReward:        market.BlockReward,
+			Miners:             []*MinerProfitability{},
+			Fleet:              &MinerProfitability{InstanceID: database.EnergyFleetID},
+		}
+		fleetDays := 0
+		for _, instanceID := range instanceIDs {
+			energy, err := db.GetEnergyBuckets(instanceID, sinceDay)
+			var rollups []*database.AxeOSRollup
+			if err == nil {
+				rollups, err = db.GetAxeOSRollups(database.ResolutionDaily, instanceID, since, now, days)
+			}
+			if err != nil {
+				log.ErrorWithRequest(r, "Error loading profitability for %s: %v", instanceID, err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+
+			miner := minerProfitability(instanceID, energy, rollups, satsFactor, price, now)
+			finishProfitability(miner, len(miner.Days))
+			fleetDays = max(fleetDays, len(miner.Days))
+			response.Miners = append(response.Miners, miner)
+
+			response.Fleet.EnergyKWh += miner.EnergyKWh
+			response.Fleet.Cost += miner.Cost
+			addEstimate(&response.Fleet.Sats, miner.Sats)
+			addEstimate(&response.Fleet.Revenue, miner.Revenue)
+			addEstimate(&response.Fleet.Profit, miner.Profit)
+		}
+		finishProfitability(response.Fleet, fleetDays)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
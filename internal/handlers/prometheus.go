@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/live"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// promMetric is one metric family in the exposition output
+type promMetric struct {
+	name    string
+	help    string
+	kind    string // gauge or counter
+	samples []string
+}
+
+// promLabel escapes a label value for the Prometheus text format
+func promLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// add appends a sample with the given label
+func (m *promMetric) add(label, id string, value float64) {
+	m.samples = append(m.samples, fmt.Sprintf("%s{%s=\"%s\"} %g", m.name, label, promLabel(id), value))
+}
+
+// sortedIDs returns the source ids of samples in a stable order
+func sortedIDs(samples map[string]live.Sample) []string {
+	ids := make([]string, 0, len(samples))
+	for id := range samples {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// buildPrometheusMetrics renders the latest collected values in the Prometheus text format
+func buildPrometheusMetrics(hub *live.Hub) string {
+	hashrate := &promMetric{name: "axeos_hashrate_ghs", help: "Miner hashrate in GH/s", kind: "gauge"}
+	temperature := &promMetric{name: "axeos_temperature_celsius", help: "ASIC temperature in degrees Celsius", kind: "gauge"}
+	power := &promMetric{name: "axeos_power_watts", help: "Power draw in watts", kind: "gauge"}
+	fanSpeed := &promMetric{name: "axeos_fan_speed_percent", help: "Fan speed in percent", kind: "gauge"}
+	accepted := &promMetric{name: "axeos_shares_accepted_total", help: "Shares accepted since the miner started", kind: "counter"}
+	rejected := &promMetric{name: "axeos_shares_rejected_total", help: "Shares rejected since the miner started", kind: "counter"}
+	bestDiff := &promMetric{name: "axeos_best_difficulty", help: "Best share difficulty", kind: "gauge"}
+	frequency := &promMetric{name: "axeos_frequency_mhz", help: "ASIC frequency in MHz", kind: "gauge"}
+	voltage := &promMetric{name: "axeos_voltage_millivolts", help: "Input voltage in mV", kind: "gauge"}
+	coreVoltage := &promMetric{name: "axeos_core_voltage_millivolts", help: "ASIC core voltage in mV", kind: "gauge"}
+	current := &promMetric{name: "axeos_current_milliamps", help: "Input current in mA", kind: "gauge"}
+
+	poolHashrate := &promMetric{name: "pool_hashrate", help: "Pool hashrate in H/s", kind: "gauge"}
+	poolWorkers := &promMetric{name: "pool_workers", help: "Connected pool workers", kind: "gauge"}
+	poolNetHashrate := &promMetric{name: "pool_network_hashrate", help: "Network hashrate reported by the pool in H/s", kind: "gauge"}
+	poolNetDiff := &promMetric{name: "pool_network_difficulty", help: "Network difficulty reported by the pool", kind: "gauge"}
+	poolBlocks := &promMetric{name: "pool_blocks_found_total", help: "Blocks found by the pool", kind: "counter"}
+
+	nodeHeight := &promMetric{name: "node_block_height", help: "Node block height", kind: "gauge"}
+	nodeConnections := &promMetric{name: "node_connections", help: "Node peer connections", kind: "gauge"}
+	nodeDifficulty := &promMetric{name: "node_difficulty", help: "Network difficulty reported by the node", kind: "gauge"}
+	nodeMempoolTxs := &promMetric{name: "node_mempool_transactions", help: "Transactions in the node mempool", kind: "gauge"}
+	nodeMempoolBytes := &promMetric{name: "node_mempool_bytes", help: "Virtual size of the node mempool in bytes", kind: "gauge"}
+	nodeFeeFast := &promMetric{name: "node_fee_fast_sat_per_vbyte", help: "Fee estimate for confirmation within 2 blocks in sat/vB", kind: "gauge"}
+	nodeFeeMedium := &promMetric{name: "node_fee_medium_sat_per_vbyte", help: "Fee estimate for confirmation within 6 blocks in sat/vB", kind: "gauge"}
+	nodeFeeSlow := &promMetric{name: "node_fee_slow_sat_per_vbyte", help: "Fee estimate for confirmation within 144 blocks in sat/vB", kind: "gauge"}
+
+	lastUpdate := &promMetric{name: "axeos_dashboard_last_update_timestamp_seconds", help: "Unix time of the last collection per source", kind: "gauge"}
+
+	axeos := hub.Latest(live.KindAxeOS)
+	for _, id := range sortedIDs(axeos) {
+		metric, ok := axeos[id].Metric.(*database.AxeOSMetric)
+		if !ok {
+			continue
+		}
+		hashrate.add("instance", id, metric.Hashrate)
+		temperature.add("instance", id, metric.Temperature)
+		power.add("instance", id, metric.Power)
+		fanSpeed.add("instance", id, float64(metric.FanSpeed))
+		accepted.add("instance", id, float64(metric.SharesAccepted))
+		rejected.add("instance", id, float64(metric.SharesRejected))
+		if diff, err := services.ParseDifficulty(metric.BestDiff); err == nil {
+			bestDiff.add("instance", id, diff)
+		}
+		frequency.add("instance", id, float64(metric.Frequency))
+		voltage.add("instance", id, metric.Voltage)
+		coreVoltage.add("instance", id, metric.CoreVoltage)
+		current.add("instance", id, metric.Current)
+		lastUpdate.samples = append(lastUpdate.samples, fmt.Sprintf("%s{kind=\"axeos\",id=\"%s\"} %d",
+			lastUpdate.name, promLabel(id), axeos[id].UpdatedAt.Unix()))
+	}
+
+	pools := hub.Latest(live.KindPool)
+	for _, id := range sortedIDs(pools) {
+		metric, ok := pools[id].Metric.(*database.PoolMetric)
+		if !ok {
+			continue
+		}
+		poolHashrate.add("pool", id, metric.PoolHashrate)
+		poolWorkers.add("pool", id, float64(metric.PoolWorkers))
+		poolNetHashrate.add("pool", id, metric.NetworkHashrate)
+		poolNetDiff.add("pool", id, metric.NetworkDifficulty)
+		poolBlocks.add("pool", id, float64(metric.BlocksFound))
+		lastUpdate.samples = append(lastUpdate.samples, fmt.Sprintf("%s{kind=\"pool\",id=\"%s\"} %d",
+			lastUpdate.name, promLabel(id), pools[id].UpdatedAt.Unix()))
+	}
+
+	nodes := hub.Latest(live.KindNode)
+	for _, id := range sortedIDs(nodes) {
+		metric, ok := nodes[id].Metric.(*database.NodeMetric)
+		if !ok {
+			continue
+		}
+		nodeHeight.add("node", id, float64(metric.BlockHeight))
+		nodeConnections.add("node", id, float64(metric.Connections))
+		nodeDifficulty.add("node", id, metric.Difficulty)
+		if metric.MempoolTransactions > 0 || metric.FeeFast > 0 {
+			// Only nodes with NodeMempool set report a mempool
+			nodeMempoolTxs.add("node", id, float64(metric.MempoolTransactions))
+			nodeMempoolBytes.add("node", id, float64(metric.MempoolBytes))
+			nodeFeeFast.add("node", id, metric.FeeFast)
+			nodeFeeMedium.add("node", id, metric.FeeMedium)
+			nodeFeeSlow.add("node", id, metric.FeeSlow)
+		}
+		lastUpdate.samples = append(lastUpdate.samples, fmt.Sprintf("%s{kind=\"node\",id=\"%s\"} %d",
+			lastUpdate.name, promLabel(id), nodes[id].UpdatedAt.Unix()))
+	}
+
+	var b strings.Builder
+	for _, m := range []*promMetric{
+		hashrate, temperature, power, fanSpeed, accepted, rejected, bestDiff, frequency, voltage, coreVoltage, current,
+		poolHashrate, poolWorkers, poolNetHashrate, poolNetDiff, poolBlocks,
+		nodeHeight, nodeConnections, nodeDifficulty, nodeMempoolTxs, nodeMempoolBytes, nodeFeeFast, nodeFeeMedium, nodeFeeSlow,
+		lastUpdate,
+	} {
+		if len(m.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, sample := range m.samples {
+			b.WriteString(sample)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// buildHTTPStatsMetrics renders the per-route request statistics in the Prometheus text format
+func buildHTTPStatsMetrics(stats []middleware.RouteStats) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+
+	var b strings.Builder
+	b.WriteString("# HELP axeos_dashboard_http_requests_total HTTP requests handled per route\n")
+	b.WriteString("# TYPE axeos_dashboard_http_requests_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "axeos_dashboard_http_requests_total{route=\"%s\"} %d\n", promLabel(s.Route), s.Requests)
+	}
+	b.WriteString("# HELP axeos_dashboard_http_errors_total HTTP requests per route answered with an error status\n")
+	b.WriteString("# TYPE axeos_dashboard_http_errors_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "axeos_dashboard_http_errors_total{route=\"%s\",class=\"4xx\"} %d\n", promLabel(s.Route), s.ClientErrors)
+		fmt.Fprintf(&b, "axeos_dashboard_http_errors_total{route=\"%s\",class=\"5xx\"} %d\n", promLabel(s.Route), s.ServerErrors)
+	}
+	b.WriteString("# HELP axeos_dashboard_http_request_duration_seconds HTTP request latency per route, without WebSocket and SSE streams\n")
+	b.WriteString("# TYPE axeos_dashboard_http_request_duration_seconds histogram\n")
+	for _, s := range stats {
+		route := promLabel(s.Route)
+		for i, bound := range middleware.HTTPLatencyBuckets {
+			fmt.Fprintf(&b, "axeos_dashboard_http_request_duration_seconds_bucket{route=\"%s\",le=\"%g\"} %d\n", route, bound, s.Buckets[i])
+		}
+		timed := s.Requests - s.Streams
+		fmt.Fprintf(&b, "axeos_dashboard_http_request_duration_seconds_bucket{route=\"%s\",le=\"+Inf\"} %d\n", route, timed)
+		fmt.Fprintf(&b, "axeos_dashboard_http_request_duration_seconds_sum{route=\"%s\"} %g\n", route, s.SumSeconds)
+		fmt.Fprintf(&b, "axeos_dashboard_http_request_duration_seconds_count{route=\"%s\"} %d\n", route, timed)
+	}
+	return b.String()
+}
+
+// HandlePrometheusMetrics handles GET /metrics
+// Exposes the latest collected values in the Prometheus text exposition format
+func HandlePrometheusMetrics(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if !cfg.PrometheusEnabled {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(buildPrometheusMetrics(live.GetHub())))
+		w.Write([]byte(buildHTTPStatsMetrics(middleware.GetHTTPStats().Snapshot())))
+	}
+}
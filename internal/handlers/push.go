@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/scheduler"
+)
+
+// maxPushBytes limits the size of a pushed system info payload
+const maxPushBytes = 256 << 10
+
+// knownDevice reports whether an id names a polled or a push device
+func knownDevice(cfg *config.Config, instanceID string) bool {
+	return findInstanceURL(cfg, instanceID) != "" || cfg.IsPushDevice(instanceID)
+}
+
+// HandlePush handles POST /api/push/{instanceId}
+// Accepts the /api/system/info JSON of a device the dashboard cannot reach
+// (behind CGNAT or at a remote site), sent by the device or a relay script,
+// and stores it like a collected sample. The device must be listed in
+// push_devices and authenticate with "Authorization: Bearer <token>" using
+// its token from push.json. No login is required.
+func HandlePush(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+
+		instanceID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/push"), "/")
+		if instanceID == "" || strings.Contains(instanceID, "/") || !cfg.IsPushDevice(instanceID) {
+			writeJSON(http.StatusNotFound, map[string]string{
+				"message": fmt.Sprintf("Push device \"%s\" not found in configuration.", instanceID),
+			})
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		valid, err := auth.VerifyPushToken(cfgManager.GetConfigDir(), instanceID, strings.TrimSpace(token))
+		if err != nil && !errors.Is(err, auth.ErrNoPushToken) {
+			log.ErrorWithRequest(r, "Error verifying push token of %s: %v", instanceID, err)
+		}
+		if !ok || !valid {
+			log.WarnWithRequest(r, "Rejected push for %s: invalid or missing token", instanceID)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="axeos-dashboard"`)
+			writeJSON(http.StatusUnauthorized, map[string]string{"message": "Unauthorized: invalid push token"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPushBytes))
+		if err != nil {
+			writeJSON(http.StatusRequestEntityTooLarge, map[string]string{"status": "error", "message": "Payload too large"})
+			return
+		}
+		var info map[string]interface{}
+		if err := json.Unmarshal(body, &info); err != nil {
+			writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": "Payload must be AxeOS system info JSON"})
+			return
+		}
+
+		manager := scheduler.Instance()
+		if manager == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+		if err := manager.IngestAxeOSInfo(instanceID, body); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, scheduler.ErrCollectionPaused) {
+				status = http.StatusServiceUnavailable
+			} else {
+				log.ErrorWithRequest(r, "Error storing pushed metrics of %s: %v", instanceID, err)
+			}
+			writeJSON(status, map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+
+		writeJSON(http.StatusAccepted, map[string]string{"status": "success", "message": "Metrics stored"})
+	}
+}
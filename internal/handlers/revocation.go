@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// RevokeRequest is the body of POST /api/auth/revoke
+type RevokeRequest struct {
+	Username string `json:"username"` // Revoke the tokens of one user
+	All      bool   `json:"all"`      // Revoke the tokens of every user
+}
+
+// HandleTokenRevocation handles /api/auth/revoke
+//
+//	GET  - the revocation cutoffs and the number of individually revoked tokens
+//	POST - invalidate every session token (and refresh token) issued so far to
+//	       one user ({"username": "alice"}) or to everyone ({"all": true})
+//
+// Use it after a password change or when a session cookie may have been stolen.
+func HandleTokenRevocation(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	list := auth.GetRevocationList()
+	if list == nil {
+		writeJSON(http.StatusServiceUnavailable, map[string]string{"message": "Token revocation is not available"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(http.StatusOK, list.Status())
+		return
+	case http.MethodPost:
+	default:
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	var req RevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+		return
+	}
+	defer r.Body.Close()
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.All == (req.Username != "") {
+		writeJSON(http.StatusBadRequest, map[string]string{"message": "Either username or all is required"})
+		return
+	}
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	// Refresh tokens go too, or they would hand out new access tokens
+	var err error
+	sessions := 0
+	store := auth.GetSessionStore()
+	if req.All {
+		if err = list.RevokeAll(); err == nil && store != nil {
+			sessions, err = store.RevokeAll()
+		}
+	} else {
+		if err = list.RevokeUser(req.Username); err == nil && store != nil {
+			sessions, err = store.RevokeUser(req.Username)
+		}
+	}
+	if err != nil {
+		log.ErrorWithRequest(r, "Error revoking tokens: %v", err)
+		writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+		return
+	}
+
+	if req.All {
+		log.InfoWithRequest(r, "%s revoked the tokens of all users (%d session(s))", username, sessions)
+	} else {
+		log.InfoWithRequest(r, "%s revoked the tokens of %s (%d session(s))", username, req.Username, sessions)
+	}
+	writeJSON(http.StatusOK, map[string]interface{}{
+		"status":          "success",
+		"revokedSessions": sessions,
+		"revocation":      list.Status(),
+	})
+}
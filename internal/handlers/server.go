@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// HandleServerReload handles POST /api/server/reload: it re-reads every
+// configuration file, reinitializes authentication, reloads the scheduler's
+// tasks and swaps in a new router without dropping open connections. The listen
+// port and TLS settings still require a restart.
+func HandleServerReload(reload func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+
+		if r.Method != http.MethodPost {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+
+		if err := reload(); err != nil {
+			log.ErrorWithRequest(r, "Error reloading server: %v", err)
+			writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+			return
+		}
+		log.InfoWithRequest(r, "Server reloaded by %s", username)
+		writeJSON(http.StatusOK, map[string]string{"status": "success", "message": "Server reloaded"})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// SessionInfo describes a renewable session without its refresh token hash
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	ClientIP  string    `json:"clientIp,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	RenewedAt time.Time `json:"renewedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// HandleSessions handles /api/sessions and /api/sessions/{id}
+//
+//	GET    /api/sessions                - list active sessions, newest first
+//	DELETE /api/sessions?username=alice - revoke all sessions of a user
+//	DELETE /api/sessions/{id}           - revoke one session
+//
+// Revoking a session stops its refresh token and its access tokens at once.
+func HandleSessions(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+	serverError := func(err error) {
+		log.ErrorWithRequest(r, "Error revoking sessions: %v", err)
+		writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+	}
+
+	store := auth.GetSessionStore()
+	if store == nil {
+		writeJSON(http.StatusServiceUnavailable, map[string]string{"message": "Sessions are not available"})
+		return
+	}
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/sessions"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		sessions := []SessionInfo{}
+		for _, session := range store.List() {
+			sessions = append(sessions, SessionInfo{
+				ID:        session.ID,
+				Username:  session.Username,
+				Role:      session.Role,
+				ClientIP:  session.ClientIP,
+				UserAgent: session.UserAgent,
+				CreatedAt: session.CreatedAt,
+				RenewedAt: session.RotatedAt,
+				ExpiresAt: session.ExpiresAt,
+			})
+		}
+		writeJSON(http.StatusOK, map[string]interface{}{"sessions": sessions})
+	case r.Method == http.MethodDelete && id == "":
+		target := r.URL.Query().Get("username")
+		if target == "" {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "username is required"})
+			return
+		}
+		revoked, err := store.RevokeUser(target)
+		if err != nil {
+			serverError(err)
+			return
+		}
+		log.InfoWithRequest(r, "%s revoked %d session(s) of %s", username, revoked, target)
+		writeJSON(http.StatusOK, map[string]interface{}{"status": "success", "revoked": revoked})
+	case r.Method == http.MethodDelete:
+		found, err := store.Revoke(id)
+		if err != nil {
+			serverError(err)
+			return
+		}
+		if !found {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Session not found"})
+			return
+		}
+		log.InfoWithRequest(r, "%s revoked session %s", username, id)
+		writeJSON(http.StatusOK, map[string]interface{}{"status": "success", "revoked": 1})
+	default:
+		writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+	}
+}
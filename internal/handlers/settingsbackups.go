@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// backupSettingsFields lists the device info fields that are settings AxeOS
+// accepts back through PATCH. Wi-Fi settings are left out so a restore cannot
+// take a device off the network; AxeOS never returns passwords.
+var backupSettingsFields = []string{
+	"hostname",
+	"stratumURL",
+	"stratumPort",
+	"stratumUser",
+	"fallbackStratumURL",
+	"fallbackStratumPort",
+	"fallbackStratumUser",
+	"frequency",
+	"coreVoltage",
+	"autofanspeed",
+	"fanspeed",
+	"temptarget",
+	"overheat_mode",
+	"flipscreen",
+	"invertscreen",
+	"invertfanpolarity",
+	"displayTimeout",
+	"statsFrequency",
+}
+
+// RestoreRequest is the optional body of POST /api/instance/settings/backups/{id}/restore
+type RestoreRequest struct {
+	Restart bool `json:"restart,omitempty"` // Restart the device after restoring
+}
+
+// backupDeviceSettings fetches the current settings of a device and stores
+// them as a settings backup. It returns nil without a backup when data
+// collection is disabled.
+func backupDeviceSettings(ctx context.Context, cfg *config.Config, instanceID, instanceURL, reason, createdBy string) (*database.SettingsBackup, error) {
+	db := database.Instance()
+	if db == nil {
+		return nil, nil
+	}
+
+	info, err := fetchDeviceInfo(ctx, cfg, instanceID, instanceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	for _, key := range backupSettingsFields {
+		if value, ok := info[key]; ok {
+			settings[key] = value
+		}
+	}
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("device returned no settings")
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	backup := &database.SettingsBackup{
+		InstanceID: instanceID,
+		Reason:     reason,
+		CreatedBy:  createdBy,
+		Settings:   string(data),
+	}
+	if err := db.InsertSettingsBackup(backup); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// HandleSettingsBackups handles /api/instance/settings/backups and /api/instance/settings/backups/{id}
+//
+//	GET  /api/instance/settings/backups[?instanceId=X]  - list backups, newest first
+//	POST /api/instance/settings/backups?instanceId=X    - back up the current settings of a device
+//	GET  /api/instance/settings/backups/{id}            - one backup with its settings
+//	POST /api/instance/settings/backups/{id}/restore    - apply a backup to its device
+//
+// Settings are backed up automatically before each settings change or pool
+// switch, and before a restore, so a restore can itself be undone.
+func HandleSettingsBackups(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		serverError := func(err error) {
+			log.ErrorWithRequest(r, "Error handling settings backups: %v", err)
+			writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+		}
+
+		db := database.Instance()
+		if db == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/instance/settings/backups"), "/")
+		if path == "" {
+			instanceID := r.URL.Query().Get("instanceId")
+			switch r.Method {
+			case http.MethodGet:
+				backups, err := db.GetSettingsBackups(instanceID)
+				if err != nil {
+					serverError(err)
+					return
+				}
+				if backups == nil {
+					backups = []*database.SettingsBackup{}
+				}
+				writeJSON(http.StatusOK, map[string]interface{}{"backups": backups})
+			case http.MethodPost:
+				if instanceID == "" {
+					writeJSON(http.StatusBadRequest, map[string]string{"message": "Missing instanceId parameter"})
+					return
+				}
+				instanceURL := findInstanceURL(cfg, instanceID)
+				if instanceURL == "" {
+					writeJSON(http.StatusNotFound, map[string]string{
+						"message": fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", instanceID),
+					})
+					return
+				}
+				backup, err := backupDeviceSettings(r.Context(), cfg, instanceID, instanceURL, database.BackupReasonManual, username)
+				if err != nil {
+					log.ErrorWithRequest(r, "Failed to back up settings of %s: %v", instanceID, err)
+					writeJSON(http.StatusBadGateway, map[string]string{"message": "Could not read device settings: " + err.Error()})
+					return
+				}
+				writeJSON(http.StatusCreated, backup)
+			default:
+				writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			}
+			return
+		}
+
+		idText, action, _ := strings.Cut(path, "/")
+		id, err := strconv.ParseInt(idText, 10, 64)
+		if err != nil || (action != "" && action != "restore") {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		if (action == "" && r.Method != http.MethodGet) || (action == "restore" && r.Method != http.MethodPost) {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		backup, err := db.GetSettingsBackup(id)
+		if err != nil {
+			serverError(err)
+			return
+		}
+		if backup == nil {
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Settings backup not found"})
+			return
+		}
+
+		var settings map[string]interface{}
+		if err := json.Unmarshal([]byte(backup.Settings), &settings); err != nil {
+			serverError(fmt.Errorf("settings backup %d is corrupt: %w", id, err))
+			return
+		}
+
+		if action == "" {
+			writeJSON(http.StatusOK, map[string]interface{}{"backup": backup, "settings": settings})
+			return
+		}
+
+		if cfg.DisableSettings {
+			writeJSON(http.StatusForbidden, map[string]string{"message": "Settings are disabled by configuration."})
+			return
+		}
+
+		var req RestoreRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+				return
+			}
+			defer r.Body.Close()
+		}
+
+		instanceURL := findInstanceURL(cfg, backup.InstanceID)
+		if instanceURL == "" {
+			writeJSON(http.StatusNotFound, map[string]string{
+				"message": fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", backup.InstanceID),
+			})
+			return
+		}
+
+		// Keep the settings being replaced, so the restore can be undone
+		if _, err := backupDeviceSettings(r.Context(), cfg, backup.InstanceID, instanceURL, database.BackupReasonRestore, username); err != nil {
+			log.ErrorWithRequest(r, "Failed to back up settings of %s before restore: %v", backup.InstanceID, err)
+		}
+
+		body, _ := json.Marshal(settings)
+		if err := services.SendDeviceRequest(cfg, backup.InstanceID, http.MethodPatch, instanceURL+services.GetAPIPath(cfg, "instanceSettings"), body); err != nil {
+			log.ErrorWithRequest(r, "Failed to restore settings of %s: %v", backup.InstanceID, err)
+			writeJSON(http.StatusBadGateway, map[string]string{"message": "Restore failed: " + err.Error()})
+			return
+		}
+		log.InfoWithRequest(r, "%s restored settings backup %d to %s", username, backup.ID, backup.InstanceID)
+
+		restarted := false
+		if req.Restart {
+			// AxeOS applies stratum and frequency changes after a restart
+			if err := services.SendDeviceRequest(cfg, backup.InstanceID, http.MethodPost, instanceURL+services.GetAPIPath(cfg, "instanceRestart"), nil); err != nil {
+				log.ErrorWithRequest(r, "Failed to restart %s after restore: %v", backup.InstanceID, err)
+				writeJSON(http.StatusBadGateway, map[string]string{"message": "Settings restored but restart failed: " + err.Error()})
+				return
+			}
+			restarted = true
+			if err := db.InsertEvent(&database.Event{
+				Type:    database.EventRestart,
+				Source:  backup.InstanceID,
+				Title:   fmt.Sprintf("%s restarted", backup.InstanceID),
+				Message: fmt.Sprintf("Restart requested by %s (settings restore)", username),
+			}); err != nil {
+				log.ErrorWithRequest(r, "Failed to record restart event: %v", err)
+			}
+		}
+
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"status":    "success",
+			"message":   fmt.Sprintf("Settings backup %d restored to %s", backup.ID, backup.InstanceID),
+			"restarted": restarted,
+		})
+	}
+}
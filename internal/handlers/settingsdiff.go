@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// Guardrails for proposed device settings. The limits match the checks of the
+// settings form; larger steps are allowed but should be confirmed.
+const (
+	maxFrequencyMHz      = 1000 // Above this a device may be damaged
+	maxCoreVoltageMV     = 1300
+	frequencyStepPercent = 10 // Frequency change worth confirming
+	coreVoltageStepMV    = 50 // Core voltage increase worth confirming
+	minManualFanPercent  = 50 // Manual fan speeds below this risk overheating
+)
+
+// Status of a field in a settings diff
+const (
+	DiffChanged   = "changed"
+	DiffUnchanged = "unchanged"
+	DiffUnknown   = "unknown"    // The device does not report the field
+	DiffWriteOnly = "write_only" // Passwords, which devices never return
+)
+
+// Warning severities of a settings diff
+const (
+	SeverityWarning = "warning" // Worth a second look
+	SeverityDanger  = "danger"  // Can damage the device or stop it mining
+)
+
+// SettingsChange compares one proposed setting with the device's current value
+type SettingsChange struct {
+	Field    string      `json:"field"`
+	Status   string      `json:"status"`
+	Current  interface{} `json:"current,omitempty"`
+	Proposed interface{} `json:"proposed,omitempty"`
+}
+
+// SettingsWarning flags a proposed change that should be confirmed
+type SettingsWarning struct {
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// SettingsDiff is the response of POST /api/instance/settings/diff
+type SettingsDiff struct {
+	InstanceID           string            `json:"instanceId"`
+	Changed              int               `json:"changed"`
+	Changes              []SettingsChange  `json:"changes"`
+	Warnings             []SettingsWarning `json:"warnings"`
+	RequiresConfirmation bool              `json:"requiresConfirmation"`
+}
+
+// settingNumber returns a setting as a number. AxeOS reports switches as 0 or
+// 1 and forms may send numbers as strings, so both are converted.
+func settingNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// sameSetting reports whether a proposed setting equals the current value
+func sameSetting(current, proposed interface{}) bool {
+	if c, ok := settingNumber(current); ok {
+		if p, ok := settingNumber(proposed); ok {
+			return c == p
+		}
+	}
+	return fmt.Sprint(current) == fmt.Sprint(proposed)
+}
+
+// diffSettings compares proposed settings with the current device info and
+// checks them against the guardrails
+func diffSettings(instanceID string, current, proposed map[string]interface{}) SettingsDiff {
+	diff := SettingsDiff{InstanceID: instanceID, Changes: []SettingsChange{}, Warnings: []SettingsWarning{}}
+
+	fields := make([]string, 0, len(proposed))
+	for field := range proposed {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	changed := map[string]bool{}
+	for _, field := range fields {
+		change := SettingsChange{Field: field, Proposed: proposed[field]}
+		value, reported := current[field]
+		switch {
+		case strings.HasSuffix(strings.ToLower(field), "password") || strings.EqualFold(field, "wifiPass"):
+			change.Status = DiffWriteOnly
+			change.Proposed = nil // Never echo secrets
+		case !reported:
+			change.Status = DiffUnknown
+		case sameSetting(value, proposed[field]):
+			change.Status = DiffUnchanged
+			change.Current = value
+		default:
+			change.Status = DiffChanged
+			change.Current = value
+			changed[field] = true
+			diff.Changed++
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+
+	warn := func(field, severity, format string, args ...interface{}) {
+		diff.Warnings = append(diff.Warnings, SettingsWarning{Field: field, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if frequency, ok := settingNumber(proposed["frequency"]); ok && !sameSetting(current["frequency"], frequency) {
+		if frequency > maxFrequencyMHz {
+			warn("frequency", SeverityDanger, "Frequency %.0f MHz is above the %d MHz limit", frequency, maxFrequencyMHz)
+		} else if old, ok := settingNumber(current["frequency"]); ok && old > 0 && math.Abs(frequency-old)/old*100 > frequencyStepPercent {
+			warn("frequency", SeverityWarning, "Frequency changes by more than %d%% (%.0f to %.0f MHz)", frequencyStepPercent, old, frequency)
+		}
+	}
+	if voltage, ok := settingNumber(proposed["coreVoltage"]); ok && !sameSetting(current["coreVoltage"], voltage) {
+		if voltage > maxCoreVoltageMV {
+			warn("coreVoltage", SeverityDanger, "Core voltage %.0f mV is above the %d mV limit", voltage, maxCoreVoltageMV)
+		} else if old, ok := settingNumber(current["coreVoltage"]); ok && voltage-old > coreVoltageStepMV {
+			warn("coreVoltage", SeverityWarning, "Core voltage rises by more than %d mV (%.0f to %.0f mV)", coreVoltageStepMV, old, voltage)
+		}
+	}
+
+	// Fan settings are checked as they will be after the change
+	effective := func(field string) (float64, bool) {
+		if value, ok := proposed[field]; ok {
+			return settingNumber(value)
+		}
+		return settingNumber(current[field])
+	}
+	if changed["autofanspeed"] || changed["fanspeed"] {
+		auto, autoKnown := effective("autofanspeed")
+		speed, speedKnown := effective("fanspeed")
+		if autoKnown && auto == 0 && speedKnown && speed < minManualFanPercent {
+			warn("fanspeed", SeverityWarning, "Automatic fan control is off with the fan at %.0f%%", speed)
+		}
+	}
+	if changed["overheat_mode"] {
+		if mode, ok := settingNumber(proposed["overheat_mode"]); ok && mode == 0 {
+			warn("overheat_mode", SeverityDanger, "Overheat protection is being turned off")
+		}
+	}
+
+	for _, field := range []string{"stratumURL", "stratumPort", "stratumUser"} {
+		if changed[field] {
+			warn(field, SeverityWarning, "The device will mine to a different pool or account")
+			break
+		}
+	}
+	if changed["hostname"] {
+		warn("hostname", SeverityWarning, "The device's network name changes; update axeos_instances if it is addressed by name")
+	}
+
+	diff.RequiresConfirmation = len(diff.Warnings) > 0
+	return diff
+}
+
+// HandleSettingsDiff handles POST /api/instance/settings/diff?instanceId=X
+// Compares proposed settings with the device's current settings without applying them
+func HandleSettingsDiff(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+
+		if r.Method != http.MethodPost {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		instanceID := r.URL.Query().Get("instanceId")
+		if instanceID == "" {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "Missing instanceId parameter"})
+			return
+		}
+		instanceURL := findInstanceURL(cfg, instanceID)
+		if instanceURL == "" {
+			writeJSON(http.StatusNotFound, map[string]string{
+				"message": fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", instanceID),
+			})
+			return
+		}
+
+		var proposed map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&proposed); err != nil || len(proposed) == 0 {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "Request body must be a JSON object of settings"})
+			return
+		}
+		defer r.Body.Close()
+
+		current, err := fetchDeviceInfo(r.Context(), cfg, instanceID, instanceURL)
+		if err != nil {
+			log.ErrorWithRequest(r, "Failed to read settings of %s: %v", instanceID, err)
+			writeJSON(http.StatusBadGateway, map[string]string{"message": "Could not read device settings: " + err.Error()})
+			return
+		}
+
+		writeJSON(http.StatusOK, diffSettings(instanceID, current, proposed))
+	}
+}
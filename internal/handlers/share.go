@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// shareableFields lists the device info fields exposed through share links,
+// in display order. Network and pool credentials are deliberately excluded.
+var shareableFields = []struct {
+	Key   string
+	Label string
+}{
+	{"hashRate", "Hashrate (GH/s)"},
+	{"expectedHashrate", "Expected Hashrate (GH/s)"},
+	{"bestDiff", "Best Difficulty"},
+	{"bestSessionDiff", "Best Session Difficulty"},
+	{"sharesAccepted", "Shares Accepted"},
+	{"sharesRejected", "Shares Rejected"},
+	{"power", "Power (W)"},
+	{"temp", "ASIC Temp (°C)"},
+	{"vrTemp", "VR Temp (°C)"},
+	{"frequency", "Frequency (MHz)"},
+	{"fanspeed", "Fan Speed (%)"},
+	{"uptimeSeconds", "Uptime (s)"},
+	{"ASICModel", "ASIC Chip"},
+	{"boardVersion", "Board Version"},
+	{"version", "AxeOS Version"},
+}
+
+// ShareRequest represents the body of a share link request
+type ShareRequest struct {
+	InstanceID string `json:"instanceId"`
+	ExpiresIn  string `json:"expiresIn"` // e.g. "1h", "24h", "7d"
+}
+
+// findInstanceURL returns the base URL of the named AxeOS instance, or "" if unknown
+func findInstanceURL(cfg *config.Config, instanceID string) string {
+	for _, instance := range cfg.AxeosInstances {
+		if url, ok := instance[instanceID]; ok {
+			return url
+		}
+	}
+	return ""
+}
+
+// fetchDeviceInfo fetches the system info of an instance
+func fetchDeviceInfo(ctx context.Context, cfg *config.Config, instanceID, instanceURL string) (map[string]interface{}, error) {
+	resp, err := services.GetHTTPClientPool().Get(ctx, cfg, instanceID, instanceURL+services.GetAPIPath(cfg, "instanceInfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var info map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// fetchShareableInfo fetches system info from an instance and keeps only shareable fields
+func fetchShareableInfo(ctx context.Context, cfg *config.Config, instanceID, instanceURL string) (map[string]interface{}, error) {
+	info, err := fetchDeviceInfo(ctx, cfg, instanceID, instanceURL)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := map[string]interface{}{}
+	for _, field := range shareableFields {
+		if value, ok := info[field.Key]; ok {
+			shared[field.Key] = value
+		}
+	}
+	return shared, nil
+}
+
+// HandleShareCreate handles POST /api/share
+// Creates a time-limited read-only link for a single device
+func HandleShareCreate(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		var req ShareRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Invalid JSON in request body"})
+			return
+		}
+		defer r.Body.Close()
+
+		if findInstanceURL(cfg, req.InstanceID) == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": fmt.Sprintf("AxeOS instance \"%s\" not found in configuration.", req.InstanceID),
+			})
+			return
+		}
+
+		if req.ExpiresIn == "" {
+			req.ExpiresIn = "24h"
+		}
+		lifetime, err := config.ParseLifetime(req.ExpiresIn)
+		if err != nil || lifetime <= 0 || lifetime > auth.MaxShareLifetime {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": fmt.Sprintf("expiresIn must be a duration up to %v", auth.MaxShareLifetime),
+			})
+			return
+		}
+
+		createdBy := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			createdBy = user.Username
+		}
+
+		token, expiresAt, err := auth.GetJWTService().CreateShareToken(req.InstanceID, createdBy, lifetime)
+		if err != nil {
+			log.ErrorWithRequest(r, "Error creating share token: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
+			return
+		}
+
+		query := url.Values{"token": {token}}.Encode()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"url":       middleware.BasePath(r) + "/share?" + query,
+			"apiUrl":    middleware.BasePath(r) + "/api/share/info?" + query,
+			"expiresAt": expiresAt.UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+// verifyShareRequest validates the token query parameter and resolves the shared instance
+func verifyShareRequest(r *http.Request, cfg *config.Config) (*auth.ShareClaims, string, error) {
+	jwtService := auth.GetJWTService()
+	if jwtService == nil {
+		return nil, "", fmt.Errorf("sharing is not available")
+	}
+
+	claims, err := jwtService.VerifyShareToken(r.URL.Query().Get("token"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	instanceURL := findInstanceURL(cfg, claims.InstanceID)
+	if instanceURL == "" {
+		return nil, "", fmt.Errorf("shared instance no longer exists")
+	}
+
+	return claims, instanceURL, nil
+}
+
+// HandleShareInfo handles GET /api/share/info?token=X
+// Returns read-only stats for the device referenced by a share token
+func HandleShareInfo(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		claims, instanceURL, err := verifyShareRequest(r, cfg)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Share link is invalid or has expired"})
+			return
+		}
+
+		info, err := fetchShareableInfo(r.Context(), cfg, claims.InstanceID, instanceURL)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Device is currently unreachable"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"instanceId": claims.InstanceID,
+			"expiresAt":  claims.ExpiresAt.Time.UTC().Format(time.RFC3339),
+			"data":       info,
+		})
+	}
+}
+
+// HandleSharePage handles GET /share?token=X
+// Renders a read-only stats page for the device referenced by a share token
+func HandleSharePage(cfgManager *config.Manager, publicDir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+
+		claims, instanceURL, err := verifyShareRequest(r, cfg)
+		if err != nil {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "<h1>Link expired</h1><p>This share link is invalid or has expired.</p>")
+			return
+		}
+
+		htmlContent, err := os.ReadFile(filepath.Join(publicDir, "html", "share.html"))
+		if err != nil {
+			writeAssetError(w, "html/share.html", err)
+			return
+		}
+
+		var rows strings.Builder
+		info, err := fetchShareableInfo(r.Context(), cfg, claims.InstanceID, instanceURL)
+		if err != nil {
+			rows.WriteString("<tr><td colspan=\"2\">Device is currently unreachable</td></tr>")
+		} else {
+			for _, field := range shareableFields {
+				if value, ok := info[field.Key]; ok {
+					fmt.Fprintf(&rows, "<tr><th>%s</th><td>%s</td></tr>",
+						html.EscapeString(field.Label), html.EscapeString(fmt.Sprint(value)))
+				}
+			}
+		}
+
+		page := string(htmlContent)
+		page = strings.ReplaceAll(page, "<!-- TITLE -->", html.EscapeString(cfg.Title))
+		page = strings.ReplaceAll(page, "<!-- DEVICE -->", html.EscapeString(claims.InstanceID))
+		page = strings.ReplaceAll(page, "<!-- EXPIRES -->", claims.ExpiresAt.Time.Format("2006-01-02 15:04"))
+		page = strings.ReplaceAll(page, "<!-- STATS -->", rows.String())
+		page = strings.ReplaceAll(page, "<!-- CURRENT_YEAR -->", fmt.Sprintf("%d", time.Now().Year()))
+		page = strings.ReplaceAll(page, "<!-- VERSION -->", safeToFixed(cfg.AxeosDashboardVersion))
+		page = strings.ReplaceAll(page, "<!-- BASE_PATH -->", middleware.BasePath(r))
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(page))
+	}
+}
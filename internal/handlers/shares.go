@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/scheduler"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// shareRateDevice is the share rate check of one device
+type shareRateDevice struct {
+	InstanceID string `json:"instanceId"`
+	scheduler.ShareRateStatus
+	// ExpectedPerMinute is the share rate at the latest hashrate and pool difficulty
+	ExpectedPerMinute float64 `json:"expectedPerMinute"`
+}
+
+// HandleShareRates handles GET /api/shares/expected
+// Compares each device's accepted shares with the shares expected from its
+// hashrate at the pool difficulty (hashrate / (difficulty × 2^32) per
+// second). The scheduler counts both over windows expecting at least 30
+// shares; a check is diverging when the accepted shares are further from
+// the expected ones than the tolerance.
+func HandleShareRates(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		manager := scheduler.Instance()
+		if manager == nil {
+			writeDataCollectionDisabled(w)
+			return
+		}
+
+		metrics := services.LatestDeviceMetrics()
+		devices := []shareRateDevice{}
+		for id, status := range manager.ShareRates() {
+			device := shareRateDevice{InstanceID: id, ShareRateStatus: status}
+			if metric, ok := metrics[id]; ok {
+				device.ExpectedPerMinute = scheduler.ExpectedSharesPerSecond(metric.Hashrate*1e9, status.Window.PoolDifficulty) * 60
+			}
+			devices = append(devices, device)
+		}
+		sort.Slice(devices, func(i, j int) bool { return devices[i].InstanceID < devices[j].InstanceID })
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tolerancePercent": cfg.Notifications.ShareRateTolerancePercent(),
+			"devices":          devices,
+		})
+	}
+}
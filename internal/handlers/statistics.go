@@ -4,9 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"time"
 
 	"github.com/scottwalter/axeos-dashboard/internal/config"
 	"github.com/scottwalter/axeos-dashboard/internal/services"
@@ -78,15 +76,10 @@ func HandleStatistics(w http.ResponseWriter, r *http.Request, cfgManager *config
 
 	statisticsURL := fmt.Sprintf("%s%s", instanceURL, statisticsPath)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
 	// Fetch statistics from the AxeOS instance
-	resp, err := client.Get(statisticsURL)
+	resp, err := services.GetHTTPClientPool().Get(r.Context(), cfg, instanceID, statisticsURL)
 	if err != nil {
-		log.Printf("Failed to fetch statistics for %s: %v", instanceID, err)
+		log.ErrorWithRequest(r, "Failed to fetch statistics for %s: %v", instanceID, err)
 		response := StatisticsResponse{
 			Success:    false,
 			Message:    fmt.Sprintf("Failed to fetch statistics from %s: %v", instanceID, err),
@@ -102,7 +95,7 @@ func HandleStatistics(w http.ResponseWriter, r *http.Request, cfgManager *config
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Statistics endpoint returned non-OK status %d: %s", resp.StatusCode, string(body))
+		log.ErrorWithRequest(r, "Statistics endpoint returned non-OK status %d: %s", resp.StatusCode, string(body))
 		response := StatisticsResponse{
 			Success:    false,
 			Message:    fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status),
@@ -117,7 +110,7 @@ func HandleStatistics(w http.ResponseWriter, r *http.Request, cfgManager *config
 	// Read and parse the statistics data
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Failed to read statistics response: %v", err)
+		log.ErrorWithRequest(r, "Failed to read statistics response: %v", err)
 		response := StatisticsResponse{
 			Success:    false,
 			Message:    fmt.Sprintf("Failed to read statistics response: %v", err),
@@ -132,7 +125,7 @@ func HandleStatistics(w http.ResponseWriter, r *http.Request, cfgManager *config
 	// Parse the statistics data
 	var statisticsData interface{}
 	if err := json.Unmarshal(body, &statisticsData); err != nil {
-		log.Printf("Failed to parse statistics JSON: %v", err)
+		log.ErrorWithRequest(r, "Failed to parse statistics JSON: %v", err)
 		response := StatisticsResponse{
 			Success:    false,
 			Message:    fmt.Sprintf("Failed to parse statistics data: %v", err),
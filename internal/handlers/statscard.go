@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	// statsCardTokenLifetime is how long a token embedding the statistics image stays valid
+	statsCardTokenLifetime = 365 * 24 * time.Hour
+	// statsCardCacheTTL limits how often embedded images can reach the devices
+	statsCardCacheTTL = 60 * time.Second
+	// Size of the statistics image in pixels
+	statsCardWidth  = 600
+	statsCardHeight = 220
+	// statsCardTitleLength is the longest title that fits the image
+	statsCardTitleLength = 38
+)
+
+// statsCardData holds the fleet values shown on the statistics image
+type statsCardData struct {
+	Title       string
+	Devices     int
+	Online      int
+	Hashrate    float64 // GH/s
+	BestDiff    float64
+	Uptime      time.Duration // Longest running online device
+	GeneratedAt time.Time
+}
+
+type statsCardCacheEntry struct {
+	generatedAt time.Time
+	image       []byte
+}
+
+var (
+	// statsCardCache holds rendered images by format and theme
+	statsCardCache   = map[string]statsCardCacheEntry{}
+	statsCardCacheMu sync.Mutex
+)
+
+// authorizeStatsCard checks the token query parameter of a statistics image
+// request. Images are open when authentication is disabled.
+func authorizeStatsCard(r *http.Request, cfg *config.Config) bool {
+	if cfg.DisableAuthentication {
+		return true
+	}
+
+	jwtService := auth.GetJWTService()
+	if jwtService == nil {
+		return false
+	}
+
+	_, err := jwtService.VerifyScopedToken(r.URL.Query().Get("token"), auth.StatsCardScope)
+	return err == nil
+}
+
+// HandleStatsCardToken handles POST /api/stats/card/token
+// Issues a long-lived token for embedding the statistics image in forums and posts
+func HandleStatsCardToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+		return
+	}
+
+	username := "anonymous"
+	if user := middleware.GetUserFromContext(r); user != nil {
+		username = user.Username
+	}
+
+	token, err := auth.GetJWTService().CreateScopedToken(username, auth.StatsCardScope, statsCardTokenLifetime)
+	if err != nil {
+		log.ErrorWithRequest(r, "Error creating statistics image token: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
+		return
+	}
+
+	query := url.Values{"token": {token}}.Encode()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"token":     token,
+		"svgUrl":    middleware.BasePath(r) + "/api/stats/card.svg?" + query,
+		"pngUrl":    middleware.BasePath(r) + "/api/stats/card.png?" + query,
+		"expiresAt": time.Now().Add(statsCardTokenLifetime).UTC().Format(time.RFC3339),
+	})
+}
+
+// HandleStatsCard handles GET /api/stats/card.svg and /api/stats/card.png?token=X[&theme=dark]
+// Renders the fleet hashrate, best difficulty and uptime as an image for
+// embedding. Images are rendered at most once a minute per format and theme.
+func HandleStatsCard(cfgManager *config.Manager, format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		if !authorizeStatsCard(r, cfg) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Invalid or missing statistics image token"})
+			return
+		}
+
+		themeName := r.URL.Query().Get("theme")
+		theme, ok := cfg.StatsCard.FindTheme(themeName)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("Unknown theme %q", themeName)})
+			return
+		}
+
+		key := format + ":" + themeName
+		statsCardCacheMu.Lock()
+		entry, cached := statsCardCache[key]
+		statsCardCacheMu.Unlock()
+
+		if !cached || time.Since(entry.generatedAt) >= statsCardCacheTTL {
+			data := collectStatsCardData(r.Context(), cfg)
+			var body []byte
+			var err error
+			if format == "png" {
+				body, err = renderStatsCardPNG(data, theme)
+			} else {
+				body, err = renderStatsCardSVG(data, theme)
+			}
+			if err != nil {
+				log.ErrorWithRequest(r, "Error rendering statistics image: %v", err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Internal Server Error"})
+				return
+			}
+			entry = statsCardCacheEntry{generatedAt: data.GeneratedAt, image: body}
+			statsCardCacheMu.Lock()
+			statsCardCache[key] = entry
+			statsCardCacheMu.Unlock()
+		}
+
+		contentType := "image/svg+xml"
+		if format == "png" {
+			contentType = "image/png"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(statsCardCacheTTL.Seconds())))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusOK)
+		w.Write(entry.image)
+	}
+}
+
+// collectStatsCardData reads every device and totals the online ones
+func collectStatsCardData(ctx context.Context, cfg *config.Config) statsCardData {
+	ctx, cancel := context.WithTimeout(ctx, services.FleetFetchTimeout)
+	defer cancel()
+
+	data := statsCardData{Title: cfg.StatsCard.CardTitle(cfg.Title), GeneratedAt: time.Now()}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, instance := range cfg.AxeosInstances {
+		for name, instanceURL := range instance {
+			data.Devices++
+			wg.Add(1)
+			go func(name, instanceURL string) {
+				defer wg.Done()
+				info, err := fetchDeviceInfo(ctx, cfg, name, instanceURL)
+				if err != nil {
+					return
+				}
+
+				hashrate, _ := info["hashRate"].(float64)
+				bestDiff, _ := services.ParseDifficulty(info["bestDiff"])
+				uptime, _ := info["uptimeSeconds"].(float64)
+
+				mu.Lock()
+				defer mu.Unlock()
+				data.Online++
+				data.Hashrate += hashrate
+				if bestDiff > data.BestDiff {
+					data.BestDiff = bestDiff
+				}
+				if d := time.Duration(uptime) * time.Second; d > data.Uptime {
+					data.Uptime = d
+				}
+			}(name, instanceURL)
+		}
+	}
+	wg.Wait()
+	return data
+}
+
+// statsCardValues returns the labels and values of the image's columns
+func statsCardValues(data statsCardData) [3][2]string {
+	bestDiff := "-"
+	if data.BestDiff > 0 {
+		bestDiff = services.FormatDifficulty(data.BestDiff)
+	}
+	uptime := "-"
+	if data.Uptime > 0 {
+		uptime = formatCardUptime(data.Uptime)
+	}
+	return [3][2]string{
+		{"HASHRATE", services.FormatHashrate(data.Hashrate)},
+		{"BEST DIFFICULTY", bestDiff},
+		{"UPTIME", uptime},
+	}
+}
+
+// statsCardFooter returns the device count and time line of the image
+func statsCardFooter(data statsCardData) string {
+	return fmt.Sprintf("%d of %d devices online - %s", data.Online, data.Devices, data.GeneratedAt.Format("2006-01-02 15:04"))
+}
+
+// statsCardTitle shortens the title to fit the image; the PNG font only has
+// ASCII, so other characters are dropped there
+func statsCardTitle(title string, asciiOnly bool) string {
+	var runes []rune
+	for _, r := range title {
+		if asciiOnly && (r < 0x20 || r > 0x7e) {
+			continue
+		}
+		runes = append(runes, r)
+	}
+	if len(runes) > statsCardTitleLength {
+		runes = append(runes[:statsCardTitleLength-3], '.', '.', '.')
+	}
+	return string(runes)
+}
+
+// formatCardUptime formats an uptime as days and hours, or hours and minutes
+func formatCardUptime(d time.Duration) string {
+	hours := int(d.Hours())
+	if hours >= 24 {
+		return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
+
+// renderStatsCardSVG renders the statistics image as SVG
+func renderStatsCardSVG(data statsCardData, theme config.StatsCardTheme) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`,
+		statsCardWidth, statsCardHeight, statsCardWidth, statsCardHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" rx="12" fill="%s"/>`, html.EscapeString(theme.Background))
+	fmt.Fprintf(&b, `<rect width="100%%" height="6" fill="%s"/>`, html.EscapeString(theme.Accent))
+	fmt.Fprintf(&b, `<text x="24" y="52" font-size="24" font-weight="bold" fill="%s">%s</text>`,
+		html.EscapeString(theme.Accent), html.EscapeString(statsCardTitle(data.Title, false)))
+	for i, column := range statsCardValues(data) {
+		x := 24 + i*196
+		fmt.Fprintf(&b, `<text x="%d" y="104" font-size="13" fill="%s">%s</text>`, x, html.EscapeString(theme.Muted), column[0])
+		fmt.Fprintf(&b, `<text x="%d" y="140" font-size="28" font-weight="bold" fill="%s">%s</text>`,
+			x, html.EscapeString(theme.Text), html.EscapeString(column[1]))
+	}
+	fmt.Fprintf(&b, `<text x="24" y="196" font-size="13" fill="%s">%s</text>`,
+		html.EscapeString(theme.Muted), html.EscapeString(statsCardFooter(data)))
+	b.WriteString(`</svg>`)
+	return b.Bytes(), nil
+}
+
+// renderStatsCardPNG renders the statistics image as PNG with the built-in
+// bitmap font, scaled up for the title and values
+func renderStatsCardPNG(data statsCardData, theme config.StatsCardTheme) ([]byte, error) {
+	colors := make([]color.RGBA, 4)
+	for i, value := range []string{theme.Background, theme.Text, theme.Muted, theme.Accent} {
+		c, err := config.ParseHexColor(value)
+		if err != nil {
+			return nil, err
+		}
+		colors[i] = c
+	}
+	background, text, muted, accent := colors[0], colors[1], colors[2], colors[3]
+
+	img := image.NewRGBA(image.Rect(0, 0, statsCardWidth, statsCardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, statsCardWidth, 6), image.NewUniform(accent), image.Point{}, draw.Src)
+
+	drawCardText(img, statsCardTitle(data.Title, true), 24, 26, 2, accent)
+	for i, column := range statsCardValues(data) {
+		x := 24 + i*196
+		drawCardText(img, column[0], x, 92, 1, muted)
+		drawCardText(img, column[1], x, 112, 2, text)
+	}
+	drawCardText(img, statsCardFooter(data), 24, 184, 1, muted)
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// drawCardText draws text with its top left corner at x, y, scaling the
+// 7x13 bitmap font by a whole factor
+func drawCardText(dst *image.RGBA, text string, x, y, scale int, c color.Color) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	if width == 0 {
+		return
+	}
+	glyphs := image.NewRGBA(image.Rect(0, 0, width, face.Height))
+	drawer := &font.Drawer{Dst: glyphs, Src: image.NewUniform(c), Face: face, Dot: fixed.P(0, face.Ascent)}
+	drawer.DrawString(text)
+	target := image.Rect(x, y, x+width*scale, y+face.Height*scale)
+	xdraw.NearestNeighbor.Scale(dst, target, glyphs, glyphs.Bounds(), xdraw.Over, nil)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// StratumTestRequest is the body of POST /api/tools/stratum-test: either a
+// pool endpoint or the name of a pool profile
+type StratumTestRequest struct {
+	config.PoolEndpoint
+	Profile string `json:"profile,omitempty"`
+}
+
+// StratumTestResponse lists the tested endpoints
+type StratumTestResponse struct {
+	Profile string                        `json:"profile,omitempty"`
+	Results []*services.StratumTestResult `json:"results"`
+}
+
+// HandleStratumTest handles POST /api/tools/stratum-test
+// Connects to a stratum pool, subscribes and authorizes, and reports the
+// latency of each step and the difficulty the pool sets, so pool settings can
+// be checked before they are pushed to miners. A profile tests its primary
+// and fallback pools.
+func HandleStratumTest(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+
+		if r.Method != http.MethodPost {
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		var req StratumTestRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+			return
+		}
+		defer r.Body.Close()
+
+		endpoints := []config.PoolEndpoint{req.PoolEndpoint}
+		if req.Profile != "" {
+			profile, ok := cfg.FindPoolProfile(req.Profile)
+			if !ok {
+				writeJSON(http.StatusNotFound, map[string]string{"message": "Pool profile not found"})
+				return
+			}
+			endpoints = []config.PoolEndpoint{profile.PoolEndpoint}
+			if profile.Fallback != nil {
+				endpoints = append(endpoints, *profile.Fallback)
+			}
+		} else {
+			// The port may also be given in the URL
+			if _, _, _, err := services.ParseStratumURL(req.URL, req.Port); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+			if req.User == "" {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": "user is required"})
+				return
+			}
+			if err := config.ValidatePoolUser(req.User); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"status": "error", "message": err.Error()})
+				return
+			}
+		}
+
+		response := StratumTestResponse{Profile: req.Profile, Results: []*services.StratumTestResult{}}
+		for _, endpoint := range endpoints {
+			result := services.TestStratum(r.Context(), endpoint)
+			if result.Error != "" {
+				log.WarnWithRequest(r, "Stratum test of %s:%d failed: %s", result.Host, result.Port, result.Error)
+			}
+			response.Results = append(response.Results, result)
+		}
+		writeJSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// compactSummaryVersion is bumped whenever a field of the compact summary is
+// renamed, removed or changes meaning. New fields may be added within a version.
+const compactSummaryVersion = 1
+
+// CompactDevice is one device in the compact summary
+type CompactDevice = services.FleetDevice
+
+// CompactFleet holds the totals over online devices
+type CompactFleet = services.FleetTotals
+
+// CompactSummary is the response of GET /api/summary/compact
+type CompactSummary struct {
+	Version         int             `json:"version"`
+	Timestamp       int64           `json:"timestamp"` // Unix seconds
+	TemperatureUnit string          `json:"temperatureUnit"`
+	Fleet           CompactFleet    `json:"fleet"`
+	Devices         []CompactDevice `json:"devices"`
+}
+
+// HandleCompactSummary handles GET /api/summary/compact[?units=C|F]
+// Returns a minimal per-device and fleet summary for widgets, watches and scripts
+func HandleCompactSummary(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		unit := temperatureUnit(r, cfg)
+		fleet, devices := services.FetchFleetStatus(r.Context(), cfg, unit)
+
+		summary := CompactSummary{
+			Version:         compactSummaryVersion,
+			Timestamp:       time.Now().Unix(),
+			TemperatureUnit: unit,
+			Fleet:           fleet,
+			Devices:         append([]CompactDevice{}, devices...),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(summary)
+	}
+}
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/scottwalter/axeos-dashboard/internal/config"
 	"github.com/scottwalter/axeos-dashboard/internal/services"
@@ -19,9 +21,11 @@ type MinerData struct {
 	Data     map[string]interface{} `json:",inline"`
 }
 
-// MiningCoreInstanceData represents mining core instance data
+// MiningCoreInstanceData represents mining core instance data. Public pools
+// are listed the same way, with their type and a single pool.
 type MiningCoreInstanceData struct {
 	InstanceName string                   `json:"instanceName"`
+	Type         string                   `json:"type,omitempty"`
 	Status       string                   `json:"status"`
 	Message      string                   `json:"message,omitempty"`
 	Pools        []map[string]interface{} `json:"pools"`
@@ -40,175 +44,211 @@ type SystemsInfoResponse struct {
 	MiningCoreEnabled        bool                      `json:"mining_core_enabled"`
 }
 
-// HandleSystemsInfo handles GET /api/systems/info
-func HandleSystemsInfo(cfgManager *config.Manager, cryptoNodeSvc *services.CryptoNodeService) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
-		apiPath := services.GetAPIPath(cfg, "instanceInfo")
-		allMinerData := []map[string]interface{}{}
+// buildSystemsInfo gathers the systems info payload from every configured
+// miner, mining core and crypto node. Scheduler samples no older than maxAge
+// are reused instead of polling the miner again.
+func buildSystemsInfo(ctx context.Context, cfg *config.Config, cryptoNodeSvc *services.CryptoNodeService, maxAge time.Duration) SystemsInfoResponse {
+	apiPath := services.GetAPIPath(cfg, "instanceInfo")
+	allMinerData := []map[string]interface{}{}
+
+	// Fetch data from all AxeOS instances concurrently
+	var wg sync.WaitGroup
+	minerChan := make(chan map[string]interface{}, len(cfg.AxeosInstances))
+
+	for _, instance := range cfg.AxeosInstances {
+		for instanceName, instanceURL := range instance {
+			wg.Add(1)
+			go func(name, url string) {
+				defer wg.Done()
+
+				// Reuse the scheduler's sample when it is recent enough
+				if maxAge > 0 {
+					if body, collectedAt, ok := services.LatestDeviceInfo(name, maxAge); ok {
+						var data map[string]interface{}
+						if err := json.Unmarshal(body, &data); err == nil {
+							data["id"] = name
+							data["sampleSource"] = "scheduler"
+							data["collectedAt"] = collectedAt.UTC().Format(time.RFC3339)
+							data["sampleAgeSeconds"] = int(time.Since(collectedAt).Seconds())
+							minerChan <- data
+							return
+						}
+					}
+				}
 
-		// Fetch data from all AxeOS instances concurrently
-		var wg sync.WaitGroup
-		minerChan := make(chan map[string]interface{}, len(cfg.AxeosInstances))
+				resp, err := services.GetHTTPClientPool().Get(ctx, cfg, name, url+apiPath)
+				if err != nil {
+					log.Error("Network or JSON parsing error for %s (%s): %v", name, url, err)
+					minerChan <- map[string]interface{}{
+						"id":       name,
+						"hostname": name,
+						"status":   "Error",
+						"message":  err.Error(),
+					}
+					return
+				}
+				defer resp.Body.Close()
 
-		for _, instance := range cfg.AxeosInstances {
+				if resp.StatusCode != http.StatusOK {
+					log.Error("Error fetching data from %s: %d %s", url, resp.StatusCode, resp.Status)
+					minerChan <- map[string]interface{}{
+						"id":       name,
+						"hostname": name,
+						"status":   "Error",
+						"message":  fmt.Sprintf("%d %s", resp.StatusCode, resp.Status),
+					}
+					return
+				}
+
+				var data map[string]interface{}
+				if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+					log.Error("JSON parsing error for %s: %v", name, err)
+					minerChan <- map[string]interface{}{
+						"id":       name,
+						"hostname": name,
+						"status":   "Error",
+						"message":  err.Error(),
+					}
+					return
+				}
+
+				data["id"] = name
+				minerChan <- data
+			}(instanceName, instanceURL)
+		}
+	}
+
+	// Wait for all miner fetches to complete
+	go func() {
+		wg.Wait()
+		close(minerChan)
+	}()
+
+	// Collect miner data
+	for data := range minerChan {
+		allMinerData = append(allMinerData, data)
+	}
+
+	// Prepare response
+	response := SystemsInfoResponse{
+		MinerData:               allMinerData,
+		DisplayFields:           cfg.DisplayFields,
+		MiningCoreData:          []MiningCoreInstanceData{},
+		MiningCoreDisplayFields: cfg.MiningCoreDisplayFields,
+		CryptoNodeData:          nil,
+		DisableSettings:         cfg.DisableSettings,
+		DisableConfigurations:   cfg.DisableConfigurations,
+		DisableAuthentication:   cfg.DisableAuthentication,
+		MiningCoreEnabled:       cfg.MiningCoreEnabled,
+	}
+
+	// Fetch mining core data if enabled
+	if cfg.MiningCoreEnabled && len(cfg.MiningCoreURL) > 0 {
+		miningCoreAPIPath := services.GetAPIPath(cfg, "pools")
+		var mcWg sync.WaitGroup
+		mcChan := make(chan MiningCoreInstanceData, len(cfg.MiningCoreURL))
+
+		for _, instance := range cfg.MiningCoreURL {
 			for instanceName, instanceURL := range instance {
-				wg.Add(1)
+				mcWg.Add(1)
 				go func(name, url string) {
-					defer wg.Done()
+					defer mcWg.Done()
 
-					resp, err := http.Get(url + apiPath)
+					body, err := services.FetchMiningCore(cfg, url+miningCoreAPIPath)
 					if err != nil {
-						fmt.Printf("Network or JSON parsing error for %s (%s): %v\n", name, url, err)
-						minerChan <- map[string]interface{}{
-							"id":       name,
-							"hostname": name,
-							"status":   "Error",
-							"message":  err.Error(),
+						log.Error("Error fetching mining core data from %s (%s): %v", name, url, err)
+						mcChan <- MiningCoreInstanceData{
+							InstanceName: name,
+							Status:       "Error",
+							Message:      err.Error(),
+							Pools:        []map[string]interface{}{},
 						}
 						return
 					}
-					defer resp.Body.Close()
-
-					if resp.StatusCode != http.StatusOK {
-						fmt.Printf("Error fetching data from %s: %d %s\n", url, resp.StatusCode, resp.Status)
-						minerChan <- map[string]interface{}{
-							"id":       name,
-							"hostname": name,
-							"status":   "Error",
-							"message":  fmt.Sprintf("%d %s", resp.StatusCode, resp.Status),
+
+					var mcData map[string]interface{}
+					if err := json.Unmarshal(body, &mcData); err != nil {
+						log.Error("JSON parsing error for mining core %s: %v", name, err)
+						mcChan <- MiningCoreInstanceData{
+							InstanceName: name,
+							Status:       "Error",
+							Message:      err.Error(),
+							Pools:        []map[string]interface{}{},
 						}
 						return
 					}
 
-					var data map[string]interface{}
-					if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-						fmt.Printf("JSON parsing error for %s: %v\n", name, err)
-						minerChan <- map[string]interface{}{
-							"id":       name,
-							"hostname": name,
-							"status":   "Error",
-							"message":  err.Error(),
+					pools := []map[string]interface{}{}
+					if poolsData, ok := mcData["pools"].([]interface{}); ok {
+						for _, pool := range poolsData {
+							if poolMap, ok := pool.(map[string]interface{}); ok {
+								pools = append(pools, poolMap)
+							}
 						}
-						return
 					}
 
-					data["id"] = name
-					minerChan <- data
+					mcChan <- MiningCoreInstanceData{
+						InstanceName: name,
+						Status:       "OK",
+						Pools:        pools,
+					}
 				}(instanceName, instanceURL)
 			}
 		}
 
-		// Wait for all miner fetches to complete
 		go func() {
-			wg.Wait()
-			close(minerChan)
+			mcWg.Wait()
+			close(mcChan)
 		}()
 
-		// Collect miner data
-		for data := range minerChan {
-			allMinerData = append(allMinerData, data)
-		}
-
-		// Prepare response
-		response := SystemsInfoResponse{
-			MinerData:               allMinerData,
-			DisplayFields:           cfg.DisplayFields,
-			MiningCoreData:          []MiningCoreInstanceData{},
-			MiningCoreDisplayFields: cfg.MiningCoreDisplayFields,
-			CryptoNodeData:          nil,
-			DisableSettings:         cfg.DisableSettings,
-			DisableConfigurations:   cfg.DisableConfigurations,
-			DisableAuthentication:   cfg.DisableAuthentication,
-			MiningCoreEnabled:       cfg.MiningCoreEnabled,
+		for data := range mcChan {
+			response.MiningCoreData = append(response.MiningCoreData, data)
 		}
+	}
 
-		// Fetch mining core data if enabled
-		if cfg.MiningCoreEnabled && len(cfg.MiningCoreURL) > 0 {
-			miningCoreAPIPath := services.GetAPIPath(cfg, "pools")
-			var mcWg sync.WaitGroup
-			mcChan := make(chan MiningCoreInstanceData, len(cfg.MiningCoreURL))
-
-			for _, instance := range cfg.MiningCoreURL {
-				for instanceName, instanceURL := range instance {
-					mcWg.Add(1)
-					go func(name, url string) {
-						defer mcWg.Done()
-
-						resp, err := http.Get(url + miningCoreAPIPath)
-						if err != nil {
-							fmt.Printf("Network error for mining core %s (%s): %v\n", name, url, err)
-							mcChan <- MiningCoreInstanceData{
-								InstanceName: name,
-								Status:       "Error",
-								Message:      err.Error(),
-								Pools:        []map[string]interface{}{},
-							}
-							return
-						}
-						defer resp.Body.Close()
-
-						if resp.StatusCode != http.StatusOK {
-							fmt.Printf("Error fetching mining core data from %s: %d %s\n", url, resp.StatusCode, resp.Status)
-							mcChan <- MiningCoreInstanceData{
-								InstanceName: name,
-								Status:       "Error",
-								Message:      fmt.Sprintf("%d %s", resp.StatusCode, resp.Status),
-								Pools:        []map[string]interface{}{},
-							}
-							return
-						}
-
-						var mcData map[string]interface{}
-						if err := json.NewDecoder(resp.Body).Decode(&mcData); err != nil {
-							fmt.Printf("JSON parsing error for mining core %s: %v\n", name, err)
-							mcChan <- MiningCoreInstanceData{
-								InstanceName: name,
-								Status:       "Error",
-								Message:      err.Error(),
-								Pools:        []map[string]interface{}{},
-							}
-							return
-						}
-
-						pools := []map[string]interface{}{}
-						if poolsData, ok := mcData["pools"].([]interface{}); ok {
-							for _, pool := range poolsData {
-								if poolMap, ok := pool.(map[string]interface{}); ok {
-									pools = append(pools, poolMap)
-								}
-							}
-						}
-
-						mcChan <- MiningCoreInstanceData{
-							InstanceName: name,
-							Status:       "OK",
-							Pools:        pools,
-						}
-					}(instanceName, instanceURL)
+	// Fetch the payout address stats of public pools
+	if len(cfg.PublicPools) > 0 {
+		publicData := make([]MiningCoreInstanceData, len(cfg.PublicPools))
+		var ppWg sync.WaitGroup
+		for i, pool := range cfg.PublicPools {
+			ppWg.Add(1)
+			go func(i int, pool config.PublicPool) {
+				defer ppWg.Done()
+				data := MiningCoreInstanceData{InstanceName: pool.Name, Type: pool.Type, Pools: []map[string]interface{}{}}
+				stats, err := services.FetchPublicPool(cfg, pool)
+				if err != nil {
+					log.Error("Error fetching public pool data from %s: %v", pool.Name, err)
+					data.Status, data.Message = "Error", err.Error()
+				} else {
+					data.Status = "OK"
+					data.Pools = append(data.Pools, stats.SystemsInfo(pool))
 				}
-			}
-
-			go func() {
-				mcWg.Wait()
-				close(mcChan)
-			}()
-
-			for data := range mcChan {
-				response.MiningCoreData = append(response.MiningCoreData, data)
-			}
+				publicData[i] = data
+			}(i, pool)
 		}
+		ppWg.Wait()
+		response.MiningCoreData = append(response.MiningCoreData, publicData...)
+	}
 
-		// Fetch crypto node data if enabled
-		if cfg.CryptNodesEnabled && cryptoNodeSvc != nil {
-			cryptoNodeData, err := cryptoNodeSvc.FetchAllCryptoNodes(cfg)
-			if err != nil {
-				fmt.Printf("Error fetching crypto node data: %v\n", err)
-				response.CryptoNodeData = []interface{}{}
-			} else {
-				response.CryptoNodeData = cryptoNodeData
-			}
+	// Fetch crypto node data if enabled
+	if cfg.CryptNodesEnabled && cryptoNodeSvc != nil {
+		cryptoNodeData, err := cryptoNodeSvc.FetchAllCryptoNodes(cfg)
+		if err != nil {
+			log.Error("Error fetching crypto node data: %v", err)
+			response.CryptoNodeData = []interface{}{}
+		} else {
+			response.CryptoNodeData = cryptoNodeData
 		}
+	}
+
+	return response
+}
+
+// HandleSystemsInfo handles GET /api/systems/info
+func HandleSystemsInfo(cfgManager *config.Manager, cryptoNodeSvc *services.CryptoNodeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		response := buildSystemsInfo(r.Context(), cfg, cryptoNodeSvc, time.Duration(cfg.SystemsInfoMaxAgeSeconds)*time.Second)
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// HandleStatusText handles GET /status.txt[?color=1&units=C|F]
+// Returns the fleet status as a plain text table for terminals, e.g.
+// `watch -n 10 curl -s ...`. color=1 adds ANSI colors (use `watch --color`).
+func HandleStatusText(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+		if r.Method != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Method Not Allowed"})
+			return
+		}
+
+		color, _ := strconv.ParseBool(r.URL.Query().Get("color"))
+		unit := temperatureUnit(r, cfg)
+		fleet, devices := services.FetchFleetStatus(r.Context(), cfg, unit)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(services.RenderFleetStatus(cfg.Title, unit, fleet, devices, time.Now(), color)))
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// temperatureUnit returns the unit requested with ?units=C|F, or the configured temperature_unit
+func temperatureUnit(r *http.Request, cfg *config.Config) string {
+	if unit, ok := config.NormalizeTemperatureUnit(r.URL.Query().Get("units")); ok {
+		return unit
+	}
+	if cfg.TemperatureUnit == "" {
+		return config.UnitCelsius
+	}
+	return cfg.TemperatureUnit
+}
+
+// hashrateUnit returns the unit requested with ?hashrateUnit=, or native (the
+// unit the source reports) when none is requested. ok is false for unknown units.
+func hashrateUnit(r *http.Request, native string) (unit string, ok bool) {
+	value := r.URL.Query().Get("hashrateUnit")
+	if value == "" {
+		return native, true
+	}
+	return config.NormalizeHashrateUnit(value)
+}
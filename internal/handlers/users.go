@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/middleware"
+)
+
+// minPasswordLength is the shortest password accepted for new and changed passwords
+const minPasswordLength = 8
+
+// CreateUserRequest is the body of POST /api/users
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"` // "admin" or "viewer"; defaults to viewer
+}
+
+// ChangePasswordRequest is the body of PUT /api/users/{username}/password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword,omitempty"` // Required to change your own password
+	Password        string `json:"password"`
+}
+
+// loginPasswordHash returns the hash stored in access.json for a password.
+// The login page sends the SHA256 digest of the password, so the digest is what gets hashed.
+func loginPasswordHash(password string) (string, error) {
+	digest := sha256.Sum256([]byte(password))
+	return auth.HashPassword(hex.EncodeToString(digest[:]))
+}
+
+// revokeUserTokens ends every session of a user: issued access tokens are
+// revoked and refresh tokens removed. It returns the number of refresh sessions ended.
+func revokeUserTokens(username string) (int, error) {
+	if list := auth.GetRevocationList(); list != nil {
+		if err := list.RevokeUser(username); err != nil {
+			return 0, err
+		}
+	}
+	if store := auth.GetSessionStore(); store != nil {
+		return store.RevokeUser(username)
+	}
+	return 0, nil
+}
+
+// HandleUsers handles /api/users and /api/users/{username}
+//
+//	GET    /api/users                     - list users and their roles (admin)
+//	POST   /api/users                     - add a user (admin)
+//	DELETE /api/users/{username}          - remove a user (admin)
+//	PUT    /api/users/{username}/password - change a password; any user may change
+//	                                        their own with currentPassword
+//
+// Users are stored in access.json. Removing a user or changing a password
+// ends every session of that user.
+func HandleUsers(cfgManager *config.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		configDir := cfgManager.GetConfigDir()
+		writeJSON := func(status int, body interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		forbidden := func() {
+			writeJSON(http.StatusForbidden, map[string]string{"message": "Forbidden: admin role required"})
+		}
+		accessError := func(err error) {
+			switch {
+			case errors.Is(err, auth.ErrUnknownUser):
+				writeJSON(http.StatusNotFound, map[string]string{"message": "User not found"})
+			case errors.Is(err, auth.ErrUserExists), errors.Is(err, auth.ErrLastAdmin), errors.Is(err, auth.ErrAccessFileLocked):
+				writeJSON(http.StatusConflict, map[string]string{"message": err.Error()})
+			default:
+				log.ErrorWithRequest(r, "Error updating access.json: %v", err)
+				writeJSON(http.StatusInternalServerError, map[string]string{"status": "error", "message": err.Error()})
+			}
+		}
+
+		username := "anonymous"
+		if user := middleware.GetUserFromContext(r); user != nil {
+			username = user.Username
+		}
+
+		target, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users"), "/"), "/")
+
+		switch {
+		case target == "" && r.Method == http.MethodGet:
+			if !middleware.IsAdmin(r) {
+				forbidden()
+				return
+			}
+			users, err := auth.ListUsers(configDir)
+			if err != nil {
+				accessError(err)
+				return
+			}
+			writeJSON(http.StatusOK, map[string]interface{}{"users": users})
+
+		case target == "" && r.Method == http.MethodPost:
+			if !middleware.IsAdmin(r) {
+				forbidden()
+				return
+			}
+			var req CreateUserRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+				return
+			}
+			defer r.Body.Close()
+
+			req.Username = strings.TrimSpace(req.Username)
+			if err := auth.ValidateUsername(req.Username); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+				return
+			}
+			if len(req.Password) < minPasswordLength {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+				return
+			}
+			role := auth.RoleViewer
+			if req.Role != "" {
+				role = auth.NormalizeRole(req.Role)
+			}
+			hash, err := loginPasswordHash(req.Password)
+			if err != nil {
+				accessError(err)
+				return
+			}
+			if err := auth.CreateUser(configDir, req.Username, role, hash); err != nil {
+				accessError(err)
+				return
+			}
+			log.InfoWithRequest(r, "%s added user %s (%s)", username, req.Username, role)
+			writeJSON(http.StatusCreated, auth.UserInfo{Username: req.Username, Role: role})
+
+		case target != "" && action == "" && r.Method == http.MethodDelete:
+			if !middleware.IsAdmin(r) {
+				forbidden()
+				return
+			}
+			if err := auth.DeleteUser(configDir, target); err != nil {
+				accessError(err)
+				return
+			}
+			sessions, err := revokeUserTokens(target)
+			if err != nil {
+				log.ErrorWithRequest(r, "Error revoking tokens of %s: %v", target, err)
+			}
+			log.InfoWithRequest(r, "%s removed user %s (%d session(s) ended)", username, target, sessions)
+			writeJSON(http.StatusOK, map[string]interface{}{"status": "success", "revokedSessions": sessions})
+
+		case target != "" && action == "password" && r.Method == http.MethodPut:
+			self := target == username
+			if !self && !middleware.IsAdmin(r) {
+				forbidden()
+				return
+			}
+			var req ChangePasswordRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": "Invalid JSON in request body"})
+				return
+			}
+			defer r.Body.Close()
+
+			if len(req.Password) < minPasswordLength {
+				writeJSON(http.StatusBadRequest, map[string]string{"message": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+				return
+			}
+			if self {
+				// A stolen session alone must not be enough to take over the account
+				digest := sha256.Sum256([]byte(req.CurrentPassword))
+				users, err := auth.LoadAccessCredentials(configDir)
+				if err != nil {
+					accessError(err)
+					return
+				}
+				if valid, _ := auth.VerifyPassword(users[target].Password, hex.EncodeToString(digest[:])); !valid {
+					writeJSON(http.StatusForbidden, map[string]string{"message": "Current password is incorrect"})
+					return
+				}
+			}
+
+			hash, err := loginPasswordHash(req.Password)
+			if err != nil {
+				accessError(err)
+				return
+			}
+			if err := auth.ChangePassword(configDir, target, hash); err != nil {
+				accessError(err)
+				return
+			}
+			sessions, err := revokeUserTokens(target)
+			if err != nil {
+				log.ErrorWithRequest(r, "Error revoking tokens of %s: %v", target, err)
+			}
+			if self {
+				middleware.ClearSessionCookies(w, r)
+			}
+			log.InfoWithRequest(r, "%s changed the password of %s (%d session(s) ended)", username, target, sessions)
+			writeJSON(http.StatusOK, map[string]interface{}{"status": "success", "revokedSessions": sessions})
+
+		case target == "" || action == "" || action == "password":
+			writeJSON(http.StatusMethodNotAllowed, map[string]string{"message": "Method Not Allowed"})
+
+		default:
+			writeJSON(http.StatusNotFound, map[string]string{"message": "Not Found"})
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Package jobs runs long-running operations (bulk updates, benchmarks, scans)
+// in the background with progress reporting and cancellation
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// workerCount is how many jobs run at the same time
+	workerCount = 2
+	// queueSize is how many jobs may wait for a worker
+	queueSize = 100
+	// retention is how long finished jobs can be polled
+	retention = 24 * time.Hour
+)
+
+var (
+	// ErrNotFound is returned for unknown job ids
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when cancelling a job that already ended
+	ErrFinished = errors.New("job already finished")
+	// ErrQueueFull is returned when too many jobs are waiting
+	ErrQueueFull = errors.New("job queue is full, try again later")
+)
+
+// Func is the work of a job. It should stop early when ctx is cancelled and
+// report progress as it goes. The returned value is exposed as the job result.
+type Func func(ctx context.Context, progress *Progress) (interface{}, error)
+
+// Factory builds the work of a job type from the parameters posted to /api/jobs
+type Factory func(params json.RawMessage) (Func, error)
+
+// Job is the status of a background job
+type Job struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Status     string      `json:"status"`
+	Done       int         `json:"done"`
+	Total      int         `json:"total"` // 0 when the amount of work is unknown
+	Message    string      `json:"message,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedBy  string      `json:"createdBy"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+
+	fn     Func
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// finished reports whether the job has ended
+func (j *Job) finished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Progress lets a running job report how far it got
+type Progress struct {
+	manager *Manager
+	job     *Job
+}
+
+// SetTotal sets the number of steps the job will take
+func (p *Progress) SetTotal(total int) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	p.job.Total = total
+}
+
+// Step marks one more step as done, with an optional status message
+func (p *Progress) Step(message string) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	p.job.Done++
+	if message != "" {
+		p.job.Message = message
+	}
+}
+
+// SetMessage updates the status message without advancing progress
+func (p *Progress) SetMessage(message string) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	p.job.Message = message
+}
+
+// Manager queues jobs and runs them on a fixed pool of workers
+type Manager struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	queue     chan *Job
+	factories map[string]Factory
+	log       *logger.Logger
+}
+
+var (
+	instance *Manager
+	once     sync.Once
+)
+
+// GetManager returns the singleton job manager, starting its workers on first use
+func GetManager() *Manager {
+	once.Do(func() {
+		instance = &Manager{
+			jobs:      make(map[string]*Job),
+			queue:     make(chan *Job, queueSize),
+			factories: make(map[string]Factory),
+			log:       logger.New(logger.ModuleService),
+		}
+		for i := 0; i < workerCount; i++ {
+			go instance.worker()
+		}
+	})
+	return instance
+}
+
+// Register makes a job type startable through POST /api/jobs
+func (m *Manager) Register(jobType string, factory Factory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.factories[jobType] = factory
+}
+
+// Types returns the job types that can be started through the API
+func (m *Manager) Types() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	types := make([]string, 0, len(m.factories))
+	for jobType := range m.factories {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Start builds a job of a registered type from its parameters and queues it
+func (m *Manager) Start(jobType string, params json.RawMessage, createdBy string) (Job, error) {
+	m.mu.Lock()
+	factory, ok := m.factories[jobType]
+	m.mu.Unlock()
+	if !ok {
+		return Job{}, fmt.Errorf("unknown job type %q", jobType)
+	}
+
+	fn, err := factory(params)
+	if err != nil {
+		return Job{}, err
+	}
+	return m.Submit(jobType, createdBy, fn)
+}
+
+// Submit queues a job and returns its initial status
+func (m *Manager) Submit(jobType, createdBy string, fn Func) (Job, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        newID(),
+		Type:      jobType,
+		Status:    StatusQueued,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		fn:        fn,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prune()
+	select {
+	case m.queue <- job:
+	default:
+		cancel()
+		return Job{}, ErrQueueFull
+	}
+	m.jobs[job.ID] = job
+
+	m.log.Info("Queued %s job %s for %s", jobType, job.ID, createdBy)
+	return *job, nil
+}
+
+// Get returns the status of a job
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// List returns all known jobs, newest first
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		list = append(list, *job)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list
+}
+
+// Cancel stops a job. A queued job is cancelled immediately; a running job is
+// asked to stop through its context and is marked cancelled when it returns.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if job.finished() {
+		return *job, ErrFinished
+	}
+
+	job.cancel()
+	if job.Status == StatusQueued {
+		now := time.Now()
+		job.Status = StatusCancelled
+		job.FinishedAt = &now
+	} else {
+		job.Message = "Cancelling..."
+	}
+	return *job, nil
+}
+
+// prune drops finished jobs past their retention; the caller holds m.mu
+func (m *Manager) prune() {
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > retention {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// worker runs queued jobs one at a time
+func (m *Manager) worker() {
+	for job := range m.queue {
+		m.mu.Lock()
+		if job.Status != StatusQueued {
+			m.mu.Unlock()
+			continue // Cancelled while waiting
+		}
+		now := time.Now()
+		job.Status = StatusRunning
+		job.StartedAt = &now
+		m.mu.Unlock()
+
+		result, err := m.run(job)
+
+		m.mu.Lock()
+		finished := time.Now()
+		job.FinishedAt = &finished
+		job.Result = result
+		switch {
+		case job.ctx.Err() != nil:
+			job.Status = StatusCancelled
+			job.Message = ""
+		case err != nil:
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		default:
+			job.Status = StatusCompleted
+		}
+		job.cancel()
+		status := job.Status
+		m.mu.Unlock()
+
+		m.log.Info("Job %s (%s) %s in %v", job.ID, job.Type, status, finished.Sub(now).Round(time.Millisecond))
+	}
+}
+
+// run calls the job function, turning a panic into a job failure
+func (m *Manager) run(job *Job) (result interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			m.log.Error("Job %s (%s) panicked: %v", job.ID, job.Type, p)
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return job.fn(job.ctx, &Progress{manager: m, job: job})
+}
+
+// newID returns a random job identifier
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
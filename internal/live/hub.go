@@ -0,0 +1,166 @@
+// Package live broadcasts the scheduler's collection results to connected dashboards
+package live
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// Source kinds published by the scheduler
+const (
+	KindAxeOS = "axeos"
+	KindPool  = "pool"
+	KindNode  = "node"
+)
+
+// clientBuffer is how many messages may queue for a client before it is dropped as too slow
+const clientBuffer = 32
+
+// Message is sent to clients: a full snapshot on connect, then deltas
+type Message struct {
+	Type      string                                       `json:"type"` // "snapshot" or "delta"
+	Kind      string                                       `json:"kind,omitempty"`
+	ID        string                                       `json:"id,omitempty"`
+	Timestamp time.Time                                    `json:"timestamp"`
+	Changes   map[string]interface{}                       `json:"changes,omitempty"`
+	Systems   map[string]map[string]map[string]interface{} `json:"systems,omitempty"`
+}
+
+// Sample is the last metric published for a source
+type Sample struct {
+	Metric    interface{}
+	UpdatedAt time.Time
+}
+
+// Hub keeps the latest values of every source and fans out changes to subscribers
+type Hub struct {
+	mu      sync.Mutex
+	state   map[string]map[string]map[string]interface{} // kind -> id -> field -> value
+	latest  map[string]map[string]Sample                 // kind -> id -> last published metric
+	clients map[chan []byte]struct{}
+	log     *logger.Logger
+}
+
+var (
+	hub     *Hub
+	hubOnce sync.Once
+)
+
+// GetHub returns the singleton hub
+func GetHub() *Hub {
+	hubOnce.Do(func() {
+		hub = &Hub{
+			state: map[string]map[string]map[string]interface{}{
+				KindAxeOS: {},
+				KindPool:  {},
+				KindNode:  {},
+			},
+			latest: map[string]map[string]Sample{
+				KindAxeOS: {},
+				KindPool:  {},
+				KindNode:  {},
+			},
+			clients: make(map[chan []byte]struct{}),
+			log:     logger.New(logger.ModuleService),
+		}
+	})
+	return hub
+}
+
+// Publish records a collected metric and broadcasts the fields that changed
+// since the previous one for the same source
+func (h *Hub) Publish(kind, id string, metric interface{}) {
+	data, err := json.Marshal(metric)
+	if err != nil {
+		return
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return
+	}
+	// The timestamp always changes; it is sent with the message instead
+	delete(fields, "timestamp")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.state[kind][id]
+	changes := map[string]interface{}{}
+	for field, value := range fields {
+		if old, ok := previous[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = value
+		}
+	}
+	h.state[kind][id] = fields
+	h.latest[kind][id] = Sample{Metric: metric, UpdatedAt: time.Now()}
+
+	if len(changes) == 0 || len(h.clients) == 0 {
+		return
+	}
+
+	message, err := json.Marshal(Message{
+		Type:      "delta",
+		Kind:      kind,
+		ID:        id,
+		Timestamp: time.Now(),
+		Changes:   changes,
+	})
+	if err != nil {
+		return
+	}
+
+	for client := range h.clients {
+		select {
+		case client <- message:
+		default:
+			// Slow client; drop it rather than block collection
+			h.log.Warn("Dropping slow live update client")
+			delete(h.clients, client)
+			close(client)
+		}
+	}
+}
+
+// Subscribe registers a client. It returns the current snapshot message, a
+// channel of delta messages (closed if the client falls behind) and a
+// function to unsubscribe.
+func (h *Hub) Subscribe() ([]byte, <-chan []byte, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot, _ := json.Marshal(Message{
+		Type:      "snapshot",
+		Timestamp: time.Now(),
+		Systems:   h.state,
+	})
+
+	client := make(chan []byte, clientBuffer)
+	h.clients[client] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[client]; ok {
+			delete(h.clients, client)
+			close(client)
+		}
+	}
+
+	return snapshot, client, unsubscribe
+}
+
+// Latest returns the last published metric of every source of a kind
+func (h *Hub) Latest(kind string) map[string]Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := make(map[string]Sample, len(h.latest[kind]))
+	for id, sample := range h.latest[kind] {
+		samples[id] = sample
+	}
+	return samples
+}
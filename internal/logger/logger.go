@@ -1,11 +1,14 @@
 package logger
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,18 +26,128 @@ const (
 	ModuleAuth       Module = "auth"
 )
 
+// Level is the severity of a log message
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	levelFatal // Always written
+)
+
+// String returns the name of a level as used in configuration and JSON logs
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "fatal"
+	}
+}
+
+// ParseLevel parses debug, info, warn (or warning) or error
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+}
+
+// Options configures where and how every logger writes
+type Options struct {
+	Level  Level
+	JSON   bool // One JSON object per line instead of the text format
+	Stdout bool
+	File   string // Log file, rotated by size; empty for none
+	// MaxSizeMB and MaxFiles control the rotation of File
+	MaxSizeMB int
+	MaxFiles  int
+}
+
+// output holds the shared settings of all loggers. Until Configure is
+// called, loggers write text at info level to stdout.
+var output = struct {
+	sync.Mutex
+	level  Level
+	json   bool
+	writer io.Writer
+	file   *rotatingFile
+}{level: LevelInfo, writer: os.Stdout}
+
+// Configure applies logging options to every logger. The previous log file,
+// if any, is closed.
+func Configure(opts Options) error {
+	var writers []io.Writer
+	var file *rotatingFile
+	if opts.File != "" {
+		var err error
+		file, err = openRotatingFile(opts.File, opts.MaxSizeMB, opts.MaxFiles)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, file)
+	}
+	if opts.Stdout || len(writers) == 0 {
+		writers = append([]io.Writer{os.Stdout}, writers...)
+	}
+
+	output.Lock()
+	defer output.Unlock()
+	if output.file != nil {
+		output.file.Close()
+	}
+	output.level = opts.Level
+	output.json = opts.JSON
+	output.writer = io.MultiWriter(writers...)
+	output.file = file
+	return nil
+}
+
+// Enabled reports whether messages of a level are written
+func Enabled(level Level) bool {
+	output.Lock()
+	defer output.Unlock()
+	return level >= output.level
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a request ID. Messages logged
+// with a request of that context include the ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of a context, or "" when it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
 // Logger provides structured logging functionality
 type Logger struct {
 	module Module
-	logger *log.Logger
 }
 
 // New creates a new logger for the specified module
 func New(module Module) *Logger {
-	return &Logger{
-		module: module,
-		logger: log.New(os.Stdout, "", 0), // No prefix, we'll format ourselves
-	}
+	return &Logger{module: module}
 }
 
 // getClientIP extracts the client IP from the request
@@ -62,71 +175,93 @@ func getClientIP(r *http.Request) string {
 }
 
 // formatMessage formats the log message with standard format:
-// [timestamp] [client_ip] [module] action
-func (l *Logger) formatMessage(clientIP, action string) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+// [timestamp] [client_ip] [module] action, or
+// [timestamp] [client_ip] [module] [request_id] action for requests with an ID
+func (l *Logger) formatMessage(now time.Time, clientIP, requestID, action string) string {
+	timestamp := now.Format("2006-01-02 15:04:05")
+
+	if clientIP == "" {
+		clientIP = "system"
+	}
+	if requestID != "" {
+		return fmt.Sprintf("[%s] [%s] [%s] [%s] %s", timestamp, clientIP, l.module, requestID, action)
+	}
+	return fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, clientIP, l.module, action)
+}
+
+// jsonEntry is one line of the JSON log format
+type jsonEntry struct {
+	Time      string `json:"time"`
+	Level     string `json:"level"`
+	Module    Module `json:"module"`
+	Client    string `json:"client,omitempty"` // Client IP of request messages
+	RequestID string `json:"requestId,omitempty"`
+	Message   string `json:"msg"`
+}
 
-	if clientIP != "" {
-		return fmt.Sprintf("[%s] [%s] [%s] %s", timestamp, clientIP, l.module, action)
+// write formats a message and writes it when its level is enabled
+func (l *Logger) write(level Level, clientIP, requestID, format string, args ...interface{}) {
+	output.Lock()
+	defer output.Unlock()
+	if level < output.level {
+		return
 	}
-	return fmt.Sprintf("[%s] [system] [%s] %s", timestamp, l.module, action)
+
+	now := time.Now()
+	action := fmt.Sprintf(format, args...)
+	var line []byte
+	if output.json {
+		line, _ = json.Marshal(jsonEntry{
+			Time:      now.Format(time.RFC3339Nano),
+			Level:     level.String(),
+			Module:    l.module,
+			Client:    clientIP,
+			RequestID: requestID,
+			Message:   action,
+		})
+	} else {
+		line = []byte(l.formatMessage(now, clientIP, requestID, action))
+	}
+	output.writer.Write(append(line, '\n'))
 }
 
 // Info logs an informational message (system-level, no client IP)
 func (l *Logger) Info(format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	msg := l.formatMessage("", action)
-	l.logger.Println(msg)
+	l.write(LevelInfo, "", "", format, args...)
 }
 
 // InfoWithRequest logs an informational message with client IP from request
 func (l *Logger) InfoWithRequest(r *http.Request, format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	clientIP := getClientIP(r)
-	msg := l.formatMessage(clientIP, action)
-	l.logger.Println(msg)
+	l.write(LevelInfo, getClientIP(r), RequestID(r.Context()), format, args...)
 }
 
 // Error logs an error message (system-level, no client IP)
 func (l *Logger) Error(format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	msg := l.formatMessage("", action)
-	l.logger.Println(msg)
+	l.write(LevelError, "", "", format, args...)
 }
 
 // ErrorWithRequest logs an error message with client IP from request
 func (l *Logger) ErrorWithRequest(r *http.Request, format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	clientIP := getClientIP(r)
-	msg := l.formatMessage(clientIP, action)
-	l.logger.Println(msg)
+	l.write(LevelError, getClientIP(r), RequestID(r.Context()), format, args...)
 }
 
 // Fatal logs a fatal error and exits the program
 func (l *Logger) Fatal(format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	msg := l.formatMessage("", action)
-	l.logger.Fatal(msg)
+	l.write(levelFatal, "", "", format, args...)
+	os.Exit(1)
 }
 
 // Warn logs a warning message (system-level, no client IP)
 func (l *Logger) Warn(format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	msg := l.formatMessage("", action)
-	l.logger.Println(msg)
+	l.write(LevelWarn, "", "", format, args...)
 }
 
 // WarnWithRequest logs a warning message with client IP from request
 func (l *Logger) WarnWithRequest(r *http.Request, format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	clientIP := getClientIP(r)
-	msg := l.formatMessage(clientIP, action)
-	l.logger.Println(msg)
+	l.write(LevelWarn, getClientIP(r), RequestID(r.Context()), format, args...)
 }
 
 // Debug logs a debug message (system-level, no client IP)
 func (l *Logger) Debug(format string, args ...interface{}) {
-	action := fmt.Sprintf(format, args...)
-	msg := l.formatMessage("", action)
-	l.logger.Println(msg)
+	l.write(LevelDebug, "", "", format, args...)
 }
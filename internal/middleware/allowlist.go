@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// AllowlistMiddleware serves clients whose IP is in the allowlist directly and
+// passes everyone else through fallback (usually the JWT auth middleware)
+func AllowlistMiddleware(cfgManager *config.Manager, allowlist func(*config.Config) []string, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := fallback(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := cfgManager.GetConfig() // Get fresh config for hot reload
+			if config.IPAllowed(net.ParseIP(ClientIP(r)), allowlist(cfg)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
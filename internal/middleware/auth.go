@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/scottwalter/axeos-dashboard/internal/auth"
 	"github.com/scottwalter/axeos-dashboard/internal/config"
@@ -17,6 +20,14 @@ const UserContextKey contextKey = "user"
 // User represents authenticated user information
 type User struct {
 	Username string
+	Role     string
+	Scopes   []string // Scopes of an API key; empty for every permission of the role
+}
+
+// HasScope reports whether the user may act within an API key scope. Users
+// without scopes may do everything their role allows.
+func (u *User) HasScope(scope string) bool {
+	return len(u.Scopes) == 0 || slices.Contains(u.Scopes, scope)
 }
 
 // AuthMiddleware creates a middleware that checks JWT authentication
@@ -32,33 +43,87 @@ func AuthMiddleware(cfgManager *config.Manager, requireJWT bool) func(http.Handl
 				return
 			}
 
-			// Get token from cookie
-			cookie, err := r.Cookie("sessionToken")
+			// Machine clients send an API key instead of the session cookie
+			if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+				var key auth.APIKey
+				ok := false
+				if store := auth.GetAPIKeyStore(); store != nil {
+					key, ok = store.Verify(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+				}
+				if !ok {
+					log.WarnWithRequest(r, "API key verification failed")
+					w.Header().Set("WWW-Authenticate", `Bearer realm="axeos-dashboard"`)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusUnauthorized)
+					json.NewEncoder(w).Encode(map[string]string{"message": "Invalid API key"})
+					return
+				}
+
+				// Every scope reads; anything else needs a scope that writes
+				user := &User{Username: "apikey:" + key.Name, Role: key.Role, Scopes: key.Scopes}
+				if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions &&
+					!user.HasScope(auth.ScopeWriteSettings) && !user.HasScope(auth.ScopeAdminConfig) {
+					log.WarnWithRequest(r, "Forbidden: API key %q is read-only", key.Name)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					json.NewEncoder(w).Encode(map[string]string{"message": "Forbidden: API key scope " + auth.ScopeReadMetrics + " is read-only"})
+					return
+				}
+				ctx := context.WithValue(r.Context(), UserContextKey, user)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Providers such as header trust identify the user from the request itself
+			provider, err := auth.NewProvider(cfgManager.GetConfigDir(), cfg)
 			if err != nil {
-				// No token found, redirect to login
-				http.Redirect(w, r, "/login", http.StatusFound)
+				log.ErrorWithRequest(r, "Authentication provider unavailable: %v", err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Server configuration error."})
 				return
 			}
+			if requestAuth, ok := provider.(auth.RequestAuthenticator); ok {
+				if identity, ok := requestAuth.AuthenticateRequest(r, ClientIP(r)); ok {
+					user := &User{Username: identity.Username, Role: identity.Role}
+					ctx := context.WithValue(r.Context(), UserContextKey, user)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
 
-			// Verify token
-			jwtService := auth.GetJWTService()
-			claims, err := jwtService.VerifyToken(cookie.Value)
+			// Get token from cookie
+			var claims *auth.Claims
+			cookie, err := r.Cookie(SessionCookie)
+			if err == nil {
+				// Verify token
+				claims, err = auth.GetJWTService().VerifyToken(cookie.Value)
+			}
 			if err != nil {
-				// Token is invalid or expired, redirect to login and clear cookie
+				// An expired access token is renewed with the refresh token, if the login has one
+				if _, refreshErr := r.Cookie(RefreshCookie); refreshErr == nil {
+					user, _, renewErr := RenewSession(w, r, cfg)
+					if renewErr == nil {
+						ctx := context.WithValue(r.Context(), UserContextKey, user)
+						next.ServeHTTP(w, r.WithContext(ctx))
+						return
+					}
+					log.WarnWithRequest(r, "Session renewal failed: %v", renewErr)
+				} else if cookie == nil {
+					// No token found, redirect to login
+					Redirect(w, r, "/login", http.StatusFound)
+					return
+				}
+
+				// Token is invalid or expired, redirect to login and clear cookies
 				log.WarnWithRequest(r, "JWT verification failed, redirecting to login: %v", err)
-				http.SetCookie(w, &http.Cookie{
-					Name:     "sessionToken",
-					Value:    "",
-					Path:     "/",
-					HttpOnly: true,
-					MaxAge:   -1,
-				})
-				http.Redirect(w, r, "/login", http.StatusFound)
+				ClearSessionCookies(w, r)
+				Redirect(w, r, "/login", http.StatusFound)
 				return
 			}
 
 			// Add user to context
-			user := &User{Username: claims.Username}
+			user := &User{Username: claims.Username, Role: claims.Role}
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -79,6 +144,15 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	log := logger.New(logger.ModuleMiddleware)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every request gets an ID, kept from a proxy's X-Request-ID when valid,
+		// so the log lines of a request can be found together
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		r = r.WithContext(logger.WithRequestID(r.Context(), requestID))
+
 		// Skip logging for health check endpoint to avoid log clutter
 		if strings.Contains(r.URL.Path, "health.html") {
 			next.ServeHTTP(w, r)
@@ -86,8 +160,30 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Log the request with client IP
-		log.InfoWithRequest(r, "Request: %s %s", r.Method, r.URL.String())
+		log.InfoWithRequest(r, "Request: %s %s", r.Method, redactedURL(r))
 
-		next.ServeHTTP(w, r)
+		start := time.Now()
+		rl := &responseLogger{ResponseWriter: w}
+		next.ServeHTTP(rl, r)
+
+		status := rl.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		log.InfoWithRequest(r, "Response: %s %s %d (%d bytes) in %.1fms",
+			r.Method, r.URL.Path, status, rl.bytes, float64(time.Since(start).Microseconds())/1000)
 	})
 }
+
+// redactedURL returns the request URL with token query parameters masked
+func redactedURL(r *http.Request) string {
+	query := r.URL.Query()
+	if query.Get("token") == "" {
+		return r.URL.String()
+	}
+
+	u := *r.URL
+	query.Set("token", "REDACTED")
+	u.RawQuery = query.Encode()
+	return u.String()
+}
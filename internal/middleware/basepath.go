@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// basePathKey holds the base path of a request in its context
+const basePathKey contextKey = "basePath"
+
+// BasePathMiddleware serves the dashboard below base_path. The base path is
+// removed before routing, so every route keeps its usual path; requests from
+// a reverse proxy that already removed it are served as they are. Handlers
+// build links, redirects and cookie paths with BasePath.
+func BasePathMiddleware(cfgManager *config.Manager, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		basePath := cfgManager.GetConfig().BasePath // Get fresh config for hot reload
+		if basePath == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.URL.Path == basePath {
+			// Relative links in the pages need the trailing slash
+			target := basePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), basePathKey, basePath))
+		if rest, ok := strings.CutPrefix(r.URL.Path, basePath+"/"); ok {
+			u := *r.URL
+			u.Path = "/" + rest
+			u.RawPath = ""
+			r.URL = &u
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BasePath returns the base_path the request was served below, or "" at the root
+func BasePath(r *http.Request) string {
+	basePath, _ := r.Context().Value(basePathKey).(string)
+	return basePath
+}
+
+// Redirect redirects to a dashboard path such as "/login", below the base path
+func Redirect(w http.ResponseWriter, r *http.Request, path string, code int) {
+	http.Redirect(w, r, BasePath(r)+path, code)
+}
+
+// cookiePath returns the Path of the dashboard cookies
+func cookiePath(r *http.Request) string {
+	if basePath := BasePath(r); basePath != "" {
+		return basePath
+	}
+	return "/"
+}
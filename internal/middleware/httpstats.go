@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPLatencyBuckets are the upper bounds, in seconds, of the request latency histograms
+var HTTPLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// unmatchedRoute labels requests that no route matched, so unknown paths do
+// not create new series
+const unmatchedRoute = "other"
+
+// routeCounters accumulates the requests of one route
+type routeCounters struct {
+	requests     uint64
+	clientErrors uint64 // 4xx responses
+	serverErrors uint64 // 5xx responses
+	streams      uint64 // WebSocket and SSE connections, left out of the latency figures
+	buckets      []uint64
+	sum          time.Duration
+	max          time.Duration
+}
+
+// HTTPStats keeps request counts, errors and latency histograms per route in memory
+type HTTPStats struct {
+	mu     sync.Mutex
+	since  time.Time
+	routes map[string]*routeCounters
+}
+
+// RouteStats is a snapshot of the requests of one route
+type RouteStats struct {
+	Route        string  `json:"route"`
+	Requests     uint64  `json:"requests"`
+	ClientErrors uint64  `json:"clientErrors"`
+	ServerErrors uint64  `json:"serverErrors"`
+	ErrorRate    float64 `json:"errorRate"` // Share of requests answered with 5xx
+	Streams      uint64  `json:"streams"`
+	// Latency of the requests that are not streams
+	Buckets    []uint64 `json:"buckets"` // Cumulative counts per HTTPLatencyBuckets bound
+	SumSeconds float64  `json:"sumSeconds"`
+	AvgMs      float64  `json:"avgMs"`
+	P95Ms      float64  `json:"p95Ms"` // Upper bound of the bucket holding the 95th percentile
+	MaxMs      float64  `json:"maxMs"`
+}
+
+var (
+	httpStats     *HTTPStats
+	httpStatsOnce sync.Once
+)
+
+// GetHTTPStats returns the request statistics of the server
+func GetHTTPStats() *HTTPStats {
+	httpStatsOnce.Do(func() {
+		httpStats = &HTTPStats{since: time.Now(), routes: make(map[string]*routeCounters)}
+	})
+	return httpStats
+}
+
+// Since returns when collection of the statistics started
+func (s *HTTPStats) Since() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.since
+}
+
+// Observe records a finished request
+func (s *HTTPStats) Observe(route string, status int, duration time.Duration, stream bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.routes[route]
+	if !ok {
+		c = &routeCounters{buckets: make([]uint64, len(HTTPLatencyBuckets))}
+		s.routes[route] = c
+	}
+
+	c.requests++
+	switch {
+	case status >= http.StatusInternalServerError:
+		c.serverErrors++
+	case status >= http.StatusBadRequest:
+		c.clientErrors++
+	}
+	if stream {
+		// A stream lasts as long as the client stays connected
+		c.streams++
+		return
+	}
+
+	seconds := duration.Seconds()
+	for i, bound := range HTTPLatencyBuckets {
+		if seconds <= bound {
+			c.buckets[i]++
+		}
+	}
+	c.sum += duration
+	if duration > c.max {
+		c.max = duration
+	}
+}
+
+// Snapshot returns the statistics of every route, slowest average first
+func (s *HTTPStats) Snapshot() []RouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(s.routes))
+	for route, c := range s.routes {
+		rs := RouteStats{
+			Route:        route,
+			Requests:     c.requests,
+			ClientErrors: c.clientErrors,
+			ServerErrors: c.serverErrors,
+			Streams:      c.streams,
+			Buckets:      append([]uint64(nil), c.buckets...),
+			SumSeconds:   c.sum.Seconds(),
+			MaxMs:        float64(c.max.Microseconds()) / 1000,
+		}
+		if c.requests > 0 {
+			rs.ErrorRate = float64(c.serverErrors) / float64(c.requests)
+		}
+		if timed := c.requests - c.streams; timed > 0 {
+			rs.AvgMs = float64(c.sum.Microseconds()) / 1000 / float64(timed)
+			rs.P95Ms = rs.MaxMs
+			target := uint64(float64(timed)*0.95 + 0.5)
+			for i, count := range c.buckets {
+				if count >= target {
+					rs.P95Ms = HTTPLatencyBuckets[i] * 1000
+					break
+				}
+			}
+		}
+		stats = append(stats, rs)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].AvgMs != stats[j].AvgMs {
+			return stats[i].AvgMs > stats[j].AvgMs
+		}
+		return stats[i].Route < stats[j].Route
+	})
+	return stats
+}
+
+// Reset clears the statistics
+func (s *HTTPStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = time.Now()
+	s.routes = make(map[string]*routeCounters)
+}
+
+const routeLabelKey contextKey = "routeLabel"
+
+// SetRouteLabel replaces the route a request is counted under, e.g. to tell
+// the devices behind /device/ apart. Labels must come from a bounded set.
+func SetRouteLabel(r *http.Request, label string) {
+	if holder, ok := r.Context().Value(routeLabelKey).(*string); ok {
+		*holder = label
+	}
+}
+
+// statsWriter notes the status of a response and whether it became a stream
+type statsWriter struct {
+	http.ResponseWriter
+	status int
+	stream bool
+}
+
+func (sw *statsWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+		sw.stream = strings.HasPrefix(sw.Header().Get("Content-Type"), "text/event-stream")
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statsWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Hijack hands the connection to a WebSocket handler
+func (sw *statsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(sw.ResponseWriter).Hijack()
+	if err == nil {
+		sw.status = http.StatusSwitchingProtocols
+		sw.stream = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (sw *statsWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// HTTPStatsMiddleware records every request handled by mux under its route pattern
+func HTTPStatsMiddleware(mux *http.ServeMux) http.Handler {
+	stats := GetHTTPStats()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		var label string
+		r = r.WithContext(context.WithValue(r.Context(), routeLabelKey, &label))
+		sw := &statsWriter{ResponseWriter: w}
+
+		mux.ServeHTTP(sw, r)
+
+		// The mux sets the pattern of the matched route on the request
+		route := label
+		if route == "" {
+			route = r.Pattern
+		}
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		stats.Observe(route, status, time.Since(start), sw.stream)
+	})
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// IdempotencyKeyHeader is the request header carrying a client-chosen key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotentBody limits the request body hashed to detect key reuse
+const maxIdempotentBody = 1 << 20
+
+// IdempotencyStore remembers responses by idempotency key so retried requests
+// are answered without repeating the action
+type IdempotencyStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+type idempotentResponse struct {
+	fingerprint [32]byte
+	created     time.Time
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// NewIdempotencyStore creates a store that keeps responses for ttl
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotentResponse),
+	}
+}
+
+// begin returns the stored entry for key, or registers a new in-flight entry
+// and returns nil when the key has not been seen
+func (s *IdempotencyStore) begin(key string, fingerprint [32]byte) *idempotentResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range s.entries {
+		if e.done && now.Sub(e.created) >= s.ttl {
+			delete(s.entries, k)
+		}
+	}
+
+	if e, ok := s.entries[key]; ok {
+		copied := *e
+		return &copied
+	}
+	s.entries[key] = &idempotentResponse{fingerprint: fingerprint, created: now}
+	return nil
+}
+
+// finish stores the response for key. Server errors are forgotten so the
+// request can be retried with the same key.
+func (s *IdempotencyStore) finish(key string, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	if status >= http.StatusInternalServerError {
+		delete(s.entries, key)
+		return
+	}
+	e.done = true
+	e.status = status
+	e.header = header
+	e.body = body
+}
+
+// unreplayedHeaders belong to the first response only: replaying a session
+// renewal's cookie would send an already rotated refresh token
+var unreplayedHeaders = []string{"Set-Cookie", RequestIDHeader}
+
+// handlerHeaders returns the headers the wrapped handler set or changed,
+// compared with those set earlier in the chain, without unreplayedHeaders
+func handlerHeaders(before, after http.Header) http.Header {
+	set := http.Header{}
+	for name, values := range after {
+		if previous, ok := before[name]; ok && slices.Equal(previous, values) {
+			continue
+		}
+		set[name] = slices.Clone(values)
+	}
+	for _, name := range unreplayedHeaders {
+		set.Del(name)
+	}
+	return set
+}
+
+// recordingWriter passes a response through while keeping a copy
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware makes requests carrying an Idempotency-Key header safe
+// to retry: the first response is replayed for later requests with the same
+// key (marked with Idempotent-Replayed: true), with the headers the wrapped
+// handler set except cookies and the request ID. Reusing a key for a different
+// request is rejected with 422, and a retry while the first request is still
+// running gets 409. Keys are scoped to the user and path. Requests without the
+// header are passed through unchanged. It must run after AuthMiddleware.
+func IdempotencyMiddleware(store *IdempotencyStore) func(http.Handler) http.Handler {
+	log := logger.New(logger.ModuleMiddleware)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method == http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writeError := func(status int, message string) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				json.NewEncoder(w).Encode(map[string]string{"message": message})
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			r.Body.Close()
+			if err != nil || len(body) > maxIdempotentBody {
+				writeError(http.StatusRequestEntityTooLarge, "Request body too large for an idempotent request")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			username := ""
+			if user := GetUserFromContext(r); user != nil {
+				username = user.Username
+			}
+			scopedKey := username + "\x00" + r.URL.Path + "\x00" + key
+			fingerprint := sha256.Sum256([]byte(r.Method + "\x00" + r.URL.RawQuery + "\x00" + string(body)))
+
+			if stored := store.begin(scopedKey, fingerprint); stored != nil {
+				switch {
+				case stored.fingerprint != fingerprint:
+					log.WarnWithRequest(r, "Idempotency key reused for a different request to %s", r.URL.Path)
+					writeError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				case !stored.done:
+					writeError(http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+				default:
+					for name, values := range stored.header {
+						w.Header()[name] = values
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(stored.status)
+					w.Write(stored.body)
+				}
+				return
+			}
+
+			// A panicking handler must not leave the key in progress forever
+			completed := false
+			defer func() {
+				if !completed {
+					store.finish(scopedKey, http.StatusInternalServerError, nil, nil)
+				}
+			}()
+
+			before := w.Header().Clone()
+			recorder := &recordingWriter{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+			completed = true
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			store.finish(scopedKey, recorder.status, handlerHeaders(before, w.Header()), recorder.body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingHandler answers with a counter of the requests it served, or
+// with the status given in the X-Status request header
+func countingHandler(calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		if status := r.Header.Get("X-Status"); status != "" {
+			var code int
+			fmt.Sscanf(status, "%d", &code)
+			w.WriteHeader(code)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+		fmt.Fprintf(w, `{"call": %d}`, *calls)
+	})
+}
+
+// idempotentRequest builds a POST as the given user with an Idempotency-Key
+func idempotentRequest(user, path, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return r.WithContext(context.WithValue(r.Context(), UserContextKey, &User{Username: user}))
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	type request struct {
+		user, path, key, body string
+		status                string // X-Status for the handler
+	}
+	tests := []struct {
+		name       string
+		first      request
+		retry      request
+		wantStatus int
+		wantBody   string
+		replayed   bool
+		calls      int
+	}{
+		{
+			name:       "replays the first response",
+			first:      request{"alice", "/api/x", "k1", `{"a":1}`, ""},
+			retry:      request{"alice", "/api/x", "k1", `{"a":1}`, ""},
+			wantStatus: http.StatusCreated, wantBody: `{"call": 1}`, replayed: true, calls: 1,
+		},
+		{
+			name:       "replays client errors",
+			first:      request{"alice", "/api/x", "k1", `{}`, "400"},
+			retry:      request{"alice", "/api/x", "k1", `{}`, ""},
+			wantStatus: http.StatusBadRequest, wantBody: `{"call": 1}`, replayed: true, calls: 1,
+		},
+		{
+			name:       "rejects a key reused for another body",
+			first:      request{"alice", "/api/x", "k1", `{"a":1}`, ""},
+			retry:      request{"alice", "/api/x", "k1", `{"a":2}`, ""},
+			wantStatus: http.StatusUnprocessableEntity, calls: 1,
+		},
+		{
+			name:       "retries after a server error",
+			first:      request{"alice", "/api/x", "k1", `{}`, "500"},
+			retry:      request{"alice", "/api/x", "k1", `{}`, ""},
+			wantStatus: http.StatusCreated, wantBody: `{"call": 2}`, calls: 2,
+		},
+		{
+			name:       "scopes keys to the user",
+			first:      request{"alice", "/api/x", "k1", `{}`, ""},
+			retry:      request{"bob", "/api/x", "k1", `{}`, ""},
+			wantStatus: http.StatusCreated, wantBody: `{"call": 2}`, calls: 2,
+		},
+		{
+			name:       "scopes keys to the path",
+			first:      request{"alice", "/api/x", "k1", `{}`, ""},
+			retry:      request{"alice", "/api/y", "k1", `{}`, ""},
+			wantStatus: http.StatusCreated, wantBody: `{"call": 2}`, calls: 2,
+		},
+		{
+			name:       "passes requests without a key through",
+			first:      request{"alice", "/api/x", "", `{}`, ""},
+			retry:      request{"alice", "/api/x", "", `{}`, ""},
+			wantStatus: http.StatusCreated, wantBody: `{"call": 2}`, calls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(countingHandler(&calls))
+
+			for i, req := range []request{tt.first, tt.retry} {
+				r := idempotentRequest(req.user, req.path, req.key, req.body)
+				if req.status != "" {
+					r.Header.Set("X-Status", req.status)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if i == 0 {
+					continue // Only the retry is checked
+				}
+
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+					t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+				}
+				if replayed := w.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.replayed {
+					t.Errorf("Idempotent-Replayed = %v, want %v", replayed, tt.replayed)
+				}
+				if tt.replayed && w.Header().Get("Content-Type") != "application/json" {
+					t.Errorf("replayed Content-Type = %q", w.Header().Get("Content-Type"))
+				}
+			}
+			if calls != tt.calls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("alice", "/api/x", "k1", `{}`))
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("alice", "/api/x", "k1", `{}`))
+	if w.Code != http.StatusConflict {
+		t.Errorf("status while in progress = %d, want %d", w.Code, http.StatusConflict)
+	}
+	close(release)
+	<-done
+}
+
+func TestIdempotencyStoreExpiry(t *testing.T) {
+	calls := 0
+	store := NewIdempotencyStore(time.Millisecond)
+	handler := IdempotencyMiddleware(store)(countingHandler(&calls))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("alice", "/api/x", "k1", `{}`))
+	time.Sleep(5 * time.Millisecond)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, idempotentRequest("alice", "/api/x", "k1", `{}`))
+
+	if calls != 2 || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("expired key was replayed (handler ran %d times)", calls)
+	}
+}
+
+func TestIdempotencyReplayKeepsOnlyHandlerHeaders(t *testing.T) {
+	calls, requests := 0, 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/api/x/1")
+		http.SetCookie(w, &http.Cookie{Name: "flash", Value: "created"})
+		w.WriteHeader(http.StatusCreated)
+	})
+	idempotent := IdempotencyMiddleware(NewIdempotencyStore(time.Hour))(handler)
+
+	// Earlier in the chain: a request ID per request, and a session renewal
+	// rotating the refresh token cookie on the first request only
+	chain := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set(RequestIDHeader, fmt.Sprintf("req-%d", requests))
+		w.Header().Set("Cache-Control", "no-store")
+		if requests == 1 {
+			http.SetCookie(w, &http.Cookie{Name: "refresh_token", Value: "rotated"})
+		}
+		idempotent.ServeHTTP(w, r)
+	})
+
+	first := httptest.NewRecorder()
+	chain.ServeHTTP(first, idempotentRequest("alice", "/api/x", "k1", `{}`))
+	if len(first.Result().Cookies()) != 2 {
+		t.Fatalf("first response cookies = %v", first.Result().Cookies())
+	}
+
+	retry := httptest.NewRecorder()
+	chain.ServeHTTP(retry, idempotentRequest("alice", "/api/x", "k1", `{}`))
+
+	if calls != 1 || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry was not replayed (handler ran %d times)", calls)
+	}
+	if cookies := retry.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("replay sent cookies %v", cookies)
+	}
+	if id := retry.Header().Values(RequestIDHeader); len(id) != 1 || id[0] != "req-2" {
+		t.Errorf("replay %s = %v, want [req-2]", RequestIDHeader, id)
+	}
+	if values := retry.Header().Values("Cache-Control"); len(values) != 1 {
+		t.Errorf("replay Cache-Control = %v, want it once", values)
+	}
+	if location := retry.Header().Get("Location"); location != "/api/x/1" {
+		t.Errorf("replay Location = %q, want the handler's", location)
+	}
+}
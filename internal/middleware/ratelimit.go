@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// RateLimiter counts requests per client IP within a fixed window
+type RateLimiter struct {
+	limit   int
+	window  time.Duration
+	mu      sync.Mutex
+	clients map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter creates a rate limiter allowing limit requests per window per client
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// Allow records a request for key and reports whether it is within the limit,
+// along with the time until the current window resets
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop expired windows so the map does not grow without bound
+	if len(rl.clients) > 10000 {
+		for k, w := range rl.clients {
+			if now.Sub(w.start) >= rl.window {
+				delete(rl.clients, k)
+			}
+		}
+	}
+
+	w, ok := rl.clients[key]
+	if !ok || now.Sub(w.start) >= rl.window {
+		w = &rateWindow{start: now}
+		rl.clients[key] = w
+	}
+
+	w.count++
+	return w.count <= rl.limit, w.start.Add(rl.window).Sub(now)
+}
+
+// ClientIP returns the remote IP of the request without the port. Forwarding
+// headers are ignored, since clients can set them freely.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitMiddleware rejects clients that exceed the limiter with 429 Too Many Requests
+func RateLimitMiddleware(rl *RateLimiter) func(http.Handler) http.Handler {
+	log := logger.New(logger.ModuleMiddleware)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter := rl.Allow(ClientIP(r))
+			if !allowed {
+				log.WarnWithRequest(r, "Rate limit exceeded for %s", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Too Many Requests"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// RequestIDHeader carries the request ID in requests from proxies and in every response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of request IDs accepted from clients
+const maxRequestIDLength = 64
+
+// newRequestID returns a random 16 character hex request ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether an ID set by a client or proxy is safe to log:
+// short and made of letters, digits, '-', '_' and '.'
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// GetRequestID returns the ID LoggingMiddleware assigned to a request, or "" outside of it
+func GetRequestID(r *http.Request) string {
+	return logger.RequestID(r.Context())
+}
+
+// responseLogger notes the status and size of a response for the response log line
+type responseLogger struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rl *responseLogger) WriteHeader(status int) {
+	if rl.status == 0 {
+		rl.status = status
+	}
+	rl.ResponseWriter.WriteHeader(status)
+}
+
+func (rl *responseLogger) Write(b []byte) (int, error) {
+	if rl.status == 0 {
+		rl.status = http.StatusOK
+	}
+	n, err := rl.ResponseWriter.Write(b)
+	rl.bytes += int64(n)
+	return n, err
+}
+
+// Hijack hands the connection to a WebSocket handler
+func (rl *responseLogger) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rl.ResponseWriter).Hijack()
+	if err == nil {
+		rl.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rl *responseLogger) Unwrap() http.ResponseWriter {
+	return rl.ResponseWriter
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// IsAdmin reports whether the request may use admin-only endpoints. Requests
+// without a user (authentication disabled) are treated as admin; API keys
+// with scopes also need admin:config.
+func IsAdmin(r *http.Request) bool {
+	return hasAdminScope(r, auth.ScopeAdminConfig)
+}
+
+// hasAdminScope reports whether the request has the admin role and, for an
+// API key with scopes, the given scope
+func hasAdminScope(r *http.Request, scope string) bool {
+	user := GetUserFromContext(r)
+	return user == nil || (user.Role == auth.RoleAdmin && user.HasScope(scope))
+}
+
+// RequireAdmin rejects non-admin users with 403 Forbidden. When methods are
+// given only those methods are restricted (e.g. PATCH on a readable endpoint).
+// API keys with scopes need admin:config. It must run after AuthMiddleware.
+func RequireAdmin(methods ...string) func(http.Handler) http.Handler {
+	return RequireAdminScope(auth.ScopeAdminConfig, methods...)
+}
+
+// RequireAdminScope is RequireAdmin for endpoints an API key reaches with a
+// scope other than admin:config, such as write:settings for device changes
+func RequireAdminScope(scope string, methods ...string) func(http.Handler) http.Handler {
+	log := logger.New(logger.ModuleAuth)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			restricted := len(methods) == 0
+			for _, method := range methods {
+				if r.Method == method {
+					restricted = true
+					break
+				}
+			}
+
+			if restricted && !hasAdminScope(r, scope) {
+				requirement := "the admin role"
+				message := "Forbidden: admin role required"
+				if user := GetUserFromContext(r); user != nil && user.Role == auth.RoleAdmin {
+					requirement = "API key scope " + scope
+					message = "Forbidden: API key scope " + scope + " required"
+				}
+				log.WarnWithRequest(r, "Forbidden: %s %s requires %s", r.Method, r.URL.Path, requirement)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"message": message})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
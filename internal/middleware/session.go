@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// Cookies holding the access token and the refresh token of a login
+const (
+	SessionCookie = "sessionToken"
+	RefreshCookie = "refreshToken"
+)
+
+// setSessionCookie sets an HTTP-only cookie for the dashboard session
+func setSessionCookie(w http.ResponseWriter, r *http.Request, name, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     cookiePath(r),
+		HttpOnly: true,
+		MaxAge:   maxAge,
+		Secure:   r.TLS != nil, // Only sent over HTTPS when the dashboard serves it
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// ClearSessionCookies removes the access and refresh token cookies
+func ClearSessionCookies(w http.ResponseWriter, r *http.Request) {
+	for _, name := range []string{SessionCookie, RefreshCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     cookiePath(r),
+			HttpOnly: true,
+			MaxAge:   -1,
+		})
+	}
+}
+
+// setAccessToken creates an access token and sets it as the session cookie
+func setAccessToken(w http.ResponseWriter, r *http.Request, cfg *config.Config, username, role, sessionID string) (time.Time, error) {
+	// Token lifetime comes from jwt_expiry in config.json, falling back to jsonWebTokenKey.json
+	jwtService := auth.GetJWTService()
+	tokenLifetime := cfg.TokenLifetime(jwtService.ExpiresIn())
+
+	token, err := jwtService.CreateSessionToken(username, role, sessionID, tokenLifetime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Cookie never outlives the token it carries
+	setSessionCookie(w, r, SessionCookie, token, cfg.SessionCookieMaxAge(tokenLifetime))
+	return time.Now().Add(tokenLifetime), nil
+}
+
+// StartSession sets the cookies of a user that just logged in. With
+// refresh_token_expiry the login also gets a refresh token, so the short-lived
+// access token can be renewed without logging in again.
+func StartSession(w http.ResponseWriter, r *http.Request, cfg *config.Config, username, role string) error {
+	sessionID := ""
+	refreshLifetime := cfg.RefreshTokenLifetime()
+	if store := auth.GetSessionStore(); store != nil && refreshLifetime > 0 {
+		refreshToken, session, err := store.Create(username, role, refreshLifetime, ClientIP(r), r.UserAgent())
+		if err != nil {
+			return err
+		}
+		sessionID = session.ID
+		setSessionCookie(w, r, RefreshCookie, refreshToken, int(refreshLifetime.Seconds()))
+	}
+
+	_, err := setAccessToken(w, r, cfg, username, role, sessionID)
+	return err
+}
+
+// RenewSession exchanges the refresh token cookie for a new access token and
+// refresh token, extending the session. It returns the user of the session
+// and when the new access token expires. When an old refresh token is
+// reused, the session is revoked and its user returned with the error.
+func RenewSession(w http.ResponseWriter, r *http.Request, cfg *config.Config) (*User, time.Time, error) {
+	refreshLifetime := cfg.RefreshTokenLifetime()
+	store := auth.GetSessionStore()
+	if store == nil || refreshLifetime <= 0 {
+		return nil, time.Time{}, fmt.Errorf("refresh tokens are not enabled")
+	}
+	cookie, err := r.Cookie(RefreshCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, time.Time{}, auth.ErrRefreshTokenInvalid
+	}
+
+	refreshToken, session, err := store.Renew(cookie.Value, refreshLifetime)
+	if errors.Is(err, auth.ErrRefreshTokenReused) {
+		return &User{Username: session.Username, Role: session.Role}, time.Time{}, err
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if refreshToken != "" {
+		setSessionCookie(w, r, RefreshCookie, refreshToken, int(refreshLifetime.Seconds()))
+	}
+
+	expiresAt, err := setAccessToken(w, r, cfg, session.Username, session.Role, session.ID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &User{Username: session.Username, Role: session.Role}, expiresAt, nil
+}
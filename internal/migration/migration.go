@@ -0,0 +1,299 @@
+// Package migration imports the configuration of the Node.js
+// bitaxe-dashboard (v2) and records the outcome for /api/migration/status
+package migration
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// StatusFile records the last import in the config directory
+const StatusFile = "migration.json"
+
+// dashboardVersion is the axeos_dashboard_version written to imported configurations
+const dashboardVersion = 3.0
+
+// ErrAlreadyConfigured is returned when the config directory already has a config.json
+var ErrAlreadyConfigured = errors.New("config.json already exists; move it away to import a legacy configuration")
+
+// legacySecretFiles are the credential files copied along with config.json
+var legacySecretFiles = []string{"access.json", "jsonWebTokenKey.json", "rpcConfig.json"}
+
+// Status is the outcome of an import, kept in StatusFile
+type Status struct {
+	Migrated   bool              `json:"migrated"`
+	Source     string            `json:"source,omitempty"` // Directory imported from, or "upload"
+	ImportedAt *time.Time        `json:"importedAt,omitempty"`
+	Files      []string          `json:"files,omitempty"`     // Files written
+	Renamed    map[string]string `json:"renamed,omitempty"`   // Legacy key to current key
+	Dropped    []string          `json:"dropped,omitempty"`   // Keys the dashboard no longer reads
+	Generated  []string          `json:"generated,omitempty"` // Files created because the legacy set lacked them
+	Skipped    []string          `json:"skipped,omitempty"`   // Files provided by a secret backend
+}
+
+// ConvertConfig maps a legacy config.json to the current format: legacy keys
+// are renamed, keys the dashboard does not know are dropped, and the result
+// must pass the checks of the configuration API
+func ConvertConfig(data []byte) (map[string]interface{}, *Status, error) {
+	var legacy map[string]interface{}
+	if err := json.Unmarshal(data, &legacy); err != nil || legacy == nil {
+		return nil, nil, fmt.Errorf("config.json must be a JSON object")
+	}
+	if legacy["bitaxe_instances"] == nil && legacy["axeos_instances"] == nil {
+		return nil, nil, fmt.Errorf("config.json has no bitaxe_instances or axeos_instances")
+	}
+
+	status := &Status{Renamed: map[string]string{}}
+	converted := map[string]interface{}{}
+	for key, value := range legacy {
+		if current, ok := config.LegacyKeys[key]; ok {
+			if _, exists := legacy[current]; exists {
+				status.Dropped = append(status.Dropped, key) // Both names present; the current one wins
+				continue
+			}
+			status.Renamed[key] = current
+			key = current
+		}
+		if !config.IsConfigField(key) {
+			status.Dropped = append(status.Dropped, key)
+			continue
+		}
+		converted[key] = value
+	}
+	converted["axeos_dashboard_version"] = dashboardVersion
+	converted["configuration_outdated"] = false
+	if converted["axeos_instances"] == nil {
+		converted["axeos_instances"] = []interface{}{}
+	}
+	sort.Strings(status.Dropped)
+
+	formatted, err := json.Marshal(converted)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := config.ValidateConfigData(formatted); err != nil {
+		return nil, nil, err
+	}
+	return converted, status, nil
+}
+
+// ImportDir imports the legacy configuration files in srcDir into configDir
+func ImportDir(srcDir, configDir string) (*Status, error) {
+	files := map[string][]byte{}
+	for _, name := range append([]string{"config.json"}, legacySecretFiles...) {
+		data, err := os.ReadFile(filepath.Join(srcDir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", name, err)
+		}
+		files[name] = data
+	}
+	return Import(configDir, files, srcDir)
+}
+
+// Import writes the current configuration set converted from legacy files,
+// keyed by file name. All files are checked before anything is written;
+// config.json is written last, as it switches the server out of setup mode.
+func Import(configDir string, files map[string][]byte, source string) (*Status, error) {
+	if _, err := os.Stat(filepath.Join(configDir, "config.json")); err == nil {
+		return nil, ErrAlreadyConfigured
+	}
+	for name := range files {
+		if name != "config.json" && !slices.Contains(legacySecretFiles, name) {
+			return nil, fmt.Errorf("%s is not a file of the legacy dashboard", name)
+		}
+	}
+	data, ok := files["config.json"]
+	if !ok {
+		return nil, fmt.Errorf("config.json is required")
+	}
+	converted, status, err := ConvertConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	authEnabled := converted["disable_authentication"] != true
+	secretFiles := map[string][]byte{}
+	if data, ok := files["access.json"]; ok {
+		if err := checkAccessFile(data, authEnabled); err != nil {
+			return nil, err
+		}
+		secretFiles["access.json"] = data
+	} else if authEnabled {
+		return nil, fmt.Errorf("access.json is required when authentication is enabled")
+	} else {
+		secretFiles["access.json"] = []byte("{}")
+		status.Generated = append(status.Generated, "access.json")
+	}
+
+	if data, ok := files["jsonWebTokenKey.json"]; ok {
+		if secretFiles["jsonWebTokenKey.json"], err = convertJWTKeyFile(data); err != nil {
+			return nil, err
+		}
+	} else {
+		key := make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		secretFiles["jsonWebTokenKey.json"], _ = json.MarshalIndent(map[string]string{
+			"jsonWebTokenKey": hex.EncodeToString(key),
+			"expiresIn":       "1h0m0s",
+		}, "", "  ")
+		status.Generated = append(status.Generated, "jsonWebTokenKey.json")
+	}
+
+	if data, ok := files["rpcConfig.json"]; ok {
+		var rpcConfig map[string]interface{}
+		if err := json.Unmarshal(data, &rpcConfig); err != nil || rpcConfig == nil {
+			return nil, fmt.Errorf("rpcConfig.json must be a JSON object")
+		}
+		secretFiles["rpcConfig.json"] = data
+	}
+
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating config directory: %w", err)
+	}
+	for _, name := range legacySecretFiles {
+		data, ok := secretFiles[name]
+		if !ok {
+			continue
+		}
+		if !secrets.IsFileBacked(name) {
+			status.Skipped = append(status.Skipped, name)
+			continue
+		}
+		if err := config.WriteSecretFile(filepath.Join(configDir, name), data); err != nil {
+			return nil, fmt.Errorf("error writing %s: %w", name, err)
+		}
+		status.Files = append(status.Files, name)
+	}
+
+	formatted, err := json.MarshalIndent(converted, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), formatted, 0644); err != nil {
+		return nil, fmt.Errorf("error writing config.json: %w", err)
+	}
+	status.Files = append([]string{"config.json"}, status.Files...)
+
+	now := time.Now().UTC()
+	status.Migrated = true
+	status.Source = source
+	status.ImportedAt = &now
+	if data, err := json.MarshalIndent(status, "", "  "); err == nil {
+		os.WriteFile(filepath.Join(configDir, StatusFile), data, 0644)
+	}
+	return status, nil
+}
+
+// ReadStatus returns the outcome of the last import; Migrated is false
+// when nothing was imported or the status was cleared
+func ReadStatus(configDir string) (*Status, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, StatusFile))
+	if os.IsNotExist(err) {
+		return &Status{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", StatusFile, err)
+	}
+	return &status, nil
+}
+
+// ClearStatus forgets the last import
+func ClearStatus(configDir string) error {
+	err := os.Remove(filepath.Join(configDir, StatusFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// checkAccessFile checks a legacy access.json; with authentication enabled
+// it needs an admin, which the plain "username": "hash" entries of the
+// Node.js dashboard are
+func checkAccessFile(data []byte, authEnabled bool) error {
+	if authEnabled {
+		return auth.ValidateAccessFile(data)
+	}
+	var users map[string]auth.AccessUser
+	if err := json.Unmarshal(data, &users); err != nil {
+		return fmt.Errorf("error parsing access.json: %w", err)
+	}
+	return nil
+}
+
+// convertJWTKeyFile checks a legacy jsonWebTokenKey.json and rewrites an
+// expiresIn the Node.js jsonwebtoken package accepted but Go durations do
+// not, such as "7d" or a number of seconds
+func convertJWTKeyFile(data []byte) ([]byte, error) {
+	var legacy struct {
+		Key       string      `json:"jsonWebTokenKey"`
+		ExpiresIn interface{} `json:"expiresIn"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("error parsing jsonWebTokenKey.json: %w", err)
+	}
+	if legacy.Key == "" {
+		return nil, fmt.Errorf("jsonWebTokenKey.json has no jsonWebTokenKey")
+	}
+
+	expiresIn, err := legacyDuration(legacy.ExpiresIn)
+	if err != nil {
+		return nil, fmt.Errorf("jsonWebTokenKey.json has an invalid expiresIn: %w", err)
+	}
+	return json.MarshalIndent(map[string]string{
+		"jsonWebTokenKey": legacy.Key,
+		"expiresIn":       expiresIn.String(),
+	}, "", "  ")
+}
+
+// legacyDuration reads a jsonwebtoken expiresIn: seconds as a number, or a
+// string such as "90m", "24h" or "7d"
+func legacyDuration(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case nil:
+		return time.Hour, nil
+	case float64:
+		if v <= 0 {
+			return 0, fmt.Errorf("must be positive")
+		}
+		return time.Duration(v) * time.Second, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if days, ok := strings.CutSuffix(v, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%q is not a number of days", v)
+			}
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("%q is not a duration", v)
+		}
+		return d, nil
+	}
+	return 0, fmt.Errorf("must be a string or a number of seconds")
+}
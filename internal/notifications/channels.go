@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// telegramAPIURL is the Telegram Bot API base; the bot token and method are appended
+const telegramAPIURL = "https://api.telegram.org/bot"
+
+// Discord embed colors per event type
+var discordColors = map[string]int{
+	config.AlertMinerOffline:         0xE74C3C, // Red
+	config.AlertOverheat:             0xE67E22, // Orange
+	config.AlertBlockFound:           0x2ECC71, // Green
+	config.AlertCredentialRotation:   0xF1C40F, // Yellow
+	config.AlertDifficultyAdjustment: 0x9B59B6, // Purple
+	config.AlertPowerBudget:          0xE67E22, // Orange
+	config.AlertPowerCap:             0xE74C3C, // Red
+	EventTest:                        0x3498DB, // Blue
+}
+
+// discordPayload formats an event as a Discord webhook message with one embed
+func discordPayload(event Event) map[string]interface{} {
+	return map[string]interface{}{
+		"username": "AxeOS Dashboard",
+		"embeds": []map[string]interface{}{{
+			"title":       event.Title,
+			"description": event.Message,
+			"color":       discordColors[event.Type],
+			"timestamp":   event.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+			"footer":      map[string]string{"text": event.Source},
+		}},
+	}
+}
+
+// telegramPayload formats an event as a Telegram sendMessage request
+func telegramPayload(chatID string, event Event) map[string]interface{} {
+	return map[string]interface{}{
+		"chat_id": chatID,
+		"text":    fmt.Sprintf("%s\n%s", event.Title, event.Message),
+	}
+}
+
+// postJSON posts a JSON body and treats any non-2xx response as an error
+func (d *Dispatcher) postJSON(client *http.Client, target string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid channel URL") // The error would contain a Telegram bot token
+	}
+	services.SetOutboundHeaders(req, d.cfgManager.GetConfig())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the URL from the error so Telegram bot tokens are not logged
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errorText, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP error! Status: %d, Body: %s", resp.StatusCode, string(errorText))
+	}
+	return nil
+}
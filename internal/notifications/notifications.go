@@ -0,0 +1,194 @@
+// Package notifications delivers alert events (miner offline, overheat,
+// block found) to webhooks, Discord and Telegram
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// EventTest is sent by /api/notifications/test
+const EventTest = "test"
+
+// sendTimeout bounds each delivery to a channel
+const sendTimeout = 10 * time.Second
+
+// Event is an alert sent to notification channels
+type Event struct {
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	// Fields are the values of the event by name, for message templates
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// ChannelSecret is one entry of the notifications.json secret, keyed by channel name
+type ChannelSecret struct {
+	URL      string `json:"url,omitempty"`
+	BotToken string `json:"botToken,omitempty"`
+}
+
+// Result is the outcome of sending an event to one channel
+type Result struct {
+	Channel string `json:"channel"`
+	Type    string `json:"type"`
+	Status  string `json:"status"` // "sent" or "error"
+	Message string `json:"message,omitempty"`
+}
+
+// Dispatcher sends alert events to the channels in the notifications section of config.json
+type Dispatcher struct {
+	cfgManager *config.Manager
+	mu         sync.Mutex
+	clients    map[string]channelClient // By channel name
+	log        *logger.Logger
+}
+
+var (
+	instance *Dispatcher
+	once     sync.Once
+)
+
+// GetDispatcher returns the singleton dispatcher
+func GetDispatcher(cfgManager *config.Manager) *Dispatcher {
+	once.Do(func() {
+		instance = &Dispatcher{
+			cfgManager: cfgManager,
+			clients:    map[string]channelClient{},
+			log:        logger.New(logger.ModuleService),
+		}
+	})
+	return instance
+}
+
+// Notify sends an event in the background to every enabled channel that wants it.
+// Delivery failures are logged; alerts never hold up collection.
+func (d *Dispatcher) Notify(event Event) {
+	cfg := d.cfgManager.GetConfig()
+	if cfg == nil || cfg.Notifications == nil || !cfg.Notifications.Enabled {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	channelSecrets := d.loadSecrets()
+	for _, channel := range cfg.Notifications.Channels {
+		if !channel.Wants(event.Type) {
+			continue
+		}
+		go func(channel config.NotificationChannel) {
+			event := d.render(cfg.Notifications, channel, event)
+			if err := d.send(cfg.Notifications, channel, channelSecrets[channel.Name], event); err != nil {
+				d.log.Error("Failed to send %s notification to %s: %v", event.Type, channel.Name, err)
+			}
+		}(channel)
+	}
+}
+
+// SendTest sends a test event to one channel (or all when name is empty),
+// whether or not notifications are enabled, and reports each outcome
+func (d *Dispatcher) SendTest(name string) ([]Result, error) {
+	cfg := d.cfgManager.GetConfig()
+	if cfg == nil || cfg.Notifications == nil || len(cfg.Notifications.Channels) == 0 {
+		return nil, fmt.Errorf("no notification channels are configured")
+	}
+
+	event := Event{
+		Type:      EventTest,
+		Source:    "axeos-dashboard",
+		Title:     "AxeOS Dashboard test notification",
+		Message:   "Notifications from AxeOS Dashboard are working.",
+		Timestamp: time.Now(),
+	}
+
+	channelSecrets := d.loadSecrets()
+	results := []Result{}
+	for _, channel := range cfg.Notifications.Channels {
+		if name != "" && !strings.EqualFold(channel.Name, name) {
+			continue
+		}
+		result := Result{Channel: channel.Name, Type: channel.Type, Status: "sent"}
+		if err := d.send(cfg.Notifications, channel, channelSecrets[channel.Name], d.render(cfg.Notifications, channel, event)); err != nil {
+			result.Status = "error"
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("notification channel %q not found", name)
+	}
+	return results, nil
+}
+
+// loadSecrets reads notifications.json; it is optional when URLs are in config.json
+func (d *Dispatcher) loadSecrets() map[string]ChannelSecret {
+	channelSecrets := map[string]ChannelSecret{}
+	data, err := secrets.Read(d.cfgManager.GetConfigDir(), "notifications.json")
+	if err != nil {
+		return channelSecrets
+	}
+	if err := json.Unmarshal(data, &channelSecrets); err != nil {
+		d.log.Warn("Failed to parse notifications.json: %v", err)
+	}
+	return channelSecrets
+}
+
+// send delivers an event to a single channel and records the outcome for the
+// dependency health of the channel
+func (d *Dispatcher) send(settings *config.Notifications, channel config.NotificationChannel, secret ChannelSecret, event Event) error {
+	start := time.Now()
+	err := d.deliver(settings, channel, secret, event)
+	dependencies.Record(dependencies.KindNotification, channel.Name, time.Since(start), err)
+	return err
+}
+
+// deliver posts an event to a single channel
+func (d *Dispatcher) deliver(settings *config.Notifications, channel config.NotificationChannel, secret ChannelSecret, event Event) error {
+	url := channel.URL
+	if secret.URL != "" {
+		url = secret.URL
+	}
+	if url != "" && channel.Type != config.ChannelTelegram && !config.IsHTTPSURL(url) {
+		// The URL may come from notifications.json, which is not validated
+		if settings.RequireHTTPS {
+			return errPlaintextRefused
+		}
+		if channel.CACert != "" || channel.CertSHA256 != "" {
+			return fmt.Errorf("channel has TLS settings but its url is not https://")
+		}
+	}
+	client, err := d.httpClient(channel, settings.RequireHTTPS)
+	if err != nil {
+		return err
+	}
+
+	switch channel.Type {
+	case config.ChannelWebhook:
+		if url == "" {
+			return fmt.Errorf("webhook channel has no url")
+		}
+		return d.postJSON(client, url, event)
+	case config.ChannelDiscord:
+		if url == "" {
+			return fmt.Errorf("discord channel has no webhook url")
+		}
+		return d.postJSON(client, url, discordPayload(event))
+	case config.ChannelTelegram:
+		if secret.BotToken == "" {
+			return fmt.Errorf("telegram channel has no botToken in notifications.json")
+		}
+		return d.postJSON(client, telegramAPIURL+secret.BotToken+"/sendMessage", telegramPayload(channel.ChatID, event))
+	}
+	return fmt.Errorf("unknown channel type %q", channel.Type)
+}
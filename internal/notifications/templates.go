@@ -0,0 +1,50 @@
+package notifications
+
+import (
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// render applies the template configured for an event and channel to the
+// title and message. A template that fails leaves the built-in text in
+// place, so the alert is still sent.
+func (d *Dispatcher) render(settings *config.Notifications, channel config.NotificationChannel, event Event) Event {
+	t := settings.FindTemplate(event.Type, channel)
+	if t == nil {
+		return event
+	}
+
+	rendered := event
+	if t.Title != "" {
+		if text, err := execute("title", t.Title, event); err != nil {
+			d.log.Warn("Failed to render the %s notification title for %s: %v", event.Type, channel.Name, err)
+		} else {
+			rendered.Title = text
+		}
+	}
+	if t.Message != "" {
+		if text, err := execute("message", t.Message, event); err != nil {
+			d.log.Warn("Failed to render the %s notification message for %s: %v", event.Type, channel.Name, err)
+		} else {
+			rendered.Message = text
+		}
+	}
+	return rendered
+}
+
+// execute runs a title or message template against an event
+func execute(name, text string, event Event) (string, error) {
+	tmpl, err := config.ParseNotificationTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	if event.Fields == nil {
+		event.Fields = map[string]interface{}{}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, event); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
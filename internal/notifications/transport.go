@@ -0,0 +1,70 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// errPlaintextRefused is returned for http:// destinations under require_https
+var errPlaintextRefused = errors.New("plaintext http:// destinations are disabled by require_https")
+
+// channelClient is a channel's HTTP client and the settings it was built with
+type channelClient struct {
+	key    string
+	client *http.Client
+}
+
+// clientKey identifies the TLS settings of a channel, so its client is
+// rebuilt when they change
+func clientKey(channel config.NotificationChannel, requireHTTPS bool) string {
+	return strings.Join([]string{channel.CACert, channel.CertSHA256, strconv.FormatBool(requireHTTPS)}, "\x00")
+}
+
+// newChannelClient creates the HTTP client of a channel: TLS trusting its
+// ca_cert or the certificate pinned by cert_sha256, refusing redirects to
+// plaintext destinations when https is required
+func newChannelClient(channel config.NotificationChannel, configDir string, requireHTTPS bool) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig, err := config.ClientTLSConfig(channel.CACert, channel.CertSHA256, configDir)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := &http.Client{Timeout: sendTimeout, Transport: transport}
+	if requireHTTPS {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return errPlaintextRefused
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	return client, nil
+}
+
+// httpClient returns the cached HTTP client of a channel, creating it on
+// first use or when the channel's TLS settings changed
+func (d *Dispatcher) httpClient(channel config.NotificationChannel, requireHTTPS bool) (*http.Client, error) {
+	key := clientKey(channel, requireHTTPS)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cached, ok := d.clients[channel.Name]; ok && cached.key == key {
+		return cached.client, nil
+	}
+	client, err := newChannelClient(channel, d.cfgManager.GetConfigDir(), requireHTTPS)
+	if err != nil {
+		return nil, err
+	}
+	d.clients[channel.Name] = channelClient{key: key, client: client}
+	return client, nil
+}
@@ -0,0 +1,148 @@
+// Package power reacts to UPS power events, so data collection stops and the
+// metrics database is checkpointed before a battery-backed host shuts down
+package power
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// Power events, named after the NUT upsmon notifications
+const (
+	EventOnBattery     = "onbatt"    // Mains power lost, running on battery
+	EventOnline        = "online"    // Mains power restored
+	EventLowBattery    = "lowbatt"   // Battery nearly empty, shutdown imminent
+	EventForceShutdown = "fsd"       // The UPS master ordered a shutdown
+	EventPowerFail     = "powerfail" // SIGPWR from init
+)
+
+// ValidEvent reports whether event is a known power event
+func ValidEvent(event string) bool {
+	switch event {
+	case EventOnBattery, EventOnline, EventLowBattery, EventForceShutdown, EventPowerFail:
+		return true
+	}
+	return false
+}
+
+// IsShutdownEvent reports whether the host is about to lose power
+func IsShutdownEvent(event string) bool {
+	return event == EventLowBattery || event == EventForceShutdown || event == EventPowerFail
+}
+
+// Status is the last power event handled
+type Status struct {
+	Enabled    bool      `json:"enabled"`
+	LastEvent  string    `json:"lastEvent,omitempty"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	Suspended  bool      `json:"collectionSuspended"` // Collection stopped until power is restored
+}
+
+// Manager dispatches power events to listeners and the configured script
+type Manager struct {
+	cfgManager *config.Manager
+	listeners  []func(event string)
+	status     Status
+	mu         sync.Mutex
+	log        *logger.Logger
+}
+
+var (
+	instance *Manager
+	once     sync.Once
+)
+
+// GetManager returns the singleton power event manager
+func GetManager(cfgManager *config.Manager) *Manager {
+	once.Do(func() {
+		instance = &Manager{
+			cfgManager: cfgManager,
+			log:        logger.New(logger.ModuleService),
+		}
+	})
+	return instance
+}
+
+// Instance returns the power event manager if it has been created, or nil
+func Instance() *Manager {
+	return instance
+}
+
+// OnEvent registers a listener called for every power event, before the script runs
+func (m *Manager) OnEvent(listener func(event string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// Handle processes a power event. Listeners finish (stopping collection and
+// checkpointing the database on shutdown events) before the script is run.
+func (m *Manager) Handle(event string) error {
+	if !ValidEvent(event) {
+		return fmt.Errorf("unknown power event %q", event)
+	}
+	cfg := m.cfgManager.GetConfig()
+	if !cfg.PowerEvents.IsEnabled() {
+		return fmt.Errorf("power events are disabled")
+	}
+
+	// The state is updated under the lock; listeners and the script run
+	// after it is released, so Status and later events do not wait for them
+	m.mu.Lock()
+	switch {
+	case IsShutdownEvent(event):
+		m.status.Suspended = true
+	case event == EventOnline:
+		m.status.Suspended = false
+	}
+	m.status.LastEvent = event
+	m.status.ReceivedAt = time.Now()
+	listeners := append([]func(event string){}, m.listeners...)
+	m.mu.Unlock()
+
+	if IsShutdownEvent(event) {
+		m.log.Warn("POWER: %s, stopping data collection before shutdown", event)
+	} else {
+		m.log.Info("POWER: %s", event)
+	}
+	for _, listener := range listeners {
+		listener(event)
+	}
+
+	if cfg.PowerEvents.Script != "" {
+		m.runScript(cfg.PowerEvents, event)
+	}
+	return nil
+}
+
+// runScript runs the configured script with the event as its only argument
+func (m *Manager) runScript(settings *config.PowerEvents, event string) {
+	ctx, cancel := context.WithTimeout(context.Background(), settings.ScriptTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, settings.Script, event)
+	cmd.Env = append(os.Environ(), "AXEOS_POWER_EVENT="+event)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		m.log.Error("Power event script %s failed: %v: %s", settings.Script, err, output)
+		return
+	}
+	m.log.Info("Power event script %s finished for %s", settings.Script, event)
+}
+
+// Status returns the last power event handled
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := m.status
+	status.Enabled = m.cfgManager.GetConfig().PowerEvents.IsEnabled()
+	return status
+}
@@ -15,10 +15,20 @@ func SetupBootstrapRouter(configDir, publicDir string) http.Handler {
 	mux.Handle("/public/", http.StripPrefix("/public/", fileServer))
 
 	// Bootstrap page (GET)
-	mux.HandleFunc("/", handlers.HandleBootstrapPage)
+	mux.HandleFunc("/", handlers.HandleBootstrapPage(publicDir))
 
 	// Bootstrap form submission (POST)
 	mux.HandleFunc("/bootstrap", handlers.HandleBootstrapSubmit(configDir))
 
+	// Import of the Node.js dashboard's configuration files (POST)
+	mux.HandleFunc("/bootstrap/import", handlers.HandleBootstrapImport(configDir))
+
+	// Network scan for AxeOS devices to fill in the form
+	mux.HandleFunc("/api/discovery/scan", handlers.HandleBootstrapDiscoveryScan)
+
+	// Health and readiness for container healthchecks while setup is pending
+	mux.HandleFunc("/api/health", handlers.HandleSetupHealth)
+	mux.HandleFunc("/api/ready", handlers.HandleSetupHealth)
+
 	return mux
 }
@@ -4,15 +4,36 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
 	"github.com/scottwalter/axeos-dashboard/internal/config"
 	"github.com/scottwalter/axeos-dashboard/internal/handlers"
+	"github.com/scottwalter/axeos-dashboard/internal/jobs"
 	"github.com/scottwalter/axeos-dashboard/internal/middleware"
 	"github.com/scottwalter/axeos-dashboard/internal/services"
 )
 
+// State holds what the routes remember between requests. It is created once
+// and passed to every router built by SetupRouter, so a reload keeps the
+// stored idempotent responses and rate limit counts.
+type State struct {
+	idempotency      *middleware.IdempotencyStore
+	badgeLimiter     *middleware.RateLimiter
+	statsCardLimiter *middleware.RateLimiter
+}
+
+// NewState creates the idempotency store and rate limiters of the routes
+func NewState() *State {
+	return &State{
+		idempotency:      middleware.NewIdempotencyStore(24 * time.Hour),
+		badgeLimiter:     middleware.NewRateLimiter(30, time.Minute),
+		statsCardLimiter: middleware.NewRateLimiter(30, time.Minute),
+	}
+}
+
 // SetupRouter configures all routes for the application
-func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publicDir string) http.Handler {
+func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publicDir string, state *State, reload func() error) http.Handler {
 	mux := http.NewServeMux()
 
 	cryptoNodeSvc := services.NewCryptoNodeService(configDir)
@@ -39,6 +60,13 @@ func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publ
 		),
 	)
 
+	// Access token renewal - authorized by the refresh token cookie
+	mux.Handle("/api/token/refresh",
+		middleware.LoggingMiddleware(
+			handlers.HandleTokenRefresh(cfgManager),
+		),
+	)
+
 	// Logout API endpoint - no authentication required
 	mux.Handle("/api/logout",
 		middleware.LoggingMiddleware(
@@ -46,6 +74,67 @@ func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publ
 		),
 	)
 
+	// Health endpoint - no authentication required, overall status only
+	mux.Handle("/api/health", handlers.HandleHealth(cfgManager, configDir, publicDir))
+
+	// Readiness endpoint - no authentication required, 503 until the dashboard can serve requests
+	mux.Handle("/api/ready", handlers.HandleReady(cfgManager, publicDir))
+
+	// Share link page and API - authorized by the signed token in the URL
+	mux.Handle("/share",
+		middleware.LoggingMiddleware(
+			handlers.HandleSharePage(cfgManager, publicDir),
+		),
+	)
+	mux.Handle("/api/share/info",
+		middleware.LoggingMiddleware(
+			handlers.HandleShareInfo(cfgManager),
+		),
+	)
+
+	// System info pushed by devices the dashboard cannot reach - authorized by the device's push token
+	mux.Handle("/api/push/",
+		middleware.LoggingMiddleware(
+			handlers.HandlePush(cfgManager),
+		),
+	)
+
+	// Public status badges - opt-in via badges_enabled, rate limited per client
+	mux.Handle("/api/badge/",
+		middleware.RateLimitMiddleware(state.badgeLimiter)(
+			handlers.HandleBadge(cfgManager),
+		),
+	)
+
+	// Statistics image for forums and social media - authorized by a token in the URL, rate limited per client
+	mux.Handle("/api/stats/card.svg",
+		middleware.RateLimitMiddleware(state.statsCardLimiter)(
+			handlers.HandleStatsCard(cfgManager, "svg"),
+		),
+	)
+	mux.Handle("/api/stats/card.png",
+		middleware.RateLimitMiddleware(state.statsCardLimiter)(
+			handlers.HandleStatsCard(cfgManager, "png"),
+		),
+	)
+
+	// Event feeds - authorized by a feed token in the URL for calendar apps
+	mux.Handle("/api/feeds/events.ics",
+		middleware.LoggingMiddleware(
+			handlers.HandleICalFeed(cfgManager),
+		),
+	)
+	mux.Handle("/api/feeds/events.rss",
+		middleware.LoggingMiddleware(
+			handlers.HandleEventFeed(cfgManager, "rss"),
+		),
+	)
+	mux.Handle("/api/feeds/events.atom",
+		middleware.LoggingMiddleware(
+			handlers.HandleEventFeed(cfgManager, "atom"),
+		),
+	)
+
 	// Dashboard page - authentication required
 	dashboardHandler := middleware.AuthMiddleware(cfgManager, true)(
 		http.HandlerFunc(handlers.HandleDashboard(cfgManager, publicDir)),
@@ -56,6 +145,16 @@ func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publ
 	// API endpoints - authentication required
 	apiAuthMiddleware := middleware.AuthMiddleware(cfgManager, true)
 
+	// Admin-only endpoints - viewers get 403 (adminOnly restricts every method)
+	adminOnly := middleware.RequireAdmin()
+	adminWrites := middleware.RequireAdmin(http.MethodPatch, http.MethodPut, http.MethodPost, http.MethodDelete)
+
+	// Admin-only endpoints that change devices - API keys need write:settings rather than admin:config
+	settingsOnly := middleware.RequireAdminScope(auth.ScopeWriteSettings)
+
+	// Device actions honor Idempotency-Key so automation can retry safely
+	idempotency := middleware.IdempotencyMiddleware(state.idempotency)
+
 	// Systems info
 	mux.Handle("/api/systems/info",
 		middleware.LoggingMiddleware(
@@ -63,6 +162,36 @@ func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publ
 		),
 	)
 
+	// Live systems info over Server-Sent Events, for proxies that block WebSockets
+	mux.Handle("/api/systems/stream",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleSystemsStream(cfgManager, cryptoNodeSvc)),
+		),
+	)
+
+	// Live systems updates over WebSocket (pushed after each scheduled collection)
+	mux.Handle("/ws/systems",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(http.HandlerFunc(handlers.HandleSystemsWebSocket)),
+		),
+	)
+
+	// Prometheus exporter - opt-in via prometheus_enabled; allowlisted scrapers skip JWT
+	mux.Handle("/metrics",
+		middleware.LoggingMiddleware(
+			middleware.AllowlistMiddleware(cfgManager, func(c *config.Config) []string {
+				return c.PrometheusAllowlist
+			}, apiAuthMiddleware)(handlers.HandlePrometheusMetrics(cfgManager)),
+		),
+	)
+
+	// Device web UIs - opt-in via device_proxy_enabled, admins only since they can change settings
+	mux.Handle("/device/",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(settingsOnly(handlers.HandleDeviceProxy(cfgManager))),
+		),
+	)
+
 	// Instance info
 	mux.Handle("/api/instance/info",
 		middleware.LoggingMiddleware(
@@ -73,21 +202,114 @@ func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publ
 	// Instance restart
 	mux.Handle("/api/instance/service/restart",
 		middleware.LoggingMiddleware(
-			apiAuthMiddleware(handlers.HandleInstanceRestart(cfgManager)),
+			apiAuthMiddleware(settingsOnly(idempotency(handlers.HandleInstanceRestart(cfgManager)))),
 		),
 	)
 
 	// Instance settings
 	mux.Handle("/api/instance/service/settings",
 		middleware.LoggingMiddleware(
-			apiAuthMiddleware(handlers.HandleInstanceSettings(cfgManager)),
+			apiAuthMiddleware(settingsOnly(idempotency(handlers.HandleInstanceSettings(cfgManager)))),
+		),
+	)
+
+	// Bulk restart/settings across devices - runs in the background
+	mux.Handle("/api/instance/service/bulk",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(settingsOnly(idempotency(handlers.HandleBulkAction(cfgManager)))),
+		),
+	)
+
+	// Batch restart, settings and pool changes across devices (same actions as the bulk endpoint)
+	mux.Handle("/api/instances/batch",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(settingsOnly(idempotency(handlers.HandleBulkAction(cfgManager)))),
+		),
+	)
+
+	// Crypto node card fields per node type - anyone can read, admins customize
+	nodeFieldsHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(adminWrites(handlers.HandleNodeDisplayFields(cfgManager))),
+	)
+	mux.Handle("/api/nodes/display-fields", nodeFieldsHandler)
+	mux.Handle("/api/nodes/display-fields/", nodeFieldsHandler)
+
+	// Push a configured pool profile to devices
+	mux.Handle("/api/instances/pool-switch",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(settingsOnly(idempotency(handlers.HandlePoolSwitch(cfgManager)))),
+		),
+	)
+
+	// Stratum handshake test of a pool endpoint or profile
+	mux.Handle("/api/tools/stratum-test",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandleStratumTest(cfgManager))),
+		),
+	)
+
+	// Preview of a settings change against the device's current settings
+	mux.Handle("/api/instance/settings/diff",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(settingsOnly(handlers.HandleSettingsDiff(cfgManager))),
+		),
+	)
+
+	// Device settings backups and restores
+	settingsBackupsHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(settingsOnly(idempotency(handlers.HandleSettingsBackups(cfgManager)))),
+	)
+	mux.Handle("/api/instance/settings/backups", settingsBackupsHandler)
+	mux.Handle("/api/instance/settings/backups/", settingsBackupsHandler)
+
+	// Bulk action status
+	actionsHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(http.HandlerFunc(handlers.HandleActionStatus)),
+	)
+	mux.Handle("/api/actions", actionsHandler)
+	mux.Handle("/api/actions/", actionsHandler)
+
+	// Background jobs - anyone can poll, admins start and cancel
+	jobsHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(adminWrites(http.HandlerFunc(handlers.HandleJobs))),
+	)
+	mux.Handle("/api/jobs", jobsHandler)
+	mux.Handle("/api/jobs/", jobsHandler)
+
+	// Network scan for AxeOS devices, run as a background job
+	jobs.GetManager().Register(handlers.DiscoveryJobType, handlers.NewDiscoveryScanJob(cfgManager))
+	mux.Handle("/api/discovery/scan",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleDiscoveryScan))),
+		),
+	)
+
+	// AxeOS devices behind the workers of the Mining Core pools, run as a background job
+	jobs.GetManager().Register(handlers.MiningCoreImportJobType, handlers.NewMiningCoreImportJob(cfgManager))
+	mux.Handle("/api/discovery/miningcore",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleMiningCoreImport))),
+		),
+	)
+
+	// Send a test alert to the notification channels
+	mux.Handle("/api/notifications/test",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandleNotificationTest(cfgManager))),
 		),
 	)
 
 	// Configuration endpoint
 	mux.Handle("/api/configuration",
 		middleware.LoggingMiddleware(
-			apiAuthMiddleware(handlers.HandleConfiguration(cfgManager, cfg)),
+			apiAuthMiddleware(adminWrites(handlers.HandleConfiguration(cfgManager, cfg))),
+		),
+	)
+
+	// Configuration backups, restores and automatic versions (admin only)
+	mux.Handle("/api/configuration/",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandleConfigurationBackups(cfgManager, cfg))),
 		),
 	)
 
@@ -100,21 +322,272 @@ func SetupRouter(cfgManager *config.Manager, cfg *config.Config, configDir, publ
 		),
 	)
 
+	// Compact summary for widgets and scripts
+	mux.Handle("/api/summary/compact",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleCompactSummary(cfgManager)),
+		),
+	)
+
+	// Plain text fleet status for terminals (watch curl)
+	mux.Handle("/status.txt",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleStatusText(cfgManager)),
+		),
+	)
+
+	// Market data (price, difficulty, hashprice) with provider failover
+	mux.Handle("/api/market",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleMarket(cfgManager)),
+		),
+	)
+
+	// Fiat prices of BTC, the market coin and market.price_coins from the price feed
+	mux.Handle("/api/price",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandlePrice(cfgManager)),
+		),
+	)
+
+	// Device summary (lifetime and rolling-window stats)
+	mux.Handle("/api/devices/",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleDeviceSummary(cfgManager)),
+		),
+	)
+
+	// Periods devices mined on their fallback pool
+	mux.Handle("/api/failovers",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandlePoolFailovers(cfgManager)),
+		),
+	)
+
+	// Payments received by the payout addresses
+	mux.Handle("/api/payouts",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandlePayouts(cfgManager)),
+		),
+	)
+
+	// Energy consumption (daily kWh per device and fleet)
+	mux.Handle("/api/energy",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleEnergy(cfgManager)),
+		),
+	)
+
+	// Ambient temperature correlated with ASIC temperature
+	mux.Handle("/api/ambient/correlation",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleAmbientCorrelation(cfgManager)),
+		),
+	)
+
+	// Devices whose efficiency (J/TH) is degrading
+	mux.Handle("/api/reports/efficiency",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleEfficiencyReport(cfgManager)),
+		),
+	)
+
+	// Input voltage and current per power supply, with brown-outs and voltage trends
+	mux.Handle("/api/power/supplies",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandlePowerSupplies(cfgManager)),
+		),
+	)
+
+	// Device power draw against the nominal wattage and the fleet power cap
+	mux.Handle("/api/power/budget",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandlePowerBudget(cfgManager)),
+		),
+	)
+
+	// Accepted shares compared with the shares expected at the pool difficulty
+	mux.Handle("/api/shares/expected",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleShareRates(cfgManager)),
+		),
+	)
+
+	// Network difficulty and hashrate next to the fleet hashrate
+	mux.Handle("/api/network/history",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleNetworkHistory(cfgManager)),
+		),
+	)
+
+	// Halving countdown and difficulty adjustment ETA
+	mux.Handle("/api/network/milestones",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleNetworkMilestones(cfgManager)),
+		),
+	)
+
+	// Bucketed device metric series for charts
+	mux.Handle("/api/charts/",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleCharts(cfgManager)),
+		),
+	)
+
+	// Saved dashboard layouts (changes are admin-only)
+	dashboardsHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(adminWrites(handlers.HandleDashboards(cfgManager))),
+	)
+	mux.Handle("/api/dashboards", dashboardsHandler)
+	mux.Handle("/api/dashboards/", dashboardsHandler)
+
+	// Messages of the day (changes are admin-only; every user can dismiss)
+	motdHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(handlers.HandleMOTD(cfgManager)),
+	)
+	mux.Handle("/api/motd", motdHandler)
+	mux.Handle("/api/motd/", motdHandler)
+
+	// UPS power events (admin only)
+	mux.Handle("/api/power",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandlePowerEvent(cfgManager))),
+		),
+	)
+
+	// Authentication audit trail (admin only)
+	mux.Handle("/api/auth/audit",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleAuthAudit))),
+		),
+	)
+
+	// Server-side token revocation (admin only)
+	mux.Handle("/api/auth/revoke",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleTokenRevocation))),
+		),
+	)
+
+	// API keys for machine clients (admin only)
+	apiKeysHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleAPIKeys))),
+	)
+	mux.Handle("/api/apikeys", apiKeysHandler)
+	mux.Handle("/api/apikeys/", apiKeysHandler)
+
+	// Users in access.json - admins manage users, everyone can change their own password
+	usersHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(handlers.HandleUsers(cfgManager)),
+	)
+	mux.Handle("/api/users", usersHandler)
+	mux.Handle("/api/users/", usersHandler)
+
+	// Renewable login sessions (admin only)
+	sessionsHandler := middleware.LoggingMiddleware(
+		apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleSessions))),
+	)
+	mux.Handle("/api/sessions", sessionsHandler)
+	mux.Handle("/api/sessions/", sessionsHandler)
+
+	// Share link creation
+	mux.Handle("/api/share",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleShareCreate(cfgManager)),
+		),
+	)
+
+	// Purge events, alerts and audit records (independent of metric retention)
+	mux.Handle("/api/events/purge",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleEventsPurge))),
+		),
+	)
+
+	// Feed subscription token
+	mux.Handle("/api/feeds/token",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(http.HandlerFunc(handlers.HandleFeedToken)),
+		),
+	)
+
+	// Statistics image embedding token
+	mux.Handle("/api/stats/card/token",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(http.HandlerFunc(handlers.HandleStatsCardToken)),
+		),
+	)
+
+	// Metrics export and import (moving history between dashboard instances)
+	mux.Handle("/api/metrics/export",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(http.HandlerFunc(handlers.HandleMetricsExport)),
+		),
+	)
+	mux.Handle("/api/metrics/import",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleMetricsImport))),
+		),
+	)
+
+	// Historical metrics query
+	mux.Handle("/api/metrics/history",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleMetricsHistory(cfgManager)),
+		),
+	)
+
+	// Energy cost and estimated earnings per miner
+	mux.Handle("/api/metrics/profitability",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(handlers.HandleProfitability(cfgManager)),
+		),
+	)
+
 	// Migration status endpoint
 	mux.Handle("/api/migration/status",
 		middleware.LoggingMiddleware(
-			apiAuthMiddleware(http.HandlerFunc(handlers.HandleMigrationStatus)),
+			apiAuthMiddleware(handlers.HandleMigrationStatus(configDir)),
 		),
 	)
 
-	// Migration clear endpoint
+	// Migration clear endpoint - deletes migration.json (admin only)
 	mux.Handle("/api/migration/clear",
 		middleware.LoggingMiddleware(
-			apiAuthMiddleware(http.HandlerFunc(handlers.HandleMigrationClear)),
+			apiAuthMiddleware(adminOnly(handlers.HandleMigrationClear(configDir))),
+		),
+	)
+
+	// Result of each health check, including secret file permissions and credential ages (admin only)
+	mux.Handle("/api/health/details",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandleHealthDetails(cfgManager, configDir, publicDir))),
+		),
+	)
+
+	// Reachability of miners, pools, nodes, market providers and notification channels (admin only)
+	mux.Handle("/api/dependencies",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandleDependencies(cfgManager))),
+		),
+	)
+
+	// Request counts, errors and latency per route (admin only)
+	mux.Handle("/api/admin/http-stats",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(http.HandlerFunc(handlers.HandleHTTPStats))),
+		),
+	)
+
+	// Re-read the configuration files and swap in a new router without a restart (admin only)
+	mux.Handle("/api/server/reload",
+		middleware.LoggingMiddleware(
+			apiAuthMiddleware(adminOnly(handlers.HandleServerReload(reload))),
 		),
 	)
 
-	return mux
+	// Every route is counted in the per-route request statistics
+	return middleware.BasePathMiddleware(cfgManager, middleware.HTTPStatsMiddleware(mux))
 }
 
 // ServeStaticAsset serves a static file with proper MIME type
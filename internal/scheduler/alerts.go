@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+)
+
+// overheatHysteresis is how far (Celsius) a device must cool below the
+// threshold before another overheat alert can be sent
+const overheatHysteresis = 3.0
+
+// setAlert records whether an alert is active and reports whether it just became active,
+// so each incident is notified once rather than on every collection
+func (m *Manager) setAlert(key string, active bool) bool {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+
+	if m.activeAlerts == nil {
+		m.activeAlerts = map[string]bool{}
+	}
+	wasActive := m.activeAlerts[key]
+	if active {
+		m.activeAlerts[key] = true
+	} else {
+		delete(m.activeAlerts, key)
+	}
+	return active && !wasActive
+}
+
+// alertMinerOffline notifies when a miner stops answering
+func (m *Manager) alertMinerOffline(instanceName string, cause error) {
+	if !m.setAlert(config.AlertMinerOffline+":"+instanceName, true) {
+		return
+	}
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Type:    config.AlertMinerOffline,
+		Source:  instanceName,
+		Title:   fmt.Sprintf("%s is offline", instanceName),
+		Message: fmt.Sprintf("The miner could not be reached: %v", cause),
+		Fields:  map[string]interface{}{"instance": instanceName, "error": cause.Error()},
+	})
+}
+
+// checkMinerAlerts clears the offline alert of a miner that answered and
+// notifies when its ASIC temperature reaches the overheat threshold
+func (m *Manager) checkMinerAlerts(cfg *config.Config, metric *database.AxeOSMetric) {
+	m.setAlert(config.AlertMinerOffline+":"+metric.InstanceID, false)
+
+	if cfg.Notifications == nil {
+		return
+	}
+	threshold := cfg.Notifications.OverheatTemperature.Celsius()
+	key := config.AlertOverheat + ":" + metric.InstanceID
+
+	switch {
+	case metric.Temperature >= threshold:
+		if !m.setAlert(key, true) {
+			return
+		}
+		notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+			Timestamp: metric.Timestamp,
+			Type:      config.AlertOverheat,
+			Source:    metric.InstanceID,
+			Title:     fmt.Sprintf("%s is overheating", metric.InstanceName),
+			Message:   fmt.Sprintf("ASIC temperature is %.1f°C (threshold %.1f°C)", metric.Temperature, threshold),
+			Fields: map[string]interface{}{
+				"instance":    metric.InstanceName,
+				"temperature": metric.Temperature,
+				"threshold":   threshold,
+			},
+		})
+	case metric.Temperature < threshold-overheatHysteresis:
+		m.setAlert(key, false)
+	}
+}
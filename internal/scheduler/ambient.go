@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// collectAmbientTemperature records the ambient temperature so it can be
+// correlated with miner temperatures
+func (m *Manager) collectAmbientTemperature(ctx context.Context) error {
+	if m.IsPaused() {
+		return nil // Disk space guard paused collection
+	}
+
+	cfg := m.cfgManager.GetConfig()
+	if cfg == nil || cfg.AmbientSource == nil {
+		return nil
+	}
+
+	reading, err := services.ReadAmbientTemperature(cfg, m.cfgManager.GetConfigDir())
+	if err != nil {
+		return fmt.Errorf("failed to read ambient temperature: %w", err)
+	}
+
+	metric := &database.AmbientMetric{
+		Timestamp:   time.Now(),
+		Source:      reading.Source,
+		Temperature: reading.Temperature,
+		Humidity:    reading.Humidity,
+	}
+	if err := m.dbManager.InsertAmbientMetric(metric); err != nil {
+		return err
+	}
+
+	m.log.Info("Collected ambient temperature %.1f°C from %s", reading.Temperature, reading.Source)
+	return nil
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// statusError is returned when a source answers with an HTTP error status
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+// classifyCollectionError maps a collection failure to an error class
+func classifyCollectionError(err error) string {
+	var status *statusError
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &status):
+		return database.ErrorClassBadStatus
+	case errors.As(err, &netErr):
+		return database.ErrorClassUnreachable
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return database.ErrorClassParse
+	}
+	return database.ErrorClassOther
+}
+
+// recordCollectionError stores a failed collection so the history can show why samples are missing
+func (m *Manager) recordCollectionError(sourceType, sourceID string, err error) {
+	collectionError := &database.CollectionError{
+		Timestamp:  time.Now(),
+		SourceType: sourceType,
+		SourceID:   sourceID,
+		Class:      classifyCollectionError(err),
+		Message:    err.Error(),
+	}
+	if err := m.dbManager.InsertCollectionError(collectionError); err != nil {
+		m.log.Warn("Failed to record collection error for %s: %v", sourceID, err)
+	}
+}
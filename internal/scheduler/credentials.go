@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/auth"
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+)
+
+// credentialCheckInterval is how often credential ages are compared with the rotation policy
+const credentialCheckInterval = 6 * time.Hour
+
+// credentialSource is the event source of rotation reminders for a credential
+func credentialSource(age auth.CredentialAge) string {
+	if age.User != "" {
+		return "credentials:" + age.Credential + ":" + age.User
+	}
+	return "credentials:" + age.Credential
+}
+
+// checkCredentialRotation raises a reminder for every credential older than
+// the rotation policy allows, repeated every reminder_days while it stays overdue
+func (m *Manager) checkCredentialRotation(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+	if cfg.DisableAuthentication || !cfg.CredentialRotation.Enabled() {
+		return nil
+	}
+
+	// Reminders are stored as events, so a restart does not repeat them early
+	reminderDays := cfg.CredentialRotation.ReminderInterval()
+	recent, err := m.dbManager.GetEvents(time.Now().AddDate(0, 0, -reminderDays), []string{database.EventAlert}, -1)
+	if err != nil {
+		return err
+	}
+	reminded := map[string]bool{}
+	for _, event := range recent {
+		reminded[event.Source] = true
+	}
+
+	for _, age := range auth.CheckCredentialAges(m.cfgManager.GetConfigDir(), cfg.CredentialRotation) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		source := credentialSource(age)
+		if !age.RotationDue || reminded[source] {
+			continue
+		}
+
+		title := fmt.Sprintf("Rotate the JWT signing key (%d days old)", age.AgeDays)
+		message := fmt.Sprintf("jsonWebTokenKey.json has not changed for %d days; the policy is to rotate it every %d days. Replace jsonWebTokenKey and set rotatedAt.", age.AgeDays, age.MaxAgeDays)
+		if age.Credential == auth.CredentialPassword {
+			title = fmt.Sprintf("Rotate the password of %s (%d days old)", age.User, age.AgeDays)
+			message = fmt.Sprintf("The password of %s has not changed for %d days; the policy is to change it every %d days. Update access.json and set passwordChangedAt.", age.User, age.AgeDays, age.MaxAgeDays)
+		}
+
+		m.log.Warn("CREDENTIALS: %s", title)
+		m.recordEvent(&database.Event{
+			Type:    database.EventAlert,
+			Source:  source,
+			Title:   title,
+			Message: message,
+		})
+		notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+			Type:    config.AlertCredentialRotation,
+			Source:  source,
+			Title:   title,
+			Message: message,
+			Fields: map[string]interface{}{
+				"credential": age.Credential,
+				"user":       age.User,
+				"ageDays":    age.AgeDays,
+				"maxAgeDays": age.MaxAgeDays,
+			},
+		})
+	}
+
+	return nil
+}
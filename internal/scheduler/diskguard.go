@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+const (
+	// diskGuardInterval is how often free space and database size are checked
+	diskGuardInterval = time.Minute
+	// diskGuardPruneFraction is the share of oldest rows removed per prune pass
+	diskGuardPruneFraction = 0.10
+)
+
+// DiskGuardStatus reports the most recent result of the disk space guard
+type DiskGuardStatus struct {
+	Status            *database.DiskStatus `json:"status,omitempty"`
+	OverLimit         bool                 `json:"overLimit"`
+	Paused            bool                 `json:"collectionPaused"`
+	Reason            string               `json:"reason,omitempty"`
+	PruneFreedNothing bool                 `json:"pruneFreedNothing,omitempty"` // Paused instead of pruning again until back within limits
+	LastChecked       time.Time            `json:"lastChecked"`
+}
+
+// overDiskLimit returns a description of the exceeded limit, or "" when within limits
+func overDiskLimit(status *database.DiskStatus, minFreeMB, maxSizeMB int) string {
+	const mb = 1024 * 1024
+
+	if minFreeMB > 0 && status.FreeBytes < uint64(minFreeMB)*mb {
+		return fmt.Sprintf("only %d MB free on data volume (minimum %d MB)", status.FreeBytes/mb, minFreeMB)
+	}
+	if maxSizeMB > 0 && status.DatabaseBytes > int64(maxSizeMB)*mb {
+		return fmt.Sprintf("metrics.db is %d MB (limit %d MB)", status.DatabaseBytes/mb, maxSizeMB)
+	}
+	return ""
+}
+
+// checkDiskSpace pauses collection or prunes old data when the data volume is running out of space
+func (m *Manager) checkDiskSpace(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+
+	status, err := m.dbManager.DiskStatus()
+	if err != nil {
+		return err
+	}
+
+	reason := overDiskLimit(status, cfg.DiskMinFreeMB, cfg.DatabaseMaxSizeMB)
+	guard := m.diskGuard()
+	wasOver := guard.OverLimit
+
+	if reason == "" {
+		if wasOver {
+			m.paused.Store(false)
+			m.log.Info("Disk space back within limits, data collection resumed")
+		}
+		m.setDiskGuard(DiskGuardStatus{Status: status, LastChecked: time.Now()})
+		return nil
+	}
+
+	if !wasOver {
+		m.log.Error("DISK SPACE ALERT: %s", reason)
+		// The insert may itself fail on a full disk; the log line above is the fallback
+		m.recordEvent(&database.Event{
+			Type:    database.EventAlert,
+			Source:  "disk",
+			Title:   "Disk space alert",
+			Message: fmt.Sprintf("%s; action: %s", reason, cfg.DiskGuardAction),
+		})
+	}
+
+	freedNothing := guard.PruneFreedNothing
+	if cfg.DiskGuardAction == "pause" || freedNothing {
+		if !m.paused.Load() {
+			m.log.Warn("Pausing data collection until disk space is available")
+		}
+		m.paused.Store(true)
+	} else {
+		deleted, err := m.dbManager.PruneOldest(diskGuardPruneFraction)
+		if err != nil {
+			m.log.Error("Disk guard prune failed: %v", err)
+		} else {
+			m.log.Warn("Disk guard pruned %d of the oldest metric rows", deleted)
+		}
+
+		// Reclaim file space when there is room for SQLite to rebuild the file
+		if status.FreeBytes > uint64(status.DatabaseBytes) {
+			if err := m.dbManager.Vacuum(ctx); err != nil {
+				m.log.Warn("VACUUM after prune failed: %v", err)
+			}
+		}
+
+		// Pruning again would only wipe history when metrics.db did not
+		// shrink, e.g. because other files fill the volume, so pause
+		// collection instead
+		if after, err := m.dbManager.DiskStatus(); err == nil {
+			if deleted == 0 || after.DatabaseBytes >= status.DatabaseBytes {
+				freedNothing = true
+				m.log.Warn("Pruning freed no disk space; pausing data collection until disk space is available")
+				m.paused.Store(true)
+			}
+			status = after
+		}
+	}
+
+	m.setDiskGuard(DiskGuardStatus{
+		Status:            status,
+		OverLimit:         true,
+		Paused:            m.paused.Load(),
+		Reason:            reason,
+		PruneFreedNothing: freedNothing,
+		LastChecked:       time.Now(),
+	})
+	return nil
+}
+
+// diskGuard returns a copy of the last disk guard result
+func (m *Manager) diskGuard() DiskGuardStatus {
+	m.guardMu.RLock()
+	defer m.guardMu.RUnlock()
+	return m.guardStatus
+}
+
+func (m *Manager) setDiskGuard(status DiskGuardStatus) {
+	m.guardMu.Lock()
+	defer m.guardMu.Unlock()
+	m.guardStatus = status
+}
+
+// DiskGuardStatus returns the last result of the disk space guard
+func (m *Manager) DiskGuardStatus() DiskGuardStatus {
+	return m.diskGuard()
+}
+
+// Instance returns the scheduler manager if it is running, or nil
+func Instance() *Manager {
+	if instance == nil || !instance.IsRunning() {
+		return nil
+	}
+	return instance
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	// efficiencyReportInterval is how often device efficiency trends are checked
+	efficiencyReportInterval = 24 * time.Hour
+	// efficiencyReportDays is how long a degrading device stays quiet after a report
+	efficiencyReportDays = 7
+)
+
+// efficiencySource is the event source of efficiency reports for a device
+func efficiencySource(instanceID string) string {
+	return "efficiency:" + instanceID
+}
+
+// reportEfficiencyRegressions fits a trend to each device's daily efficiency
+// and reports the devices degrading beyond the threshold, each at most once
+// a week while it keeps degrading
+func (m *Manager) reportEfficiencyRegressions(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+	report := cfg.EfficiencyReport
+	if !report.IsEnabled() {
+		return nil
+	}
+
+	// Reports are stored as events, so a restart does not repeat them early
+	recent, err := m.dbManager.GetEvents(time.Now().AddDate(0, 0, -efficiencyReportDays), []string{database.EventAlert}, -1)
+	if err != nil {
+		return err
+	}
+	reported := map[string]bool{}
+	for _, event := range recent {
+		reported[event.Source] = true
+	}
+
+	var lines []string
+	var degrading []map[string]interface{}
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			source := efficiencySource(name)
+			if reported[source] {
+				continue
+			}
+			trend, err := services.AnalyzeEfficiency(m.dbManager, name, time.Now(), report.TrendWeeks(), report.Threshold())
+			if err != nil {
+				m.log.Error("Failed to analyze the efficiency of %s: %v", name, err)
+				continue
+			}
+			if !trend.Degrading {
+				continue
+			}
+
+			title := fmt.Sprintf("%s efficiency is degrading (+%.1f%% per week)", name, trend.ChangePercentPerWeek)
+			message := fmt.Sprintf("%s went from %.1f to %.1f J/TH over the last %d days (+%.2f J/TH per week). Check for dust buildup and failing fans.",
+				name, trend.StartEfficiency, trend.EndEfficiency, trend.Days, trend.ChangePerWeek)
+			m.log.Warn("EFFICIENCY: %s", title)
+			m.recordEvent(&database.Event{
+				Type:    database.EventAlert,
+				Source:  source,
+				Title:   title,
+				Message: message,
+			})
+			lines = append(lines, message)
+			degrading = append(degrading, map[string]interface{}{
+				"instance":             name,
+				"startEfficiency":      trend.StartEfficiency,
+				"endEfficiency":        trend.EndEfficiency,
+				"changePerWeek":        trend.ChangePerWeek,
+				"changePercentPerWeek": trend.ChangePercentPerWeek,
+				"days":                 trend.Days,
+			})
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+	title := "1 device is losing efficiency"
+	if len(lines) > 1 {
+		title = fmt.Sprintf("%d devices are losing efficiency", len(lines))
+	}
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Type:    config.AlertEfficiencyRegression,
+		Source:  "efficiency",
+		Title:   title,
+		Message: strings.Join(lines, "\n"),
+		Fields:  map[string]interface{}{"devices": degrading},
+	})
+	return nil
+}
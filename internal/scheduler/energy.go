@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// energyMaxGapIntervals is the largest gap between samples (in collection
+// intervals) that is integrated; longer gaps mean the device or dashboard was
+// down and only one interval at the current power is counted.
+const energyMaxGapIntervals = 2
+
+// accumulateEnergy integrates power between the previous and the new sample
+// (trapezoidal rule) into the device and fleet kWh buckets for the day, along
+// with the estimated emissions at the current grid carbon intensity and the
+// cost at the electricity price in effect midway through the interval
+func (m *Manager) accumulateEnergy(previous, metric *database.AxeOSMetric, interval time.Duration) {
+	if metric.Power <= 0 {
+		return
+	}
+
+	elapsed := interval
+	avgPower := metric.Power
+	if previous != nil {
+		gap := metric.Timestamp.Sub(previous.Timestamp)
+		if gap > 0 && gap <= energyMaxGapIntervals*interval {
+			elapsed = gap
+			avgPower = (previous.Power + metric.Power) / 2
+		}
+	}
+
+	wattHours := avgPower * elapsed.Hours()
+	cfg := m.cfgManager.GetConfig()
+
+	var co2Grams float64
+	if intensity := services.GetCarbonIntensity(cfg, m.cfgManager.GetConfigDir()); intensity != nil {
+		co2Grams = wattHours / 1000 * intensity.GramsPerKWh
+	}
+
+	cost := wattHours / 1000 * cfg.Electricity.PriceAt(metric.Timestamp.Add(-elapsed/2))
+
+	day := metric.Timestamp.Format(database.SnapshotDayFormat)
+	if err := m.dbManager.AddEnergy(metric.InstanceID, day, wattHours, co2Grams, cost, int(elapsed.Seconds())); err != nil {
+		m.log.Error("Failed to record energy for %s: %v", metric.InstanceID, err)
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// recordEvent stores an event, logging rather than failing collection on error
+func (m *Manager) recordEvent(event *database.Event) {
+	if err := m.dbManager.InsertEvent(event); err != nil {
+		m.log.Error("Failed to record %s event for %s: %v", event.Type, event.Source, err)
+		return
+	}
+	m.log.Info("Event: %s", event.Title)
+}
+
+// detectAxeOSEvents compares a new metric with the previous one for the
+// same instance and records milestone events (new best difficulty)
+func (m *Manager) detectAxeOSEvents(previous, metric *database.AxeOSMetric) {
+	if previous == nil {
+		return
+	}
+
+	newBest, err := services.ParseDifficulty(metric.BestDiff)
+	if err != nil {
+		return
+	}
+	oldBest, err := services.ParseDifficulty(previous.BestDiff)
+	if err != nil {
+		return
+	}
+
+	if newBest > oldBest {
+		m.recordEvent(&database.Event{
+			Timestamp: metric.Timestamp,
+			Type:      database.EventBestDiff,
+			Source:    metric.InstanceID,
+			Title:     fmt.Sprintf("%s: new best difficulty %s", metric.InstanceName, services.FormatDifficulty(newBest)),
+			Message:   fmt.Sprintf("Best difficulty increased from %s to %s", services.FormatDifficulty(oldBest), services.FormatDifficulty(newBest)),
+		})
+	}
+}
+
+// detectPoolEvents records a block_found event when a pool's block count increases
+func (m *Manager) detectPoolEvents(metric *database.PoolMetric) {
+	previous, err := m.dbManager.GetLatestPoolMetric(metric.PoolID)
+	if err != nil || previous == nil {
+		return
+	}
+
+	if metric.BlocksFound > previous.BlocksFound {
+		found := metric.BlocksFound - previous.BlocksFound
+		m.recordEvent(&database.Event{
+			Timestamp: metric.Timestamp,
+			Type:      database.EventBlockFound,
+			Source:    metric.PoolID,
+			Title:     fmt.Sprintf("%s: block found!", metric.PoolName),
+			Message:   fmt.Sprintf("%d new block(s) found, %d total", found, metric.BlocksFound),
+		})
+		notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+			Timestamp: metric.Timestamp,
+			Type:      config.AlertBlockFound,
+			Source:    metric.PoolID,
+			Title:     fmt.Sprintf("%s: block found!", metric.PoolName),
+			Message:   fmt.Sprintf("%d new block(s) found, %d total", found, metric.BlocksFound),
+			Fields:    map[string]interface{}{"pool": metric.PoolName, "found": found, "total": metric.BlocksFound},
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+)
+
+// usingFallback reads isUsingFallbackStratum, which AxeOS reports as 0 or 1
+// (or true/false on some builds). ok is false when the firmware does not report it.
+func usingFallback(info map[string]interface{}) (fallback, ok bool) {
+	switch v := info["isUsingFallbackStratum"].(type) {
+	case float64:
+		return v != 0, true
+	case bool:
+		return v, true
+	}
+	return false, false
+}
+
+// stratumPool returns "url:port" of a pool in the device info, or "" when it is not set
+func stratumPool(info map[string]interface{}, urlField, portField string) string {
+	url, _ := info[urlField].(string)
+	if url == "" {
+		return ""
+	}
+	if port, ok := info[portField].(float64); ok && port > 0 {
+		return fmt.Sprintf("%s:%.0f", url, port)
+	}
+	return url
+}
+
+// formatMinutes returns a duration as whole minutes or hours for messages
+func formatMinutes(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 120 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return fmt.Sprintf("%.1f hours", d.Hours())
+}
+
+// trackPoolFailover records when a device switches to or back from its
+// fallback pool, and raises an alert once when it stays on the fallback pool
+// longer than fallback_alert_minutes. Failovers are stored, so an alert is
+// not repeated after a restart.
+func (m *Manager) trackPoolFailover(cfg *config.Config, metric *database.AxeOSMetric, info map[string]interface{}) {
+	fallback, ok := usingFallback(info)
+	if !ok {
+		return
+	}
+
+	active, err := m.dbManager.GetActivePoolFailover(metric.InstanceID)
+	if err != nil {
+		m.log.Warn("Failed to load pool failover of %s: %v", metric.InstanceID, err)
+		return
+	}
+
+	switch {
+	case fallback && active == nil:
+		active = &database.PoolFailover{
+			InstanceID:   metric.InstanceID,
+			StartedAt:    metric.Timestamp,
+			PrimaryPool:  stratumPool(info, "stratumURL", "stratumPort"),
+			FallbackPool: stratumPool(info, "fallbackStratumURL", "fallbackStratumPort"),
+		}
+		if err := m.dbManager.StartPoolFailover(active); err != nil {
+			m.log.Error("Failed to record pool failover of %s: %v", metric.InstanceID, err)
+			return
+		}
+		m.recordEvent(&database.Event{
+			Timestamp: metric.Timestamp,
+			Type:      database.EventFailover,
+			Source:    metric.InstanceID,
+			Title:     fmt.Sprintf("%s switched to its fallback pool", metric.InstanceName),
+			Message:   fmt.Sprintf("Primary pool %s is not in use; mining on %s", active.PrimaryPool, active.FallbackPool),
+		})
+
+	case !fallback && active != nil:
+		if err := m.dbManager.EndPoolFailover(active.ID, metric.Timestamp); err != nil {
+			m.log.Error("Failed to record the end of the pool failover of %s: %v", metric.InstanceID, err)
+			return
+		}
+		m.recordEvent(&database.Event{
+			Timestamp: metric.Timestamp,
+			Type:      database.EventFailover,
+			Source:    metric.InstanceID,
+			Title:     fmt.Sprintf("%s is back on its primary pool", metric.InstanceName),
+			Message:   fmt.Sprintf("Mined on the fallback pool %s for %s", active.FallbackPool, formatMinutes(metric.Timestamp.Sub(active.StartedAt))),
+		})
+		return
+	}
+
+	if active == nil || active.Alerted {
+		return
+	}
+	window := cfg.Notifications.FallbackAlertWindow()
+	onFallback := metric.Timestamp.Sub(active.StartedAt)
+	if window == 0 || onFallback < window {
+		return
+	}
+
+	title := fmt.Sprintf("%s has been on its fallback pool for %s", metric.InstanceName, formatMinutes(onFallback))
+	message := fmt.Sprintf("Primary pool %s has not been in use since %s; check the pool and the device's stratum settings",
+		active.PrimaryPool, active.StartedAt.Format(time.RFC3339))
+	m.recordEvent(&database.Event{
+		Timestamp: metric.Timestamp,
+		Type:      database.EventAlert,
+		Source:    metric.InstanceID,
+		Title:     title,
+		Message:   message,
+	})
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Timestamp: metric.Timestamp,
+		Type:      config.AlertPoolFailover,
+		Source:    metric.InstanceID,
+		Title:     title,
+		Message:   message,
+		Fields: map[string]interface{}{
+			"instance":     metric.InstanceName,
+			"primaryPool":  active.PrimaryPool,
+			"fallbackPool": active.FallbackPool,
+			"since":        active.StartedAt,
+			"minutes":      int(onFallback.Minutes()),
+		},
+	})
+	if err := m.dbManager.MarkPoolFailoverAlerted(active.ID); err != nil {
+		m.log.Error("Failed to update pool failover of %s: %v", metric.InstanceID, err)
+	}
+}
@@ -2,8 +2,10 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/scottwalter/axeos-dashboard/internal/config"
@@ -26,6 +28,23 @@ type Manager struct {
 	wg         sync.WaitGroup
 	mu         sync.RWMutex
 	log        *logger.Logger
+
+	// Disk space guard state
+	paused      atomic.Bool
+	guardStatus DiskGuardStatus
+	guardMu     sync.RWMutex
+
+	// Alerts currently active, notified once per incident
+	activeAlerts map[string]bool
+	alertMu      sync.Mutex
+
+	// Accepted against expected shares per device
+	shareWindows map[string]*shareWindow
+	shareMu      sync.Mutex
+
+	// Devices the power cap controller adjusted, waiting for a new metric
+	powerCapAdjusted map[string]time.Time
+	powerCapMu       sync.Mutex
 }
 
 // Task represents a scheduled collection task
@@ -34,6 +53,29 @@ type Task struct {
 	Interval time.Duration
 	Ticker   *time.Ticker
 	Fn       func(context.Context) error
+	stop     chan struct{} // Closed to retire the task after its current run
+	lastRun  atomic.Pointer[taskRun]
+}
+
+// taskRun is the outcome of the last run of a task
+type taskRun struct {
+	at  time.Time
+	err error
+}
+
+// TaskStatus describes a scheduled task for health checks
+type TaskStatus struct {
+	Name            string     `json:"name"`
+	IntervalSeconds float64    `json:"intervalSeconds"`
+	LastRun         *time.Time `json:"lastRun,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+}
+
+// Status describes the scheduler for health checks
+type Status struct {
+	Running bool         `json:"running"`
+	Paused  bool         `json:"paused"` // Collection paused by the disk space guard
+	Tasks   []TaskStatus `json:"tasks"`
 }
 
 // GetManager returns the singleton scheduler manager instance
@@ -67,18 +109,69 @@ func (m *Manager) Start() error {
 	}
 
 	// Register collection tasks based on configuration
-	m.registerTasks(cfg)
+	m.tasks = m.registerTasks(cfg)
 
 	// Start all tasks
 	for _, task := range m.tasks {
-		m.wg.Add(1)
-		go m.runTask(task)
+		m.startTask(task)
 	}
 
 	m.log.Info("Scheduler started with %d tasks", len(m.tasks))
 	return nil
 }
 
+// Reload brings the running tasks in line with a changed configuration:
+// tasks that are no longer configured stop after their current run, new ones
+// start, and changed intervals take effect from the next tick. Collections in
+// progress are not interrupted.
+func (m *Manager) Reload(cfg *config.Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel == nil {
+		return // Not running; Start reads the configuration
+	}
+
+	next := m.registerTasks(cfg)
+	desired := map[string]*Task{}
+	for _, task := range next {
+		desired[task.Name] = task
+	}
+
+	var tasks []*Task
+	added, removed, changed := 0, 0, 0
+	for _, task := range m.tasks {
+		replacement, ok := desired[task.Name]
+		if !ok {
+			close(task.stop)
+			removed++
+			m.log.Info("Retiring task: %s", task.Name)
+			continue
+		}
+		if replacement.Interval != task.Interval {
+			task.Interval = replacement.Interval
+			task.Ticker.Reset(replacement.Interval)
+			changed++
+			m.log.Info("Task %s now runs every %v", task.Name, task.Interval)
+		}
+		delete(desired, task.Name)
+		tasks = append(tasks, task)
+	}
+	// Start new tasks in registration order
+	for _, task := range next {
+		if _, ok := desired[task.Name]; ok {
+			m.startTask(task)
+			tasks = append(tasks, task)
+			added++
+		}
+	}
+	m.tasks = tasks
+
+	if added > 0 || removed > 0 || changed > 0 {
+		m.log.Info("Scheduler reloaded: %d tasks added, %d retired, %d rescheduled", added, removed, changed)
+	}
+}
+
 // Stop gracefully stops all scheduled tasks
 func (m *Manager) Stop() {
 	m.mu.Lock()
@@ -103,12 +196,16 @@ func (m *Manager) Stop() {
 
 	m.cancel = nil
 	m.tasks = make([]*Task, 0)
+	m.paused.Store(false)
+	m.setDiskGuard(DiskGuardStatus{})
 
 	m.log.Info("Scheduler stopped")
 }
 
 // registerTasks creates collection tasks based on configuration
-func (m *Manager) registerTasks(cfg *config.Config) {
+func (m *Manager) registerTasks(cfg *config.Config) []*Task {
+	var tasks []*Task
+
 	// Default collection interval (5 minutes if not specified)
 	defaultInterval := 5 * time.Minute
 
@@ -120,17 +217,17 @@ func (m *Manager) registerTasks(cfg *config.Config) {
 
 	// Register AxeOS miner collection task
 	if len(cfg.AxeosInstances) > 0 {
-		m.tasks = append(m.tasks, &Task{
+		tasks = append(tasks, &Task{
 			Name:     "AxeOS Miners Collection",
 			Interval: collectionInterval,
 			Fn:       m.collectAxeOSMetrics,
 		})
 	}
 
-	// Register Mining Core pool collection task
-	if cfg.MiningCoreEnabled && len(cfg.MiningCoreURL) > 0 {
-		m.tasks = append(m.tasks, &Task{
-			Name:     "Mining Core Pools Collection",
+	// Register Mining Core and public pool collection task
+	if (cfg.MiningCoreEnabled && len(cfg.MiningCoreURL) > 0) || len(cfg.PublicPools) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Pools Collection",
 			Interval: collectionInterval,
 			Fn:       m.collectPoolMetrics,
 		})
@@ -138,28 +235,126 @@ func (m *Manager) registerTasks(cfg *config.Config) {
 
 	// Register crypto node collection task
 	if cfg.CryptNodesEnabled {
-		m.tasks = append(m.tasks, &Task{
+		tasks = append(tasks, &Task{
 			Name:     "Crypto Nodes Collection",
 			Interval: collectionInterval,
 			Fn:       m.collectNodeMetrics,
 		})
 	}
+
+	// Register ambient temperature collection
+	if cfg.AmbientSource != nil {
+		tasks = append(tasks, &Task{
+			Name:     "Ambient Temperature Collection",
+			Interval: collectionInterval,
+			Fn:       m.collectAmbientTemperature,
+		})
+	}
+
+	// Register hourly and daily rollups used for long history ranges
+	if len(cfg.AxeosInstances) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Metric Rollups",
+			Interval: rollupInterval,
+			Fn:       m.updateRollups,
+		})
+	}
+
+	// Register daily snapshot of lifetime counters
+	if len(cfg.AxeosInstances) > 0 || (cfg.MiningCoreEnabled && len(cfg.MiningCoreURL) > 0) || len(cfg.PublicPools) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Daily Snapshot",
+			Interval: snapshotInterval,
+			Fn:       m.takeDailySnapshots,
+		})
+	}
+
+	// Register event, alert and audit retention
+	tasks = append(tasks, &Task{
+		Name:     "Event Retention",
+		Interval: eventRetentionInterval,
+		Fn:       m.pruneEvents,
+	})
+
+	// Register credential rotation reminders
+	if cfg.CredentialRotation.Enabled() {
+		tasks = append(tasks, &Task{
+			Name:     "Credential Rotation",
+			Interval: credentialCheckInterval,
+			Fn:       m.checkCredentialRotation,
+		})
+	}
+
+	// Register the weekly efficiency regression report
+	if cfg.EfficiencyReport.IsEnabled() && len(cfg.AxeosInstances) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Efficiency Report",
+			Interval: efficiencyReportInterval,
+			Fn:       m.reportEfficiencyRegressions,
+		})
+	}
+
+	// Register the daily power supply degradation check
+	if cfg.PowerMonitoring.IsEnabled() && len(cfg.AxeosInstances) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Power Supply Trends",
+			Interval: supplyTrendInterval,
+			Fn:       m.reportSupplyDegradation,
+		})
+	}
+
+	// Register the fleet power cap controller
+	if cfg.PowerBudget.ControlsCap() && len(cfg.AxeosInstances) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Power Cap Controller",
+			Interval: powerCapControlInterval,
+			Fn:       m.controlPowerCap,
+		})
+	}
+
+	// Register the payout address watch
+	if len(cfg.PayoutAddresses) > 0 {
+		tasks = append(tasks, &Task{
+			Name:     "Payout Addresses",
+			Interval: payoutCheckInterval,
+			Fn:       m.checkPayouts,
+		})
+	}
+
+	// Register disk space guard
+	tasks = append(tasks, &Task{
+		Name:     "Disk Space Guard",
+		Interval: diskGuardInterval,
+		Fn:       m.checkDiskSpace,
+	})
+
+	return tasks
+}
+
+// ErrCollectionPaused is returned for pushed samples while the disk space guard pauses collection
+var ErrCollectionPaused = errors.New("data collection is paused by the disk space guard")
+
+// IsPaused reports whether collection is paused by the disk space guard
+func (m *Manager) IsPaused() bool {
+	return m.paused.Load()
+}
+
+// startTask creates the ticker of a task and runs it in a goroutine. The caller holds m.mu.
+func (m *Manager) startTask(task *Task) {
+	task.Ticker = time.NewTicker(task.Interval)
+	task.stop = make(chan struct{})
+	m.log.Info("Started task: %s (interval: %v)", task.Name, task.Interval)
+	m.wg.Add(1)
+	go m.runTask(task)
 }
 
 // runTask runs a single scheduled task in a goroutine
 func (m *Manager) runTask(task *Task) {
 	defer m.wg.Done()
-
-	// Create ticker for this task
-	task.Ticker = time.NewTicker(task.Interval)
 	defer task.Ticker.Stop()
 
-	m.log.Info("Started task: %s (interval: %v)", task.Name, task.Interval)
-
 	// Run immediately on start
-	if err := task.Fn(m.ctx); err != nil {
-		m.log.Error("Error in task %s: %v", task.Name, err)
-	}
+	m.runOnce(task)
 
 	// Then run on ticker
 	for {
@@ -167,12 +362,42 @@ func (m *Manager) runTask(task *Task) {
 		case <-m.ctx.Done():
 			m.log.Info("Stopped task: %s", task.Name)
 			return
+		case <-task.stop:
+			m.log.Info("Stopped task: %s", task.Name)
+			return
 		case <-task.Ticker.C:
-			if err := task.Fn(m.ctx); err != nil {
-				m.log.Error("Error in task %s: %v", task.Name, err)
+			m.runOnce(task)
+		}
+	}
+}
+
+// runOnce runs a task and records the outcome
+func (m *Manager) runOnce(task *Task) {
+	err := task.Fn(m.ctx)
+	if err != nil {
+		m.log.Error("Error in task %s: %v", task.Name, err)
+	}
+	task.lastRun.Store(&taskRun{at: time.Now(), err: err})
+}
+
+// Status returns whether the scheduler is running and when each task last ran
+func (m *Manager) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := Status{Running: m.cancel != nil, Paused: m.paused.Load(), Tasks: []TaskStatus{}}
+	for _, task := range m.tasks {
+		taskStatus := TaskStatus{Name: task.Name, IntervalSeconds: task.Interval.Seconds()}
+		if run := task.lastRun.Load(); run != nil {
+			at := run.at
+			taskStatus.LastRun = &at
+			if run.err != nil {
+				taskStatus.LastError = run.err.Error()
 			}
 		}
+		status.Tasks = append(status.Tasks, taskStatus)
 	}
+	return status
 }
 
 // IsRunning returns whether the scheduler is currently running
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// detectNetworkMilestones compares a node's new network sample with the
+// previous one. When the chain entered the next difficulty period it stores
+// the retarget result, records an event and, with difficulty_adjustments
+// enabled, notifies; crossing a halving height records an event.
+func (m *Manager) detectNetworkMilestones(cfg *config.Config, previous, metric *database.NetworkMetric) {
+	if previous == nil || previous.BlockHeight <= 0 || metric.BlockHeight <= previous.BlockHeight {
+		return
+	}
+
+	previousEpoch := previous.BlockHeight / services.DifficultyAdjustmentInterval
+	epoch := metric.BlockHeight / services.DifficultyAdjustmentInterval
+	// After a gap of more than one period the previous difficulty is not the one that was adjusted
+	if epoch == previousEpoch+1 && previous.Difficulty > 0 && metric.Difficulty > 0 {
+		adjustment := &database.DifficultyAdjustment{
+			NodeID:             metric.NodeID,
+			Height:             epoch * services.DifficultyAdjustmentInterval,
+			Timestamp:          metric.Timestamp,
+			PreviousDifficulty: previous.Difficulty,
+			Difficulty:         metric.Difficulty,
+			ChangePercent:      (metric.Difficulty/previous.Difficulty - 1) * 100,
+		}
+		inserted, err := m.dbManager.InsertDifficultyAdjustment(adjustment)
+		if err != nil {
+			m.log.Error("Failed to record difficulty adjustment of %s: %v", metric.NodeID, err)
+		} else if inserted {
+			title := fmt.Sprintf("Difficulty adjusted %+.2f%% at block %d", adjustment.ChangePercent, adjustment.Height)
+			message := fmt.Sprintf("Difficulty changed from %s to %s",
+				services.FormatDifficulty(adjustment.PreviousDifficulty), services.FormatDifficulty(adjustment.Difficulty))
+			m.recordEvent(&database.Event{
+				Timestamp: metric.Timestamp,
+				Type:      database.EventDifficultyAdjustment,
+				Source:    metric.NodeID,
+				Title:     title,
+				Message:   message,
+			})
+			if cfg.Notifications != nil && cfg.Notifications.DifficultyAdjustments {
+				notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+					Timestamp: metric.Timestamp,
+					Type:      config.AlertDifficultyAdjustment,
+					Source:    metric.NodeID,
+					Title:     title,
+					Message:   message,
+					Fields: map[string]interface{}{
+						"height":             adjustment.Height,
+						"previousDifficulty": adjustment.PreviousDifficulty,
+						"difficulty":         adjustment.Difficulty,
+						"changePercent":      adjustment.ChangePercent,
+					},
+				})
+			}
+		}
+	}
+
+	if halvings := metric.BlockHeight / services.HalvingInterval; halvings > previous.BlockHeight/services.HalvingInterval {
+		m.recordEvent(&database.Event{
+			Timestamp: metric.Timestamp,
+			Type:      database.EventHalving,
+			Source:    metric.NodeID,
+			Title:     fmt.Sprintf("Halving %d at block %d", halvings, halvings*services.HalvingInterval),
+			Message:   fmt.Sprintf("The block subsidy is now %g", services.BitcoinSubsidy(metric.BlockHeight)),
+		})
+	}
+}
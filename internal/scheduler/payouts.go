@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	// payoutCheckInterval is how often payout addresses are checked for payments
+	payoutCheckInterval = 10 * time.Minute
+	// knownPayoutLimit is how many stored payments of an address are compared
+	// with the transactions read
+	knownPayoutLimit = 1000
+)
+
+// checkPayouts reads the payments to every payout address, stores new ones
+// and records a payout event for each payment received since the address
+// has been watched
+func (m *Manager) checkPayouts(ctx context.Context) error {
+	if m.IsPaused() {
+		return nil // Disk space guard paused collection
+	}
+	cfg := m.cfgManager.GetConfig()
+
+	var rpcClient *services.RPCClient
+	for _, payout := range cfg.PayoutAddresses {
+		if payout.SourceOrDefault() == config.PayoutSourceNode {
+			rpcClient = services.NewRPCClient(m.cfgManager.GetConfigDir())
+			rpcClient.SetOutboundConfig(cfg)
+			if err := rpcClient.LoadConfig(); err != nil {
+				rpcClient = nil
+			}
+			break
+		}
+	}
+
+	for _, payout := range cfg.PayoutAddresses {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := m.checkPayoutAddress(cfg, rpcClient, payout); err != nil {
+			m.log.Error("Failed to check payout address %s: %v", payout.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkPayoutAddress stores the new payments of one payout address
+func (m *Manager) checkPayoutAddress(cfg *config.Config, rpcClient *services.RPCClient, payout config.PayoutAddress) error {
+	since, err := m.dbManager.StartPayoutWatch(payout.Address)
+	if err != nil {
+		return err
+	}
+	stored, err := m.dbManager.GetPayouts(payout.Address, knownPayoutLimit)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(stored))
+	for _, p := range stored {
+		known[p.TxID] = true
+	}
+
+	payouts, err := services.FetchPayouts(cfg, rpcClient, payout, func(txid string) bool { return known[txid] })
+	if err != nil {
+		return err
+	}
+
+	// Store oldest first, so events are recorded in the order they were paid
+	for i := len(payouts) - 1; i >= 0; i-- {
+		p := payouts[i]
+		inserted, err := m.dbManager.InsertPayout(p)
+		if err != nil {
+			return err
+		}
+		if !inserted || p.Timestamp.Before(since) {
+			continue // Paid before the address was watched
+		}
+
+		kind := "Payment"
+		if p.Coinbase {
+			kind = "Coinbase payment"
+		}
+		m.recordEvent(&database.Event{
+			Timestamp: p.Timestamp,
+			Type:      database.EventPayout,
+			Source:    payout.Name,
+			Title:     fmt.Sprintf("%s: received %.8f BTC", payout.Name, float64(p.AmountSats)/1e8),
+			Message:   fmt.Sprintf("%s to %s in block %d (%s)", kind, payout.Address, p.BlockHeight, p.TxID),
+		})
+	}
+	return nil
+}
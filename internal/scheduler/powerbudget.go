@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// powerCapRecoveryFactor is the part of the fleet cap the total power must
+// drop below before another power_cap alert can be sent
+const powerCapRecoveryFactor = 0.95
+
+// powerCapSource is the event source of fleet power cap alerts
+const powerCapSource = "power_cap"
+
+// powerBudgetSource is the event source of a device's power budget alerts
+func powerBudgetSource(instance string) string { return "power_budget:" + instance }
+
+// checkPowerBudget judges a device's new metric against its nominal wattage
+// and the fleet's total against the household cap, alerting once per
+// incident. A device can alert again once it draws no more than its budget.
+func (m *Manager) checkPowerBudget(cfg *config.Config, metric *database.AxeOSMetric) {
+	budget := cfg.PowerBudget
+	if !budget.IsEnabled() {
+		return
+	}
+
+	var instances []string
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			instances = append(instances, name)
+		}
+	}
+	metrics := services.LatestDeviceMetrics()
+	metrics[metric.InstanceID] = metric
+	reading := services.ReadPowerBudget(budget, instances, metrics)
+
+	if device := reading.Device(metric.InstanceID); device != nil && device.BudgetWatts > 0 {
+		key := config.AlertPowerBudget + ":" + metric.InstanceID
+		switch {
+		case device.Over:
+			if m.setAlert(key, true) {
+				m.alertPowerBudget(metric, device)
+			}
+		case device.Power <= device.BudgetWatts:
+			m.setAlert(key, false)
+		}
+	}
+
+	if reading.FleetCapWatts > 0 {
+		switch {
+		case reading.OverCap:
+			if m.setAlert(config.AlertPowerCap, true) {
+				m.alertPowerCap(metric, reading)
+			}
+		case reading.FleetWatts < reading.FleetCapWatts*powerCapRecoveryFactor:
+			m.setAlert(config.AlertPowerCap, false)
+		}
+	}
+}
+
+// alertPowerBudget records and notifies a device drawing above its budget
+func (m *Manager) alertPowerBudget(metric *database.AxeOSMetric, device *services.DeviceBudget) {
+	title := fmt.Sprintf("%s is over its power budget", metric.InstanceName)
+	message := fmt.Sprintf("Drawing %.1f W against a budget of %.1f W (limit %.1f W); check the core voltage setting and the voltage regulator",
+		device.Power, device.BudgetWatts, device.LimitWatts)
+	m.log.Warn("POWER BUDGET: %s: %s", title, message)
+	m.recordEvent(&database.Event{
+		Timestamp: metric.Timestamp,
+		Type:      database.EventAlert,
+		Source:    powerBudgetSource(metric.InstanceID),
+		Title:     title,
+		Message:   message,
+	})
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Timestamp: metric.Timestamp,
+		Type:      config.AlertPowerBudget,
+		Source:    metric.InstanceID,
+		Title:     title,
+		Message:   message,
+		Fields: map[string]interface{}{
+			"instance": metric.InstanceName,
+			"power":    device.Power,
+			"budget":   device.BudgetWatts,
+			"limit":    device.LimitWatts,
+			"budgetBy": device.BudgetBy,
+		},
+	})
+}
+
+// alertPowerCap records and notifies the fleet drawing above the household cap
+func (m *Manager) alertPowerCap(metric *database.AxeOSMetric, reading *services.PowerBudgetReading) {
+	title := "Fleet is over the power cap"
+	message := fmt.Sprintf("The devices draw %.1f W together, above the cap of %.1f W", reading.FleetWatts, reading.FleetCapWatts)
+	m.log.Warn("POWER CAP: %s", message)
+	m.recordEvent(&database.Event{
+		Timestamp: metric.Timestamp,
+		Type:      database.EventAlert,
+		Source:    powerCapSource,
+		Title:     title,
+		Message:   message,
+	})
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Timestamp: metric.Timestamp,
+		Type:      config.AlertPowerCap,
+		Source:    powerCapSource,
+		Title:     title,
+		Message:   message,
+		Fields: map[string]interface{}{
+			"power":   reading.FleetWatts,
+			"cap":     reading.FleetCapWatts,
+			"devices": len(reading.Devices),
+		},
+	})
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	// powerCapControlInterval is how often the power cap controller runs
+	powerCapControlInterval = 5 * time.Minute
+	// powerCapRestoreFactor is the part of the fleet cap the total power must
+	// drop below before a stepped-down device is stepped back up
+	powerCapRestoreFactor = 0.9
+	// powerCapSettleTimeout is how long the controller waits for a new metric
+	// of an adjusted device before it goes on without one
+	powerCapSettleTimeout = 30 * time.Minute
+)
+
+// controlPowerCap keeps the fleet below the power_budget cap. Over the cap,
+// the least efficient device with a lower profile left is stepped down one
+// profile; well below it, the most efficient stepped-down device is stepped
+// back up, until it runs on its own settings again. One device is adjusted
+// per run, and only once every adjusted device has reported a new metric.
+func (m *Manager) controlPowerCap(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+	budget := cfg.PowerBudget
+	if !budget.ControlsCap() {
+		return nil
+	}
+
+	m.powerCapMu.Lock()
+	defer m.powerCapMu.Unlock()
+	if m.powerCapAdjusted == nil {
+		m.powerCapAdjusted = map[string]time.Time{}
+	}
+
+	metrics := services.LatestDeviceMetrics()
+	if !m.powerCapSettled(metrics) {
+		return nil
+	}
+
+	steps, err := m.dbManager.GetPowerCapSteps()
+	if err != nil {
+		return err
+	}
+	var instances []string
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			instances = append(instances, name)
+		}
+	}
+	reading := services.ReadPowerBudget(budget, instances, metrics)
+
+	switch {
+	case reading.FleetWatts > reading.FleetCapWatts:
+		// Least efficient first; devices not hashing have no efficiency to judge
+		var candidates []services.DeviceBudget
+		for _, device := range reading.Devices {
+			if device.Efficiency > 0 && (steps[device.InstanceID] == nil || steps[device.InstanceID].Level < len(budget.Profiles)) {
+				candidates = append(candidates, device)
+			}
+		}
+		if len(candidates) == 0 {
+			m.log.Warn("POWER CAP: the fleet draws %.1f W above the cap of %.1f W, but every device is on the lowest profile",
+				reading.FleetWatts, reading.FleetCapWatts)
+			return nil
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Efficiency > candidates[j].Efficiency })
+		return m.stepPowerProfile(ctx, cfg, reading, candidates[0], steps[candidates[0].InstanceID], 1)
+
+	case reading.FleetWatts < reading.FleetCapWatts*powerCapRestoreFactor && len(steps) > 0:
+		// Most efficient first, as it adds the most hashrate per watt
+		var candidates []services.DeviceBudget
+		for _, device := range reading.Devices {
+			if steps[device.InstanceID] != nil {
+				candidates = append(candidates, device)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			if (candidates[i].Efficiency > 0) != (candidates[j].Efficiency > 0) {
+				return candidates[i].Efficiency > 0
+			}
+			return candidates[i].Efficiency < candidates[j].Efficiency
+		})
+		return m.stepPowerProfile(ctx, cfg, reading, candidates[0], steps[candidates[0].InstanceID], -1)
+	}
+	return nil
+}
+
+// powerCapSettled reports whether every device the controller adjusted has
+// reported a metric since, so the fleet's power reflects the change. The
+// caller holds m.powerCapMu.
+func (m *Manager) powerCapSettled(metrics map[string]*database.AxeOSMetric) bool {
+	settled := true
+	for id, adjustedAt := range m.powerCapAdjusted {
+		metric, ok := metrics[id]
+		switch {
+		case ok && metric.Timestamp.After(adjustedAt):
+			delete(m.powerCapAdjusted, id)
+		case time.Since(adjustedAt) > powerCapSettleTimeout:
+			m.log.Warn("POWER CAP: no metric from %s since it was adjusted; going on without one", id)
+			delete(m.powerCapAdjusted, id)
+		default:
+			settled = false
+		}
+	}
+	return settled
+}
+
+// stepPowerProfile moves a device one profile down (direction 1) or up
+// (direction -1), restoring its own settings when it steps up from the first
+// profile, and records the adjustment as an event. The caller holds
+// m.powerCapMu.
+func (m *Manager) stepPowerProfile(ctx context.Context, cfg *config.Config, reading *services.PowerBudgetReading, device services.DeviceBudget, step *database.PowerCapStep, direction int) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	profiles := cfg.PowerBudget.Profiles
+	if step == nil {
+		// Keep the device's own settings to restore once the fleet has room again
+		metric := services.LatestDeviceMetrics()[device.InstanceID]
+		if metric == nil || metric.Frequency <= 0 || metric.CoreVoltage <= 0 {
+			return fmt.Errorf("no frequency and core voltage reported by %s to restore later", device.InstanceID)
+		}
+		step = &database.PowerCapStep{InstanceID: device.InstanceID, Frequency: metric.Frequency, CoreVoltage: int(metric.CoreVoltage)}
+	}
+	level := min(step.Level+direction, len(profiles))
+
+	frequency, coreVoltage, setting := step.Frequency, step.CoreVoltage, "its own settings"
+	if level > 0 {
+		profile := profiles[level-1]
+		frequency, coreVoltage, setting = profile.Frequency, profile.CoreVoltage, fmt.Sprintf("the %s profile", profile.Name)
+	}
+	if err := services.ApplyDeviceClock(cfg, device.InstanceID, frequency, coreVoltage); err != nil {
+		return fmt.Errorf("failed to apply %s to %s: %w", setting, device.InstanceID, err)
+	}
+
+	step.Level = level
+	step.UpdatedAt = time.Now()
+	if level > 0 {
+		if err := m.dbManager.SetPowerCapStep(step); err != nil {
+			return err
+		}
+	} else if err := m.dbManager.DeletePowerCapStep(device.InstanceID); err != nil {
+		return err
+	}
+	m.powerCapAdjusted[device.InstanceID] = step.UpdatedAt
+
+	var title, reason string
+	if direction > 0 {
+		title = fmt.Sprintf("%s stepped down to %s", device.InstanceID, setting)
+		reason = fmt.Sprintf("The fleet draws %.1f W, above the cap of %.1f W", reading.FleetWatts, reading.FleetCapWatts)
+	} else {
+		title = fmt.Sprintf("%s stepped back up to %s", device.InstanceID, setting)
+		reason = fmt.Sprintf("The fleet draws %.1f W, below %.0f%% of the cap of %.1f W", reading.FleetWatts, powerCapRestoreFactor*100, reading.FleetCapWatts)
+	}
+	message := fmt.Sprintf("%s; %s drew %.1f W at %.1f J/TH and now runs at %d MHz and %d mV", reason, device.InstanceID, device.Power, device.Efficiency, frequency, coreVoltage)
+	m.log.Info("POWER CAP: %s: %s", title, message)
+	m.recordEvent(&database.Event{
+		Timestamp: step.UpdatedAt,
+		Type:      database.EventPowerCap,
+		Source:    device.InstanceID,
+		Title:     title,
+		Message:   message,
+	})
+	return nil
+}
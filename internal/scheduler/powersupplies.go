@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	// supplyTrendInterval is how often power supply voltage trends are checked
+	supplyTrendInterval = 24 * time.Hour
+	// supplyTrendReportDays is how long a degrading supply stays quiet after a report
+	supplyTrendReportDays = 7
+	// brownoutRecoveryFactor is the part of the sag a supply must recover
+	// before another brown-out alert can be sent
+	brownoutRecoveryFactor = 0.5
+)
+
+// brownoutSource and supplyTrendSource are the event sources of power supply alerts
+func brownoutSource(supply string) string    { return "brownout:" + supply }
+func supplyTrendSource(supply string) string { return "supply:" + supply }
+
+// checkBrownout judges the supply powering a device with its new metric and
+// the latest metrics of the other devices on the supply, and alerts once per
+// brown-out when a hashing device's input voltage sags below the threshold
+func (m *Manager) checkBrownout(cfg *config.Config, metric *database.AxeOSMetric) {
+	monitoring := cfg.PowerMonitoring
+	if !monitoring.IsEnabled() {
+		return
+	}
+
+	supply := monitoring.SupplyOf(metric.InstanceID)
+	metrics := services.LatestDeviceMetrics()
+	metrics[metric.InstanceID] = metric
+	reading := services.ReadSupply(supply, metrics, monitoring.Sag())
+	key := config.AlertBrownout + ":" + supply.Name
+
+	if !reading.Brownout {
+		recovered := reading.NominalVolts * 1000 * (1 - monitoring.Sag()*brownoutRecoveryFactor/100)
+		if reading.MinVoltage == 0 || reading.MinVoltage >= recovered {
+			m.setAlert(key, false)
+		}
+		return
+	}
+	if !m.setAlert(key, true) {
+		return
+	}
+
+	var low []string
+	for _, device := range reading.Devices {
+		if device.Loaded && device.Voltage < reading.ThresholdVoltage {
+			low = append(low, fmt.Sprintf("%s %.2f V", device.InstanceID, device.Voltage/1000))
+		}
+	}
+	title := fmt.Sprintf("Brown-out on %s", supply.Name)
+	message := fmt.Sprintf("Input voltage sagged to %.2f V under a %.1f W load (%.2f A); the %.0f V supply should stay above %.2f V. Low: %s.",
+		reading.MinVoltage/1000, reading.Power, reading.Current/1000, reading.NominalVolts, reading.ThresholdVoltage/1000,
+		strings.Join(low, ", "))
+	m.log.Warn("BROWNOUT: %s: %s", title, message)
+	m.recordEvent(&database.Event{
+		Timestamp: metric.Timestamp,
+		Type:      database.EventAlert,
+		Source:    brownoutSource(supply.Name),
+		Title:     title,
+		Message:   message,
+	})
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Timestamp: metric.Timestamp,
+		Type:      config.AlertBrownout,
+		Source:    supply.Name,
+		Title:     title,
+		Message:   message,
+		Fields: map[string]interface{}{
+			"supply":       supply.Name,
+			"voltage":      reading.MinVoltage / 1000,
+			"threshold":    reading.ThresholdVoltage / 1000,
+			"nominalVolts": reading.NominalVolts,
+			"power":        reading.Power,
+			"current":      reading.Current / 1000,
+			"low":          low,
+		},
+	})
+}
+
+// reportSupplyDegradation fits a trend to each supply's daily input voltage
+// under load and reports the supplies whose voltage is drifting down, each at
+// most once a week while it keeps dropping
+func (m *Manager) reportSupplyDegradation(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+	monitoring := cfg.PowerMonitoring
+	if !monitoring.IsEnabled() {
+		return nil
+	}
+
+	// Reports are stored as events, so a restart does not repeat them early
+	recent, err := m.dbManager.GetEvents(time.Now().AddDate(0, 0, -supplyTrendReportDays), []string{database.EventAlert}, -1)
+	if err != nil {
+		return err
+	}
+	reported := map[string]bool{}
+	for _, event := range recent {
+		reported[event.Source] = true
+	}
+
+	var instances []string
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			instances = append(instances, name)
+		}
+	}
+
+	var lines []string
+	var degrading []map[string]interface{}
+	for _, supply := range monitoring.AllSupplies(instances) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		source := supplyTrendSource(supply.Name)
+		if reported[source] {
+			continue
+		}
+		trend, err := services.AnalyzeSupply(m.dbManager, supply, time.Now(), monitoring.Days(), monitoring.Degradation())
+		if err != nil {
+			m.log.Error("Failed to analyze power supply %s: %v", supply.Name, err)
+			continue
+		}
+		if !trend.Degrading {
+			continue
+		}
+
+		title := fmt.Sprintf("Power supply %s is degrading (%.1f%%)", supply.Name, trend.ChangePercent)
+		message := fmt.Sprintf("The input voltage under load on %s went from %.2f to %.2f V over the last %d days. Check the power supply and its cables.",
+			supply.Name, trend.StartVoltage/1000, trend.EndVoltage/1000, trend.Days)
+		m.log.Warn("POWER SUPPLY: %s", title)
+		m.recordEvent(&database.Event{
+			Type:    database.EventAlert,
+			Source:  source,
+			Title:   title,
+			Message: message,
+		})
+		lines = append(lines, message)
+		degrading = append(degrading, map[string]interface{}{
+			"supply":        supply.Name,
+			"startVoltage":  trend.StartVoltage / 1000,
+			"endVoltage":    trend.EndVoltage / 1000,
+			"changePercent": trend.ChangePercent,
+			"days":          trend.Days,
+		})
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+	title := "1 power supply is degrading"
+	if len(lines) > 1 {
+		title = fmt.Sprintf("%d power supplies are degrading", len(lines))
+	}
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Type:    config.AlertSupplyDegradation,
+		Source:  "power_supplies",
+		Title:   title,
+		Message: strings.Join(lines, "\n"),
+		Fields:  map[string]interface{}{"supplies": degrading},
+	})
+	return nil
+}
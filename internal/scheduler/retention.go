@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// eventRetentionInterval is how often expired events, alerts and audit records are deleted
+const eventRetentionInterval = time.Hour
+
+// pruneEvents deletes events older than the retention configured for their category
+func (m *Manager) pruneEvents(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+
+	for _, category := range database.EventCategories {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		days := cfg.EventRetention.Days(category)
+		if days == 0 {
+			continue // Kept forever
+		}
+
+		deleted, err := m.dbManager.PruneEvents(category, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			m.log.Error("Failed to apply %s retention: %v", category, err)
+			continue
+		}
+		if deleted > 0 {
+			m.log.Info("Deleted %d %s older than %d days", deleted, category, days)
+		}
+	}
+
+	// Failover periods are kept as long as the events that record them
+	if days := cfg.EventRetention.Days(database.EventCategoryEvents); days > 0 {
+		if _, err := m.dbManager.PrunePoolFailovers(time.Now().AddDate(0, 0, -days)); err != nil {
+			m.log.Error("Failed to prune pool failovers: %v", err)
+		}
+	}
+
+	return nil
+}
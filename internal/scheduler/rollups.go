@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// rollupInterval is how often the hourly and daily rollups are brought up to
+// date. The newest bucket of each device is refreshed on every run, so
+// partial hours and days fill in as samples arrive.
+const rollupInterval = 15 * time.Minute
+
+// updateRollups aggregates new AxeOS samples into the hourly and daily rollups
+func (m *Manager) updateRollups(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+
+	for _, instance := range cfg.AxeosInstances {
+		for name := range instance {
+			for _, resolution := range []string{database.ResolutionHourly, database.ResolutionDaily} {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err := m.rollupAxeOS(ctx, resolution, name); err != nil {
+					m.log.Error("Failed to update %s rollups of %s: %v", resolution, name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// rollupAxeOS rolls up one device from its newest rollup bucket (or its
+// oldest sample, the first time) through the current bucket
+func (m *Manager) rollupAxeOS(ctx context.Context, resolution, instanceID string) error {
+	from, ok, err := m.dbManager.GetLatestAxeOSRollup(resolution, instanceID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if from, ok, err = m.dbManager.GetOldestAxeOSTimestamp(instanceID); err != nil || !ok {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for bucket := database.RollupBucket(resolution, from); !bucket.After(now); bucket = database.NextRollupBucket(resolution, bucket) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := m.dbManager.RollupAxeOS(resolution, instanceID, bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
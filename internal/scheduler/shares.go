@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/notifications"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+const (
+	// minExpectedShares is how many shares a window must expect before the
+	// accepted shares are compared, so chance does not raise alerts
+	// (the spread of 30 expected shares is about 18%)
+	minExpectedShares = 30
+	// shareWindowGap is how many collection intervals without a sample end a window
+	shareWindowGap = 3
+)
+
+// hashesPerShare is the work of a share at difficulty 1
+var hashesPerShare = math.Pow(2, 32)
+
+// ShareRateCheck compares the shares a device had accepted with the shares
+// expected from its hashrate at the pool difficulty
+type ShareRateCheck struct {
+	InstanceID     string    `json:"instanceId"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	PoolDifficulty float64   `json:"poolDifficulty"` // At the end of the window
+	Expected       float64   `json:"expected"`
+	Accepted       int       `json:"accepted"`
+	Ratio          float64   `json:"ratio"`     // Accepted over expected
+	Diverging      bool      `json:"diverging"` // Outside the tolerance
+}
+
+// ShareRateStatus is the open window and the last check of a device
+type ShareRateStatus struct {
+	Window    ShareRateCheck  `json:"window"` // Shares counted since the last check
+	LastCheck *ShareRateCheck `json:"lastCheck"`
+}
+
+// shareWindow accumulates the expected and accepted shares of a device
+// between checks
+type shareWindow struct {
+	window       ShareRateCheck
+	lastAt       time.Time
+	lastAccepted int
+	lastCheck    *ShareRateCheck
+}
+
+// ExpectedSharesPerSecond returns the shares a hashrate (H/s) finds per
+// second at a pool difficulty
+func ExpectedSharesPerSecond(hashrate, difficulty float64) float64 {
+	if difficulty <= 0 {
+		return 0
+	}
+	return hashrate / (difficulty * hashesPerShare)
+}
+
+// poolDifficulty reads poolDifficulty from the device info, which AxeOS
+// sends as a number or, on some builds, as a formatted string
+func poolDifficulty(info map[string]interface{}) float64 {
+	difficulty, err := services.ParseDifficulty(info["poolDifficulty"])
+	if err != nil {
+		return 0
+	}
+	return difficulty
+}
+
+// checkShareRate adds a sample to the device's share window and, once the
+// window expects enough shares, compares the accepted shares with the
+// expected ones. Accepted shares far from the expected count point at a
+// stratum misconfiguration (a wrong difficulty, stale or rejected work)
+// that the hashrate graphs do not show. Each divergence is notified once.
+func (m *Manager) checkShareRate(cfg *config.Config, metric *database.AxeOSMetric, info map[string]interface{}) {
+	tolerance := cfg.Notifications.ShareRateTolerancePercent()
+	difficulty := poolDifficulty(info)
+	if tolerance == 0 || difficulty <= 0 {
+		return
+	}
+
+	m.shareMu.Lock()
+	if m.shareWindows == nil {
+		m.shareWindows = map[string]*shareWindow{}
+	}
+	state := m.shareWindows[metric.InstanceID]
+	gap := time.Duration(shareWindowGap*cfg.CollectionIntervalSeconds) * time.Second
+	if state == nil || metric.SharesAccepted < state.lastAccepted || metric.Timestamp.Sub(state.lastAt) > gap {
+		// First sample, device restart (counters reset) or collection gap
+		var lastCheck *ShareRateCheck
+		if state != nil {
+			lastCheck = state.lastCheck
+		}
+		m.shareWindows[metric.InstanceID] = &shareWindow{
+			window:       ShareRateCheck{InstanceID: metric.InstanceID, Start: metric.Timestamp, End: metric.Timestamp, PoolDifficulty: difficulty},
+			lastAt:       metric.Timestamp,
+			lastAccepted: metric.SharesAccepted,
+			lastCheck:    lastCheck,
+		}
+		m.shareMu.Unlock()
+		return
+	}
+
+	seconds := metric.Timestamp.Sub(state.lastAt).Seconds()
+	state.window.Expected += ExpectedSharesPerSecond(metric.Hashrate*1e9, difficulty) * seconds // GH/s to H/s
+	state.window.Accepted += metric.SharesAccepted - state.lastAccepted
+	state.window.End = metric.Timestamp
+	state.window.PoolDifficulty = difficulty
+	state.lastAt = metric.Timestamp
+	state.lastAccepted = metric.SharesAccepted
+	if state.window.Expected < minExpectedShares {
+		m.shareMu.Unlock()
+		return
+	}
+
+	check := state.window
+	check.Ratio = float64(check.Accepted) / check.Expected
+	check.Diverging = math.Abs(check.Ratio-1)*100 > tolerance
+	state.lastCheck = &check
+	state.window = ShareRateCheck{InstanceID: metric.InstanceID, Start: metric.Timestamp, End: metric.Timestamp, PoolDifficulty: difficulty}
+	m.shareMu.Unlock()
+
+	if !m.setAlert(config.AlertShareRate+":"+metric.InstanceID, check.Diverging) {
+		return
+	}
+	hint := "check for rejected or stale shares and that the device reports the pool's difficulty"
+	if check.Ratio > 1 {
+		hint = "the pool difficulty the device reports is likely lower than the pool's"
+	}
+	title := fmt.Sprintf("%s accepted %.0f%% of the expected shares", metric.InstanceName, check.Ratio*100)
+	message := fmt.Sprintf("%d shares were accepted where %.0f were expected at difficulty %s over %s; %s",
+		check.Accepted, check.Expected, services.FormatDifficulty(difficulty), formatMinutes(check.End.Sub(check.Start)), hint)
+	m.log.Warn("SHARE RATE: %s: %s", title, message)
+	m.recordEvent(&database.Event{
+		Timestamp: metric.Timestamp,
+		Type:      database.EventAlert,
+		Source:    metric.InstanceID,
+		Title:     title,
+		Message:   message,
+	})
+	notifications.GetDispatcher(m.cfgManager).Notify(notifications.Event{
+		Timestamp: metric.Timestamp,
+		Type:      config.AlertShareRate,
+		Source:    metric.InstanceID,
+		Title:     title,
+		Message:   message,
+		Fields: map[string]interface{}{
+			"instance":   metric.InstanceName,
+			"accepted":   check.Accepted,
+			"expected":   check.Expected,
+			"percent":    check.Ratio * 100,
+			"difficulty": difficulty,
+			"minutes":    int(check.End.Sub(check.Start).Minutes()),
+		},
+	})
+}
+
+// ShareRates returns the share windows and last checks by device
+func (m *Manager) ShareRates() map[string]ShareRateStatus {
+	m.shareMu.Lock()
+	defer m.shareMu.Unlock()
+
+	rates := make(map[string]ShareRateStatus, len(m.shareWindows))
+	for id, state := range m.shareWindows {
+		status := ShareRateStatus{Window: state.window}
+		if state.lastCheck != nil {
+			check := *state.lastCheck
+			status.LastCheck = &check
+		}
+		rates[id] = status
+	}
+	return rates
+}
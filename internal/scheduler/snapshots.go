@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/services"
+)
+
+// snapshotInterval is how often today's snapshot is refreshed. Yesterday's
+// snapshot is rewritten on each run too, so the final values are captured
+// after midnight even if the dashboard was down at the time.
+const snapshotInterval = time.Hour
+
+// takeDailySnapshots stores cumulative counters for every device and pool
+func (m *Manager) takeDailySnapshots(ctx context.Context) error {
+	cfg := m.cfgManager.GetConfig()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		for _, instance := range cfg.AxeosInstances {
+			for name := range instance {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err := m.snapshotAxeOS(name, day, cfg.CollectionIntervalSeconds); err != nil {
+					m.log.Error("Failed to snapshot %s: %v", name, err)
+				}
+			}
+		}
+
+		for _, poolMap := range cfg.MiningCoreURL {
+			for poolName := range poolMap {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if err := m.snapshotPool(poolName, day); err != nil {
+					m.log.Error("Failed to snapshot pool %s: %v", poolName, err)
+				}
+			}
+		}
+
+		for _, pool := range cfg.PublicPools {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := m.snapshotPool(pool.Name, day); err != nil {
+				m.log.Error("Failed to snapshot pool %s: %v", pool.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// snapshotAxeOS saves the day's share counters, best difficulty and rollup for one instance.
+// Each sample counts as one collection interval of uptime.
+func (m *Manager) snapshotAxeOS(instanceID string, day time.Time, intervalSeconds int) error {
+	totals, err := m.dbManager.GetAxeOSDayTotals(instanceID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	if totals.Samples == 0 {
+		return nil
+	}
+
+	var bestDiff float64
+	for _, s := range totals.BestDiffs {
+		if d, err := services.ParseDifficulty(s); err == nil && d > bestDiff {
+			bestDiff = d
+		}
+	}
+
+	uptime := totals.Samples * intervalSeconds
+	if daySeconds := int(day.AddDate(0, 0, 1).Sub(day).Seconds()); uptime > daySeconds {
+		uptime = daySeconds
+	}
+
+	dayKey := day.Format(database.SnapshotDayFormat)
+	energy, err := m.dbManager.GetDayEnergy(instanceID, dayKey)
+	if err != nil {
+		return err
+	}
+
+	return m.dbManager.UpsertDailySnapshot(&database.DailySnapshot{
+		Day:            dayKey,
+		SourceType:     database.SnapshotAxeOS,
+		SourceID:       instanceID,
+		SourceName:     totals.SourceName,
+		SharesAccepted: totals.SharesAccepted,
+		SharesRejected: totals.SharesRejected,
+		BestDiff:       bestDiff,
+		Samples:        totals.Samples,
+		AvgHashrate:    totals.AvgHashrate,
+		MaxHashrate:    totals.MaxHashrate,
+		AvgTemperature: totals.AvgTemperature,
+		UptimeSeconds:  uptime,
+		EnergyKWh:      energy,
+	})
+}
+
+// snapshotPool saves the day's block count for one pool
+func (m *Manager) snapshotPool(poolID string, day time.Time) error {
+	totals, err := m.dbManager.GetPoolDayTotals(poolID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	if totals.Samples == 0 {
+		return nil
+	}
+
+	return m.dbManager.UpsertDailySnapshot(&database.DailySnapshot{
+		Day:         day.Format(database.SnapshotDayFormat),
+		SourceType:  database.SnapshotPool,
+		SourceID:    poolID,
+		SourceName:  totals.SourceName,
+		BlocksFound: totals.BlocksFound,
+	})
+}
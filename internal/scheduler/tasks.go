@@ -8,12 +8,18 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/scottwalter/axeos-dashboard/internal/config"
 	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/live"
 	"github.com/scottwalter/axeos-dashboard/internal/services"
 )
 
 // collectAxeOSMetrics collects metrics from all configured AxeOS miners
 func (m *Manager) collectAxeOSMetrics(ctx context.Context) error {
+	if m.IsPaused() {
+		return nil // Disk space guard paused collection
+	}
+
 	cfg, err := m.cfgManager.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -27,6 +33,7 @@ func (m *Manager) collectAxeOSMetrics(ctx context.Context) error {
 			default:
 				if err := m.collectSingleAxeOSMetric(name, baseURL); err != nil {
 					m.log.Error("Failed to collect AxeOS metrics from %s: %v", name, err)
+					m.recordCollectionError(live.KindAxeOS, name, err)
 					// Continue with other instances even if one fails
 					continue
 				}
@@ -50,14 +57,16 @@ func (m *Manager) collectSingleAxeOSMetric(instanceName, baseURL string) error {
 		infoEndpoint = "/api/system/info" // Default endpoint
 	}
 	infoURL := baseURL + infoEndpoint
-	resp, err := http.Get(infoURL)
+	resp, err := services.GetHTTPClientPool().Get(context.Background(), cfg, instanceName, infoURL)
 	if err != nil {
+		m.alertMinerOffline(instanceName, err)
 		return fmt.Errorf("failed to fetch info: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		m.alertMinerOffline(instanceName, fmt.Errorf("HTTP status %d", resp.StatusCode))
+		return &statusError{code: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -65,10 +74,35 @@ func (m *Manager) collectSingleAxeOSMetric(instanceName, baseURL string) error {
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if err := m.ingestAxeOSInfo(cfg, instanceName, body); err != nil {
+		return err
+	}
+	m.log.Info("Collected AxeOS metrics from %s", instanceName)
+	return nil
+}
+
+// IngestAxeOSInfo stores system info a device pushed to the dashboard, the
+// same way as a collected sample
+func (m *Manager) IngestAxeOSInfo(instanceID string, body []byte) error {
+	if m.IsPaused() {
+		return ErrCollectionPaused
+	}
+	cfg := m.cfgManager.GetConfig()
+	if err := m.ingestAxeOSInfo(cfg, instanceID, body); err != nil {
+		return err
+	}
+	m.log.Info("Received AxeOS metrics from %s", instanceID)
+	return nil
+}
+
+// ingestAxeOSInfo parses AxeOS system info, runs event, alert, failover and
+// energy tracking against the previous sample and stores the metric
+func (m *Manager) ingestAxeOSInfo(cfg *config.Config, instanceName string, body []byte) error {
 	var data map[string]interface{}
 	if err := json.Unmarshal(body, &data); err != nil {
 		return fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	services.StoreDeviceInfo(instanceName, body)
 
 	// Extract metrics and save to database
 	metric := &database.AxeOSMetric{
@@ -92,6 +126,8 @@ func (m *Manager) collectSingleAxeOSMetric(instanceName, baseURL string) error {
 	}
 	if bestDiff, ok := data["bestDiff"].(string); ok {
 		metric.BestDiff = bestDiff
+	} else if bestDiff, ok := data["bestDiff"].(float64); ok {
+		metric.BestDiff = services.FormatDifficulty(bestDiff)
 	}
 	if sharesAccepted, ok := data["sharesAccepted"].(float64); ok {
 		metric.SharesAccepted = int(sharesAccepted)
@@ -108,18 +144,38 @@ func (m *Manager) collectSingleAxeOSMetric(instanceName, baseURL string) error {
 	if coreVoltage, ok := data["coreVoltage"].(float64); ok {
 		metric.CoreVoltage = coreVoltage
 	}
+	if current, ok := data["current"].(float64); ok {
+		metric.Current = current
+	}
+
+	// Compare with the previous sample before the new metric becomes the latest one
+	previous, err := m.dbManager.GetLatestAxeOSMetric(instanceName)
+	if err != nil {
+		m.log.Warn("Failed to load previous metric for %s: %v", instanceName, err)
+	}
+	m.detectAxeOSEvents(previous, metric)
+	m.checkMinerAlerts(cfg, metric)
+	m.checkBrownout(cfg, metric)
+	m.checkPowerBudget(cfg, metric)
+	m.trackPoolFailover(cfg, metric, data)
+	m.checkShareRate(cfg, metric, data)
+	m.accumulateEnergy(previous, metric, time.Duration(cfg.CollectionIntervalSeconds)*time.Second)
 
 	// Insert into database
 	if err := m.dbManager.InsertAxeOSMetric(metric); err != nil {
 		return fmt.Errorf("failed to insert metric: %w", err)
 	}
 
-	m.log.Info("Collected AxeOS metrics from %s", instanceName)
+	live.GetHub().Publish(live.KindAxeOS, instanceName, metric)
 	return nil
 }
 
-// collectPoolMetrics collects metrics from all configured Mining Core pools
+// collectPoolMetrics collects metrics from all configured Mining Core and public pools
 func (m *Manager) collectPoolMetrics(ctx context.Context) error {
+	if m.IsPaused() {
+		return nil // Disk space guard paused collection
+	}
+
 	cfg, err := m.cfgManager.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -133,32 +189,57 @@ func (m *Manager) collectPoolMetrics(ctx context.Context) error {
 			default:
 				if err := m.collectSinglePoolMetric(poolName, poolURL); err != nil {
 					m.log.Error("Failed to collect pool metrics from %s: %v", poolName, err)
+					m.recordCollectionError(live.KindPool, poolName, err)
 					continue
 				}
 			}
 		}
 	}
 
+	for _, pool := range cfg.PublicPools {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := m.collectPublicPoolMetric(cfg, pool); err != nil {
+			m.log.Error("Failed to collect pool metrics from %s: %v", pool.Name, err)
+			m.recordCollectionError(live.KindPool, pool.Name, err)
+		}
+	}
+
 	return nil
 }
 
-// collectSinglePoolMetric collects metrics from a single Mining Core pool
-func (m *Manager) collectSinglePoolMetric(poolName, poolURL string) error {
-	// Fetch pool stats (adjust endpoint based on Mining Core API)
-	statsURL := poolURL + "/api/pools"
-	resp, err := http.Get(statsURL)
+// collectPublicPoolMetric collects the stats of a payout address from a public pool
+func (m *Manager) collectPublicPoolMetric(cfg *config.Config, pool config.PublicPool) error {
+	stats, err := services.FetchPublicPool(cfg, pool)
 	if err != nil {
 		return fmt.Errorf("failed to fetch pool stats: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	metric := stats.Metric(pool.Name)
+	m.detectPoolEvents(metric)
+
+	if err := m.dbManager.InsertPoolMetric(metric); err != nil {
+		return fmt.Errorf("failed to insert pool metric: %w", err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	live.GetHub().Publish(live.KindPool, pool.Name, metric)
+
+	m.log.Info("Collected pool metrics from %s", pool.Name)
+	return nil
+}
+
+// collectSinglePoolMetric collects metrics from a single Mining Core pool
+func (m *Manager) collectSinglePoolMetric(poolName, poolURL string) error {
+	cfg, err := m.cfgManager.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
+	}
+
+	// Fetch pool stats through the cache shared with the systems API
+	body, err := services.FetchMiningCore(cfg, poolURL+services.GetAPIPath(cfg, "pools"))
+	if err != nil {
+		return fmt.Errorf("failed to fetch pool stats: %w", err)
 	}
 
 	var data map[string]interface{}
@@ -190,20 +271,30 @@ func (m *Manager) collectSinglePoolMetric(poolName, poolURL string) error {
 		metric.BlocksFound = int(blocks)
 	}
 
+	// Record found blocks before the new metric becomes the latest one
+	m.detectPoolEvents(metric)
+
 	// Insert into database
 	if err := m.dbManager.InsertPoolMetric(metric); err != nil {
 		return fmt.Errorf("failed to insert pool metric: %w", err)
 	}
 
+	live.GetHub().Publish(live.KindPool, poolName, metric)
+
 	m.log.Info("Collected pool metrics from %s", poolName)
 	return nil
 }
 
 // collectNodeMetrics collects metrics from all configured crypto nodes
 func (m *Manager) collectNodeMetrics(ctx context.Context) error {
+	if m.IsPaused() {
+		return nil // Disk space guard paused collection
+	}
+
 	// Create RPC client to read rpcConfig.json
 	configDir := m.cfgManager.GetConfigDir()
 	rpcClient := services.NewRPCClient(configDir)
+	rpcClient.SetOutboundConfig(m.cfgManager.GetConfig())
 
 	// Try to load RPC config - if it fails, just return (no error)
 	if err := rpcClient.LoadConfig(); err != nil {
@@ -225,6 +316,7 @@ func (m *Manager) collectNodeMetrics(ctx context.Context) error {
 		default:
 			if err := m.collectSingleNodeMetric(rpcClient, nodeID); err != nil {
 				m.log.Error("Failed to collect node metrics from %s: %v", nodeID, err)
+				m.recordCollectionError(live.KindNode, nodeID, err)
 				continue
 			}
 		}
@@ -241,34 +333,28 @@ func (m *Manager) collectSingleNodeMetric(rpcClient *services.RPCClient, nodeID
 		NodeName:  nodeID,
 	}
 
-	// Get blockchain info (block height, difficulty)
-	blockchainInfo, err := rpcClient.CallRPC(nodeID, "getblockchaininfo", []interface{}{})
-	if err != nil {
-		return fmt.Errorf("failed to get blockchain info: %w", err)
-	}
-
-	if blockchainInfo != nil {
-		if infoMap, ok := blockchainInfo.(map[string]interface{}); ok {
-			if blocks, ok := infoMap["blocks"].(float64); ok {
-				metric.BlockHeight = int(blocks)
-			}
-			if diff, ok := infoMap["difficulty"].(float64); ok {
-				metric.Difficulty = diff
-			}
-		}
-	}
-
-	// Get network info (connections)
-	networkInfo, err := rpcClient.CallRPC(nodeID, "getnetworkinfo", []interface{}{})
+	// Read the chain state with the adapter of the node's type
+	nodeConfig := services.FindNodeConfig(m.cfgManager.GetConfig(), nodeID)
+	status, err := services.FetchNodeStatus(rpcClient, nodeConfig)
 	if err != nil {
-		return fmt.Errorf("failed to get network info: %w", err)
+		return err
 	}
-
-	if networkInfo != nil {
-		if infoMap, ok := networkInfo.(map[string]interface{}); ok {
-			if connections, ok := infoMap["connections"].(float64); ok {
-				metric.Connections = int(connections)
-			}
+	metric.BlockHeight = status.BlockHeight
+	metric.Difficulty = status.Difficulty
+	metric.Connections = status.Connections
+	metric.NetworkHashrate = status.NetworkHashrate
+	if metric.NetworkHashrate == 0 {
+		m.log.Debug("Node %s did not report a network hashrate", nodeID)
+	}
+	if nodeConfig.NodeMempool {
+		if mempool, err := services.FetchNodeMempool(rpcClient, nodeConfig); err != nil {
+			m.log.Warn("Failed to read the mempool of %s: %v", nodeID, err)
+		} else {
+			metric.MempoolTransactions = mempool.Transactions
+			metric.MempoolBytes = mempool.Bytes
+			metric.FeeFast = mempool.FeeFast
+			metric.FeeMedium = mempool.FeeMedium
+			metric.FeeSlow = mempool.FeeSlow
 		}
 	}
 
@@ -277,6 +363,25 @@ func (m *Manager) collectSingleNodeMetric(rpcClient *services.RPCClient, nodeID
 		return fmt.Errorf("failed to insert node metric: %w", err)
 	}
 
+	// Network difficulty and hashrate are kept as their own series for overlaying on fleet charts
+	previous, err := m.dbManager.GetLatestNetworkMetric(nodeID)
+	if err != nil {
+		m.log.Warn("Failed to load the previous network sample of %s: %v", nodeID, err)
+	}
+	network := &database.NetworkMetric{
+		Timestamp:       metric.Timestamp,
+		NodeID:          nodeID,
+		BlockHeight:     metric.BlockHeight,
+		Difficulty:      metric.Difficulty,
+		NetworkHashrate: metric.NetworkHashrate,
+	}
+	if err := m.dbManager.InsertNetworkMetric(network); err != nil {
+		return err
+	}
+	m.detectNetworkMilestones(m.cfgManager.GetConfig(), previous, network)
+
+	live.GetHub().Publish(live.KindNode, nodeID, metric)
+
 	m.log.Info("Collected node metrics from %s", nodeID)
 	return nil
 }
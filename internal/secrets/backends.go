@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileBackend reads secrets from JSON files in the config directory
+type fileBackend struct {
+	dir string
+}
+
+func (f *fileBackend) Name() string { return "file" }
+
+func (f *fileBackend) Read(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.dir, name))
+}
+
+// envBackend reads secrets from environment variables. The variable name is
+// derived from the file name, e.g. access.json -> AXEOS_ACCESS_JSON.
+// A *_FILE variant pointing to a file is honoured as well.
+type envBackend struct{}
+
+func (e *envBackend) Name() string { return "env" }
+
+func (e *envBackend) Read(name string) ([]byte, error) {
+	envName := EnvVarName(name)
+
+	if value := os.Getenv(envName); value != "" {
+		return []byte(value), nil
+	}
+
+	if path := os.Getenv(envName + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s_FILE: %w", envName, err)
+		}
+		return data, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// EnvVarName returns the environment variable holding the named secret
+func EnvVarName(name string) string {
+	name = strings.ToUpper(name)
+	name = strings.NewReplacer(".", "_", "-", "_").Replace(name)
+	return "AXEOS_" + name
+}
+
+// dockerBackend reads secrets mounted as files by Docker swarm or Kubernetes
+type dockerBackend struct {
+	dir string
+}
+
+func newDockerBackend() *dockerBackend {
+	dir := os.Getenv("AXEOS_SECRETS_DIR")
+	if dir == "" {
+		dir = "/run/secrets"
+	}
+	return &dockerBackend{dir: dir}
+}
+
+func (d *dockerBackend) Name() string { return "docker" }
+
+func (d *dockerBackend) Read(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// vaultBackend reads secrets from a HashiCorp Vault KV v2 engine. Each secret
+// is stored under its file name as a key of a single Vault secret.
+type vaultBackend struct {
+	addr   string
+	token  string
+	mount  string
+	path   string
+	client *http.Client
+}
+
+func newVaultBackend() (*vaultBackend, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if tokenFile := os.Getenv("VAULT_TOKEN_FILE"); tokenFile != "" {
+			data, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read VAULT_TOKEN_FILE: %w", err)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN is not set")
+	}
+
+	mount := os.Getenv("AXEOS_VAULT_MOUNT")
+	if mount == "" {
+		mount = "secret"
+	}
+	path := os.Getenv("AXEOS_VAULT_PATH")
+	if path == "" {
+		path = "axeos-dashboard"
+	}
+
+	return &vaultBackend{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (v *vaultBackend) Name() string { return "vault" }
+
+func (v *vaultBackend) Read(name string) ([]byte, error) {
+	url := fmt.Sprintf("%s/v1/%s/data/%s", v.addr, v.mount, v.path)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if headers := getRequestHeaders(); headers != nil {
+		headers(req)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	value, ok := body.Data.Data[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	// Values may be stored either as a JSON string or as a nested object
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(value)
+}
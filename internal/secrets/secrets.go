@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// ErrNotFound is returned by a backend that does not hold the requested secret
+var ErrNotFound = errors.New("secret not found")
+
+// Backend is a source of secret file contents (access.json, jsonWebTokenKey.json, rpcConfig.json, ...)
+type Backend interface {
+	// Name returns the backend identifier used in logs
+	Name() string
+	// Read returns the raw contents of the named secret, or ErrNotFound
+	Read(name string) ([]byte, error)
+}
+
+// BackendEnvVar selects the secret backend: file (default), env, docker or vault
+const BackendEnvVar = "AXEOS_SECRET_BACKEND"
+
+var (
+	backend     Backend
+	backendOnce sync.Once
+
+	requestHeaders   func(*http.Request)
+	requestHeadersMu sync.RWMutex
+)
+
+// SetRequestHeaders registers the function that adds the configured user
+// agent and outbound headers to requests to a remote backend. It is set by
+// the server once the configuration manager exists, since the configuration
+// itself reads secrets.
+func SetRequestHeaders(fn func(*http.Request)) {
+	requestHeadersMu.Lock()
+	defer requestHeadersMu.Unlock()
+	requestHeaders = fn
+}
+
+// getRequestHeaders returns the function registered with SetRequestHeaders
+func getRequestHeaders() func(*http.Request) {
+	requestHeadersMu.RLock()
+	defer requestHeadersMu.RUnlock()
+	return requestHeaders
+}
+
+// getBackend returns the backend selected through the environment
+func getBackend() Backend {
+	backendOnce.Do(func() {
+		log := logger.New(logger.ModuleAuth)
+
+		switch strings.ToLower(os.Getenv(BackendEnvVar)) {
+		case "", "file":
+			backend = nil
+		case "env":
+			backend = &envBackend{}
+		case "docker", "kubernetes", "k8s":
+			backend = newDockerBackend()
+		case "vault":
+			vb, err := newVaultBackend()
+			if err != nil {
+				log.Error("Vault secret backend unavailable, falling back to config files: %v", err)
+				return
+			}
+			backend = vb
+		default:
+			log.Error("Unknown secret backend %q, falling back to config files", os.Getenv(BackendEnvVar))
+			return
+		}
+
+		if backend != nil {
+			log.Info("Using %s secret backend", backend.Name())
+		}
+	})
+	return backend
+}
+
+// Read returns the contents of the named secret. The configured backend is
+// consulted first; the file in configDir is used when the backend does not
+// hold the secret.
+func Read(configDir, name string) ([]byte, error) {
+	if b := getBackend(); b != nil {
+		data, err := b.Read(name)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%s backend: %w", b.Name(), err)
+		}
+	}
+
+	return (&fileBackend{dir: configDir}).Read(name)
+}
+
+// Exists reports whether the named secret is available from any source
+func Exists(configDir, name string) bool {
+	_, err := Read(configDir, name)
+	return err == nil
+}
+
+// IsFileBacked reports whether the named secret is read from configDir
+func IsFileBacked(name string) bool {
+	b := getBackend()
+	if b == nil {
+		return true
+	}
+	_, err := b.Read(name)
+	return err != nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// openWeatherMapURL is the OpenWeatherMap current weather endpoint
+const openWeatherMapURL = "https://api.openweathermap.org/data/2.5/weather"
+
+// maxSensorBytes limits the size of a sensor response
+const maxSensorBytes = 64 << 10
+
+var ambientClient = &http.Client{Timeout: 10 * time.Second}
+
+// OpenWeatherMapConfig represents the openWeatherMap.json secret
+type OpenWeatherMapConfig struct {
+	APIKey string `json:"apiKey"`
+}
+
+// AmbientReading is one ambient temperature sample
+type AmbientReading struct {
+	Temperature float64  // Celsius
+	Humidity    *float64 // Percent, when the source reports it
+	Source      string
+}
+
+// ReadAmbientTemperature reads the current temperature from the configured ambient source
+func ReadAmbientTemperature(cfg *config.Config, configDir string) (*AmbientReading, error) {
+	source := cfg.AmbientSource
+	switch source.Type {
+	case config.AmbientOpenWeatherMap:
+		return readOpenWeatherMap(cfg, source, configDir)
+	case config.AmbientURL:
+		body, err := fetchSensor(cfg, source.URL)
+		if err != nil {
+			return nil, err
+		}
+		return parseSensorPayload(body, source, source.URL)
+	case config.AmbientMQTT:
+		payload, err := readMQTTMessage(source.Broker, source.Topic, configDir)
+		if err != nil {
+			return nil, err
+		}
+		return parseSensorPayload(payload, source, "mqtt:"+source.Topic)
+	}
+	return nil, fmt.Errorf("unknown ambient source %q", source.Type)
+}
+
+// readOpenWeatherMap fetches the current outdoor temperature and humidity
+func readOpenWeatherMap(cfg *config.Config, source *config.AmbientSource, configDir string) (*AmbientReading, error) {
+	data, err := secrets.Read(configDir, "openWeatherMap.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openWeatherMap.json: %w", err)
+	}
+	var owmConfig OpenWeatherMapConfig
+	if err := json.Unmarshal(data, &owmConfig); err != nil || owmConfig.APIKey == "" {
+		return nil, fmt.Errorf("openWeatherMap.json has no apiKey")
+	}
+
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(source.Latitude, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(source.Longitude, 'f', -1, 64))
+	query.Set("units", "metric")
+	query.Set("appid", owmConfig.APIKey)
+
+	resp, err := getAmbient(cfg, openWeatherMapURL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch weather: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("OpenWeatherMap returned HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Main struct {
+			Temp     *float64 `json:"temp"`
+			Humidity *float64 `json:"humidity"`
+		} `json:"main"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse weather: %w", err)
+	}
+	if result.Main.Temp == nil {
+		return nil, fmt.Errorf("weather response has no temperature")
+	}
+
+	return &AmbientReading{
+		Temperature: *result.Main.Temp,
+		Humidity:    result.Main.Humidity,
+		Source:      config.AmbientOpenWeatherMap,
+	}, nil
+}
+
+// getAmbient fetches a weather or sensor URL with the configured outbound headers
+func getAmbient(cfg *config.Config, target string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	SetOutboundHeaders(req, cfg)
+	return ambientClient.Do(req)
+}
+
+// fetchSensor reads the body of a local sensor URL
+func fetchSensor(cfg *config.Config, sensorURL string) ([]byte, error) {
+	resp, err := getAmbient(cfg, sensorURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sensor: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("sensor returned HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSensorBytes))
+}
+
+// parseSensorPayload reads the temperature from a bare number or from the
+// configured field of a JSON object. A sibling "humidity" value is kept.
+func parseSensorPayload(payload []byte, source *config.AmbientSource, name string) (*AmbientReading, error) {
+	text := strings.TrimSpace(string(payload))
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return &AmbientReading{Temperature: config.CelsiusFrom(source.Unit, value), Source: name}, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(text), &data); err != nil {
+		return nil, fmt.Errorf("sensor payload is neither a number nor JSON")
+	}
+
+	path := strings.Split(source.Field, ".")
+	parent := data
+	for _, key := range path[:len(path)-1] {
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("sensor payload has no field %q", source.Field)
+		}
+		parent = object[key]
+	}
+	object, ok := parent.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("sensor payload has no field %q", source.Field)
+	}
+	value, ok := jsonNumber(object[path[len(path)-1]])
+	if !ok {
+		return nil, fmt.Errorf("sensor field %q is not a number", source.Field)
+	}
+
+	reading := &AmbientReading{Temperature: config.CelsiusFrom(source.Unit, value), Source: name}
+	if humidity, ok := jsonNumber(object["humidity"]); ok {
+		reading.Humidity = &humidity
+	}
+	return reading, nil
+}
+
+// jsonNumber accepts a JSON number or a numeric string
+func jsonNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
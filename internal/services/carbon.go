@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+const (
+	// electricityMapsURL is the Electricity Maps latest carbon intensity endpoint
+	electricityMapsURL = "https://api.electricitymap.org/v3/carbon-intensity/latest"
+	// carbonCacheTTL limits how often the Electricity Maps API is called
+	carbonCacheTTL = 15 * time.Minute
+)
+
+// Carbon intensity sources
+const (
+	CarbonSourceElectricityMaps = "electricity_maps"
+	CarbonSourceConfig          = "config"
+)
+
+// ElectricityMapsConfig represents the electricityMaps.json secret
+type ElectricityMapsConfig struct {
+	APIKey string `json:"apiKey"`
+}
+
+// CarbonIntensity is the grid carbon intensity used to estimate emissions
+type CarbonIntensity struct {
+	GramsPerKWh float64   `json:"gramsPerKWh"`
+	Source      string    `json:"source"`
+	Zone        string    `json:"zone,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+var (
+	carbonCache    *CarbonIntensity
+	carbonFailedAt time.Time
+	carbonCacheMu  sync.Mutex
+	carbonClient   = &http.Client{Timeout: 10 * time.Second}
+)
+
+// GetCarbonIntensity returns the current grid carbon intensity. When
+// electricity_maps_zone is set and electricityMaps.json holds an API key the
+// live value is used (cached for 15 minutes); otherwise, or if the API fails,
+// the static carbon_intensity from config.json is used. Returns nil when
+// neither is configured.
+func GetCarbonIntensity(cfg *config.Config, configDir string) *CarbonIntensity {
+	if cfg.ElectricityMapsZone != "" {
+		if intensity, err := electricityMapsIntensity(cfg, configDir); err == nil {
+			return intensity
+		}
+	}
+
+	if cfg.CarbonIntensity > 0 {
+		return &CarbonIntensity{GramsPerKWh: cfg.CarbonIntensity, Source: CarbonSourceConfig}
+	}
+	return nil
+}
+
+// electricityMapsIntensity fetches (or returns the cached) intensity for the
+// configured zone
+func electricityMapsIntensity(cfg *config.Config, configDir string) (*CarbonIntensity, error) {
+	zone := cfg.ElectricityMapsZone
+	carbonCacheMu.Lock()
+	defer carbonCacheMu.Unlock()
+
+	if carbonCache != nil && carbonCache.Zone == zone && time.Since(carbonCache.UpdatedAt) < carbonCacheTTL {
+		return carbonCache, nil
+	}
+	// Do not call the API on every sample while it is failing
+	if time.Since(carbonFailedAt) < carbonCacheTTL {
+		return nil, fmt.Errorf("last request failed at %s", carbonFailedAt.Format(time.RFC3339))
+	}
+
+	intensity, err := fetchElectricityMaps(cfg, zone, configDir)
+	if err != nil {
+		carbonFailedAt = time.Now()
+		logger.New(logger.ModuleService).Warn("Electricity Maps unavailable, using configured carbon intensity: %v", err)
+		return nil, err
+	}
+	carbonCache = intensity
+	return carbonCache, nil
+}
+
+// fetchElectricityMaps calls the Electricity Maps API for the latest intensity of a zone
+func fetchElectricityMaps(cfg *config.Config, zone, configDir string) (*CarbonIntensity, error) {
+	data, err := secrets.Read(configDir, "electricityMaps.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read electricityMaps.json: %w", err)
+	}
+	var emConfig ElectricityMapsConfig
+	if err := json.Unmarshal(data, &emConfig); err != nil || emConfig.APIKey == "" {
+		return nil, fmt.Errorf("electricityMaps.json has no apiKey")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, electricityMapsURL+"?zone="+url.QueryEscape(zone), nil)
+	if err != nil {
+		return nil, err
+	}
+	SetOutboundHeaders(req, cfg)
+	req.Header.Set("auth-token", emConfig.APIKey)
+
+	resp, err := carbonClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		CarbonIntensity float64 `json:"carbonIntensity"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &CarbonIntensity{
+		GramsPerKWh: result.CarbonIntensity,
+		Source:      CarbonSourceElectricityMaps,
+		Zone:        zone,
+		UpdatedAt:   time.Now(),
+	}, nil
+}
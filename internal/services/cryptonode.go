@@ -1,7 +1,6 @@
 package services
 
 import (
-	"fmt"
 	"log"
 	"sync"
 
@@ -27,6 +26,7 @@ type NodeData struct {
 	Balance        interface{} `json:"balance,omitempty"`
 	NetworkInfo    interface{} `json:"networkInfo,omitempty"`
 	DisplayFields  interface{} `json:"displayFields,omitempty"`
+	Mempool        *NodeMempool `json:"mempool,omitempty"` // When NodeMempool is set
 }
 
 // NodeConfig represents a node configuration from config.json
@@ -35,6 +35,7 @@ type NodeConfig struct {
 	NodeName   string `json:"NodeName"`
 	NodeID     string `json:"NodeId"`
 	NodeAlgo   string `json:"NodeAlgo"`
+	NodeMempool bool  `json:"NodeMempool"` // Also read the mempool and fee estimates
 }
 
 // NewCryptoNodeService creates a new crypto node service
@@ -45,125 +46,51 @@ func NewCryptoNodeService(configDir string) *CryptoNodeService {
 	}
 }
 
-// getBlockchainInfo fetches blockchain info from a crypto node
-func (c *CryptoNodeService) getBlockchainInfo(nodeID string) (interface{}, error) {
-	result, err := c.rpcClient.CallRPC(nodeID, "getblockchaininfo", []interface{}{})
-	if err != nil {
-		return nil, fmt.Errorf("error fetching blockchain info for %s: %w", nodeID, err)
-	}
-	return result, nil
-}
-
-// getNetworkTotals fetches network totals from a crypto node
-func (c *CryptoNodeService) getNetworkTotals(nodeID string) (interface{}, error) {
-	result, err := c.rpcClient.CallRPC(nodeID, "getnettotals", []interface{}{})
-	if err != nil {
-		return nil, fmt.Errorf("error fetching network totals for %s: %w", nodeID, err)
-	}
-	return result, nil
-}
-
-// getBalance fetches wallet balance from a crypto node
-func (c *CryptoNodeService) getBalance(nodeID string) (interface{}, error) {
-	result, err := c.rpcClient.CallRPC(nodeID, "getbalance", []interface{}{})
-	if err != nil {
-		return nil, fmt.Errorf("error fetching balance for %s: %w", nodeID, err)
-	}
-	return result, nil
-}
-
-// getNetworkInfo fetches network info from a crypto node
-func (c *CryptoNodeService) getNetworkInfo(nodeID string) (interface{}, error) {
-	result, err := c.rpcClient.CallRPC(nodeID, "getnetworkinfo", []interface{}{})
-	if err != nil {
-		return nil, fmt.Errorf("error fetching network info for %s: %w", nodeID, err)
-	}
-	return result, nil
-}
-
-// fetchCryptoNodeData aggregates all crypto node data for a single node
+// fetchCryptoNodeData aggregates all crypto node data for a single node,
+// read with the adapter of its node type
 func (c *CryptoNodeService) fetchCryptoNodeData(nodeConfig NodeConfig, displayFields interface{}) NodeData {
 	nodeID := nodeConfig.NodeID
+	nodeName := nodeConfig.NodeName
+	if nodeName == "" {
+		nodeName = nodeID
+	}
 
-	// Fetch all data concurrently using goroutines
-	var wg sync.WaitGroup
-	var blockchainInfo, networkTotals, balance, networkInfo interface{}
-	var bcErr, ntErr, balErr, niErr error
-
-	wg.Add(4)
-
-	go func() {
-		defer wg.Done()
-		blockchainInfo, bcErr = c.getBlockchainInfo(nodeID)
-	}()
-
-	go func() {
-		defer wg.Done()
-		networkTotals, ntErr = c.getNetworkTotals(nodeID)
-	}()
-
-	go func() {
-		defer wg.Done()
-		balance, balErr = c.getBalance(nodeID)
-	}()
-
-	go func() {
-		defer wg.Done()
-		networkInfo, niErr = c.getNetworkInfo(nodeID)
-	}()
-
-	wg.Wait()
-
-	// Check if any errors occurred
-	if bcErr != nil || ntErr != nil || balErr != nil || niErr != nil {
-		errMsg := ""
-		if bcErr != nil {
-			errMsg += bcErr.Error() + "; "
-		}
-		if ntErr != nil {
-			errMsg += ntErr.Error() + "; "
-		}
-		if balErr != nil {
-			errMsg += balErr.Error() + "; "
-		}
-		if niErr != nil {
-			errMsg += niErr.Error()
-		}
-
-		log.Printf("Failed to fetch data for node %s: %s", nodeID, errMsg)
+	card, err := GetNodeAdapter(nodeConfig.NodeType).FetchCard(c.rpcClient, nodeConfig)
+	if err != nil {
+		log.Printf("Failed to fetch data for node %s: %s", nodeID, err)
 
 		// Return error object for this node
-		nodeName := nodeConfig.NodeName
-		if nodeName == "" {
-			nodeName = nodeID
-		}
-
 		return NodeData{
 			ID:       nodeName,
 			NodeID:   nodeID,
 			NodeType: nodeConfig.NodeType,
 			Status:   "Error",
-			Message:  errMsg,
+			Message:  err.Error(),
 		}
 	}
 
-	// Combine all data into a single object
-	nodeName := nodeConfig.NodeName
-	if nodeName == "" {
-		nodeName = nodeID
+	// The mempool is optional, so a failure leaves the card as it is
+	var mempool *NodeMempool
+	if nodeConfig.NodeMempool {
+		mempool, err = FetchNodeMempool(c.rpcClient, nodeConfig)
+		if err != nil {
+			log.Printf("Failed to fetch mempool for node %s: %s", nodeID, err)
+		}
 	}
 
+	// Combine all data into a single object
 	return NodeData{
 		ID:             nodeName,
 		NodeID:         nodeID,
 		NodeType:       nodeConfig.NodeType,
 		NodeAlgo:       nodeConfig.NodeAlgo,
 		Status:         "online",
-		BlockchainInfo: blockchainInfo,
-		NetworkTotals:  networkTotals,
-		Balance:        balance,
-		NetworkInfo:    networkInfo,
+		BlockchainInfo: card.BlockchainInfo,
+		NetworkTotals:  card.NetworkTotals,
+		Balance:        card.Balance,
+		NetworkInfo:    card.NetworkInfo,
 		DisplayFields:  displayFields,
+		Mempool:        mempool,
 	}
 }
 
@@ -173,52 +100,10 @@ func (c *CryptoNodeService) FetchAllCryptoNodes(cfg *config.Config) (interface{}
 	if !cfg.CryptNodesEnabled {
 		return []interface{}{}, nil
 	}
-
-	// Parse the cryptoNodes configuration structure
-	cryptoNodes, ok := cfg.CryptoNodes.([]interface{})
-	if !ok || len(cryptoNodes) == 0 {
-		return []interface{}{}, nil
-	}
+	c.rpcClient.SetOutboundConfig(cfg)
 
 	// Find the Nodes and NodeDisplayFields in the cryptoNodes array
-	var nodes []NodeConfig
-	var displayFields interface{}
-
-	for _, item := range cryptoNodes {
-		itemMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		// Check for Nodes array
-		if nodesRaw, exists := itemMap["Nodes"]; exists {
-			if nodesArray, ok := nodesRaw.([]interface{}); ok {
-				for _, nodeRaw := range nodesArray {
-					if nodeMap, ok := nodeRaw.(map[string]interface{}); ok {
-						node := NodeConfig{}
-						if nt, ok := nodeMap["NodeType"].(string); ok {
-							node.NodeType = nt
-						}
-						if nn, ok := nodeMap["NodeName"].(string); ok {
-							node.NodeName = nn
-						}
-						if nid, ok := nodeMap["NodeId"].(string); ok {
-							node.NodeID = nid
-						}
-						if na, ok := nodeMap["NodeAlgo"].(string); ok {
-							node.NodeAlgo = na
-						}
-						nodes = append(nodes, node)
-					}
-				}
-			}
-		}
-
-		// Check for NodeDisplayFields
-		if ndf, exists := itemMap["NodeDisplayFields"]; exists {
-			displayFields = ndf
-		}
-	}
+	nodes, displayFields := ConfiguredNodes(cfg)
 
 	// If nodes array is empty, return empty array
 	if len(nodes) == 0 {
@@ -233,7 +118,7 @@ func (c *CryptoNodeService) FetchAllCryptoNodes(cfg *config.Config) (interface{}
 		wg.Add(1)
 		go func(nc NodeConfig) {
 			defer wg.Done()
-			nodeData := c.fetchCryptoNodeData(nc, displayFields)
+			nodeData := c.fetchCryptoNodeData(nc, ResolveNodeDisplayFields(cfg, nc.NodeType, displayFields))
 			nodeDataChan <- nodeData
 		}(nodeConfig)
 	}
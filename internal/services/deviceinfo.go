@@ -0,0 +1,38 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// deviceInfoSample is the last system info response collected from a device
+type deviceInfoSample struct {
+	body        []byte
+	collectedAt time.Time
+}
+
+var (
+	deviceInfo   = map[string]deviceInfoSample{}
+	deviceInfoMu sync.Mutex
+)
+
+// StoreDeviceInfo records the system info response the scheduler collected
+// from a device, so the systems API can serve it instead of polling again
+func StoreDeviceInfo(instance string, body []byte) {
+	deviceInfoMu.Lock()
+	defer deviceInfoMu.Unlock()
+	deviceInfo[instance] = deviceInfoSample{body: body, collectedAt: time.Now()}
+}
+
+// LatestDeviceInfo returns the last collected system info response of a
+// device and when it was collected, if it is no older than maxAge
+func LatestDeviceInfo(instance string, maxAge time.Duration) ([]byte, time.Time, bool) {
+	deviceInfoMu.Lock()
+	defer deviceInfoMu.Unlock()
+
+	sample, ok := deviceInfo[instance]
+	if !ok || time.Since(sample.collectedAt) > maxAge {
+		return nil, time.Time{}, false
+	}
+	return sample.body, sample.collectedAt, true
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// difficultySuffixes maps the unit suffixes used by AxeOS to multipliers
+var difficultySuffixes = map[string]float64{
+	"k": 1e3,
+	"K": 1e3,
+	"M": 1e6,
+	"G": 1e9,
+	"T": 1e12,
+	"P": 1e15,
+	"E": 1e18,
+}
+
+// ParseDifficulty parses a difficulty value such as "4.29G" or "123456" into a number
+func ParseDifficulty(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0, fmt.Errorf("empty difficulty")
+		}
+		multiplier := 1.0
+		if m, ok := difficultySuffixes[s[len(s)-1:]]; ok {
+			multiplier = m
+			s = strings.TrimSpace(s[:len(s)-1])
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid difficulty %q", v)
+		}
+		return n * multiplier, nil
+	default:
+		return 0, fmt.Errorf("unsupported difficulty type %T", value)
+	}
+}
+
+// FormatDifficulty formats a difficulty with the AxeOS style unit suffix
+func FormatDifficulty(value float64) string {
+	units := []struct {
+		suffix string
+		size   float64
+	}{
+		{"E", 1e18}, {"P", 1e15}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"k", 1e3},
+	}
+	for _, unit := range units {
+		if value >= unit.size {
+			return fmt.Sprintf("%.2f%s", value/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%.0f", value)
+}
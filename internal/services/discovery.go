@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+const (
+	// maxScanHosts limits a scan to a /22 so it finishes in reasonable time
+	maxScanHosts = 1024
+	// scanConcurrency is how many hosts are probed at the same time
+	scanConcurrency = 64
+	// scanProbeTimeout bounds each probe; AxeOS answers on the LAN well within it
+	scanProbeTimeout = 1500 * time.Millisecond
+	// maxProbeBytes limits the size of a probed system info response
+	maxProbeBytes = 1 << 20
+)
+
+// DiscoveredMiner is an AxeOS device found on the network
+type DiscoveredMiner struct {
+	IP            string `json:"ip"`
+	URL           string `json:"url"`
+	Hostname      string `json:"hostname,omitempty"`
+	ASICModel     string `json:"asicModel,omitempty"`
+	Version       string `json:"version,omitempty"`
+	SuggestedName string `json:"suggestedName"`
+	ConfiguredAs  string `json:"configuredAs,omitempty"` // Instance name if already in axeos_instances
+	Worker        string `json:"worker,omitempty"`       // Mining Core worker the device was found through
+}
+
+// ScanResult is the outcome of a network scan
+type ScanResult struct {
+	Subnets []string          `json:"subnets"`
+	Scanned int               `json:"scanned"`
+	Miners  []DiscoveredMiner `json:"miners"`
+}
+
+// LocalSubnets returns the IPv4 networks of the host's interfaces, narrowed
+// to the /24 around the host's address when the interface network is larger
+func LocalSubnets() ([]*net.IPNet, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []*net.IPNet
+	seen := map[string]bool{}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		mask := ipNet.Mask
+		if ones, _ := mask.Size(); ones < 24 {
+			mask = net.CIDRMask(24, 32)
+		}
+		subnet := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		if !seen[subnet.String()] {
+			seen[subnet.String()] = true
+			subnets = append(subnets, subnet)
+		}
+	}
+	if len(subnets) == 0 {
+		return nil, errors.New("no IPv4 network interface found")
+	}
+	return subnets, nil
+}
+
+// ParseScanSubnet parses a subnet to scan in CIDR notation
+func ParseScanSubnet(cidr string) (*net.IPNet, error) {
+	_, subnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid subnet %q, expected CIDR notation such as 192.168.1.0/24", cidr)
+	}
+	ones, bits := subnet.Mask.Size()
+	if bits != 32 {
+		return nil, errors.New("only IPv4 subnets can be scanned")
+	}
+	if 1<<(bits-ones) > maxScanHosts {
+		return nil, fmt.Errorf("subnet %s is too large, the maximum is a /22", cidr)
+	}
+	return subnet, nil
+}
+
+// subnetHosts returns the host addresses of a subnet, without the network
+// and broadcast addresses
+func subnetHosts(subnet *net.IPNet) []string {
+	ones, bits := subnet.Mask.Size()
+	size := 1 << (bits - ones)
+	base := subnet.IP.To4()
+	start := uint32(base[0])<<24 | uint32(base[1])<<16 | uint32(base[2])<<8 | uint32(base[3])
+
+	var hosts []string
+	for i := 0; i < size; i++ {
+		if size > 2 && (i == 0 || i == size-1) {
+			continue
+		}
+		n := start + uint32(i)
+		hosts = append(hosts, net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).String())
+	}
+	return hosts
+}
+
+// ScanForMiners probes every host of the subnets for the AxeOS system info
+// API, with the outbound headers of cfg. configured maps base URLs already in
+// axeos_instances to their names. progress, if set, is called after each host.
+func ScanForMiners(ctx context.Context, cfg *config.Config, subnets []*net.IPNet, infoPath string, configured map[string]string, progress func(done, total int)) (*ScanResult, error) {
+	result := &ScanResult{Miners: []DiscoveredMiner{}}
+	var hosts []string
+	for _, subnet := range subnets {
+		result.Subnets = append(result.Subnets, subnet.String())
+		hosts = append(hosts, subnetHosts(subnet)...)
+	}
+	if len(hosts) > maxScanHosts {
+		return nil, fmt.Errorf("too many hosts to scan (%d), the maximum is %d", len(hosts), maxScanHosts)
+	}
+
+	client := &http.Client{Timeout: scanProbeTimeout}
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+	slots := make(chan struct{}, scanConcurrency)
+
+scan:
+	for _, host := range hosts {
+		select {
+		case <-ctx.Done():
+			break scan
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			miner, ok := probeMiner(ctx, cfg, client, host, infoPath)
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if ok {
+				miner.ConfiguredAs = configured[miner.URL]
+				result.Miners = append(result.Miners, miner)
+			}
+			if progress != nil {
+				progress(done, len(hosts))
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result.Scanned = len(hosts)
+	sort.Slice(result.Miners, func(i, j int) bool {
+		a, b := net.ParseIP(result.Miners[i].IP).To4(), net.ParseIP(result.Miners[j].IP).To4()
+		return string(a) < string(b)
+	})
+	return result, nil
+}
+
+// probeMiner checks whether a host answers like an AxeOS device
+func probeMiner(ctx context.Context, cfg *config.Config, client *http.Client, host, infoPath string) (DiscoveredMiner, bool) {
+	baseURL := "http://" + host
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+infoPath, nil)
+	if err != nil {
+		return DiscoveredMiner{}, false
+	}
+	SetOutboundHeaders(req, cfg)
+	resp, err := client.Do(req)
+	if err != nil {
+		return DiscoveredMiner{}, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return DiscoveredMiner{}, false
+	}
+
+	var info map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProbeBytes)).Decode(&info); err != nil {
+		return DiscoveredMiner{}, false
+	}
+	// Any web server may answer with JSON; AxeOS always reports these
+	if _, ok := info["hashRate"]; !ok {
+		return DiscoveredMiner{}, false
+	}
+	if _, ok := info["ASICModel"]; !ok {
+		return DiscoveredMiner{}, false
+	}
+
+	miner := DiscoveredMiner{IP: host, URL: baseURL}
+	miner.Hostname, _ = info["hostname"].(string)
+	miner.ASICModel, _ = info["ASICModel"].(string)
+	miner.Version, _ = info["version"].(string)
+	miner.SuggestedName = miner.Hostname
+	if miner.SuggestedName == "" {
+		miner.SuggestedName = "AxeOS-" + strings.ReplaceAll(host, ".", "-")
+	}
+	return miner, true
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// minEfficiencyTrendDays is how many days with data a trend needs
+const minEfficiencyTrendDays = 7
+
+// EfficiencyTrend is a linear fit of a device's daily efficiency (J/TH)
+type EfficiencyTrend struct {
+	InstanceID string `json:"instanceId"`
+	Days       int    `json:"days"` // Days with data in the range
+	// Fitted efficiency (J/TH) on the first and last day of the range
+	StartEfficiency float64 `json:"startEfficiency"`
+	EndEfficiency   float64 `json:"endEfficiency"`
+	// ChangePerWeek is the slope of the fit in J/TH per week; positive is worse
+	ChangePerWeek        float64 `json:"changePerWeek"`
+	ChangePercentPerWeek float64 `json:"changePercentPerWeek"` // Relative to the average efficiency
+	RSquared             float64 `json:"rSquared"`             // How well the line fits, 0 to 1
+	Degrading            bool    `json:"degrading"`            // Worsening faster than the threshold
+	Insufficient         bool    `json:"insufficient,omitempty"`
+}
+
+// AnalyzeEfficiency fits a line to a device's daily efficiency over the
+// weeks before end, using the daily rollups of whole days. A device is
+// degrading when its efficiency worsens by more than thresholdPercent of its
+// average per week. Fewer than a week of days gives an insufficient trend.
+func AnalyzeEfficiency(db *database.Manager, instanceID string, end time.Time, weeks int, thresholdPercent float64) (*EfficiencyTrend, error) {
+	today := database.RollupBucket(database.ResolutionDaily, end)
+	start := today.AddDate(0, 0, -7*weeks)
+	rollups, err := db.GetAxeOSRollups(database.ResolutionDaily, instanceID, start, today.Add(-time.Second), 7*weeks)
+	if err != nil {
+		return nil, err
+	}
+
+	var xs, ys []float64
+	var sumY float64
+	for _, r := range rollups {
+		if r.Samples == 0 || r.AvgHashrate <= 0 || r.AvgPower <= 0 {
+			continue
+		}
+		xs = append(xs, r.Timestamp.Sub(start).Hours()/24)
+		ys = append(ys, r.AvgPower/(r.AvgHashrate/1000)) // GH/s to TH/s
+		sumY += ys[len(ys)-1]
+	}
+
+	trend := &EfficiencyTrend{InstanceID: instanceID, Days: len(xs)}
+	if len(xs) < minEfficiencyTrendDays {
+		trend.Insufficient = true
+		return trend, nil
+	}
+
+	slope, intercept, rSquared, ok := linearFit(xs, ys)
+	meanY := sumY / float64(len(ys))
+	if !ok || meanY == 0 {
+		trend.Insufficient = true
+		return trend, nil
+	}
+
+	minX, maxX := xs[0], xs[0]
+	for _, x := range xs {
+		minX, maxX = min(minX, x), max(maxX, x)
+	}
+
+	trend.StartEfficiency = intercept + slope*minX
+	trend.EndEfficiency = intercept + slope*maxX
+	trend.ChangePerWeek = slope * 7 // slope is J/TH per day
+	trend.ChangePercentPerWeek = trend.ChangePerWeek / meanY * 100
+	trend.RSquared = rSquared
+	trend.Degrading = trend.ChangePercentPerWeek > thresholdPercent
+	return trend, nil
+}
+
+// linearFit fits y = intercept + slope*x by least squares and reports how
+// well the line fits (R², 0 to 1). It fails when all xs are equal.
+func linearFit(xs, ys []float64) (slope, intercept, rSquared float64, ok bool) {
+	n := float64(len(xs))
+	if n == 0 {
+		return 0, 0, 0, false
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return 0, 0, 0, false
+	}
+
+	slope = sxy / sxx
+	intercept = meanY - slope*meanX
+	if syy > 0 {
+		rSquared = sxy * sxy / (sxx * syy)
+	}
+	return slope, intercept, rSquared, true
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// errInjectedFailure is returned for requests failed by fault injection
+var errInjectedFailure = errors.New("connection refused (injected fault)")
+
+// faultTransport injects the faults configured in fault_injection into the
+// requests to one instance: connection failures, slow responses and JSON
+// responses cut short
+type faultTransport struct {
+	base     http.RoundTripper
+	faults   *config.FaultInjection
+	instance string
+	log      *logger.Logger
+}
+
+// withFaults wraps a transport with fault injection when it applies to the instance
+func withFaults(base http.RoundTripper, cfg *config.Config, instance string) http.RoundTripper {
+	if !cfg.FaultInjection.Applies(instance) {
+		return base
+	}
+	return &faultTransport{
+		base:     base,
+		faults:   cfg.FaultInjection,
+		instance: instance,
+		log:      logger.New(logger.ModuleService),
+	}
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rand.Float64() < t.faults.SlowRate {
+		delay := time.Duration(t.faults.SlowDelayMs()) * time.Millisecond
+		t.log.Warn("Fault injection: delaying %s %s to %s by %s", req.Method, req.URL.Path, t.instance, delay)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+	if rand.Float64() < t.faults.FailureRate {
+		t.log.Warn("Fault injection: failing %s %s to %s", req.Method, req.URL.Path, t.instance)
+		return nil, fmt.Errorf("dial %s: %w", req.URL.Host, errInjectedFailure)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return resp, err
+	}
+	if rand.Float64() < t.faults.MalformedRate {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		// Cut the document in half so it no longer parses
+		body = body[:len(body)/2]
+		t.log.Warn("Fault injection: malformed JSON from %s %s to %s", req.Method, req.URL.Path, t.instance)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Del("Content-Length")
+	}
+	return resp, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// FleetFetchTimeout keeps fleet status requests responsive when a device is offline
+const FleetFetchTimeout = 5 * time.Second
+
+// FleetDevice is the live state of one device
+type FleetDevice struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`   // "online" or "offline"
+	Hashrate float64 `json:"hashrate"` // GH/s
+	Temp     float64 `json:"temp"`
+	Power    float64 `json:"power"` // W
+}
+
+// FleetTotals holds the totals over online devices
+type FleetTotals struct {
+	Devices  int     `json:"devices"`
+	Online   int     `json:"online"`
+	Hashrate float64 `json:"hashrate"` // GH/s
+	Power    float64 `json:"power"`    // W
+	MaxTemp  float64 `json:"maxTemp"`
+}
+
+// round2 rounds to two decimals to keep payloads small
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// FetchFleetStatus queries every device live, in the order of
+// axeos_instances, with temperatures in the given unit
+func FetchFleetStatus(ctx context.Context, cfg *config.Config, unit string) (FleetTotals, []FleetDevice) {
+	apiPath := GetAPIPath(cfg, "instanceInfo")
+	ctx, cancel := context.WithTimeout(ctx, FleetFetchTimeout)
+	defer cancel()
+
+	var devices []FleetDevice
+	var urls []string
+	for _, instance := range cfg.AxeosInstances {
+		for name, url := range instance {
+			devices = append(devices, FleetDevice{Name: name, Status: "offline"})
+			urls = append(urls, url)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range devices {
+		wg.Add(1)
+		go func(device *FleetDevice, url string) {
+			defer wg.Done()
+
+			resp, err := GetHTTPClientPool().Get(ctx, cfg, device.Name, url+apiPath)
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			var info struct {
+				HashRate float64 `json:"hashRate"`
+				Temp     float64 `json:"temp"`
+				Power    float64 `json:"power"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+				return
+			}
+
+			device.Status = "online"
+			device.Hashrate = round2(info.HashRate)
+			device.Temp = round2(config.CelsiusTo(unit, info.Temp))
+			device.Power = round2(info.Power)
+		}(&devices[i], urls[i])
+	}
+	wg.Wait()
+
+	fleet := FleetTotals{Devices: len(devices)}
+	for _, device := range devices {
+		if device.Status != "online" {
+			continue
+		}
+		fleet.Online++
+		fleet.Hashrate += device.Hashrate
+		fleet.Power += device.Power
+		if fleet.Online == 1 || device.Temp > fleet.MaxTemp {
+			fleet.MaxTemp = device.Temp
+		}
+	}
+	fleet.Hashrate = round2(fleet.Hashrate)
+	fleet.Power = round2(fleet.Power)
+	return fleet, devices
+}
+
+// ANSI escape sequences of the terminal status
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+)
+
+// FormatHashrate formats a hashrate given in GH/s with a readable unit
+func FormatHashrate(ghs float64) string {
+	switch {
+	case ghs >= 1e6:
+		return fmt.Sprintf("%.2f PH/s", ghs/1e6)
+	case ghs >= 1e3:
+		return fmt.Sprintf("%.2f TH/s", ghs/1e3)
+	default:
+		return fmt.Sprintf("%.2f GH/s", ghs)
+	}
+}
+
+// RenderFleetStatus renders the fleet status as a text table for terminals,
+// with ANSI colors when color is set. Lines end with newline alone.
+func RenderFleetStatus(title, unit string, fleet FleetTotals, devices []FleetDevice, now time.Time, color bool) string {
+	style := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + ansiReset
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s\n", style(ansiBold, title), style(ansiDim, now.Format("2006-01-02 15:04:05")))
+	maxTemp := "-"
+	if fleet.Online > 0 {
+		maxTemp = fmt.Sprintf("%.1f°%s", fleet.MaxTemp, unit)
+	}
+	fmt.Fprintf(&b, "Fleet: %d/%d online  %s  %.1f W  max %s\n\n",
+		fleet.Online, fleet.Devices, FormatHashrate(fleet.Hashrate), fleet.Power, maxTemp)
+
+	nameWidth := len("DEVICE")
+	for _, device := range devices {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(device.Name))
+	}
+	header := fmt.Sprintf("%-*s  %-7s  %12s  %8s  %8s", nameWidth, "DEVICE", "STATUS", "HASHRATE", "TEMP", "POWER")
+	b.WriteString(style(ansiBold, header) + "\n")
+	for _, device := range devices {
+		// Pad before coloring so escape sequences do not break the columns
+		if device.Status != "online" {
+			fmt.Fprintf(&b, "%-*s  %s  %12s  %8s  %8s\n", nameWidth, device.Name,
+				style(ansiRed, fmt.Sprintf("%-7s", "offline")), "-", "-", "-")
+			continue
+		}
+		fmt.Fprintf(&b, "%-*s  %s  %12s  %8s  %8s\n", nameWidth, device.Name,
+			style(ansiGreen, fmt.Sprintf("%-7s", "online")), FormatHashrate(device.Hashrate),
+			fmt.Sprintf("%.1f°%s", device.Temp, unit), fmt.Sprintf("%.1f W", device.Power))
+	}
+	if len(devices) == 0 {
+		b.WriteString("No devices configured\n")
+	}
+	return b.String()
+}
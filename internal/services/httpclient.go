@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
+)
+
+// maxHTTPBackoff caps the delay between retries
+const maxHTTPBackoff = 5 * time.Second
+
+// HTTPClientPool hands out HTTP clients for devices, pools and nodes with the
+// timeout and retry policy configured for each instance in config.json. All
+// clients share one transport so connections to a device are reused, and the
+// number of requests in flight to each instance is capped.
+type HTTPClientPool struct {
+	transport *http.Transport
+	clients   map[string]*http.Client
+	inFlight  map[string]chan struct{} // instance -> semaphore sized by its in-flight limit
+	mu        sync.Mutex
+}
+
+var (
+	clientPool     *HTTPClientPool
+	clientPoolOnce sync.Once
+)
+
+// GetHTTPClientPool returns the singleton client pool
+func GetHTTPClientPool() *HTTPClientPool {
+	clientPoolOnce.Do(func() {
+		clientPool = &HTTPClientPool{
+			clients:  make(map[string]*http.Client),
+			inFlight: make(map[string]chan struct{}),
+		}
+	})
+	return clientPool
+}
+
+// sharedTransport returns the transport, replacing it when the configured
+// connection limits change. The caller holds p.mu.
+func (p *HTTPClientPool) sharedTransport(cfg *config.Config) *http.Transport {
+	maxConns, maxIdle := cfg.HTTPConnLimits()
+	if p.transport != nil && p.transport.MaxConnsPerHost == maxConns && p.transport.MaxIdleConnsPerHost == maxIdle {
+		return p.transport
+	}
+
+	if p.transport != nil {
+		p.transport.CloseIdleConnections()
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = maxConns
+	transport.MaxIdleConnsPerHost = maxIdle
+	p.transport = transport
+	p.clients = make(map[string]*http.Client)
+	return transport
+}
+
+// Client returns the client for an instance, using its configured timeout
+func (p *HTTPClientPool) Client(cfg *config.Config, instance string) *http.Client {
+	timeout := cfg.HTTPPolicy(instance).Timeout()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	transport := p.sharedTransport(cfg)
+	client, ok := p.clients[instance]
+	if !ok || client.Timeout != timeout {
+		client = &http.Client{Transport: transport, Timeout: timeout}
+		p.clients[instance] = client
+	}
+	return client
+}
+
+// Transport returns the transport shared by all clients, for proxying to a device
+func (p *HTTPClientPool) Transport(cfg *config.Config, instance string) http.RoundTripper {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return withFaults(p.sharedTransport(cfg), cfg, instance)
+}
+
+// slot returns the in-flight semaphore of an instance. Requests holding a slot
+// of a replaced semaphore release it into the old one.
+func (p *HTTPClientPool) slot(cfg *config.Config, instance string) chan struct{} {
+	limit := cfg.HTTPPolicy(instance).InFlightLimit()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sem, ok := p.inFlight[instance]
+	if !ok || cap(sem) != limit {
+		sem = make(chan struct{}, limit)
+		p.inFlight[instance] = sem
+	}
+	return sem
+}
+
+// releaseBody frees an in-flight slot once the response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// send waits for a free slot, then sends the request. The slot is held until
+// the caller closes the response body.
+func (p *HTTPClientPool) send(client *http.Client, sem chan struct{}, req *http.Request) (*http.Response, error) {
+	select {
+	case sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := func() { <-sem }
+
+	resp, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// Get fetches a URL from an instance, retrying network errors and 5xx
+// responses with exponential backoff. The caller closes the response body.
+func (p *HTTPClientPool) Get(ctx context.Context, cfg *config.Config, instance, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.Do(cfg, instance, req)
+}
+
+// SetOutboundHeaders adds the configured user agent and extra headers to a
+// request to a device, pool or node. Headers the request already carries,
+// such as a node's Authorization, are kept.
+func SetOutboundHeaders(req *http.Request, cfg *config.Config) {
+	for name, value := range cfg.OutboundHeaders {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = config.DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+}
+
+// Do sends a request to an instance. GET and HEAD requests are retried like
+// Get; other methods (restarts, settings changes) are sent exactly once.
+// The outcome is recorded for the dependency health of the instance.
+func (p *HTTPClientPool) Do(cfg *config.Config, instance string, req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := p.do(cfg, instance, req)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The client went away; says nothing about the device
+	case err == nil && resp.StatusCode >= 500:
+		dependencies.Record(dependencies.KindAxeOS, instance, time.Since(start), fmt.Errorf("unexpected status: %s", resp.Status))
+	default:
+		dependencies.Record(dependencies.KindAxeOS, instance, time.Since(start), err)
+	}
+	return resp, err
+}
+
+// do sends a request to an instance with the retry policy of Do
+func (p *HTTPClientPool) do(cfg *config.Config, instance string, req *http.Request) (*http.Response, error) {
+	client := p.Client(cfg, instance)
+	if cfg.FaultInjection.Applies(instance) {
+		client = &http.Client{Transport: withFaults(client.Transport, cfg, instance), Timeout: client.Timeout}
+	}
+	sem := p.slot(cfg, instance)
+	SetOutboundHeaders(req, cfg)
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return p.send(client, sem, req)
+	}
+
+	policy := cfg.HTTPPolicy(instance)
+	retries := policy.RetryCount()
+	backoff := policy.Backoff()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := p.send(client, sem, req)
+		if (err == nil && resp.StatusCode < 500) || attempt >= retries {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxHTTPBackoff {
+			backoff = maxHTTPBackoff
+		}
+	}
+}
+
+// SendDeviceRequest sends a request to an AxeOS device and reports non-200 responses as errors
+func SendDeviceRequest(cfg *config.Config, instanceID, method, deviceURL string, body []byte) error {
+	req, err := http.NewRequest(method, deviceURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := GetHTTPClientPool().Do(cfg, instanceID, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errorText, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP error! Status: %d, Body: %s", resp.StatusCode, string(errorText))
+	}
+	return nil
+}
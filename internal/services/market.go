@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// Market data fields a provider can report
+const (
+	MarketFieldPrice           = "price"
+	MarketFieldDifficulty      = "difficulty"
+	MarketFieldNetworkHashrate = "networkHashrate"
+	MarketFieldBlockReward     = "blockReward"
+)
+
+const (
+	// marketFailureBackoff is how long a failing provider is skipped
+	marketFailureBackoff = 5 * time.Minute
+	// marketMaxStale is how old a cached value may be when every provider fails
+	marketMaxStale = 6 * time.Hour
+	// marketFetchTimeout bounds each provider request
+	marketFetchTimeout = 10 * time.Second
+)
+
+// ErrMarketUnsupported is returned by providers that have no data for a coin or currency
+var ErrMarketUnsupported = errors.New("not supported for this coin")
+
+// MarketQuote is the data one provider returned; fields it does not report are zero
+type MarketQuote struct {
+	Price           float64 // In the configured currency
+	Difficulty      float64
+	NetworkHashrate float64 // H/s
+	BlockReward     float64 // Coins per block, excluding fees
+}
+
+// value returns a field of the quote
+func (q *MarketQuote) value(field string) float64 {
+	switch field {
+	case MarketFieldPrice:
+		return q.Price
+	case MarketFieldDifficulty:
+		return q.Difficulty
+	case MarketFieldNetworkHashrate:
+		return q.NetworkHashrate
+	case MarketFieldBlockReward:
+		return q.BlockReward
+	}
+	return 0
+}
+
+// MarketProvider is an external source of price or network data
+type MarketProvider interface {
+	Name() string
+	Provides() []string // MarketField* values the provider can report
+	Fetch(ctx context.Context, cfg *config.Config, coin, currency string) (*MarketQuote, error)
+}
+
+// MarketValue is one market figure and where it came from
+type MarketValue struct {
+	Value     float64   `json:"value"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Stale     bool      `json:"stale,omitempty"` // Every provider failed; this is the last known value
+}
+
+// MarketProviderStatus reports the state of a provider
+type MarketProviderStatus struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"` // "ok", "cached", "error", "unsupported" or "unused"
+	Error     string     `json:"error,omitempty"`
+	FetchedAt *time.Time `json:"fetchedAt,omitempty"`
+	RetryAt   *time.Time `json:"retryAt,omitempty"`
+}
+
+// MarketData is the merged view of all providers
+type MarketData struct {
+	Coin            string                 `json:"coin"`
+	Currency        string                 `json:"currency"`
+	Price           *MarketValue           `json:"price"`
+	Difficulty      *MarketValue           `json:"difficulty"`
+	NetworkHashrate *MarketValue           `json:"networkHashrate"` // H/s
+	BlockReward     *MarketValue           `json:"blockReward"`
+	Hashprice       *MarketValue           `json:"hashprice"` // Currency per TH/s per day
+	Providers       []MarketProviderStatus `json:"providers"`
+}
+
+// marketState is the cached result of one provider for one coin and currency
+type marketState struct {
+	quote     *MarketQuote
+	fetchedAt time.Time
+	err       error
+	retryAt   time.Time
+}
+
+var (
+	marketCache   = map[string]*marketState{}
+	marketCacheMu sync.Mutex
+)
+
+// GetMarketData returns price, difficulty, network hashrate, block reward and
+// hashprice for the configured coin. Providers are tried in the configured
+// order and only while a field is still missing; responses are cached for the
+// configured TTL, failing or rate-limited providers are skipped for a while,
+// and the last known value is returned (marked stale) when all of them fail.
+func GetMarketData(ctx context.Context, cfg *config.Config) *MarketData {
+	market := cfg.Market
+	ttl := time.Duration(market.CacheTTLSeconds) * time.Second
+	fields := []string{MarketFieldPrice, MarketFieldDifficulty, MarketFieldNetworkHashrate, MarketFieldBlockReward}
+
+	marketCacheMu.Lock()
+	defer marketCacheMu.Unlock()
+
+	values := map[string]*MarketValue{}
+	missing := func(provider MarketProvider) bool {
+		for _, field := range provider.Provides() {
+			if values[field] == nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	data := &MarketData{Coin: market.Coin, Currency: market.Currency, Providers: []MarketProviderStatus{}}
+	states := map[string]*marketState{}
+	for _, name := range market.Providers {
+		provider := marketProviders[name]
+		if provider == nil {
+			continue
+		}
+		status := MarketProviderStatus{Name: name, Status: "unused"}
+
+		if missing(provider) {
+			state := refreshMarketProvider(ctx, cfg, provider, market.Coin, market.Currency, ttl)
+			states[name] = state
+			status = state.status(name, ttl)
+
+			if state.quote != nil && time.Since(state.fetchedAt) < ttl {
+				for _, field := range provider.Provides() {
+					if v := state.quote.value(field); v > 0 && values[field] == nil {
+						values[field] = &MarketValue{Value: v, Source: name, UpdatedAt: state.fetchedAt}
+					}
+				}
+			}
+		}
+		data.Providers = append(data.Providers, status)
+	}
+
+	// Fall back to the last known values of providers that are failing now
+	for _, field := range fields {
+		if values[field] != nil {
+			continue
+		}
+		for _, name := range market.Providers {
+			state := states[name]
+			if state == nil || state.quote == nil || time.Since(state.fetchedAt) > marketMaxStale {
+				continue
+			}
+			if v := state.quote.value(field); v > 0 {
+				values[field] = &MarketValue{Value: v, Source: name, UpdatedAt: state.fetchedAt, Stale: true}
+				break
+			}
+		}
+	}
+	if values[MarketFieldBlockReward] == nil && market.BlockReward > 0 {
+		values[MarketFieldBlockReward] = &MarketValue{Value: market.BlockReward, Source: "config", UpdatedAt: time.Now()}
+	}
+
+	data.Price = values[MarketFieldPrice]
+	data.Difficulty = values[MarketFieldDifficulty]
+	data.NetworkHashrate = values[MarketFieldNetworkHashrate]
+	data.BlockReward = values[MarketFieldBlockReward]
+	data.Hashprice = hashprice(data.Price, data.Difficulty, data.BlockReward)
+	return data
+}
+
+// refreshMarketProvider returns the cached state of a provider, fetching a new
+// quote when the cache expired and the provider is not backing off.
+// The caller holds marketCacheMu.
+func refreshMarketProvider(ctx context.Context, cfg *config.Config, provider MarketProvider, coin, currency string, ttl time.Duration) *marketState {
+	key := provider.Name() + ":" + coin + ":" + currency
+	state := marketCache[key]
+	if state == nil {
+		state = &marketState{}
+		marketCache[key] = state
+	}
+
+	if state.quote != nil && time.Since(state.fetchedAt) < ttl {
+		return state
+	}
+	if time.Now().Before(state.retryAt) {
+		return state
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, marketFetchTimeout)
+	defer cancel()
+
+	start := time.Now()
+	quote, err := provider.Fetch(fetchCtx, cfg, coin, currency)
+	if !errors.Is(err, ErrMarketUnsupported) {
+		dependencies.Record(dependencies.KindMarket, provider.Name(), time.Since(start), err)
+	}
+	if err != nil {
+		state.err = err
+		backoff := marketFailureBackoff
+		if errors.Is(err, ErrMarketUnsupported) {
+			backoff = ttl // Support does not change; no need to ask again soon
+		}
+		var limited *rateLimitedError
+		if errors.As(err, &limited) && limited.retryAfter > backoff {
+			backoff = limited.retryAfter
+		}
+		state.retryAt = time.Now().Add(backoff)
+		if !errors.Is(err, ErrMarketUnsupported) {
+			logger.New(logger.ModuleService).Warn("Market provider %s failed, retrying after %v: %v", provider.Name(), backoff, err)
+		}
+		return state
+	}
+
+	state.quote = quote
+	state.fetchedAt = time.Now()
+	state.err = nil
+	state.retryAt = time.Time{}
+	return state
+}
+
+// status describes a provider state for the API
+func (s *marketState) status(name string, ttl time.Duration) MarketProviderStatus {
+	status := MarketProviderStatus{Name: name, Status: "ok"}
+	if !s.fetchedAt.IsZero() {
+		fetchedAt := s.fetchedAt
+		status.FetchedAt = &fetchedAt
+	}
+	switch {
+	case errors.Is(s.err, ErrMarketUnsupported):
+		status.Status = "unsupported"
+	case s.err != nil:
+		status.Status = "error"
+		status.Error = s.err.Error()
+		retryAt := s.retryAt
+		status.RetryAt = &retryAt
+	case time.Since(s.fetchedAt) >= ttl:
+		status.Status = "cached"
+	}
+	return status
+}
+
+// hashprice is the expected revenue of 1 TH/s for one day:
+// reward * price * seconds per day * 1e12 / (difficulty * 2^32)
+func hashprice(price, difficulty, reward *MarketValue) *MarketValue {
+	if price == nil || difficulty == nil || reward == nil {
+		return nil
+	}
+	value := reward.Value * price.Value * 86400 * 1e12 / (difficulty.Value * math.Pow(2, 32))
+
+	updatedAt := price.UpdatedAt
+	if difficulty.UpdatedAt.Before(updatedAt) {
+		updatedAt = difficulty.UpdatedAt
+	}
+	return &MarketValue{
+		Value:     value,
+		Source:    fmt.Sprintf("%s+%s", price.Source, difficulty.Source),
+		UpdatedAt: updatedAt,
+		Stale:     price.Stale || difficulty.Stale || reward.Stale,
+	}
+}
+
+// SatsPerDay is the expected reward, in satoshis (1e-8 coins) per day, of a
+// hashrate in GH/s at a network difficulty: reward * hashes per day / (difficulty * 2^32)
+func SatsPerDay(hashrateGHs, difficulty, blockReward float64) float64 {
+	if difficulty <= 0 {
+		return 0
+	}
+	return hashrateGHs * 1e9 * 86400 * blockReward * 1e8 / (difficulty * math.Pow(2, 32))
+}
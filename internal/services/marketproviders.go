@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/live"
+)
+
+// maxMarketBytes limits the size of a provider response
+const maxMarketBytes = 256 << 10
+
+var marketClient = &http.Client{Timeout: marketFetchTimeout}
+
+// marketProviders are the available providers by configuration name
+var marketProviders = map[string]MarketProvider{
+	config.MarketCoinGecko:      coinGeckoProvider{},
+	config.MarketCoinbase:       coinbaseProvider{},
+	config.MarketMempool:        mempoolProvider{},
+	config.MarketBlockchainInfo: blockchainInfoProvider{},
+	config.MarketNode:           nodeProvider{},
+}
+
+// RegisterMarketProvider adds a provider that market.providers can then list
+// by its name. It must be called before the configuration is loaded, usually
+// from an init function.
+func RegisterMarketProvider(provider MarketProvider) {
+	config.RegisterMarketProvider(provider.Name())
+	marketProviders[provider.Name()] = provider
+}
+
+// coinGeckoIDs maps tickers to CoinGecko coin ids
+var coinGeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"BCH":  "bitcoin-cash",
+	"BSV":  "bitcoin-cash-sv",
+	"DGB":  "digibyte",
+	"XEC":  "ecash",
+	"LTC":  "litecoin",
+	"DOGE": "dogecoin",
+}
+
+// rateLimitedError is returned when a provider answers 429 Too Many Requests
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited (retry after %v)", e.retryAfter)
+}
+
+// marketStatusError is returned for other unsuccessful HTTP responses
+type marketStatusError struct {
+	status int
+}
+
+func (e *marketStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d", e.status)
+}
+
+// getMarket fetches a provider URL and returns the body
+func getMarket(ctx context.Context, cfg *config.Config, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	SetOutboundHeaders(req, cfg)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := marketClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := marketFailureBackoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, &rateLimitedError{retryAfter: retryAfter}
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, &marketStatusError{status: resp.StatusCode}
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMarketBytes))
+}
+
+// getMarketNumber fetches a URL whose body is a single number
+func getMarketNumber(ctx context.Context, cfg *config.Config, target string) (float64, error) {
+	body, err := getMarket(ctx, cfg, target)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected response %q", strings.TrimSpace(string(body)))
+	}
+	return value, nil
+}
+
+// BitcoinSubsidy returns the block subsidy at a height (50 BTC halving every 210,000 blocks)
+func BitcoinSubsidy(height int) float64 {
+	return 50 / math.Pow(2, float64(height/HalvingInterval))
+}
+
+// coinGeckoProvider reads prices from the CoinGecko public API
+type coinGeckoProvider struct{}
+
+func (coinGeckoProvider) Name() string       { return config.MarketCoinGecko }
+func (coinGeckoProvider) Provides() []string { return []string{MarketFieldPrice} }
+
+func (coinGeckoProvider) Fetch(ctx context.Context, cfg *config.Config, coin, currency string) (*MarketQuote, error) {
+	id, ok := coinGeckoIDs[coin]
+	if !ok {
+		return nil, ErrMarketUnsupported
+	}
+	vs := strings.ToLower(currency)
+
+	body, err := getMarket(ctx, cfg, "https://api.coingecko.com/api/v3/simple/price?ids="+url.QueryEscape(id)+"&vs_currencies="+url.QueryEscape(vs))
+	if err != nil {
+		return nil, err
+	}
+	var prices map[string]map[string]float64
+	if err := json.Unmarshal(body, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	price := prices[id][vs]
+	if price <= 0 {
+		return nil, ErrMarketUnsupported
+	}
+	return &MarketQuote{Price: price}, nil
+}
+
+// coinbaseProvider reads spot prices from the Coinbase public API
+type coinbaseProvider struct{}
+
+func (coinbaseProvider) Name() string       { return config.MarketCoinbase }
+func (coinbaseProvider) Provides() []string { return []string{MarketFieldPrice} }
+
+func (coinbaseProvider) Fetch(ctx context.Context, cfg *config.Config, coin, currency string) (*MarketQuote, error) {
+	body, err := getMarket(ctx, cfg, "https://api.coinbase.com/v2/prices/"+url.PathEscape(coin+"-"+currency)+"/spot")
+	var statusErr *marketStatusError
+	if errors.As(err, &statusErr) && (statusErr.status == http.StatusNotFound || statusErr.status == http.StatusBadRequest) {
+		return nil, ErrMarketUnsupported // Unknown currency pair
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Data struct {
+			Amount string `json:"amount"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	price, err := strconv.ParseFloat(result.Data.Amount, 64)
+	if err != nil || price <= 0 {
+		return nil, fmt.Errorf("unexpected price %q", result.Data.Amount)
+	}
+	return &MarketQuote{Price: price}, nil
+}
+
+// mempoolProvider reads Bitcoin network data from mempool.space
+type mempoolProvider struct{}
+
+func (mempoolProvider) Name() string { return config.MarketMempool }
+func (mempoolProvider) Provides() []string {
+	return []string{MarketFieldDifficulty, MarketFieldNetworkHashrate, MarketFieldBlockReward}
+}
+
+func (mempoolProvider) Fetch(ctx context.Context, cfg *config.Config, coin, currency string) (*MarketQuote, error) {
+	if coin != "BTC" {
+		return nil, ErrMarketUnsupported
+	}
+
+	body, err := getMarket(ctx, cfg, "https://mempool.space/api/v1/mining/hashrate/3d")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		CurrentHashrate   float64 `json:"currentHashrate"`
+		CurrentDifficulty float64 `json:"currentDifficulty"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if result.CurrentDifficulty <= 0 {
+		return nil, fmt.Errorf("no difficulty in response")
+	}
+
+	quote := &MarketQuote{Difficulty: result.CurrentDifficulty, NetworkHashrate: result.CurrentHashrate}
+	if height, err := getMarketNumber(ctx, cfg, "https://mempool.space/api/blocks/tip/height"); err == nil {
+		quote.BlockReward = BitcoinSubsidy(int(height))
+	}
+	return quote, nil
+}
+
+// blockchainInfoProvider reads Bitcoin network data from the blockchain.com query API
+type blockchainInfoProvider struct{}
+
+func (blockchainInfoProvider) Name() string { return config.MarketBlockchainInfo }
+func (blockchainInfoProvider) Provides() []string {
+	return []string{MarketFieldDifficulty, MarketFieldNetworkHashrate, MarketFieldBlockReward}
+}
+
+func (blockchainInfoProvider) Fetch(ctx context.Context, cfg *config.Config, coin, currency string) (*MarketQuote, error) {
+	if coin != "BTC" {
+		return nil, ErrMarketUnsupported
+	}
+
+	difficulty, err := getMarketNumber(ctx, cfg, "https://blockchain.info/q/getdifficulty")
+	if err != nil {
+		return nil, err
+	}
+	quote := &MarketQuote{Difficulty: difficulty}
+	if hashrate, err := getMarketNumber(ctx, cfg, "https://blockchain.info/q/hashrate"); err == nil {
+		quote.NetworkHashrate = hashrate * 1e9 // Reported in GH/s
+	}
+	if height, err := getMarketNumber(ctx, cfg, "https://blockchain.info/q/getblockcount"); err == nil {
+		quote.BlockReward = BitcoinSubsidy(int(height))
+	}
+	return quote, nil
+}
+
+// nodeProvider uses the difficulty last collected from the configured crypto node.
+// It needs data collection and works for any coin the node runs.
+type nodeProvider struct{}
+
+func (nodeProvider) Name() string { return config.MarketNode }
+func (nodeProvider) Provides() []string {
+	return []string{MarketFieldDifficulty, MarketFieldNetworkHashrate}
+}
+
+func (nodeProvider) Fetch(ctx context.Context, cfg *config.Config, coin, currency string) (*MarketQuote, error) {
+	samples := live.GetHub().Latest(live.KindNode)
+	ids := make([]string, 0, len(samples))
+	for id := range samples {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		metric, ok := samples[id].Metric.(*database.NodeMetric)
+		if ok && metric.Difficulty > 0 {
+			return &MarketQuote{Difficulty: metric.Difficulty, NetworkHashrate: metric.NetworkHashrate}, nil
+		}
+	}
+	return nil, fmt.Errorf("no crypto node data collected yet")
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Bitcoin consensus intervals used for milestones
+const (
+	DifficultyAdjustmentInterval = 2016   // Blocks between difficulty retargets
+	HalvingInterval              = 210000 // Blocks between subsidy halvings
+	TargetBlockSeconds           = 600
+)
+
+// DifficultyEpoch is the progress of the current difficulty period and the
+// estimated result of its retarget
+type DifficultyEpoch struct {
+	StartHeight            int       `json:"startHeight"`
+	NextHeight             int       `json:"nextHeight"` // Height of the next retarget
+	BlocksRemaining        int       `json:"blocksRemaining"`
+	ProgressPercent        float64   `json:"progressPercent"`
+	StartedAt              time.Time `json:"startedAt"`
+	AvgBlockSeconds        float64   `json:"avgBlockSeconds"` // Over the blocks of this period so far
+	EstimatedAt            time.Time `json:"estimatedAt"`
+	EstimatedChangePercent float64   `json:"estimatedChangePercent"`
+}
+
+// HalvingCountdown is the time left until the next subsidy halving
+type HalvingCountdown struct {
+	Halvings        int       `json:"halvings"` // Halvings so far
+	NextHeight      int       `json:"nextHeight"`
+	BlocksRemaining int       `json:"blocksRemaining"`
+	EstimatedAt     time.Time `json:"estimatedAt"` // At the target block time
+	Subsidy         float64   `json:"subsidy"`
+	NextSubsidy     float64   `json:"nextSubsidy"`
+}
+
+// NetworkMilestones are the upcoming timechain milestones seen by a crypto node
+type NetworkMilestones struct {
+	NodeID               string           `json:"nodeId"`
+	Chain                string           `json:"chain"`
+	Height               int              `json:"height"`
+	Difficulty           float64          `json:"difficulty"`
+	DifficultyAdjustment DifficultyEpoch  `json:"difficultyAdjustment"`
+	Halving              HalvingCountdown `json:"halving"`
+}
+
+// blockTime returns the header time of the block at a height, or of the
+// block with the given hash when hash is not empty
+func blockTime(rpcClient *RPCClient, nodeID string, height int, hash string) (time.Time, error) {
+	if hash == "" {
+		result, err := rpcClient.CallRPC(nodeID, "getblockhash", []interface{}{height})
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get block hash at %d: %w", height, err)
+		}
+		hash, _ = result.(string)
+	}
+	result, err := rpcClient.CallRPC(nodeID, "getblockheader", []interface{}{hash})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get block header %s: %w", hash, err)
+	}
+	header, _ := result.(map[string]interface{})
+	seconds, ok := header["time"].(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("block header %s has no time", hash)
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// GetNetworkMilestones reads the chain tip from a node and estimates the next
+// difficulty adjustment, from the average block time of the current period,
+// and the next halving, at the target block time. It follows Bitcoin's rules.
+func GetNetworkMilestones(rpcClient *RPCClient, nodeID string) (*NetworkMilestones, error) {
+	result, err := rpcClient.CallRPC(nodeID, "getblockchaininfo", []interface{}{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockchain info: %w", err)
+	}
+	info, _ := result.(map[string]interface{})
+	blocks, ok := info["blocks"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("node did not report its block height")
+	}
+
+	milestones := &NetworkMilestones{NodeID: nodeID, Height: int(blocks)}
+	milestones.Chain, _ = info["chain"].(string)
+	milestones.Difficulty, _ = info["difficulty"].(float64)
+	height := milestones.Height
+
+	// Difficulty period
+	epoch := &milestones.DifficultyAdjustment
+	epoch.StartHeight = height - height%DifficultyAdjustmentInterval
+	epoch.NextHeight = epoch.StartHeight + DifficultyAdjustmentInterval
+	epoch.BlocksRemaining = epoch.NextHeight - height
+	mined := height - epoch.StartHeight
+	epoch.ProgressPercent = float64(mined) / DifficultyAdjustmentInterval * 100
+
+	epoch.StartedAt, err = blockTime(rpcClient, nodeID, epoch.StartHeight, "")
+	if err != nil {
+		return nil, err
+	}
+	epoch.AvgBlockSeconds = TargetBlockSeconds
+	tipTime := time.Now()
+	if mined > 0 {
+		bestHash, _ := info["bestblockhash"].(string)
+		if tipTime, err = blockTime(rpcClient, nodeID, height, bestHash); err != nil {
+			return nil, err
+		}
+		if avg := tipTime.Sub(epoch.StartedAt).Seconds() / float64(mined); avg > 0 {
+			epoch.AvgBlockSeconds = avg
+		}
+	}
+	epoch.EstimatedAt = tipTime.Add(time.Duration(float64(epoch.BlocksRemaining) * epoch.AvgBlockSeconds * float64(time.Second)))
+	// The retarget is limited to a factor of four either way
+	factor := math.Max(0.25, math.Min(4, TargetBlockSeconds/epoch.AvgBlockSeconds))
+	epoch.EstimatedChangePercent = (factor - 1) * 100
+
+	// Halving
+	halving := &milestones.Halving
+	halving.Halvings = height / HalvingInterval
+	halving.NextHeight = (halving.Halvings + 1) * HalvingInterval
+	halving.BlocksRemaining = halving.NextHeight - height
+	halving.EstimatedAt = tipTime.Add(time.Duration(halving.BlocksRemaining) * TargetBlockSeconds * time.Second)
+	halving.Subsidy = BitcoinSubsidy(height)
+	halving.NextSubsidy = BitcoinSubsidy(halving.NextHeight)
+
+	return milestones, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
+)
+
+// maxMiningCoreBytes limits the size of a Mining Core or public pool response
+const maxMiningCoreBytes = 8 << 20
+
+// miningCoreEntry is a cached pool API response, or a request in flight
+type miningCoreEntry struct {
+	body      []byte
+	fetchedAt time.Time
+	done      chan struct{} // Closed when the request in flight finishes
+	err       error
+}
+
+var (
+	miningCoreCache   = map[string]*miningCoreEntry{}
+	miningCoreCacheMu sync.Mutex
+	miningCoreClient  = &http.Client{Timeout: 15 * time.Second}
+)
+
+// FetchMiningCore returns the body of a Mining Core API response. Responses
+// are shared between the systems API and the scheduler for
+// mining_core_cache_seconds, and
+// concurrent requests for the same URL wait for a single upstream call, so
+// several dashboards polling at once do not overload the pool. A ttl of zero
+// or less only coalesces concurrent requests.
+func FetchMiningCore(cfg *config.Config, url string) ([]byte, error) {
+	return fetchPoolAPI(cfg, url, miningCoreName(cfg, url))
+}
+
+// fetchPoolAPI returns the body of a pool API response through the shared
+// cache, recording the request as a dependency of the pool with the name
+func fetchPoolAPI(cfg *config.Config, url, name string) ([]byte, error) {
+	ttl := cfg.MiningCoreCacheTTL()
+
+	miningCoreCacheMu.Lock()
+	entry := miningCoreCache[url]
+	if entry != nil {
+		if entry.done != nil {
+			// Another caller is fetching this URL; wait for its result
+			done := entry.done
+			miningCoreCacheMu.Unlock()
+			<-done
+			return entry.body, entry.err
+		}
+		if entry.err == nil && time.Since(entry.fetchedAt) < ttl {
+			miningCoreCacheMu.Unlock()
+			return entry.body, nil
+		}
+	}
+
+	entry = &miningCoreEntry{done: make(chan struct{})}
+	miningCoreCache[url] = entry
+	miningCoreCacheMu.Unlock()
+
+	start := time.Now()
+	body, err := fetchMiningCore(cfg, url)
+	dependencies.Record(dependencies.KindPool, name, time.Since(start), err)
+
+	miningCoreCacheMu.Lock()
+	entry.body, entry.err, entry.fetchedAt = body, err, time.Now()
+	close(entry.done)
+	entry.done = nil
+	if err != nil {
+		delete(miningCoreCache, url) // Errors are not cached
+	}
+	miningCoreCacheMu.Unlock()
+
+	return body, err
+}
+
+// miningCoreName returns the configured name of the pool a URL belongs to,
+// or the URL when no configured pool matches
+func miningCoreName(cfg *config.Config, url string) string {
+	for _, poolMap := range cfg.MiningCoreURL {
+		for name, poolURL := range poolMap {
+			if poolURL != "" && strings.HasPrefix(url, poolURL) {
+				return name
+			}
+		}
+	}
+	return url
+}
+
+// fetchMiningCore performs the upstream request
+func fetchMiningCore(cfg *config.Config, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	SetOutboundHeaders(req, cfg)
+
+	resp, err := miningCoreClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMiningCoreBytes))
+}
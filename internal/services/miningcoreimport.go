@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+const (
+	// maxImportAddresses limits how many miner addresses are read per pool
+	maxImportAddresses = 100
+	// maxImportWorkers limits how many workers are probed per import
+	maxImportWorkers = 1024
+)
+
+// workerHostname matches worker names that can be used as a hostname
+var workerHostname = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// MiningCoreWorker is a worker Mining Core reports for a miner address
+type MiningCoreWorker struct {
+	Pool     string  `json:"pool"`   // Name in mining_core_url
+	PoolID   string  `json:"poolId"` // Pool id on the Mining Core server
+	Address  string  `json:"address"`
+	Worker   string  `json:"worker"`
+	Hashrate float64 `json:"hashrate"`
+	Host     string  `json:"host,omitempty"`   // Where the worker was looked for
+	Reason   string  `json:"reason,omitempty"` // Why no AxeOS device was found
+}
+
+// MiningCoreImportResult is the outcome of reading workers from Mining Core
+type MiningCoreImportResult struct {
+	Pools     []string           `json:"pools"`
+	Workers   int                `json:"workers"`
+	Miners    []DiscoveredMiner  `json:"miners"`
+	Unmatched []MiningCoreWorker `json:"unmatched"`
+}
+
+// miningCoreJSON fetches a Mining Core API response through the shared cache
+func miningCoreJSON(cfg *config.Config, url string, v interface{}) error {
+	body, err := FetchMiningCore(cfg, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// workerHost returns the host an AxeOS device behind a worker answers on.
+// Forks of Mining Core that report the worker's address are used first;
+// otherwise the worker name is used, as miners are commonly named after
+// their hostname or IP address. Worker names cannot contain dots, so IP
+// addresses are written as 192-168-1-20 or 192_168_1_20.
+func workerHost(name string, stats map[string]interface{}) string {
+	for _, key := range []string{"ipAddress", "remoteAddress", "ip"} {
+		if value, ok := stats[key].(string); ok && value != "" {
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				return host
+			}
+			return value
+		}
+	}
+	if ip := net.ParseIP(strings.NewReplacer("-", ".", "_", ".").Replace(name)); ip != nil && ip.To4() != nil {
+		return ip.String()
+	}
+	if workerHostname.MatchString(name) {
+		return name
+	}
+	return ""
+}
+
+// ListMiningCoreWorkers returns the workers a Mining Core server reports for
+// the miners of each of its pools
+func ListMiningCoreWorkers(ctx context.Context, cfg *config.Config, poolName, poolURL string) ([]MiningCoreWorker, []string, error) {
+	poolsURL := strings.TrimRight(poolURL, "/") + GetAPIPath(cfg, "pools")
+
+	var pools struct {
+		Pools []struct {
+			ID string `json:"id"`
+		} `json:"pools"`
+	}
+	if err := miningCoreJSON(cfg, poolsURL, &pools); err != nil {
+		return nil, nil, err
+	}
+
+	var workers []MiningCoreWorker
+	var poolIDs []string
+	for _, pool := range pools.Pools {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		poolIDs = append(poolIDs, pool.ID)
+
+		var miners []struct {
+			Miner string `json:"miner"`
+		}
+		minersURL := fmt.Sprintf("%s/%s/miners?page=0&pageSize=%d", poolsURL, url.PathEscape(pool.ID), maxImportAddresses)
+		if err := miningCoreJSON(cfg, minersURL, &miners); err != nil {
+			return nil, nil, err
+		}
+
+		for _, miner := range miners {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			var stats struct {
+				Performance *struct {
+					Workers map[string]map[string]interface{} `json:"workers"`
+				} `json:"performance"`
+			}
+			minerURL := fmt.Sprintf("%s/%s/miners/%s", poolsURL, url.PathEscape(pool.ID), url.PathEscape(miner.Miner))
+			if err := miningCoreJSON(cfg, minerURL, &stats); err != nil {
+				return nil, nil, err
+			}
+			if stats.Performance == nil {
+				continue // No shares in the last sample
+			}
+			for name, workerStats := range stats.Performance.Workers {
+				worker := MiningCoreWorker{
+					Pool:    poolName,
+					PoolID:  pool.ID,
+					Address: miner.Miner,
+					Worker:  name,
+					Host:    workerHost(name, workerStats),
+				}
+				worker.Hashrate, _ = workerStats["hashrate"].(float64)
+				workers = append(workers, worker)
+			}
+		}
+	}
+	return workers, poolIDs, nil
+}
+
+// ImportMiningCoreWorkers reads the workers of Mining Core servers (name ->
+// base URL) and probes each for the AxeOS system info API. configured maps
+// base URLs already in axeos_instances to their names. progress, if set, is
+// called after each probe.
+func ImportMiningCoreWorkers(ctx context.Context, cfg *config.Config, servers map[string]string, infoPath string, configured map[string]string, progress func(done, total int)) (*MiningCoreImportResult, error) {
+	result := &MiningCoreImportResult{Pools: []string{}, Miners: []DiscoveredMiner{}, Unmatched: []MiningCoreWorker{}}
+
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var workers []MiningCoreWorker
+	for _, name := range names {
+		found, poolIDs, err := ListMiningCoreWorkers(ctx, cfg, name, servers[name])
+		if err != nil {
+			return nil, fmt.Errorf("Mining Core %s: %w", name, err)
+		}
+		for _, id := range poolIDs {
+			result.Pools = append(result.Pools, name+"/"+id)
+		}
+		workers = append(workers, found...)
+	}
+	result.Workers = len(workers)
+	if len(workers) > maxImportWorkers {
+		return nil, fmt.Errorf("too many workers to probe (%d), the maximum is %d", len(workers), maxImportWorkers)
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		if workers[i].Pool != workers[j].Pool {
+			return workers[i].Pool < workers[j].Pool
+		}
+		return workers[i].Address+"."+workers[i].Worker < workers[j].Address+"."+workers[j].Worker
+	})
+
+	// Several workers may point at the same host; probe it once
+	probes := map[string][]int{}
+	var hosts []string
+	for i, worker := range workers {
+		if worker.Host == "" {
+			worker.Reason = "worker name is not a hostname or IP address"
+			result.Unmatched = append(result.Unmatched, worker)
+			continue
+		}
+		if _, ok := probes[worker.Host]; !ok {
+			hosts = append(hosts, worker.Host)
+		}
+		probes[worker.Host] = append(probes[worker.Host], i)
+	}
+
+	client := &http.Client{Timeout: scanProbeTimeout}
+	found := map[string]DiscoveredMiner{}
+	var (
+		mu   sync.Mutex
+		done int
+		wg   sync.WaitGroup
+	)
+	slots := make(chan struct{}, scanConcurrency)
+
+probe:
+	for _, host := range hosts {
+		select {
+		case <-ctx.Done():
+			break probe
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			miner, ok := probeMiner(ctx, cfg, client, host, infoPath)
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if ok {
+				found[host] = miner
+			}
+			if progress != nil {
+				progress(done, len(hosts))
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		miner, ok := found[host]
+		if !ok {
+			for _, i := range probes[host] {
+				worker := workers[i]
+				worker.Reason = "no AxeOS device answered at " + host
+				result.Unmatched = append(result.Unmatched, worker)
+			}
+			continue
+		}
+		worker := workers[probes[host][0]]
+		miner.Worker = worker.Address + "." + worker.Worker
+		miner.ConfiguredAs = configured[miner.URL]
+		if miner.Hostname == "" {
+			miner.SuggestedName = worker.Worker
+		}
+		result.Miners = append(result.Miners, miner)
+	}
+	return result, nil
+}
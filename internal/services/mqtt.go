@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
+)
+
+// mqttTimeout bounds a whole ambient read: connect, subscribe and wait for a message.
+// Sensors should publish with the retain flag so a value is delivered immediately.
+const mqttTimeout = 15 * time.Second
+
+// MQTT 3.1.1 packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttSubscribe  = 8
+	mqttDisconnect = 14
+)
+
+// MQTTConfig represents the optional mqtt.json secret
+type MQTTConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// readMQTTMessage connects to a broker, subscribes to topic and returns the
+// payload of the first message received (the retained one, if any)
+func readMQTTMessage(broker, topic, configDir string) ([]byte, error) {
+	var credentials MQTTConfig
+	if data, err := secrets.Read(configDir, "mqtt.json"); err == nil {
+		if err := json.Unmarshal(data, &credentials); err != nil {
+			return nil, fmt.Errorf("failed to parse mqtt.json: %w", err)
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(broker); err != nil {
+		broker = net.JoinHostPort(broker, "1883")
+	}
+	conn, err := net.DialTimeout("tcp", broker, mqttTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(mqttTimeout))
+	reader := bufio.NewReader(conn)
+
+	// CONNECT with a clean session and a 60s keep-alive
+	var connect []byte
+	connect = appendMQTTString(connect, "MQTT")
+	flags := byte(0x02)
+	if credentials.Username != "" {
+		flags |= 0x80
+		if credentials.Password != "" {
+			flags |= 0x40
+		}
+	}
+	connect = append(connect, 4, flags, 0, 60)
+	connect = appendMQTTString(connect, "axeos-dashboard-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	if credentials.Username != "" {
+		connect = appendMQTTString(connect, credentials.Username)
+		if credentials.Password != "" {
+			connect = appendMQTTString(connect, credentials.Password)
+		}
+	}
+	if err := writeMQTTPacket(conn, mqttConnect<<4, connect); err != nil {
+		return nil, err
+	}
+
+	header, body, err := readMQTTPacket(reader)
+	if err != nil {
+		return nil, err
+	}
+	if header>>4 != mqttConnack || len(body) < 2 {
+		return nil, errors.New("MQTT broker did not acknowledge the connection")
+	}
+	if body[1] != 0 {
+		return nil, fmt.Errorf("MQTT broker refused the connection (code %d)", body[1])
+	}
+
+	// SUBSCRIBE (packet id 1) at QoS 0
+	subscribe := appendMQTTString([]byte{0, 1}, topic)
+	subscribe = append(subscribe, 0)
+	if err := writeMQTTPacket(conn, mqttSubscribe<<4|0x02, subscribe); err != nil {
+		return nil, err
+	}
+
+	for {
+		header, body, err := readMQTTPacket(reader)
+		if err != nil {
+			return nil, fmt.Errorf("no message on %s: %w", topic, err)
+		}
+		if header>>4 != mqttPublish {
+			continue // SUBACK and anything else
+		}
+
+		if len(body) < 2 {
+			return nil, errors.New("malformed MQTT publish")
+		}
+		offset := 2 + int(binary.BigEndian.Uint16(body))
+		if qos := (header >> 1) & 0x03; qos > 0 {
+			offset += 2 // Packet identifier
+		}
+		if offset > len(body) {
+			return nil, errors.New("malformed MQTT publish")
+		}
+
+		writeMQTTPacket(conn, mqttDisconnect<<4, nil)
+		return body[offset:], nil
+	}
+}
+
+// appendMQTTString appends a length-prefixed UTF-8 string
+func appendMQTTString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// writeMQTTPacket writes a packet with the given first header byte
+func writeMQTTPacket(w io.Writer, header byte, body []byte) error {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(packet, body...))
+	return err
+}
+
+// readMQTTPacket reads one packet, returning its first header byte (packet
+// type and flags) and its body
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed MQTT packet length")
+		}
+		multiplier *= 128
+	}
+	if length > maxSensorBytes {
+		return 0, nil, errors.New("MQTT packet too large")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header, body, nil
+}
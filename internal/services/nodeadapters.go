@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// NodeCard holds the RPC results shown on a crypto node card. Sections a
+// node type does not have are nil.
+type NodeCard struct {
+	BlockchainInfo interface{}
+	NetworkTotals  interface{}
+	Balance        interface{}
+	NetworkInfo    interface{}
+}
+
+// NodeStatus is the chain state of a crypto node, normalized across node types
+type NodeStatus struct {
+	Chain           string
+	BlockHeight     int
+	Difficulty      float64
+	Connections     int
+	NetworkHashrate float64 // H/s, 0 when the node does not report it
+}
+
+// NodeAdapter reads a type of crypto node, whose RPC methods and result
+// fields differ from Bitcoin Core's
+type NodeAdapter interface {
+	// FetchCard reads the sections shown on the node card
+	FetchCard(client *RPCClient, node NodeConfig) (*NodeCard, error)
+	// FetchStatus reads the block height, difficulty, connections and network hashrate
+	FetchStatus(client *RPCClient, node NodeConfig) (*NodeStatus, error)
+}
+
+// nodeAdapters are the adapters by normalized node type; other types use
+// the Bitcoin Core adapter
+var nodeAdapters = map[string]NodeAdapter{
+	"btc": bitcoinNodeAdapter{},
+	"ltc": bitcoinNodeAdapter{},
+	"dgb": digiByteNodeAdapter{},
+	"xmr": moneroNodeAdapter{},
+}
+
+// RegisterNodeAdapter sets the adapter of a node type, usually from an init function
+func RegisterNodeAdapter(nodeType string, adapter NodeAdapter) {
+	nodeAdapters[config.NormalizeNodeType(nodeType)] = adapter
+}
+
+// GetNodeAdapter returns the adapter of a node type, the Bitcoin Core
+// adapter for types without their own
+func GetNodeAdapter(nodeType string) NodeAdapter {
+	if adapter, ok := nodeAdapters[config.NormalizeNodeType(nodeType)]; ok {
+		return adapter
+	}
+	return bitcoinNodeAdapter{}
+}
+
+// ConfiguredNodes returns the nodes listed in the cryptoNodes section of
+// config.json and its NodeDisplayFields entry, if any
+func ConfiguredNodes(cfg *config.Config) ([]NodeConfig, interface{}) {
+	cryptoNodes, ok := cfg.CryptoNodes.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var nodes []NodeConfig
+	var displayFields interface{}
+	for _, item := range cryptoNodes {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Check for Nodes array
+		if nodesArray, ok := itemMap["Nodes"].([]interface{}); ok {
+			for _, nodeRaw := range nodesArray {
+				if nodeMap, ok := nodeRaw.(map[string]interface{}); ok {
+					node := NodeConfig{}
+					node.NodeType, _ = nodeMap["NodeType"].(string)
+					node.NodeName, _ = nodeMap["NodeName"].(string)
+					node.NodeID, _ = nodeMap["NodeId"].(string)
+					node.NodeAlgo, _ = nodeMap["NodeAlgo"].(string)
+					node.NodeMempool, _ = nodeMap["NodeMempool"].(bool)
+					nodes = append(nodes, node)
+				}
+			}
+		}
+
+		// Check for NodeDisplayFields
+		if ndf, exists := itemMap["NodeDisplayFields"]; exists {
+			displayFields = ndf
+		}
+	}
+	return nodes, displayFields
+}
+
+// FindNodeConfig returns the config.json entry of a node in rpcConfig.json,
+// or an entry without a type (read as Bitcoin Core) when it is not listed
+func FindNodeConfig(cfg *config.Config, nodeID string) NodeConfig {
+	nodes, _ := ConfiguredNodes(cfg)
+	for _, node := range nodes {
+		if node.NodeID == nodeID {
+			return node
+		}
+	}
+	return NodeConfig{NodeID: nodeID}
+}
+
+// FetchNodeStatus reads the chain state of a node with the adapter of its type
+func FetchNodeStatus(client *RPCClient, node NodeConfig) (*NodeStatus, error) {
+	return GetNodeAdapter(node.NodeType).FetchStatus(client, node)
+}
+
+// rpcMap returns the object result of an RPC call
+func rpcMap(client *RPCClient, nodeID, method string, params ...interface{}) (map[string]interface{}, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	result, err := client.CallRPC(nodeID, method, params)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s result", method)
+	}
+	return info, nil
+}
+
+// bitcoinNodeAdapter reads Bitcoin Core and nodes keeping its RPC interface
+// (Litecoin, Bitcoin Cash and most other forks)
+type bitcoinNodeAdapter struct{}
+
+func (bitcoinNodeAdapter) FetchCard(client *RPCClient, node NodeConfig) (*NodeCard, error) {
+	methods := []string{"getblockchaininfo", "getnettotals", "getbalance", "getnetworkinfo"}
+	labels := []string{"blockchain info", "network totals", "balance", "network info"}
+	results := make([]interface{}, len(methods))
+	errs := make([]error, len(methods))
+
+	// Fetch all data concurrently
+	var wg sync.WaitGroup
+	for i, method := range methods {
+		wg.Add(1)
+		go func(i int, method string) {
+			defer wg.Done()
+			results[i], errs[i] = client.CallRPC(node.NodeID, method, []interface{}{})
+		}(i, method)
+	}
+	wg.Wait()
+
+	var messages []string
+	for i, err := range errs {
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("error fetching %s for %s: %v", labels[i], node.NodeID, err))
+		}
+	}
+	if len(messages) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+	return &NodeCard{
+		BlockchainInfo: results[0],
+		NetworkTotals:  results[1],
+		Balance:        results[2],
+		NetworkInfo:    results[3],
+	}, nil
+}
+
+func (a bitcoinNodeAdapter) FetchStatus(client *RPCClient, node NodeConfig) (*NodeStatus, error) {
+	info, err := rpcMap(client, node.NodeID, "getblockchaininfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockchain info: %w", err)
+	}
+	status := &NodeStatus{}
+	status.Chain, _ = info["chain"].(string)
+	if blocks, ok := info["blocks"].(float64); ok {
+		status.BlockHeight = int(blocks)
+	}
+	status.Difficulty, _ = info["difficulty"].(float64)
+
+	if err := readConnections(client, node.NodeID, status); err != nil {
+		return nil, err
+	}
+
+	// Not every node implements the network hashrate estimate
+	if hashps, err := client.CallRPC(node.NodeID, "getnetworkhashps", []interface{}{}); err == nil {
+		status.NetworkHashrate, _ = hashps.(float64)
+	}
+	return status, nil
+}
+
+// readConnections reads the peer count from getnetworkinfo
+func readConnections(client *RPCClient, nodeID string, status *NodeStatus) error {
+	info, err := rpcMap(client, nodeID, "getnetworkinfo")
+	if err != nil {
+		return fmt.Errorf("failed to get network info: %w", err)
+	}
+	if connections, ok := info["connections"].(float64); ok {
+		status.Connections = int(connections)
+	}
+	return nil
+}
+
+// defaultDigiByteAlgo is the DigiByte algorithm used when NodeAlgo is not set
+const defaultDigiByteAlgo = "sha256d"
+
+// digiByteNodeAdapter reads DigiByte Core, which reports a difficulty and
+// network hashrate per mining algorithm. NodeAlgo selects the algorithm.
+type digiByteNodeAdapter struct {
+	bitcoinNodeAdapter
+}
+
+func (digiByteNodeAdapter) FetchStatus(client *RPCClient, node NodeConfig) (*NodeStatus, error) {
+	algo := strings.ToLower(strings.TrimSpace(node.NodeAlgo))
+	if algo == "" {
+		algo = defaultDigiByteAlgo
+	}
+
+	info, err := rpcMap(client, node.NodeID, "getblockchaininfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockchain info: %w", err)
+	}
+	status := &NodeStatus{}
+	status.Chain, _ = info["chain"].(string)
+	if blocks, ok := info["blocks"].(float64); ok {
+		status.BlockHeight = int(blocks)
+	}
+	if difficulties, ok := info["difficulties"].(map[string]interface{}); ok {
+		status.Difficulty, _ = difficulties[algo].(float64)
+	} else {
+		status.Difficulty, _ = info["difficulty"].(float64)
+	}
+
+	if err := readConnections(client, node.NodeID, status); err != nil {
+		return nil, err
+	}
+
+	// getnetworkhashps takes the algorithm after the block count and height
+	if hashps, err := client.CallRPC(node.NodeID, "getnetworkhashps", []interface{}{120, -1, algo}); err == nil {
+		status.NetworkHashrate, _ = hashps.(float64)
+	}
+	return status, nil
+}
+
+// moneroRPCPath is the JSON-RPC endpoint of monerod
+const moneroRPCPath = "/json_rpc"
+
+// moneroNodeAdapter reads monerod, whose JSON-RPC interface has get_info
+// instead of the Bitcoin methods. Wallet balances live in monero-wallet-rpc,
+// so the card has no balance.
+type moneroNodeAdapter struct{}
+
+// getInfo calls get_info on monerod
+func (moneroNodeAdapter) getInfo(client *RPCClient, nodeID string) (map[string]interface{}, error) {
+	result, err := client.CallRPCPath(nodeID, moneroRPCPath, "get_info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching info for %s: %w", nodeID, err)
+	}
+	info, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected get_info result from %s", nodeID)
+	}
+	return info, nil
+}
+
+func (a moneroNodeAdapter) FetchCard(client *RPCClient, node NodeConfig) (*NodeCard, error) {
+	info, err := a.getInfo(client, node.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeCard{BlockchainInfo: info, NetworkInfo: info}, nil
+}
+
+func (a moneroNodeAdapter) FetchStatus(client *RPCClient, node NodeConfig) (*NodeStatus, error) {
+	info, err := a.getInfo(client, node.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	status := &NodeStatus{}
+	status.Chain, _ = info["nettype"].(string)
+	if height, ok := info["height"].(float64); ok {
+		status.BlockHeight = int(height)
+	}
+	status.Difficulty, _ = info["difficulty"].(float64)
+	in, _ := info["incoming_connections_count"].(float64)
+	out, _ := info["outgoing_connections_count"].(float64)
+	status.Connections = int(in + out)
+	// The network hashrate is the difficulty over the block target in seconds
+	if target, ok := info["target"].(float64); ok && target > 0 {
+		status.NetworkHashrate = status.Difficulty / target
+	}
+	return status, nil
+}
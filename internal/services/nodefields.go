@@ -0,0 +1,120 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// GenericNodeType is the catalog entry used for node types without their own field set
+const GenericNodeType = "generic"
+
+// bitcoinNodeFields returns the card fields of a Bitcoin Core derived node,
+// with the difficulty read from the given RPC field
+func bitcoinNodeFields(difficulty ...map[string]string) config.NodeDisplayFields {
+	blockchain := []map[string]string{
+		{"chain": "Chain"},
+		{"blocks": "Blocks"},
+		{"headers": "Headers"},
+		{"size_on_disk": "Size on Disk"},
+		{"mediantime": "Median Time"},
+		{"pruned": "Pruned"},
+		{"verificationprogress": "Verification"},
+		{"initialblockdownload": "Initializing"},
+		{"warnings": "Warnings"},
+	}
+	return config.NodeDisplayFields{
+		{"Block Chain Info": append(blockchain, difficulty...)},
+		{"Network Info": {
+			{"version": "Version"},
+			{"subversion": "Subversion"},
+			{"protocolversion": "Protocol"},
+			{"networkactive": "Active"},
+			{"warnings": "Warnings"},
+			{"connections": "Connections"},
+			{"connections_in": "In"},
+			{"connections_out": "Out"},
+		}},
+		{"Network Totals": {
+			{"target": "Target"},
+			{"totalbytesrecv": "Received"},
+			{"totalbytessent": "Sent"},
+			{"bytes_left_in_cycle": "Bytes Left"},
+			{"timemillis": "Updated"},
+			{"target_reached": "Target Reached"},
+			{"serve_historical_blocks": "Historicals"},
+			{"timeframe": "Cycle Time"},
+			{"time_left_in_cycle": "Time Left"},
+		}},
+		{"Wallet Info": {
+			{"balance": "Balance"},
+		}},
+	}
+}
+
+// nodeDisplayFieldCatalog holds the built-in card fields per node type
+var nodeDisplayFieldCatalog = map[string]config.NodeDisplayFields{
+	"btc": bitcoinNodeFields(map[string]string{"difficulty": "Difficulty"}),
+	"ltc": bitcoinNodeFields(map[string]string{"difficulty": "Difficulty"}),
+	// DigiByte mines with several algorithms, each with its own difficulty
+	"dgb": bitcoinNodeFields(
+		map[string]string{"difficulties/sha256d": "SHA256d Difficulty"},
+		map[string]string{"difficulties/scrypt": "Scrypt Difficulty"},
+	),
+	// Monero daemons report get_info fields
+	"xmr": {
+		{"Block Chain Info": {
+			{"nettype": "Network"},
+			{"height": "Height"},
+			{"target_height": "Target Height"},
+			{"difficulty": "Difficulty"},
+			{"database_size": "Database Size"},
+			{"synchronized": "Synchronized"},
+			{"busy_syncing": "Syncing"},
+			{"status": "Status"},
+		}},
+		{"Network Info": {
+			{"version": "Version"},
+			{"incoming_connections_count": "In"},
+			{"outgoing_connections_count": "Out"},
+			{"white_peerlist_size": "White Peers"},
+			{"grey_peerlist_size": "Grey Peers"},
+		}},
+	},
+	GenericNodeType: bitcoinNodeFields(
+		map[string]string{"difficulty": "Difficulty"},
+		map[string]string{"difficulties/sha256d": "SHA256d Difficulty"},
+	),
+}
+
+// DefaultNodeDisplayFields returns the built-in card fields of a node type,
+// falling back to the generic set
+func DefaultNodeDisplayFields(nodeType string) config.NodeDisplayFields {
+	if fields, ok := nodeDisplayFieldCatalog[config.NormalizeNodeType(nodeType)]; ok {
+		return fields
+	}
+	return nodeDisplayFieldCatalog[GenericNodeType]
+}
+
+// NodeDisplayFieldTypes returns the node types with built-in card fields, sorted
+func NodeDisplayFieldTypes() []string {
+	types := make([]string, 0, len(nodeDisplayFieldCatalog))
+	for nodeType := range nodeDisplayFieldCatalog {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ResolveNodeDisplayFields returns the card fields of a node type: a
+// customization in node_display_fields, then the NodeDisplayFields entry of
+// cryptoNodes (which applies to every node), then the built-in defaults
+func ResolveNodeDisplayFields(cfg *config.Config, nodeType string, legacy interface{}) interface{} {
+	if fields, ok := cfg.NodeDisplayFields[config.NormalizeNodeType(nodeType)]; ok {
+		return fields
+	}
+	if legacy != nil {
+		return legacy
+	}
+	return DefaultNodeDisplayFields(nodeType)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+)
+
+// Confirmation targets, in blocks, of the fee estimates
+const (
+	FeeTargetFast   = 2   // Next blocks (Bitcoin Core estimates no faster than 2)
+	FeeTargetMedium = 6   // About an hour
+	FeeTargetSlow   = 144 // About a day
+)
+
+// btcPerKvBToSatPerVByte converts the BTC/kvB rates of the fee RPCs to sat/vB
+const btcPerKvBToSatPerVByte = 1e8 / 1000
+
+// NodeMempool is the mempool of a crypto node with its fee estimates. Fee
+// rates are in sat/vB; an estimate is 0 while the node lacks the data.
+type NodeMempool struct {
+	Transactions int     `json:"transactions"`
+	Bytes        int64   `json:"bytes"`      // Virtual size of the transactions
+	Usage        int64   `json:"usage"`      // Memory used by the mempool
+	MaxBytes     int64   `json:"maxBytes"`   // Memory limit (maxmempool)
+	MinFeeRate   float64 `json:"minFeeRate"` // Lowest fee rate the mempool accepts
+	FeeFast      float64 `json:"feeFast"`    // FeeTargetFast blocks
+	FeeMedium    float64 `json:"feeMedium"`  // FeeTargetMedium blocks
+	FeeSlow      float64 `json:"feeSlow"`    // FeeTargetSlow blocks
+}
+
+// MempoolAdapter is implemented by node adapters that can read the mempool
+type MempoolAdapter interface {
+	FetchMempool(client *RPCClient, node NodeConfig) (*NodeMempool, error)
+}
+
+// FetchNodeMempool reads the mempool of a node with the adapter of its type
+func FetchNodeMempool(client *RPCClient, node NodeConfig) (*NodeMempool, error) {
+	adapter, ok := GetNodeAdapter(node.NodeType).(MempoolAdapter)
+	if !ok {
+		return nil, fmt.Errorf("node type %q does not report a mempool", node.NodeType)
+	}
+	return adapter.FetchMempool(client, node)
+}
+
+// FetchMempool reads getmempoolinfo and estimatesmartfee at each fee target
+func (bitcoinNodeAdapter) FetchMempool(client *RPCClient, node NodeConfig) (*NodeMempool, error) {
+	info, err := rpcMap(client, node.NodeID, "getmempoolinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mempool info: %w", err)
+	}
+	mempool := &NodeMempool{}
+	if size, ok := info["size"].(float64); ok {
+		mempool.Transactions = int(size)
+	}
+	if bytes, ok := info["bytes"].(float64); ok {
+		mempool.Bytes = int64(bytes)
+	}
+	if usage, ok := info["usage"].(float64); ok {
+		mempool.Usage = int64(usage)
+	}
+	if maxBytes, ok := info["maxmempool"].(float64); ok {
+		mempool.MaxBytes = int64(maxBytes)
+	}
+	if minFee, ok := info["mempoolminfee"].(float64); ok {
+		mempool.MinFeeRate = minFee * btcPerKvBToSatPerVByte
+	}
+
+	for _, estimate := range []struct {
+		target int
+		rate   *float64
+	}{
+		{FeeTargetFast, &mempool.FeeFast},
+		{FeeTargetMedium, &mempool.FeeMedium},
+		{FeeTargetSlow, &mempool.FeeSlow},
+	} {
+		// Without enough data the node answers with errors and no feerate
+		result, err := rpcMap(client, node.NodeID, "estimatesmartfee", estimate.target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate the fee for %d blocks: %w", estimate.target, err)
+		}
+		if feerate, ok := result["feerate"].(float64); ok {
+			*estimate.rate = feerate * btcPerKvBToSatPerVByte
+		}
+	}
+	return mempool, nil
+}
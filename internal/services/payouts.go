@@ -0,0 +1,167 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+const (
+	// maxPayoutPages bounds how far back the transactions of an address are read
+	maxPayoutPages = 10
+	// nodePayoutPageSize is the number of wallet transactions read per call
+	nodePayoutPageSize = 100
+)
+
+// mempoolTx is a transaction of the mempool/esplora address API
+type mempoolTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		IsCoinbase bool `json:"is_coinbase"`
+	} `json:"vin"`
+	Vout []struct {
+		Address string `json:"scriptpubkey_address"`
+		Value   int64  `json:"value"` // sats
+	} `json:"vout"`
+	Status struct {
+		Confirmed   bool  `json:"confirmed"`
+		BlockHeight int   `json:"block_height"`
+		BlockTime   int64 `json:"block_time"`
+	} `json:"status"`
+}
+
+// FetchPayouts reads the confirmed payments to a payout address, newest
+// first, with the address's source. Reading goes back page by page until
+// known reports a transaction that is already stored, or maxPayoutPages.
+func FetchPayouts(cfg *config.Config, rpcClient *RPCClient, payout config.PayoutAddress, known func(txid string) bool) ([]*database.Payout, error) {
+	if payout.SourceOrDefault() == config.PayoutSourceNode {
+		return fetchNodePayouts(rpcClient, payout, known)
+	}
+	return fetchMempoolPayouts(cfg, payout, known)
+}
+
+// fetchMempoolPayouts reads the confirmed transactions of an address from a
+// mempool API, 25 per page
+func fetchMempoolPayouts(cfg *config.Config, payout config.PayoutAddress, known func(txid string) bool) ([]*database.Payout, error) {
+	base := payout.MempoolURL() + "/api/address/" + url.PathEscape(payout.Address) + "/txs/chain"
+
+	var payouts []*database.Payout
+	lastSeen := ""
+	for page := 0; page < maxPayoutPages; page++ {
+		target := base
+		if lastSeen != "" {
+			target += "/" + url.PathEscape(lastSeen)
+		}
+		body, err := fetchMiningCore(cfg, target)
+		if err != nil {
+			return nil, err
+		}
+		var txs []mempoolTx
+		if err := json.Unmarshal(body, &txs); err != nil {
+			return nil, fmt.Errorf("failed to parse mempool response: %w", err)
+		}
+
+		reachedKnown := false
+		for _, tx := range txs {
+			if known(tx.TxID) {
+				reachedKnown = true
+				break
+			}
+			if !tx.Status.Confirmed {
+				continue
+			}
+			var amount int64
+			for _, out := range tx.Vout {
+				if out.Address == payout.Address {
+					amount += out.Value
+				}
+			}
+			if amount <= 0 {
+				continue // Spent from the address
+			}
+			payouts = append(payouts, &database.Payout{
+				Address:     payout.Address,
+				TxID:        tx.TxID,
+				AmountSats:  amount,
+				Coinbase:    len(tx.Vin) > 0 && tx.Vin[0].IsCoinbase,
+				BlockHeight: tx.Status.BlockHeight,
+				Timestamp:   time.Unix(tx.Status.BlockTime, 0).UTC(),
+			})
+		}
+		if reachedKnown || len(txs) < 25 {
+			break
+		}
+		lastSeen = txs[len(txs)-1].TxID
+	}
+	return payouts, nil
+}
+
+// fetchNodePayouts reads the payments to an address from the wallet of a
+// crypto node. The wallet must hold the address, for example imported as
+// watch-only.
+func fetchNodePayouts(rpcClient *RPCClient, payout config.PayoutAddress, known func(txid string) bool) ([]*database.Payout, error) {
+	if rpcClient == nil {
+		return nil, fmt.Errorf("no crypto nodes are configured")
+	}
+
+	var payouts []*database.Payout
+	byTx := map[string]*database.Payout{}
+	for page := 0; page < maxPayoutPages; page++ {
+		result, err := rpcClient.CallRPC(payout.Node, "listtransactions", []interface{}{"*", nodePayoutPageSize, page * nodePayoutPageSize, true})
+		if err != nil {
+			return nil, err
+		}
+		entries, ok := result.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected listtransactions result")
+		}
+
+		// Entries are oldest first; read the page from the newest
+		reachedKnown := false
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry, _ := entries[i].(map[string]interface{})
+			address, _ := entry["address"].(string)
+			if address != payout.Address {
+				continue
+			}
+			txid, _ := entry["txid"].(string)
+			if known(txid) {
+				reachedKnown = true
+				break
+			}
+			category, _ := entry["category"].(string)
+			confirmations, _ := entry["confirmations"].(float64)
+			if (category != "receive" && category != "generate" && category != "immature") || confirmations < 1 {
+				continue
+			}
+
+			amount, _ := entry["amount"].(float64)
+			sats := int64(math.Round(amount * 1e8))
+			if existing := byTx[txid]; existing != nil {
+				existing.AmountSats += sats // Another output of the transaction
+				continue
+			}
+			height, _ := entry["blockheight"].(float64)
+			blockTime, _ := entry["blocktime"].(float64)
+			p := &database.Payout{
+				Address:     payout.Address,
+				TxID:        txid,
+				AmountSats:  sats,
+				Coinbase:    category != "receive",
+				BlockHeight: int(height),
+				Timestamp:   time.Unix(int64(blockTime), 0).UTC(),
+			}
+			byTx[txid] = p
+			payouts = append(payouts, p)
+		}
+		if reachedKnown || len(entries) < nodePayoutPageSize {
+			break
+		}
+	}
+	return payouts, nil
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// PoolWorker is one worker of a payout address on a public pool
+type PoolWorker struct {
+	Name           string     `json:"name"`
+	Hashrate       float64    `json:"hashrate"` // H/s
+	BestDifficulty float64    `json:"bestDifficulty,omitempty"`
+	LastShare      *time.Time `json:"lastShare,omitempty"`
+}
+
+// PoolStats are the stats of a payout address on a public pool, normalized
+// from the pool's own API
+type PoolStats struct {
+	Hashrate          float64      // H/s of all workers
+	Workers           []PoolWorker // Sorted by name
+	BestDifficulty    float64
+	LastShare         *time.Time
+	NetworkHashrate   float64 // H/s, when the pool reports it
+	NetworkDifficulty float64
+	BlocksFound       int
+	PayoutScheme      string // e.g. SOLO or TIDES
+}
+
+// PoolAdapter reads the stats of a payout address from a type of public pool
+type PoolAdapter interface {
+	Type() string       // Type used in public_pools
+	DefaultURL() string // API base URL of the public instance
+	FetchStats(cfg *config.Config, pool config.PublicPool, baseURL string) (*PoolStats, error)
+}
+
+// poolAdapters are the available adapters by pool type
+var poolAdapters = map[string]PoolAdapter{
+	config.PoolTypePublicPool: publicPoolAdapter{},
+	config.PoolTypeCKPool:     ckPoolAdapter{},
+	config.PoolTypeOcean:      oceanAdapter{},
+}
+
+// RegisterPoolAdapter adds an adapter that public_pools entries can then use
+// by its type. It must be called before the configuration is loaded, usually
+// from an init function.
+func RegisterPoolAdapter(adapter PoolAdapter) {
+	config.RegisterPoolType(adapter.Type())
+	poolAdapters[strings.ToLower(adapter.Type())] = adapter
+}
+
+// FetchPublicPool reads the stats of a public pool's payout address.
+// Responses share the Mining Core cache, so the systems API and the
+// scheduler do not query the pool twice within mining_core_cache_seconds.
+func FetchPublicPool(cfg *config.Config, pool config.PublicPool) (*PoolStats, error) {
+	adapter, ok := poolAdapters[strings.ToLower(pool.Type)]
+	if !ok {
+		return nil, fmt.Errorf("unknown pool type %q", pool.Type)
+	}
+	baseURL := pool.URL
+	if baseURL == "" {
+		baseURL = adapter.DefaultURL()
+	}
+	stats, err := adapter.FetchStats(cfg, pool, strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(stats.Workers, func(i, j int) bool { return stats.Workers[i].Name < stats.Workers[j].Name })
+	return stats, nil
+}
+
+// Metric returns the stats as a pool metric: the pool hashrate and workers
+// are those of the payout address
+func (s *PoolStats) Metric(poolName string) *database.PoolMetric {
+	return &database.PoolMetric{
+		Timestamp:         time.Now(),
+		PoolID:            poolName,
+		PoolName:          poolName,
+		PoolHashrate:      s.Hashrate,
+		PoolWorkers:       len(s.Workers),
+		NetworkHashrate:   s.NetworkHashrate,
+		NetworkDifficulty: s.NetworkDifficulty,
+		BlocksFound:       s.BlocksFound,
+	}
+}
+
+// SystemsInfo returns the stats shaped like a Mining Core pool, so the
+// dashboard shows them with the same display fields
+func (s *PoolStats) SystemsInfo(pool config.PublicPool) map[string]interface{} {
+	info := map[string]interface{}{
+		"id":      pool.Name,
+		"type":    pool.Type,
+		"address": pool.Address,
+		"coin":    map[string]interface{}{"symbol": "BTC"},
+		"paymentProcessing": map[string]interface{}{
+			"payoutScheme": s.PayoutScheme,
+		},
+		"poolStats": map[string]interface{}{
+			"connectedMiners": len(s.Workers),
+			"poolHashrate":    s.Hashrate,
+		},
+		"bestDifficulty": s.BestDifficulty,
+		"totalBlocks":    s.BlocksFound,
+		"workers":        s.Workers,
+	}
+	if s.LastShare != nil {
+		info["lastShare"] = s.LastShare
+	}
+	if s.NetworkHashrate > 0 || s.NetworkDifficulty > 0 {
+		info["networkStats"] = map[string]interface{}{
+			"networkHashrate":   s.NetworkHashrate,
+			"networkDifficulty": s.NetworkDifficulty,
+		}
+	}
+	return info
+}
+
+// getPoolJSON fetches a pool API URL and decodes the JSON response
+func getPoolJSON(cfg *config.Config, pool config.PublicPool, target string, v interface{}) error {
+	body, err := fetchPoolAPI(cfg, target, pool.Name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse pool response: %w", err)
+	}
+	return nil
+}
+
+// poolNumber reads a number that pools send as a JSON number or as a string
+// such as "1.2T"; anything else is zero
+func poolNumber(value interface{}) float64 {
+	n, err := ParseDifficulty(value)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// poolTime reads a Unix time in seconds or milliseconds, or an RFC 3339 time
+func poolTime(value interface{}) *time.Time {
+	var t time.Time
+	switch v := value.(type) {
+	case float64:
+		if v <= 0 {
+			return nil
+		}
+		if v > 1e12 {
+			t = time.UnixMilli(int64(v))
+		} else {
+			t = time.Unix(int64(v), 0)
+		}
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if n := poolNumber(v); n > 0 {
+				return poolTime(n)
+			}
+			return nil
+		}
+		t = parsed
+	default:
+		return nil
+	}
+	return &t
+}
+
+// publicPoolAdapter reads public-pool.io and self-hosted public-pool instances
+type publicPoolAdapter struct{}
+
+func (publicPoolAdapter) Type() string       { return config.PoolTypePublicPool }
+func (publicPoolAdapter) DefaultURL() string { return "https://public-pool.io:40557" }
+
+func (publicPoolAdapter) FetchStats(cfg *config.Config, pool config.PublicPool, baseURL string) (*PoolStats, error) {
+	var client struct {
+		BestDifficulty interface{} `json:"bestDifficulty"`
+		Workers        []struct {
+			Name           string      `json:"name"`
+			SessionID      string      `json:"sessionId"`
+			BestDifficulty interface{} `json:"bestDifficulty"`
+			HashRate       interface{} `json:"hashRate"`
+			LastSeen       interface{} `json:"lastSeen"`
+		} `json:"workers"`
+	}
+	if err := getPoolJSON(cfg, pool, baseURL+"/api/client/"+url.PathEscape(pool.Address), &client); err != nil {
+		return nil, err
+	}
+
+	stats := &PoolStats{BestDifficulty: poolNumber(client.BestDifficulty), PayoutScheme: "SOLO"}
+	for _, w := range client.Workers {
+		name := w.Name
+		if name == "" {
+			name = w.SessionID
+		}
+		worker := PoolWorker{
+			Name:           name,
+			Hashrate:       poolNumber(w.HashRate),
+			BestDifficulty: poolNumber(w.BestDifficulty),
+			LastShare:      poolTime(w.LastSeen),
+		}
+		stats.Hashrate += worker.Hashrate
+		if worker.LastShare != nil && (stats.LastShare == nil || worker.LastShare.After(*stats.LastShare)) {
+			stats.LastShare = worker.LastShare
+		}
+		stats.Workers = append(stats.Workers, worker)
+	}
+
+	// Network stats are optional; the address stats are still useful without them
+	var network map[string]interface{}
+	if err := getPoolJSON(cfg, pool, baseURL+"/api/network", &network); err == nil {
+		stats.NetworkHashrate = poolNumber(network["networkhashps"])
+		stats.NetworkDifficulty = poolNumber(network["difficulty"])
+	}
+	return stats, nil
+}
+
+// ckPoolAdapter reads CKPool solo user stats
+type ckPoolAdapter struct{}
+
+func (ckPoolAdapter) Type() string       { return config.PoolTypeCKPool }
+func (ckPoolAdapter) DefaultURL() string { return "https://solo.ckpool.org" }
+
+func (ckPoolAdapter) FetchStats(cfg *config.Config, pool config.PublicPool, baseURL string) (*PoolStats, error) {
+	var user struct {
+		Hashrate5m interface{} `json:"hashrate5m"`
+		LastShare  interface{} `json:"lastshare"`
+		BestEver   interface{} `json:"bestever"`
+		Workers    []struct {
+			Name       string      `json:"workername"`
+			Hashrate5m interface{} `json:"hashrate5m"`
+			LastShare  interface{} `json:"lastshare"`
+			BestEver   interface{} `json:"bestever"`
+		} `json:"worker"`
+	}
+	if err := getPoolJSON(cfg, pool, baseURL+"/users/"+url.PathEscape(pool.Address), &user); err != nil {
+		return nil, err
+	}
+
+	stats := &PoolStats{
+		Hashrate:       poolNumber(user.Hashrate5m),
+		BestDifficulty: poolNumber(user.BestEver),
+		LastShare:      poolTime(user.LastShare),
+		PayoutScheme:   "SOLO",
+	}
+	for _, w := range user.Workers {
+		stats.Workers = append(stats.Workers, PoolWorker{
+			Name:           w.Name,
+			Hashrate:       poolNumber(w.Hashrate5m),
+			BestDifficulty: poolNumber(w.BestEver),
+			LastShare:      poolTime(w.LastShare),
+		})
+	}
+	return stats, nil
+}
+
+// oceanAdapter reads OCEAN user stats
+type oceanAdapter struct{}
+
+func (oceanAdapter) Type() string       { return config.PoolTypeOcean }
+func (oceanAdapter) DefaultURL() string { return "https://api.ocean.xyz" }
+
+func (oceanAdapter) FetchStats(cfg *config.Config, pool config.PublicPool, baseURL string) (*PoolStats, error) {
+	address := url.PathEscape(pool.Address)
+
+	var snap struct {
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := getPoolJSON(cfg, pool, baseURL+"/v1/statsnap/"+address, &snap); err != nil {
+		return nil, err
+	}
+	stats := &PoolStats{
+		Hashrate:     poolNumber(snap.Result["hashrate_300s"]),
+		LastShare:    poolTime(snap.Result["lastest_share_ts"]),
+		PayoutScheme: "TIDES",
+	}
+
+	// Per-worker hashrates are optional; the address totals are still useful without them
+	var full struct {
+		Result struct {
+			Workers interface{} `json:"workers"`
+		} `json:"result"`
+	}
+	if err := getPoolJSON(cfg, pool, baseURL+"/v1/user_hashrate_full/"+address, &full); err == nil {
+		stats.Workers = oceanWorkers(full.Result.Workers)
+	}
+	return stats, nil
+}
+
+// oceanWorkers reads the workers of a user_hashrate_full response, which
+// maps worker names to their stats (or lists of such maps)
+func oceanWorkers(value interface{}) []PoolWorker {
+	var workers []PoolWorker
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			workers = append(workers, oceanWorkers(item)...)
+		}
+	case map[string]interface{}:
+		for name, data := range v {
+			if list, ok := data.([]interface{}); ok && len(list) > 0 {
+				data = list[0]
+			}
+			fields, ok := data.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			workers = append(workers, PoolWorker{
+				Name:      name,
+				Hashrate:  poolNumber(fields["hashrate_300s"]),
+				LastShare: poolTime(fields["lastest_share_ts"]),
+			})
+		}
+	}
+	return workers
+}
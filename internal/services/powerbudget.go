@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+)
+
+// deviceModelFields are the system info fields a device's model is read
+// from, in the order they are matched against power_budget models
+var deviceModelFields = []string{"deviceModel", "boardVersion", "ASICModel"}
+
+// deviceModelMaxAge is how old collected system info may be to read a model from
+const deviceModelMaxAge = time.Hour
+
+// DeviceBudget is the latest power draw of a device against its budget
+type DeviceBudget struct {
+	InstanceID  string    `json:"instanceId"`
+	Timestamp   time.Time `json:"timestamp"`
+	Power       float64   `json:"power"`                 // W
+	Efficiency  float64   `json:"efficiency,omitempty"`  // J/TH; 0 while the device is not hashing
+	BudgetWatts float64   `json:"budgetWatts,omitempty"` // Nominal wattage; 0 when the device has none
+	BudgetBy    string    `json:"budgetBy,omitempty"`    // Instance name or model the budget was found by
+	LimitWatts  float64   `json:"limitWatts,omitempty"`  // Budget plus the margin
+	Over        bool      `json:"over"`
+}
+
+// PowerBudgetReading is the power draw of every device and the fleet
+type PowerBudgetReading struct {
+	Devices       []DeviceBudget `json:"devices"`
+	FleetWatts    float64        `json:"fleetWatts"`
+	FleetCapWatts float64        `json:"fleetCapWatts,omitempty"`
+	OverCap       bool           `json:"overCap"`
+}
+
+// DeviceModels returns the model fields of a device's last collected system
+// info, in the order of deviceModelFields
+func DeviceModels(instanceID string) []string {
+	body, _, ok := LatestDeviceInfo(instanceID, deviceModelMaxAge)
+	if !ok {
+		return nil
+	}
+	var info map[string]interface{}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil
+	}
+	var models []string
+	for _, field := range deviceModelFields {
+		if model, ok := info[field].(string); ok && model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// ReadPowerBudget judges the latest metric of each device against its
+// nominal wattage plus the margin, and the fleet's total against the cap.
+// Devices without a metric are left out.
+func ReadPowerBudget(budget *config.PowerBudget, instances []string, metrics map[string]*database.AxeOSMetric) *PowerBudgetReading {
+	reading := &PowerBudgetReading{Devices: []DeviceBudget{}}
+	if budget != nil {
+		reading.FleetCapWatts = budget.FleetCapWatts
+	}
+	for _, id := range instances {
+		metric, ok := metrics[id]
+		if !ok || metric == nil {
+			continue
+		}
+		device := DeviceBudget{InstanceID: id, Timestamp: metric.Timestamp, Power: metric.Power}
+		if metric.Hashrate > 0 {
+			device.Efficiency = metric.Power / (metric.Hashrate / 1000) // GH/s to TH/s
+		}
+		device.BudgetWatts, device.BudgetBy = budget.BudgetOf(id, DeviceModels(id))
+		if device.BudgetWatts > 0 {
+			device.LimitWatts = device.BudgetWatts * (1 + budget.Margin()/100)
+			device.Over = metric.Power > device.LimitWatts
+		}
+		reading.FleetWatts += metric.Power
+		reading.Devices = append(reading.Devices, device)
+	}
+	reading.OverCap = reading.FleetCapWatts > 0 && reading.FleetWatts > reading.FleetCapWatts
+	return reading
+}
+
+// Device returns the reading of one device, or nil
+func (r *PowerBudgetReading) Device(instanceID string) *DeviceBudget {
+	for i := range r.Devices {
+		if r.Devices[i].InstanceID == instanceID {
+			return &r.Devices[i]
+		}
+	}
+	return nil
+}
+
+// ApplyDeviceClock sends a frequency and core voltage to a device and
+// restarts it, as AxeOS applies them after a restart
+func ApplyDeviceClock(cfg *config.Config, instanceID string, frequency, coreVoltage int) error {
+	baseURL := ""
+	for _, instance := range cfg.AxeosInstances {
+		if url, ok := instance[instanceID]; ok {
+			baseURL = url
+		}
+	}
+	if baseURL == "" {
+		return fmt.Errorf("unknown instance %s", instanceID)
+	}
+
+	body, err := json.Marshal(map[string]int{"frequency": frequency, "coreVoltage": coreVoltage})
+	if err != nil {
+		return err
+	}
+	if err := SendDeviceRequest(cfg, instanceID, http.MethodPatch, baseURL+GetAPIPath(cfg, "instanceSettings"), body); err != nil {
+		return err
+	}
+	if err := SendDeviceRequest(cfg, instanceID, http.MethodPost, baseURL+GetAPIPath(cfg, "instanceRestart"), nil); err != nil {
+		return fmt.Errorf("settings applied but restart failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/database"
+	"github.com/scottwalter/axeos-dashboard/internal/live"
+)
+
+// minSupplyTrendDays is how many days with data a supply trend needs
+const minSupplyTrendDays = 7
+
+// SupplyDevice is the latest reading of a device on a power supply
+type SupplyDevice struct {
+	InstanceID string    `json:"instanceId"`
+	Timestamp  time.Time `json:"timestamp"`
+	Voltage    float64   `json:"voltage"` // Input voltage in mV
+	Current    float64   `json:"current"` // Input current in mA
+	Power      float64   `json:"power"`   // W
+	Loaded     bool      `json:"loaded"`  // Hashing when read
+}
+
+// SupplyReading is the state of a power supply from the latest samples of
+// its devices
+type SupplyReading struct {
+	Name         string  `json:"name"`
+	NominalVolts float64 `json:"nominalVolts"`
+	// ThresholdVoltage is the input voltage (mV) below which a hashing
+	// device is browning out
+	ThresholdVoltage float64        `json:"thresholdVoltage"`
+	MinVoltage       float64        `json:"minVoltage"`  // Lowest input voltage (mV) of a hashing device; 0 when none is hashing
+	Power            float64        `json:"power"`       // W drawn by all devices
+	Current          float64        `json:"current"`     // mA drawn by all devices
+	LoadPercent      *float64       `json:"loadPercent"` // Of the rated watts, when configured
+	Brownout         bool           `json:"brownout"`
+	Devices          []SupplyDevice `json:"devices"`
+}
+
+// LatestDeviceMetrics returns the latest collected metric of every device
+func LatestDeviceMetrics() map[string]*database.AxeOSMetric {
+	metrics := map[string]*database.AxeOSMetric{}
+	for id, sample := range live.GetHub().Latest(live.KindAxeOS) {
+		if metric, ok := sample.Metric.(*database.AxeOSMetric); ok {
+			metrics[id] = metric
+		}
+	}
+	return metrics
+}
+
+// ReadSupply judges a power supply from its devices' latest metrics. The
+// supply is browning out when a hashing device's input voltage is more than
+// sagPercent below nominal. Devices without a metric are left out.
+func ReadSupply(supply config.PowerSupply, metrics map[string]*database.AxeOSMetric, sagPercent float64) *SupplyReading {
+	reading := &SupplyReading{Name: supply.Name, Devices: []SupplyDevice{}}
+	highest := 0.0
+	for _, id := range supply.Instances {
+		metric, ok := metrics[id]
+		if !ok || metric == nil {
+			continue
+		}
+		device := SupplyDevice{
+			InstanceID: id,
+			Timestamp:  metric.Timestamp,
+			Voltage:    metric.Voltage,
+			Current:    metric.Current,
+			Power:      metric.Power,
+			Loaded:     metric.Hashrate > 0 && metric.Power > 0 && metric.Voltage > 0,
+		}
+		reading.Power += device.Power
+		reading.Current += device.Current
+		highest = max(highest, device.Voltage)
+		if device.Loaded && (reading.MinVoltage == 0 || device.Voltage < reading.MinVoltage) {
+			reading.MinVoltage = device.Voltage
+		}
+		reading.Devices = append(reading.Devices, device)
+	}
+	sort.Slice(reading.Devices, func(i, j int) bool { return reading.Devices[i].InstanceID < reading.Devices[j].InstanceID })
+
+	// The highest reading sags the least, so it is the best guess of the rail
+	reading.NominalVolts = supply.Nominal(highest)
+	reading.ThresholdVoltage = reading.NominalVolts * 1000 * (1 - sagPercent/100)
+	reading.Brownout = reading.MinVoltage > 0 && reading.MinVoltage < reading.ThresholdVoltage
+	if supply.RatedWatts > 0 {
+		load := reading.Power / supply.RatedWatts * 100
+		reading.LoadPercent = &load
+	}
+	return reading
+}
+
+// SupplyTrend is a linear fit of a power supply's daily input voltage under load
+type SupplyTrend struct {
+	Name         string  `json:"name"`
+	Days         int     `json:"days"` // Days with data in the range
+	NominalVolts float64 `json:"nominalVolts"`
+	// Fitted input voltage (mV) on the first and last day of the range
+	StartVoltage float64 `json:"startVoltage"`
+	EndVoltage   float64 `json:"endVoltage"`
+	// ChangePercent is the fitted change over the range in percent of the
+	// nominal voltage; negative is a drop
+	ChangePercent float64               `json:"changePercent"`
+	RSquared      float64               `json:"rSquared"`  // How well the line fits, 0 to 1
+	Degrading     bool                  `json:"degrading"` // Dropping by more than the threshold
+	Insufficient  bool                  `json:"insufficient,omitempty"`
+	History       []*database.SupplyDay `json:"history"`
+}
+
+// AnalyzeSupply fits a line to the daily input voltage of a supply's devices
+// while hashing, over the days before end. A supply is degrading when its
+// loaded voltage drops by more than degradationPercent of nominal over the
+// range. Fewer than a week of days gives an insufficient trend.
+func AnalyzeSupply(db *database.Manager, supply config.PowerSupply, end time.Time, days int, degradationPercent float64) (*SupplyTrend, error) {
+	today := database.RollupBucket(database.ResolutionDaily, end)
+	start := today.AddDate(0, 0, -days)
+	history, err := db.GetSupplyDays(supply.Instances, start, today)
+	if err != nil {
+		return nil, err
+	}
+
+	trend := &SupplyTrend{Name: supply.Name, Days: len(history), History: history}
+	var xs, ys []float64
+	highest := 0.0
+	for _, day := range history {
+		xs = append(xs, day.Day.Sub(start).Hours()/24)
+		ys = append(ys, day.AvgVoltage)
+		highest = max(highest, day.AvgVoltage)
+	}
+	trend.NominalVolts = supply.Nominal(highest)
+	if len(xs) < minSupplyTrendDays {
+		trend.Insufficient = true
+		return trend, nil
+	}
+
+	slope, intercept, rSquared, ok := linearFit(xs, ys)
+	if !ok {
+		trend.Insufficient = true
+		return trend, nil
+	}
+	trend.StartVoltage = intercept + slope*xs[0]
+	trend.EndVoltage = intercept + slope*xs[len(xs)-1]
+	trend.ChangePercent = (trend.EndVoltage - trend.StartVoltage) / (trend.NominalVolts * 1000) * 100
+	trend.RSquared = rSquared
+	trend.Degrading = -trend.ChangePercent > degradationPercent
+	return trend, nil
+}
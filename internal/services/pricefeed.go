@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// CoinPrice is the latest fiat price of one coin
+type CoinPrice struct {
+	Coin     string       `json:"coin"`
+	Currency string       `json:"currency"`
+	Price    *MarketValue `json:"price"` // Nil until a provider reported a price
+	Error    string       `json:"error,omitempty"`
+}
+
+// PriceFeed keeps the fiat prices of BTC, the configured market coin and
+// market.price_coins up to date in the background
+type PriceFeed struct {
+	cfgManager *config.Manager
+	mu         sync.RWMutex
+	prices     map[string]*CoinPrice
+	cancel     context.CancelFunc
+	done       chan struct{}
+	log        *logger.Logger
+}
+
+var (
+	priceFeed     *PriceFeed
+	priceFeedOnce sync.Once
+)
+
+// GetPriceFeed returns the singleton price feed
+func GetPriceFeed(cfgManager *config.Manager) *PriceFeed {
+	priceFeedOnce.Do(func() {
+		priceFeed = &PriceFeed{
+			cfgManager: cfgManager,
+			prices:     map[string]*CoinPrice{},
+			log:        logger.New(logger.ModuleService),
+		}
+	})
+	return priceFeed
+}
+
+// PriceCoins returns the coins the price feed tracks: BTC, the market coin
+// and market.price_coins, without duplicates
+func PriceCoins(market *config.Market) []string {
+	coins := []string{"BTC"}
+	seen := map[string]bool{"BTC": true}
+	for _, coin := range append([]string{market.Coin}, market.PriceCoins...) {
+		if coin != "" && !seen[coin] {
+			seen[coin] = true
+			coins = append(coins, coin)
+		}
+	}
+	return coins
+}
+
+// Start refreshes the prices now and then every market.cache_ttl_seconds
+// until Stop is called
+func (f *PriceFeed) Start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go f.run(ctx, f.done)
+	f.log.Info("Price feed started")
+}
+
+// Stop ends the background refresh and waits for a refresh in progress
+func (f *PriceFeed) Stop() {
+	f.mu.Lock()
+	cancel, done := f.cancel, f.done
+	f.cancel, f.done = nil, nil
+	f.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (f *PriceFeed) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		f.Refresh(ctx)
+
+		// Read the interval each time so configuration changes apply
+		interval := time.Duration(f.cfgManager.GetConfig().Market.CacheTTLSeconds) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Refresh fetches the price of every tracked coin whose cached price expired
+func (f *PriceFeed) Refresh(ctx context.Context) {
+	cfg := f.cfgManager.GetConfig()
+	prices := map[string]*CoinPrice{}
+	for _, coin := range PriceCoins(cfg.Market) {
+		prices[coin] = fetchPrice(ctx, cfg, coin)
+	}
+
+	f.mu.Lock()
+	f.prices = prices
+	f.mu.Unlock()
+}
+
+// Prices returns the latest price of every tracked coin, in the order of
+// PriceCoins. Coins added since the last refresh have no price yet.
+func (f *PriceFeed) Prices() []CoinPrice {
+	market := f.cfgManager.GetConfig().Market
+	coins := PriceCoins(market)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	prices := make([]CoinPrice, 0, len(coins))
+	for _, coin := range coins {
+		if price := f.prices[coin]; price != nil && price.Currency == market.Currency {
+			prices = append(prices, *price)
+		} else {
+			prices = append(prices, CoinPrice{Coin: coin, Currency: market.Currency})
+		}
+	}
+	return prices
+}
+
+// Price returns the latest price of a coin, or nil when the coin is not tracked
+func (f *PriceFeed) Price(coin string) *CoinPrice {
+	for _, price := range f.Prices() {
+		if price.Coin == coin {
+			return &price
+		}
+	}
+	return nil
+}
+
+// fetchPrice returns the price of a coin from the first configured provider
+// that reports prices and succeeds, falling back to the last known price when
+// all of them fail. Responses share the market data cache, so the feed and
+// GetMarketData do not fetch the same price twice.
+func fetchPrice(ctx context.Context, cfg *config.Config, coin string) *CoinPrice {
+	market := cfg.Market
+	ttl := time.Duration(market.CacheTTLSeconds) * time.Second
+	result := &CoinPrice{Coin: coin, Currency: market.Currency}
+
+	marketCacheMu.Lock()
+	defer marketCacheMu.Unlock()
+
+	var stale *MarketValue
+	for _, name := range market.Providers {
+		provider := marketProviders[name]
+		if provider == nil || !providesField(provider, MarketFieldPrice) {
+			continue
+		}
+		state := refreshMarketProvider(ctx, cfg, provider, coin, market.Currency, ttl)
+		if state.err != nil && result.Error == "" {
+			result.Error = name + ": " + state.err.Error()
+		}
+		if state.quote == nil || state.quote.Price <= 0 {
+			continue
+		}
+		if time.Since(state.fetchedAt) < ttl {
+			result.Price = &MarketValue{Value: state.quote.Price, Source: name, UpdatedAt: state.fetchedAt}
+			result.Error = ""
+			return result
+		}
+		if stale == nil && time.Since(state.fetchedAt) <= marketMaxStale {
+			stale = &MarketValue{Value: state.quote.Price, Source: name, UpdatedAt: state.fetchedAt, Stale: true}
+		}
+	}
+	result.Price = stale
+	if result.Price == nil && result.Error == "" {
+		result.Error = "no price provider is configured for " + coin
+	}
+	return result
+}
+
+// providesField reports whether a provider can report a market field
+func providesField(provider MarketProvider, field string) bool {
+	for _, f := range provider.Provides() {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
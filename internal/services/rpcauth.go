@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RPC authentication modes of a node in rpcConfig.json
+const (
+	RPCAuthPassword = "password" // NodeRPAuth holds "user:password"
+	RPCAuthCookie   = "cookie"   // Credentials are read from the node's .cookie file
+	RPCAuthRPCAuth  = "rpcauth"  // User and password checked against an rpcauth line
+)
+
+// AuthMode returns the authentication mode of a node: NodeRPCAuthMode, or
+// cookie when only a cookie file is set and password otherwise
+func (n *RPCNodeConfig) AuthMode() string {
+	if mode := strings.ToLower(strings.TrimSpace(n.NodeRPCAuthMode)); mode != "" {
+		return mode
+	}
+	if n.NodeRPCCookieFile != "" && n.NodeRPAuth == "" {
+		return RPCAuthCookie
+	}
+	return RPCAuthPassword
+}
+
+// credentials returns the "user:password" sent with basic authentication.
+// Cookie files are read on every call, as the node writes a new cookie each
+// time it starts; relative paths are resolved against the config directory.
+func (n *RPCNodeConfig) credentials(configDir string) (string, error) {
+	switch n.AuthMode() {
+	case RPCAuthPassword:
+		return n.NodeRPAuth, nil
+
+	case RPCAuthCookie:
+		if n.NodeRPCCookieFile == "" {
+			return "", fmt.Errorf("node %s uses cookie authentication but has no NodeRPCCookieFile", n.NodeID)
+		}
+		path := n.NodeRPCCookieFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(configDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the cookie file of node %s: %w", n.NodeID, err)
+		}
+		cookie := strings.TrimSpace(string(data))
+		if !strings.Contains(cookie, ":") {
+			return "", fmt.Errorf("cookie file of node %s is not in user:password form", n.NodeID)
+		}
+		return cookie, nil
+
+	case RPCAuthRPCAuth:
+		if err := CheckRPCAuth(n.NodeRPCAuthLine, n.NodeRPCUser, n.NodeRPCPassword); err != nil {
+			return "", fmt.Errorf("node %s: %w", n.NodeID, err)
+		}
+		return n.NodeRPCUser + ":" + n.NodeRPCPassword, nil
+	}
+	return "", fmt.Errorf("node %s has unknown NodeRPCAuthMode %q (use password, cookie or rpcauth)", n.NodeID, n.NodeRPCAuthMode)
+}
+
+// CheckRPCAuth checks a user and password against an rpcauth line from
+// bitcoin.conf ("user:salt$hash", with or without "rpcauth="), where hash is
+// the hex HMAC-SHA256 of the password keyed with the salt. It catches a
+// password that does not match before the node rejects it.
+func CheckRPCAuth(line, user, password string) error {
+	line = strings.TrimPrefix(strings.TrimSpace(line), "rpcauth=")
+	lineUser, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return fmt.Errorf("rpcauth line must be user:salt$hash")
+	}
+	salt, hash, ok := strings.Cut(rest, "$")
+	if !ok || salt == "" || hash == "" {
+		return fmt.Errorf("rpcauth line must be user:salt$hash")
+	}
+	if user != lineUser {
+		return fmt.Errorf("rpcauth line is for user %q, not %q", lineUser, user)
+	}
+	expected, err := hex.DecodeString(hash)
+	if err != nil {
+		return fmt.Errorf("rpcauth hash is not hex")
+	}
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(password))
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("password does not match the rpcauth line")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// rpcCacheEntry is a cached RPC result, kept encoded so every caller decodes
+// its own copy; done is set while the call is in flight
+type rpcCacheEntry struct {
+	result    []byte
+	err       error
+	fetchedAt time.Time
+	done      chan struct{}
+}
+
+var (
+	rpcCache   = map[string]*rpcCacheEntry{}
+	rpcCacheMu sync.Mutex
+)
+
+// rpcCacheKey identifies a call by node, endpoint path, method and params
+func rpcCacheKey(nodeID, path, method string, params interface{}) (string, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return nodeID + "\x00" + path + "\x00" + method + "\x00" + string(encoded), nil
+}
+
+// cachedCall returns the result of an RPC call through the shared cache.
+// Results are shared by every RPCClient, so the systems API and the
+// scheduler reading the same node within rpc_cache_seconds make one call,
+// and concurrent identical calls wait for a single request. A ttl of zero
+// or less only coalesces concurrent calls. Errors are not cached.
+func cachedCall(key string, ttl time.Duration, call func() (interface{}, error)) (interface{}, error) {
+	rpcCacheMu.Lock()
+	entry := rpcCache[key]
+	if entry != nil {
+		if entry.done != nil {
+			// Another caller is making this call; wait for its result
+			done := entry.done
+			rpcCacheMu.Unlock()
+			<-done
+			return decodeRPCResult(entry.result, entry.err)
+		}
+		if entry.err == nil && time.Since(entry.fetchedAt) < ttl {
+			rpcCacheMu.Unlock()
+			return decodeRPCResult(entry.result, nil)
+		}
+	}
+
+	entry = &rpcCacheEntry{done: make(chan struct{})}
+	rpcCache[key] = entry
+	rpcCacheMu.Unlock()
+
+	result, err := call()
+	var encoded []byte
+	if err == nil {
+		encoded, err = json.Marshal(result)
+	}
+
+	rpcCacheMu.Lock()
+	entry.result, entry.err, entry.fetchedAt = encoded, err, time.Now()
+	close(entry.done)
+	entry.done = nil
+	if err != nil || ttl <= 0 {
+		delete(rpcCache, key)
+	}
+	rpcCacheMu.Unlock()
+
+	return result, err
+}
+
+// decodeRPCResult decodes a cached result the way responses are decoded
+func decodeRPCResult(encoded []byte, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
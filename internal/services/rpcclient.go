@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/dependencies"
 	"github.com/scottwalter/axeos-dashboard/internal/logger"
+	"github.com/scottwalter/axeos-dashboard/internal/secrets"
 )
 
 // RPCConfig represents the rpcConfig.json structure
@@ -25,6 +27,19 @@ type RPCNodeConfig struct {
 	NodeRPCAddress string `json:"NodeRPCAddress"`
 	NodeRPCPort    int    `json:"NodeRPCPort"`
 	NodeRPAuth     string `json:"NodeRPAuth"`
+
+	// Authentication other than a static "user:password" (see AuthMode)
+	NodeRPCAuthMode   string `json:"NodeRPCAuthMode,omitempty"`   // password, cookie or rpcauth
+	NodeRPCCookieFile string `json:"NodeRPCCookieFile,omitempty"` // Path of the node's .cookie file
+	NodeRPCUser       string `json:"NodeRPCUser,omitempty"`       // rpcauth user
+	NodeRPCPassword   string `json:"NodeRPCPassword,omitempty"`   // rpcauth password
+	NodeRPCAuthLine   string `json:"NodeRPCAuthLine,omitempty"`   // rpcauth=user:salt$hash from bitcoin.conf
+
+	// Transport (see newHTTPClient)
+	NodeRPCTLS        bool   `json:"NodeRPCTLS,omitempty"`        // Connect with https
+	NodeRPCCACert     string `json:"NodeRPCCACert,omitempty"`     // PEM CA bundle or self-signed certificate to trust
+	NodeRPCCertSHA256 string `json:"NodeRPCCertSHA256,omitempty"` // Pinned SHA-256 fingerprint of the node certificate
+	NodeRPCProxy      string `json:"NodeRPCProxy,omitempty"`      // socks5://host:port, e.g. Tor at 127.0.0.1:9050
 }
 
 // RPCClient handles JSON-RPC calls to cryptocurrency nodes
@@ -32,16 +47,17 @@ type RPCClient struct {
 	configDir string
 	rpcConfig *RPCConfig
 	mu        sync.RWMutex
-	client    *http.Client
+	clients   map[string]nodeHTTPClient // By node ID
+	outbound  *config.Config // User agent, extra headers and cache TTL, if set
 	log       *logger.Logger
 }
 
 // RPCRequest represents a JSON-RPC request
 type RPCRequest struct {
-	JSONRpc string        `json:"jsonrpc"`
-	ID      string        `json:"id"`
-	Method  string        `json:"method"`
-	Params  []interface{} `json:"params"`
+	JSONRpc string      `json:"jsonrpc"`
+	ID      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"` // Positional list or named object
 }
 
 // RPCResponse represents a JSON-RPC response
@@ -61,21 +77,24 @@ type RPCError struct {
 func NewRPCClient(configDir string) *RPCClient {
 	return &RPCClient{
 		configDir: configDir,
-		client: &http.Client{
-			Timeout: 30 * 1000000000, // 30 seconds in nanoseconds
-		},
 		log: logger.New(logger.ModuleService),
 	}
 }
 
+// SetOutboundConfig sets the configuration whose user agent and extra
+// headers are sent with RPC requests
+func (r *RPCClient) SetOutboundConfig(cfg *config.Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outbound = cfg
+}
+
 // loadRPCConfig loads the RPC configuration from rpcConfig.json
 func (r *RPCClient) loadRPCConfig() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	configPath := filepath.Join(r.configDir, "rpcConfig.json")
-
-	data, err := os.ReadFile(configPath)
+	data, err := secrets.Read(r.configDir, "rpcConfig.json")
 	if err != nil {
 		return fmt.Errorf("failed to read rpcConfig.json: %w", err)
 	}
@@ -128,8 +147,38 @@ func (r *RPCClient) GetConfiguredNodes() []string {
 	return nodeIDs
 }
 
-// CallRPC makes a JSON-RPC call to a cryptocurrency node
+// CallRPC makes a JSON-RPC call to a cryptocurrency node and records the
+// outcome for the dependency health of the node
 func (r *RPCClient) CallRPC(nodeID, method string, params []interface{}) (interface{}, error) {
+	return r.CallRPCPath(nodeID, "", method, params)
+}
+
+// CallRPCPath makes a JSON-RPC call to an endpoint path of a node, such as
+// Monero's /json_rpc, with positional or named params (nil sends none).
+// Clients with an outbound config share results for rpc_cache_seconds.
+func (r *RPCClient) CallRPCPath(nodeID, path, method string, params interface{}) (interface{}, error) {
+	call := func() (interface{}, error) {
+		start := time.Now()
+		result, err := r.callRPC(nodeID, path, method, params)
+		dependencies.Record(dependencies.KindNode, nodeID, time.Since(start), err)
+		return result, err
+	}
+
+	r.mu.RLock()
+	outbound := r.outbound
+	r.mu.RUnlock()
+	if outbound == nil {
+		return call()
+	}
+	key, err := rpcCacheKey(nodeID, path, method, params)
+	if err != nil {
+		return call()
+	}
+	return cachedCall(key, outbound.RPCCacheTTL(), call)
+}
+
+// callRPC sends a JSON-RPC request to a node
+func (r *RPCClient) callRPC(nodeID, path, method string, params interface{}) (interface{}, error) {
 	// Ensure config is loaded
 	if r.rpcConfig == nil {
 		if err := r.loadRPCConfig(); err != nil {
@@ -143,6 +192,11 @@ func (r *RPCClient) CallRPC(nodeID, method string, params []interface{}) (interf
 		return nil, err
 	}
 
+	auth, err := nodeConfig.credentials(r.configDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create RPC request
 	rpcReq := RPCRequest{
 		JSONRpc: "2.0",
@@ -157,7 +211,11 @@ func (r *RPCClient) CallRPC(nodeID, method string, params []interface{}) (interf
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("http://%s:%d", nodeConfig.NodeRPCAddress, nodeConfig.NodeRPCPort)
+	client, err := r.httpClient(nodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	url := nodeConfig.endpoint(path)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
@@ -165,14 +223,19 @@ func (r *RPCClient) CallRPC(nodeID, method string, params []interface{}) (interf
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	authEncoded := base64.StdEncoding.EncodeToString([]byte(nodeConfig.NodeRPAuth))
+	authEncoded := base64.StdEncoding.EncodeToString([]byte(auth))
 	req.Header.Set("Authorization", "Basic "+authEncoded)
+	r.mu.RLock()
+	outbound := r.outbound
+	r.mu.RUnlock()
+	if outbound != nil {
+		SetOutboundHeaders(req, outbound)
+	}
 
-	r.log.Info("Sending RPC request to %s:%d - Method: %s",
-		nodeConfig.NodeRPCAddress, nodeConfig.NodeRPCPort, method)
+	r.log.Info("Sending RPC request to %s - Method: %s", url, method)
 
 	// Send request
-	resp, err := r.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("RPC request error: %w", err)
 	}
@@ -186,6 +249,9 @@ func (r *RPCClient) CallRPC(nodeID, method string, params []interface{}) (interf
 
 	// Check for empty response (often indicates auth failure)
 	if len(body) == 0 {
+		if resp.StatusCode == http.StatusUnauthorized && nodeConfig.AuthMode() == RPCAuthCookie {
+			return nil, fmt.Errorf("RPC server rejected the cookie. Check that NodeRPCCookieFile is the cookie of the running node. Status: %d", resp.StatusCode)
+		}
 		return nil, fmt.Errorf("empty response from RPC server. Check RPC credentials (rpcauth) and rpcallowip in node config. Status: %d", resp.StatusCode)
 	}
 
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+// rpcTimeout bounds each RPC request, including the proxy and TLS handshakes
+const rpcTimeout = 30 * time.Second
+
+// endpoint returns the URL of an RPC path on the node. NodeRPCAddress may
+// carry an http:// or https:// scheme; NodeRPCTLS also selects https.
+func (n *RPCNodeConfig) endpoint(path string) string {
+	scheme, host := "http", n.NodeRPCAddress
+	if rest, ok := strings.CutPrefix(host, "https://"); ok {
+		scheme, host = "https", rest
+	} else if rest, ok := strings.CutPrefix(host, "http://"); ok {
+		host = rest
+	}
+	if n.NodeRPCTLS {
+		scheme = "https"
+	}
+	host = strings.Trim(strings.TrimRight(host, "/"), "[]")
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, strconv.Itoa(n.NodeRPCPort)), path)
+}
+
+// transportKey identifies the connection settings of a node, so its client
+// is rebuilt when they change
+func (n *RPCNodeConfig) transportKey() string {
+	return strings.Join([]string{n.NodeRPCCACert, n.NodeRPCCertSHA256, n.NodeRPCProxy}, "\x00")
+}
+
+// newHTTPClient creates the HTTP client of a node: TLS trusting NodeRPCCACert
+// or the certificate pinned by NodeRPCCertSHA256 (for self-signed node
+// certificates), through the SOCKS5 proxy in NodeRPCProxy, if set. Relative
+// certificate paths are resolved against the config directory.
+func (n *RPCNodeConfig) newHTTPClient(configDir string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // Environment proxies are for the dashboard's own traffic
+
+	if n.NodeRPCProxy != "" {
+		proxyURL, err := url.Parse(n.NodeRPCProxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("node %s has an invalid NodeRPCProxy", n.NodeID)
+		}
+		switch proxyURL.Scheme {
+		case "socks5", "socks5h":
+			// Host names are resolved by the proxy, so .onion addresses work
+			proxyURL.Scheme = "socks5"
+		default:
+			return nil, fmt.Errorf("node %s NodeRPCProxy must be a socks5:// URL", n.NodeID)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := config.ClientTLSConfig(n.NodeRPCCACert, n.NodeRPCCertSHA256, configDir)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: %w", n.NodeID, err)
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: rpcTimeout, Transport: transport}, nil
+}
+
+// httpClient returns the cached HTTP client of a node, creating it on first
+// use or when the node's connection settings changed
+func (r *RPCClient) httpClient(node *RPCNodeConfig) (*http.Client, error) {
+	key := node.transportKey()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.clients[node.NodeID]; ok && cached.key == key {
+		return cached.client, nil
+	}
+	client, err := node.newHTTPClient(r.configDir)
+	if err != nil {
+		return nil, err
+	}
+	if r.clients == nil {
+		r.clients = map[string]nodeHTTPClient{}
+	}
+	r.clients[node.NodeID] = nodeHTTPClient{key: key, client: client}
+	return client, nil
+}
+
+// nodeHTTPClient is a node's HTTP client and the settings it was built with
+type nodeHTTPClient struct {
+	key    string
+	client *http.Client
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+	"github.com/scottwalter/axeos-dashboard/internal/logger"
+)
+
+// sshHandshakeTimeout bounds the key exchange and login of a connection
+const sshHandshakeTimeout = 10 * time.Second
+
+// StatusSSH serves the fleet status to SSH clients: an interactive session
+// redraws it every ssh_status.refresh_seconds until q is pressed, and
+// `ssh host status` prints it once. Sessions cannot change anything.
+type StatusSSH struct {
+	cfgManager *config.Manager
+	mu         sync.Mutex
+	listener   net.Listener
+	conns      map[net.Conn]bool
+	wg         sync.WaitGroup
+	log        *logger.Logger
+}
+
+var (
+	statusSSH     *StatusSSH
+	statusSSHOnce sync.Once
+)
+
+// GetStatusSSH returns the singleton SSH status server
+func GetStatusSSH(cfgManager *config.Manager) *StatusSSH {
+	statusSSHOnce.Do(func() {
+		statusSSH = &StatusSSH{
+			cfgManager: cfgManager,
+			conns:      map[net.Conn]bool{},
+			log:        logger.New(logger.ModuleService),
+		}
+	})
+	return statusSSH
+}
+
+// Start listens for SSH clients when ssh_status is enabled. A missing host
+// key is generated.
+func (s *StatusSSH) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		return
+	}
+
+	settings := s.cfgManager.GetConfig().SSHStatus
+	if !settings.IsEnabled() {
+		return
+	}
+	configDir := s.cfgManager.GetConfigDir()
+	signer, err := loadSSHHostKey(settings.HostKeyPath(configDir))
+	if err != nil {
+		s.log.Error("SSH status disabled: %v", err)
+		return
+	}
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			return s.authorize(conn, key)
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", settings.Address())
+	if err != nil {
+		s.log.Error("SSH status disabled: %v", err)
+		return
+	}
+	s.listener = listener
+	s.wg.Add(1)
+	go s.serve(listener, serverConfig)
+	s.log.Info("SSH status listening on %s (host key fingerprint %s)", listener.Addr(), ssh.FingerprintSHA256(signer.PublicKey()))
+}
+
+// Stop closes the listener and every open session
+func (s *StatusSSH) Stop() {
+	s.mu.Lock()
+	listener := s.listener
+	s.listener = nil
+	if listener != nil {
+		listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// authorize accepts keys listed in the authorized keys file, which is read
+// on every login so keys can be added and removed without a restart
+func (s *StatusSSH) authorize(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	path := s.cfgManager.GetConfig().SSHStatus.AuthorizedKeysPath(s.cfgManager.GetConfigDir())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.log.Warn("SSH status login of %s from %s refused: %v", conn.User(), conn.RemoteAddr(), err)
+		return nil, fmt.Errorf("no authorized keys")
+	}
+	for len(data) > 0 {
+		authorized, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			break // No more keys
+		}
+		if bytes.Equal(authorized.Marshal(), key.Marshal()) {
+			return &ssh.Permissions{}, nil
+		}
+		data = rest
+	}
+	s.log.Warn("SSH status login of %s from %s refused: key %s is not authorized", conn.User(), conn.RemoteAddr(), ssh.FingerprintSHA256(key))
+	return nil, fmt.Errorf("unknown public key")
+}
+
+// serve accepts connections until the listener is closed
+func (s *StatusSSH) serve(listener net.Listener, serverConfig *ssh.ServerConfig) {
+	defer s.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.log.Error("SSH status stopped: %v", err)
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.listener == nil {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.handleConn(conn, serverConfig)
+		}()
+	}
+}
+
+// handleConn runs the sessions of one SSH connection
+func (s *StatusSSH) handleConn(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	conn.SetDeadline(time.Now().Add(sshHandshakeTimeout))
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	defer serverConn.Close()
+	s.log.Info("SSH status session of %s from %s", serverConn.User(), serverConn.RemoteAddr())
+
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(channel, channelRequests)
+	}
+}
+
+// handleSession answers the requests of a session: a shell shows the live
+// status, exec of "status" prints it once
+func (s *StatusSSH) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	started := false
+	for req := range requests {
+		switch req.Type {
+		case "pty-req", "window-change", "env":
+			req.Reply(true, nil)
+		case "shell":
+			req.Reply(!started, nil)
+			if !started {
+				started = true
+				go s.runLive(channel)
+			}
+		case "exec":
+			var payload struct{ Command string }
+			ssh.Unmarshal(req.Payload, &payload)
+			req.Reply(!started, nil)
+			if started {
+				continue
+			}
+			started = true
+			go func() {
+				exitStatus := uint32(0)
+				if command := strings.TrimSpace(payload.Command); command == "status" || command == "" {
+					channel.Write([]byte(s.render(false)))
+				} else {
+					fmt.Fprintf(channel.Stderr(), "unknown command %q, use status\n", command)
+					exitStatus = 1
+				}
+				s.exit(channel, exitStatus)
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// runLive redraws the status until the client presses q, Ctrl-C or Ctrl-D
+func (s *StatusSSH) runLive(channel ssh.Channel) {
+	quit := make(chan struct{})
+	go func() {
+		defer close(quit)
+		buf := make([]byte, 64)
+		for {
+			n, err := channel.Read(buf)
+			if err != nil {
+				return
+			}
+			if bytes.ContainsAny(buf[:n], "qQ\x03\x04") {
+				return
+			}
+		}
+	}()
+
+	channel.Write([]byte("\x1b[?25l")) // Hide the cursor
+	for {
+		screen := "\x1b[H\x1b[2J" + s.render(true) + "\n" + ansiDim + "Press q to quit" + ansiReset
+		if _, err := channel.Write([]byte(strings.ReplaceAll(screen, "\n", "\r\n"))); err != nil {
+			return
+		}
+
+		refresh := time.Duration(s.cfgManager.GetConfig().SSHStatus.Refresh()) * time.Second
+		select {
+		case <-quit:
+			channel.Write([]byte("\x1b[?25h\r\n"))
+			s.exit(channel, 0)
+			return
+		case <-time.After(refresh):
+		}
+	}
+}
+
+// render returns the current fleet status
+func (s *StatusSSH) render(color bool) string {
+	cfg := s.cfgManager.GetConfig()
+	unit := cfg.TemperatureUnit
+	if unit == "" {
+		unit = config.UnitCelsius
+	}
+	fleet, devices := FetchFleetStatus(context.Background(), cfg, unit)
+	return RenderFleetStatus(cfg.Title, unit, fleet, devices, time.Now(), color)
+}
+
+// exit sends the exit status of the session and closes it
+func (s *StatusSSH) exit(channel ssh.Channel, status uint32) {
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+	channel.Close()
+}
+
+// loadSSHHostKey reads the host key, creating an Ed25519 key when the file
+// does not exist
+func loadSSHHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		_, key, genErr := ed25519.GenerateKey(rand.Reader)
+		if genErr != nil {
+			return nil, fmt.Errorf("failed to generate the SSH host key: %w", genErr)
+		}
+		block, genErr := ssh.MarshalPrivateKey(key, "axeos-dashboard")
+		if genErr != nil {
+			return nil, fmt.Errorf("failed to encode the SSH host key: %w", genErr)
+		}
+		data = pem.EncodeToMemory(block)
+		if err := config.WriteSecretFile(path, data); err != nil {
+			return nil, fmt.Errorf("failed to write the SSH host key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the SSH host key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the SSH host key %s: %w", path, err)
+	}
+	return signer, nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scottwalter/axeos-dashboard/internal/config"
+)
+
+const (
+	// stratumTestTimeout bounds a whole stratum test
+	stratumTestTimeout = 15 * time.Second
+	// stratumDifficultyWait is how long to wait for mining.set_difficulty after authorizing
+	stratumDifficultyWait = 3 * time.Second
+	// maxStratumLine limits the size of one stratum message
+	maxStratumLine = 64 << 10
+	// stratumTestWorker fills in pool user placeholders when testing
+	stratumTestWorker = "stratum-test"
+)
+
+// StratumTestResult is the outcome of a stratum connection test. Latencies
+// are in milliseconds; a step that was not reached is null.
+type StratumTestResult struct {
+	Host            string   `json:"host"`
+	Port            int      `json:"port"`
+	TLS             bool     `json:"tls"`
+	User            string   `json:"user"`
+	Connected       bool     `json:"connected"`
+	ConnectMs       *float64 `json:"connectMs"`
+	Subscribed      bool     `json:"subscribed"`
+	SubscribeMs     *float64 `json:"subscribeMs"`
+	ExtraNonce1     string   `json:"extraNonce1,omitempty"`
+	ExtraNonce2Size int      `json:"extraNonce2Size,omitempty"`
+	Authorized      bool     `json:"authorized"`
+	AuthorizeMs     *float64 `json:"authorizeMs"`
+	Difficulty      *float64 `json:"difficulty"`  // From mining.set_difficulty
+	JobReceived     bool     `json:"jobReceived"` // A mining.notify arrived
+	Error           string   `json:"error,omitempty"`
+}
+
+// stratumMessage is a stratum JSON-RPC request, response or notification
+type stratumMessage struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// ParseStratumURL splits a pool URL such as "stratum+tcp://pool.example:3333"
+// into host, port and whether it uses TLS. A port in the URL takes the place
+// of defaultPort.
+func ParseStratumURL(raw string, defaultPort int) (string, int, bool, error) {
+	host := strings.TrimSpace(raw)
+	useTLS := false
+	if scheme, rest, ok := strings.Cut(host, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "stratum+tcp", "tcp", "stratum":
+		case "stratum+ssl", "stratum+tls", "ssl", "tls":
+			useTLS = true
+		default:
+			return "", 0, false, fmt.Errorf("unsupported scheme %q", scheme)
+		}
+		host = rest
+	}
+	host = strings.TrimRight(host, "/")
+
+	port := defaultPort
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", 0, false, fmt.Errorf("invalid port %q", p)
+		}
+		host, port = h, n
+	}
+	if host == "" || strings.ContainsAny(host, "/?#@ ") {
+		return "", 0, false, fmt.Errorf("invalid pool host %q", raw)
+	}
+	if port < 1 || port > 65535 {
+		return "", 0, false, fmt.Errorf("port must be between 1 and 65535")
+	}
+	return host, port, useTLS, nil
+}
+
+// TestStratum connects to a stratum pool, subscribes and authorizes the
+// endpoint's user, and reports the latency of each step and the difficulty
+// the pool sets. Placeholders in the user are filled in with "stratum-test".
+// Failures are reported in the result rather than as an error.
+func TestStratum(ctx context.Context, endpoint config.PoolEndpoint) *StratumTestResult {
+	ctx, cancel := context.WithTimeout(ctx, stratumTestTimeout)
+	defer cancel()
+
+	user := config.ExpandPoolUser(endpoint.User, config.PoolUserDevice{
+		InstanceID: stratumTestWorker,
+		Hostname:   stratumTestWorker,
+		MAC:        "000000000000",
+	})
+	result := &StratumTestResult{User: user}
+
+	host, port, useTLS, err := ParseStratumURL(endpoint.URL, endpoint.Port)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Host, result.Port, result.TLS = host, port, useTLS
+
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	start := time.Now()
+	var conn net.Conn
+	if useTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: host}}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("connect: %v", err)
+		return result
+	}
+	defer conn.Close()
+	result.Connected = true
+	result.ConnectMs = elapsedMs(start)
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	go func() {
+		<-ctx.Done()
+		conn.SetDeadline(time.Now()) // Unblock reads when the request is canceled
+	}()
+
+	reader := bufio.NewReaderSize(conn, 4096)
+	send := func(id int, method string, params ...interface{}) error {
+		data, err := json.Marshal(map[string]interface{}{"id": id, "method": method, "params": params})
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(append(data, '\n'))
+		return err
+	}
+	// await reads messages until the response with an id, noting the
+	// difficulty and jobs the pool announces on the way
+	await := func(id int) (*stratumMessage, error) {
+		for {
+			line, err := readStratumLine(reader)
+			if err != nil {
+				return nil, err
+			}
+			var msg stratumMessage
+			if err := json.Unmarshal(line, &msg); err != nil {
+				return nil, fmt.Errorf("invalid stratum message: %s", truncate(string(line), 120))
+			}
+			result.notice(&msg)
+			if msg.Method == "" && fmt.Sprint(msg.ID) == strconv.Itoa(id) {
+				return &msg, nil
+			}
+		}
+	}
+
+	start = time.Now()
+	if err := send(1, "mining.subscribe", "axeos-dashboard"); err != nil {
+		result.Error = fmt.Sprintf("subscribe: %v", err)
+		return result
+	}
+	msg, err := await(1)
+	if err != nil {
+		result.Error = fmt.Sprintf("subscribe: %v", err)
+		return result
+	}
+	result.SubscribeMs = elapsedMs(start)
+	if rpcErr := stratumError(msg); rpcErr != "" {
+		result.Error = "subscribe: " + rpcErr
+		return result
+	}
+	result.Subscribed = true
+	var subscription []interface{}
+	if json.Unmarshal(msg.Result, &subscription) == nil && len(subscription) >= 3 {
+		result.ExtraNonce1, _ = subscription[1].(string)
+		if size, ok := subscription[2].(float64); ok {
+			result.ExtraNonce2Size = int(size)
+		}
+	}
+
+	start = time.Now()
+	if err := send(2, "mining.authorize", user, endpoint.PasswordOrDefault()); err != nil {
+		result.Error = fmt.Sprintf("authorize: %v", err)
+		return result
+	}
+	msg, err = await(2)
+	if err != nil {
+		result.Error = fmt.Sprintf("authorize: %v", err)
+		return result
+	}
+	result.AuthorizeMs = elapsedMs(start)
+	if rpcErr := stratumError(msg); rpcErr != "" {
+		result.Error = "authorize: " + rpcErr
+		return result
+	}
+	var authorized bool
+	if json.Unmarshal(msg.Result, &authorized) != nil || !authorized {
+		result.Error = "authorize: the pool rejected the user"
+		return result
+	}
+	result.Authorized = true
+
+	// Pools usually send the difficulty and the first job right after authorizing
+	if result.Difficulty == nil || !result.JobReceived {
+		wait := time.Now().Add(stratumDifficultyWait)
+		if wait.Before(deadline) {
+			conn.SetReadDeadline(wait)
+		}
+		for result.Difficulty == nil || !result.JobReceived {
+			line, err := readStratumLine(reader)
+			if err != nil {
+				break
+			}
+			var msg stratumMessage
+			if json.Unmarshal(line, &msg) == nil {
+				result.notice(&msg)
+			}
+		}
+	}
+	return result
+}
+
+// notice records the difficulty and jobs announced by the pool
+func (r *StratumTestResult) notice(msg *stratumMessage) {
+	switch msg.Method {
+	case "mining.set_difficulty":
+		var params []float64
+		if json.Unmarshal(msg.Params, &params) == nil && len(params) > 0 {
+			difficulty := params[0]
+			r.Difficulty = &difficulty
+		}
+	case "mining.notify":
+		r.JobReceived = true
+	}
+}
+
+// readStratumLine reads one newline-terminated message
+func readStratumLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxStratumLine {
+			return nil, fmt.Errorf("stratum message exceeds %d bytes", maxStratumLine)
+		}
+		if !isPrefix {
+			if len(strings.TrimSpace(string(line))) == 0 {
+				continue
+			}
+			return line, nil
+		}
+	}
+}
+
+// stratumError returns the error of a response, or "" when it succeeded
+func stratumError(msg *stratumMessage) string {
+	if len(msg.Error) == 0 || string(msg.Error) == "null" {
+		return ""
+	}
+	// Errors are usually [code, message, traceback]
+	var parts []interface{}
+	if json.Unmarshal(msg.Error, &parts) == nil && len(parts) >= 2 {
+		return fmt.Sprintf("%v (code %v)", parts[1], parts[0])
+	}
+	return string(msg.Error)
+}
+
+// elapsedMs returns the milliseconds since start
+func elapsedMs(start time.Time) *float64 {
+	ms := float64(time.Since(start).Microseconds()) / 1000
+	return &ms
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -13,6 +13,7 @@
  * - JWT key generation with animation
  * - Mining Core configuration toggle
  * - Form submission with validation
+ * - Import of the Node.js dashboard's configuration files
  * - Auto-redirect after successful setup
  * 
  * @author Scott Walter
@@ -30,6 +31,9 @@ document.addEventListener('DOMContentLoaded', function() {
     const cryptoNodeFields = document.getElementById('cryptoNodeFields');
     const generateJWTButton = document.getElementById('generateJWT');
     const addDeviceButton = document.getElementById('addDevice');
+    const scanDevicesButton = document.getElementById('scanDevices');
+    const importLegacyButton = document.getElementById('importLegacy');
+    const legacyFilesInput = document.getElementById('legacyFiles');
     const axeosInstancesContainer = document.getElementById('axeosInstances');
     const addMiningCoreButton = document.getElementById('addMiningCore');
     const miningCoreInstancesContainer = document.getElementById('miningCoreInstances');
@@ -115,6 +119,28 @@ document.addEventListener('DOMContentLoaded', function() {
         addDeviceInstance();
     });
 
+    // Scan network button
+    scanDevicesButton.addEventListener('click', async function() {
+        this.disabled = true;
+        this.textContent = 'Scanning...';
+        try {
+            await scanForDevices();
+        } finally {
+            this.disabled = false;
+            this.textContent = 'Scan Network';
+        }
+    });
+
+    // Import legacy configuration button
+    importLegacyButton.addEventListener('click', async function() {
+        this.disabled = true;
+        try {
+            await importLegacyConfiguration();
+        } finally {
+            this.disabled = false;
+        }
+    });
+
     // Add mining core button
     addMiningCoreButton.addEventListener('click', function() {
         addMiningCoreInstance();
@@ -166,6 +192,50 @@ document.addEventListener('DOMContentLoaded', function() {
         updateRemoveButtonHandlers();
     }
 
+    /**
+     * Scans the local network for AxeOS devices and adds the ones found to the form
+     */
+    async function scanForDevices() {
+        showMessage('Scanning the local network for AxeOS devices...', 'info');
+        try {
+            const response = await fetch('api/discovery/scan', { method: 'POST' });
+            const result = await response.json();
+            if (!response.ok) {
+                showMessage('Scan failed: ' + result.message, 'error');
+                return;
+            }
+
+            const existingUrls = Array.from(document.querySelectorAll('input[name="deviceUrl"]'))
+                .map(input => input.value.replace(/\/+$/, ''));
+            let added = 0;
+            result.miners.forEach(miner => {
+                if (existingUrls.includes(miner.url)) {
+                    return;
+                }
+                // Fill the first empty row before adding new ones
+                let row = Array.from(document.querySelectorAll('.device-instance')).find(instance =>
+                    !instance.querySelector('input[name="deviceName"]').value &&
+                    !instance.querySelector('input[name="deviceUrl"]').value);
+                if (!row) {
+                    addDeviceInstance();
+                    row = axeosInstancesContainer.lastElementChild;
+                }
+                row.querySelector('input[name="deviceName"]').value = miner.suggestedName;
+                row.querySelector('input[name="deviceUrl"]').value = miner.url;
+                added++;
+            });
+
+            if (result.miners.length === 0) {
+                showMessage('No AxeOS devices found on ' + result.subnets.join(', ') + '.', 'error');
+            } else {
+                showMessage(`Found ${result.miners.length} AxeOS device(s), added ${added}.`, 'success');
+            }
+        } catch (error) {
+            console.error('Network scan failed:', error);
+            showMessage('An error occurred while scanning the network. Please try again.', 'error');
+        }
+    }
+
     /**
      * Updates event handlers for remove device buttons
      */
@@ -336,7 +406,7 @@ document.addEventListener('DOMContentLoaded', function() {
                 return;
             }
 
-            const response = await fetch('/bootstrap', {
+            const response = await fetch('bootstrap', {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json',
@@ -352,7 +422,7 @@ document.addEventListener('DOMContentLoaded', function() {
                 
                 // Wait 2 seconds then redirect to allow the server to switch modes
                 setTimeout(() => {
-                    window.location.href = '/';
+                    window.location.href = './';
                 }, 2000);
             } else {
                 showMessage('Validation failed: ' + result.message, 'error');
@@ -364,6 +434,60 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     }
 
+    /**
+     * Uploads the selected configuration files of the Node.js dashboard, which
+     * the server converts instead of the form
+     */
+    async function importLegacyConfiguration() {
+        const selected = Array.from(legacyFilesInput.files);
+        if (!selected.some(file => file.name === 'config.json')) {
+            showMessage('Select at least the config.json of the legacy dashboard.', 'error');
+            return;
+        }
+
+        try {
+            const files = {};
+            for (const file of selected) {
+                try {
+                    files[file.name] = JSON.parse(await file.text());
+                } catch (parseError) {
+                    showMessage(`${file.name} is not valid JSON.`, 'error');
+                    return;
+                }
+            }
+
+            showMessage('Importing the legacy configuration...', 'info');
+            const response = await fetch('bootstrap/import', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ files })
+            });
+
+            const result = await response.json();
+
+            if (result.success) {
+                let message = 'Configuration imported successfully! Redirecting to dashboard...';
+                if (result.data && result.data.dropped && result.data.dropped.length > 0) {
+                    message += ' Settings no longer used: ' + result.data.dropped.join(', ') + '.';
+                }
+                showMessage(message, 'success');
+                form.style.display = 'none';
+
+                // Wait 2 seconds then redirect to allow the server to switch modes
+                setTimeout(() => {
+                    window.location.href = './';
+                }, 2000);
+            } else {
+                showMessage('Import failed: ' + result.message, 'error');
+            }
+        } catch (error) {
+            console.error('Legacy import error:', error);
+            showMessage('An error occurred while importing the configuration. Please try again.', 'error');
+        }
+    }
+
     /**
      * Shows a message to the user
      * @param {string} message - The message to display
@@ -120,7 +120,7 @@ document.addEventListener('DOMContentLoaded', () => {
     showSkeletonLoaders(3);
 
     // --- Retrieve and Parse Data via Fetch ---
-    fetch('/api/systems/info')
+    fetch('api/systems/info')
         .then(response => {
             if (!response.ok) {
                 // If the response is not OK (e.g., 404, 500), throw an error.
@@ -202,7 +202,7 @@ document.addEventListener('DOMContentLoaded', () => {
                         `Are you sure you want to restart instance "${instanceId}"?`,
                         async () => {
                             try {
-                                const response = await fetch(`/api/instance/service/restart?instanceId=${instanceId}`, {
+                                const response = await fetch(`api/instance/service/restart?instanceId=${instanceId}`, {
                                     method: 'POST'
                                 });
                                 const result = await response.json();
@@ -763,11 +763,11 @@ document.addEventListener('DOMContentLoaded', () => {
                         allPoolsHtml += `<h4><span class="status-indicator status-online" style="margin-right: 8px;"></span>${miner.id} <div class="line-graph-icon chart-button" data-instance-id="${miner.id}" title="View ${miner.id} Statistics"></div>`;
                         // Add restart and settings icons if settings are enabled
                         if(!disableSettings){
-                            allPoolsHtml += ` <img src="/public/icon/icons8-rotate-right-64-white.png" class="restart-button restart-icon-hover" data-instance-id="${miner.id}" title="Restart Instance" style="width: 20px; height: 20px; margin-left: 8px; vertical-align: middle; cursor: pointer;">`;
-                            allPoolsHtml += ` <img src="/public/icon/icons8-audio-65-white.png" class="settings-button settings-icon-hover" data-instance-id="${miner.id}" title="Edit Settings" style="width: 20px; height: 20px; margin-left: 8px; vertical-align: middle; cursor: pointer;">`;
+                            allPoolsHtml += ` <img src="public/icon/icons8-rotate-right-64-white.png" class="restart-button restart-icon-hover" data-instance-id="${miner.id}" title="Restart Instance" style="width: 20px; height: 20px; margin-left: 8px; vertical-align: middle; cursor: pointer;">`;
+                            allPoolsHtml += ` <img src="public/icon/icons8-audio-65-white.png" class="settings-button settings-icon-hover" data-instance-id="${miner.id}" title="Edit Settings" style="width: 20px; height: 20px; margin-left: 8px; vertical-align: middle; cursor: pointer;">`;
                         }
                         // Add information icon (always visible)
-                        allPoolsHtml += ` <img src="/public/icon/icons8-information-64-white.png" class="info-button info-icon-hover" data-instance-id="${miner.id}" title="View Detailed Information" style="width: 20px; height: 20px; margin-left: 8px; vertical-align: middle; cursor: pointer;">`;
+                        allPoolsHtml += ` <img src="public/icon/icons8-information-64-white.png" class="info-button info-icon-hover" data-instance-id="${miner.id}" title="View Detailed Information" style="width: 20px; height: 20px; margin-left: 8px; vertical-align: middle; cursor: pointer;">`;
                         allPoolsHtml += `</h4><div class="details-grid-five-columns">`;
 
                         if (isCompactView) {
@@ -814,8 +814,8 @@ document.addEventListener('DOMContentLoaded', () => {
         allPoolsHtml += `</div>`; // Close collapsible-content
         allPoolsHtml += `</div>`; // Close individual miner status card
 
-        // Only show pool data if mining core is enabled
-        if (miningCoreEnabled) {
+        // Only show pool data if mining core is enabled or public pools are configured
+        if (miningCoreEnabled || (data && data.some(instance => instance.type))) {
             // Check if mining core data is available
             if (data && data.length > 0) {
                 // Create single Mining Pool Status wrapper section
@@ -833,7 +833,7 @@ document.addEventListener('DOMContentLoaded', () => {
                     if (instanceStatus === 'Error') {
                         // Show error state for this instance as a card
                         allPoolsHtml += `<div class="pool-card">`;
-                        allPoolsHtml += `<h4><span class="status-indicator status-error" style="margin-right: 8px;"></span>${instanceName}: <span style="color: #dc3545; font-weight: bold;">Mining Core Unreachable</span></h4>`;
+                        allPoolsHtml += `<h4><span class="status-indicator status-error" style="margin-right: 8px;"></span>${instanceName}: <span style="color: #dc3545; font-weight: bold;">${miningCoreInstance.type ? 'Pool' : 'Mining Core'} Unreachable</span></h4>`;
                         allPoolsHtml += `<div class="details-grid">`;
                         allPoolsHtml += `<strong>Message:</strong> <span>${miningCoreInstance.message || 'Could not connect to mining core'}</span>`;
                         allPoolsHtml += `<strong>Note:</strong> <span>Mining core data is not available, but individual miners are still monitored.</span>`;
@@ -1149,6 +1149,11 @@ document.addEventListener('DOMContentLoaded', () => {
             return nodeData.networkTotals.uploadtarget[fieldKey];
         }
 
+        // Check mempool (nodes with NodeMempool set)
+        if (nodeData.mempool && fieldKey in nodeData.mempool) {
+            return nodeData.mempool[fieldKey];
+        }
+
         // Check balance (direct value)
         if (fieldKey === 'balance' && nodeData.balance !== undefined) {
             return nodeData.balance;
@@ -1316,13 +1321,13 @@ document.addEventListener('DOMContentLoaded', () => {
 
                     logoutButton.addEventListener('click', async () => {
                         try {
-                            const response = await fetch('/api/logout', {
+                            const response = await fetch('api/logout', {
                                 method: 'POST'
                             });
                             if (response.ok) {
                                 // On successful logout, the server clears the session cookie.
                                 // Redirect the user to the login page.
-                                window.location.href = '/login';
+                                window.location.href = 'login';
                             } else {
                                 const result = await response.json();
                                 alert(`Logout failed: ${result.message || 'Unknown error'}`);
@@ -1387,7 +1392,7 @@ document.addEventListener('DOMContentLoaded', () => {
      */
     async function checkConfigurationMigration() {
         try {
-            const response = await fetch('/api/migration/status');
+            const response = await fetch('api/migration/status');
             const result = await response.json();
 
             if (result.success && result.data && result.data.migrated) {
@@ -1440,7 +1445,7 @@ document.addEventListener('DOMContentLoaded', () => {
         const closeModal = async () => {
             // Clear the migration status on the backend
             try {
-                await fetch('/api/migration/clear', { method: 'POST' });
+                await fetch('api/migration/clear', { method: 'POST' });
             } catch (error) {
                 console.error('Error clearing migration status:', error);
             }
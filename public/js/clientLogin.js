@@ -49,7 +49,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 const hashedPassword = await hashPasswordSHA256(password);
 
                 // Send the login request to the API endpoint.
-                const response = await fetch('/api/login', {
+                const response = await fetch('api/login', {
                     method: 'POST',
                     headers: {
                         'Content-Type': 'application/json',
@@ -60,7 +60,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 if (response.ok) {
                     // On successful login (HTTP 200-299), the server sets a session cookie.
                     // Redirect the user to the main dashboard page.
-                    window.location.href = '/';
+                    window.location.href = './';
                 } else {
                     // If the server returns an error (e.g., 401 Unauthorized), display the error message.
                     const result = await response.json();
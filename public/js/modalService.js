@@ -498,7 +498,7 @@ const modalService = (() => {
         });
 
         try {
-            const response = await fetch(`/api/instance/service/settings?instanceId=${instanceId}`, {
+            const response = await fetch(`api/instance/service/settings?instanceId=${instanceId}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(payload)
@@ -579,7 +579,7 @@ const modalService = (() => {
 
         
         try {
-            const response = await fetch('/api/configuration', {
+            const response = await fetch('api/configuration', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(payload)
@@ -607,7 +607,7 @@ const modalService = (() => {
 
         try {
             // Fetch current configuration
-            const response = await fetch('/api/configuration');
+            const response = await fetch('api/configuration');
             const result = await response.json();
             
             if (!response.ok) {
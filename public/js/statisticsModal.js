@@ -345,7 +345,7 @@ const statisticsModal = (() => {
      */
     async function fetchStatisticsData() {
         try {
-            const response = await fetch(`/api/statistics?instanceId=${encodeURIComponent(currentInstanceId)}`);
+            const response = await fetch(`api/statistics?instanceId=${encodeURIComponent(currentInstanceId)}`);
             
             if (!response.ok) {
                 const errorData = await response.json().catch(() => ({ message: 'Unknown error' }));